@@ -1,6 +1,269 @@
 package api
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	"github.com/Keisn1/note-taking-app/foundation/markdown"
+	"github.com/Keisn1/note-taking-app/foundation/validate"
+	"github.com/google/uuid"
+)
+
+// NotePost creates or replaces a note. An empty format is plain for new notes
+// and keeps the current format on updates.
 type NotePost struct {
 	Title   string `json:"title"`
 	Content string `json:"content"`
+	Format  string `json:"format,omitempty"`
+}
+
+func (np NotePost) Validate() error {
+	var v validate.Validator
+	v.Required("title", np.Title)
+	v.Text("title", np.Title)
+	v.MaxLength("title", np.Title, note.MaxTitleLength)
+	v.Text("content", np.Content)
+	v.MaxBytes("content", np.Content, note.MaxContentLength)
+	if np.Format != "" {
+		_, err := note.ParseFormat(np.Format)
+		v.Check(err == nil, "format", formatMsg)
+	}
+	return v.Err()
+}
+
+const formatMsg = "must be plain or markdown"
+
+// NotePatch changes some fields of a note. Absent fields are kept, a null
+// content clears the content. Title and format can't be null.
+type NotePatch struct {
+	Title   note.Title
+	Content note.Content
+	Format  note.Format
+	patch
+}
+
+func (np *NotePatch) UnmarshalJSON(data []byte) error {
+	return np.decode(data, map[string]any{"title": &np.Title, "content": &np.Content, "format": &np.Format})
+}
+
+func (np NotePatch) Validate() error {
+	var v validate.Validator
+	v.Check(!np.isNull("title"), "title", "must not be null")
+	v.Check(!np.isInvalid("title"), "title", "must be a string")
+	v.Check(!np.isInvalid("content"), "content", "must be a string")
+	v.Check(!np.isNull("format"), "format", "must not be null")
+	v.Check(!np.isInvalid("format"), "format", formatMsg)
+	if !np.Title.IsEmpty() {
+		v.Required("title", np.Title.String())
+		v.Text("title", np.Title.String())
+		v.MaxLength("title", np.Title.String(), note.MaxTitleLength)
+	}
+	if !np.Content.IsEmpty() {
+		v.Text("content", np.Content.String())
+		v.MaxBytes("content", np.Content.String(), note.MaxContentLength)
+	}
+	return v.Err()
+}
+
+// MarshalJSON encodes only the fields that are set.
+func (np NotePatch) MarshalJSON() ([]byte, error) {
+	m := map[string]any{}
+	if !np.Title.IsEmpty() {
+		m["title"] = np.Title
+	}
+	if np.isNull("content") {
+		m["content"] = nil
+	} else if !np.Content.IsEmpty() {
+		m["content"] = np.Content
+	}
+	if !np.Format.IsEmpty() {
+		m["format"] = np.Format
+	}
+	return json.Marshal(m)
+}
+
+// UpdateNote returns the changes for note.Service.Update.
+func (np NotePatch) UpdateNote(userID uuid.UUID) note.UpdateNote {
+	un := note.UpdateNote{Title: np.Title, Content: np.Content, Format: np.Format, UserID: userID}
+	if np.isNull("content") {
+		un.Content = note.NewContent("")
+	}
+	return un
+}
+
+type NoteResponse struct {
+	ID      uuid.UUID `json:"id"`
+	Title   string    `json:"title"`
+	Content string    `json:"content"`
+	Format  string    `json:"format"`
+	UserID  uuid.UUID `json:"user_id"`
+}
+
+func ToNoteResponse(n note.Note) NoteResponse {
+	return NoteResponse{
+		ID:      n.ID,
+		Title:   n.Title.String(),
+		Content: n.Content.String(),
+		Format:  n.Format.String(),
+		UserID:  n.UserID,
+	}
+}
+
+// RenderedNoteResponse is a note together with its content rendered to
+// sanitised HTML.
+type RenderedNoteResponse struct {
+	NoteResponse
+	HTML string             `json:"html"`
+	TOC  []markdown.Heading `json:"toc"`
+}
+
+func ToRenderedNoteResponse(n note.Note, doc markdown.Document) RenderedNoteResponse {
+	toc := doc.TOC
+	if toc == nil {
+		toc = []markdown.Heading{}
+	}
+	return RenderedNoteResponse{NoteResponse: ToNoteResponse(n), HTML: doc.HTML, TOC: toc}
+}
+
+// NoteETag identifies the state of a note. Clients send it back in If-Match
+// to detect that the note changed since they read it.
+func NoteETag(n note.Note) string {
+	h := sha256.New()
+	h.Write([]byte(n.Title.String()))
+	h.Write([]byte{0})
+	h.Write([]byte(n.Content.String()))
+	h.Write([]byte{0})
+	h.Write([]byte(n.Format.String()))
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+type UserPost struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+func (up UserPost) Validate() error {
+	var v validate.Validator
+	v.Required("name", up.Name)
+	v.Text("name", up.Name)
+	v.MaxLength("name", up.Name, user.MaxNameLength)
+	v.Required("email", up.Email)
+	v.Email("email", up.Email)
+	v.Text("password", up.Password)
+	v.MinBytes("password", up.Password, user.MinPasswordLength)
+	v.MaxBytes("password", up.Password, user.MaxPasswordLength)
+	return v.Err()
+}
+
+// UserPut replaces name and email of a user. An empty password keeps the
+// current one.
+type UserPut struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password,omitempty"`
+}
+
+func (up UserPut) Validate() error {
+	var v validate.Validator
+	v.Required("name", up.Name)
+	v.Text("name", up.Name)
+	v.MaxLength("name", up.Name, user.MaxNameLength)
+	v.Required("email", up.Email)
+	v.Email("email", up.Email)
+	if up.Password != "" {
+		v.Text("password", up.Password)
+		v.MinBytes("password", up.Password, user.MinPasswordLength)
+		v.MaxBytes("password", up.Password, user.MaxPasswordLength)
+	}
+	return v.Err()
+}
+
+// UserPatch changes some fields of a user. Absent fields are kept, none of
+// them can be null.
+type UserPatch struct {
+	Name     user.Name
+	Email    user.Email
+	Password user.Password
+	patch
+}
+
+func (up *UserPatch) UnmarshalJSON(data []byte) error {
+	return up.decode(data, map[string]any{"name": &up.Name, "email": &up.Email, "password": &up.Password})
+}
+
+func (up UserPatch) Validate() error {
+	var v validate.Validator
+	for _, field := range []string{"name", "email", "password"} {
+		v.Check(!up.isNull(field), field, "must not be null")
+	}
+	v.Check(!up.isInvalid("name"), "name", "must be a string")
+	v.Check(!up.isInvalid("email"), "email", "must be a valid email address")
+	v.Check(!up.isInvalid("password"), "password", "must be a string")
+	if !up.Name.IsEmpty() {
+		v.Required("name", up.Name.String())
+		v.Text("name", up.Name.String())
+		v.MaxLength("name", up.Name.String(), user.MaxNameLength)
+	}
+	if !up.Password.IsEmpty() {
+		v.Text("password", up.Password.String())
+		v.MinBytes("password", up.Password.String(), user.MinPasswordLength)
+		v.MaxBytes("password", up.Password.String(), user.MaxPasswordLength)
+	}
+	return v.Err()
+}
+
+// MarshalJSON encodes only the fields that are set.
+func (up UserPatch) MarshalJSON() ([]byte, error) {
+	m := map[string]any{}
+	if !up.Name.IsEmpty() {
+		m["name"] = up.Name
+	}
+	if !up.Email.IsEmpty() {
+		m["email"] = up.Email
+	}
+	if !up.Password.IsEmpty() {
+		m["password"] = up.Password.String()
+	}
+	return json.Marshal(m)
+}
+
+// UpdateUser returns the changes for user.Service.Update.
+func (up UserPatch) UpdateUser() user.UpdateUser {
+	uu := user.UpdateUser{Name: up.Name, Password: up.Password}
+	if !up.Email.IsEmpty() {
+		uu.Email = user.NewEmail(up.Email.String().Address)
+	}
+	return uu
+}
+
+type UserResponse struct {
+	ID    uuid.UUID `json:"id"`
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+}
+
+func ToUserResponse(u user.User) UserResponse {
+	return UserResponse{ID: u.ID, Name: u.Name.String(), Email: u.Email.String().Address}
+}
+
+type LoginPost struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+func (lp LoginPost) Validate() error {
+	var v validate.Validator
+	v.Required("email", lp.Email)
+	v.Required("password", lp.Password)
+	return v.Err()
+}
+
+type TokenResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
@@ -0,0 +1,234 @@
+package api_test
+
+import (
+	"encoding/json"
+	"math"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/Keisn1/note-taking-app/app/api"
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/Keisn1/note-taking-app/foundation/validate"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotePost_Validate(t *testing.T) {
+	testCases := []struct {
+		name string
+		np   api.NotePost
+		want validate.FieldErrors
+	}{
+		{
+			name: "valid note",
+			np:   api.NotePost{Title: "title", Content: "content"},
+		},
+		{
+			name: "empty content is allowed",
+			np:   api.NotePost{Title: "title"},
+		},
+		{
+			name: "missing title",
+			np:   api.NotePost{Title: "  ", Content: "content"},
+			want: validate.FieldErrors{{Field: "title", Error: "is required"}},
+		},
+		{
+			name: "title too long",
+			np:   api.NotePost{Title: strings.Repeat("ä", 201)},
+			want: validate.FieldErrors{{Field: "title", Error: "must be at most 200 characters"}},
+		},
+		{
+			name: "content too long",
+			np:   api.NotePost{Title: "title", Content: strings.Repeat("a", 1<<20+1)},
+			want: validate.FieldErrors{{Field: "content", Error: "must be at most 1048576 bytes"}},
+		},
+		{
+			name: "invalid text in title and content",
+			np:   api.NotePost{Title: "ti\x00tle", Content: "\xff"},
+			want: validate.FieldErrors{
+				{Field: "title", Error: "must be valid UTF-8 text"},
+				{Field: "content", Error: "must be valid UTF-8 text"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.np.Validate()
+			if tc.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, tc.want, err)
+		})
+	}
+}
+
+func TestUserPost_Validate(t *testing.T) {
+	testCases := []struct {
+		name string
+		up   api.UserPost
+		want validate.FieldErrors
+	}{
+		{
+			name: "valid user",
+			up:   api.UserPost{Name: "rob", Email: "rob@example.com", Password: "password"},
+		},
+		{
+			name: "email with display name",
+			up:   api.UserPost{Name: "rob", Email: "Rob <rob@example.com>", Password: "password"},
+		},
+		{
+			name: "missing fields",
+			up:   api.UserPost{},
+			want: validate.FieldErrors{
+				{Field: "name", Error: "is required"},
+				{Field: "email", Error: "is required"},
+				{Field: "password", Error: "must be at least 8 bytes"},
+			},
+		},
+		{
+			name: "invalid email",
+			up:   api.UserPost{Name: "rob", Email: "rob@", Password: "password"},
+			want: validate.FieldErrors{{Field: "email", Error: "must be a valid email address"}},
+		},
+		{
+			name: "password too long",
+			up:   api.UserPost{Name: "rob", Email: "rob@example.com", Password: strings.Repeat("a", 73)},
+			want: validate.FieldErrors{{Field: "password", Error: "must be at most 72 bytes"}},
+		},
+		{
+			name: "password length counts bytes",
+			up:   api.UserPost{Name: "rob", Email: "rob@example.com", Password: "äöü"},
+			want: validate.FieldErrors{{Field: "password", Error: "must be at least 8 bytes"}},
+		},
+		{
+			name: "multibyte password",
+			up:   api.UserPost{Name: "rob", Email: "rob@example.com", Password: "äöüß"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.up.Validate()
+			if tc.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, tc.want, err)
+		})
+	}
+}
+
+func TestNotePatch(t *testing.T) {
+	testCases := []struct {
+		name        string
+		body        string
+		wantTitle   *string
+		wantContent *string
+		wantErr     validate.FieldErrors
+	}{
+		{name: "absent fields are kept", body: `{}`},
+		{name: "title only", body: `{"title": "title"}`, wantTitle: ptr("title")},
+		{name: "empty content", body: `{"content": ""}`, wantContent: ptr("")},
+		{name: "null content clears it", body: `{"content": null}`, wantContent: ptr("")},
+		{
+			name:    "null title",
+			body:    `{"title": null}`,
+			wantErr: validate.FieldErrors{{Field: "title", Error: "must not be null"}},
+		},
+		{
+			name:    "empty title",
+			body:    `{"title": " "}`,
+			wantErr: validate.FieldErrors{{Field: "title", Error: "is required"}},
+		},
+		{
+			name:    "null format",
+			body:    `{"format": null}`,
+			wantErr: validate.FieldErrors{{Field: "format", Error: "must not be null"}},
+		},
+		{
+			name:    "unknown format",
+			body:    `{"format": "html"}`,
+			wantErr: validate.FieldErrors{{Field: "format", Error: "must be plain or markdown"}},
+		},
+		{
+			name:    "wrong types",
+			body:    `{"title": 1, "content": []}`,
+			wantErr: validate.FieldErrors{{Field: "title", Error: "must be a string"}, {Field: "content", Error: "must be a string"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var np api.NotePatch
+			require.NoError(t, json.Unmarshal([]byte(tc.body), &np))
+
+			err := np.Validate()
+			if tc.wantErr != nil {
+				assert.Equal(t, tc.wantErr, err)
+				return
+			}
+			require.NoError(t, err)
+
+			un := np.UpdateNote(uuid.UUID{1})
+			assert.Equal(t, tc.wantTitle == nil, un.Title.IsEmpty())
+			if tc.wantTitle != nil {
+				assert.Equal(t, *tc.wantTitle, un.Title.String())
+			}
+			assert.Equal(t, tc.wantContent == nil, un.Content.IsEmpty())
+			if tc.wantContent != nil {
+				assert.Equal(t, *tc.wantContent, un.Content.String())
+			}
+		})
+	}
+
+	t.Run("Unknown fields are rejected", func(t *testing.T) {
+		var np api.NotePatch
+		assert.EqualError(t, json.Unmarshal([]byte(`{"user_id": "x"}`), &np), `json: unknown field "user_id"`)
+		assert.Error(t, json.Unmarshal([]byte(`null`), &np))
+	})
+
+	t.Run("Only set fields are encoded", func(t *testing.T) {
+		data, err := json.Marshal(api.NotePatch{Content: note.NewContent(""), Format: note.FormatMarkdown})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"content": "", "format": "markdown"}`, string(data))
+	})
+}
+
+func TestUserPatch(t *testing.T) {
+	var up api.UserPatch
+	require.NoError(t, json.Unmarshal([]byte(`{"email": "Rob <rob@example.com>"}`), &up))
+	require.NoError(t, up.Validate())
+
+	uu := up.UpdateUser()
+	assert.True(t, uu.Name.IsEmpty())
+	assert.True(t, uu.Password.IsEmpty())
+	assert.Equal(t, mail.Address{Address: "rob@example.com"}, uu.Email.String())
+
+	data, err := json.Marshal(up)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"email": "rob@example.com"}`, string(data))
+}
+
+func TestNewListResponse(t *testing.T) {
+	lr := api.NewListResponse[int](nil, api.Page{Number: 4, PerPage: 2}, 5)
+	assert.Equal(t, []int{}, lr.Items)
+	assert.False(t, lr.HasMore())
+
+	assert.True(t, api.NewListResponse([]int{3, 4}, api.Page{Number: 2, PerPage: 2}, 5).HasMore())
+	assert.False(t, api.NewListResponse([]int{5}, api.Page{Number: 3, PerPage: 2}, 5).HasMore())
+}
+
+func TestPage_Offset(t *testing.T) {
+	offset, ok := api.Page{Number: 3, PerPage: 20}.Offset()
+	assert.True(t, ok)
+	assert.Equal(t, 40, offset)
+
+	_, ok = api.Page{Number: math.MaxInt, PerPage: api.MaxPerPage}.Offset()
+	assert.False(t, ok)
+}
+
+func ptr(s string) *string { return &s }
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"time"
+
+	"github.com/Keisn1/note-taking-app/domain/core/attachment"
+	"github.com/google/uuid"
+)
+
+type AttachmentResponse struct {
+	ID          uuid.UUID `json:"id"`
+	NoteID      uuid.UUID `json:"note_id"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func ToAttachmentResponse(a attachment.Attachment) AttachmentResponse {
+	return AttachmentResponse{
+		ID:          a.ID,
+		NoteID:      a.NoteID,
+		Filename:    a.Filename,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		CreatedAt:   a.CreatedAt,
+	}
+}
+
+// ToAttachmentResponses converts the attachments. The result is never nil,
+// so that no attachments are encoded as an empty list.
+func ToAttachmentResponses(as []attachment.Attachment) []AttachmentResponse {
+	ret := make([]AttachmentResponse, 0, len(as))
+	for _, a := range as {
+		ret = append(ret, ToAttachmentResponse(a))
+	}
+	return ret
+}
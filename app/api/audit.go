@@ -0,0 +1,111 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/domain/core/audit"
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultAuditLimit = 100
+	MaxAuditLimit     = 500
+)
+
+// ParseAuditFilter reads the query parameters actor, action, target, from
+// and to, which select all entries if absent, before, which pages back, and
+// limit, which defaults to DefaultAuditLimit. from and to are RFC 3339
+// timestamps.
+func ParseAuditFilter(r *http.Request) (audit.Filter, error) {
+	q := r.URL.Query()
+	f := audit.Filter{Action: q.Get("action"), Target: q.Get("target"), Limit: DefaultAuditLimit}
+
+	if s := q.Get("actor"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return audit.Filter{}, errors.New("actor must be a user ID")
+		}
+		f.Actor = id
+	}
+
+	for _, p := range []struct {
+		name string
+		t    *time.Time
+	}{{"from", &f.From}, {"to", &f.To}} {
+		if s := q.Get(p.name); s != "" {
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return audit.Filter{}, errors.New(p.name + " must be an RFC 3339 timestamp")
+			}
+			*p.t = t
+		}
+	}
+
+	if s := q.Get("before"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || n < 1 {
+			return audit.Filter{}, errors.New("before must be a positive integer")
+		}
+		f.Before = n
+	}
+
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > MaxAuditLimit {
+			return audit.Filter{}, errors.New("limit must be an integer between 1 and " + strconv.Itoa(MaxAuditLimit))
+		}
+		f.Limit = n
+	}
+
+	return f, nil
+}
+
+// AuditEntryResponse is an entry of the audit trail. Actor is null for
+// anonymous actions.
+type AuditEntryResponse struct {
+	Seq       int64      `json:"seq"`
+	ID        uuid.UUID  `json:"id"`
+	Actor     *uuid.UUID `json:"actor"`
+	Action    string     `json:"action"`
+	Target    string     `json:"target"`
+	IP        string     `json:"ip"`
+	UserAgent string     `json:"user_agent"`
+	RequestID string     `json:"request_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	PrevHash  string     `json:"prev_hash"`
+	Hash      string     `json:"hash"`
+}
+
+// AuditResponse is a page of the audit trail, newest first. Clients pass
+// Next as before to get the following entries.
+type AuditResponse struct {
+	Entries []AuditEntryResponse `json:"entries"`
+	Next    int64                `json:"next"`
+	HasMore bool                 `json:"has_more"`
+}
+
+func ToAuditResponse(p audit.Page) AuditResponse {
+	ar := AuditResponse{Entries: make([]AuditEntryResponse, 0, len(p.Entries)), Next: p.Next, HasMore: p.HasMore}
+	for _, e := range p.Entries {
+		er := AuditEntryResponse{
+			Seq:       e.Seq,
+			ID:        e.ID,
+			Action:    e.Action,
+			Target:    e.Target,
+			IP:        e.IP,
+			UserAgent: e.UserAgent,
+			RequestID: e.RequestID,
+			CreatedAt: e.CreatedAt,
+			PrevHash:  e.PrevHash,
+			Hash:      e.Hash,
+		}
+		if e.Actor != uuid.Nil {
+			er.Actor = &e.Actor
+		}
+		ar.Entries = append(ar.Entries, er)
+	}
+	return ar
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"github.com/Keisn1/note-taking-app/domain/core/collab"
+	"github.com/Keisn1/note-taking-app/foundation/crdt"
+	"github.com/google/uuid"
+)
+
+// Messages the client sends besides ops and cursor, and the server's answer
+// to a ping.
+const (
+	CollabPing = "ping"
+	CollabPong = "pong"
+)
+
+// CollabMessage is a message of the collaborative editing protocol. Clients
+// send ops, cursor and ping messages; the server sends the types of
+// collab.Update and pong. Empty fields are left out.
+type CollabMessage struct {
+	Type   string         `json:"type"`
+	Site   string         `json:"site,omitempty"`
+	UserID *uuid.UUID     `json:"user_id,omitempty"`
+	Clock  uint64         `json:"clock,omitempty"`
+	Doc    []crdt.Element `json:"doc,omitempty"`
+	Peers  []CollabPeer   `json:"peers,omitempty"`
+	Ops    []crdt.Op      `json:"ops,omitempty"`
+	Cursor *CollabCursor  `json:"cursor,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// CollabCursor is a selection, its ends follow the elements Anchor and
+// Head, null for the start of the text.
+type CollabCursor struct {
+	Anchor *crdt.ID `json:"anchor"`
+	Head   *crdt.ID `json:"head"`
+}
+
+type CollabPeer struct {
+	Site   string        `json:"site"`
+	UserID uuid.UUID     `json:"user_id"`
+	Cursor *CollabCursor `json:"cursor"`
+}
+
+// ToCollabMessage converts an update. Errors are replaced by a generic
+// message, they are for the logs.
+func ToCollabMessage(u collab.Update) CollabMessage {
+	m := CollabMessage{
+		Type:   u.Type,
+		Site:   u.Site,
+		Clock:  u.Clock,
+		Doc:    u.Doc,
+		Ops:    u.Ops,
+		Cursor: toCollabCursor(u.Cursor),
+	}
+	if u.UserID != uuid.Nil {
+		id := u.UserID
+		m.UserID = &id
+	}
+	for _, p := range u.Peers {
+		m.Peers = append(m.Peers, CollabPeer{Site: p.Site, UserID: p.UserID, Cursor: toCollabCursor(p.Cursor)})
+	}
+	if u.Type == collab.UpdateError {
+		m.Error = "saving the note failed, it is retried"
+	}
+	return m
+}
+
+// ToCursor converts the cursor of the message.
+func (cc CollabCursor) ToCursor() collab.Cursor {
+	var cur collab.Cursor
+	if cc.Anchor != nil {
+		cur.Anchor = *cc.Anchor
+	}
+	if cc.Head != nil {
+		cur.Head = *cc.Head
+	}
+	return cur
+}
+
+func toCollabCursor(cur *collab.Cursor) *CollabCursor {
+	if cur == nil {
+		return nil
+	}
+	var cc CollabCursor
+	if !cur.Anchor.IsZero() {
+		a := cur.Anchor
+		cc.Anchor = &a
+	}
+	if !cur.Head.IsZero() {
+		h := cur.Head
+		cc.Head = &h
+	}
+	return &cc
+}
@@ -0,0 +1,32 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Keisn1/note-taking-app/foundation/validate"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+)
+
+type ErrorResponse struct {
+	Error  string               `json:"error"`
+	Fields validate.FieldErrors `json:"fields,omitempty"`
+}
+
+// RespondDecodeError answers a request whose body failed web.Decode. Field
+// errors are reported with 422, oversized bodies with 413 and everything else
+// with 400.
+func RespondDecodeError(w http.ResponseWriter, r *http.Request, err error) {
+	var fieldErrs validate.FieldErrors
+	var maxBytesErr *http.MaxBytesError
+
+	switch {
+	case errors.As(err, &fieldErrs):
+		web.Respond(w, http.StatusUnprocessableEntity, ErrorResponse{Error: "validation failed", Fields: fieldErrs})
+	case errors.As(err, &maxBytesErr):
+		web.Respond(w, http.StatusRequestEntityTooLarge, ErrorResponse{Error: "request body too large"})
+	default:
+		web.Respond(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+	web.Logger(r.Context()).Info("invalid request body", "error", err)
+}
@@ -0,0 +1,22 @@
+package api
+
+import (
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/google/uuid"
+)
+
+// NoteEventResponse is the data of a note event. Note is null when the note
+// was deleted.
+type NoteEventResponse struct {
+	NoteID uuid.UUID     `json:"note_id"`
+	Note   *NoteResponse `json:"note"`
+}
+
+func ToNoteEventResponse(e note.Event) NoteEventResponse {
+	er := NoteEventResponse{NoteID: e.Note.ID}
+	if e.Type != note.EventDeleted {
+		nr := ToNoteResponse(e.Note)
+		er.Note = &nr
+	}
+	return er
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"github.com/Keisn1/note-taking-app/domain/core/link"
+	"github.com/google/uuid"
+)
+
+// MaxGraphDepth is the largest depth of a graph around a root note.
+const MaxGraphDepth = 10
+
+// EdgeLink is the kind of the edges between a note and the notes it links
+// to, the only kind so far.
+const EdgeLink = "link"
+
+// GraphResponse is the graph of the notes of a user, with the nodes and
+// edges in the shape D3 force layouts take.
+type GraphResponse struct {
+	Nodes   []GraphNode  `json:"nodes"`
+	Edges   []GraphEdge  `json:"edges"`
+	Metrics GraphMetrics `json:"metrics"`
+}
+
+type GraphNode struct {
+	ID       uuid.UUID `json:"id"`
+	Title    string    `json:"title"`
+	LinksIn  int       `json:"links_in"`
+	LinksOut int       `json:"links_out"`
+	Orphan   bool      `json:"orphan"`
+	Distance int       `json:"distance"`
+}
+
+type GraphEdge struct {
+	ID     string    `json:"id"`
+	Source uuid.UUID `json:"source"`
+	Target uuid.UUID `json:"target"`
+	Kind   string    `json:"kind"`
+}
+
+type GraphMetrics struct {
+	Orphans []uuid.UUID `json:"orphans"`
+	Hubs    []uuid.UUID `json:"hubs"`
+}
+
+func ToGraphResponse(g link.Graph) GraphResponse {
+	gr := GraphResponse{
+		Nodes:   make([]GraphNode, 0, len(g.Nodes)),
+		Edges:   make([]GraphEdge, 0, len(g.Edges)),
+		Metrics: GraphMetrics{Orphans: g.Metrics.Orphans, Hubs: g.Metrics.Hubs},
+	}
+	for _, n := range g.Nodes {
+		gr.Nodes = append(gr.Nodes, GraphNode{
+			ID:       n.Note.ID,
+			Title:    n.Note.Title.String(),
+			LinksIn:  n.LinksIn,
+			LinksOut: n.LinksOut,
+			Orphan:   n.Orphan,
+			Distance: n.Distance,
+		})
+	}
+	for _, e := range g.Edges {
+		gr.Edges = append(gr.Edges, GraphEdge{
+			ID:     e.SourceID.String() + "-" + e.TargetID.String(),
+			Source: e.SourceID,
+			Target: e.TargetID,
+			Kind:   EdgeLink,
+		})
+	}
+	return gr
+}
+
+// CytoscapeGraphResponse is a GraphResponse in the elements format of
+// Cytoscape.js.
+type CytoscapeGraphResponse struct {
+	Elements struct {
+		Nodes []CytoscapeElement[GraphNode] `json:"nodes"`
+		Edges []CytoscapeElement[GraphEdge] `json:"edges"`
+	} `json:"elements"`
+	Metrics GraphMetrics `json:"metrics"`
+}
+
+type CytoscapeElement[T any] struct {
+	Data T `json:"data"`
+}
+
+func ToCytoscapeGraphResponse(g link.Graph) CytoscapeGraphResponse {
+	gr := ToGraphResponse(g)
+
+	var cr CytoscapeGraphResponse
+	cr.Metrics = gr.Metrics
+	cr.Elements.Nodes = make([]CytoscapeElement[GraphNode], 0, len(gr.Nodes))
+	for _, n := range gr.Nodes {
+		cr.Elements.Nodes = append(cr.Elements.Nodes, CytoscapeElement[GraphNode]{Data: n})
+	}
+	cr.Elements.Edges = make([]CytoscapeElement[GraphEdge], 0, len(gr.Edges))
+	for _, e := range gr.Edges {
+		cr.Elements.Edges = append(cr.Elements.Edges, CytoscapeElement[GraphEdge]{Data: e})
+	}
+	return cr
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"github.com/Keisn1/note-taking-app/domain/core/link"
+	"github.com/google/uuid"
+)
+
+// LinkResponse is a link of a note. NoteID and Title are those of the note
+// it points to, null if the link is broken.
+type LinkResponse struct {
+	Target string     `json:"target"`
+	NoteID *uuid.UUID `json:"note_id"`
+	Title  *string    `json:"title"`
+	Broken bool       `json:"broken"`
+}
+
+func ToLinkResponses(targets []link.Target) []LinkResponse {
+	ret := make([]LinkResponse, 0, len(targets))
+	for _, t := range targets {
+		lr := LinkResponse{Target: t.Target, Broken: t.Broken()}
+		if !t.Broken() {
+			id, title := t.Note.ID, t.Note.Title.String()
+			lr.NoteID, lr.Title = &id, &title
+		}
+		ret = append(ret, lr)
+	}
+	return ret
+}
+
+// BacklinkResponse is a link in the note with NoteID and Title.
+type BacklinkResponse struct {
+	NoteID uuid.UUID `json:"note_id"`
+	Title  string    `json:"title"`
+	Target string    `json:"target"`
+}
+
+func ToBacklinkResponses(sources []link.Source) []BacklinkResponse {
+	ret := make([]BacklinkResponse, 0, len(sources))
+	for _, s := range sources {
+		ret = append(ret, BacklinkResponse{NoteID: s.Note.ID, Title: s.Note.Title.String(), Target: s.Target})
+	}
+	return ret
+}
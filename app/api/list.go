@@ -0,0 +1,74 @@
+package api
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+const (
+	DefaultPerPage = 50
+	MaxPerPage     = 200
+)
+
+// Page selects a page of a list. Pages are numbered from 1.
+type Page struct {
+	Number  int
+	PerPage int
+}
+
+// ParsePage reads the query parameters page and per_page, which default to 1
+// and DefaultPerPage.
+func ParsePage(r *http.Request) (Page, error) {
+	p := Page{Number: 1, PerPage: DefaultPerPage}
+
+	if s := r.URL.Query().Get("page"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return Page{}, errors.New("page must be a positive integer")
+		}
+		p.Number = n
+	}
+
+	if s := r.URL.Query().Get("per_page"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > MaxPerPage {
+			return Page{}, errors.New("per_page must be an integer between 1 and " + strconv.Itoa(MaxPerPage))
+		}
+		p.PerPage = n
+	}
+
+	return p, nil
+}
+
+// Offset returns the number of items before the page. ok is false if that
+// doesn't fit into an int, the page is empty then.
+func (p Page) Offset() (offset int, ok bool) {
+	if p.Number-1 > math.MaxInt/p.PerPage {
+		return 0, false
+	}
+	return (p.Number - 1) * p.PerPage, true
+}
+
+// ListResponse is a page of a list together with the pagination metadata.
+type ListResponse[T any] struct {
+	Items   []T `json:"items"`
+	Page    int `json:"page"`
+	PerPage int `json:"per_page"`
+	Total   int `json:"total"`
+}
+
+// HasMore reports whether there are items after this page.
+func (lr ListResponse[T]) HasMore() bool {
+	return lr.Page*lr.PerPage < lr.Total
+}
+
+// NewListResponse returns the page of items that was selected in storage,
+// total counts all items of the list.
+func NewListResponse[T any](items []T, p Page, total int) ListResponse[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return ListResponse[T]{Items: items, Page: p.Number, PerPage: p.PerPage, Total: total}
+}
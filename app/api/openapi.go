@@ -0,0 +1,27 @@
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed openapi.yaml
+var openAPIYAML []byte
+
+// OpenAPI returns the OpenAPI document of the API as JSON. The document is
+// maintained in openapi.yaml next to the types it describes.
+var OpenAPI = sync.OnceValues(func() ([]byte, error) {
+	var doc any
+	if err := yaml.Unmarshal(openAPIYAML, &doc); err != nil {
+		return nil, fmt.Errorf("openAPI: %w", err)
+	}
+	spec, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("openAPI: %w", err)
+	}
+	return spec, nil
+})
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// patch records the fields of a PATCH body that are null or failed to
+// decode. Absent fields are left untouched, so their domain values stay
+// empty (IsEmpty) and the service keeps the current value.
+type patch struct {
+	nulls   map[string]bool
+	invalid map[string]bool
+}
+
+// decode decodes the JSON object in data into fields, which map the JSON
+// names to pointers to the destination values. Unknown fields are an error.
+func (p *patch) decode(data []byte, fields map[string]any) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw == nil {
+		return errors.New("json: body must be an object")
+	}
+
+	p.nulls, p.invalid = map[string]bool{}, map[string]bool{}
+	for name, value := range raw {
+		dst, ok := fields[name]
+		if !ok {
+			return fmt.Errorf("json: unknown field %q", name)
+		}
+		if bytes.Equal(bytes.TrimSpace(value), []byte("null")) {
+			p.nulls[name] = true
+			continue
+		}
+		if err := json.Unmarshal(value, dst); err != nil {
+			p.invalid[name] = true
+		}
+	}
+	return nil
+}
+
+func (p patch) isNull(field string) bool    { return p.nulls[field] }
+func (p patch) isInvalid(field string) bool { return p.invalid[field] }
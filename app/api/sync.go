@@ -0,0 +1,173 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Keisn1/note-taking-app/domain/core/change"
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/Keisn1/note-taking-app/foundation/validate"
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultSyncLimit = 100
+	MaxSyncLimit     = 500
+	MaxSyncMutations = 100
+)
+
+// SyncQuery selects the changes after Since.
+type SyncQuery struct {
+	Since int64
+	Limit int
+}
+
+// ParseSyncQuery reads the query parameters since, which defaults to 0, and
+// limit, which defaults to DefaultSyncLimit.
+func ParseSyncQuery(r *http.Request) (SyncQuery, error) {
+	q := SyncQuery{Limit: DefaultSyncLimit}
+
+	if s := r.URL.Query().Get("since"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || n < 0 {
+			return SyncQuery{}, errors.New("since must be a non-negative integer")
+		}
+		q.Since = n
+	}
+
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > MaxSyncLimit {
+			return SyncQuery{}, errors.New("limit must be an integer between 1 and " + strconv.Itoa(MaxSyncLimit))
+		}
+		q.Limit = n
+	}
+
+	return q, nil
+}
+
+// SyncChange is the latest change of a note. Version is the version of the
+// note, Note is null for tombstones.
+type SyncChange struct {
+	NoteID  uuid.UUID     `json:"note_id"`
+	Version int64         `json:"version"`
+	Deleted bool          `json:"deleted"`
+	Note    *NoteResponse `json:"note"`
+}
+
+// SyncResponse is a page of the change feed. Clients pass Next as since to
+// get the following changes.
+type SyncResponse struct {
+	Changes []SyncChange `json:"changes"`
+	Next    int64        `json:"next"`
+	HasMore bool         `json:"has_more"`
+}
+
+func ToSyncResponse(f change.Feed) SyncResponse {
+	sr := SyncResponse{Changes: make([]SyncChange, 0, len(f.Entries)), Next: f.Next, HasMore: f.HasMore}
+	for _, e := range f.Entries {
+		sc := SyncChange{NoteID: e.NoteID, Version: e.Seq, Deleted: e.Deleted}
+		if !e.Deleted {
+			nr := ToNoteResponse(e.Note)
+			sc.Note = &nr
+		}
+		sr.Changes = append(sr.Changes, sc)
+	}
+	return sr
+}
+
+// SyncPost is a batch of mutations made offline, applied in their order.
+type SyncPost struct {
+	Mutations []SyncMutation `json:"mutations"`
+}
+
+// SyncMutation creates, replaces or deletes a note. BaseVersion is the
+// version the client changed, 0 for notes it created. An empty format is
+// plain for new notes and keeps the current format otherwise.
+type SyncMutation struct {
+	Op          string    `json:"op"`
+	NoteID      uuid.UUID `json:"note_id"`
+	BaseVersion int64     `json:"base_version"`
+	Title       string    `json:"title,omitempty"`
+	Content     string    `json:"content,omitempty"`
+	Format      string    `json:"format,omitempty"`
+}
+
+func (sp SyncPost) Validate() error {
+	var v validate.Validator
+	v.Check(len(sp.Mutations) > 0, "mutations", "must not be empty")
+	v.Check(len(sp.Mutations) <= MaxSyncMutations, "mutations", fmt.Sprintf("must have at most %d items", MaxSyncMutations))
+	for i, m := range sp.Mutations {
+		field := func(name string) string { return fmt.Sprintf("mutations[%d].%s", i, name) }
+		v.Check(m.Op == string(change.OpUpsert) || m.Op == string(change.OpDelete), field("op"), "must be upsert or delete")
+		v.Check(m.NoteID != uuid.Nil, field("note_id"), "is required")
+		v.Check(m.BaseVersion >= 0, field("base_version"), "must not be negative")
+		if m.Op != string(change.OpUpsert) {
+			continue
+		}
+		v.Required(field("title"), m.Title)
+		v.Text(field("title"), m.Title)
+		v.MaxLength(field("title"), m.Title, note.MaxTitleLength)
+		v.Text(field("content"), m.Content)
+		v.MaxBytes(field("content"), m.Content, note.MaxContentLength)
+		if m.Format != "" {
+			_, err := note.ParseFormat(m.Format)
+			v.Check(err == nil, field("format"), formatMsg)
+		}
+	}
+	return v.Err()
+}
+
+// ToMutations returns the mutations for change.Service.Apply.
+func (sp SyncPost) ToMutations() []change.Mutation {
+	ret := make([]change.Mutation, 0, len(sp.Mutations))
+	for _, m := range sp.Mutations {
+		ret = append(ret, change.Mutation{
+			Op:          change.Op(m.Op),
+			NoteID:      m.NoteID,
+			BaseVersion: m.BaseVersion,
+			Title:       m.Title,
+			Content:     m.Content,
+			Format:      note.Format(m.Format),
+		})
+	}
+	return ret
+}
+
+// SyncResult is the outcome of a mutation, see change.Status. Version and
+// Note are the state of the note on the server afterwards, Note is null if
+// it is deleted.
+type SyncResult struct {
+	NoteID       uuid.UUID     `json:"note_id"`
+	Status       string        `json:"status"`
+	Version      int64         `json:"version"`
+	Note         *NoteResponse `json:"note"`
+	ConflictCopy *NoteResponse `json:"conflict_copy,omitempty"`
+	Error        string        `json:"error,omitempty"`
+}
+
+type SyncPostResponse struct {
+	Results []SyncResult `json:"results"`
+}
+
+func ToSyncPostResponse(results []change.Result) SyncPostResponse {
+	spr := SyncPostResponse{Results: make([]SyncResult, 0, len(results))}
+	for _, r := range results {
+		sr := SyncResult{NoteID: r.NoteID, Status: string(r.Status), Version: r.Version}
+		if r.Note.ID != uuid.Nil {
+			nr := ToNoteResponse(r.Note)
+			sr.Note = &nr
+		}
+		if r.Copy.ID != uuid.Nil {
+			nr := ToNoteResponse(r.Copy)
+			sr.ConflictCopy = &nr
+		}
+		if r.Err != nil {
+			sr.Error = r.Err.Error()
+		}
+		spr.Results = append(spr.Results, sr)
+	}
+	return spr
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"time"
+
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/google/uuid"
+)
+
+// ExportVersion is the version of the export formats.
+const ExportVersion = 1
+
+// Export is the JSON export of the notes of a user.
+type Export struct {
+	Version int          `json:"version"`
+	Notes   []ExportNote `json:"notes"`
+}
+
+// ExportNote is a note in an export. It has no owner so that it can be
+// imported into another account.
+type ExportNote struct {
+	ID      uuid.UUID `json:"id"`
+	Title   string    `json:"title"`
+	Content string    `json:"content"`
+	Format  string    `json:"format"`
+}
+
+func ToExportNote(n note.Note) ExportNote {
+	return ExportNote{ID: n.ID, Title: n.Title.String(), Content: n.Content.String(), Format: n.Format.String()}
+}
+
+// Import job states.
+const (
+	ImportRunning = "running"
+	ImportDone    = "done"
+	ImportFailed  = "failed"
+)
+
+// ImportJob reports the progress of an import. Items are the notes found in
+// the upload; each is either imported, skipped as a duplicate or failed.
+type ImportJob struct {
+	ID         uuid.UUID     `json:"id"`
+	Status     string        `json:"status"`
+	Format     string        `json:"format"`
+	Total      int           `json:"total"`
+	Processed  int           `json:"processed"`
+	Imported   int           `json:"imported"`
+	Duplicates int           `json:"duplicates"`
+	Failed     int           `json:"failed"`
+	Errors     []ImportError `json:"errors"`
+	CreatedAt  time.Time     `json:"created_at"`
+	FinishedAt *time.Time    `json:"finished_at,omitempty"`
+}
+
+// ImportError is the reason an item of an import failed. Item names the file
+// or, for JSON and ENEX, the position and title of the note.
+type ImportError struct {
+	Item  string `json:"item"`
+	Error string `json:"error"`
+}
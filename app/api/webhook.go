@@ -0,0 +1,153 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/domain/core/webhook"
+	"github.com/Keisn1/note-taking-app/foundation/validate"
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultDeliveryLimit = 50
+	MaxDeliveryLimit     = 200
+)
+
+// WebhookPost registers an endpoint. Empty events subscribe to all of them.
+type WebhookPost struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+}
+
+func (wp WebhookPost) Validate() error {
+	var v validate.Validator
+	v.Required("url", wp.URL)
+	v.MaxBytes("url", wp.URL, webhook.MaxURLLength)
+	u, err := url.Parse(wp.URL)
+	v.Check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && u.User == nil, "url", "must be an http or https URL without credentials")
+	for i, e := range wp.Events {
+		v.Check(slices.Contains(webhook.Events, e), fmt.Sprintf("events[%d]", i), "must be one of "+strings.Join(webhook.Events, ", "))
+	}
+	return v.Err()
+}
+
+func (wp WebhookPost) ToNewWebhook(userID uuid.UUID) webhook.NewWebhook {
+	var events []string
+	for _, e := range wp.Events {
+		if !slices.Contains(events, e) {
+			events = append(events, e)
+		}
+	}
+	return webhook.NewWebhook{UserID: userID, URL: wp.URL, Events: events}
+}
+
+// WebhookResponse is a webhook. The secret is only shown when the webhook
+// is created.
+type WebhookResponse struct {
+	ID        uuid.UUID `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func ToWebhookResponse(w webhook.Webhook) WebhookResponse {
+	events := w.Events
+	if events == nil {
+		events = []string{}
+	}
+	return WebhookResponse{ID: w.ID, URL: w.URL, Events: events, CreatedAt: w.CreatedAt}
+}
+
+// ToWebhookResponses converts the webhooks. The result is never nil, so
+// that no webhooks are encoded as an empty list.
+func ToWebhookResponses(ws []webhook.Webhook) []WebhookResponse {
+	ret := make([]WebhookResponse, 0, len(ws))
+	for _, w := range ws {
+		ret = append(ret, ToWebhookResponse(w))
+	}
+	return ret
+}
+
+// DeliveryQuery selects the deliveries of a webhook.
+type DeliveryQuery struct {
+	Status webhook.Status
+	Limit  int
+}
+
+// ParseDeliveryQuery reads the query parameters status, which selects all
+// deliveries if absent, and limit, which defaults to DefaultDeliveryLimit.
+func ParseDeliveryQuery(r *http.Request) (DeliveryQuery, error) {
+	q := DeliveryQuery{Limit: DefaultDeliveryLimit}
+
+	if s := r.URL.Query().Get("status"); s != "" {
+		status, err := webhook.ParseStatus(s)
+		if err != nil {
+			return DeliveryQuery{}, errors.New("status must be pending, succeeded or dead")
+		}
+		q.Status = status
+	}
+
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > MaxDeliveryLimit {
+			return DeliveryQuery{}, errors.New("limit must be an integer between 1 and " + strconv.Itoa(MaxDeliveryLimit))
+		}
+		q.Limit = n
+	}
+
+	return q, nil
+}
+
+// DeliveryResponse is an entry of the delivery log. NextAttemptAt is only
+// set for pending deliveries, ResponseStatus is 0 if the endpoint didn't
+// respond.
+type DeliveryResponse struct {
+	ID             uuid.UUID       `json:"id"`
+	WebhookID      uuid.UUID       `json:"webhook_id"`
+	Event          string          `json:"event"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at"`
+	ResponseStatus int             `json:"response_status"`
+	LastError      string          `json:"last_error"`
+	Payload        json.RawMessage `json:"payload"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+func ToDeliveryResponse(d webhook.Delivery) DeliveryResponse {
+	dr := DeliveryResponse{
+		ID:             d.ID,
+		WebhookID:      d.WebhookID,
+		Event:          d.Event,
+		Status:         string(d.Status),
+		Attempts:       d.Attempts,
+		ResponseStatus: d.ResponseStatus,
+		LastError:      d.LastError,
+		Payload:        d.Payload,
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+	}
+	if d.Status == webhook.StatusPending {
+		dr.NextAttemptAt = &d.NextAttemptAt
+	}
+	return dr
+}
+
+// ToDeliveryResponses converts the deliveries. The result is never nil.
+func ToDeliveryResponses(ds []webhook.Delivery) []DeliveryResponse {
+	ret := make([]DeliveryResponse, 0, len(ds))
+	for _, d := range ds {
+		ret = append(ret, ToDeliveryResponse(d))
+	}
+	return ret
+}
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/app/api"
+)
+
+// Login exchanges email and password for a token, which is used by all
+// further calls.
+func (c *Client) Login(ctx context.Context, email, password string) (api.TokenResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.login(ctx, email, password)
+}
+
+// Refresh exchanges the current token for a new one.
+func (c *Client) Refresh(ctx context.Context) (api.TokenResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.refresh(ctx)
+}
+
+// Token returns the current token and its expiry, e.g. to store it for later
+// use with WithToken.
+func (c *Client) Token() (string, time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.token, c.expiresAt
+}
+
+// authorize returns a token for the next call. A token that is about to
+// expire is refreshed, an expired one is replaced by logging in again if the
+// client has credentials.
+func (c *Client) authorize(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	switch {
+	case c.token != "" && (c.expiresAt.IsZero() || now.Add(refreshMargin).Before(c.expiresAt)):
+		return c.token, nil
+	case c.token != "" && now.Before(c.expiresAt):
+		_, err := c.refresh(ctx)
+		if err == nil {
+			return c.token, nil
+		}
+		if !c.hasCredentials() {
+			return "", err
+		}
+	}
+
+	if !c.hasCredentials() {
+		return "", ErrNotLoggedIn
+	}
+	if _, err := c.login(ctx, c.email, c.password); err != nil {
+		return "", err
+	}
+	return c.token, nil
+}
+
+func (c *Client) hasCredentials() bool {
+	return c.email != "" && c.password != ""
+}
+
+// login and refresh must be called with c.mu held.
+
+func (c *Client) login(ctx context.Context, email, password string) (api.TokenResponse, error) {
+	var tr api.TokenResponse
+	req := request{method: http.MethodPost, path: "/users/login", body: api.LoginPost{Email: email, Password: password}, public: true}
+	if _, err := c.do(ctx, req, &tr); err != nil {
+		return api.TokenResponse{}, fmt.Errorf("login: %w", err)
+	}
+
+	c.token, c.expiresAt = tr.Token, tr.ExpiresAt
+	return tr, nil
+}
+
+func (c *Client) refresh(ctx context.Context) (api.TokenResponse, error) {
+	if c.token == "" {
+		return api.TokenResponse{}, fmt.Errorf("refresh: %w", ErrNotLoggedIn)
+	}
+
+	var tr api.TokenResponse
+	req := request{method: http.MethodPost, path: "/users/token", header: bearer(c.token), public: true}
+	if _, err := c.do(ctx, req, &tr); err != nil {
+		return api.TokenResponse{}, fmt.Errorf("refresh: %w", err)
+	}
+
+	c.token, c.expiresAt = tr.Token, tr.ExpiresAt
+	return tr, nil
+}
+
+func bearer(token string) http.Header {
+	return http.Header{"Authorization": {"Bearer " + token}}
+}
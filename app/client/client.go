@@ -0,0 +1,223 @@
+// Package client is a Go client of the notes API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRetries = 3
+	defaultBackoff = 200 * time.Millisecond
+	maxBackoff     = 5 * time.Second
+
+	// refreshMargin is how long before expiry a token is refreshed.
+	refreshMargin = time.Minute
+)
+
+// Client calls the notes API. It is safe for concurrent use.
+type Client struct {
+	baseURL string
+	http    *http.Client
+	retries int
+	backoff time.Duration
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+	email     string
+	password  string
+}
+
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client, http.DefaultClient is used otherwise.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.http = hc }
+}
+
+// WithToken sets a token obtained earlier, e.g. by Login.
+func WithToken(token string, expiresAt time.Time) Option {
+	return func(c *Client) { c.token, c.expiresAt = token, expiresAt }
+}
+
+// WithCredentials lets the client log in by itself whenever it has no valid
+// token.
+func WithCredentials(email, password string) Option {
+	return func(c *Client) { c.email, c.password = email, password }
+}
+
+// WithRetries sets how often idempotent requests are retried and the backoff
+// before the first retry, which doubles with every further retry. Zero
+// retries disable retrying.
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *Client) { c.retries, c.backoff = retries, backoff }
+}
+
+// New returns a client of the API at baseURL, e.g. "http://localhost:3000".
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		http:    http.DefaultClient,
+		retries: defaultRetries,
+		backoff: defaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+	body   any
+	// public requests are sent without token.
+	public bool
+}
+
+// do sends the request and decodes the response body into out, if out isn't
+// nil. It returns the response header. Answers other than 2xx are returned as
+// *Error.
+func (c *Client) do(ctx context.Context, req request, out any) (http.Header, error) {
+	var body []byte
+	if req.body != nil {
+		var err error
+		if body, err = json.Marshal(req.body); err != nil {
+			return nil, fmt.Errorf("encode request: %w", err)
+		}
+	}
+
+	var token string
+	if !req.public {
+		var err error
+		if token, err = c.authorize(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	retries := 0
+	if isIdempotent(req.method) {
+		retries = c.retries
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, req, body, token)
+		if attempt < retries && shouldRetry(ctx, resp, err) {
+			wait := c.backoffFor(attempt, resp)
+			if resp != nil {
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+			}
+			if err := sleep(ctx, wait); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return resp.Header, parseError(resp)
+		}
+		if out != nil && resp.StatusCode != http.StatusNoContent {
+			if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+				return resp.Header, fmt.Errorf("decode response: %w", err)
+			}
+		}
+		return resp.Header, nil
+	}
+}
+
+func (c *Client) send(ctx context.Context, req request, body []byte, token string) (*http.Response, error) {
+	u := c.baseURL + req.path
+	if len(req.query) > 0 {
+		u += "?" + req.query.Encode()
+	}
+
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	hr, err := http.NewRequestWithContext(ctx, req.method, u, r)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range req.header {
+		hr.Header[k] = v
+	}
+	hr.Header.Set("Accept", "application/json")
+	if body != nil {
+		hr.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		hr.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return c.http.Do(hr)
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// shouldRetry reports whether the attempt failed for a reason that may go
+// away, i.e. a network error or an overloaded or unavailable server.
+func shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoffFor returns how long to wait before the next attempt. Retry-After is
+// respected, otherwise the backoff grows exponentially with some jitter.
+func (c *Client) backoffFor(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs >= 0 {
+			return min(time.Duration(secs)*time.Second, maxBackoff)
+		}
+	}
+
+	d := min(c.backoff<<attempt, maxBackoff)
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
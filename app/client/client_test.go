@@ -0,0 +1,323 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/app/api"
+	"github.com/Keisn1/note-taking-app/app/client"
+	"github.com/Keisn1/note-taking-app/app/handlers/notesgrp"
+	"github.com/Keisn1/note-taking-app/app/handlers/usersgrp"
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	notememory "github.com/Keisn1/note-taking-app/domain/core/note/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	usermemory "github.com/Keisn1/note-taking-app/domain/core/user/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/web/auth"
+	"github.com/Keisn1/note-taking-app/domain/web/mux"
+	"github.com/Keisn1/note-taking-app/foundation/common"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAPI(tokenTTL time.Duration) http.Handler {
+	userSvc := user.NewSvc(usermemory.NewRepo(nil))
+	cfg := mux.Config{
+		Auth:     auth.NewAuth(auth.MustNewJWTService(common.MustGenerateRandomKey(32))),
+		NoteSvc:  note.NewNotesService(notememory.MustNewRepo(nil), userSvc),
+		UserSvc:  userSvc,
+		TokenTTL: tokenTTL,
+	}
+	routes := func(app *web.App, cfg mux.Config) {
+		notesgrp.Routes(app, cfg)
+		usersgrp.Routes(app, cfg)
+	}
+	return mux.NewAPI(routes, cfg)
+}
+
+func newServer(t *testing.T, h http.Handler) *httptest.Server {
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+var rob = api.UserPost{Name: "rob", Email: "rob@example.com", Password: "password"}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	srv := newServer(t, newAPI(time.Hour))
+	c := client.New(srv.URL)
+
+	t.Run("Calls require login", func(t *testing.T) {
+		_, err := c.ListNotes(ctx, client.NoteFilter{})
+		assert.ErrorIs(t, err, client.ErrNotLoggedIn)
+	})
+
+	var me api.UserResponse
+	t.Run("Signup and login", func(t *testing.T) {
+		var err error
+		me, err = c.CreateUser(ctx, rob)
+		require.NoError(t, err)
+		assert.Equal(t, "rob@example.com", me.Email)
+
+		_, err = c.CreateUser(ctx, rob)
+		assert.ErrorIs(t, err, client.ErrConflict)
+
+		_, err = c.Login(ctx, rob.Email, "wrong password")
+		assert.ErrorIs(t, err, client.ErrUnauthorized)
+		assert.EqualError(t, err, "login: server answered 401: invalid credentials")
+
+		tr, err := c.Login(ctx, rob.Email, rob.Password)
+		require.NoError(t, err)
+		token, expiresAt := c.Token()
+		assert.Equal(t, tr.Token, token)
+		assert.Equal(t, tr.ExpiresAt, expiresAt)
+
+		got, err := c.Me(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, me, got)
+	})
+
+	var n client.Note
+	t.Run("Create and get notes", func(t *testing.T) {
+		var err error
+		n, err = c.CreateNote(ctx, api.NotePost{Title: "Groceries", Content: "milk"})
+		require.NoError(t, err)
+		assert.Equal(t, me.ID, n.UserID)
+		assert.NotEmpty(t, n.ETag)
+
+		got, err := c.GetNote(ctx, n.ID)
+		require.NoError(t, err)
+		assert.Equal(t, n, got)
+
+		_, err = c.CreateNote(ctx, api.NotePost{Title: "Ideas", Content: "buy more milk"})
+		require.NoError(t, err)
+	})
+
+	t.Run("Validation errors have fields", func(t *testing.T) {
+		_, err := c.CreateNote(ctx, api.NotePost{Content: "no title"})
+		require.ErrorIs(t, err, client.ErrValidation)
+
+		var apiErr *client.Error
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+		require.Len(t, apiErr.Fields, 1)
+		assert.Equal(t, "title", apiErr.Fields[0].Field)
+	})
+
+	t.Run("List notes", func(t *testing.T) {
+		lr, err := c.ListNotes(ctx, client.NoteFilter{})
+		require.NoError(t, err)
+		assert.Len(t, lr.Items, 2)
+		assert.Equal(t, 2, lr.Total)
+
+		lr, err = c.ListNotes(ctx, client.NoteFilter{Title: "groc"})
+		require.NoError(t, err)
+		require.Len(t, lr.Items, 1)
+		assert.Equal(t, "Groceries", lr.Items[0].Title)
+
+		lr, err = c.ListNotes(ctx, client.NoteFilter{Query: "more milk"})
+		require.NoError(t, err)
+		require.Len(t, lr.Items, 1)
+		assert.Equal(t, "Ideas", lr.Items[0].Title)
+	})
+
+	t.Run("List notes page by page", func(t *testing.T) {
+		lr, err := c.ListNotes(ctx, client.NoteFilter{Page: 2, PerPage: 1})
+		require.NoError(t, err)
+		require.Len(t, lr.Items, 1)
+		assert.Equal(t, "Ideas", lr.Items[0].Title)
+		assert.False(t, lr.HasMore())
+
+		notes, err := c.AllNotes(ctx, client.NoteFilter{PerPage: 1})
+		require.NoError(t, err)
+		require.Len(t, notes, 2)
+		assert.Equal(t, "Groceries", notes[0].Title)
+		assert.Equal(t, "Ideas", notes[1].Title)
+	})
+
+	t.Run("Update note with etag", func(t *testing.T) {
+		updated, err := c.UpdateNote(ctx, n.ID, api.NotePost{Title: "Groceries", Content: "milk, eggs"}, n.ETag)
+		require.NoError(t, err)
+		assert.Equal(t, "milk, eggs", updated.Content)
+		assert.NotEqual(t, n.ETag, updated.ETag)
+
+		_, err = c.UpdateNote(ctx, n.ID, api.NotePost{Title: "Groceries", Content: "stale"}, n.ETag)
+		assert.ErrorIs(t, err, client.ErrPreconditionFailed)
+
+		_, err = c.UpdateNote(ctx, n.ID, api.NotePost{Title: "Groceries", Content: "bread"}, "")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Patch note", func(t *testing.T) {
+		patched, err := c.PatchNote(ctx, n.ID, api.NotePatch{Content: note.NewContent("eggs")}, "")
+		require.NoError(t, err)
+		assert.Equal(t, "Groceries", patched.Title)
+		assert.Equal(t, "eggs", patched.Content)
+
+		_, err = c.PatchNote(ctx, n.ID, api.NotePatch{Title: note.NewTitle("")}, "")
+		assert.ErrorIs(t, err, client.ErrValidation)
+	})
+
+	t.Run("Render markdown note", func(t *testing.T) {
+		md, err := c.CreateNote(ctx, api.NotePost{Title: "Plan", Content: "# Steps\n\n1. *think*", Format: "markdown"})
+		require.NoError(t, err)
+		assert.Equal(t, "markdown", md.Format)
+
+		got, err := c.GetRenderedNote(ctx, md.ID)
+		require.NoError(t, err)
+		assert.Equal(t, md.NoteResponse, got.NoteResponse)
+		assert.Contains(t, got.HTML, "<em>think</em>")
+		assert.Len(t, got.TOC, 1)
+
+		require.NoError(t, c.DeleteNote(ctx, md.ID))
+	})
+
+	t.Run("Delete note", func(t *testing.T) {
+		require.NoError(t, c.DeleteNote(ctx, n.ID))
+
+		_, err := c.GetNote(ctx, n.ID)
+		assert.ErrorIs(t, err, client.ErrForbidden)
+	})
+
+	t.Run("Update and delete user", func(t *testing.T) {
+		got, err := c.UpdateMe(ctx, api.UserPut{Name: "robert", Email: "robert@example.com"})
+		require.NoError(t, err)
+		assert.Equal(t, "robert", got.Name)
+
+		got, err = c.PatchMe(ctx, api.UserPatch{Name: user.NewName("bob")})
+		require.NoError(t, err)
+		assert.Equal(t, "bob", got.Name)
+		assert.Equal(t, "robert@example.com", got.Email)
+
+		require.NoError(t, c.DeleteMe(ctx))
+
+		_, err = c.Me(ctx)
+		assert.ErrorIs(t, err, client.ErrForbidden)
+	})
+}
+
+func TestClient_TokenHandling(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Logs in with credentials", func(t *testing.T) {
+		srv := newServer(t, newAPI(time.Hour))
+		_, err := client.New(srv.URL).CreateUser(ctx, rob)
+		require.NoError(t, err)
+
+		c := client.New(srv.URL, client.WithCredentials(rob.Email, rob.Password))
+		_, err = c.Me(ctx)
+		require.NoError(t, err)
+
+		token, _ := c.Token()
+		assert.NotEmpty(t, token)
+	})
+
+	t.Run("Refreshes tokens about to expire", func(t *testing.T) {
+		srv := newServer(t, newAPI(30*time.Second))
+		_, err := client.New(srv.URL).CreateUser(ctx, rob)
+		require.NoError(t, err)
+
+		c := client.New(srv.URL)
+		tr, err := c.Login(ctx, rob.Email, rob.Password)
+		require.NoError(t, err)
+
+		// The client is told the token expires within the refresh margin,
+		// so it refreshes it before the call.
+		c = client.New(srv.URL, client.WithToken(tr.Token, time.Now().Add(time.Second)))
+		_, err = c.Me(ctx)
+		require.NoError(t, err)
+
+		_, expiresAt := c.Token()
+		assert.WithinDuration(t, time.Now().Add(30*time.Second), expiresAt, 2*time.Second)
+	})
+
+	t.Run("Expired token without credentials", func(t *testing.T) {
+		srv := newServer(t, newAPI(time.Hour))
+		c := client.New(srv.URL, client.WithToken("token", time.Now().Add(-time.Minute)))
+
+		_, err := c.Me(ctx)
+		assert.ErrorIs(t, err, client.ErrNotLoggedIn)
+	})
+
+	t.Run("Invalid token", func(t *testing.T) {
+		srv := newServer(t, newAPI(time.Hour))
+		c := client.New(srv.URL, client.WithToken("token", time.Now().Add(time.Hour)))
+
+		_, err := c.Me(ctx)
+		assert.ErrorIs(t, err, client.ErrForbidden)
+		assert.EqualError(t, err, "me: server answered 403: failed authentication")
+	})
+}
+
+// flaky answers the first failures requests with 503.
+type flaky struct {
+	next     http.Handler
+	failures int32
+	calls    atomic.Int32
+}
+
+func (f *flaky) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if f.calls.Add(1) <= f.failures {
+		http.Error(w, "", http.StatusServiceUnavailable)
+		return
+	}
+	f.next.ServeHTTP(w, r)
+}
+
+func TestClient_Retries(t *testing.T) {
+	ctx := context.Background()
+	h := newAPI(time.Hour)
+	srv := newServer(t, h)
+	c := client.New(srv.URL, client.WithRetries(3, time.Millisecond))
+	_, err := c.CreateUser(ctx, rob)
+	require.NoError(t, err)
+	tr, err := c.Login(ctx, rob.Email, rob.Password)
+	require.NoError(t, err)
+
+	newClient := func(f *flaky, retries int) *client.Client {
+		srv := newServer(t, f)
+		return client.New(srv.URL, client.WithToken(tr.Token, tr.ExpiresAt), client.WithRetries(retries, time.Millisecond))
+	}
+
+	t.Run("Idempotent calls are retried", func(t *testing.T) {
+		f := &flaky{next: h, failures: 2}
+		_, err := newClient(f, 3).ListNotes(ctx, client.NoteFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), f.calls.Load())
+	})
+
+	t.Run("Retries are limited", func(t *testing.T) {
+		f := &flaky{next: h, failures: 5}
+		_, err := newClient(f, 2).ListNotes(ctx, client.NoteFilter{})
+		var apiErr *client.Error
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+		assert.Equal(t, int32(3), f.calls.Load())
+	})
+
+	t.Run("Other calls are not retried", func(t *testing.T) {
+		f := &flaky{next: h, failures: 1}
+		_, err := newClient(f, 3).CreateNote(ctx, api.NotePost{Title: "title"})
+		require.Error(t, err)
+		assert.Equal(t, int32(1), f.calls.Load())
+	})
+
+	t.Run("Backoff respects the context", func(t *testing.T) {
+		f := &flaky{next: h, failures: 5}
+		srv := newServer(t, f)
+		c := client.New(srv.URL, client.WithToken(tr.Token, tr.ExpiresAt), client.WithRetries(3, time.Hour))
+
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		_, err := c.GetNote(ctx, uuid.New())
+		assert.True(t, errors.Is(err, context.DeadlineExceeded), err)
+		assert.Equal(t, int32(1), f.calls.Load())
+	})
+}
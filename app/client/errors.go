@@ -0,0 +1,78 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/Keisn1/note-taking-app/app/api"
+	"github.com/Keisn1/note-taking-app/foundation/validate"
+)
+
+// Errors for the status codes of the API. *Error matches them with errors.Is.
+var (
+	ErrUnauthorized       = errors.New("unauthorized")
+	ErrForbidden          = errors.New("forbidden")
+	ErrNotFound           = errors.New("not found")
+	ErrConflict           = errors.New("conflict")
+	ErrPreconditionFailed = errors.New("precondition failed")
+	ErrValidation         = errors.New("validation failed")
+)
+
+// ErrNotLoggedIn is returned by authenticated calls if the client has neither
+// a valid token nor credentials to log in.
+var ErrNotLoggedIn = errors.New("not logged in")
+
+var statusErrors = map[int]error{
+	http.StatusUnauthorized:        ErrUnauthorized,
+	http.StatusForbidden:           ErrForbidden,
+	http.StatusNotFound:            ErrNotFound,
+	http.StatusConflict:            ErrConflict,
+	http.StatusPreconditionFailed:  ErrPreconditionFailed,
+	http.StatusUnprocessableEntity: ErrValidation,
+}
+
+// Error is an answer of the API other than 2xx. Message and Fields are taken
+// from the api.ErrorResponse in the body.
+type Error struct {
+	StatusCode int
+	Message    string
+	Fields     validate.FieldErrors
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if len(e.Fields) > 0 {
+		msg = e.Fields.Error()
+	}
+	return fmt.Sprintf("server answered %d: %s", e.StatusCode, msg)
+}
+
+func (e *Error) Is(target error) bool {
+	return target != nil && statusErrors[e.StatusCode] == target
+}
+
+// parseError reads an error answer. The body is an api.ErrorResponse or, for
+// errors of the middleware, plain text.
+func parseError(resp *http.Response) error {
+	e := &Error{StatusCode: resp.StatusCode}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+
+	var er api.ErrorResponse
+	if mediaType == "application/json" && json.Unmarshal(body, &er) == nil {
+		e.Message, e.Fields = er.Error, er.Fields
+	} else {
+		e.Message = strings.TrimSpace(string(body))
+	}
+
+	if e.Message == "" {
+		e.Message = strings.ToLower(http.StatusText(resp.StatusCode))
+	}
+	return e
+}
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/Keisn1/note-taking-app/app/api"
+	"github.com/google/uuid"
+)
+
+// Note is a note together with its ETag, which UpdateNote uses to detect
+// concurrent changes.
+type Note struct {
+	api.NoteResponse
+	ETag string `json:"-"`
+}
+
+// NoteFilter restricts ListNotes. Title matches titles, Query titles or
+// content, both as case-insensitive substrings. Page and PerPage select the
+// page, the server defaults are used if they are zero.
+type NoteFilter struct {
+	Title   string
+	Query   string
+	Page    int
+	PerPage int
+}
+
+func (f NoteFilter) values() url.Values {
+	v := url.Values{}
+	if f.Title != "" {
+		v.Set("title", f.Title)
+	}
+	if f.Query != "" {
+		v.Set("q", f.Query)
+	}
+	if f.Page > 0 {
+		v.Set("page", strconv.Itoa(f.Page))
+	}
+	if f.PerPage > 0 {
+		v.Set("per_page", strconv.Itoa(f.PerPage))
+	}
+	return v
+}
+
+func (c *Client) CreateNote(ctx context.Context, np api.NotePost) (Note, error) {
+	var n Note
+	h, err := c.do(ctx, request{method: http.MethodPost, path: "/notes", body: np}, &n.NoteResponse)
+	if err != nil {
+		return Note{}, fmt.Errorf("create note: %w", err)
+	}
+	n.ETag = h.Get("ETag")
+	return n, nil
+}
+
+// ListNotes returns a page of the notes, ordered by title.
+func (c *Client) ListNotes(ctx context.Context, f NoteFilter) (api.ListResponse[api.NoteResponse], error) {
+	var lr api.ListResponse[api.NoteResponse]
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: "/notes", query: f.values()}, &lr); err != nil {
+		return api.ListResponse[api.NoteResponse]{}, fmt.Errorf("list notes: %w", err)
+	}
+	return lr, nil
+}
+
+// AllNotes returns the notes of all pages, f.Page is ignored.
+func (c *Client) AllNotes(ctx context.Context, f NoteFilter) ([]api.NoteResponse, error) {
+	notes := []api.NoteResponse{}
+	for f.Page = 1; ; f.Page++ {
+		lr, err := c.ListNotes(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, lr.Items...)
+		if !lr.HasMore() || len(lr.Items) == 0 {
+			return notes, nil
+		}
+	}
+}
+
+func (c *Client) GetNote(ctx context.Context, id uuid.UUID) (Note, error) {
+	var n Note
+	h, err := c.do(ctx, request{method: http.MethodGet, path: notePath(id)}, &n.NoteResponse)
+	if err != nil {
+		return Note{}, fmt.Errorf("get note: %w", err)
+	}
+	n.ETag = h.Get("ETag")
+	return n, nil
+}
+
+// GetRenderedNote returns the note with its content rendered to sanitised
+// HTML and its table of contents.
+func (c *Client) GetRenderedNote(ctx context.Context, id uuid.UUID) (api.RenderedNoteResponse, error) {
+	var rn api.RenderedNoteResponse
+	req := request{method: http.MethodGet, path: notePath(id), query: url.Values{"render": {"html"}}}
+	if _, err := c.do(ctx, req, &rn); err != nil {
+		return api.RenderedNoteResponse{}, fmt.Errorf("get rendered note: %w", err)
+	}
+	return rn, nil
+}
+
+// UpdateNote replaces title and content of the note. If etag isn't empty, the
+// update fails with ErrPreconditionFailed if the note changed since etag was
+// obtained.
+func (c *Client) UpdateNote(ctx context.Context, id uuid.UUID, np api.NotePost, etag string) (Note, error) {
+	req := request{method: http.MethodPut, path: notePath(id), body: np}
+	if etag != "" {
+		req.header = http.Header{"If-Match": {etag}}
+	}
+
+	var n Note
+	h, err := c.do(ctx, req, &n.NoteResponse)
+	if err != nil {
+		return Note{}, fmt.Errorf("update note: %w", err)
+	}
+	n.ETag = h.Get("ETag")
+	return n, nil
+}
+
+// PatchNote changes the fields of the note that are set in np. The etag is
+// handled like by UpdateNote.
+func (c *Client) PatchNote(ctx context.Context, id uuid.UUID, np api.NotePatch, etag string) (Note, error) {
+	req := request{method: http.MethodPatch, path: notePath(id), body: np}
+	if etag != "" {
+		req.header = http.Header{"If-Match": {etag}}
+	}
+
+	var n Note
+	h, err := c.do(ctx, req, &n.NoteResponse)
+	if err != nil {
+		return Note{}, fmt.Errorf("patch note: %w", err)
+	}
+	n.ETag = h.Get("ETag")
+	return n, nil
+}
+
+func (c *Client) DeleteNote(ctx context.Context, id uuid.UUID) error {
+	if _, err := c.do(ctx, request{method: http.MethodDelete, path: notePath(id)}, nil); err != nil {
+		return fmt.Errorf("delete note: %w", err)
+	}
+	return nil
+}
+
+func notePath(id uuid.UUID) string {
+	return "/notes/" + id.String()
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/Keisn1/note-taking-app/app/api"
+)
+
+// CreateUser signs up a new user. It doesn't log in.
+func (c *Client) CreateUser(ctx context.Context, up api.UserPost) (api.UserResponse, error) {
+	var u api.UserResponse
+	req := request{method: http.MethodPost, path: "/users", body: up, public: true}
+	if _, err := c.do(ctx, req, &u); err != nil {
+		return api.UserResponse{}, fmt.Errorf("create user: %w", err)
+	}
+	return u, nil
+}
+
+// Me returns the logged in user.
+func (c *Client) Me(ctx context.Context) (api.UserResponse, error) {
+	var u api.UserResponse
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: "/users/me"}, &u); err != nil {
+		return api.UserResponse{}, fmt.Errorf("me: %w", err)
+	}
+	return u, nil
+}
+
+// UpdateMe replaces name and email of the logged in user, and the password
+// if it isn't empty.
+func (c *Client) UpdateMe(ctx context.Context, up api.UserPut) (api.UserResponse, error) {
+	var u api.UserResponse
+	if _, err := c.do(ctx, request{method: http.MethodPut, path: "/users/me", body: up}, &u); err != nil {
+		return api.UserResponse{}, fmt.Errorf("update me: %w", err)
+	}
+	return u, nil
+}
+
+// PatchMe changes the fields of the logged in user that are set in up.
+func (c *Client) PatchMe(ctx context.Context, up api.UserPatch) (api.UserResponse, error) {
+	var u api.UserResponse
+	if _, err := c.do(ctx, request{method: http.MethodPatch, path: "/users/me", body: up}, &u); err != nil {
+		return api.UserResponse{}, fmt.Errorf("patch me: %w", err)
+	}
+	return u, nil
+}
+
+// DeleteMe deletes the logged in user with all their notes.
+func (c *Client) DeleteMe(ctx context.Context) error {
+	if _, err := c.do(ctx, request{method: http.MethodDelete, path: "/users/me"}, nil); err != nil {
+		return fmt.Errorf("delete me: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+// Package config holds the configuration shared by the notes services and
+// tools. See conf for how values are loaded.
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/foundation/conf"
+	"github.com/Keisn1/note-taking-app/foundation/tracing"
+	"github.com/google/uuid"
+)
+
+// Prefix of the environment variables, e.g. NOTES_DB_DSN.
+const Prefix = "NOTES"
+
+// MinSigningKeyLength is the minimum length in bytes of a decoded JWT
+// signing key.
+const MinSigningKeyLength = 32
+
+type Config struct {
+	Web struct {
+		Addr              string        `default:":3000" help:"address the API listens on"`
+		ReadHeaderTimeout time.Duration `default:"5s"`
+		ReadTimeout       time.Duration `default:"10s"`
+		WriteTimeout      time.Duration `default:"10s"`
+		IdleTimeout       time.Duration `default:"120s"`
+		DrainPeriod       time.Duration `default:"5s" help:"time readiness fails before the listener is closed"`
+		ShutdownTimeout   time.Duration `default:"20s" help:"time running requests get to finish on shutdown"`
+		CORSOrigins       []string      `help:"comma separated origins allowed to call the API, * allows any"`
+	}
+	DB struct {
+		DSN          string `default:"host=localhost port=5432 user=postgres password=password sslmode=disable" secret:"true" help:"Postgres connection string"`
+		MaxOpenConns int    `default:"25"`
+		MaxIdleConns int    `default:"25"`
+		Migrate      bool   `default:"true" help:"apply pending migrations at startup"`
+	}
+	Auth struct {
+		SigningKey          string        `secret:"true" help:"base64 encoded JWT signing key, a random key is used if empty"`
+		PreviousSigningKeys []string      `secret:"true" help:"base64 encoded keys of tokens that are still accepted"`
+		TokenTTL            time.Duration `default:"24h" help:"lifetime of issued tokens"`
+	}
+	Attachments struct {
+		Store   string `default:"fs" help:"fs or s3"`
+		Dir     string `default:"data/attachments" help:"directory of the fs store"`
+		MaxSize int64  `default:"26214400" help:"maximum size of a file in bytes"`
+		Quota   int64  `default:"524288000" help:"maximum total size of the files of a user in bytes"`
+		S3      struct {
+			Endpoint  string `help:"URL of the S3 compatible service, e.g. https://s3.eu-central-1.amazonaws.com"`
+			Region    string `default:"us-east-1"`
+			Bucket    string
+			AccessKey string `secret:"true"`
+			SecretKey string `secret:"true"`
+		}
+	}
+	Events struct {
+		LogSize int `default:"1000" help:"number of events kept for clients that reconnect to GET /events"`
+	}
+	Collab struct {
+		SnapshotInterval time.Duration `default:"10s" help:"how often notes edited collaboratively are saved"`
+	}
+	Outbox struct {
+		PollInterval time.Duration `default:"1s" help:"how often the domain events of the outbox are relayed"`
+		Retention    time.Duration `default:"168h" help:"how long relayed events are kept"`
+	}
+	Webhooks struct {
+		PollInterval time.Duration `default:"5s" help:"how often due webhook deliveries are sent"`
+		Timeout      time.Duration `default:"10s" help:"time an endpoint gets to respond, at most 1m"`
+		MaxAttempts  int           `default:"8" help:"attempts before a delivery is dead"`
+		Retention    time.Duration `default:"720h" help:"how long finished deliveries are kept"`
+		AllowPrivate bool          `help:"allow endpoints on loopback and private addresses"`
+	}
+	Audit struct {
+		Admins        []string      `help:"comma separated IDs of the users that may query the audit trail"`
+		ChainInterval time.Duration `default:"1s" help:"how often recorded audit entries are chained into the trail"`
+	}
+	Log struct {
+		Level  string `default:"info" help:"debug, info, warn or error"`
+		Format string `default:"json" help:"json or text"`
+	}
+	Tracing struct {
+		Exporter    string  `default:"none" help:"none, stdout or otlp"`
+		Endpoint    string  `default:"localhost:4318" help:"OTLP/HTTP collector endpoint"`
+		Insecure    bool    `default:"true"`
+		SampleRatio float64 `default:"1"`
+	}
+}
+
+// Load reads the configuration from the environment, the optional file and
+// the command line arguments.
+func Load(args []string) (Config, error) {
+	var cfg Config
+	if err := conf.Parse(&cfg, Prefix, args); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) Validate() error {
+	if c.DB.DSN == "" {
+		return errors.New("db.dsn is required")
+	}
+	if c.DB.MaxOpenConns < 0 || c.DB.MaxIdleConns < 0 {
+		return errors.New("db: connection limits must not be negative")
+	}
+	if c.Auth.TokenTTL <= 0 {
+		return errors.New("auth.token_ttl must be positive")
+	}
+	if c.Auth.SigningKey == "" && len(c.Auth.PreviousSigningKeys) > 0 {
+		return errors.New("auth.previous_signing_keys requires auth.signing_key")
+	}
+	if _, _, err := c.SigningKeys(); err != nil {
+		return err
+	}
+	switch c.Attachments.Store {
+	case "fs":
+		if c.Attachments.Dir == "" {
+			return errors.New("attachments.dir is required")
+		}
+	case "s3":
+		if c.Attachments.S3.Endpoint == "" || c.Attachments.S3.Bucket == "" {
+			return errors.New("attachments.s3: endpoint and bucket are required")
+		}
+	default:
+		return fmt.Errorf("attachments.store: unknown store %q", c.Attachments.Store)
+	}
+	if c.Attachments.MaxSize <= 0 || c.Attachments.Quota <= 0 {
+		return errors.New("attachments: max_size and quota must be positive")
+	}
+	if c.Events.LogSize <= 0 {
+		return errors.New("events.log_size must be positive")
+	}
+	if c.Collab.SnapshotInterval <= 0 {
+		return errors.New("collab.snapshot_interval must be positive")
+	}
+	if c.Outbox.PollInterval <= 0 || c.Outbox.Retention <= 0 {
+		return errors.New("outbox: poll_interval and retention must be positive")
+	}
+	if c.Webhooks.PollInterval <= 0 || c.Webhooks.Retention <= 0 {
+		return errors.New("webhooks: poll_interval and retention must be positive")
+	}
+	if c.Webhooks.Timeout <= 0 || c.Webhooks.Timeout > time.Minute {
+		return errors.New("webhooks.timeout must be between 0 and 1m")
+	}
+	if c.Webhooks.MaxAttempts <= 0 {
+		return errors.New("webhooks.max_attempts must be positive")
+	}
+	if _, err := c.AuditAdmins(); err != nil {
+		return err
+	}
+	if c.Audit.ChainInterval <= 0 {
+		return errors.New("audit.chain_interval must be positive")
+	}
+	if _, err := parseLevel(c.Log.Level); err != nil {
+		return err
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		return fmt.Errorf("log.format: unknown format %q", c.Log.Format)
+	}
+	switch c.Tracing.Exporter {
+	case tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP:
+	default:
+		return fmt.Errorf("tracing.exporter: unknown exporter %q", c.Tracing.Exporter)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		return errors.New("tracing.sample_ratio must be between 0 and 1")
+	}
+	return nil
+}
+
+// SigningKeys decodes the JWT signing key and the previous keys. The key is
+// nil if none is configured.
+func (c *Config) SigningKeys() (key []byte, previous [][]byte, err error) {
+	if c.Auth.SigningKey != "" {
+		key, err = decodeKey(c.Auth.SigningKey)
+		if err != nil {
+			return nil, nil, fmt.Errorf("auth.signing_key: %w", err)
+		}
+	}
+	for i, k := range c.Auth.PreviousSigningKeys {
+		prev, err := decodeKey(k)
+		if err != nil {
+			return nil, nil, fmt.Errorf("auth.previous_signing_keys[%d]: %w", i, err)
+		}
+		previous = append(previous, prev)
+	}
+	return key, previous, nil
+}
+
+// AuditAdmins parses the IDs of the admins.
+func (c *Config) AuditAdmins() ([]uuid.UUID, error) {
+	admins := make([]uuid.UUID, 0, len(c.Audit.Admins))
+	for _, s := range c.Audit.Admins {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return nil, fmt.Errorf("audit.admins: invalid user ID %q", s)
+		}
+		admins = append(admins, id)
+	}
+	return admins, nil
+}
+
+// Logger returns a logger writing to w with the configured level and format.
+func (c *Config) Logger(w io.Writer) (*slog.Logger, error) {
+	level, err := parseLevel(c.Log.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	if c.Log.Format == "text" {
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	}
+	return slog.New(slog.NewJSONHandler(w, opts)), nil
+}
+
+// String returns the configuration with secrets redacted.
+func (c *Config) String() string {
+	s, err := conf.String(c)
+	if err != nil {
+		return err.Error()
+	}
+	return s
+}
+
+func decodeKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("must be base64 encoded")
+	}
+	if len(key) < MinSigningKeyLength {
+		return nil, fmt.Errorf("must be at least %d bytes", MinSigningKeyLength)
+	}
+	return key, nil
+}
+
+func parseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.ToLower(s))); err != nil {
+		return 0, fmt.Errorf("log.level: unknown level %q", s)
+	}
+	return level, nil
+}
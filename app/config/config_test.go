@@ -0,0 +1,188 @@
+package config_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/app/config"
+	"github.com/Keisn1/note-taking-app/foundation/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, err := config.Load(nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, ":3000", cfg.Web.Addr)
+	assert.Equal(t, 20*time.Second, cfg.Web.ShutdownTimeout)
+	assert.True(t, cfg.DB.Migrate)
+	assert.Equal(t, 24*time.Hour, cfg.Auth.TokenTTL)
+	assert.Equal(t, "none", cfg.Tracing.Exporter)
+	assert.Equal(t, "fs", cfg.Attachments.Store)
+	assert.Equal(t, int64(25<<20), cfg.Attachments.MaxSize)
+	assert.Equal(t, 1000, cfg.Events.LogSize)
+	assert.Equal(t, 10*time.Second, cfg.Collab.SnapshotInterval)
+	assert.Equal(t, time.Second, cfg.Outbox.PollInterval)
+	assert.Equal(t, 8, cfg.Webhooks.MaxAttempts)
+	assert.False(t, cfg.Webhooks.AllowPrivate)
+	assert.Empty(t, cfg.Audit.Admins)
+	assert.Equal(t, time.Second, cfg.Audit.ChainInterval)
+
+	key, previous, err := cfg.SigningKeys()
+	require.NoError(t, err)
+	assert.Nil(t, key)
+	assert.Nil(t, previous)
+}
+
+func TestLoad_SigningKeys(t *testing.T) {
+	key := common.MustGenerateRandomKey(32)
+	prev := common.MustGenerateRandomKey(48)
+
+	t.Setenv("NOTES_AUTH_SIGNING_KEY", base64.StdEncoding.EncodeToString(key))
+	t.Setenv("NOTES_AUTH_PREVIOUS_SIGNING_KEYS", base64.StdEncoding.EncodeToString(prev))
+
+	cfg, err := config.Load(nil)
+	require.NoError(t, err)
+
+	gotKey, gotPrevious, err := cfg.SigningKeys()
+	require.NoError(t, err)
+	assert.Equal(t, key, gotKey)
+	assert.Equal(t, [][]byte{prev}, gotPrevious)
+
+	s := cfg.String()
+	assert.Contains(t, s, "auth.signing_key=[redacted]\n")
+	assert.Contains(t, s, "db.dsn=[redacted]\n")
+	assert.NotContains(t, s, base64.StdEncoding.EncodeToString(key))
+	assert.NotContains(t, s, "password=password")
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	shortKey := base64.StdEncoding.EncodeToString(common.MustGenerateRandomKey(16))
+	key := base64.StdEncoding.EncodeToString(common.MustGenerateRandomKey(32))
+
+	testCases := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "signing key not base64",
+			args: []string{"--auth-signing-key", "not base64!"},
+			want: "validate: auth.signing_key: must be base64 encoded",
+		},
+		{
+			name: "signing key too short",
+			args: []string{"--auth-signing-key", shortKey},
+			want: "validate: auth.signing_key: must be at least 32 bytes",
+		},
+		{
+			name: "previous key too short",
+			args: []string{"--auth-signing-key", key, "--auth-previous-signing-keys", shortKey},
+			want: "validate: auth.previous_signing_keys[0]: must be at least 32 bytes",
+		},
+		{
+			name: "previous keys without signing key",
+			args: []string{"--auth-previous-signing-keys", key},
+			want: "validate: auth.previous_signing_keys requires auth.signing_key",
+		},
+		{
+			name: "empty dsn",
+			args: []string{"--db-dsn", ""},
+			want: "validate: db.dsn is required",
+		},
+		{
+			name: "unknown log level",
+			args: []string{"--log-level", "verbose"},
+			want: `validate: log.level: unknown level "verbose"`,
+		},
+		{
+			name: "unknown log format",
+			args: []string{"--log-format", "xml"},
+			want: `validate: log.format: unknown format "xml"`,
+		},
+		{
+			name: "unknown exporter",
+			args: []string{"--tracing-exporter", "jaeger"},
+			want: `validate: tracing.exporter: unknown exporter "jaeger"`,
+		},
+		{
+			name: "sample ratio out of range",
+			args: []string{"--tracing-sample-ratio", "2"},
+			want: "validate: tracing.sample_ratio must be between 0 and 1",
+		},
+		{
+			name: "unknown attachment store",
+			args: []string{"--attachments-store", "ftp"},
+			want: `validate: attachments.store: unknown store "ftp"`,
+		},
+		{
+			name: "s3 store without bucket",
+			args: []string{"--attachments-store", "s3", "--attachments-s3-endpoint", "http://localhost:9000"},
+			want: "validate: attachments.s3: endpoint and bucket are required",
+		},
+		{
+			name: "non positive quota",
+			args: []string{"--attachments-quota", "0"},
+			want: "validate: attachments: max_size and quota must be positive",
+		},
+		{
+			name: "non positive event log size",
+			args: []string{"--events-log-size", "0"},
+			want: "validate: events.log_size must be positive",
+		},
+		{
+			name: "non positive snapshot interval",
+			args: []string{"--collab-snapshot-interval", "0s"},
+			want: "validate: collab.snapshot_interval must be positive",
+		},
+		{
+			name: "non positive outbox poll interval",
+			args: []string{"--outbox-poll-interval", "0s"},
+			want: "validate: outbox: poll_interval and retention must be positive",
+		},
+		{
+			name: "webhook timeout above the lease",
+			args: []string{"--webhooks-timeout", "2m"},
+			want: "validate: webhooks.timeout must be between 0 and 1m",
+		},
+		{
+			name: "invalid audit admin",
+			args: []string{"--audit-admins", "00000000-0000-0000-0000-000000000001,rob"},
+			want: `validate: audit.admins: invalid user ID "rob"`,
+		},
+		{
+			name: "non positive audit chain interval",
+			args: []string{"--audit-chain-interval", "0s"},
+			want: "validate: audit.chain_interval must be positive",
+		},
+		{
+			name: "non positive token ttl",
+			args: []string{"--auth-token-ttl", "0s"},
+			want: "validate: auth.token_ttl must be positive",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := config.Load(tc.args)
+			assert.EqualError(t, err, tc.want)
+		})
+	}
+}
+
+func TestConfig_Logger(t *testing.T) {
+	cfg, err := config.Load([]string{"--log-level", "warn", "--log-format", "text"})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	log, err := cfg.Logger(&buf)
+	require.NoError(t, err)
+
+	log.Info("hidden")
+	log.Warn("shown")
+	assert.NotContains(t, buf.String(), "hidden")
+	assert.Contains(t, buf.String(), "level=WARN msg=shown")
+}
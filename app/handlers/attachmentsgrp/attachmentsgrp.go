@@ -0,0 +1,191 @@
+// Package attachmentsgrp uploads and downloads the files attached to notes.
+package attachmentsgrp
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/app/api"
+	"github.com/Keisn1/note-taking-app/domain/core/attachment"
+	"github.com/Keisn1/note-taking-app/domain/web/mid"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+	"github.com/google/uuid"
+)
+
+const (
+	// transferTimeout is how long an upload or download may take. The
+	// server's read and write timeouts would otherwise cut off large files.
+	transferTimeout = 10 * time.Minute
+
+	// multipartOverhead is what the request body may contain on top of the
+	// file: boundaries, part headers and other form fields.
+	multipartOverhead = 64 << 10
+
+	// downloadCSP keeps browsers from running anything embedded in a file,
+	// e.g. scripts in a PDF, in the origin of the API.
+	downloadCSP = "default-src 'none'; style-src 'unsafe-inline'; sandbox"
+)
+
+type Handlers struct {
+	attachmentSvc attachment.Service
+}
+
+func NewHandlers(as attachment.Service) Handlers {
+	return Handlers{attachmentSvc: as}
+}
+
+// Upload stores the file of the multipart/form-data field "file". The body is
+// streamed into the service, it is never held in memory.
+func (hdl Handlers) Upload(w http.ResponseWriter, r *http.Request) {
+	n := mid.GetNote(r.Context())
+	userID := mid.GetUserID(r.Context())
+
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(time.Now().Add(transferTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		web.Logger(r.Context()).Error("upload attachment: set read deadline", "error", err)
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, hdl.attachmentSvc.Limits().MaxSize+multipartOverhead)
+	mr, err := r.MultipartReader()
+	if err != nil {
+		web.Respond(w, http.StatusBadRequest, api.ErrorResponse{Error: "body must be multipart/form-data"})
+		return
+	}
+
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			web.Respond(w, http.StatusBadRequest, api.ErrorResponse{Error: `form field "file" is missing`})
+			return
+		}
+		if err != nil {
+			api.RespondDecodeError(w, r, err)
+			return
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		a, err := hdl.attachmentSvc.Create(r.Context(), n.ID, userID, part.FileName(), part)
+		part.Close()
+		if err != nil {
+			respondError(w, r, "upload attachment", err)
+			return
+		}
+
+		w.Header().Set("Location", "/notes/"+n.ID.String()+"/attachments/"+a.ID.String())
+		web.Respond(w, http.StatusCreated, api.ToAttachmentResponse(a))
+		web.Logger(r.Context()).Info("attachment uploaded", "note_id", n.ID, "attachment_id", a.ID, "size", a.Size)
+		return
+	}
+}
+
+// List returns the attachments of the note, oldest first.
+func (hdl Handlers) List(w http.ResponseWriter, r *http.Request) {
+	n := mid.GetNote(r.Context())
+
+	attachments, err := hdl.attachmentSvc.QueryByNoteID(r.Context(), n.ID)
+	if err != nil {
+		respondError(w, r, "list attachments", err)
+		return
+	}
+	web.Respond(w, http.StatusOK, api.ToAttachmentResponses(attachments))
+}
+
+// Download streams the file. Range requests and conditional requests are
+// served by http.ServeContent; the content of an attachment never changes,
+// so its ID serves as ETag.
+func (hdl Handlers) Download(w http.ResponseWriter, r *http.Request) {
+	a, ok := hdl.attachment(w, r)
+	if !ok {
+		return
+	}
+
+	obj, err := hdl.attachmentSvc.Open(r.Context(), a)
+	if err != nil {
+		respondError(w, r, "download attachment", err)
+		return
+	}
+	defer obj.Close()
+
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Now().Add(transferTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		web.Logger(r.Context()).Error("download attachment: set write deadline", "error", err)
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", a.ContentType)
+	h.Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": a.Filename}))
+	h.Set("Content-Security-Policy", downloadCSP)
+	h.Set("X-Content-Type-Options", "nosniff")
+	h.Set("Cache-Control", "private, max-age=86400")
+	h.Set("ETag", `"`+a.ID.String()+`"`)
+	http.ServeContent(w, r, "", a.CreatedAt, obj)
+}
+
+func (hdl Handlers) Delete(w http.ResponseWriter, r *http.Request) {
+	a, ok := hdl.attachment(w, r)
+	if !ok {
+		return
+	}
+
+	if err := hdl.attachmentSvc.Delete(r.Context(), a); err != nil {
+		respondError(w, r, "delete attachment", err)
+		return
+	}
+
+	web.Respond(w, http.StatusNoContent, nil)
+	web.Logger(r.Context()).Info("attachment deleted", "note_id", a.NoteID, "attachment_id", a.ID)
+}
+
+// attachment returns the attachment of the path. Attachments of other notes
+// are not found, so that access to the note grants access to the attachment.
+func (hdl Handlers) attachment(w http.ResponseWriter, r *http.Request) (attachment.Attachment, bool) {
+	n := mid.GetNote(r.Context())
+
+	id, err := uuid.Parse(r.PathValue("attachment_id"))
+	if err != nil {
+		web.Respond(w, http.StatusNotFound, api.ErrorResponse{Error: attachment.ErrAttachmentNotFound.Error()})
+		return attachment.Attachment{}, false
+	}
+
+	a, err := hdl.attachmentSvc.QueryByID(r.Context(), id)
+	if err == nil && a.NoteID != n.ID {
+		err = attachment.ErrAttachmentNotFound
+	}
+	if err != nil {
+		respondError(w, r, "query attachment", err)
+		return attachment.Attachment{}, false
+	}
+	return a, true
+}
+
+func respondError(w http.ResponseWriter, r *http.Request, op string, err error) {
+	var maxBytesErr *http.MaxBytesError
+
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, attachment.ErrAttachmentNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, attachment.ErrTooLarge), errors.Is(err, attachment.ErrQuotaExceeded):
+		status = http.StatusRequestEntityTooLarge
+	case errors.As(err, &maxBytesErr):
+		status, err = http.StatusRequestEntityTooLarge, attachment.ErrTooLarge
+	case errors.Is(err, attachment.ErrUnsupportedType):
+		status = http.StatusUnsupportedMediaType
+	case errors.Is(err, attachment.ErrEmpty):
+		status = http.StatusUnprocessableEntity
+	}
+
+	if status == http.StatusInternalServerError {
+		web.Respond(w, status, api.ErrorResponse{Error: http.StatusText(status)})
+		web.Logger(r.Context()).Error(op, "error", err)
+		return
+	}
+	web.Respond(w, status, api.ErrorResponse{Error: err.Error()})
+	web.Logger(r.Context()).Info(op, "error", err)
+}
@@ -0,0 +1,267 @@
+package attachmentsgrp_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"errors"
+	"github.com/Keisn1/note-taking-app/app/api"
+	"github.com/Keisn1/note-taking-app/app/handlers/attachmentsgrp"
+	"github.com/Keisn1/note-taking-app/domain/core/attachment"
+	attachmentmemory "github.com/Keisn1/note-taking-app/domain/core/attachment/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	notememory "github.com/Keisn1/note-taking-app/domain/core/note/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	usermemory "github.com/Keisn1/note-taking-app/domain/core/user/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/web/auth"
+	"github.com/Keisn1/note-taking-app/domain/web/mux"
+	"github.com/Keisn1/note-taking-app/foundation/blobstore"
+	"github.com/Keisn1/note-taking-app/foundation/common"
+	"github.com/Keisn1/note-taking-app/foundation/transaction"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var pdf = []byte("%PDF-1.4\n1 0 obj << /Type /Catalog >> endobj\ntrailer << /Root 1 0 R >>\n%%EOF\n")
+
+func multipartBody(t *testing.T, field, filename string, content []byte) (*bytes.Buffer, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	require.NoError(t, mw.WriteField("description", "ignored"))
+	fw, err := mw.CreateFormFile(field, filename)
+	require.NoError(t, err)
+	_, err = fw.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+	return &buf, mw.FormDataContentType()
+}
+
+func Test_Attachments(t *testing.T) {
+	rob := user.User{ID: uuid.UUID{1}, Name: user.NewName("rob"), Email: user.NewEmail("rob@example.com")}
+	anna := user.User{ID: uuid.UUID{2}, Name: user.NewName("anna"), Email: user.NewEmail("anna@example.com")}
+	notes := []note.Note{
+		{ID: uuid.UUID{11}, Title: note.NewTitle("Trip"), Content: note.NewContent(""), Format: note.FormatPlain, UserID: rob.ID},
+		{ID: uuid.UUID{12}, Title: note.NewTitle("Taxes"), Content: note.NewContent(""), Format: note.FormatPlain, UserID: rob.ID},
+		{ID: uuid.UUID{13}, Title: note.NewTitle("Anna's note"), Content: note.NewContent(""), Format: note.FormatPlain, UserID: anna.ID},
+	}
+
+	store, err := blobstore.NewFS(t.TempDir())
+	require.NoError(t, err)
+	attachmentSvc := attachment.NewSvc(attachmentmemory.NewRepo(), store, attachment.Limits{MaxSize: 1 << 10, Quota: 1 << 20})
+
+	jwtSvc := auth.MustNewJWTService(common.MustGenerateRandomKey(32))
+	userSvc := user.NewSvc(usermemory.NewRepo([]user.User{rob, anna}))
+	noteSvc := note.NewNotesService(notememory.MustNewRepo(notes), userSvc, note.WithDeleteHook(attachmentSvc.DeleteByNoteID))
+	app := mux.NewAPI(attachmentsgrp.Routes, mux.Config{Auth: auth.NewAuth(jwtSvc), NoteSvc: noteSvc, UserSvc: userSvc, AttachmentSvc: attachmentSvc})
+
+	robToken, err := jwtSvc.CreateToken(rob.ID, time.Minute)
+	require.NoError(t, err)
+
+	do := func(method, target string, body io.Reader, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, body)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		req.Header.Set("Authorization", "Bearer "+robToken)
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, req)
+		return rr
+	}
+	upload := func(noteID uuid.UUID, field, filename string, content []byte) *httptest.ResponseRecorder {
+		body, contentType := multipartBody(t, field, filename, content)
+		return do(http.MethodPost, "/notes/"+noteID.String()+"/attachments", body, http.Header{"Content-Type": {contentType}})
+	}
+
+	rr := upload(notes[0].ID, "file", "scan.pdf", pdf)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var created api.AttachmentResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	assert.Equal(t, "scan.pdf", created.Filename)
+	assert.Equal(t, "application/pdf", created.ContentType)
+	assert.Equal(t, int64(len(pdf)), created.Size)
+	target := "/notes/" + notes[0].ID.String() + "/attachments/" + created.ID.String()
+	assert.Equal(t, target, rr.Header().Get("Location"))
+
+	t.Run("List", func(t *testing.T) {
+		rr := do(http.MethodGet, "/notes/"+notes[0].ID.String()+"/attachments", nil, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var got []api.AttachmentResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+		assert.Equal(t, []api.AttachmentResponse{created}, got)
+
+		rr = do(http.MethodGet, "/notes/"+notes[1].ID.String()+"/attachments", nil, nil)
+		assert.JSONEq(t, `[]`, rr.Body.String())
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		rr := do(http.MethodGet, target, nil, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, pdf, rr.Body.Bytes())
+		assert.Equal(t, "application/pdf", rr.Header().Get("Content-Type"))
+		assert.Equal(t, "inline; filename=scan.pdf", rr.Header().Get("Content-Disposition"))
+		assert.Equal(t, "nosniff", rr.Header().Get("X-Content-Type-Options"))
+		assert.Contains(t, rr.Header().Get("Content-Security-Policy"), "sandbox")
+		assert.Equal(t, "bytes", rr.Header().Get("Accept-Ranges"))
+
+		rr = do(http.MethodGet, target, nil, http.Header{"Range": {"bytes=5-7"}})
+		require.Equal(t, http.StatusPartialContent, rr.Code)
+		assert.Equal(t, pdf[5:8], rr.Body.Bytes())
+		assert.Equal(t, "bytes 5-7/"+strconv.Itoa(len(pdf)), rr.Header().Get("Content-Range"))
+
+		rr = do(http.MethodGet, target, nil, http.Header{"If-None-Match": {`"` + created.ID.String() + `"`}})
+		assert.Equal(t, http.StatusNotModified, rr.Code)
+	})
+
+	t.Run("Attachments of other notes are not found", func(t *testing.T) {
+		rr := do(http.MethodGet, "/notes/"+notes[1].ID.String()+"/attachments/"+created.ID.String(), nil, nil)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		rr = do(http.MethodGet, "/notes/"+notes[0].ID.String()+"/attachments/not-a-uuid", nil, nil)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Notes of other users are forbidden", func(t *testing.T) {
+		rr := upload(notes[2].ID, "file", "scan.pdf", pdf)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("Invalid uploads", func(t *testing.T) {
+		testCases := []struct {
+			name    string
+			field   string
+			content []byte
+			status  int
+		}{
+			{name: "unsupported type", field: "file", content: []byte("<html><script>alert(1)</script></html>"), status: http.StatusUnsupportedMediaType},
+			{name: "empty file", field: "file", content: nil, status: http.StatusUnprocessableEntity},
+			{name: "too large", field: "file", content: append(bytes.Clone(pdf), make([]byte, 1<<10)...), status: http.StatusRequestEntityTooLarge},
+			{name: "missing file field", field: "upload", content: pdf, status: http.StatusBadRequest},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				rr := upload(notes[0].ID, tc.field, "x.pdf", tc.content)
+				assert.Equal(t, tc.status, rr.Code, rr.Body.String())
+			})
+		}
+
+		rr := do(http.MethodPost, "/notes/"+notes[0].ID.String()+"/attachments", bytes.NewReader(pdf), http.Header{"Content-Type": {"application/pdf"}})
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		rr := upload(notes[1].ID, "file", "receipt.pdf", pdf)
+		require.Equal(t, http.StatusCreated, rr.Code)
+		var a api.AttachmentResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &a))
+
+		target := "/notes/" + notes[1].ID.String() + "/attachments/" + a.ID.String()
+		rr = do(http.MethodDelete, target, nil, nil)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		rr = do(http.MethodGet, target, nil, nil)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Deleting the note deletes its attachments", func(t *testing.T) {
+		require.NoError(t, noteSvc.Delete(context.Background(), notes[0].ID))
+
+		got, err := attachmentSvc.QueryByNoteID(context.Background(), notes[0].ID)
+		require.NoError(t, err)
+		assert.Empty(t, got)
+		usage, err := attachmentSvc.Usage(context.Background(), rob.ID)
+		require.NoError(t, err)
+		assert.Zero(t, usage)
+	})
+}
+
+func Test_Attachments_DeleteNote(t *testing.T) {
+	rob := user.User{ID: uuid.UUID{1}, Name: user.NewName("rob"), Email: user.NewEmail("rob@example.com")}
+	n := note.Note{ID: uuid.UUID{11}, Title: note.NewTitle("Trip"), Content: note.NewContent(""), Format: note.FormatPlain, UserID: rob.ID}
+
+	store, err := blobstore.NewFS(t.TempDir())
+	require.NoError(t, err)
+	attachmentRepo, noteRepo := attachmentmemory.NewRepo(), notememory.MustNewRepo([]note.Note{n})
+	attachmentSvc := attachment.NewSvc(attachmentRepo, store, attachment.Limits{})
+
+	failing := true
+	userSvc := user.NewSvc(usermemory.NewRepo([]user.User{rob}))
+	noteSvc := note.NewNotesService(noteRepo, userSvc,
+		note.WithTransactions(transaction.NewMemory(noteRepo, attachmentRepo)),
+		note.WithDeleteHook(attachmentSvc.DeleteByNoteID),
+		note.WithEventHook(func(ctx context.Context, e note.Event) error {
+			if failing {
+				return errors.New("event hook failed")
+			}
+			return nil
+		}),
+	)
+	jwtSvc := auth.MustNewJWTService(common.MustGenerateRandomKey(32))
+	app := mux.NewAPI(attachmentsgrp.Routes, mux.Config{Auth: auth.NewAuth(jwtSvc), NoteSvc: noteSvc, UserSvc: userSvc, AttachmentSvc: attachmentSvc})
+
+	tokenS, err := jwtSvc.CreateToken(rob.ID, time.Minute)
+	require.NoError(t, err)
+	download := func(id uuid.UUID) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/notes/"+n.ID.String()+"/attachments/"+id.String(), nil)
+		req.Header.Set("Authorization", "Bearer "+tokenS)
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, req)
+		return rr
+	}
+
+	a, err := attachmentSvc.Create(context.Background(), n.ID, rob.ID, "scan.pdf", bytes.NewReader(pdf))
+	require.NoError(t, err)
+
+	t.Run("A failed deletion leaves the attachments downloadable", func(t *testing.T) {
+		assert.Error(t, noteSvc.Delete(context.Background(), n.ID))
+
+		rr := download(a.ID)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, pdf, rr.Body.Bytes())
+	})
+
+	t.Run("The content is deleted after the commit", func(t *testing.T) {
+		failing = false
+		require.NoError(t, noteSvc.Delete(context.Background(), n.ID))
+
+		_, err := attachmentSvc.Open(context.Background(), a)
+		assert.ErrorIs(t, err, attachment.ErrAttachmentNotFound)
+	})
+}
+
+func Test_Attachments_Quota(t *testing.T) {
+	rob := user.User{ID: uuid.UUID{1}, Name: user.NewName("rob"), Email: user.NewEmail("rob@example.com")}
+	n := note.Note{ID: uuid.UUID{11}, Title: note.NewTitle("Trip"), Content: note.NewContent(""), Format: note.FormatPlain, UserID: rob.ID}
+
+	store, err := blobstore.NewFS(t.TempDir())
+	require.NoError(t, err)
+	attachmentSvc := attachment.NewSvc(attachmentmemory.NewRepo(), store, attachment.Limits{MaxSize: 1 << 10, Quota: int64(len(pdf)) + 10})
+
+	jwtSvc := auth.MustNewJWTService(common.MustGenerateRandomKey(32))
+	userSvc := user.NewSvc(usermemory.NewRepo([]user.User{rob}))
+	noteSvc := note.NewNotesService(notememory.MustNewRepo([]note.Note{n}), userSvc)
+	app := mux.NewAPI(attachmentsgrp.Routes, mux.Config{Auth: auth.NewAuth(jwtSvc), NoteSvc: noteSvc, UserSvc: userSvc, AttachmentSvc: attachmentSvc})
+
+	tokenS, err := jwtSvc.CreateToken(rob.ID, time.Minute)
+	require.NoError(t, err)
+
+	statuses := make([]int, 2)
+	for i := range statuses {
+		body, contentType := multipartBody(t, "file", "scan.pdf", pdf)
+		req := httptest.NewRequest(http.MethodPost, "/notes/"+n.ID.String()+"/attachments", body)
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("Authorization", "Bearer "+tokenS)
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, req)
+		statuses[i] = rr.Code
+	}
+	assert.Equal(t, []int{http.StatusCreated, http.StatusRequestEntityTooLarge}, statuses)
+}
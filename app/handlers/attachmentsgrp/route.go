@@ -0,0 +1,19 @@
+package attachmentsgrp
+
+import (
+	"github.com/Keisn1/note-taking-app/domain/web/mid"
+	"github.com/Keisn1/note-taking-app/domain/web/mux"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+)
+
+// Routes adds the attachment routes to the app. All of them require that the
+// note belongs to the user.
+func Routes(app *web.App, cfg mux.Config) {
+	hdl := NewHandlers(cfg.AttachmentSvc)
+
+	attachments := app.Group("/notes/{note_id}/attachments", mid.Authenticate(cfg.Auth, cfg.UserSvc), mid.AuthorizeNote(cfg.NoteSvc))
+	attachments.Post("", hdl.Upload)
+	attachments.Get("", hdl.List)
+	attachments.Get("/{attachment_id}", hdl.Download)
+	attachments.Delete("/{attachment_id}", hdl.Delete)
+}
@@ -0,0 +1,38 @@
+// Package auditgrp lets admins query the audit trail.
+package auditgrp
+
+import (
+	"net/http"
+
+	"github.com/Keisn1/note-taking-app/app/api"
+	"github.com/Keisn1/note-taking-app/domain/core/audit"
+	"github.com/Keisn1/note-taking-app/domain/web/mid"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+)
+
+type Handlers struct {
+	auditSvc audit.Service
+}
+
+func NewHandlers(as audit.Service) Handlers {
+	return Handlers{auditSvc: as}
+}
+
+// Query returns the entries of the audit trail that match the query
+// parameters, newest first. Queries are recorded themselves.
+func (hdl Handlers) Query(w http.ResponseWriter, r *http.Request) {
+	f, err := api.ParseAuditFilter(r)
+	if err != nil {
+		web.Respond(w, http.StatusBadRequest, api.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	p, err := hdl.auditSvc.Query(r.Context(), f)
+	if err != nil {
+		web.Respond(w, http.StatusInternalServerError, api.ErrorResponse{Error: http.StatusText(http.StatusInternalServerError)})
+		web.Logger(r.Context()).Error("query audit trail", "error", err)
+		return
+	}
+	web.Respond(w, http.StatusOK, api.ToAuditResponse(p))
+	mid.RecordAudit(r.Context(), mid.GetUserID(r.Context()), audit.ActionAuditQueried, audit.PathTarget(r.URL.RequestURI()))
+}
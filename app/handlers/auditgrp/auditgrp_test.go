@@ -0,0 +1,100 @@
+package auditgrp_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/app/api"
+	"github.com/Keisn1/note-taking-app/app/handlers/auditgrp"
+	"github.com/Keisn1/note-taking-app/domain/core/audit"
+	auditmemory "github.com/Keisn1/note-taking-app/domain/core/audit/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	usermemory "github.com/Keisn1/note-taking-app/domain/core/user/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/web/auth"
+	"github.com/Keisn1/note-taking-app/domain/web/mux"
+	"github.com/Keisn1/note-taking-app/foundation/common"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Audit(t *testing.T) {
+	admin, rob := uuid.New(), uuid.New()
+	auditSvc := audit.NewSvc(auditmemory.NewRepo())
+	for _, e := range []audit.Entry{
+		{Actor: uuid.Nil, Action: audit.ActionLoginFailed, Target: audit.EmailTarget("rob@example.com")},
+		{Actor: rob, Action: audit.ActionLogin, Target: audit.UserTarget(rob)},
+		{Actor: rob, Action: audit.ActionNoteCreated, Target: audit.NoteTarget(uuid.UUID{1})},
+	} {
+		_, err := auditSvc.Record(context.Background(), e)
+		require.NoError(t, err)
+	}
+
+	jwtSvc := auth.MustNewJWTService(common.MustGenerateRandomKey(32))
+	userSvc := user.NewSvc(usermemory.NewRepo([]user.User{{ID: admin}, {ID: rob}}))
+	app := mux.NewAPI(auditgrp.Routes, mux.Config{Auth: auth.NewAuth(jwtSvc), UserSvc: userSvc, AuditSvc: auditSvc, AuditAdmins: []uuid.UUID{admin}})
+
+	call := func(target string, userID uuid.UUID) *httptest.ResponseRecorder {
+		token, err := jwtSvc.CreateToken(userID, time.Minute)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, req)
+		return rr
+	}
+	query := func(target string) api.AuditResponse {
+		t.Helper()
+		rr := call(target, admin)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var got api.AuditResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+		return got
+	}
+
+	t.Run("Admins query the trail by actor and target, newest first", func(t *testing.T) {
+		got := query("/audit?actor=" + rob.String() + "&limit=1")
+		require.Len(t, got.Entries, 1)
+		assert.Equal(t, audit.ActionNoteCreated, got.Entries[0].Action)
+		assert.True(t, got.HasMore)
+
+		got = query("/audit?actor=" + rob.String() + "&before=3")
+		require.Len(t, got.Entries, 1)
+		assert.Equal(t, audit.ActionLogin, got.Entries[0].Action)
+		assert.False(t, got.HasMore)
+
+		got = query("/audit?target=email:rob@example.com")
+		require.Len(t, got.Entries, 1)
+		assert.Nil(t, got.Entries[0].Actor)
+	})
+
+	hourAgo := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	t.Run("Entries are filtered by time", func(t *testing.T) {
+		got := query("/audit?to=" + hourAgo)
+		assert.Empty(t, got.Entries)
+
+		got = query("/audit?from=" + hourAgo + "&action=user.login")
+		assert.Len(t, got.Entries, 1)
+	})
+
+	t.Run("Queries are recorded", func(t *testing.T) {
+		got := query("/audit?action=audit.queried&limit=1")
+		require.Len(t, got.Entries, 1)
+		assert.Equal(t, admin, *got.Entries[0].Actor)
+		assert.Equal(t, audit.PathTarget("/audit?from="+hourAgo+"&action=user.login"), got.Entries[0].Target)
+	})
+
+	t.Run("Invalid filters are rejected", func(t *testing.T) {
+		for _, target := range []string{"/audit?actor=rob", "/audit?from=yesterday", "/audit?before=0", "/audit?limit=501"} {
+			assert.Equal(t, http.StatusBadRequest, call(target, admin).Code, target)
+		}
+	})
+
+	t.Run("Other users are denied", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, call("/audit", rob).Code)
+	})
+}
@@ -0,0 +1,15 @@
+package auditgrp
+
+import (
+	"github.com/Keisn1/note-taking-app/domain/web/mid"
+	"github.com/Keisn1/note-taking-app/domain/web/mux"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+)
+
+// Routes adds the audit routes to the app. Only the configured admins may
+// use them.
+func Routes(app *web.App, cfg mux.Config) {
+	hdl := NewHandlers(cfg.AuditSvc)
+
+	app.Get("/audit", hdl.Query, mid.Authenticate(cfg.Auth, cfg.UserSvc), mid.AuthorizeAdmin(cfg.AuditAdmins))
+}
@@ -0,0 +1,76 @@
+package checkgrp
+
+import (
+	"net/http"
+	"runtime/debug"
+
+	"github.com/Keisn1/note-taking-app/foundation/health"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+)
+
+type Handlers struct {
+	readiness *health.Readiness
+}
+
+func NewHandlers(rd *health.Readiness) Handlers {
+	return Handlers{readiness: rd}
+}
+
+// Liveness reports that the process is alive and able to serve requests.
+func (hdl Handlers) Liveness(w http.ResponseWriter, r *http.Request) {
+	web.Respond(w, http.StatusOK, map[string]string{"status": health.StatusOK})
+}
+
+// Readiness reports whether the service can handle traffic. It answers with
+// 503 if a dependency check fails or the service is shutting down.
+func (hdl Handlers) Readiness(w http.ResponseWriter, r *http.Request) {
+	report := hdl.readiness.Check(r.Context())
+
+	status := http.StatusOK
+	if report.Status != health.StatusOK {
+		status = http.StatusServiceUnavailable
+		log := web.Logger(r.Context())
+		for name, res := range report.Checks {
+			if res.Err != nil {
+				log.Warn("readiness check failed", "check", name, "error", res.Err)
+			}
+		}
+		log.Warn("not ready", "status", report.Status)
+	}
+	web.Respond(w, status, report)
+}
+
+type versionResponse struct {
+	Module    string `json:"module"`
+	Version   string `json:"version"`
+	GoVersion string `json:"go_version"`
+	Revision  string `json:"revision,omitempty"`
+	Time      string `json:"time,omitempty"`
+	Modified  bool   `json:"modified"`
+}
+
+// Version reports the build information embedded by the go tool.
+func (hdl Handlers) Version(w http.ResponseWriter, r *http.Request) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		web.Respond(w, http.StatusInternalServerError, map[string]string{"error": "build info not available"})
+		return
+	}
+
+	resp := versionResponse{
+		Module:    info.Main.Path,
+		Version:   info.Main.Version,
+		GoVersion: info.GoVersion,
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			resp.Revision = s.Value
+		case "vcs.time":
+			resp.Time = s.Value
+		case "vcs.modified":
+			resp.Modified = s.Value == "true"
+		}
+	}
+	web.Respond(w, http.StatusOK, resp)
+}
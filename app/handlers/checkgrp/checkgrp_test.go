@@ -0,0 +1,103 @@
+package checkgrp_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/app/handlers/checkgrp"
+	"github.com/Keisn1/note-taking-app/domain/web/mux"
+	"github.com/Keisn1/note-taking-app/foundation/health"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Liveness(t *testing.T) {
+	api := mux.NewAPI(checkgrp.Routes, mux.Config{})
+
+	rr := httptest.NewRecorder()
+	api.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, rr.Body.String())
+}
+
+func Test_Readiness(t *testing.T) {
+	var dbErr error
+	readiness := health.NewReadiness(time.Second,
+		health.Check{Name: "postgres", Check: func(ctx context.Context) error { return dbErr }},
+		health.Check{Name: "signing_keys", Check: func(ctx context.Context) error { return nil }},
+	)
+	api := mux.NewAPI(checkgrp.Routes, mux.Config{Readiness: readiness})
+
+	testCases := []struct {
+		name       string
+		setup      func()
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "all checks pass",
+			setup:      func() {},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"status":"ok","checks":{"postgres":{"status":"ok"},"signing_keys":{"status":"ok"}}}`,
+		},
+		{
+			name:       "failing dependency",
+			setup:      func() { dbErr = errors.New("connection refused") },
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   `{"status":"failing","checks":{"postgres":{"status":"failing"},"signing_keys":{"status":"ok"}}}`,
+		},
+		{
+			name:       "draining during shutdown",
+			setup:      func() { dbErr = nil; readiness.Drain() },
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   `{"status":"draining","checks":{"postgres":{"status":"ok"},"signing_keys":{"status":"ok"}}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.setup()
+			rr := httptest.NewRecorder()
+			api.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			assert.Equal(t, tc.wantStatus, rr.Code)
+			assert.JSONEq(t, tc.wantBody, rr.Body.String())
+		})
+	}
+}
+
+func Test_Readiness_Timeout(t *testing.T) {
+	readiness := health.NewReadiness(10*time.Millisecond,
+		health.Check{Name: "slow", Check: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+	)
+	var logBuf bytes.Buffer
+	api := mux.NewAPI(checkgrp.Routes, mux.Config{Readiness: readiness, Log: slog.New(slog.NewTextHandler(&logBuf, nil))})
+
+	rr := httptest.NewRecorder()
+	api.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.JSONEq(t, `{"status":"failing","checks":{"slow":{"status":"failing"}}}`, rr.Body.String())
+	assert.Contains(t, logBuf.String(), "check=slow error=\"context deadline exceeded\"")
+}
+
+func Test_Version(t *testing.T) {
+	api := mux.NewAPI(checkgrp.Routes, mux.Config{})
+
+	rr := httptest.NewRecorder()
+	api.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/version", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var got map[string]any
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	assert.Contains(t, got, "go_version")
+	assert.Contains(t, got, "module")
+	assert.Contains(t, got, "modified")
+}
@@ -0,0 +1,20 @@
+package checkgrp
+
+import (
+	"github.com/Keisn1/note-taking-app/domain/web/mux"
+	"github.com/Keisn1/note-taking-app/foundation/health"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+)
+
+// Routes adds the probe and build information routes to the app.
+func Routes(app *web.App, cfg mux.Config) {
+	rd := cfg.Readiness
+	if rd == nil {
+		rd = health.NewReadiness(0)
+	}
+	hdl := NewHandlers(rd)
+
+	app.Get("/healthz", hdl.Liveness)
+	app.Get("/readyz", hdl.Readiness)
+	app.Get("/version", hdl.Version)
+}
@@ -0,0 +1,220 @@
+// Package collabgrp serves the collaborative editing of notes over
+// WebSockets, see package collab.
+package collabgrp
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/app/api"
+	"github.com/Keisn1/note-taking-app/domain/core/collab"
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/Keisn1/note-taking-app/domain/web/mid"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// Protocol is the WebSocket subprotocol of the editing protocol.
+	Protocol = "notes-collab"
+
+	// tokenProtocolPrefix marks a token sent as subprotocol, browsers can't
+	// set the Authorization header of WebSockets.
+	tokenProtocolPrefix = "bearer."
+
+	// idleTimeout is how long a client may stay silent, clients ping to
+	// keep the connection open.
+	idleTimeout    = 60 * time.Second
+	writeTimeout   = 10 * time.Second
+	leaveTimeout   = 10 * time.Second
+	maxMessageSize = 1 << 20
+)
+
+var errTokenExpired = errors.New("token expired")
+
+type Handlers struct {
+	hub     *collab.Hub
+	origins []string
+}
+
+func NewHandlers(hub *collab.Hub, origins []string) Handlers {
+	return Handlers{hub: hub, origins: origins}
+}
+
+// Edit joins the editing session of the note and upgrades the connection to
+// a WebSocket that carries api.CollabMessages. The connection is closed when
+// the token expires.
+func (hdl Handlers) Edit(w http.ResponseWriter, r *http.Request) {
+	n := mid.GetNote(r.Context())
+	userID := mid.GetUserID(r.Context())
+
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		web.Respond(w, http.StatusBadRequest, api.ErrorResponse{Error: "WebSocket upgrade required"})
+		return
+	}
+
+	c, err := hdl.hub.Join(r.Context(), n.ID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, note.ErrNoteNotFound):
+			web.Respond(w, http.StatusNotFound, api.ErrorResponse{Error: "note not found"})
+		case errors.Is(err, collab.ErrClosed):
+			web.Respond(w, http.StatusServiceUnavailable, api.ErrorResponse{Error: "the server is shutting down"})
+		default:
+			web.Respond(w, http.StatusInternalServerError, api.ErrorResponse{Error: http.StatusText(http.StatusInternalServerError)})
+			web.Logger(r.Context()).Error("collab: join", "note_id", n.ID, "error", err)
+		}
+		return
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), leaveTimeout)
+		defer cancel()
+		if err := c.Leave(ctx); err != nil {
+			web.Logger(r.Context()).Error("collab: leave", "note_id", n.ID, "error", err)
+		}
+	}()
+
+	srv := websocket.Server{
+		Handshake: hdl.handshake,
+		Handler:   func(ws *websocket.Conn) { hdl.serve(ws, r, c) },
+	}
+	srv.ServeHTTP(w, r)
+}
+
+// handshake checks the origin of browsers and selects Protocol if the client
+// offers it.
+func (hdl Handlers) handshake(cfg *websocket.Config, r *http.Request) error {
+	if origin := r.Header.Get("Origin"); origin != "" && !hdl.allowedOrigin(origin, r.Host) {
+		return fmt.Errorf("origin %q not allowed", origin)
+	}
+	if slices.Contains(cfg.Protocol, Protocol) {
+		cfg.Protocol = []string{Protocol}
+	} else {
+		cfg.Protocol = nil
+	}
+	return nil
+}
+
+// allowedOrigin allows the origin of the API itself and the CORS origins.
+func (hdl Handlers) allowedOrigin(origin, host string) bool {
+	if slices.Contains(hdl.origins, "*") || slices.Contains(hdl.origins, origin) {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == host
+}
+
+func (hdl Handlers) serve(ws *websocket.Conn, r *http.Request, c *collab.Client) {
+	log := web.Logger(r.Context()).With("note_id", mid.GetNote(r.Context()).ID, "site", c.Site)
+	defer ws.Close()
+
+	ws.MaxPayloadBytes = maxMessageSize
+	// The deadlines the server set for the request don't apply to the
+	// connection.
+	ws.SetDeadline(time.Time{})
+
+	send := func(m api.CollabMessage) error {
+		if err := ws.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+			return err
+		}
+		return websocket.JSON.Send(ws, m)
+	}
+	sendError := func(err error) {
+		send(api.CollabMessage{Type: collab.UpdateError, Error: err.Error()})
+	}
+
+	received := make(chan error, 1)
+	go func() {
+		received <- receive(ws, c, send)
+	}()
+
+	var expired <-chan time.Time
+	if exp := mid.GetClaims(r.Context()).ExpiresAt; exp != nil {
+		t := time.NewTimer(time.Until(exp.Time))
+		defer t.Stop()
+		expired = t.C
+	}
+
+	var err error
+	for err == nil {
+		select {
+		case u, ok := <-c.Updates():
+			switch {
+			case !ok:
+				err = cmp.Or(c.Err(), collab.ErrClosed)
+				sendError(err)
+			case u.Type == collab.UpdateError:
+				log.Error("collab: save", "error", u.Err)
+				fallthrough
+			default:
+				err = send(api.ToCollabMessage(u))
+			}
+
+		case err = <-received:
+			if err != nil {
+				sendError(err)
+			}
+
+		case <-expired:
+			err = errTokenExpired
+			sendError(err)
+		}
+	}
+	log.Info("collab: connection closed", "reason", err)
+}
+
+// receive handles the messages of the client until the connection fails or
+// a message is invalid.
+func receive(ws *websocket.Conn, c *collab.Client, send func(api.CollabMessage) error) error {
+	for {
+		if err := ws.SetReadDeadline(time.Now().Add(idleTimeout)); err != nil {
+			return err
+		}
+		var m api.CollabMessage
+		if err := websocket.JSON.Receive(ws, &m); err != nil {
+			return err
+		}
+
+		switch m.Type {
+		case collab.UpdateOps:
+			if err := c.Apply(m.Ops); err != nil {
+				return err
+			}
+		case collab.UpdateCursor:
+			if m.Cursor == nil {
+				return errors.New("cursor: cursor is required")
+			}
+			c.MoveCursor(m.Cursor.ToCursor())
+		case api.CollabPing:
+			if err := send(api.CollabMessage{Type: api.CollabPong}); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown message type %q", m.Type)
+		}
+	}
+}
+
+// tokenFromProtocol moves a token offered as subprotocol "bearer.<token>" to
+// the Authorization header, for mid.Authenticate.
+func tokenFromProtocol(next http.Handler) http.Handler {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			for _, p := range strings.Split(r.Header.Get("Sec-WebSocket-Protocol"), ",") {
+				if token, ok := strings.CutPrefix(strings.TrimSpace(p), tokenProtocolPrefix); ok {
+					r.Header.Set("Authorization", "Bearer "+token)
+					break
+				}
+			}
+		}
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(h)
+}
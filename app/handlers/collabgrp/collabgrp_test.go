@@ -0,0 +1,156 @@
+package collabgrp_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/app/api"
+	"github.com/Keisn1/note-taking-app/app/handlers/collabgrp"
+	"github.com/Keisn1/note-taking-app/domain/core/collab"
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	notememory "github.com/Keisn1/note-taking-app/domain/core/note/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	usermemory "github.com/Keisn1/note-taking-app/domain/core/user/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/web/auth"
+	"github.com/Keisn1/note-taking-app/domain/web/mux"
+	"github.com/Keisn1/note-taking-app/foundation/common"
+	"github.com/Keisn1/note-taking-app/foundation/crdt"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+func Test_Edit(t *testing.T) {
+	ctx := context.Background()
+	rob := user.User{ID: uuid.UUID{1}, Name: user.NewName("rob"), Email: user.NewEmail("rob@example.com")}
+	anna := user.User{ID: uuid.UUID{2}, Name: user.NewName("anna"), Email: user.NewEmail("anna@example.com")}
+
+	userSvc := user.NewSvc(usermemory.NewRepo([]user.User{rob, anna}))
+	noteSvc := note.NewNotesService(notememory.MustNewRepo(nil), userSvc)
+	n, err := noteSvc.Create(ctx, note.UpdateNote{Title: note.NewTitle("Groceries"), Content: note.NewContent("milk"), UserID: rob.ID})
+	require.NoError(t, err)
+	hub := collab.NewHub(noteSvc, time.Hour)
+
+	jwtSvc := auth.MustNewJWTService(common.MustGenerateRandomKey(32))
+	srv := httptest.NewServer(mux.NewAPI(collabgrp.Routes, mux.Config{
+		Auth:        auth.NewAuth(jwtSvc),
+		NoteSvc:     noteSvc,
+		UserSvc:     userSvc,
+		Collab:      hub,
+		CORSOrigins: []string{"https://notes.example.com"},
+	}))
+	defer srv.Close()
+
+	robToken, err := jwtSvc.CreateToken(rob.ID, time.Minute)
+	require.NoError(t, err)
+	annaToken, err := jwtSvc.CreateToken(anna.ID, time.Minute)
+	require.NoError(t, err)
+
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/notes/" + n.ID.String() + "/collab"
+
+	dial := func(t *testing.T, token, origin string) (*websocket.Conn, error) {
+		cfg, err := websocket.NewConfig(wsURL, origin)
+		require.NoError(t, err)
+		cfg.Protocol = []string{collabgrp.Protocol, "bearer." + token}
+		return websocket.DialConfig(cfg)
+	}
+	receive := func(t *testing.T, ws *websocket.Conn) api.CollabMessage {
+		t.Helper()
+		require.NoError(t, ws.SetReadDeadline(time.Now().Add(time.Second)))
+		var m api.CollabMessage
+		require.NoError(t, websocket.JSON.Receive(ws, &m))
+		return m
+	}
+
+	t.Run("Clients edit the note together", func(t *testing.T) {
+		alice, err := dial(t, robToken, "https://notes.example.com")
+		require.NoError(t, err)
+		defer alice.Close()
+		assert.Equal(t, collabgrp.Protocol, alice.Config().Protocol[0])
+
+		init := receive(t, alice)
+		assert.Equal(t, collab.UpdateInit, init.Type)
+		doc := crdt.FromText(collab.ServerSite, "milk")
+		assert.Equal(t, doc.Elements(), init.Doc)
+
+		bob, err := dial(t, robToken, srv.URL)
+		require.NoError(t, err)
+		defer bob.Close()
+		bobInit := receive(t, bob)
+		require.Len(t, bobInit.Peers, 1)
+		assert.Equal(t, init.Site, bobInit.Peers[0].Site)
+		assert.Equal(t, collab.UpdateJoin, receive(t, alice).Type)
+
+		ops := doc.Insert(init.Site, doc.Visible()[3], " and eggs")
+		require.NoError(t, websocket.JSON.Send(alice, api.CollabMessage{Type: collab.UpdateOps, Ops: ops}))
+		m := receive(t, bob)
+		assert.Equal(t, collab.UpdateOps, m.Type)
+		assert.Equal(t, init.Site, m.Site)
+		assert.Equal(t, ops, m.Ops)
+
+		head := ops[0].ID
+		require.NoError(t, websocket.JSON.Send(alice, api.CollabMessage{Type: collab.UpdateCursor, Cursor: &api.CollabCursor{Head: &head}}))
+		m = receive(t, bob)
+		assert.Equal(t, collab.UpdateCursor, m.Type)
+		assert.Equal(t, &api.CollabCursor{Head: &head}, m.Cursor)
+
+		require.NoError(t, websocket.JSON.Send(bob, api.CollabMessage{Type: api.CollabPing}))
+		assert.Equal(t, api.CollabPong, receive(t, bob).Type)
+
+		require.NoError(t, websocket.JSON.Send(bob, api.CollabMessage{Type: "shout"}))
+		m = receive(t, bob)
+		assert.Equal(t, collab.UpdateError, m.Type)
+		assert.Contains(t, m.Error, "unknown message type")
+		assert.Equal(t, collab.UpdateLeave, receive(t, alice).Type)
+
+		require.NoError(t, alice.Close())
+		assert.Eventually(t, func() bool {
+			n, err := noteSvc.QueryByID(ctx, n.ID)
+			return err == nil && n.Content.String() == "milk and eggs"
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("Clients are closed when the hub closes", func(t *testing.T) {
+		hub := collab.NewHub(noteSvc, time.Hour)
+		srv := httptest.NewServer(mux.NewAPI(collabgrp.Routes, mux.Config{Auth: auth.NewAuth(jwtSvc), NoteSvc: noteSvc, UserSvc: userSvc, Collab: hub}))
+		defer srv.Close()
+
+		cfg, err := websocket.NewConfig("ws"+strings.TrimPrefix(srv.URL, "http")+"/notes/"+n.ID.String()+"/collab", srv.URL)
+		require.NoError(t, err)
+		cfg.Header.Set("Authorization", "Bearer "+robToken)
+		ws, err := websocket.DialConfig(cfg)
+		require.NoError(t, err)
+		defer ws.Close()
+		assert.Empty(t, ws.Config().Protocol)
+		receive(t, ws)
+
+		require.NoError(t, hub.Close(ctx))
+		m := receive(t, ws)
+		assert.Equal(t, collab.UpdateError, m.Type)
+		assert.Equal(t, collab.ErrClosed.Error(), m.Error)
+	})
+
+	t.Run("Connections are refused", func(t *testing.T) {
+		_, err := dial(t, annaToken, srv.URL)
+		assert.Error(t, err, "note of another user")
+
+		_, err = dial(t, "invalid", srv.URL)
+		assert.Error(t, err, "invalid token")
+
+		_, err = dial(t, robToken, "https://evil.example.com")
+		assert.Error(t, err, "foreign origin")
+
+		req, err := http.NewRequest(http.MethodGet, srv.URL+"/notes/"+n.ID.String()+"/collab", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+robToken)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
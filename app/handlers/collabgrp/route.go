@@ -0,0 +1,16 @@
+package collabgrp
+
+import (
+	"github.com/Keisn1/note-taking-app/domain/web/mid"
+	"github.com/Keisn1/note-taking-app/domain/web/mux"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+)
+
+// Routes adds the collaborative editing route to the app. It requires that
+// the note belongs to the user, the token may also be sent as a WebSocket
+// subprotocol.
+func Routes(app *web.App, cfg mux.Config) {
+	hdl := NewHandlers(cfg.Collab, cfg.CORSOrigins)
+
+	app.Get("/notes/{note_id}/collab", hdl.Edit, tokenFromProtocol, mid.Authenticate(cfg.Auth, cfg.UserSvc), mid.AuthorizeNote(cfg.NoteSvc))
+}
@@ -0,0 +1,58 @@
+package docsgrp
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/Keisn1/note-taking-app/app/api"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+)
+
+// docsCSP lets the page load only the embedded Redoc bundle and the OpenAPI
+// document. Redoc injects its styles and runs its search in a blob worker.
+const docsCSP = "default-src 'none'; script-src 'self'; style-src 'unsafe-inline'; img-src 'self' data:; font-src 'self' data:; connect-src 'self'; worker-src blob:; base-uri 'none'; form-action 'none'; frame-ancestors 'none'"
+
+var (
+	//go:embed docs.html
+	docsPage []byte
+
+	// redoc is the bundle of Redoc v2.1.5, see the redoc target of the
+	// Makefile.
+	//go:embed redoc.standalone.js
+	redoc []byte
+)
+
+type Handlers struct{}
+
+func NewHandlers() Handlers {
+	return Handlers{}
+}
+
+// OpenAPI serves the OpenAPI document of the API.
+func (hdl Handlers) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	spec, err := api.OpenAPI()
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		web.Logger(r.Context()).Error("openapi", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(spec)
+}
+
+// Docs serves a page that renders the OpenAPI document with Redoc.
+func (hdl Handlers) Docs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", docsCSP)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write(docsPage)
+}
+
+// Redoc serves the Redoc bundle used by the docs page.
+func (hdl Handlers) Redoc(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write(redoc)
+}
@@ -0,0 +1,505 @@
+package docsgrp_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/app/api"
+	"github.com/Keisn1/note-taking-app/app/handlers/attachmentsgrp"
+	"github.com/Keisn1/note-taking-app/app/handlers/auditgrp"
+	"github.com/Keisn1/note-taking-app/app/handlers/checkgrp"
+	"github.com/Keisn1/note-taking-app/app/handlers/collabgrp"
+	"github.com/Keisn1/note-taking-app/app/handlers/docsgrp"
+	"github.com/Keisn1/note-taking-app/app/handlers/eventsgrp"
+	"github.com/Keisn1/note-taking-app/app/handlers/linksgrp"
+	"github.com/Keisn1/note-taking-app/app/handlers/notesgrp"
+	"github.com/Keisn1/note-taking-app/app/handlers/syncgrp"
+	"github.com/Keisn1/note-taking-app/app/handlers/transfergrp"
+	"github.com/Keisn1/note-taking-app/app/handlers/usersgrp"
+	"github.com/Keisn1/note-taking-app/app/handlers/webhooksgrp"
+	"github.com/Keisn1/note-taking-app/domain/core/attachment"
+	attachmentmemory "github.com/Keisn1/note-taking-app/domain/core/attachment/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/core/audit"
+	auditmemory "github.com/Keisn1/note-taking-app/domain/core/audit/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/core/change"
+	changememory "github.com/Keisn1/note-taking-app/domain/core/change/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/core/collab"
+	"github.com/Keisn1/note-taking-app/domain/core/link"
+	linkmemory "github.com/Keisn1/note-taking-app/domain/core/link/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	notememory "github.com/Keisn1/note-taking-app/domain/core/note/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	usermemory "github.com/Keisn1/note-taking-app/domain/core/user/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/core/webhook"
+	webhookmemory "github.com/Keisn1/note-taking-app/domain/core/webhook/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/web/auth"
+	"github.com/Keisn1/note-taking-app/domain/web/mux"
+	"github.com/Keisn1/note-taking-app/foundation/blobstore"
+	"github.com/Keisn1/note-taking-app/foundation/common"
+	"github.com/Keisn1/note-taking-app/foundation/eventbus"
+	"github.com/Keisn1/note-taking-app/foundation/health"
+	"github.com/Keisn1/note-taking-app/foundation/transaction"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+	"github.com/google/uuid"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Docs(t *testing.T) {
+	app := mux.NewAPI(docsgrp.Routes, mux.Config{})
+
+	rr := httptest.NewRecorder()
+	app.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+	assert.Equal(t, "3.1.0", doc.OpenAPI)
+
+	rr = httptest.NewRecorder()
+	app.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `spec-url="openapi.json"`)
+	assert.Contains(t, rr.Header().Get("Content-Security-Policy"), "script-src 'self'")
+	assert.NotContains(t, rr.Body.String(), "https://")
+
+	rr = httptest.NewRecorder()
+	app.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/docs/redoc.standalone.js", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/javascript; charset=utf-8", rr.Header().Get("Content-Type"))
+}
+
+// Test_OpenAPI runs requests against the real handlers and checks that every
+// response is documented and matches its schema, and that every documented
+// operation is exercised.
+func Test_OpenAPI(t *testing.T) {
+	sv := newSpecValidator(t)
+
+	store, err := blobstore.NewFS(t.TempDir())
+	require.NoError(t, err)
+	attachmentSvc := attachment.NewSvc(attachmentmemory.NewRepo(), store, attachment.Limits{MaxSize: 1 << 10})
+
+	changeRepo, memoryNoteRepo := changememory.NewRepo(), notememory.MustNewRepo(nil)
+	noteRepo := change.NewNoteRepo(memoryNoteRepo, changeRepo)
+	linkSvc := link.NewSvc(linkmemory.NewRepo(), noteRepo)
+
+	admin := uuid.New()
+	userSvc := user.NewSvc(usermemory.NewRepo([]user.User{{ID: admin}}))
+	events := eventbus.New(10)
+	webhookRepo := webhookmemory.NewRepo()
+	webhookSvc := webhook.NewSvc(webhookRepo)
+	auditSvc := audit.NewSvc(auditmemory.NewRepo())
+	failing := false
+	noteSvc := note.NewNotesService(noteRepo, userSvc,
+		note.WithSaveHook(linkSvc.Sync),
+		note.WithDeleteHook(attachmentSvc.DeleteByNoteID),
+		note.WithDeleteHook(linkSvc.DeleteByNoteID),
+		note.WithEventHook(webhookSvc.Enqueue),
+		note.WithEventHook(audit.NoteEventHook(auditSvc)),
+		note.WithPublisher(eventsgrp.NotePublisher(events)),
+	)
+	jwtSvc := auth.MustNewJWTService(common.MustGenerateRandomKey(32))
+	cfg := mux.Config{
+		Auth:          auth.NewAuth(jwtSvc),
+		NoteSvc:       noteSvc,
+		UserSvc:       userSvc,
+		AttachmentSvc: attachmentSvc,
+		LinkSvc:       linkSvc,
+		SyncSvc:       change.NewSvc(changeRepo, noteSvc, transaction.NewMemory(changeRepo, memoryNoteRepo)),
+		WebhookSvc:    webhookSvc,
+		AuditSvc:      auditSvc,
+		AuditAdmins:   []uuid.UUID{admin},
+		Events:        events,
+		Collab:        collab.NewHub(noteSvc, time.Hour),
+		TokenTTL:      time.Hour,
+		Readiness: health.NewReadiness(time.Second, health.Check{Name: "postgres", Check: func(ctx context.Context) error {
+			if failing {
+				return errors.New("connection refused")
+			}
+			return nil
+		}}),
+	}
+	routes := func(app *web.App, cfg mux.Config) {
+		attachmentsgrp.Routes(app, cfg)
+		auditgrp.Routes(app, cfg)
+		checkgrp.Routes(app, cfg)
+		collabgrp.Routes(app, cfg)
+		docsgrp.Routes(app, cfg)
+		eventsgrp.Routes(app, cfg)
+		linksgrp.Routes(app, cfg)
+		notesgrp.Routes(app, cfg)
+		syncgrp.Routes(app, cfg)
+		transfergrp.Routes(app, cfg)
+		usersgrp.Routes(app, cfg)
+		webhooksgrp.Routes(app, cfg)
+	}
+	app := mux.NewAPI(routes, cfg)
+
+	call := func(method, path, token, body string, header ...string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, req)
+		sv.validate(t, req, rr.Result())
+		return rr
+	}
+
+	call(http.MethodGet, "/healthz", "", "")
+	call(http.MethodGet, "/readyz", "", "")
+	failing = true
+	call(http.MethodGet, "/readyz", "", "")
+	call(http.MethodGet, "/version", "", "")
+	call(http.MethodGet, "/metrics", "", "")
+	call(http.MethodGet, "/openapi.json", "", "")
+	call(http.MethodGet, "/docs", "", "")
+	call(http.MethodGet, "/docs/redoc.standalone.js", "", "")
+
+	rr := call(http.MethodPost, "/users", "", `{"name": "rob", "email": "rob@example.com", "password": "password"}`)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	call(http.MethodPost, "/users", "", `{"name": "rob", "email": "rob@example.com", "password": "password"}`)
+	call(http.MethodPost, "/users", "", `{"name": "", "email": "rob", "password": "short"}`)
+	call(http.MethodPost, "/users", "", `{"name":`)
+
+	call(http.MethodPost, "/users/login", "", `{"email": "rob@example.com", "password": "wrong password"}`)
+	rr = call(http.MethodPost, "/users/login", "", `{"email": "rob@example.com", "password": "password"}`)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var tr api.TokenResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &tr))
+	token := tr.Token
+
+	call(http.MethodPost, "/users/token", token, "")
+	call(http.MethodPost, "/users/token", "invalid", "")
+
+	call(http.MethodGet, "/users/me", token, "")
+	call(http.MethodGet, "/users/me", "", "")
+	call(http.MethodPut, "/users/me", token, `{"name": "robert", "email": "robert@example.com"}`)
+	call(http.MethodPut, "/users/me", token, `{"name": "robert", "email": "invalid"}`)
+	call(http.MethodPatch, "/users/me", token, `{"name": "rob"}`)
+	call(http.MethodPatch, "/users/me", token, `{"name": null}`)
+
+	rr = call(http.MethodPost, "/notes", token, `{"title": "Groceries", "content": "# Shopping\n\n- [ ] milk", "format": "markdown"}`)
+	require.Equal(t, http.StatusAccepted, rr.Code)
+	var n api.NoteResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &n))
+	etag := rr.Header().Get("ETag")
+
+	call(http.MethodPost, "/notes", token, `{"content": "no title"}`)
+	call(http.MethodPost, "/notes", "", `{"title": "Groceries"}`)
+
+	rr = call(http.MethodPost, "/notes", token, `{"title": "Plan", "content": "buy [[groceries|food]], cook [[Recipes]]"}`)
+	require.Equal(t, http.StatusAccepted, rr.Code)
+	var plan api.NoteResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &plan))
+	call(http.MethodGet, "/notes/"+plan.ID.String()+"/links", token, "")
+	call(http.MethodGet, "/notes/"+n.ID.String()+"/backlinks", token, "")
+	call(http.MethodGet, "/notes/"+uuid.NewString()+"/backlinks", token, "")
+	call(http.MethodGet, "/links/broken", token, "")
+	call(http.MethodGet, "/links/broken", "", "")
+	call(http.MethodGet, "/graph", token, "")
+	call(http.MethodGet, "/graph?format=cytoscape&root="+n.ID.String()+"&depth=2", token, "")
+	call(http.MethodGet, "/graph?root="+uuid.NewString(), token, "")
+	call(http.MethodGet, "/graph?depth=2", token, "")
+	call(http.MethodGet, "/graph", "", "")
+
+	// The stream ends once the request is canceled.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Last-Event-ID", "1-1")
+	rr = httptest.NewRecorder()
+	app.ServeHTTP(rr, req)
+	sv.validate(t, req, rr.Result())
+	assert.Contains(t, rr.Body.String(), "event: reset\n")
+	call(http.MethodGet, "/events", "", "")
+
+	// Upgrades need a connection that can be hijacked, see the tests of
+	// collabgrp.
+	call(http.MethodGet, "/notes/"+n.ID.String()+"/collab", token, "")
+	call(http.MethodGet, "/notes/"+uuid.NewString()+"/collab", token, "", "Upgrade", "websocket")
+
+	rr = call(http.MethodPost, "/webhooks", token, `{"url": "https://example.com/hook", "events": ["note.created"]}`)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	webhookPath := rr.Header().Get("Location")
+	call(http.MethodPost, "/webhooks", token, `{"url": "ftp://example.com/hook"}`)
+	call(http.MethodPost, "/webhooks", token, `{"url":`)
+	call(http.MethodPost, "/webhooks", token, `{"url": "https://example.com/`+strings.Repeat("a", 3<<20)+`"}`)
+	call(http.MethodPost, "/webhooks", "", `{"url": "https://example.com/hook"}`)
+	call(http.MethodGet, "/webhooks", token, "")
+	call(http.MethodGet, "/webhooks", "", "")
+	call(http.MethodGet, webhookPath, token, "")
+	call(http.MethodGet, "/webhooks/"+uuid.NewString(), token, "")
+
+	call(http.MethodPost, "/sync", token, `{"mutations": [
+		{"op": "upsert", "note_id": "`+uuid.NewString()+`", "base_version": 0, "title": "Offline", "content": "draft"},
+		{"op": "upsert", "note_id": "`+n.ID.String()+`", "base_version": 0, "title": "Groceries", "content": "eggs"}
+	]}`)
+	call(http.MethodPost, "/sync", token, `{"mutations": [{"op": "move"}]}`)
+	call(http.MethodPost, "/sync", token, `{"mutations":`)
+	call(http.MethodPost, "/sync", token, `{"mutations": [{"op": "upsert", "note_id": "`+uuid.NewString()+`", "base_version": 0, "title": "Big", "content": "`+strings.Repeat("a", 3<<20)+`"}]}`)
+	call(http.MethodPost, "/sync", "", `{"mutations": []}`)
+	call(http.MethodGet, "/sync", token, "")
+	call(http.MethodGet, "/sync?since=1&limit=1", token, "")
+	call(http.MethodGet, "/sync?limit=0", token, "")
+	call(http.MethodGet, "/sync", "", "")
+
+	rr = call(http.MethodGet, webhookPath+"/deliveries", token, "")
+	var deliveries []api.DeliveryResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &deliveries))
+	require.NotEmpty(t, deliveries)
+	call(http.MethodGet, webhookPath+"/deliveries?status=dead&limit=1", token, "")
+	call(http.MethodGet, webhookPath+"/deliveries?limit=0", token, "")
+	call(http.MethodGet, "/webhooks/"+uuid.NewString()+"/deliveries", token, "")
+	retryPath := webhookPath + "/deliveries/" + deliveries[0].ID.String() + "/retry"
+	call(http.MethodPost, retryPath, token, "")
+	d, err := webhookRepo.QueryDeliveryByID(context.Background(), deliveries[0].ID)
+	require.NoError(t, err)
+	d.Status, d.Attempts, d.ResponseStatus, d.LastError = webhook.StatusDead, 8, http.StatusBadGateway, "unexpected response status: 502 Bad Gateway"
+	require.NoError(t, webhookRepo.UpdateDelivery(context.Background(), d))
+	call(http.MethodGet, webhookPath+"/deliveries?status=dead", token, "")
+	call(http.MethodPost, retryPath, token, "")
+	call(http.MethodPost, webhookPath+"/deliveries/"+uuid.NewString()+"/retry", token, "")
+	call(http.MethodPost, retryPath, "", "")
+	call(http.MethodDelete, webhookPath, token, "")
+	call(http.MethodDelete, webhookPath, token, "")
+
+	adminToken, err := jwtSvc.CreateToken(admin, time.Hour)
+	require.NoError(t, err)
+	rr = call(http.MethodGet, "/audit", adminToken, "")
+	var trail api.AuditResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &trail))
+	require.NotEmpty(t, trail.Entries)
+	call(http.MethodGet, "/audit?target=note:"+n.ID.String()+"&from=2024-01-01T00:00:00Z&limit=1", adminToken, "")
+	call(http.MethodGet, "/audit?actor=rob", adminToken, "")
+	call(http.MethodGet, "/audit", token, "")
+
+	call(http.MethodGet, "/notes", token, "")
+	call(http.MethodGet, "/notes?title=groc&q=milk&page=2&per_page=10", token, "")
+	call(http.MethodGet, "/notes?page=0", token, "")
+
+	call(http.MethodGet, "/export", token, "")
+	call(http.MethodGet, "/export?format=json", token, "")
+	call(http.MethodGet, "/export?format=pdf", token, "")
+	call(http.MethodGet, "/export", "", "")
+
+	rr = call(http.MethodPost, "/import", token, `{"version": 1, "notes": [{"id": "`+uuid.NewString()+`", "title": "Ideas", "content": "", "format": "plain"}]}`, "Content-Type", "application/json")
+	require.Equal(t, http.StatusAccepted, rr.Code)
+	jobPath := rr.Header().Get("Location")
+	// wait for the job, the memory repository isn't safe for concurrent use
+	require.Eventually(t, func() bool {
+		var job api.ImportJob
+		require.NoError(t, json.Unmarshal(call(http.MethodGet, jobPath, token, "").Body.Bytes(), &job))
+		return job.Status != api.ImportRunning
+	}, time.Second, 5*time.Millisecond)
+	call(http.MethodGet, "/import/"+uuid.NewString(), token, "")
+	call(http.MethodPost, "/import", token, "notes", "Content-Type", "text/plain")
+	call(http.MethodPost, "/import?format=markdown", token, "not a zip")
+
+	notePath := "/notes/" + n.ID.String()
+	call(http.MethodGet, notePath, token, "")
+	call(http.MethodGet, notePath+"?render=html", token, "")
+	call(http.MethodGet, notePath+"?render=pdf", token, "")
+	call(http.MethodGet, notePath, token, "", "Accept", "text/html")
+	call(http.MethodGet, "/notes/"+uuid.NewString(), token, "")
+	call(http.MethodPut, notePath, token, `{"title": "Groceries", "content": "milk, eggs"}`, "If-Match", etag)
+	call(http.MethodPut, notePath, token, `{"title": "Groceries", "content": "stale"}`, "If-Match", etag)
+	call(http.MethodPatch, notePath, token, `{"content": null}`)
+	call(http.MethodPatch, notePath, token, `{"title": null}`)
+	call(http.MethodPatch, notePath, token, `{"format": "plain"}`)
+	call(http.MethodPatch, notePath, token, `{"format": "html"}`)
+
+	attachmentsPath := notePath + "/attachments"
+	upload := func(content string) *httptest.ResponseRecorder {
+		var body strings.Builder
+		mw := multipart.NewWriter(&body)
+		fw, err := mw.CreateFormFile("file", "scan.pdf")
+		require.NoError(t, err)
+		_, err = io.WriteString(fw, content)
+		require.NoError(t, err)
+		require.NoError(t, mw.Close())
+		return call(http.MethodPost, attachmentsPath, token, body.String(), "Content-Type", mw.FormDataContentType())
+	}
+	rr = upload("%PDF-1.4\n%%EOF\n")
+	require.Equal(t, http.StatusCreated, rr.Code)
+	attachmentPath := rr.Header().Get("Location")
+	upload("")
+	upload("plain text")
+	upload("%PDF-1.4\n" + strings.Repeat(" ", 1<<10))
+	call(http.MethodPost, attachmentsPath, token, "%PDF-1.4", "Content-Type", "application/pdf")
+	call(http.MethodPost, "/notes/"+uuid.NewString()+"/attachments", token, "")
+	call(http.MethodGet, attachmentsPath, token, "")
+	call(http.MethodGet, attachmentPath, token, "")
+	call(http.MethodGet, attachmentPath, token, "", "Range", "bytes=0-3")
+	call(http.MethodGet, attachmentPath, token, "", "Range", "bytes=1000-")
+	call(http.MethodGet, attachmentPath, token, "", "If-None-Match", `"`+path.Base(attachmentPath)+`"`)
+	call(http.MethodGet, attachmentsPath+"/"+uuid.NewString(), token, "")
+	call(http.MethodDelete, attachmentPath, token, "")
+	call(http.MethodDelete, attachmentPath, token, "")
+	call(http.MethodDelete, attachmentsPath+"/"+uuid.NewString(), "", "")
+
+	call(http.MethodDelete, notePath, token, "")
+	call(http.MethodDelete, notePath, token, "")
+
+	call(http.MethodDelete, "/users/me", token, "")
+	call(http.MethodGet, "/users/me", token, "")
+
+	sv.assertAllOperationsCalled(t)
+}
+
+type specValidator struct {
+	compiler *jsonschema.Compiler
+	doc      map[string]any
+	called   map[string]bool
+}
+
+func newSpecValidator(t *testing.T) *specValidator {
+	spec, err := api.OpenAPI()
+	require.NoError(t, err)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(spec, &doc))
+	require.Equal(t, "3.1.0", doc["openapi"])
+
+	c := jsonschema.NewCompiler()
+	c.Draft = jsonschema.Draft2020
+	c.AssertFormat = true
+	require.NoError(t, c.AddResource("openapi.json", bytes.NewReader(spec)))
+
+	return &specValidator{compiler: c, doc: doc, called: map[string]bool{}}
+}
+
+// validate fails the test if the response to req isn't documented or doesn't
+// match the documented schema.
+func (sv *specValidator) validate(t *testing.T, req *http.Request, resp *http.Response) {
+	t.Helper()
+	op := req.Method + " " + req.URL.Path
+
+	tmpl, ok := sv.matchPath(req.URL.Path)
+	if !assert.True(t, ok, "%s: path not documented", op) {
+		return
+	}
+	method := strings.ToLower(req.Method)
+	_, ok = sv.lookup("paths", tmpl, method).(map[string]any)
+	if !assert.True(t, ok, "%s: operation not documented", op) {
+		return
+	}
+	sv.called[req.Method+" "+tmpl] = true
+
+	status := strconv.Itoa(resp.StatusCode)
+	ptr := []string{"paths", tmpl, method, "responses", status}
+	response, ok := sv.lookup(ptr...).(map[string]any)
+	if !assert.True(t, ok, "%s: response %s not documented", op, status) {
+		return
+	}
+	if ref, ok := response["$ref"].(string); ok {
+		ptr = strings.Split(strings.TrimPrefix(ref, "#/"), "/")
+		response = sv.lookup(ptr...).(map[string]any)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	content, _ := response["content"].(map[string]any)
+	if len(content) == 0 {
+		assert.Empty(t, body, "%s: response %s has no documented content", op, status)
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !assert.Contains(t, content, mediaType, "%s: response %s content type not documented", op, status) {
+		return
+	}
+	if mediaType != "application/json" {
+		return
+	}
+
+	schemaPtr := append(ptr, "content", mediaType, "schema")
+	schema, err := sv.compiler.Compile("openapi.json#/" + pointer(schemaPtr...))
+	require.NoError(t, err)
+
+	var v any
+	require.NoError(t, json.Unmarshal(body, &v), "%s: response %s isn't JSON", op, status)
+	assert.NoError(t, schema.Validate(v), "%s: response %s: %s", op, status, body)
+}
+
+func (sv *specValidator) assertAllOperationsCalled(t *testing.T) {
+	var missing []string
+	for tmpl, item := range sv.doc["paths"].(map[string]any) {
+		for method := range item.(map[string]any) {
+			if method == "parameters" {
+				continue
+			}
+			if op := strings.ToUpper(method) + " " + tmpl; !sv.called[op] {
+				missing = append(missing, op)
+			}
+		}
+	}
+	sort.Strings(missing)
+	assert.Empty(t, missing, "documented operations not exercised")
+}
+
+// matchPath returns the documented path template that matches path.
+func (sv *specValidator) matchPath(path string) (string, bool) {
+	segs := strings.Split(path, "/")
+	for tmpl := range sv.doc["paths"].(map[string]any) {
+		tsegs := strings.Split(tmpl, "/")
+		if len(tsegs) != len(segs) {
+			continue
+		}
+		match := true
+		for i, ts := range tsegs {
+			if ts != segs[i] && !(strings.HasPrefix(ts, "{") && strings.HasSuffix(ts, "}")) {
+				match = false
+				break
+			}
+		}
+		if match {
+			return tmpl, true
+		}
+	}
+	return "", false
+}
+
+func (sv *specValidator) lookup(keys ...string) any {
+	var v any = sv.doc
+	for _, k := range keys {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[k]
+	}
+	return v
+}
+
+// pointer returns the JSON pointer of the keys, see RFC 6901.
+func pointer(keys ...string) string {
+	escaped := make([]string, len(keys))
+	for i, k := range keys {
+		escaped[i] = strings.ReplaceAll(strings.ReplaceAll(k, "~", "~0"), "/", "~1")
+	}
+	return strings.Join(escaped, "/")
+}
@@ -0,0 +1,3 @@
+// Placeholder for the Redoc bundle, which is served from the binary instead
+// of a CDN. Replace it with the pinned release by running `make redoc`.
+document.body.textContent = "The Redoc bundle is missing, run make redoc and rebuild. The API is described at openapi.json.";
@@ -0,0 +1,15 @@
+package docsgrp
+
+import (
+	"github.com/Keisn1/note-taking-app/domain/web/mux"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+)
+
+// Routes adds the API documentation routes to the app.
+func Routes(app *web.App, cfg mux.Config) {
+	hdl := NewHandlers()
+
+	app.Get("/openapi.json", hdl.OpenAPI)
+	app.Get("/docs", hdl.Docs)
+	app.Get("/docs/redoc.standalone.js", hdl.Redoc)
+}
@@ -0,0 +1,158 @@
+// Package eventsgrp streams the changes to the notes of the user as
+// server-sent events.
+package eventsgrp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/app/api"
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/Keisn1/note-taking-app/domain/web/mid"
+	"github.com/Keisn1/note-taking-app/foundation/eventbus"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+)
+
+const (
+	// EventReset tells the client that events were lost and that it has to
+	// fetch the notes again.
+	EventReset = "reset"
+
+	heartbeatInterval = 15 * time.Second
+	writeTimeout      = 10 * time.Second
+	retryInterval     = 3 * time.Second
+)
+
+// NotePublisher publishes the note events on the bus, to the owner of the
+// note.
+func NotePublisher(bus *eventbus.Bus) note.Publisher {
+	return note.PublisherFunc(func(ctx context.Context, e note.Event) {
+		bus.Publish(e.Type, e.Note.UserID.String(), api.ToNoteEventResponse(e))
+	})
+}
+
+type Handlers struct {
+	bus *eventbus.Bus
+}
+
+func NewHandlers(bus *eventbus.Bus) Handlers {
+	return Handlers{bus: bus}
+}
+
+// Stream sends the events of the user until the client goes away. A client
+// that reconnects with Last-Event-ID gets the events it missed, or a reset
+// event if they are no longer in the log of the bus.
+func (hdl Handlers) Stream(w http.ResponseWriter, r *http.Request) {
+	userID := mid.GetUserID(r.Context())
+	log := web.Logger(r.Context())
+
+	var (
+		sub    *eventbus.Subscription
+		replay []eventbus.Event
+		reset  bool
+	)
+	if lastID := r.Header.Get("Last-Event-ID"); lastID != "" {
+		after, ok := hdl.parseEventID(lastID)
+		if ok {
+			sub, replay, ok = hdl.bus.Resume(userID.String(), after)
+		} else {
+			sub = hdl.bus.Subscribe(userID.String())
+		}
+		reset = !ok
+	} else {
+		sub = hdl.bus.Subscribe(userID.String())
+	}
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	send := func(format string, args ...any) error {
+		if err := rc.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, format, args...); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+	sendEvent := func(e eventbus.Event) error {
+		data, err := json.Marshal(e.Data)
+		if err != nil {
+			return err
+		}
+		return send("id: %s\nevent: %s\ndata: %s\n\n", hdl.eventID(e.Seq), e.Type, data)
+	}
+
+	err := send("retry: %d\n\n", retryInterval.Milliseconds())
+	if err == nil && reset {
+		log.Info("events: resume not possible, sending reset", "last_event_id", r.Header.Get("Last-Event-ID"))
+		err = send("id: %s\nevent: %s\ndata: {}\n\n", hdl.eventID(sub.Start()), EventReset)
+	}
+	for _, e := range replay {
+		if err != nil {
+			break
+		}
+		err = sendEvent(e)
+	}
+	if err == nil && !reset {
+		// Moves the client past the events of other users, so that it
+		// resumes from here even if none of its own arrive.
+		err = send("id: %s\n\n", hdl.eventID(sub.Start()))
+	}
+	if err != nil {
+		log.Info("events: send", "error", err)
+		return
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case e, ok := <-sub.Events():
+			if !ok {
+				// The client resumes from the log when it reconnects.
+				log.Info("events: subscription closed", "lagged", sub.Lagged())
+				return
+			}
+			err = sendEvent(e)
+
+		case <-heartbeat.C:
+			err = send(": heartbeat\n\n")
+		}
+		if err != nil {
+			log.Info("events: send", "error", err)
+			return
+		}
+	}
+}
+
+// eventID returns the ID of the event with sequence number seq. It contains
+// the epoch of the bus, because sequence numbers start over on restart.
+func (hdl Handlers) eventID(seq uint64) string {
+	return strconv.FormatInt(hdl.bus.Epoch(), 10) + "-" + strconv.FormatUint(seq, 10)
+}
+
+// parseEventID returns the sequence number of the event ID, ok is false if
+// it is invalid or of another epoch.
+func (hdl Handlers) parseEventID(id string) (seq uint64, ok bool) {
+	epoch, seqS, found := strings.Cut(id, "-")
+	if !found || epoch != strconv.FormatInt(hdl.bus.Epoch(), 10) {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(seqS, 10, 64)
+	return seq, err == nil
+}
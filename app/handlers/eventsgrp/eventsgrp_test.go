@@ -0,0 +1,170 @@
+package eventsgrp_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/app/api"
+	"github.com/Keisn1/note-taking-app/app/handlers/eventsgrp"
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	notememory "github.com/Keisn1/note-taking-app/domain/core/note/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	usermemory "github.com/Keisn1/note-taking-app/domain/core/user/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/web/auth"
+	"github.com/Keisn1/note-taking-app/domain/web/mux"
+	"github.com/Keisn1/note-taking-app/foundation/common"
+	"github.com/Keisn1/note-taking-app/foundation/eventbus"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sseEvent struct {
+	ID, Event, Data string
+}
+
+// stream reads the server-sent events of a response.
+type stream struct {
+	t    *testing.T
+	resp *http.Response
+	sc   *bufio.Scanner
+}
+
+// next returns the next event, skipping comments and the retry field.
+func (s stream) next() sseEvent {
+	s.t.Helper()
+	var e sseEvent
+	for s.sc.Scan() {
+		line := s.sc.Text()
+		if line == "" {
+			if e != (sseEvent{}) {
+				return e
+			}
+			continue
+		}
+		field, value, _ := strings.Cut(line, ": ")
+		switch field {
+		case "id":
+			e.ID = value
+		case "event":
+			e.Event = value
+		case "data":
+			e.Data = value
+		}
+	}
+	require.NoError(s.t, s.sc.Err())
+	require.FailNow(s.t, "stream ended")
+	return e
+}
+
+func Test_Stream(t *testing.T) {
+	rob := user.User{ID: uuid.UUID{1}, Name: user.NewName("rob"), Email: user.NewEmail("rob@example.com")}
+	anna := user.User{ID: uuid.UUID{2}, Name: user.NewName("anna"), Email: user.NewEmail("anna@example.com")}
+
+	bus := eventbus.New(3)
+	userSvc := user.NewSvc(usermemory.NewRepo([]user.User{rob, anna}))
+	noteSvc := note.NewNotesService(notememory.MustNewRepo(nil), userSvc, note.WithPublisher(eventsgrp.NotePublisher(bus)))
+
+	jwtSvc := auth.MustNewJWTService(common.MustGenerateRandomKey(32))
+	srv := httptest.NewServer(mux.NewAPI(eventsgrp.Routes, mux.Config{Auth: auth.NewAuth(jwtSvc), NoteSvc: noteSvc, UserSvc: userSvc, Events: bus}))
+	defer srv.Close()
+
+	robToken, err := jwtSvc.CreateToken(rob.ID, time.Minute)
+	require.NoError(t, err)
+
+	open := func(t *testing.T, token string, header ...string) stream {
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/events", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+token)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return stream{t: t, resp: resp, sc: bufio.NewScanner(resp.Body)}
+	}
+	create := func(userID uuid.UUID, title string) note.Note {
+		n, err := noteSvc.Create(context.Background(), note.UpdateNote{Title: note.NewTitle(title), Content: note.NewContent(""), UserID: userID})
+		require.NoError(t, err)
+		return n
+	}
+
+	var lastID string
+	t.Run("Only events of the user are delivered", func(t *testing.T) {
+		s := open(t, robToken)
+		require.Equal(t, http.StatusOK, s.resp.StatusCode)
+		assert.Equal(t, "text/event-stream", s.resp.Header.Get("Content-Type"))
+		assert.Equal(t, "no-cache", s.resp.Header.Get("Cache-Control"))
+		start := s.next()
+		assert.Empty(t, start.Event)
+		assert.NotEmpty(t, start.ID)
+
+		create(anna.ID, "Anna's note")
+		groceries := create(rob.ID, "Groceries")
+		_, err := noteSvc.Update(context.Background(), groceries, note.UpdateNote{Content: note.NewContent("milk")})
+		require.NoError(t, err)
+		require.NoError(t, noteSvc.Delete(context.Background(), groceries.ID))
+
+		e := s.next()
+		assert.Equal(t, note.EventCreated, e.Event)
+		var got api.NoteEventResponse
+		require.NoError(t, json.Unmarshal([]byte(e.Data), &got))
+		assert.Equal(t, groceries.ID, got.NoteID)
+		require.NotNil(t, got.Note)
+		assert.Equal(t, "Groceries", got.Note.Title)
+
+		e = s.next()
+		assert.Equal(t, note.EventUpdated, e.Event)
+		assert.Contains(t, e.Data, `"content":"milk"`)
+
+		e = s.next()
+		assert.Equal(t, note.EventDeleted, e.Event)
+		assert.JSONEq(t, `{"note_id": "`+groceries.ID.String()+`", "note": null}`, e.Data)
+		lastID = e.ID
+	})
+
+	t.Run("Resume with Last-Event-ID", func(t *testing.T) {
+		create(anna.ID, "Plan")
+		ideas := create(rob.ID, "Ideas")
+
+		s := open(t, robToken, "Last-Event-ID", lastID)
+		e := s.next()
+		assert.Equal(t, note.EventCreated, e.Event)
+		assert.Contains(t, e.Data, ideas.ID.String())
+
+		// Past the event of anna.
+		e = s.next()
+		assert.Empty(t, e.Event)
+		assert.NotEqual(t, lastID, e.ID)
+	})
+
+	t.Run("Reset if events were dropped from the log", func(t *testing.T) {
+		for range 3 {
+			create(anna.ID, "Todo")
+		}
+
+		s := open(t, robToken, "Last-Event-ID", lastID)
+		e := s.next()
+		assert.Equal(t, eventsgrp.EventReset, e.Event)
+		assert.NotEmpty(t, e.ID)
+	})
+
+	t.Run("Reset on an ID of another epoch", func(t *testing.T) {
+		s := open(t, robToken, "Last-Event-ID", "1-1")
+		assert.Equal(t, eventsgrp.EventReset, s.next().Event)
+	})
+
+	t.Run("Requires authentication", func(t *testing.T) {
+		s := open(t, "invalid")
+		assert.Equal(t, http.StatusForbidden, s.resp.StatusCode)
+	})
+}
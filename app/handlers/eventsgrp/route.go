@@ -0,0 +1,14 @@
+package eventsgrp
+
+import (
+	"github.com/Keisn1/note-taking-app/domain/web/mid"
+	"github.com/Keisn1/note-taking-app/domain/web/mux"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+)
+
+// Routes adds the event stream to the app. It requires authentication.
+func Routes(app *web.App, cfg mux.Config) {
+	hdl := NewHandlers(cfg.Events)
+
+	app.Get("/events", hdl.Stream, mid.Authenticate(cfg.Auth, cfg.UserSvc))
+}
@@ -0,0 +1,126 @@
+// Package linksgrp serves the wiki links between notes and the graph they
+// form.
+package linksgrp
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Keisn1/note-taking-app/app/api"
+	"github.com/Keisn1/note-taking-app/domain/core/link"
+	"github.com/Keisn1/note-taking-app/domain/web/mid"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+	"github.com/google/uuid"
+)
+
+type Handlers struct {
+	linkSvc link.Service
+}
+
+func NewHandlers(ls link.Service) Handlers {
+	return Handlers{linkSvc: ls}
+}
+
+// Links returns the links of the note in the order they appear in the
+// content.
+func (hdl Handlers) Links(w http.ResponseWriter, r *http.Request) {
+	n := mid.GetNote(r.Context())
+
+	targets, err := hdl.linkSvc.Links(r.Context(), n)
+	if err != nil {
+		respondError(w, r, "query links", err)
+		return
+	}
+	web.Respond(w, http.StatusOK, api.ToLinkResponses(targets))
+}
+
+// Backlinks returns the notes that link to the note, ordered by title.
+func (hdl Handlers) Backlinks(w http.ResponseWriter, r *http.Request) {
+	n := mid.GetNote(r.Context())
+
+	sources, err := hdl.linkSvc.Backlinks(r.Context(), n)
+	if err != nil {
+		respondError(w, r, "query backlinks", err)
+		return
+	}
+	web.Respond(w, http.StatusOK, api.ToBacklinkResponses(sources))
+}
+
+// Broken returns the links of all notes of the user that don't point to a
+// note.
+func (hdl Handlers) Broken(w http.ResponseWriter, r *http.Request) {
+	userID := mid.GetUserID(r.Context())
+
+	sources, err := hdl.linkSvc.Broken(r.Context(), userID)
+	if err != nil {
+		respondError(w, r, "query broken links", err)
+		return
+	}
+	web.Respond(w, http.StatusOK, api.ToBacklinkResponses(sources))
+}
+
+// Graph returns the link graph of the notes of the user. With root only the
+// notes within depth links of it, in either direction, are included. The
+// format is D3 (default) or Cytoscape (format=cytoscape).
+func (hdl Handlers) Graph(w http.ResponseWriter, r *http.Request) {
+	userID := mid.GetUserID(r.Context())
+
+	f, err := parseGraphFilter(r)
+	if err != nil {
+		web.Respond(w, http.StatusBadRequest, api.ErrorResponse{Error: err.Error()})
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "d3" && format != "cytoscape" {
+		web.Respond(w, http.StatusBadRequest, api.ErrorResponse{Error: "format must be d3 or cytoscape"})
+		return
+	}
+
+	g, err := hdl.linkSvc.Graph(r.Context(), userID, f)
+	if errors.Is(err, link.ErrRootNotFound) {
+		web.Respond(w, http.StatusNotFound, api.ErrorResponse{Error: link.ErrRootNotFound.Error()})
+		return
+	}
+	if err != nil {
+		respondError(w, r, "query graph", err)
+		return
+	}
+
+	if format == "cytoscape" {
+		web.Respond(w, http.StatusOK, api.ToCytoscapeGraphResponse(g))
+		return
+	}
+	web.Respond(w, http.StatusOK, api.ToGraphResponse(g))
+}
+
+// parseGraphFilter reads the query parameters root and depth, which defaults
+// to 1 and requires root.
+func parseGraphFilter(r *http.Request) (link.GraphFilter, error) {
+	q := r.URL.Query()
+
+	var f link.GraphFilter
+	if s := q.Get("root"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return link.GraphFilter{}, errors.New("root must be a note id")
+		}
+		f.Root, f.Depth = id, 1
+	}
+	if s := q.Get("depth"); s != "" {
+		if f.Root == uuid.Nil {
+			return link.GraphFilter{}, errors.New("depth requires root")
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 || n > api.MaxGraphDepth {
+			return link.GraphFilter{}, errors.New("depth must be an integer between 0 and " + strconv.Itoa(api.MaxGraphDepth))
+		}
+		f.Depth = n
+	}
+	return f, nil
+}
+
+func respondError(w http.ResponseWriter, r *http.Request, op string, err error) {
+	web.Respond(w, http.StatusInternalServerError, api.ErrorResponse{Error: http.StatusText(http.StatusInternalServerError)})
+	web.Logger(r.Context()).Error(op, "error", err)
+}
@@ -0,0 +1,125 @@
+package linksgrp_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/app/api"
+	"github.com/Keisn1/note-taking-app/app/handlers/linksgrp"
+	"github.com/Keisn1/note-taking-app/domain/core/link"
+	linkmemory "github.com/Keisn1/note-taking-app/domain/core/link/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	notememory "github.com/Keisn1/note-taking-app/domain/core/note/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	usermemory "github.com/Keisn1/note-taking-app/domain/core/user/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/web/auth"
+	"github.com/Keisn1/note-taking-app/domain/web/mux"
+	"github.com/Keisn1/note-taking-app/foundation/common"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Links(t *testing.T) {
+	rob := user.User{ID: uuid.UUID{1}, Name: user.NewName("rob"), Email: user.NewEmail("rob@example.com")}
+	anna := user.User{ID: uuid.UUID{2}, Name: user.NewName("anna"), Email: user.NewEmail("anna@example.com")}
+
+	noteRepo := notememory.MustNewRepo(nil)
+	linkSvc := link.NewSvc(linkmemory.NewRepo(), noteRepo)
+	userSvc := user.NewSvc(usermemory.NewRepo([]user.User{rob, anna}))
+	noteSvc := note.NewNotesService(noteRepo, userSvc, note.WithSaveHook(linkSvc.Sync), note.WithDeleteHook(linkSvc.DeleteByNoteID))
+
+	jwtSvc := auth.MustNewJWTService(common.MustGenerateRandomKey(32))
+	app := mux.NewAPI(linksgrp.Routes, mux.Config{Auth: auth.NewAuth(jwtSvc), NoteSvc: noteSvc, UserSvc: userSvc, LinkSvc: linkSvc})
+
+	create := func(userID uuid.UUID, title, content string) note.Note {
+		n, err := noteSvc.Create(context.Background(), note.UpdateNote{Title: note.NewTitle(title), Content: note.NewContent(content), UserID: userID})
+		require.NoError(t, err)
+		return n
+	}
+	groceries := create(rob.ID, "Groceries", "")
+	plan := create(rob.ID, "Plan", "buy [[groceries|food]], cook [[Recipes]]")
+	annas := create(anna.ID, "Anna's note", "[[Groceries]]")
+
+	robToken, err := jwtSvc.CreateToken(rob.ID, time.Minute)
+	require.NoError(t, err)
+	get := func(target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Authorization", "Bearer "+robToken)
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Links", func(t *testing.T) {
+		rr := get("/notes/" + plan.ID.String() + "/links")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var got []api.LinkResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+		title := "Groceries"
+		assert.Equal(t, []api.LinkResponse{
+			{Target: "groceries", NoteID: &groceries.ID, Title: &title},
+			{Target: "Recipes", Broken: true},
+		}, got)
+
+		rr = get("/notes/" + groceries.ID.String() + "/links")
+		assert.JSONEq(t, `[]`, rr.Body.String())
+	})
+
+	t.Run("Backlinks only come from notes of the user", func(t *testing.T) {
+		rr := get("/notes/" + groceries.ID.String() + "/backlinks")
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[{"note_id": "`+plan.ID.String()+`", "title": "Plan", "target": "groceries"}]`, rr.Body.String())
+	})
+
+	t.Run("Broken links", func(t *testing.T) {
+		rr := get("/links/broken")
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[{"note_id": "`+plan.ID.String()+`", "title": "Plan", "target": "Recipes"}]`, rr.Body.String())
+	})
+
+	t.Run("Graph", func(t *testing.T) {
+		rr := get("/graph")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var g api.GraphResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &g))
+		require.Len(t, g.Nodes, 2)
+		assert.Equal(t, api.GraphNode{ID: groceries.ID, Title: "Groceries", LinksIn: 1}, g.Nodes[0])
+		assert.Equal(t, []api.GraphEdge{{ID: plan.ID.String() + "-" + groceries.ID.String(), Source: plan.ID, Target: groceries.ID, Kind: api.EdgeLink}}, g.Edges)
+		assert.Equal(t, api.GraphMetrics{Orphans: []uuid.UUID{}, Hubs: []uuid.UUID{groceries.ID}}, g.Metrics)
+
+		rr = get("/graph?format=cytoscape&root=" + groceries.ID.String() + "&depth=0")
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{
+			"elements": {
+				"nodes": [{"data": {"id": "`+groceries.ID.String()+`", "title": "Groceries", "links_in": 1, "links_out": 0, "orphan": false, "distance": 0}}],
+				"edges": []
+			},
+			"metrics": {"orphans": [], "hubs": ["`+groceries.ID.String()+`"]}
+		}`, rr.Body.String())
+	})
+
+	t.Run("Invalid graph queries", func(t *testing.T) {
+		testCases := map[string]int{
+			"/graph?root=" + annas.ID.String():              http.StatusNotFound,
+			"/graph?root=abc":                               http.StatusBadRequest,
+			"/graph?depth=2":                                http.StatusBadRequest,
+			"/graph?root=" + plan.ID.String() + "&depth=11": http.StatusBadRequest,
+			"/graph?format=dot":                             http.StatusBadRequest,
+		}
+		for target, status := range testCases {
+			assert.Equal(t, status, get(target).Code, target)
+		}
+	})
+
+	t.Run("Notes of other users are forbidden", func(t *testing.T) {
+		rr := get("/notes/" + annas.ID.String() + "/backlinks")
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+}
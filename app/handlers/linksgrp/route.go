@@ -0,0 +1,21 @@
+package linksgrp
+
+import (
+	"github.com/Keisn1/note-taking-app/domain/web/mid"
+	"github.com/Keisn1/note-taking-app/domain/web/mux"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+)
+
+// Routes adds the link routes to the app. All of them require
+// authentication, the routes of a note also require that it belongs to the
+// user.
+func Routes(app *web.App, cfg mux.Config) {
+	hdl := NewHandlers(cfg.LinkSvc)
+
+	app.Get("/links/broken", hdl.Broken, mid.Authenticate(cfg.Auth, cfg.UserSvc))
+	app.Get("/graph", hdl.Graph, mid.Authenticate(cfg.Auth, cfg.UserSvc))
+
+	note := app.Group("/notes/{note_id}", mid.Authenticate(cfg.Auth, cfg.UserSvc), mid.AuthorizeNote(cfg.NoteSvc))
+	note.Get("/links", hdl.Links)
+	note.Get("/backlinks", hdl.Backlinks)
+}
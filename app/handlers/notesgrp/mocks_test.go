@@ -28,26 +28,47 @@ func (mNS *mockNotesSvc) Reset() {
 	mNS.ExpectedCalls = []*mock.Call{}
 }
 
-func (mNS *mockNotesSvc) GetNotesByUserID(userID uuid.UUID) ([]note.Note, error) {
+func (mNS *mockNotesSvc) GetNotesByUserID(ctx context.Context, userID uuid.UUID) ([]note.Note, error) {
 	args := mNS.Called(userID)
 	return args.Get(0).([]note.Note), args.Error(1)
 }
 
+func (mNS *mockNotesSvc) IterateByUserID(ctx context.Context, userID uuid.UUID, fn func(note.Note) error) error {
+	args := mNS.Called(userID)
+	return args.Error(0)
+}
+
+func (mNS *mockNotesSvc) QueryByFilter(ctx context.Context, f note.Filter) ([]note.Note, int, error) {
+	args := mNS.Called(f)
+	return args.Get(0).([]note.Note), args.Int(1), args.Error(2)
+}
+
 func (mNS *mockNotesSvc) QueryByID(ctx context.Context, noteID uuid.UUID) (note.Note, error) {
 	args := mNS.Called(noteID)
 	return args.Get(0).(note.Note), args.Error(1)
 }
 
-func (mNS *mockNotesSvc) Create(newN note.UpdateNote) (note.Note, error) {
+func (mNS *mockNotesSvc) Create(ctx context.Context, newN note.UpdateNote) (note.Note, error) {
 	args := mNS.Called(newN)
 	return args.Get(0).(note.Note), args.Error(1)
 }
 
-func (mNS *mockNotesSvc) Update(n note.Note, un note.UpdateNote) (note.Note, error) {
-	return note.Note{}, nil
+func (mNS *mockNotesSvc) Update(ctx context.Context, n note.Note, un note.UpdateNote) (note.Note, error) {
+	args := mNS.Called(n, un)
+	return args.Get(0).(note.Note), args.Error(1)
 }
 
-func (mNS *mockNotesSvc) Delete(noteID uuid.UUID) error {
+func (mNS *mockNotesSvc) UpdateFunc(ctx context.Context, noteID uuid.UUID, fn func(note.Note) (note.UpdateNote, error)) (note.Note, error) {
 	args := mNS.Called(noteID)
+	return args.Get(0).(note.Note), args.Error(1)
+}
+
+func (mNS *mockNotesSvc) Delete(ctx context.Context, noteID uuid.UUID) error {
+	args := mNS.Called(noteID)
+	return args.Error(0)
+}
+
+func (mNS *mockNotesSvc) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	args := mNS.Called(userID)
 	return args.Error(0)
 }
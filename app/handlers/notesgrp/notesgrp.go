@@ -1,30 +1,18 @@
 package notesgrp
 
 import (
-	"encoding/json"
-	"fmt"
-	"log/slog"
+	"errors"
 	"net/http"
 
 	"github.com/Keisn1/note-taking-app/app/api"
 	"github.com/Keisn1/note-taking-app/domain/core/note"
 	"github.com/Keisn1/note-taking-app/domain/web/mid"
+	"github.com/Keisn1/note-taking-app/foundation/web"
 	"github.com/google/uuid"
 )
 
-// import (
-// 	"encoding/json"
-// 	"fmt"
-// 	"log/slog"
-// 	"net/http"
-// 	"strconv"
-
-// 	"github.com/Keisn1/note-taking-app/app/api"
-// 	"github.com/Keisn1/note-taking-app/domain/core/note"
-// 	"github.com/Keisn1/note-taking-app/foundation"
-// 	"github.com/go-chi/chi/v5"
-// 	"github.com/google/uuid"
-// )
+// errModified tells that the note no longer matches the If-Match header.
+var errModified = errors.New("note was modified")
 
 type Handlers struct {
 	notesSvc note.Service
@@ -34,183 +22,173 @@ func NewHandlers(ns note.Service) Handlers {
 	return Handlers{notesSvc: ns}
 }
 
-// func (nc *Handlers) Edit(w http.ResponseWriter, r *http.Request) {
-// 	userID, ok := r.Context().Value(foundation.UserIDKey).(uuid.UUID)
-// 	if !ok {
-// 		logMsg := "Edit: invalid userID"
-// 		handleError(w, "", http.StatusBadRequest, logMsg)
-// 		return
-// 	}
-
-// 	noteID, err := strconv.Atoi(chi.URLParam(r, "noteID"))
-// 	if err != nil || noteID < 0 {
-// 		logMsg := fmt.Sprintf("Edit: invalid noteID %v", chi.URLParam(r, "noteID"))
-// 		handleError(w, "", http.StatusBadRequest, logMsg, "error", err)
-// 		return
-// 	}
-
-// 	var np api.NotePost
-// 	err = json.NewDecoder(r.Body).Decode(&np)
-// 	if err != nil {
-// 		handleError(w, "", http.StatusBadRequest, "Add: invalid body", "error", err)
-// 		return
-// 	}
-
-// 	err = nc.notesSvc.EditNote(userID, noteID, np.Note)
-// 	if err != nil {
-// 		logMsg := fmt.Sprintf("Edit: userID %v noteID %v body %v", userID, noteID, np)
-// 		handleError(w, "", http.StatusConflict, logMsg)
-// 		return
-// 	}
-
-// 	w.WriteHeader(http.StatusAccepted)
-// 	slog.Info(
-// 		fmt.Sprintf("Success: Edit: userID %v noteID %v body %v", userID, noteID, np),
-// 	)
-// }
-
-// func (nc *Handlers) Delete(w http.ResponseWriter, r *http.Request) {
-// 	userID, ok := r.Context().Value(foundation.UserIDKey).(uuid.UUID)
-// 	if !ok {
-// 		handleError(w, "", http.StatusBadRequest, "Delete: invalid userID")
-// 		return
-// 	}
-
-// 	noteID, err := strconv.Atoi(chi.URLParam(r, "noteID"))
-// 	if err != nil || noteID < 0 {
-// 		logMsg := fmt.Sprintf("Delete: invalid noteID %v", chi.URLParam(r, "noteID"))
-// 		handleError(w, "", http.StatusBadRequest, logMsg, "error", err)
-// 		return
-// 	}
-
-// 	err = nc.notesSvc.Delete(userID, noteID)
-// 	if err != nil {
-// 		handleError(w, "", http.StatusInternalServerError, "Delete: DBError")
-// 		return
-// 	}
-
-// 	w.WriteHeader(http.StatusNoContent)
-// 	slog.Info(fmt.Sprintf("Success: Delete: userID %v noteID %v", userID, noteID))
-// }
-
 func (hdl *Handlers) Create(w http.ResponseWriter, r *http.Request) {
 	userID := mid.GetUserID(r.Context())
 
 	var np api.NotePost
-	err := json.NewDecoder(r.Body).Decode(&np)
+	if err := web.Decode(w, r, &np); err != nil {
+		api.RespondDecodeError(w, r, err)
+		return
+	}
+
+	n, err := hdl.notesSvc.Create(r.Context(), toUpdateNote(np, userID))
+	if err != nil {
+		handleError(w, r, "", http.StatusConflict, "create note", "error", err)
+		return
+	}
+
+	w.Header().Set("ETag", api.NoteETag(n))
+	web.Respond(w, http.StatusAccepted, api.ToNoteResponse(n))
+	web.Logger(r.Context()).Info("note created", "note_id", n.ID)
+}
+
+// List returns a page of the notes of the user, ordered by title. The query
+// parameter title filters by title, q by title or content. Both match
+// case-insensitive substrings.
+func (hdl *Handlers) List(w http.ResponseWriter, r *http.Request) {
+	userID := mid.GetUserID(r.Context())
+
+	page, err := api.ParsePage(r)
 	if err != nil {
-		handleError(w, "", http.StatusBadRequest, "Add: invalid body", "error", err)
+		web.Respond(w, http.StatusBadRequest, api.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	f := note.Filter{UserID: userID, Title: r.URL.Query().Get("title"), Query: r.URL.Query().Get("q"), Limit: page.PerPage}
+	offset, ok := page.Offset()
+	if !ok {
+		// No such page, only the total is answered.
+		f.Limit = 0
+	}
+	f.Offset = offset
+
+	notes, total, err := hdl.notesSvc.QueryByFilter(r.Context(), f)
+	if err != nil {
+		handleError(w, r, "", http.StatusInternalServerError, "list notes", "error", err)
+		return
+	}
+
+	items := make([]api.NoteResponse, 0, len(notes))
+	for _, n := range notes {
+		items = append(items, api.ToNoteResponse(n))
+	}
+	web.Respond(w, http.StatusOK, api.NewListResponse(items, page, total))
+}
+
+// Get returns the note loaded by mid.AuthorizeNote. With render=html the
+// response also holds the content rendered to sanitised HTML and its table of
+// contents. Clients that prefer text/html over JSON get a standalone page.
+func (hdl *Handlers) Get(w http.ResponseWriter, r *http.Request) {
+	n := mid.GetNote(r.Context())
+
+	renderHTML := false
+	switch r.URL.Query().Get("render") {
+	case "":
+	case "html":
+		renderHTML = true
+	default:
+		web.Respond(w, http.StatusBadRequest, api.ErrorResponse{Error: "render must be html"})
+		return
+	}
+	asPage := web.Negotiate(r, "application/json", "text/html") == "text/html"
+
+	w.Header().Set("ETag", api.NoteETag(n))
+	w.Header().Add("Vary", "Accept")
+	if !renderHTML && !asPage {
+		web.Respond(w, http.StatusOK, api.ToNoteResponse(n))
 		return
 	}
 
-	n, err := hdl.notesSvc.Create(toUpdateNote(np, userID))
+	doc, err := render(n)
 	if err != nil {
-		logMsg := fmt.Sprintf("Add: userID %v body %v", userID, np)
-		handleError(w, "", http.StatusConflict, logMsg, "error", err)
+		handleError(w, r, "", http.StatusInternalServerError, "render note", "note_id", n.ID, "error", err)
+		return
+	}
+
+	if asPage {
+		if err := respondPage(w, n, doc); err != nil {
+			web.Logger(r.Context()).Error("render note", "note_id", n.ID, "error", err)
+		}
+		return
+	}
+	web.Respond(w, http.StatusOK, api.ToRenderedNoteResponse(n, doc))
+}
+
+// Update replaces title and content of the note. If the request has an
+// If-Match header, the note is only updated if it still matches the ETag.
+func (hdl *Handlers) Update(w http.ResponseWriter, r *http.Request) {
+	n := mid.GetNote(r.Context())
+
+	var np api.NotePost
+	if err := web.Decode(w, r, &np); err != nil {
+		api.RespondDecodeError(w, r, err)
 		return
 	}
 
-	data, err := json.Marshal(n)
+	hdl.update(w, r, n, toUpdateNote(np, n.UserID))
+}
+
+// Patch changes the fields of the note present in the request, see
+// api.NotePatch. If-Match is handled like by Update.
+func (hdl *Handlers) Patch(w http.ResponseWriter, r *http.Request) {
+	n := mid.GetNote(r.Context())
+
+	var np api.NotePatch
+	if err := web.Decode(w, r, &np); err != nil {
+		api.RespondDecodeError(w, r, err)
+		return
+	}
+
+	hdl.update(w, r, n, np.UpdateNote(n.UserID))
+}
+
+// update compares If-Match with the note read for the update, so that of
+// two requests with the same ETag only the first one succeeds.
+func (hdl *Handlers) update(w http.ResponseWriter, r *http.Request, n note.Note, un note.UpdateNote) {
+	ifMatch := r.Header.Get("If-Match")
+	updated, err := hdl.notesSvc.UpdateFunc(r.Context(), n.ID, func(cur note.Note) (note.UpdateNote, error) {
+		if ifMatch != "" && ifMatch != "*" && ifMatch != api.NoteETag(cur) {
+			return note.UpdateNote{}, errModified
+		}
+		return un, nil
+	})
+	if errors.Is(err, errModified) {
+		web.Respond(w, http.StatusPreconditionFailed, api.ErrorResponse{Error: errModified.Error()})
+		web.Logger(r.Context()).Info("update note: precondition failed", "note_id", n.ID)
+		return
+	}
 	if err != nil {
-		logMsg := fmt.Sprintf("Create: userID %v body %v", userID, np)
-		handleError(w, "", http.StatusInternalServerError, logMsg, "error", err)
+		handleError(w, r, "", http.StatusInternalServerError, "update note", "note_id", n.ID, "error", err)
 		return
 	}
 
-	w.WriteHeader(http.StatusAccepted)
-	w.Write(data)
-	slog.Info(
-		fmt.Sprintf("Success: Create: userID %v body %v", userID, np),
-	)
+	w.Header().Set("ETag", api.NoteETag(updated))
+	web.Respond(w, http.StatusOK, api.ToNoteResponse(updated))
+	web.Logger(r.Context()).Info("note updated", "note_id", n.ID)
 }
 
-// func (nc *Handlers) GetNotesByUserID(w http.ResponseWriter, r *http.Request) {
-// 	userID, ok := r.Context().Value(foundation.UserIDKey).(uuid.UUID)
-// 	if !ok {
-// 		logMsg := "GetNotesByUserID: invalid userID"
-// 		handleError(w, "", http.StatusBadRequest, logMsg)
-// 		return
-// 	}
-
-// 	notes, err := nc.notesSvc.GetNotesByUserID(userID)
-// 	if err != nil {
-// 		logMsg := fmt.Sprintf("GetNotesByUserID: userID %v", userID)
-// 		handleError(w, "", http.StatusInternalServerError, logMsg, "error", err)
-// 		return
-// 	}
-
-// 	err = json.NewEncoder(w).Encode(notes)
-// 	if err != nil {
-// 		logMsg := fmt.Sprintf("GetNotesByUserID: userID %v: json encoding error", userID)
-// 		handleError(w, "", http.StatusInternalServerError, logMsg, "error", err)
-// 		return
-// 	}
-
-// 	slog.Info(fmt.Sprintf("Success: GetNotesByUserID: userID %v", userID))
-// }
-
-// func (nc *Handlers) GetNoteByUserIDAndNoteID(w http.ResponseWriter, r *http.Request) {
-// 	userID, ok := r.Context().Value(foundation.UserIDKey).(uuid.UUID)
-// 	if !ok {
-// 		logMsg := "GetNoteByUserIDandNoteID: invalid userID"
-// 		handleError(w, "", http.StatusBadRequest, logMsg)
-// 		return
-// 	}
-
-// 	noteID, err := strconv.Atoi(chi.URLParam(r, "noteID"))
-// 	if err != nil || noteID < 0 {
-// 		logMsg := fmt.Sprintf("GetNoteByUserIDandNoteID: invalid noteID %v", chi.URLParam(r, "noteID"))
-// 		handleError(w, "", http.StatusBadRequest, logMsg, "error", err)
-// 		return
-// 	}
-
-// 	notes, err := nc.notesSvc.GetNoteByUserIDAndNoteID(userID, noteID)
-// 	if err != nil {
-// 		logMsg := fmt.Sprintf("GetNoteByUserIDAndNoteID: userID %v noteID %v", userID, noteID)
-// 		handleError(w, "", http.StatusInternalServerError, logMsg)
-// 		return
-// 	}
-
-// 	if len(notes) == 0 {
-// 		logMsg := fmt.Sprintf("GetNoteByUserIDAndNoteID: userID %v noteID %v", userID, noteID)
-// 		handleError(w, "", http.StatusNotFound, logMsg, "error", "Not Found")
-// 		return
-// 	}
-
-// 	err = json.NewEncoder(w).Encode(notes)
-// 	if err != nil {
-// 		logMsg := fmt.Sprintf("GetNoteByUserIDAndNoteID: userID %v noteID %v: json encoding error", userID, noteID)
-// 		handleError(w, "", http.StatusInternalServerError, logMsg, "error", err)
-// 		return
-// 	}
-
-// 	slog.Info(fmt.Sprintf(
-// 		"Success: GetNoteByUserIDAndNoteID: userID %v noteID %v", userID, noteID,
-// 	))
-// }
-
-// func (nc *Handlers) GetAllNotes(w http.ResponseWriter, r *http.Request) {
-// 	notes, err := nc.notesSvc.GetAllNotes()
-// 	if err != nil {
-// 		http.Error(w, "", http.StatusInternalServerError)
-// 		slog.Error("GetAllNotes: DBError")
-// 		return
-// 	}
-
-// 	err = json.NewEncoder(w).Encode(notes)
-// 	if err != nil {
-// 		handleError(w, "", http.StatusInternalServerError, "GetAllNotes: json encoding error", "error", err)
-// 		return
-// 	}
-
-// 	slog.Info("Success: GetAllNotes")
-// }
-
-func handleError(w http.ResponseWriter, errMsg string, status int, logMsg string, args ...any) {
+func (hdl *Handlers) Delete(w http.ResponseWriter, r *http.Request) {
+	n := mid.GetNote(r.Context())
+
+	if err := hdl.notesSvc.Delete(r.Context(), n.ID); err != nil {
+		handleError(w, r, "", http.StatusInternalServerError, "delete note", "note_id", n.ID, "error", err)
+		return
+	}
+
+	web.Respond(w, http.StatusNoContent, nil)
+	web.Logger(r.Context()).Info("note deleted", "note_id", n.ID)
+}
+
+func handleError(w http.ResponseWriter, r *http.Request, errMsg string, status int, logMsg string, args ...any) {
 	http.Error(w, errMsg, status)
-	slog.Error(logMsg, args...)
+	web.Logger(r.Context()).Error(logMsg, args...)
 }
 
 func toUpdateNote(np api.NotePost, userID uuid.UUID) note.UpdateNote {
-	return note.UpdateNote{Title: note.NewTitle(np.Title), Content: note.NewContent(np.Content), UserID: userID}
+	return note.UpdateNote{
+		Title:   note.NewTitle(np.Title),
+		Content: note.NewContent(np.Content),
+		Format:  note.Format(np.Format),
+		UserID:  userID,
+	}
 }
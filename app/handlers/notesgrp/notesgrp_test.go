@@ -10,13 +10,21 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"fmt"
 
 	"github.com/Keisn1/note-taking-app/app/api"
 	"github.com/Keisn1/note-taking-app/app/handlers/notesgrp"
 	"github.com/Keisn1/note-taking-app/domain/core/note"
+	notememory "github.com/Keisn1/note-taking-app/domain/core/note/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	usermemory "github.com/Keisn1/note-taking-app/domain/core/user/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/web/auth"
+	"github.com/Keisn1/note-taking-app/domain/web/mux"
 	"github.com/Keisn1/note-taking-app/foundation"
+	"github.com/Keisn1/note-taking-app/foundation/common"
+	"github.com/Keisn1/note-taking-app/foundation/markdown"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
@@ -72,12 +80,13 @@ func Test_Create(t *testing.T) {
 			},
 			wantStatus: http.StatusAccepted,
 			wantBody: func(userID uuid.UUID, body api.NotePost) string {
-				return mustEncode(t, note.Note{ID: uuid.UUID{1}, Title: note.NewTitle(body.Title), Content: note.NewContent(body.Content), UserID: userID})
+				return mustEncode(t, api.NoteResponse{ID: uuid.UUID{1}, Title: body.Title, Content: body.Content, UserID: userID})
 			},
 			wantLogging: func(userID uuid.UUID, body api.NotePost) []string {
 				return []string{
 					"INFO",
-					fmt.Sprintf("Success: Create: userID %v body %v", userID, body)}
+					"note created",
+					fmt.Sprintf("note_id=%s", uuid.UUID{1})}
 			},
 			assertions: func(t *testing.T, rr *httptest.ResponseRecorder, wantStatus int, wantBody string, wL []string, mNSP mockNotesStoreParams) {
 				assert.Equal(t, wantStatus, rr.Code)
@@ -166,561 +175,384 @@ func Test_Create(t *testing.T) {
 	}
 }
 
-// func TestGetAllNotes(t *testing.T) {
-// 	mNotesStore := &mockNotesSvc{}
-// 	hdl := notesgrp.NewHandlers(mNotesStore)
-// 	logBuf := &bytes.Buffer{}
-// 	log.SetOutput(logBuf)
-
-// 	type testCase struct {
-// 		name        string
-// 		mNSP        mockNotesStoreParams
-// 		wantStatus  int
-// 		wantBody    string
-// 		wantLogging []string
-// 		assertions  func(t *testing.T, rr *httptest.ResponseRecorder, wantStatus int, wantBody string, wL []string, mNSP mockNotesStoreParams)
-// 	}
-
-// 	testNotes := domain.Notes{
-// 		{NoteID: 1, UserID: uuid.New(), Note: "Note 1 user 1"},
-// 		{NoteID: 2, UserID: uuid.New(), Note: "Note 2 user 1"},
-// 		{NoteID: 3, UserID: uuid.New(), Note: "Note 1 user 2"},
-// 		{NoteID: 4, UserID: uuid.New(), Note: "Note 2 user 2"},
-// 	}
-// 	testCases := []testCase{
-
-// 		{
-// 			name:        "GetAllNotes Success",
-// 			mNSP:        mockNotesStoreParams{method: "GetAllNotes", returnArguments: []any{testNotes, nil}},
-// 			wantStatus:  http.StatusOK,
-// 			wantBody:    mustEncode(t, testNotes).String(),
-// 			wantLogging: []string{"Success: GetAllNotes"},
-// 			assertions: func(t *testing.T, rr *httptest.ResponseRecorder, wantStatus int, wantBody string, wL []string, callAssertion mockNotesStoreParams) {
-// 				assert.Equal(t, wantStatus, rr.Code)
-// 				assert.Equal(t, rr.Body.String(), wantBody)
-// 				for _, logMsg := range wL {
-// 					assert.Contains(t, logBuf.String(), logMsg)
-// 				}
-// 				mNotesStore.AssertCalled(t, callAssertion.method, callAssertion.arguments...)
-// 			},
-// 		},
-// 		{
-// 			name: "GetAllNotes Error DB",
-// 			mNSP: mockNotesStoreParams{
-// 				method:          "GetAllNotes",
-// 				returnArguments: []any{domain.Notes{}, errors.New("error notesStore.GetAllNotes")},
-// 			},
-// 			wantBody:    fmt.Sprintln(""),
-// 			wantStatus:  http.StatusInternalServerError,
-// 			wantLogging: []string{"ERROR", "GetAllNotes: DBError"},
-// 			assertions: func(t *testing.T, rr *httptest.ResponseRecorder, wantStatus int, wantBody string, wL []string, callAssertion mockNotesStoreParams) {
-// 				assert.Equal(t, wantStatus, rr.Code)
-// 				assert.Equal(t, rr.Body.String(), wantBody)
-// 				mNotesStore.AssertCalled(t, callAssertion.method, callAssertion.arguments...)
-// 				for _, logMsg := range wL {
-// 					assert.Contains(t, logBuf.String(), logMsg)
-// 				}
-// 			},
-// 		},
-// 	}
-
-// 	for _, tc := range testCases {
-// 		logBuf.Reset()
-// 		mNotesStore.Setup(tc.mNSP)
-// 		req := setupRequest(t, "GET", "/notes", nil, "", &bytes.Buffer{})
-// 		rr := httptest.NewRecorder()
-// 		hdl.GetAllNotes(rr, req)
-// 		tc.assertions(t, rr, tc.wantStatus, tc.wantBody, tc.wantLogging, tc.mNSP)
-// 	}
-// }
-
-// func TestGetNotesByUserID(t *testing.T) {
-// 	mNotesStore := &mockNotesSvc{}
-// 	hdl := notesgrp.NewHandlers(mNotesStore)
-// 	logBuf := &bytes.Buffer{}
-// 	log.SetOutput(logBuf)
-
-// 	type testCase struct {
-// 		name        string
-// 		userID      any
-// 		mNSP        func(userID any) mockNotesStoreParams
-// 		wantStatus  int
-// 		wantBody    func(userID any) string
-// 		wantLogging func(userID any) []string
-// 		assertions  func(t *testing.T, rr *httptest.ResponseRecorder, wantStatus int, wantBody string, wL []string, mNSP mockNotesStoreParams)
-// 	}
-
-// 	testCases := []testCase{
-// 		{
-// 			name:   "GetNotesByUserID success",
-// 			userID: uuid.New(),
-// 			mNSP: func(userID any) mockNotesStoreParams {
-// 				uid := userID.(uuid.UUID)
-// 				return mockNotesStoreParams{
-// 					method:    "GetNotesByUserID",
-// 					arguments: []any{userID},
-// 					returnArguments: []any{domain.Notes{
-// 						{NoteID: 1, UserID: uid, Note: "Note 1 user 1"},
-// 						{NoteID: 2, UserID: uid, Note: "Note 2 user 1"},
-// 					}, nil},
-// 				}
-// 			},
-// 			wantStatus: http.StatusOK,
-// 			wantBody: func(userID any) string {
-// 				uid := userID.(uuid.UUID)
-// 				return mustEncode(t, domain.Notes{
-// 					{NoteID: 1, UserID: uid, Note: "Note 1 user 1"},
-// 					{NoteID: 2, UserID: uid, Note: "Note 2 user 1"},
-// 				}).String()
-// 			},
-// 			wantLogging: func(userID any) []string {
-// 				return []string{
-// 					"INFO",
-// 					fmt.Sprintf("Success: GetNotesByUserID: userID %v", userID),
-// 				}
-// 			},
-// 			assertions: func(t *testing.T, rr *httptest.ResponseRecorder, wantStatus int, wantBody string, wL []string, mNSP mockNotesStoreParams) {
-// 				assert.Equal(t, wantStatus, rr.Code)
-// 				assert.Equal(t, wantBody, rr.Body.String())
-// 				mNotesStore.AssertCalled(t, mNSP.method, mNSP.arguments...)
-// 				for _, logMsg := range wL {
-// 					assert.Contains(t, logBuf.String(), logMsg)
-// 				}
-// 			},
-// 		},
-// 		{
-// 			name:       "GetNotesByUserID invalid userID",
-// 			userID:     123,
-// 			mNSP:       func(userID any) mockNotesStoreParams { return mockNotesStoreParams{} },
-// 			wantStatus: http.StatusBadRequest,
-// 			wantBody:   func(userID any) string { return fmt.Sprintln("") },
-// 			wantLogging: func(userID any) []string {
-// 				return []string{
-// 					"ERROR",
-// 					"GetNotesByUserID: invalid userID",
-// 				}
-// 			},
-// 			assertions: func(t *testing.T, rr *httptest.ResponseRecorder, wantStatus int, wantBody string, wL []string, mNSP mockNotesStoreParams) {
-// 				assert.Equal(t, wantStatus, rr.Code)
-// 				assert.Equal(t, wantBody, rr.Body.String())
-// 				mNotesStore.AssertNotCalled(t, "GetNotesByUserID")
-// 				for _, logMsg := range wL {
-// 					assert.Contains(t, logBuf.String(), logMsg)
-// 				}
-// 			},
-// 		},
-// 		{
-// 			name:   "GetNotesByUserID DBError",
-// 			userID: uuid.New(),
-// 			mNSP: func(userID any) mockNotesStoreParams {
-// 				return mockNotesStoreParams{
-// 					method:          "GetNotesByUserID",
-// 					arguments:       []any{userID},
-// 					returnArguments: []any{domain.Notes{}, errors.New("error notesStore.GetNotesByUserID")},
-// 				}
-// 			},
-// 			wantStatus: http.StatusInternalServerError,
-// 			wantBody:   func(userID any) string { return fmt.Sprintln("") },
-// 			wantLogging: func(userID any) []string {
-// 				return []string{
-// 					"ERROR",
-// 					fmt.Sprintf("GetNotesByUserID: userID %v", userID),
-// 					"error notesStore.GetNotesByUserID",
-// 				}
-// 			},
-// 			assertions: func(t *testing.T, rr *httptest.ResponseRecorder, wantStatus int, wantBody string, wL []string, callAssertion mockNotesStoreParams) {
-// 				assert.Equal(t, wantStatus, rr.Code)
-// 				assert.Equal(t, wantBody, rr.Body.String())
-// 				mNotesStore.AssertCalled(t, callAssertion.method, callAssertion.arguments...)
-// 				for _, logMsg := range wL {
-// 					assert.Contains(t, logBuf.String(), logMsg)
-// 				}
-// 			},
-// 		},
-// 	}
-
-// 	for _, tc := range testCases {
-// 		logBuf.Reset()
-// 		mNotesStore.Setup(tc.mNSP(tc.userID))
-// 		req := setupRequest(t, "GET", "/users/notes", tc.userID, "", &bytes.Buffer{})
-// 		rr := httptest.NewRecorder()
-// 		hdl.GetNotesByUserID(rr, req)
-// 		tc.assertions(t, rr, tc.wantStatus, tc.wantBody(tc.userID), tc.wantLogging(tc.userID), tc.mNSP(tc.userID))
-// 	}
-// }
-
-// func TestGetNoteByUserIDandNoteID(t *testing.T) {
-// 	mNotesStore := &mockNotesSvc{}
-// 	hdl := notesgrp.NewHandlers(mNotesStore)
-// 	logBuf := &bytes.Buffer{}
-// 	log.SetOutput(logBuf)
-
-// 	type testCase struct {
-// 		name        string
-// 		userID      any
-// 		noteID      string
-// 		mNSP        func(userID any, noteID string) mockNotesStoreParams
-// 		wantStatus  int
-// 		wantBody    func(userID any, noteID string) string
-// 		wantLogging func(userID any, noteID string) []string
-// 		assertions  func(t *testing.T, rr *httptest.ResponseRecorder, wantStatus int, wantBody string, wL []string, mNSP mockNotesStoreParams)
-// 	}
-
-// 	testCases := []testCase{
-// 		{
-// 			name:   "GetNoteByUserIDandNoteID success",
-// 			userID: uuid.New(),
-// 			noteID: "1",
-// 			mNSP: func(userID any, noteID string) mockNotesStoreParams {
-// 				uid := userID.(uuid.UUID)
-// 				nid := mustAtoi(t, noteID)
-// 				return mockNotesStoreParams{
-// 					method:    "GetNoteByUserIDAndNoteID",
-// 					arguments: []any{uid, nid},
-// 					returnArguments: []any{
-// 						domain.Notes{{UserID: uid, NoteID: nid, Note: "test note"}},
-// 						nil,
-// 					},
-// 				}
-// 			},
-// 			wantStatus: http.StatusOK,
-// 			wantBody: func(userID any, noteID string) string {
-// 				uid := userID.(uuid.UUID)
-// 				nid := mustAtoi(t, noteID)
-// 				return mustEncode(t, domain.Notes{{UserID: uid, NoteID: nid, Note: "test note"}}).String()
-// 			},
-// 			wantLogging: func(userID any, noteID string) []string {
-// 				return []string{fmt.Sprintf("Success: GetNoteByUserIDAndNoteID: userID %v noteID %v", userID, noteID)}
-// 			},
-// 			assertions: func(t *testing.T, rr *httptest.ResponseRecorder, wantStatus int, wantBody string, wL []string, callAssertion mockNotesStoreParams) {
-// 				assert.Equal(t, wantStatus, rr.Code)
-// 				assert.Equal(t, wantBody, rr.Body.String())
-// 				mNotesStore.AssertCalled(t, callAssertion.method, callAssertion.arguments...)
-// 				for _, logMsg := range wL {
-// 					assert.Contains(t, logBuf.String(), logMsg)
-// 				}
-// 			},
-// 		},
-// 		{
-// 			name:       "GetNoteByUserIDandNoteID invalid userID",
-// 			userID:     "invalid userID",
-// 			noteID:     "1",
-// 			mNSP:       func(userID any, noteID string) mockNotesStoreParams { return mockNotesStoreParams{} },
-// 			wantStatus: http.StatusBadRequest,
-// 			wantBody:   func(userID any, noteID string) string { return fmt.Sprintln("") },
-// 			wantLogging: func(userID any, noteID string) []string {
-// 				return []string{
-// 					"ERROR",
-// 					"GetNoteByUserIDandNoteID: invalid userID",
-// 				}
-// 			},
-// 			assertions: func(t *testing.T, rr *httptest.ResponseRecorder, wantStatus int, wantBody string, wL []string, mNSP mockNotesStoreParams) {
-// 				assert.Equal(t, wantStatus, rr.Code)
-// 				assert.Equal(t, wantBody, rr.Body.String())
-// 				mNotesStore.AssertNotCalled(t, "GetNotesByUserID")
-// 				for _, logMsg := range wL {
-// 					assert.Contains(t, logBuf.String(), logMsg)
-// 				}
-// 			},
-// 		},
-// 		{
-// 			name:       "GetNoteByUserIDandNoteID invalid noteID",
-// 			userID:     uuid.New(),
-// 			noteID:     "-1",
-// 			mNSP:       func(userID any, noteID string) mockNotesStoreParams { return mockNotesStoreParams{} },
-// 			wantStatus: http.StatusBadRequest,
-// 			wantBody:   func(userID any, noteID string) string { return fmt.Sprintln("") },
-// 			wantLogging: func(userID any, noteID string) []string {
-// 				return []string{
-// 					"ERROR",
-// 					"GetNoteByUserIDandNoteID: invalid noteID",
-// 				}
-// 			},
-// 			assertions: func(t *testing.T, rr *httptest.ResponseRecorder, wantStatus int, wantBody string, wL []string, mNSP mockNotesStoreParams) {
-// 				assert.Equal(t, wantStatus, rr.Code)
-// 				assert.Equal(t, wantBody, rr.Body.String())
-// 				mNotesStore.AssertNotCalled(t, "GetNotesByUserID")
-// 				for _, logMsg := range wL {
-// 					assert.Contains(t, logBuf.String(), logMsg)
-// 				}
-// 			},
-// 		},
-// 		{
-// 			name:       "GetNoteByUserIDandNoteID invalid noteID",
-// 			userID:     uuid.New(),
-// 			noteID:     "invalid noteID",
-// 			mNSP:       func(userID any, noteID string) mockNotesStoreParams { return mockNotesStoreParams{} },
-// 			wantStatus: http.StatusBadRequest,
-// 			wantBody:   func(userID any, noteID string) string { return fmt.Sprintln("") },
-// 			wantLogging: func(userID any, noteID string) []string {
-// 				return []string{
-// 					"ERROR",
-// 					"GetNoteByUserIDandNoteID: invalid noteID",
-// 				}
-// 			},
-// 			assertions: func(t *testing.T, rr *httptest.ResponseRecorder, wantStatus int, wantBody string, wL []string, mNSP mockNotesStoreParams) {
-// 				assert.Equal(t, wantStatus, rr.Code)
-// 				assert.Equal(t, wantBody, rr.Body.String())
-// 				mNotesStore.AssertNotCalled(t, "GetNotesByUserID")
-// 				for _, logMsg := range wL {
-// 					assert.Contains(t, logBuf.String(), logMsg)
-// 				}
-// 			},
-// 		},
-
-// 		{
-// 			name:   "GetNoteByUserIDandNoteID DBError",
-// 			userID: uuid.New(),
-// 			noteID: "1",
-// 			mNSP: func(userID any, noteID string) mockNotesStoreParams {
-// 				uid := userID.(uuid.UUID)
-// 				nid := mustAtoi(t, noteID)
-// 				return mockNotesStoreParams{
-// 					method:    "GetNoteByUserIDAndNoteID",
-// 					arguments: []any{uid, nid},
-// 					returnArguments: []any{
-// 						domain.Notes{},
-// 						errors.New("error notesStore.GetNoteByUserIDAndNoteID"),
-// 					},
-// 				}
-// 			},
-// 			wantStatus: http.StatusInternalServerError,
-// 			wantBody:   func(userID any, noteID string) string { return fmt.Sprintln("") },
-// 			wantLogging: func(userID any, noteID string) []string {
-// 				return []string{
-// 					"ERROR",
-// 					fmt.Sprintf("GetNoteByUserIDAndNoteID: userID %v noteID %v", userID, noteID),
-// 				}
-// 			},
-// 			assertions: func(t *testing.T, rr *httptest.ResponseRecorder, wantStatus int, wantBody string, wL []string, callAssertion mockNotesStoreParams) {
-// 				assert.Equal(t, wantStatus, rr.Code)
-// 				assert.Equal(t, wantBody, rr.Body.String())
-// 				mNotesStore.AssertCalled(t, callAssertion.method, callAssertion.arguments...)
-// 				for _, logMsg := range wL {
-// 					assert.Contains(t, logBuf.String(), logMsg)
-// 				}
-// 			},
-// 		},
-// 		{
-// 			name:   "GetNoteByUserIDandNoteID Not found if empty return from DB",
-// 			userID: uuid.New(),
-// 			noteID: "1",
-// 			mNSP: func(userID any, noteID string) mockNotesStoreParams {
-// 				uid := userID.(uuid.UUID)
-// 				nid := mustAtoi(t, noteID)
-// 				return mockNotesStoreParams{
-// 					method:          "GetNoteByUserIDAndNoteID",
-// 					arguments:       []any{uid, nid},
-// 					returnArguments: []any{domain.Notes{}, nil},
-// 				}
-// 			},
-// 			wantStatus: http.StatusNotFound,
-// 			wantBody:   func(userID any, noteID string) string { return fmt.Sprintln("") },
-// 			wantLogging: func(userID any, noteID string) []string {
-// 				return []string{
-// 					"ERROR",
-// 					fmt.Sprintf("GetNoteByUserIDAndNoteID: userID %v noteID %v", userID, noteID),
-// 					"Not Found",
-// 				}
-// 			},
-// 			assertions: func(t *testing.T, rr *httptest.ResponseRecorder, wantStatus int, wantBody string, wL []string, callAssertion mockNotesStoreParams) {
-// 				assert.Equal(t, wantStatus, rr.Code)
-// 				assert.Equal(t, wantBody, rr.Body.String())
-// 				mNotesStore.AssertCalled(t, callAssertion.method, callAssertion.arguments...)
-// 				for _, logMsg := range wL {
-// 					assert.Contains(t, logBuf.String(), logMsg)
-// 				}
-// 			},
-// 		},
-// 	}
-
-// 	for _, tc := range testCases {
-// 		logBuf.Reset()
-// 		mNotesStore.Setup(tc.mNSP(tc.userID, tc.noteID))
-
-// 		req := setupRequest(t, "GET", "/users/notes/{noteID}", tc.userID, tc.noteID, &bytes.Buffer{})
-// 		rr := httptest.NewRecorder()
-// 		hdl.GetNoteByUserIDAndNoteID(rr, req)
-
-// 		tc.assertions(t, rr, tc.wantStatus, tc.wantBody(tc.userID, tc.noteID), tc.wantLogging(tc.userID, tc.noteID), tc.mNSP(tc.userID, tc.noteID))
-// 	}
-// }
-
-// func TestDelete(t *testing.T) {
-// 	mNotesStore := &mockNotesSvc{}
-// 	hdl := notesgrp.NewHandlers(mNotesStore)
-// 	logBuf := &bytes.Buffer{}
-// 	log.SetOutput(logBuf)
-
-// 	type testCase struct {
-// 		name        string
-// 		userID      any
-// 		noteID      string
-// 		mNSP        func(userID any, noteID string) mockNotesStoreParams
-// 		wantStatus  int
-// 		wantBody    string
-// 		wantLogging func(userID any, noteID string) []string
-// 		assertions  func(t *testing.T, rr *httptest.ResponseRecorder, wantStatus int, wantBody string, wL []string, mNSP mockNotesStoreParams)
-// 	}
-
-// 	testCases := []testCase{
-// 		{
-// 			name:   "Success Deletion",
-// 			userID: uuid.New(),
-// 			noteID: "1",
-// 			mNSP: func(userID any, noteID string) mockNotesStoreParams {
-// 				uid := userID.(uuid.UUID)
-// 				nid := mustAtoi(t, noteID)
-// 				return mockNotesStoreParams{
-// 					method:          "Delete",
-// 					arguments:       []any{uid, nid},
-// 					returnArguments: []any{nil},
-// 				}
-// 			},
-// 			wantStatus: http.StatusNoContent,
-// 			wantBody:   "",
-// 			wantLogging: func(userID any, noteID string) []string {
-// 				return []string{
-// 					"INFO",
-// 					fmt.Sprintf("Success: Delete: userID %v noteID %v", userID, noteID),
-// 				}
-// 			},
-// 			assertions: func(t *testing.T, rr *httptest.ResponseRecorder, wantStatus int, wantBody string, wL []string, callAssertion mockNotesStoreParams) {
-// 				assert.Equal(t, wantStatus, rr.Code)
-// 				assert.Equal(t, wantBody, rr.Body.String())
-// 				mNotesStore.AssertCalled(t, callAssertion.method, callAssertion.arguments...)
-// 				for _, logMsg := range wL {
-// 					assert.Contains(t, logBuf.String(), logMsg)
-// 				}
-// 			},
-// 		},
-// 		{
-// 			name:       "Delete invalid userID",
-// 			userID:     "invalid userID",
-// 			noteID:     "1",
-// 			mNSP:       func(userID any, noteID string) mockNotesStoreParams { return mockNotesStoreParams{} },
-// 			wantStatus: http.StatusBadRequest,
-// 			wantBody:   fmt.Sprintln(""),
-// 			wantLogging: func(userID any, noteID string) []string {
-// 				return []string{
-// 					"ERROR",
-// 					"Delete: invalid userID",
-// 				}
-// 			},
-// 			assertions: func(t *testing.T, rr *httptest.ResponseRecorder, wantStatus int, wantBody string, wL []string, mNSP mockNotesStoreParams) {
-// 				assert.Equal(t, wantStatus, rr.Code)
-// 				assert.Equal(t, wantBody, rr.Body.String())
-// 				mNotesStore.AssertNotCalled(t, "Delete")
-// 				for _, logMsg := range wL {
-// 					assert.Contains(t, logBuf.String(), logMsg)
-// 				}
-// 			},
-// 		},
-// 		{
-// 			name:       "Delete invalid noteID",
-// 			userID:     uuid.New(),
-// 			noteID:     "-1",
-// 			mNSP:       func(userID any, noteID string) mockNotesStoreParams { return mockNotesStoreParams{} },
-// 			wantStatus: http.StatusBadRequest,
-// 			wantBody:   fmt.Sprintln(""),
-// 			wantLogging: func(userID any, noteID string) []string {
-// 				return []string{
-// 					"ERROR",
-// 					"Delete: invalid noteID",
-// 				}
-// 			},
-// 			assertions: func(t *testing.T, rr *httptest.ResponseRecorder, wantStatus int, wantBody string, wL []string, mNSP mockNotesStoreParams) {
-// 				assert.Equal(t, wantStatus, rr.Code)
-// 				assert.Equal(t, wantBody, rr.Body.String())
-// 				mNotesStore.AssertNotCalled(t, "Delete")
-// 				for _, logMsg := range wL {
-// 					assert.Contains(t, logBuf.String(), logMsg)
-// 				}
-// 			},
-// 		},
-// 		{
-// 			name:       "Delete invalid noteID",
-// 			userID:     uuid.New(),
-// 			noteID:     "invalid noteID",
-// 			mNSP:       func(userID any, noteID string) mockNotesStoreParams { return mockNotesStoreParams{} },
-// 			wantStatus: http.StatusBadRequest,
-// 			wantBody:   fmt.Sprintln(""),
-// 			wantLogging: func(userID any, noteID string) []string {
-// 				return []string{
-// 					"ERROR",
-// 					"Delete: invalid noteID",
-// 				}
-// 			},
-// 			assertions: func(t *testing.T, rr *httptest.ResponseRecorder, wantStatus int, wantBody string, wL []string, mNSP mockNotesStoreParams) {
-// 				assert.Equal(t, wantStatus, rr.Code)
-// 				assert.Equal(t, wantBody, rr.Body.String())
-// 				mNotesStore.AssertNotCalled(t, "Delete")
-// 				for _, logMsg := range wL {
-// 					assert.Contains(t, logBuf.String(), logMsg)
-// 				}
-// 			},
-// 		},
-// 		{
-// 			name:   "Delete DBError",
-// 			userID: uuid.New(),
-// 			noteID: "1",
-// 			mNSP: func(userID any, noteID string) mockNotesStoreParams {
-// 				uid := userID.(uuid.UUID)
-// 				nid := mustAtoi(t, noteID)
-// 				return mockNotesStoreParams{
-// 					method:          "Delete",
-// 					arguments:       []any{uid, nid},
-// 					returnArguments: []any{errors.New("error notesStore.Delete")},
-// 				}
-// 			},
-// 			wantStatus: http.StatusInternalServerError,
-// 			wantBody:   fmt.Sprintln(""),
-// 			wantLogging: func(userID any, noteID string) []string {
-// 				return []string{
-// 					"ERROR",
-// 					"Delete: DBError",
-// 				}
-// 			},
-// 			assertions: func(t *testing.T, rr *httptest.ResponseRecorder, wantStatus int, wantBody string, wL []string, mNSP mockNotesStoreParams) {
-// 				assert.Equal(t, wantStatus, rr.Code)
-// 				assert.Equal(t, wantBody, rr.Body.String())
-// 				mNotesStore.AssertNotCalled(t, "Delete")
-// 				for _, logMsg := range wL {
-// 					assert.Contains(t, logBuf.String(), logMsg)
-// 				}
-// 			},
-// 		},
-// 	}
-
-// 	for _, tc := range testCases {
-// 		logBuf.Reset()
-// 		mNotesStore.Setup(tc.mNSP(tc.userID, tc.noteID))
-
-// 		req := setupRequest(t, "DELETE", "/users/notes/{noteID}", tc.userID, tc.noteID, &bytes.Buffer{})
-// 		rr := httptest.NewRecorder()
-// 		hdl.Delete(rr, req)
-
-// 		tc.assertions(t, rr, tc.wantStatus, tc.wantBody, tc.wantLogging(tc.userID, tc.noteID), tc.mNSP(tc.userID, tc.noteID))
-// 	}
-// }
-
-// // WithUrlParam returns a pointer to a request object with the given URL params
-// // added to a new chi.Context object.
-// type Params map[string]string
-
-// func WithUrlParams(r *http.Request, params Params) *http.Request {
-// 	chiCtx := chi.NewRouteContext()
-// 	req := r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, chiCtx))
-// 	for key, value := range params {
-// 		chiCtx.URLParams.Add(key, value)
-// 	}
-// 	return req
-// }
-
-// func mustAtoi(t *testing.T, s string) int {
-// 	i, err := strconv.Atoi(s)
-// 	if err != nil {
-// 		t.Fatal()
-// 	}
-// 	return i
-// }
+func Test_Routes(t *testing.T) {
+	key := common.MustGenerateRandomKey(32)
+	jwtSvc := auth.MustNewJWTService(key)
+	mNotesSvc := &mockNotesSvc{}
+	userID := uuid.New()
+	cfg := mux.Config{Auth: auth.NewAuth(jwtSvc), NoteSvc: mNotesSvc, UserSvc: user.NewSvc(usermemory.NewRepo([]user.User{{ID: userID}}))}
+	app := mux.NewAPI(notesgrp.Routes, cfg)
+
+	t.Run("Notes routes require authentication", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/notes", strings.NewReader(`{"title": "t"}`))
+		app.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		mNotesSvc.AssertNotCalled(t, "Create")
+	})
+
+	t.Run("Authenticated user can create a note", func(t *testing.T) {
+		body := api.NotePost{Title: "title", Content: "content"}
+		updateN := note.UpdateNote{Title: note.NewTitle(body.Title), Content: note.NewContent(body.Content), UserID: userID}
+		mNotesSvc.Setup(mockNotesStoreParams{method: "Create", arguments: []any{updateN}, returnArguments: []any{note.Note{}, nil}})
+
+		tokenS, err := jwtSvc.CreateToken(userID, time.Minute)
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/notes", strings.NewReader(mustEncode(t, body)))
+		req.Header.Set("Authorization", "Bearer "+tokenS)
+		app.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+		mNotesSvc.AssertCalled(t, "Create", updateN)
+	})
+}
+
+func Test_Create_InvalidBody(t *testing.T) {
+	mNotesSvc := &mockNotesSvc{}
+	hdl := notesgrp.NewHandlers(mNotesSvc)
+
+	testCases := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "empty body",
+			body:       "",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"decode: request body is empty"}`,
+		},
+		{
+			name:       "malformed json",
+			body:       `{"title": `,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"decode: unexpected EOF"}`,
+		},
+		{
+			name:       "unknown field",
+			body:       `{"title": "title", "note": "content"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"decode: json: unknown field \"note\""}`,
+		},
+		{
+			name:       "more than one value",
+			body:       `{"title": "title"} {"title": "title"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"decode: body must contain a single JSON value"}`,
+		},
+		{
+			name:       "invalid utf-8",
+			body:       "{\"title\": \"\xff\"}",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"decode: request body is not valid UTF-8"}`,
+		},
+		{
+			name:       "missing title",
+			body:       `{"content": "content"}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantBody:   `{"error":"validation failed","fields":[{"field":"title","error":"is required"}]}`,
+		},
+		{
+			name:       "body too large",
+			body:       fmt.Sprintf(`{"title": "title", "content": "%s"}`, strings.Repeat("a", 2<<20)),
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantBody:   `{"error":"request body too large"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := setupRequest(t, http.MethodPost, "/notes", uuid.New())
+			req.Body = io.NopCloser(strings.NewReader(tc.body))
+			rr := httptest.NewRecorder()
+
+			hdl.Create(rr, req)
+			assert.Equal(t, tc.wantStatus, rr.Code)
+			assert.JSONEq(t, tc.wantBody, rr.Body.String())
+			mNotesSvc.AssertNotCalled(t, "Create")
+		})
+	}
+}
+
+func Test_NoteRoutes(t *testing.T) {
+	rob := user.User{ID: uuid.UUID{1}, Name: user.NewName("rob"), Email: user.NewEmail("rob@example.com")}
+	anna := user.User{ID: uuid.UUID{2}, Name: user.NewName("anna"), Email: user.NewEmail("anna@example.com")}
+	notes := []note.Note{
+		{ID: uuid.UUID{11}, Title: note.NewTitle("Groceries"), Content: note.NewContent("milk, eggs"), UserID: rob.ID},
+		{ID: uuid.UUID{12}, Title: note.NewTitle("Ideas"), Content: note.NewContent("buy more milk"), UserID: rob.ID},
+		{ID: uuid.UUID{13}, Title: note.NewTitle("Anna's note"), Content: note.NewContent("milk"), UserID: anna.ID},
+	}
+
+	jwtSvc := auth.MustNewJWTService(common.MustGenerateRandomKey(32))
+	userSvc := user.NewSvc(usermemory.NewRepo([]user.User{rob, anna}))
+	noteSvc := note.NewNotesService(notememory.MustNewRepo(notes), userSvc)
+	app := mux.NewAPI(notesgrp.Routes, mux.Config{Auth: auth.NewAuth(jwtSvc), NoteSvc: noteSvc, UserSvc: userSvc})
+
+	tokenS, err := jwtSvc.CreateToken(rob.ID, time.Minute)
+	assert.NoError(t, err)
+
+	do := func(method, target, body string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		req.Header.Set("Authorization", "Bearer "+tokenS)
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, req)
+		return rr
+	}
+
+	titles := func(t *testing.T, rr *httptest.ResponseRecorder) []string {
+		var resp api.ListResponse[api.NoteResponse]
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		var titles []string
+		for _, n := range resp.Items {
+			titles = append(titles, n.Title)
+		}
+		return titles
+	}
+
+	t.Run("List notes of the user", func(t *testing.T) {
+		rr := do(http.MethodGet, "/notes", "", nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, []string{"Groceries", "Ideas"}, titles(t, rr))
+	})
+
+	t.Run("Paginate notes", func(t *testing.T) {
+		rr := do(http.MethodGet, "/notes?page=2&per_page=1", "", nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"items": [`+mustEncode(t, api.ToNoteResponse(notes[1]))+`], "page": 2, "per_page": 1, "total": 2}`, rr.Body.String())
+
+		rr = do(http.MethodGet, "/notes?page=3&per_page=1", "", nil)
+		assert.JSONEq(t, `{"items": [], "page": 3, "per_page": 1, "total": 2}`, rr.Body.String())
+
+		rr = do(http.MethodGet, "/notes?page=9223372036854775807&per_page=200", "", nil)
+		assert.JSONEq(t, `{"items": [], "page": 9223372036854775807, "per_page": 200, "total": 2}`, rr.Body.String())
+
+		for _, query := range []string{"page=0", "page=x", "per_page=0", "per_page=201"} {
+			rr = do(http.MethodGet, "/notes?"+query, "", nil)
+			assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+		}
+	})
+
+	t.Run("Filter notes", func(t *testing.T) {
+		rr := do(http.MethodGet, "/notes?title=GROC", "", nil)
+		assert.Equal(t, []string{"Groceries"}, titles(t, rr))
+
+		rr = do(http.MethodGet, "/notes?q=more", "", nil)
+		assert.Equal(t, []string{"Ideas"}, titles(t, rr))
+
+		rr = do(http.MethodGet, "/notes?q=nothing", "", nil)
+		assert.JSONEq(t, `{"items": [], "page": 1, "per_page": 50, "total": 0}`, rr.Body.String())
+	})
+
+	t.Run("Get a note", func(t *testing.T) {
+		rr := do(http.MethodGet, "/notes/"+notes[0].ID.String(), "", nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, api.NoteETag(notes[0]), rr.Header().Get("ETag"))
+		assert.JSONEq(t, mustEncode(t, api.ToNoteResponse(notes[0])), rr.Body.String())
+	})
+
+	t.Run("Notes of other users are forbidden", func(t *testing.T) {
+		rr := do(http.MethodGet, "/notes/"+notes[2].ID.String(), "", nil)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+
+		rr = do(http.MethodDelete, "/notes/"+notes[2].ID.String(), "", nil)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("Update a note", func(t *testing.T) {
+		target := "/notes/" + notes[1].ID.String()
+		etag := do(http.MethodGet, target, "", nil).Header().Get("ETag")
+
+		rr := do(http.MethodPut, target, `{"title": "Ideas", "content": "sell milk"}`, http.Header{"If-Match": {etag}})
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NotEqual(t, etag, rr.Header().Get("ETag"))
+
+		var got api.NoteResponse
+		assert.NoError(t, json.Unmarshal(do(http.MethodGet, target, "", nil).Body.Bytes(), &got))
+		assert.Equal(t, "sell milk", got.Content)
+
+		// the stale ETag no longer matches
+		rr = do(http.MethodPut, target, `{"title": "Ideas", "content": "lost update"}`, http.Header{"If-Match": {etag}})
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+		assert.JSONEq(t, `{"error": "note was modified"}`, rr.Body.String())
+	})
+
+	t.Run("Update validates the body", func(t *testing.T) {
+		rr := do(http.MethodPut, "/notes/"+notes[1].ID.String(), `{"content": "no title"}`, nil)
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	})
+
+	t.Run("Patch a note", func(t *testing.T) {
+		target := "/notes/" + notes[1].ID.String()
+		get := func() api.NoteResponse {
+			var got api.NoteResponse
+			assert.NoError(t, json.Unmarshal(do(http.MethodGet, target, "", nil).Body.Bytes(), &got))
+			return got
+		}
+
+		// absent fields are kept
+		rr := do(http.MethodPatch, target, `{"title": "Plans"}`, nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "Plans", get().Title)
+		assert.Equal(t, "sell milk", get().Content)
+
+		rr = do(http.MethodPatch, target, `{"content": "buy a cow"}`, nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "Plans", get().Title)
+		assert.Equal(t, "buy a cow", get().Content)
+
+		// null clears the content
+		rr = do(http.MethodPatch, target, `{"content": null}`, nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "", get().Content)
+
+		rr = do(http.MethodPatch, target, `{"title": null}`, nil)
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		assert.JSONEq(t, `{"error":"validation failed","fields":[{"field":"title","error":"must not be null"}]}`, rr.Body.String())
+
+		rr = do(http.MethodPatch, target, `{"title": ""}`, nil)
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+		rr = do(http.MethodPatch, target, `{"title": 1}`, nil)
+		assert.JSONEq(t, `{"error":"validation failed","fields":[{"field":"title","error":"must be a string"}]}`, rr.Body.String())
+
+		rr = do(http.MethodPatch, target, `{"owner": "anna"}`, nil)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = do(http.MethodPatch, target, `{"title": "Plans"}`, http.Header{"If-Match": {`"stale"`}})
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+	})
+
+	t.Run("Render a note", func(t *testing.T) {
+		rr := do(http.MethodPost, "/notes", mustEncode(t, api.NotePost{
+			Title:   "<Todo>",
+			Content: "# Today\n\n- [x] milk\n\n[click](javascript:alert(1)) <script>alert(1)</script>",
+			Format:  "markdown",
+		}), nil)
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+		var created api.NoteResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+		assert.Equal(t, "markdown", created.Format)
+		target := "/notes/" + created.ID.String()
+
+		rr = do(http.MethodGet, target+"?render=html", "", nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var got api.RenderedNoteResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+		assert.Equal(t, created, got.NoteResponse)
+		assert.Equal(t, []markdown.Heading{{Level: 1, Text: "Today", ID: "today"}}, got.TOC)
+		assert.Contains(t, got.HTML, `<h1 id="today">Today</h1>`)
+		assert.Contains(t, got.HTML, `<input checked="" disabled="" type="checkbox">`)
+		assert.NotContains(t, got.HTML, "javascript")
+		assert.NotContains(t, got.HTML, "<script>")
+
+		// browsers get a page
+		rr = do(http.MethodGet, target, "", http.Header{"Accept": {"text/html,application/xhtml+xml,*/*;q=0.8"}})
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "text/html; charset=utf-8", rr.Header().Get("Content-Type"))
+		assert.Contains(t, rr.Header().Get("Content-Security-Policy"), "default-src 'none'")
+		assert.Contains(t, rr.Body.String(), "<title>&lt;Todo&gt;</title>")
+		assert.Contains(t, rr.Body.String(), `<a href="#today">Today</a>`)
+		assert.NotContains(t, rr.Body.String(), "<script>")
+
+		rr = do(http.MethodGet, target, "", http.Header{"Accept": {"application/json, text/html;q=0.5"}})
+		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+		// plain notes are escaped
+		rr = do(http.MethodPatch, target, `{"format": "plain"}`, nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		rr = do(http.MethodGet, target+"?render=html", "", nil)
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+		assert.Equal(t, []markdown.Heading{}, got.TOC)
+		assert.True(t, strings.HasPrefix(got.HTML, "<pre># Today"))
+		assert.Contains(t, got.HTML, "&lt;script&gt;")
+
+		rr = do(http.MethodGet, target+"?render=pdf", "", nil)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = do(http.MethodPatch, target, `{"format": "html"}`, nil)
+		assert.JSONEq(t, `{"error":"validation failed","fields":[{"field":"format","error":"must be plain or markdown"}]}`, rr.Body.String())
+	})
+
+	t.Run("Delete a note", func(t *testing.T) {
+		target := "/notes/" + notes[0].ID.String()
+		rr := do(http.MethodDelete, target, "", nil)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+
+		rr = do(http.MethodGet, target, "", nil)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+}
+
+// racingNoteService runs race once after the note of a request was loaded, as
+// if another request changed it in between.
+type racingNoteService struct {
+	note.Service
+	race *func()
+}
+
+func (s racingNoteService) QueryByID(ctx context.Context, noteID uuid.UUID) (note.Note, error) {
+	n, err := s.Service.QueryByID(ctx, noteID)
+	if race := *s.race; race != nil {
+		*s.race = nil
+		race()
+	}
+	return n, err
+}
+
+func Test_ConcurrentUpdates(t *testing.T) {
+	rob := user.User{ID: uuid.UUID{1}}
+	n := note.Note{ID: uuid.UUID{11}, Title: note.NewTitle("Ideas"), Content: note.NewContent("buy milk"), Format: note.FormatPlain, UserID: rob.ID}
+
+	jwtSvc := auth.MustNewJWTService(common.MustGenerateRandomKey(32))
+	userSvc := user.NewSvc(usermemory.NewRepo([]user.User{rob}))
+	noteSvc := note.NewNotesService(notememory.MustNewRepo([]note.Note{n}), userSvc)
+	var race func()
+	app := mux.NewAPI(notesgrp.Routes, mux.Config{Auth: auth.NewAuth(jwtSvc), NoteSvc: racingNoteService{Service: noteSvc, race: &race}, UserSvc: userSvc})
+
+	tokenS, err := jwtSvc.CreateToken(rob.ID, time.Minute)
+	assert.NoError(t, err)
+	target := "/notes/" + n.ID.String()
+
+	do := func(method, body string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header = header
+		req.Header.Set("Authorization", "Bearer "+tokenS)
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, req)
+		return rr
+	}
+	changeContent := func(content string) func() {
+		return func() {
+			_, err := noteSvc.Update(context.Background(), n, note.UpdateNote{Content: note.NewContent(content)})
+			assert.NoError(t, err)
+		}
+	}
+
+	t.Run("Of two updates with the same ETag only the first one succeeds", func(t *testing.T) {
+		etag := do(http.MethodGet, "", http.Header{}).Header().Get("ETag")
+
+		race = changeContent("sell milk")
+		rr := do(http.MethodPut, `{"title": "Ideas", "content": "lost update"}`, http.Header{"If-Match": {etag}})
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+
+		got, err := noteSvc.QueryByID(context.Background(), n.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "sell milk", got.Content.String())
+	})
+
+	t.Run("A patch keeps the fields another request changed meanwhile", func(t *testing.T) {
+		race = changeContent("buy a cow")
+		rr := do(http.MethodPatch, `{"title": "Plans"}`, http.Header{})
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		got, err := noteSvc.QueryByID(context.Background(), n.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Plans", got.Title.String())
+		assert.Equal(t, "buy a cow", got.Content.String())
+	})
+}
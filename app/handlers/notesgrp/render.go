@@ -0,0 +1,62 @@
+package notesgrp
+
+import (
+	"html"
+	"html/template"
+	"net/http"
+
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/Keisn1/note-taking-app/foundation/markdown"
+)
+
+// pageCSP forbids scripts, plugins and remote resources on rendered notes.
+// The content is sanitised anyway, the policy is a second line of defence.
+const pageCSP = "default-src 'none'; style-src 'unsafe-inline'; img-src https: data:; base-uri 'none'; form-action 'none'; frame-ancestors 'none'"
+
+var page = template.Must(template.New("note").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>body { max-width: 48rem; margin: 2rem auto; padding: 0 1rem; font-family: sans-serif; line-height: 1.5 } pre { white-space: pre-wrap }</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{- if .TOC}}
+<nav>
+<ul>
+{{- range .TOC}}
+<li style="margin-left: {{.Level}}rem"><a href="#{{.ID}}">{{.Text}}</a></li>
+{{- end}}
+</ul>
+</nav>
+{{- end}}
+<main>
+{{.HTML}}
+</main>
+</body>
+</html>
+`))
+
+// render renders the content of the note to sanitised HTML. Plain text is
+// escaped and kept preformatted.
+func render(n note.Note) (markdown.Document, error) {
+	if n.Format != note.FormatMarkdown {
+		return markdown.Document{HTML: "<pre>" + html.EscapeString(n.Content.String()) + "</pre>"}, nil
+	}
+	return markdown.Render(n.Content.String())
+}
+
+// respondPage writes the note as a standalone HTML page.
+func respondPage(w http.ResponseWriter, n note.Note, doc markdown.Document) error {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", pageCSP)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	return page.Execute(w, struct {
+		Title string
+		TOC   []markdown.Heading
+		HTML  template.HTML
+	}{n.Title.String(), doc.TOC, template.HTML(doc.HTML)})
+}
@@ -0,0 +1,23 @@
+package notesgrp
+
+import (
+	"github.com/Keisn1/note-taking-app/domain/web/mid"
+	"github.com/Keisn1/note-taking-app/domain/web/mux"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+)
+
+// Routes adds the notes routes to the app. All of them require authentication,
+// the routes of a single note also require that it belongs to the user.
+func Routes(app *web.App, cfg mux.Config) {
+	hdl := NewHandlers(cfg.NoteSvc)
+
+	notes := app.Group("/notes", mid.Authenticate(cfg.Auth, cfg.UserSvc))
+	notes.Post("", hdl.Create)
+	notes.Get("", hdl.List)
+
+	note := notes.Group("/{note_id}", mid.AuthorizeNote(cfg.NoteSvc))
+	note.Get("", hdl.Get)
+	note.Put("", hdl.Update)
+	note.Patch("", hdl.Patch)
+	note.Delete("", hdl.Delete)
+}
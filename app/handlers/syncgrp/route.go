@@ -0,0 +1,15 @@
+package syncgrp
+
+import (
+	"github.com/Keisn1/note-taking-app/domain/web/mid"
+	"github.com/Keisn1/note-taking-app/domain/web/mux"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+)
+
+// Routes adds the sync routes to the app. Both require authentication.
+func Routes(app *web.App, cfg mux.Config) {
+	hdl := NewHandlers(cfg.SyncSvc)
+
+	app.Get("/sync", hdl.Changes, mid.Authenticate(cfg.Auth, cfg.UserSvc))
+	app.Post("/sync", hdl.Apply, mid.Authenticate(cfg.Auth, cfg.UserSvc))
+}
@@ -0,0 +1,70 @@
+// Package syncgrp serves the sync of clients that work offline: the change
+// feed of the notes and batches of offline mutations.
+package syncgrp
+
+import (
+	"net/http"
+
+	"github.com/Keisn1/note-taking-app/app/api"
+	"github.com/Keisn1/note-taking-app/domain/core/change"
+	"github.com/Keisn1/note-taking-app/domain/web/mid"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+)
+
+type Handlers struct {
+	syncSvc change.Service
+}
+
+func NewHandlers(ss change.Service) Handlers {
+	return Handlers{syncSvc: ss}
+}
+
+// Changes returns the changes of the notes of the user after the query
+// parameter since.
+func (hdl Handlers) Changes(w http.ResponseWriter, r *http.Request) {
+	userID := mid.GetUserID(r.Context())
+
+	q, err := api.ParseSyncQuery(r)
+	if err != nil {
+		web.Respond(w, http.StatusBadRequest, api.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	f, err := hdl.syncSvc.Changes(r.Context(), userID, q.Since, q.Limit)
+	if err != nil {
+		respondError(w, r, "query changes", err)
+		return
+	}
+	web.Respond(w, http.StatusOK, api.ToSyncResponse(f))
+}
+
+// Apply applies a batch of mutations and returns the result of each.
+func (hdl Handlers) Apply(w http.ResponseWriter, r *http.Request) {
+	userID := mid.GetUserID(r.Context())
+
+	var sp api.SyncPost
+	if err := web.Decode(w, r, &sp); err != nil {
+		api.RespondDecodeError(w, r, err)
+		return
+	}
+
+	results, err := hdl.syncSvc.Apply(r.Context(), userID, sp.ToMutations())
+	if err != nil {
+		respondError(w, r, "apply mutations", err)
+		return
+	}
+	web.Respond(w, http.StatusOK, api.ToSyncPostResponse(results))
+
+	conflicts := 0
+	for _, res := range results {
+		if res.Status == change.StatusConflict {
+			conflicts++
+		}
+	}
+	web.Logger(r.Context()).Info("mutations applied", "mutations", len(results), "conflicts", conflicts)
+}
+
+func respondError(w http.ResponseWriter, r *http.Request, op string, err error) {
+	web.Respond(w, http.StatusInternalServerError, api.ErrorResponse{Error: http.StatusText(http.StatusInternalServerError)})
+	web.Logger(r.Context()).Error(op, "error", err)
+}
@@ -0,0 +1,106 @@
+package syncgrp_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/app/api"
+	"github.com/Keisn1/note-taking-app/app/handlers/syncgrp"
+	"github.com/Keisn1/note-taking-app/domain/core/change"
+	changememory "github.com/Keisn1/note-taking-app/domain/core/change/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	notememory "github.com/Keisn1/note-taking-app/domain/core/note/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	usermemory "github.com/Keisn1/note-taking-app/domain/core/user/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/web/auth"
+	"github.com/Keisn1/note-taking-app/domain/web/mux"
+	"github.com/Keisn1/note-taking-app/foundation/common"
+	"github.com/Keisn1/note-taking-app/foundation/transaction"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Sync(t *testing.T) {
+	rob := user.User{ID: uuid.UUID{1}, Name: user.NewName("rob"), Email: user.NewEmail("rob@example.com")}
+
+	changeRepo, noteRepo := changememory.NewRepo(), notememory.MustNewRepo(nil)
+	userSvc := user.NewSvc(usermemory.NewRepo([]user.User{rob}))
+	noteSvc := note.NewNotesService(change.NewNoteRepo(noteRepo, changeRepo), userSvc)
+
+	jwtSvc := auth.MustNewJWTService(common.MustGenerateRandomKey(32))
+	app := mux.NewAPI(syncgrp.Routes, mux.Config{Auth: auth.NewAuth(jwtSvc), NoteSvc: noteSvc, UserSvc: userSvc, SyncSvc: change.NewSvc(changeRepo, noteSvc, transaction.NewMemory(changeRepo, noteRepo))})
+
+	robToken, err := jwtSvc.CreateToken(rob.ID, time.Minute)
+	require.NoError(t, err)
+	call := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+robToken)
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, req)
+		return rr
+	}
+
+	groceries, err := noteSvc.Create(context.Background(), note.UpdateNote{Title: note.NewTitle("Groceries"), Content: note.NewContent("milk"), UserID: rob.ID})
+	require.NoError(t, err)
+	offline := uuid.New()
+
+	t.Run("Mutations are applied, conflicts copied", func(t *testing.T) {
+		rr := call(http.MethodPost, "/sync", `{"mutations": [
+			{"op": "upsert", "note_id": "`+offline.String()+`", "base_version": 0, "title": "Offline", "content": "draft"},
+			{"op": "upsert", "note_id": "`+groceries.ID.String()+`", "base_version": 0, "title": "Groceries", "content": "eggs"}
+		]}`)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		var got api.SyncPostResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+		require.Len(t, got.Results, 2)
+		assert.Equal(t, "applied", got.Results[0].Status)
+		assert.Equal(t, int64(2), got.Results[0].Version)
+		assert.Equal(t, "draft", got.Results[0].Note.Content)
+		assert.Nil(t, got.Results[0].ConflictCopy)
+
+		assert.Equal(t, "conflict", got.Results[1].Status)
+		assert.Equal(t, int64(1), got.Results[1].Version)
+		assert.Equal(t, "milk", got.Results[1].Note.Content)
+		require.NotNil(t, got.Results[1].ConflictCopy)
+		assert.Equal(t, "Groceries (conflict copy)", got.Results[1].ConflictCopy.Title)
+	})
+
+	t.Run("The change feed has tombstones", func(t *testing.T) {
+		rr := call(http.MethodPost, "/sync", `{"mutations": [{"op": "delete", "note_id": "`+offline.String()+`", "base_version": 2}]}`)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		rr = call(http.MethodGet, "/sync?since=1&limit=2", "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		var got api.SyncResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+		require.Len(t, got.Changes, 2)
+		assert.Equal(t, "Groceries (conflict copy)", got.Changes[0].Note.Title)
+		assert.Equal(t, api.SyncChange{NoteID: offline, Version: 4, Deleted: true}, got.Changes[1])
+		assert.Equal(t, int64(4), got.Next)
+		assert.False(t, got.HasMore)
+	})
+
+	t.Run("Invalid requests", func(t *testing.T) {
+		rr := call(http.MethodGet, "/sync?since=-1", "")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		rr = call(http.MethodGet, "/sync?limit=1000", "")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = call(http.MethodPost, "/sync", `{"mutations": [{"op": "move", "note_id": "`+uuid.Nil.String()+`", "base_version": -1}, {"op": "upsert", "note_id": "`+offline.String()+`"}]}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		var got api.ErrorResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+		assert.Len(t, got.Fields, 4)
+		assert.Equal(t, "mutations[1].title", got.Fields[3].Field)
+
+		rr = call(http.MethodPost, "/sync", `{"mutations": []}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	})
+}
@@ -0,0 +1,289 @@
+package transfergrp
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// enmlToMarkdown converts the ENML (Evernote's XHTML subset) of a note to
+// Markdown. Attachments (en-media) and images are dropped, formatting that
+// Markdown can't express is reduced to its text.
+func enmlToMarkdown(enml string) (string, error) {
+	doc, err := html.Parse(strings.NewReader(enml))
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(blocks(doc), "\n\n"), nil
+}
+
+// blocks converts the children of n to Markdown blocks. Inline content
+// between block elements forms paragraphs.
+func blocks(n *html.Node) []string {
+	var out []string
+	var para strings.Builder
+
+	flush := func() {
+		p := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(para.String()), "\\"))
+		para.Reset()
+		if p == "" {
+			return
+		}
+		// An en-todo at the start of a paragraph is a task list item.
+		if strings.HasPrefix(p, "[ ] ") || strings.HasPrefix(p, "[x] ") {
+			p = "- " + p
+		}
+		out = append(out, p)
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || !isBlock(c) {
+			para.WriteString(inline(c))
+			continue
+		}
+		flush()
+		out = append(out, block(c)...)
+	}
+	flush()
+	return out
+}
+
+func isBlock(n *html.Node) bool {
+	switch n.DataAtom {
+	case atom.Html, atom.Body, atom.Div, atom.P, atom.Center, atom.Section, atom.Article,
+		atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6,
+		atom.Ul, atom.Ol, atom.Pre, atom.Blockquote, atom.Hr, atom.Table:
+		return true
+	}
+	return n.Data == "en-note"
+}
+
+func block(n *html.Node) []string {
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level := int(n.Data[1] - '0')
+		text := strings.TrimSpace(inlineChildren(n))
+		if text == "" {
+			return nil
+		}
+		return []string{strings.Repeat("#", level) + " " + text}
+
+	case atom.Ul, atom.Ol:
+		return []string{list(n)}
+
+	case atom.Pre:
+		return []string{"```\n" + strings.TrimRight(textContent(n), "\n") + "\n```"}
+
+	case atom.Blockquote:
+		inner := strings.Join(blocks(n), "\n\n")
+		if inner == "" {
+			return nil
+		}
+		return []string{prefixLines(inner, "> ", ">")}
+
+	case atom.Hr:
+		return []string{"---"}
+
+	case atom.Table:
+		return []string{table(n)}
+	}
+	return blocks(n)
+}
+
+func list(n *html.Node) string {
+	var items []string
+	i := 0
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.DataAtom != atom.Li {
+			continue
+		}
+		i++
+		marker := "- "
+		if n.DataAtom == atom.Ol {
+			marker = strconv.Itoa(i) + ". "
+		}
+		content := strings.Join(blocks(c), "\n\n")
+		indent := strings.Repeat(" ", len(marker))
+		items = append(items, marker+strings.TrimPrefix(prefixLines(content, indent, ""), indent))
+	}
+	return strings.Join(items, "\n")
+}
+
+func table(n *html.Node) string {
+	var rows [][]string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.DataAtom != atom.Tr {
+				walk(c)
+				continue
+			}
+			var row []string
+			for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.DataAtom == atom.Td || cell.DataAtom == atom.Th {
+					text := strings.Join(strings.Fields(inlineChildren(cell)), " ")
+					row = append(row, strings.ReplaceAll(text, "|", `\|`))
+				}
+			}
+			rows = append(rows, row)
+		}
+	}
+	walk(n)
+	if len(rows) == 0 {
+		return ""
+	}
+
+	cols := 0
+	for _, row := range rows {
+		cols = max(cols, len(row))
+	}
+	line := func(cells []string) string {
+		cells = append(cells, make([]string, cols-len(cells))...)
+		return "| " + strings.Join(cells, " | ") + " |"
+	}
+
+	lines := []string{line(rows[0]), line(slicesRepeat("---", cols))}
+	for _, row := range rows[1:] {
+		lines = append(lines, line(row))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func inline(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return escape(collapseSpace(n.Data))
+	case html.ElementNode:
+	default:
+		return ""
+	}
+
+	switch n.DataAtom {
+	case atom.B, atom.Strong:
+		return wrap(inlineChildren(n), "**")
+	case atom.I, atom.Em:
+		return wrap(inlineChildren(n), "*")
+	case atom.S, atom.Strike, atom.Del:
+		return wrap(inlineChildren(n), "~~")
+	case atom.Code, atom.Tt:
+		text := textContent(n)
+		if text == "" {
+			return ""
+		}
+		return "`" + text + "`"
+	case atom.A:
+		text := inlineChildren(n)
+		href := attr(n, "href")
+		if href == "" || strings.TrimSpace(text) == "" {
+			return text
+		}
+		return "[" + text + "](<" + href + ">)"
+	case atom.Br:
+		return "\\\n"
+	case atom.Img, atom.Script, atom.Style:
+		return ""
+	}
+
+	switch n.Data {
+	case "en-todo":
+		// The HTML parser doesn't know that en-todo is empty and makes the
+		// text after it its children.
+		if attr(n, "checked") == "true" {
+			return "[x] " + inlineChildren(n)
+		}
+		return "[ ] " + inlineChildren(n)
+	case "en-media", "en-crypt":
+		return ""
+	}
+	return inlineChildren(n)
+}
+
+func inlineChildren(n *html.Node) string {
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(inline(c))
+	}
+	return sb.String()
+}
+
+// wrap puts the delimiter around s, outside of its surrounding space, as
+// emphasis must not start or end with space.
+func wrap(s, delim string) string {
+	trimmed := strings.TrimSpace(s)
+	if trimmed == "" {
+		return s
+	}
+	start := strings.Index(s, trimmed)
+	return s[:start] + delim + trimmed + delim + s[start+len(trimmed):]
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.DataAtom == atom.Br {
+			sb.WriteByte('\n')
+			continue
+		}
+		sb.WriteString(textContent(c))
+	}
+	return sb.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func collapseSpace(s string) string {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		if s == "" {
+			return ""
+		}
+		return " "
+	}
+	out := strings.Join(fields, " ")
+	if strings.TrimLeft(s[:1], " \t\r\n") == "" {
+		out = " " + out
+	}
+	if strings.TrimRight(s[len(s)-1:], " \t\r\n") == "" {
+		out += " "
+	}
+	return out
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", `\<`, "#", `\#`)
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+// prefixLines puts prefix before every line of s, or blank before empty ones.
+func prefixLines(s, prefix, blank string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if l == "" {
+			lines[i] = blank
+		} else {
+			lines[i] = prefix + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func slicesRepeat(s string, n int) []string {
+	out := make([]string, n)
+	for i := range out {
+		out[i] = s
+	}
+	return out
+}
@@ -0,0 +1,153 @@
+package transfergrp
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Keisn1/note-taking-app/app/api"
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"gopkg.in/yaml.v3"
+)
+
+// exporter writes notes one at a time. Nothing but the current note is held
+// in memory.
+type exporter interface {
+	contentType() string
+	extension() string
+	write(n note.Note) error
+	close() error
+}
+
+// frontMatter is the YAML header of an exported Markdown file.
+type frontMatter struct {
+	ID     string `yaml:"id"`
+	Title  string `yaml:"title"`
+	Format string `yaml:"format"`
+}
+
+// zipExporter writes one Markdown file per note, named after its title.
+type zipExporter struct {
+	zw    *zip.Writer
+	names map[string]bool
+}
+
+func newZipExporter(w io.Writer) *zipExporter {
+	return &zipExporter{zw: zip.NewWriter(w), names: map[string]bool{}}
+}
+
+func (e *zipExporter) contentType() string { return "application/zip" }
+func (e *zipExporter) extension() string   { return ".zip" }
+
+func (e *zipExporter) write(n note.Note) error {
+	f, err := e.zw.Create(e.filename(n))
+	if err != nil {
+		return fmt.Errorf("write note [%s]: %w", n.ID, err)
+	}
+	if _, err := f.Write(markdownFile(n)); err != nil {
+		return fmt.Errorf("write note [%s]: %w", n.ID, err)
+	}
+	return nil
+}
+
+func (e *zipExporter) close() error {
+	return e.zw.Close()
+}
+
+// filename returns a file name derived from the title that is unique in the
+// archive.
+func (e *zipExporter) filename(n note.Note) string {
+	base := slug(n.Title.String())
+	name := base + ".md"
+	for i := 2; e.names[name]; i++ {
+		name = base + "-" + strconv.Itoa(i) + ".md"
+	}
+	e.names[name] = true
+	return name
+}
+
+// markdownFile returns the note as Markdown with YAML front matter.
+func markdownFile(n note.Note) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	// Encoding a struct of strings doesn't fail.
+	fm, _ := yaml.Marshal(frontMatter{ID: n.ID.String(), Title: n.Title.String(), Format: n.Format.String()})
+	buf.Write(fm)
+	buf.WriteString("---\n")
+	buf.WriteString(n.Content.String())
+	return buf.Bytes()
+}
+
+const maxSlugLength = 64
+
+// slug turns a title into a file name without path separators or characters
+// that are special on common file systems.
+func slug(title string) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if dash && sb.Len() > 0 {
+				sb.WriteByte('-')
+			}
+			dash = false
+			sb.WriteRune(r)
+		default:
+			dash = true
+		}
+		if sb.Len() >= maxSlugLength {
+			break
+		}
+	}
+	if sb.Len() == 0 {
+		return "note"
+	}
+	return sb.String()
+}
+
+// jsonExporter writes an api.Export, one note at a time.
+type jsonExporter struct {
+	w     io.Writer
+	count int
+}
+
+func newJSONExporter(w io.Writer) *jsonExporter {
+	return &jsonExporter{w: w}
+}
+
+func (e *jsonExporter) contentType() string { return "application/json" }
+func (e *jsonExporter) extension() string   { return ".json" }
+
+var jsonHeader = `{"version":` + strconv.Itoa(api.ExportVersion) + `,"notes":[`
+
+func (e *jsonExporter) write(n note.Note) error {
+	prefix := ","
+	if e.count == 0 {
+		prefix = jsonHeader
+	}
+	e.count++
+
+	data, err := json.Marshal(api.ToExportNote(n))
+	if err != nil {
+		return fmt.Errorf("write note [%s]: %w", n.ID, err)
+	}
+	if _, err := io.WriteString(e.w, prefix+string(data)); err != nil {
+		return fmt.Errorf("write note [%s]: %w", n.ID, err)
+	}
+	return nil
+}
+
+func (e *jsonExporter) close() error {
+	end := "]}\n"
+	if e.count == 0 {
+		end = jsonHeader + end
+	}
+	_, err := io.WriteString(e.w, end)
+	return err
+}
@@ -0,0 +1,225 @@
+package transfergrp
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/Keisn1/note-taking-app/app/api"
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// MaxImportBytes is the maximum size of an upload.
+	MaxImportBytes = 32 << 20
+
+	// maxExpandedBytes limits the total size of the files in a zip, so that
+	// a small upload can't expand to fill the memory.
+	maxExpandedBytes = 256 << 20
+
+	// maxImportItems is the maximum number of notes in an upload.
+	maxImportItems = 10_000
+)
+
+var errTooLarge = errors.New("upload expands to too many or too large notes")
+
+// item is a note found in an upload. If it couldn't be read, err says why
+// and the note is left empty.
+type item struct {
+	name string
+	note api.NotePost
+	err  error
+}
+
+// parser reads the notes of an upload.
+type parser func(data []byte) ([]item, error)
+
+// parseMarkdownZip reads the .md, .markdown and .txt files of a zip. Titles
+// and formats are taken from YAML front matter, otherwise the file name is
+// the title and .txt files are plain text.
+func parseMarkdownZip(data []byte) ([]item, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("read zip: %w", err)
+	}
+
+	var items []item
+	var expanded uint64
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || hidden(f.Name) {
+			continue
+		}
+		if len(items) == maxImportItems {
+			return nil, errTooLarge
+		}
+
+		ext := strings.ToLower(path.Ext(f.Name))
+		if ext != ".md" && ext != ".markdown" && ext != ".txt" {
+			items = append(items, item{name: f.Name, err: errors.New("not a Markdown or text file")})
+			continue
+		}
+
+		expanded += f.UncompressedSize64
+		if expanded > maxExpandedBytes {
+			return nil, errTooLarge
+		}
+
+		content, err := readZipFile(f)
+		if err != nil {
+			items = append(items, item{name: f.Name, err: err})
+			continue
+		}
+
+		np := api.NotePost{Title: strings.TrimSuffix(path.Base(f.Name), path.Ext(f.Name)), Format: note.FormatMarkdown.String()}
+		if ext == ".txt" {
+			np.Format = note.FormatPlain.String()
+		}
+
+		fm, body, err := splitFrontMatter(content)
+		if err != nil {
+			items = append(items, item{name: f.Name, err: err})
+			continue
+		}
+		if fm.Title != "" {
+			np.Title = fm.Title
+		}
+		if fm.Format != "" {
+			np.Format = fm.Format
+		}
+		np.Content = body
+
+		items = append(items, item{name: f.Name, note: np})
+	}
+	return items, nil
+}
+
+// readZipFile reads a file of at most note.MaxContentLength plus some front
+// matter. The size in the zip header can't be trusted.
+func readZipFile(f *zip.File) (string, error) {
+	const limit = note.MaxContentLength + 64<<10
+
+	rc, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > limit {
+		return "", errors.New("file too large")
+	}
+	return string(data), nil
+}
+
+// hidden reports whether the file is metadata of the archiver or the
+// operating system, such as __MACOSX/ or .DS_Store.
+func hidden(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return true
+		}
+	}
+	return false
+}
+
+// splitFrontMatter separates YAML front matter delimited by --- lines from
+// the content.
+func splitFrontMatter(s string) (frontMatter, string, error) {
+	rest, ok := cutLine(strings.TrimPrefix(s, "\ufeff"), "---")
+	if !ok {
+		return frontMatter{}, s, nil
+	}
+
+	var header []string
+	for rest != "" {
+		var line string
+		line, rest, _ = strings.Cut(rest, "\n")
+		if strings.TrimRight(line, "\r") == "---" {
+			var fm frontMatter
+			if err := yaml.Unmarshal([]byte(strings.Join(header, "\n")), &fm); err != nil {
+				return frontMatter{}, "", fmt.Errorf("front matter: %w", err)
+			}
+			return fm, rest, nil
+		}
+		header = append(header, line)
+	}
+	return frontMatter{}, "", errors.New("front matter: missing closing ---")
+}
+
+// cutLine returns the rest of s after its first line, if that line is line.
+func cutLine(s, line string) (string, bool) {
+	first, rest, found := strings.Cut(s, "\n")
+	if !found || strings.TrimRight(first, "\r") != line {
+		return s, false
+	}
+	return rest, true
+}
+
+// parseJSON reads an api.Export.
+func parseJSON(data []byte) ([]item, error) {
+	var exp api.Export
+	if err := json.Unmarshal(data, &exp); err != nil {
+		return nil, fmt.Errorf("read json: %w", err)
+	}
+	if exp.Version != api.ExportVersion {
+		return nil, fmt.Errorf("read json: unsupported version %d", exp.Version)
+	}
+	if len(exp.Notes) > maxImportItems {
+		return nil, errTooLarge
+	}
+
+	items := make([]item, len(exp.Notes))
+	for i, en := range exp.Notes {
+		items[i] = item{
+			name: itemName(i, en.Title),
+			note: api.NotePost{Title: en.Title, Content: en.Content, Format: en.Format},
+		}
+	}
+	return items, nil
+}
+
+type enex struct {
+	Notes []struct {
+		Title   string `xml:"title"`
+		Content string `xml:"content"`
+	} `xml:"note"`
+}
+
+// parseENEX reads an Evernote export and converts the notes to Markdown.
+func parseENEX(data []byte) ([]item, error) {
+	var exp enex
+	if err := xml.Unmarshal(data, &exp); err != nil {
+		return nil, fmt.Errorf("read enex: %w", err)
+	}
+	if len(exp.Notes) > maxImportItems {
+		return nil, errTooLarge
+	}
+
+	items := make([]item, len(exp.Notes))
+	for i, en := range exp.Notes {
+		items[i] = item{name: itemName(i, en.Title)}
+		content, err := enmlToMarkdown(en.Content)
+		if err != nil {
+			items[i].err = fmt.Errorf("convert content: %w", err)
+			continue
+		}
+		items[i].note = api.NotePost{Title: strings.TrimSpace(en.Title), Content: content, Format: note.FormatMarkdown.String()}
+	}
+	return items, nil
+}
+
+// itemName names the i-th note of an upload.
+func itemName(i int, title string) string {
+	return "#" + strconv.Itoa(i+1) + " " + strconv.Quote(title)
+}
@@ -0,0 +1,108 @@
+package transfergrp
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/app/api"
+	"github.com/google/uuid"
+)
+
+// jobTTL is how long finished import jobs can be polled.
+const jobTTL = time.Hour
+
+// MaxRunningImports is the maximum number of imports that run at the same
+// time across all users, each holds its upload in memory.
+const MaxRunningImports = 4
+
+var (
+	errImportRunning  = errors.New("an import is already running")
+	errTooManyImports = errors.New("too many imports are running, try again later")
+)
+
+// jobStore keeps the import jobs in memory. Jobs don't survive a restart;
+// a restart during an import leaves the notes imported so far.
+type jobStore struct {
+	mu   sync.Mutex
+	jobs map[uuid.UUID]*job
+}
+
+type job struct {
+	userID uuid.UUID
+	state  api.ImportJob
+}
+
+func newJobStore() *jobStore {
+	return &jobStore{jobs: map[uuid.UUID]*job{}}
+}
+
+// start adds a running job for the user. It fails with errImportRunning if
+// the user already has one and with errTooManyImports if MaxRunningImports
+// are running.
+func (s *jobStore) start(userID uuid.UUID, format string) (uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	running := 0
+	for id, j := range s.jobs {
+		if j.state.FinishedAt != nil && now.Sub(*j.state.FinishedAt) > jobTTL {
+			delete(s.jobs, id)
+			continue
+		}
+		if j.state.Status != api.ImportRunning {
+			continue
+		}
+		if j.userID == userID {
+			return uuid.Nil, errImportRunning
+		}
+		running++
+	}
+	if running >= MaxRunningImports {
+		return uuid.Nil, errTooManyImports
+	}
+
+	id := uuid.New()
+	s.jobs[id] = &job{userID: userID, state: api.ImportJob{
+		ID:        id,
+		Status:    api.ImportRunning,
+		Format:    format,
+		Errors:    []api.ImportError{},
+		CreatedAt: now.UTC().Truncate(time.Second),
+	}}
+	return id, nil
+}
+
+// cancel removes a job that was started but never ran.
+func (s *jobStore) cancel(id uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.jobs, id)
+}
+
+// get returns a copy of the job if it belongs to the user.
+func (s *jobStore) get(userID, id uuid.UUID) (api.ImportJob, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.jobs[id]
+	if !ok || j.userID != userID {
+		return api.ImportJob{}, false
+	}
+	state := j.state
+	state.Errors = slices.Clone(j.state.Errors)
+	return state, true
+}
+
+// update changes the job with fn while holding the lock.
+func (s *jobStore) update(id uuid.UUID, fn func(*api.ImportJob)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if j, ok := s.jobs[id]; ok {
+		fn(&j.state)
+	}
+}
@@ -0,0 +1,19 @@
+package transfergrp
+
+import (
+	"github.com/Keisn1/note-taking-app/domain/web/mid"
+	"github.com/Keisn1/note-taking-app/domain/web/mux"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+)
+
+// Routes adds the export and import routes to the app. All of them require
+// authentication.
+func Routes(app *web.App, cfg mux.Config) {
+	hdl := NewHandlers(cfg.NoteSvc)
+
+	app.Get("/export", hdl.Export, mid.Authenticate(cfg.Auth, cfg.UserSvc))
+
+	imp := app.Group("/import", mid.Authenticate(cfg.Auth, cfg.UserSvc))
+	imp.Post("", hdl.Import)
+	imp.Get("/{job_id}", hdl.ImportStatus)
+}
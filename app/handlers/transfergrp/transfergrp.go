@@ -0,0 +1,264 @@
+// Package transfergrp moves all notes of a user in and out of the service.
+package transfergrp
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/app/api"
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/Keisn1/note-taking-app/domain/web/mid"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+	"github.com/google/uuid"
+)
+
+// noteWriteTimeout is how long writing a single note of an export may take.
+// The server's write timeout would otherwise cut off large exports.
+const noteWriteTimeout = 30 * time.Second
+
+type Handlers struct {
+	notesSvc note.Service
+	jobs     *jobStore
+}
+
+func NewHandlers(ns note.Service) Handlers {
+	return Handlers{notesSvc: ns, jobs: newJobStore()}
+}
+
+// Export streams all notes of the user as a zip of Markdown files with YAML
+// front matter (format=markdown, the default) or as a JSON api.Export
+// (format=json). The notes are written while they are read from the
+// repository. If that fails midway the connection is aborted, so that the
+// client doesn't mistake the truncated export for a complete one.
+func (hdl Handlers) Export(w http.ResponseWriter, r *http.Request) {
+	userID := mid.GetUserID(r.Context())
+
+	var exp exporter
+	switch r.URL.Query().Get("format") {
+	case "", "markdown":
+		exp = newZipExporter(w)
+	case "json":
+		exp = newJSONExporter(w)
+	default:
+		web.Respond(w, http.StatusBadRequest, api.ErrorResponse{Error: "format must be markdown or json"})
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	filename := "notes-" + time.Now().UTC().Format("20060102") + exp.extension()
+	w.Header().Set("Content-Type", exp.contentType())
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+
+	count := 0
+	err := hdl.notesSvc.IterateByUserID(r.Context(), userID, func(n note.Note) error {
+		if err := rc.SetWriteDeadline(time.Now().Add(noteWriteTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		count++
+		return exp.write(n)
+	})
+	if err == nil {
+		err = exp.close()
+	}
+	if err != nil && count == 0 {
+		// Nothing was written yet, the exporters only write once they have
+		// a note.
+		w.Header().Del("Content-Disposition")
+		web.Respond(w, http.StatusInternalServerError, api.ErrorResponse{Error: http.StatusText(http.StatusInternalServerError)})
+		web.Logger(r.Context()).Error("export notes", "user_id", userID, "error", err)
+		return
+	}
+	if err != nil {
+		web.Logger(r.Context()).Error("export notes", "user_id", userID, "notes", count, "error", err)
+		panic(http.ErrAbortHandler)
+	}
+
+	web.Logger(r.Context()).Info("notes exported", "user_id", userID, "notes", count)
+}
+
+// Import reads the notes of the uploaded file and creates them in the
+// background. The format is given by the query parameter format or else by
+// the Content-Type: a zip of Markdown files (markdown), an Evernote export
+// (enex) or an export of this service (json). The response is the
+// api.ImportJob to poll at its Location. The job is reserved before the
+// upload is read, so that a user can't hold several uploads in memory.
+func (hdl Handlers) Import(w http.ResponseWriter, r *http.Request) {
+	userID := mid.GetUserID(r.Context())
+
+	format, parse, ok := importFormat(r)
+	if !ok {
+		web.Respond(w, http.StatusUnsupportedMediaType, api.ErrorResponse{Error: "format must be markdown, enex or json"})
+		return
+	}
+
+	jobID, err := hdl.jobs.start(userID, format)
+	switch {
+	case errors.Is(err, errImportRunning):
+		web.Respond(w, http.StatusConflict, api.ErrorResponse{Error: err.Error()})
+		return
+	case errors.Is(err, errTooManyImports):
+		web.Respond(w, http.StatusServiceUnavailable, api.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxImportBytes))
+	if err != nil {
+		hdl.jobs.cancel(jobID)
+		api.RespondDecodeError(w, r, err)
+		return
+	}
+
+	items, err := parse(data)
+	if err != nil {
+		hdl.jobs.cancel(jobID)
+		status := http.StatusBadRequest
+		if errors.Is(err, errTooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		web.Respond(w, status, api.ErrorResponse{Error: err.Error()})
+		web.Logger(r.Context()).Info("invalid import", "format", format, "error", err)
+		return
+	}
+	hdl.jobs.update(jobID, func(j *api.ImportJob) { j.Total = len(items) })
+
+	// The job outlives the request but keeps its logger and IDs.
+	go hdl.runImport(context.WithoutCancel(r.Context()), jobID, userID, items)
+
+	job, _ := hdl.jobs.get(userID, jobID)
+	w.Header().Set("Location", "/import/"+jobID.String())
+	web.Respond(w, http.StatusAccepted, job)
+	web.Logger(r.Context()).Info("import started", "job_id", jobID, "format", format, "items", len(items))
+}
+
+// ImportStatus returns the progress of an import job of the user.
+func (hdl Handlers) ImportStatus(w http.ResponseWriter, r *http.Request) {
+	jobID, err := uuid.Parse(r.PathValue("job_id"))
+	if err != nil {
+		web.Respond(w, http.StatusNotFound, api.ErrorResponse{Error: "import job not found"})
+		return
+	}
+
+	job, ok := hdl.jobs.get(mid.GetUserID(r.Context()), jobID)
+	if !ok {
+		web.Respond(w, http.StatusNotFound, api.ErrorResponse{Error: "import job not found"})
+		return
+	}
+
+	web.Respond(w, http.StatusOK, job)
+}
+
+func importFormat(r *http.Request) (string, parser, bool) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		switch mediaType {
+		case "application/zip", "application/x-zip-compressed":
+			format = "markdown"
+		case "application/enex+xml", "application/xml", "text/xml":
+			format = "enex"
+		case "application/json":
+			format = "json"
+		}
+	}
+
+	switch format {
+	case "markdown":
+		return format, parseMarkdownZip, true
+	case "enex":
+		return format, parseENEX, true
+	case "json":
+		return format, parseJSON, true
+	}
+	return "", nil, false
+}
+
+// runImport creates the notes of the items. Notes with the same title and
+// content as an existing note or an earlier item are skipped as duplicates.
+func (hdl Handlers) runImport(ctx context.Context, jobID, userID uuid.UUID, items []item) {
+	log := web.Logger(ctx)
+
+	seen := map[[sha256.Size]byte]bool{}
+	err := hdl.notesSvc.IterateByUserID(ctx, userID, func(n note.Note) error {
+		seen[contentHash(n.Title.String(), n.Content.String())] = true
+		return nil
+	})
+	if err != nil {
+		log.Error("import", "job_id", jobID, "error", err)
+		hdl.jobs.update(jobID, func(j *api.ImportJob) {
+			j.Status = api.ImportFailed
+			j.Errors = append(j.Errors, api.ImportError{Error: "reading the existing notes failed"})
+			j.FinishedAt = now()
+		})
+		return
+	}
+
+	for _, it := range items {
+		duplicate, err := hdl.importItem(ctx, userID, it, seen)
+		if err != nil && it.err == nil {
+			log.Info("import item", "job_id", jobID, "item", it.name, "error", err)
+		}
+
+		hdl.jobs.update(jobID, func(j *api.ImportJob) {
+			j.Processed++
+			switch {
+			case err != nil:
+				j.Failed++
+				j.Errors = append(j.Errors, api.ImportError{Item: it.name, Error: err.Error()})
+			case duplicate:
+				j.Duplicates++
+			default:
+				j.Imported++
+			}
+		})
+	}
+
+	hdl.jobs.update(jobID, func(j *api.ImportJob) {
+		j.Status = api.ImportDone
+		j.FinishedAt = now()
+		log.Info("import finished", "job_id", jobID, "imported", j.Imported, "duplicates", j.Duplicates, "failed", j.Failed)
+	})
+}
+
+var errSaveNote = errors.New("the note could not be saved")
+
+func (hdl Handlers) importItem(ctx context.Context, userID uuid.UUID, it item, seen map[[sha256.Size]byte]bool) (duplicate bool, err error) {
+	if it.err != nil {
+		return false, it.err
+	}
+	if err := it.note.Validate(); err != nil {
+		return false, err
+	}
+
+	hash := contentHash(it.note.Title, it.note.Content)
+	if seen[hash] {
+		return true, nil
+	}
+
+	_, err = hdl.notesSvc.Create(ctx, note.UpdateNote{
+		Title:   note.NewTitle(it.note.Title),
+		Content: note.NewContent(it.note.Content),
+		Format:  note.Format(it.note.Format),
+		UserID:  userID,
+	})
+	if err != nil {
+		web.Logger(ctx).Error("import item", "item", it.name, "error", err)
+		return false, errSaveNote
+	}
+
+	seen[hash] = true
+	return false, nil
+}
+
+func contentHash(title, content string) [sha256.Size]byte {
+	return sha256.Sum256([]byte(title + "\x00" + content))
+}
+
+func now() *time.Time {
+	t := time.Now().UTC().Truncate(time.Second)
+	return &t
+}
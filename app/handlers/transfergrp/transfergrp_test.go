@@ -0,0 +1,419 @@
+package transfergrp_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/app/api"
+	"github.com/Keisn1/note-taking-app/app/handlers/transfergrp"
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	notememory "github.com/Keisn1/note-taking-app/domain/core/note/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	usermemory "github.com/Keisn1/note-taking-app/domain/core/user/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/web/auth"
+	"github.com/Keisn1/note-taking-app/domain/web/mux"
+	"github.com/Keisn1/note-taking-app/foundation/common"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Export(t *testing.T) {
+	rob := user.User{ID: uuid.UUID{1}, Name: user.NewName("rob"), Email: user.NewEmail("rob@example.com")}
+	anna := user.User{ID: uuid.UUID{2}, Name: user.NewName("anna"), Email: user.NewEmail("anna@example.com")}
+	notes := []note.Note{
+		{ID: uuid.UUID{11}, Title: note.NewTitle("Groceries"), Content: note.NewContent("milk, eggs"), Format: note.FormatPlain, UserID: rob.ID},
+		{ID: uuid.UUID{12}, Title: note.NewTitle("Ideas: 2024/25"), Content: note.NewContent("# Ideas\n\n- more milk"), Format: note.FormatMarkdown, UserID: rob.ID},
+		{ID: uuid.UUID{13}, Title: note.NewTitle("ideas 2024 25"), Content: note.NewContent(""), Format: note.FormatPlain, UserID: rob.ID},
+		{ID: uuid.UUID{14}, Title: note.NewTitle("Anna's note"), Content: note.NewContent("milk"), Format: note.FormatPlain, UserID: anna.ID},
+	}
+
+	jwtSvc := auth.MustNewJWTService(common.MustGenerateRandomKey(32))
+	userSvc := user.NewSvc(usermemory.NewRepo([]user.User{rob, anna}))
+	noteSvc := note.NewNotesService(notememory.MustNewRepo(notes), userSvc)
+	app := mux.NewAPI(transfergrp.Routes, mux.Config{Auth: auth.NewAuth(jwtSvc), NoteSvc: noteSvc, UserSvc: userSvc})
+
+	tokenS, err := jwtSvc.CreateToken(rob.ID, time.Minute)
+	require.NoError(t, err)
+
+	get := func(target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Authorization", "Bearer "+tokenS)
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Markdown zip", func(t *testing.T) {
+		rr := get("/export")
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "application/zip", rr.Header().Get("Content-Type"))
+		assert.Regexp(t, `^attachment; filename=notes-\d{8}\.zip$`, rr.Header().Get("Content-Disposition"))
+
+		zr, err := zip.NewReader(bytes.NewReader(rr.Body.Bytes()), int64(rr.Body.Len()))
+		require.NoError(t, err)
+
+		files := map[string]string{}
+		var names []string
+		for _, f := range zr.File {
+			rc, err := f.Open()
+			require.NoError(t, err)
+			data, err := io.ReadAll(rc)
+			require.NoError(t, err)
+			files[f.Name] = string(data)
+			names = append(names, f.Name)
+		}
+
+		assert.Equal(t, []string{"groceries.md", "ideas-2024-25.md", "ideas-2024-25-2.md"}, names)
+		assert.Equal(t, "---\nid: 0b000000-0000-0000-0000-000000000000\ntitle: Groceries\nformat: plain\n---\nmilk, eggs", files["groceries.md"])
+		assert.Equal(t, "---\nid: 0c000000-0000-0000-0000-000000000000\ntitle: 'Ideas: 2024/25'\nformat: markdown\n---\n# Ideas\n\n- more milk", files["ideas-2024-25.md"])
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		rr := get("/export?format=json")
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+		var exp api.Export
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &exp))
+		assert.Equal(t, api.ExportVersion, exp.Version)
+		assert.Equal(t, []api.ExportNote{
+			api.ToExportNote(notes[0]),
+			api.ToExportNote(notes[1]),
+			api.ToExportNote(notes[2]),
+		}, exp.Notes)
+	})
+
+	t.Run("Empty JSON export", func(t *testing.T) {
+		tokenS, err := jwtSvc.CreateToken(anna.ID, time.Minute)
+		require.NoError(t, err)
+		require.NoError(t, noteSvc.Delete(context.Background(), notes[3].ID))
+
+		req := httptest.NewRequest(http.MethodGet, "/export?format=json", nil)
+		req.Header.Set("Authorization", "Bearer "+tokenS)
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, req)
+		assert.JSONEq(t, `{"version": 1, "notes": []}`, rr.Body.String())
+	})
+
+	t.Run("Unknown format", func(t *testing.T) {
+		rr := get("/export?format=pdf")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.JSONEq(t, `{"error": "format must be markdown or json"}`, rr.Body.String())
+	})
+
+	t.Run("Requires authentication", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/export", nil))
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+}
+
+func Test_Export_Failure(t *testing.T) {
+	n := note.Note{ID: uuid.UUID{11}, Title: note.NewTitle("Groceries"), Content: note.NewContent("milk"), Format: note.FormatPlain}
+	hdl := transfergrp.NewHandlers(failingNotesSvc{notes: []note.Note{n}})
+
+	t.Run("Before the first note the error is answered", func(t *testing.T) {
+		hdl := transfergrp.NewHandlers(failingNotesSvc{})
+		rr := httptest.NewRecorder()
+		hdl.Export(rr, httptest.NewRequest(http.MethodGet, "/export", nil))
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.Empty(t, rr.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("Afterwards the connection is aborted", func(t *testing.T) {
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			hdl.Export(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/export?format=json", nil))
+		})
+	})
+}
+
+// failingNotesSvc calls fn with its notes and then fails.
+type failingNotesSvc struct {
+	note.Service
+	notes []note.Note
+}
+
+func (s failingNotesSvc) IterateByUserID(ctx context.Context, userID uuid.UUID, fn func(note.Note) error) error {
+	for _, n := range s.notes {
+		if err := fn(n); err != nil {
+			return err
+		}
+	}
+	return errors.New("connection reset")
+}
+
+func Test_Import(t *testing.T) {
+	rob := user.User{ID: uuid.UUID{1}, Name: user.NewName("rob"), Email: user.NewEmail("rob@example.com")}
+	anna := user.User{ID: uuid.UUID{2}, Name: user.NewName("anna"), Email: user.NewEmail("anna@example.com")}
+	existing := note.Note{ID: uuid.UUID{11}, Title: note.NewTitle("Groceries"), Content: note.NewContent("milk, eggs"), Format: note.FormatPlain, UserID: rob.ID}
+
+	jwtSvc := auth.MustNewJWTService(common.MustGenerateRandomKey(32))
+	userSvc := user.NewSvc(usermemory.NewRepo([]user.User{rob, anna}))
+	noteSvc := note.NewNotesService(notememory.MustNewRepo([]note.Note{existing}), userSvc)
+	app := mux.NewAPI(transfergrp.Routes, mux.Config{Auth: auth.NewAuth(jwtSvc), NoteSvc: noteSvc, UserSvc: userSvc})
+
+	tokenS, err := jwtSvc.CreateToken(rob.ID, time.Minute)
+	require.NoError(t, err)
+
+	do := func(method, target, contentType string, body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, bytes.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+tokenS)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// importNotes uploads body and waits for the import to finish.
+	importNotes := func(t *testing.T, target, contentType string, body []byte) api.ImportJob {
+		t.Helper()
+		rr := do(http.MethodPost, target, contentType, body)
+		require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+
+		var job api.ImportJob
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &job))
+		assert.Equal(t, "/import/"+job.ID.String(), rr.Header().Get("Location"))
+
+		require.Eventually(t, func() bool {
+			rr := do(http.MethodGet, rr.Header().Get("Location"), "", nil)
+			require.Equal(t, http.StatusOK, rr.Code)
+			job = api.ImportJob{}
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &job))
+			return job.Status != api.ImportRunning
+		}, time.Second, 5*time.Millisecond)
+		return job
+	}
+
+	notesByTitle := func(t *testing.T) map[string]note.Note {
+		notes, err := noteSvc.GetNotesByUserID(context.Background(), rob.ID)
+		require.NoError(t, err)
+		m := map[string]note.Note{}
+		for _, n := range notes {
+			m[n.Title.String()] = n
+		}
+		return m
+	}
+
+	t.Run("Markdown zip", func(t *testing.T) {
+		body := zipFiles(t, map[string]string{
+			"Trip.md":                      "---\ntitle: Trip to Rome\nformat: markdown\n---\n# Day 1\n",
+			"journal/2024-01-02.md":        "Dear diary",
+			"todo.txt":                     "* not a list",
+			"groceries.md":                 "---\ntitle: Groceries\nformat: plain\n---\nmilk, eggs",
+			"broken.md":                    "---\ntitle: [unclosed\n---\n",
+			"photo.jpg":                    "\xff\xd8",
+			"__MACOSX/._Trip.md":           "junk",
+			"journal/.DS_Store":            "junk",
+			"empty title/---.md":           "---\ntitle: ' '\n---\n",
+			"unterminated front matter.md": "---\ntitle: x\n",
+		})
+
+		job := importNotes(t, "/import", "application/zip", body)
+		assert.Equal(t, api.ImportDone, job.Status)
+		assert.Equal(t, "markdown", job.Format)
+		assert.Equal(t, 8, job.Total)
+		assert.Equal(t, 8, job.Processed)
+		assert.Equal(t, 3, job.Imported)
+		assert.Equal(t, 1, job.Duplicates)
+		assert.Equal(t, 4, job.Failed)
+		assert.NotNil(t, job.FinishedAt)
+
+		var failed []string
+		for _, e := range job.Errors {
+			failed = append(failed, e.Item)
+		}
+		assert.ElementsMatch(t, []string{"broken.md", "photo.jpg", "empty title/---.md", "unterminated front matter.md"}, failed)
+
+		notes := notesByTitle(t)
+		assert.Equal(t, "# Day 1\n", notes["Trip to Rome"].Content.String())
+		assert.Equal(t, note.FormatMarkdown, notes["Trip to Rome"].Format)
+		assert.Equal(t, "Dear diary", notes["2024-01-02"].Content.String())
+		assert.Equal(t, note.FormatPlain, notes["todo"].Format)
+	})
+
+	t.Run("JSON export", func(t *testing.T) {
+		body := `{"version": 1, "notes": [
+			{"id": "` + uuid.NewString() + `", "title": "Ideas", "content": "buy more milk", "format": "plain"},
+			{"id": "` + uuid.NewString() + `", "title": "Ideas", "content": "buy more milk", "format": "plain"},
+			{"id": "` + uuid.NewString() + `", "title": "Bad", "content": "", "format": "html"}
+		]}`
+
+		job := importNotes(t, "/import?format=json", "", []byte(body))
+		assert.Equal(t, 1, job.Imported)
+		assert.Equal(t, 1, job.Duplicates)
+		assert.Equal(t, []api.ImportError{{Item: `#3 "Bad"`, Error: "format: must be plain or markdown"}}, job.Errors)
+		assert.Equal(t, "buy more milk", notesByTitle(t)["Ideas"].Content.String())
+	})
+
+	t.Run("Evernote export", func(t *testing.T) {
+		body := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE en-export SYSTEM "http://xml.evernote.com/pub/evernote-export3.dtd">
+<en-export export-date="20240102T120000Z" application="Evernote" version="10">
+<note><title>Recipe</title><content><![CDATA[<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<!DOCTYPE en-note SYSTEM "http://xml.evernote.com/pub/enml2.dtd">
+<en-note><h2>Pancakes</h2><div>Mix <b>flour</b> and <i>milk</i>, see <a href="https://example.com/a b">this</a>.</div>
+<div><br/></div>
+<ul><li><div>2 eggs</div></li><li><div>salt</div><ul><li>a pinch</li></ul></li></ul>
+<div><en-todo checked="true"/>buy eggs</div><div><en-todo/>bake</div>
+<ol><li>stir</li><li>fry</li></ol>
+<blockquote><div>Tasty</div></blockquote>
+<pre>x := 1
+y := 2</pre>
+<table><tr><td>a</td><td>b|c</td></tr><tr><td>1</td></tr></table>
+<div>5 * 3 = [15] <en-media type="image/png" hash="abc"/></div>
+</en-note>]]></content><created>20240101T100000Z</created></note>
+<note><title></title><content><![CDATA[<en-note>untitled</en-note>]]></content></note>
+</en-export>`
+
+		job := importNotes(t, "/import", "application/enex+xml", []byte(body))
+		assert.Equal(t, "enex", job.Format)
+		assert.Equal(t, 1, job.Imported)
+		assert.Equal(t, []api.ImportError{{Item: `#2 ""`, Error: "title: is required"}}, job.Errors)
+
+		recipe := notesByTitle(t)["Recipe"]
+		assert.Equal(t, note.FormatMarkdown, recipe.Format)
+		assert.Equal(t, "## Pancakes\n\n"+
+			"Mix **flour** and *milk*, see [this](<https://example.com/a b>).\n\n"+
+			"- 2 eggs\n- salt\n\n  - a pinch\n\n"+
+			"- [x] buy eggs\n\n"+
+			"- [ ] bake\n\n"+
+			"1. stir\n2. fry\n\n"+
+			"> Tasty\n\n"+
+			"```\nx := 1\ny := 2\n```\n\n"+
+			"| a | b\\|c |\n| --- | --- |\n| 1 |  |\n\n"+
+			`5 \* 3 = \[15\]`, recipe.Content.String())
+	})
+
+	t.Run("Invalid uploads", func(t *testing.T) {
+		rr := do(http.MethodPost, "/import", "text/plain", []byte("notes"))
+		assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+
+		rr = do(http.MethodPost, "/import", "application/zip", []byte("not a zip"))
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = do(http.MethodPost, "/import", "application/json", []byte(`{"version": 2, "notes": []}`))
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.JSONEq(t, `{"error": "read json: unsupported version 2"}`, rr.Body.String())
+
+		rr = do(http.MethodPost, "/import", "application/json", bytes.Repeat([]byte(" "), transfergrp.MaxImportBytes+1))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	})
+
+	t.Run("Jobs of other users are not found", func(t *testing.T) {
+		job := importNotes(t, "/import", "application/json", []byte(`{"version": 1, "notes": []}`))
+		assert.Equal(t, api.ImportDone, job.Status)
+
+		tokenS, err := jwtSvc.CreateToken(anna.ID, time.Minute)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodGet, "/import/"+job.ID.String(), nil)
+		req.Header.Set("Authorization", "Bearer "+tokenS)
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		rr = do(http.MethodGet, "/import/"+uuid.NewString(), "", nil)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		rr = do(http.MethodGet, "/import/x", "", nil)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func Test_Import_Limits(t *testing.T) {
+	var users []user.User
+	for i := range transfergrp.MaxRunningImports + 1 {
+		users = append(users, user.User{ID: uuid.UUID{byte(i + 1)}})
+	}
+
+	jwtSvc := auth.MustNewJWTService(common.MustGenerateRandomKey(32))
+	userSvc := user.NewSvc(usermemory.NewRepo(users))
+	release := make(chan struct{})
+	defer close(release)
+	noteSvc := blockingNotesSvc{Service: note.NewNotesService(notememory.MustNewRepo(nil), userSvc), release: release}
+	app := mux.NewAPI(transfergrp.Routes, mux.Config{Auth: auth.NewAuth(jwtSvc), NoteSvc: noteSvc, UserSvc: userSvc})
+
+	upload := func(t *testing.T, userID uuid.UUID, body io.Reader) *httptest.ResponseRecorder {
+		t.Helper()
+		tokenS, err := jwtSvc.CreateToken(userID, time.Minute)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/import", body)
+		req.Header.Set("Authorization", "Bearer "+tokenS)
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, req)
+		return rr
+	}
+	empty := func() io.Reader { return strings.NewReader(`{"version": 1, "notes": []}`) }
+
+	t.Run("An invalid upload frees its job", func(t *testing.T) {
+		rr := upload(t, users[0].ID, strings.NewReader("not json"))
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	for _, u := range users[:transfergrp.MaxRunningImports] {
+		rr := upload(t, u.ID, empty())
+		require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+	}
+
+	t.Run("A second import of the user is refused before the upload is read", func(t *testing.T) {
+		body := &countingReader{r: empty()}
+		rr := upload(t, users[0].ID, body)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.Zero(t, body.n)
+	})
+
+	t.Run("Imports of all users are limited", func(t *testing.T) {
+		body := &countingReader{r: empty()}
+		rr := upload(t, users[transfergrp.MaxRunningImports].ID, body)
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		assert.JSONEq(t, `{"error": "too many imports are running, try again later"}`, rr.Body.String())
+		assert.Zero(t, body.n)
+	})
+}
+
+// blockingNotesSvc keeps the imports running until release is closed.
+type blockingNotesSvc struct {
+	note.Service
+	release chan struct{}
+}
+
+func (s blockingNotesSvc) IterateByUserID(ctx context.Context, userID uuid.UUID, fn func(note.Note) error) error {
+	<-s.release
+	return s.Service.IterateByUserID(ctx, userID, fn)
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+func zipFiles(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
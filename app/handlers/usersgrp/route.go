@@ -0,0 +1,24 @@
+package usersgrp
+
+import (
+	"github.com/Keisn1/note-taking-app/domain/web/mid"
+	"github.com/Keisn1/note-taking-app/domain/web/mux"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+)
+
+// Routes adds the user routes to the app. Signup and login are public, the
+// routes of the current user require authentication.
+func Routes(app *web.App, cfg mux.Config) {
+	hdl := NewHandlers(cfg.UserSvc, cfg.Auth, cfg.TokenTTL)
+
+	users := app.Group("/users")
+	users.Post("", hdl.Create)
+	users.Post("/login", hdl.Login)
+	users.Post("/token", hdl.Refresh, mid.Authenticate(cfg.Auth, cfg.UserSvc))
+
+	me := users.Group("/me", mid.Authenticate(cfg.Auth, cfg.UserSvc))
+	me.Get("", hdl.Me)
+	me.Put("", hdl.Update)
+	me.Patch("", hdl.Patch)
+	me.Delete("", hdl.Delete)
+}
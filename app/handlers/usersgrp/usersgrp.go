@@ -0,0 +1,214 @@
+package usersgrp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/app/api"
+	"github.com/Keisn1/note-taking-app/domain/core/audit"
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	"github.com/Keisn1/note-taking-app/domain/web/auth"
+	"github.com/Keisn1/note-taking-app/domain/web/mid"
+	"github.com/Keisn1/note-taking-app/foundation/metrics"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+	"github.com/google/uuid"
+)
+
+// DefaultTokenTTL is used if no token lifetime is configured.
+const DefaultTokenTTL = 24 * time.Hour
+
+type Handlers struct {
+	userSvc  user.Service
+	auth     auth.Auth
+	tokenTTL time.Duration
+}
+
+func NewHandlers(us user.Service, a auth.Auth, tokenTTL time.Duration) Handlers {
+	if tokenTTL <= 0 {
+		tokenTTL = DefaultTokenTTL
+	}
+	return Handlers{userSvc: us, auth: a, tokenTTL: tokenTTL}
+}
+
+// Create registers a new user.
+func (hdl Handlers) Create(w http.ResponseWriter, r *http.Request) {
+	var up api.UserPost
+	if err := web.Decode(w, r, &up); err != nil {
+		api.RespondDecodeError(w, r, err)
+		return
+	}
+
+	email, err := user.ParseEmail(up.Email)
+	if err != nil {
+		respondError(w, r, http.StatusUnprocessableEntity, "invalid email", err)
+		return
+	}
+
+	u, err := hdl.userSvc.Create(r.Context(), user.UpdateUser{
+		Name:     user.NewName(up.Name),
+		Email:    user.NewEmail(email.String().Address),
+		Password: user.NewPassword(up.Password),
+	})
+	if err != nil {
+		hdl.respondUserError(w, r, "create user", err)
+		return
+	}
+
+	web.Respond(w, http.StatusCreated, api.ToUserResponse(u))
+	web.Logger(r.Context()).Info("user created", "user_id", u.ID)
+}
+
+// Me returns the authenticated user.
+func (hdl Handlers) Me(w http.ResponseWriter, r *http.Request) {
+	u, err := hdl.userSvc.QueryByID(r.Context(), mid.GetUserID(r.Context()))
+	if err != nil {
+		hdl.respondUserError(w, r, "query user", err)
+		return
+	}
+
+	web.Respond(w, http.StatusOK, api.ToUserResponse(u))
+}
+
+// Update replaces name, email and optionally the password of the
+// authenticated user.
+func (hdl Handlers) Update(w http.ResponseWriter, r *http.Request) {
+	var up api.UserPut
+	if err := web.Decode(w, r, &up); err != nil {
+		api.RespondDecodeError(w, r, err)
+		return
+	}
+
+	email, err := user.ParseEmail(up.Email)
+	if err != nil {
+		respondError(w, r, http.StatusUnprocessableEntity, "invalid email", err)
+		return
+	}
+
+	u, err := hdl.userSvc.QueryByID(r.Context(), mid.GetUserID(r.Context()))
+	if err != nil {
+		hdl.respondUserError(w, r, "update user", err)
+		return
+	}
+
+	uu := user.UpdateUser{Name: user.NewName(up.Name), Email: user.NewEmail(email.String().Address)}
+	if up.Password != "" {
+		uu.Password = user.NewPassword(up.Password)
+	}
+
+	u, err = hdl.userSvc.Update(r.Context(), u, uu)
+	if err != nil {
+		hdl.respondUserError(w, r, "update user", err)
+		return
+	}
+
+	web.Respond(w, http.StatusOK, api.ToUserResponse(u))
+	web.Logger(r.Context()).Info("user updated", "user_id", u.ID)
+}
+
+// Patch changes the fields of the authenticated user present in the request,
+// see api.UserPatch.
+func (hdl Handlers) Patch(w http.ResponseWriter, r *http.Request) {
+	var up api.UserPatch
+	if err := web.Decode(w, r, &up); err != nil {
+		api.RespondDecodeError(w, r, err)
+		return
+	}
+
+	u, err := hdl.userSvc.QueryByID(r.Context(), mid.GetUserID(r.Context()))
+	if err != nil {
+		hdl.respondUserError(w, r, "patch user", err)
+		return
+	}
+
+	u, err = hdl.userSvc.Update(r.Context(), u, up.UpdateUser())
+	if err != nil {
+		hdl.respondUserError(w, r, "patch user", err)
+		return
+	}
+
+	web.Respond(w, http.StatusOK, api.ToUserResponse(u))
+	web.Logger(r.Context()).Info("user updated", "user_id", u.ID)
+}
+
+// Delete deletes the authenticated user.
+func (hdl Handlers) Delete(w http.ResponseWriter, r *http.Request) {
+	userID := mid.GetUserID(r.Context())
+
+	if err := hdl.userSvc.Delete(r.Context(), userID); err != nil {
+		hdl.respondUserError(w, r, "delete user", err)
+		return
+	}
+
+	web.Respond(w, http.StatusNoContent, nil)
+	web.Logger(r.Context()).Info("user deleted", "user_id", userID)
+}
+
+// Login exchanges email and password for a token.
+func (hdl Handlers) Login(w http.ResponseWriter, r *http.Request) {
+	var lp api.LoginPost
+	if err := web.Decode(w, r, &lp); err != nil {
+		api.RespondDecodeError(w, r, err)
+		return
+	}
+
+	u, err := hdl.userSvc.Authenticate(r.Context(), lp.Email, lp.Password)
+	if err != nil {
+		if errors.Is(err, user.ErrInvalidCredentials) || errors.Is(err, user.ErrUserDisabled) {
+			respondError(w, r, http.StatusUnauthorized, "invalid credentials", err)
+			metrics.AuthFailures.Inc()
+			mid.RecordAudit(r.Context(), uuid.Nil, audit.ActionLoginFailed, audit.EmailTarget(lp.Email))
+			return
+		}
+		respondError(w, r, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	hdl.respondToken(w, r, u.ID)
+	web.Logger(r.Context()).Info("user logged in", "user_id", u.ID)
+	mid.RecordAudit(r.Context(), u.ID, audit.ActionLogin, audit.UserTarget(u.ID))
+}
+
+// Refresh exchanges a valid token for a new one. Authenticate already
+// rejected the tokens of disabled and deleted users.
+func (hdl Handlers) Refresh(w http.ResponseWriter, r *http.Request) {
+	hdl.respondToken(w, r, mid.GetUserID(r.Context()))
+}
+
+func (hdl Handlers) respondToken(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	expiresAt := time.Now().Add(hdl.tokenTTL)
+	tokenS, err := hdl.auth.CreateToken(userID, hdl.tokenTTL)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	web.Respond(w, http.StatusOK, api.TokenResponse{Token: tokenS, ExpiresAt: expiresAt.UTC().Truncate(time.Second)})
+}
+
+func (hdl Handlers) respondUserError(w http.ResponseWriter, r *http.Request, op string, err error) {
+	switch {
+	case errors.Is(err, user.ErrUserNotFound):
+		respondError(w, r, http.StatusNotFound, "user not found", err)
+	case errors.Is(err, user.ErrEmailTaken):
+		respondError(w, r, http.StatusConflict, "email already taken", err)
+	case errors.Is(err, user.ErrInvalidPassword):
+		respondError(w, r, http.StatusUnprocessableEntity, "invalid password", err)
+	default:
+		respondError(w, r, http.StatusInternalServerError, "", fmt.Errorf("%s: %w", op, err))
+	}
+}
+
+// respondError answers with an api.ErrorResponse. Server errors are logged
+// with their cause but answered with a generic message.
+func respondError(w http.ResponseWriter, r *http.Request, status int, msg string, err error) {
+	log := web.Logger(r.Context())
+	if status >= http.StatusInternalServerError {
+		msg = http.StatusText(status)
+		log.Error("request failed", "error", err)
+	} else {
+		log.Info(msg, "error", err)
+	}
+	web.Respond(w, status, api.ErrorResponse{Error: msg})
+}
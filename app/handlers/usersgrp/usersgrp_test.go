@@ -0,0 +1,230 @@
+package usersgrp_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/app/api"
+	"github.com/Keisn1/note-taking-app/app/handlers/usersgrp"
+	"github.com/Keisn1/note-taking-app/domain/core/audit"
+	auditmemory "github.com/Keisn1/note-taking-app/domain/core/audit/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	"github.com/Keisn1/note-taking-app/domain/core/user/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/web/auth"
+	"github.com/Keisn1/note-taking-app/domain/web/mux"
+	"github.com/Keisn1/note-taking-app/foundation/common"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Login(t *testing.T) {
+	jwtSvc := auth.MustNewJWTService(common.MustGenerateRandomKey(32))
+	userSvc := user.NewSvc(memory.NewRepo(nil))
+	rob, err := userSvc.Create(context.Background(), user.UpdateUser{
+		Name: user.NewName("rob"), Email: user.NewEmail("rob@example.com"), Password: user.NewPassword("password"),
+	})
+	require.NoError(t, err)
+
+	auditSvc := audit.NewSvc(auditmemory.NewRepo())
+	cfg := mux.Config{Auth: auth.NewAuth(jwtSvc), UserSvc: userSvc, AuditSvc: auditSvc, TokenTTL: time.Hour}
+	app := mux.NewAPI(usersgrp.Routes, cfg)
+
+	login := func(body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/users/login", strings.NewReader(body)))
+		return rr
+	}
+	lastAudit := func() audit.Entry {
+		t.Helper()
+		p, err := auditSvc.Query(context.Background(), audit.Filter{Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, p.Entries, 1)
+		return p.Entries[0]
+	}
+
+	t.Run("Valid credentials return a token", func(t *testing.T) {
+		rr := login(`{"email": "rob@example.com", "password": "password"}`)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp api.TokenResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.WithinDuration(t, time.Now().Add(time.Hour), resp.ExpiresAt, 2*time.Second)
+
+		claims, err := jwtSvc.Verify(resp.Token)
+		require.NoError(t, err)
+		assert.Equal(t, rob.ID.String(), claims.Subject)
+
+		e := lastAudit()
+		assert.Equal(t, audit.ActionLogin, e.Action)
+		assert.Equal(t, rob.ID, e.Actor)
+	})
+
+	t.Run("Wrong password", func(t *testing.T) {
+		rr := login(`{"email": "rob@example.com", "password": "wrong password"}`)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		assert.JSONEq(t, `{"error": "invalid credentials"}`, rr.Body.String())
+
+		e := lastAudit()
+		assert.Equal(t, audit.ActionLoginFailed, e.Action)
+		assert.Equal(t, uuid.Nil, e.Actor)
+		assert.Equal(t, audit.EmailTarget("rob@example.com"), e.Target)
+	})
+
+	t.Run("Unknown email", func(t *testing.T) {
+		rr := login(`{"email": "anna@example.com", "password": "password"}`)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("Missing fields", func(t *testing.T) {
+		rr := login(`{"email": "rob@example.com"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	})
+
+	t.Run("Disabled user", func(t *testing.T) {
+		_, err := userSvc.SetDisabled(context.Background(), rob.ID, true)
+		require.NoError(t, err)
+
+		rr := login(`{"email": "rob@example.com", "password": "password"}`)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
+
+func Test_UserRoutes(t *testing.T) {
+	jwtSvc := auth.MustNewJWTService(common.MustGenerateRandomKey(32))
+	a := auth.NewAuth(jwtSvc)
+	userSvc := user.NewSvc(memory.NewRepo(nil))
+	anna, err := userSvc.Create(context.Background(), user.UpdateUser{
+		Name: user.NewName("anna"), Email: user.NewEmail("anna@example.com"), Password: user.NewPassword("password"),
+	})
+	require.NoError(t, err)
+
+	cfg := mux.Config{Auth: a, UserSvc: userSvc, TokenTTL: time.Hour}
+	app := mux.NewAPI(usersgrp.Routes, cfg)
+
+	do := func(method, target, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, req)
+		return rr
+	}
+
+	var rob api.UserResponse
+	t.Run("Signup", func(t *testing.T) {
+		rr := do(http.MethodPost, "/users", "", `{"name": "rob", "email": "rob@example.com", "password": "password"}`)
+		require.Equal(t, http.StatusCreated, rr.Code)
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &rob))
+		assert.Equal(t, "rob", rob.Name)
+		assert.Equal(t, "rob@example.com", rob.Email)
+	})
+
+	t.Run("Signup with taken email", func(t *testing.T) {
+		rr := do(http.MethodPost, "/users", "", `{"name": "rob", "email": "anna@example.com", "password": "password"}`)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.JSONEq(t, `{"error": "email already taken"}`, rr.Body.String())
+	})
+
+	t.Run("Signup with invalid fields", func(t *testing.T) {
+		rr := do(http.MethodPost, "/users", "", `{"name": "rob", "email": "rob", "password": "short"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	})
+
+	token, err := a.CreateToken(rob.ID, time.Hour)
+	require.NoError(t, err)
+
+	t.Run("Me requires authentication", func(t *testing.T) {
+		rr := do(http.MethodGet, "/users/me", "", "")
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("Me", func(t *testing.T) {
+		rr := do(http.MethodGet, "/users/me", token, "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		var got api.UserResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+		assert.Equal(t, rob, got)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		rr := do(http.MethodPut, "/users/me", token, `{"name": "robert", "email": "robert@example.com", "password": "new password"}`)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"id": "`+rob.ID.String()+`", "name": "robert", "email": "robert@example.com"}`, rr.Body.String())
+
+		_, err := userSvc.Authenticate(context.Background(), "robert@example.com", "new password")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Update keeps the password if omitted", func(t *testing.T) {
+		rr := do(http.MethodPut, "/users/me", token, `{"name": "rob", "email": "robert@example.com"}`)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		_, err := userSvc.Authenticate(context.Background(), "robert@example.com", "new password")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Update to taken email", func(t *testing.T) {
+		rr := do(http.MethodPut, "/users/me", token, `{"name": "rob", "email": "anna@example.com"}`)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("Patch", func(t *testing.T) {
+		rr := do(http.MethodPatch, "/users/me", token, `{"name": "bob"}`)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"id": "`+rob.ID.String()+`", "name": "bob", "email": "robert@example.com"}`, rr.Body.String())
+
+		rr = do(http.MethodPatch, "/users/me", token, `{"email": null, "password": "short"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		assert.JSONEq(t, `{"error": "validation failed", "fields": [
+			{"field": "email", "error": "must not be null"},
+			{"field": "password", "error": "must be at least 8 bytes"}
+		]}`, rr.Body.String())
+
+		rr = do(http.MethodPatch, "/users/me", token, `{"email": "invalid"}`)
+		assert.JSONEq(t, `{"error": "validation failed", "fields": [{"field": "email", "error": "must be a valid email address"}]}`, rr.Body.String())
+
+		rr = do(http.MethodPatch, "/users/me", token, `{"email": "anna@example.com"}`)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("Refresh", func(t *testing.T) {
+		rr := do(http.MethodPost, "/users/token", token, "")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp api.TokenResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		claims, err := jwtSvc.Verify(resp.Token)
+		require.NoError(t, err)
+		assert.Equal(t, rob.ID.String(), claims.Subject)
+	})
+
+	t.Run("Refresh of disabled user", func(t *testing.T) {
+		annaToken, err := a.CreateToken(anna.ID, time.Hour)
+		require.NoError(t, err)
+		_, err = userSvc.SetDisabled(context.Background(), anna.ID, true)
+		require.NoError(t, err)
+
+		rr := do(http.MethodPost, "/users/token", annaToken, "")
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		rr = do(http.MethodGet, "/users/me", annaToken, "")
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		rr := do(http.MethodDelete, "/users/me", token, "")
+		require.Equal(t, http.StatusNoContent, rr.Code)
+
+		rr = do(http.MethodGet, "/users/me", token, "")
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+
+		rr = do(http.MethodPost, "/users/token", token, "")
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+}
@@ -0,0 +1,21 @@
+package webhooksgrp
+
+import (
+	"github.com/Keisn1/note-taking-app/domain/web/mid"
+	"github.com/Keisn1/note-taking-app/domain/web/mux"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+)
+
+// Routes adds the webhook routes to the app. All of them require
+// authentication, webhooks of other users are not found.
+func Routes(app *web.App, cfg mux.Config) {
+	hdl := NewHandlers(cfg.WebhookSvc)
+
+	webhooks := app.Group("/webhooks", mid.Authenticate(cfg.Auth, cfg.UserSvc))
+	webhooks.Post("", hdl.Create)
+	webhooks.Get("", hdl.List)
+	webhooks.Get("/{webhook_id}", hdl.Get)
+	webhooks.Delete("/{webhook_id}", hdl.Delete)
+	webhooks.Get("/{webhook_id}/deliveries", hdl.Deliveries)
+	webhooks.Post("/{webhook_id}/deliveries/{delivery_id}/retry", hdl.Retry)
+}
@@ -0,0 +1,182 @@
+// Package webhooksgrp manages the webhooks of the user and shows their
+// delivery logs.
+package webhooksgrp
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Keisn1/note-taking-app/app/api"
+	"github.com/Keisn1/note-taking-app/domain/core/webhook"
+	"github.com/Keisn1/note-taking-app/domain/web/mid"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+	"github.com/google/uuid"
+)
+
+type Handlers struct {
+	webhookSvc webhook.Service
+}
+
+func NewHandlers(ws webhook.Service) Handlers {
+	return Handlers{webhookSvc: ws}
+}
+
+// Create registers a webhook. The response is the only one that shows its
+// secret.
+func (hdl Handlers) Create(w http.ResponseWriter, r *http.Request) {
+	userID := mid.GetUserID(r.Context())
+
+	var wp api.WebhookPost
+	if err := web.Decode(w, r, &wp); err != nil {
+		api.RespondDecodeError(w, r, err)
+		return
+	}
+
+	wh, err := hdl.webhookSvc.Create(r.Context(), wp.ToNewWebhook(userID))
+	if err != nil {
+		respondError(w, r, "create webhook", err)
+		return
+	}
+
+	resp := api.ToWebhookResponse(wh)
+	resp.Secret = wh.Secret
+	w.Header().Set("Location", "/webhooks/"+wh.ID.String())
+	web.Respond(w, http.StatusCreated, resp)
+	web.Logger(r.Context()).Info("webhook created", "webhook_id", wh.ID)
+}
+
+// List returns the webhooks of the user, oldest first.
+func (hdl Handlers) List(w http.ResponseWriter, r *http.Request) {
+	userID := mid.GetUserID(r.Context())
+
+	webhooks, err := hdl.webhookSvc.QueryByUserID(r.Context(), userID)
+	if err != nil {
+		respondError(w, r, "list webhooks", err)
+		return
+	}
+	web.Respond(w, http.StatusOK, api.ToWebhookResponses(webhooks))
+}
+
+func (hdl Handlers) Get(w http.ResponseWriter, r *http.Request) {
+	wh, ok := hdl.webhook(w, r)
+	if !ok {
+		return
+	}
+	web.Respond(w, http.StatusOK, api.ToWebhookResponse(wh))
+}
+
+// Delete deletes the webhook, its pending deliveries are not sent.
+func (hdl Handlers) Delete(w http.ResponseWriter, r *http.Request) {
+	wh, ok := hdl.webhook(w, r)
+	if !ok {
+		return
+	}
+
+	if err := hdl.webhookSvc.Delete(r.Context(), wh.ID); err != nil {
+		respondError(w, r, "delete webhook", err)
+		return
+	}
+	web.Respond(w, http.StatusNoContent, nil)
+	web.Logger(r.Context()).Info("webhook deleted", "webhook_id", wh.ID)
+}
+
+// Deliveries returns the delivery log of the webhook, newest first.
+func (hdl Handlers) Deliveries(w http.ResponseWriter, r *http.Request) {
+	wh, ok := hdl.webhook(w, r)
+	if !ok {
+		return
+	}
+
+	q, err := api.ParseDeliveryQuery(r)
+	if err != nil {
+		web.Respond(w, http.StatusBadRequest, api.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	ds, err := hdl.webhookSvc.Deliveries(r.Context(), wh.ID, q.Status, q.Limit)
+	if err != nil {
+		respondError(w, r, "list deliveries", err)
+		return
+	}
+	web.Respond(w, http.StatusOK, api.ToDeliveryResponses(ds))
+}
+
+// Retry sends a dead delivery again.
+func (hdl Handlers) Retry(w http.ResponseWriter, r *http.Request) {
+	wh, ok := hdl.webhook(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("delivery_id"))
+	if err != nil {
+		respondError(w, r, "retry delivery", webhook.ErrDeliveryNotFound)
+		return
+	}
+	d, err := hdl.webhookSvc.QueryDeliveryByID(r.Context(), id)
+	if err == nil && d.WebhookID != wh.ID {
+		err = webhook.ErrDeliveryNotFound
+	}
+	if err != nil {
+		respondError(w, r, "retry delivery", err)
+		return
+	}
+
+	d, err = hdl.webhookSvc.Retry(r.Context(), d)
+	if err != nil {
+		respondError(w, r, "retry delivery", err)
+		return
+	}
+	web.Respond(w, http.StatusAccepted, api.ToDeliveryResponse(d))
+	web.Logger(r.Context()).Info("delivery retried", "webhook_id", wh.ID, "delivery_id", d.ID)
+}
+
+// webhook returns the webhook of the path if it belongs to the user. The
+// webhooks of other users are not found, so that their IDs aren't revealed.
+func (hdl Handlers) webhook(w http.ResponseWriter, r *http.Request) (webhook.Webhook, bool) {
+	userID := mid.GetUserID(r.Context())
+
+	id, err := uuid.Parse(r.PathValue("webhook_id"))
+	if err != nil {
+		respondError(w, r, "query webhook", webhook.ErrNotFound)
+		return webhook.Webhook{}, false
+	}
+
+	wh, err := hdl.webhookSvc.QueryByID(r.Context(), id)
+	if err == nil && wh.UserID != userID {
+		err = webhook.ErrNotFound
+	}
+	if err != nil {
+		respondError(w, r, "query webhook", err)
+		return webhook.Webhook{}, false
+	}
+	return wh, true
+}
+
+// respondError responds with the status of err. The known errors are
+// returned without the context the service wrapped them in.
+func respondError(w http.ResponseWriter, r *http.Request, op string, err error) {
+	status, msg := http.StatusInternalServerError, error(nil)
+	for _, known := range []struct {
+		err    error
+		status int
+	}{
+		{webhook.ErrNotFound, http.StatusNotFound},
+		{webhook.ErrDeliveryNotFound, http.StatusNotFound},
+		{webhook.ErrLimitReached, http.StatusConflict},
+		{webhook.ErrNotDead, http.StatusConflict},
+	} {
+		if errors.Is(err, known.err) {
+			status, msg = known.status, known.err
+			break
+		}
+	}
+
+	if msg == nil {
+		web.Respond(w, status, api.ErrorResponse{Error: http.StatusText(status)})
+		web.Logger(r.Context()).Error(op, "error", err)
+		return
+	}
+	web.Respond(w, status, api.ErrorResponse{Error: msg.Error()})
+	web.Logger(r.Context()).Info(op, "error", err)
+}
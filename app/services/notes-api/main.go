@@ -0,0 +1,412 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/app/config"
+	"github.com/Keisn1/note-taking-app/app/handlers/attachmentsgrp"
+	"github.com/Keisn1/note-taking-app/app/handlers/auditgrp"
+	"github.com/Keisn1/note-taking-app/app/handlers/checkgrp"
+	"github.com/Keisn1/note-taking-app/app/handlers/collabgrp"
+	"github.com/Keisn1/note-taking-app/app/handlers/docsgrp"
+	"github.com/Keisn1/note-taking-app/app/handlers/eventsgrp"
+	"github.com/Keisn1/note-taking-app/app/handlers/linksgrp"
+	"github.com/Keisn1/note-taking-app/app/handlers/notesgrp"
+	"github.com/Keisn1/note-taking-app/app/handlers/syncgrp"
+	"github.com/Keisn1/note-taking-app/app/handlers/transfergrp"
+	"github.com/Keisn1/note-taking-app/app/handlers/usersgrp"
+	"github.com/Keisn1/note-taking-app/app/handlers/webhooksgrp"
+	"github.com/Keisn1/note-taking-app/domain/core/attachment"
+	"github.com/Keisn1/note-taking-app/domain/core/attachment/repositories/attachmentdb"
+	"github.com/Keisn1/note-taking-app/domain/core/audit"
+	"github.com/Keisn1/note-taking-app/domain/core/audit/repositories/auditdb"
+	"github.com/Keisn1/note-taking-app/domain/core/change"
+	"github.com/Keisn1/note-taking-app/domain/core/change/repositories/changedb"
+	"github.com/Keisn1/note-taking-app/domain/core/collab"
+	"github.com/Keisn1/note-taking-app/domain/core/link"
+	"github.com/Keisn1/note-taking-app/domain/core/link/repositories/linkdb"
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/Keisn1/note-taking-app/domain/core/note/repositories/notedb"
+	"github.com/Keisn1/note-taking-app/domain/core/note/repositories/notemetrics"
+	"github.com/Keisn1/note-taking-app/domain/core/outbox"
+	"github.com/Keisn1/note-taking-app/domain/core/outbox/repositories/outboxdb"
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	"github.com/Keisn1/note-taking-app/domain/core/user/repositories/userdb"
+	"github.com/Keisn1/note-taking-app/domain/core/user/repositories/usermetrics"
+	"github.com/Keisn1/note-taking-app/domain/core/webhook"
+	"github.com/Keisn1/note-taking-app/domain/core/webhook/repositories/webhookdb"
+	"github.com/Keisn1/note-taking-app/domain/data/migrate"
+	"github.com/Keisn1/note-taking-app/domain/web/auth"
+	"github.com/Keisn1/note-taking-app/domain/web/mux"
+	"github.com/Keisn1/note-taking-app/foundation/blobstore"
+	"github.com/Keisn1/note-taking-app/foundation/common"
+	"github.com/Keisn1/note-taking-app/foundation/conf"
+	"github.com/Keisn1/note-taking-app/foundation/eventbus"
+	"github.com/Keisn1/note-taking-app/foundation/health"
+	"github.com/Keisn1/note-taking-app/foundation/metrics"
+	"github.com/Keisn1/note-taking-app/foundation/tracing"
+	"github.com/Keisn1/note-taking-app/foundation/transaction"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+func main() {
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "config" {
+		if err := configCmd(args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	cfg, err := loadConfig(args)
+	if err != nil {
+		if errors.Is(err, conf.ErrHelp) {
+			return
+		}
+		fmt.Fprintln(os.Stderr, "config:", err)
+		os.Exit(2)
+	}
+
+	log, err := cfg.Logger(os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "config:", err)
+		os.Exit(2)
+	}
+
+	if err := run(log, cfg); err != nil {
+		log.Error("startup", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run(log *slog.Logger, cfg config.Config) error {
+	ctx := context.Background()
+
+	// -------------------------------------------------------------------------
+	// Tracing
+
+	shutdownTracing, err := tracing.Init(ctx, tracing.Config{
+		ServiceName: "notes-api",
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		return fmt.Errorf("tracing: %w", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	// -------------------------------------------------------------------------
+	// Database
+
+	db, err := sql.Open("pgx", cfg.DB.DSN)
+	if err != nil {
+		return fmt.Errorf("open db: %w", err)
+	}
+	defer db.Close()
+
+	db.SetMaxOpenConns(cfg.DB.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.DB.MaxIdleConns)
+
+	if err := metrics.RegisterDBStats(db, "notes"); err != nil {
+		return fmt.Errorf("db metrics: %w", err)
+	}
+
+	if cfg.DB.Migrate {
+		if err := migrate.Migrate(ctx, db); err != nil {
+			return err
+		}
+	}
+
+	// -------------------------------------------------------------------------
+	// Services
+
+	key, previousKeys, err := cfg.SigningKeys()
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		log.Warn("startup: no signing key configured, tokens will not survive a restart")
+		key = common.MustGenerateRandomKey(config.MinSigningKeyLength)
+	}
+
+	auditAdmins, err := cfg.AuditAdmins()
+	if err != nil {
+		return err
+	}
+
+	jwtSvc, err := auth.NewJWTService(key, previousKeys...)
+	if err != nil {
+		return fmt.Errorf("jwt service: %w", err)
+	}
+
+	store, err := attachmentStore(cfg)
+	if err != nil {
+		return fmt.Errorf("attachment store: %w", err)
+	}
+
+	userRepo := usermetrics.NewRepo(userdb.NewUserRepo(db))
+	attachmentSvc := attachment.NewSvc(attachmentdb.NewAttachmentRepo(db), store, attachment.Limits{
+		MaxSize: cfg.Attachments.MaxSize,
+		Quota:   cfg.Attachments.Quota,
+	})
+	events := eventbus.New(cfg.Events.LogSize)
+	changeRepo := changedb.NewChangeRepo(db)
+	noteRepo := change.NewNoteRepo(notemetrics.NewRepo(notedb.NewNotesRepo(db)), changeRepo)
+	linkSvc := link.NewSvc(linkdb.NewLinkRepo(db), noteRepo)
+	webhookRepo := webhookdb.NewWebhookRepo(db)
+	webhookSvc := webhook.NewSvc(webhookRepo)
+	auditRepo := auditdb.NewAuditRepo(db)
+	auditSvc := audit.NewSvc(auditRepo)
+	txManager := transaction.NewPostgres(db)
+	noteSvc := note.NewNotesService(
+		noteRepo,
+		user.NewSvc(userRepo),
+		note.WithTransactions(txManager),
+		note.WithSaveHook(linkSvc.Sync),
+		note.WithDeleteHook(attachmentSvc.DeleteByNoteID),
+		note.WithDeleteHook(linkSvc.DeleteByNoteID),
+		note.WithEventHook(audit.NoteEventHook(auditSvc)),
+		note.WithPublisher(eventsgrp.NotePublisher(events)),
+	)
+	// The notes of a deleted user are deleted through the note service,
+	// which removes their attachments and sends their events.
+	userSvc := user.NewSvc(userRepo, user.WithTransactions(txManager), user.WithDeleteHook(noteSvc.DeleteByUserID))
+	collabHub := collab.NewHub(noteSvc, cfg.Collab.SnapshotInterval)
+
+	// The note and user repositories write their domain events to the
+	// outbox with the change, the relay hands them to the subscribers.
+	relay := outbox.NewRelay(outboxdb.NewOutboxRepo(db), outbox.RelayConfig{
+		PollInterval: cfg.Outbox.PollInterval,
+		Retention:    cfg.Outbox.Retention,
+		OnError:      func(err error) { log.Error("outbox", "error", err) },
+	}, webhook.Subscriber(webhookSvc))
+	relayCtx, stopRelay := context.WithCancel(ctx)
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		relay.Run(relayCtx)
+	}()
+	defer func() {
+		stopRelay()
+		<-relayDone
+	}()
+
+	// Audit entries show up in the trail once chained, the pending ones are
+	// chained at the next start.
+	chainer := audit.NewChainer(auditRepo, audit.ChainerConfig{
+		Interval: cfg.Audit.ChainInterval,
+		OnError:  func(err error) { log.Error("audit", "error", err) },
+	})
+	chainCtx, stopChain := context.WithCancel(ctx)
+	chainDone := make(chan struct{})
+	go func() {
+		defer close(chainDone)
+		chainer.Run(chainCtx)
+	}()
+	defer func() {
+		stopChain()
+		<-chainDone
+	}()
+
+	// Deliveries are only sent while the dispatcher runs, the pending ones
+	// stay in the outbox until the next start.
+	dispatcher := webhook.NewDispatcher(webhookRepo, webhook.NewHTTPClient(cfg.Webhooks.Timeout, cfg.Webhooks.AllowPrivate), webhook.DispatcherConfig{
+		PollInterval: cfg.Webhooks.PollInterval,
+		MaxAttempts:  cfg.Webhooks.MaxAttempts,
+		Retention:    cfg.Webhooks.Retention,
+		OnError:      func(err error) { log.Error("webhooks", "error", err) },
+	})
+	dispatchCtx, stopDispatch := context.WithCancel(ctx)
+	dispatchDone := make(chan struct{})
+	go func() {
+		defer close(dispatchDone)
+		dispatcher.Run(dispatchCtx)
+	}()
+	defer func() {
+		stopDispatch()
+		<-dispatchDone
+	}()
+
+	readiness := health.NewReadiness(2*time.Second,
+		health.Check{Name: "postgres", Check: db.PingContext},
+		health.Check{Name: "migrations", Check: migrationsCheck(db)},
+		health.Check{Name: "signing_keys", Check: signingKeysCheck(jwtSvc)},
+	)
+
+	muxCfg := mux.Config{
+		Log:           log,
+		Auth:          auth.NewAuth(jwtSvc),
+		NoteSvc:       noteSvc,
+		UserSvc:       userSvc,
+		AttachmentSvc: attachmentSvc,
+		LinkSvc:       linkSvc,
+		SyncSvc:       change.NewSvc(changeRepo, noteSvc, txManager),
+		WebhookSvc:    webhookSvc,
+		AuditSvc:      auditSvc,
+		Events:        events,
+		Collab:        collabHub,
+		Readiness:     readiness,
+		TokenTTL:      cfg.Auth.TokenTTL,
+		CORSOrigins:   cfg.Web.CORSOrigins,
+		AuditAdmins:   auditAdmins,
+	}
+
+	// -------------------------------------------------------------------------
+	// Server
+
+	srv := http.Server{
+		Addr:              cfg.Web.Addr,
+		Handler:           mux.NewAPI(routes, muxCfg),
+		ReadHeaderTimeout: cfg.Web.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Web.ReadTimeout,
+		WriteTimeout:      cfg.Web.WriteTimeout,
+		IdleTimeout:       cfg.Web.IdleTimeout,
+		ErrorLog:          slog.NewLogLogger(log.Handler(), slog.LevelError),
+	}
+	// Event streams don't end on their own, closing the bus ends them on
+	// shutdown.
+	srv.RegisterOnShutdown(events.Close)
+
+	serverErrors := make(chan error, 1)
+	go func() {
+		log.Info("startup", "addr", cfg.Web.Addr)
+		serverErrors <- srv.ListenAndServe()
+	}()
+
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case err := <-serverErrors:
+		return fmt.Errorf("server: %w", err)
+
+	case sig := <-shutdown:
+		log.Info("shutdown: draining", "signal", sig.String(), "drain_period", cfg.Web.DrainPeriod)
+
+		// Fail readiness first so that the orchestrator stops routing new
+		// traffic here before the listener is closed.
+		readiness.Drain()
+		time.Sleep(cfg.Web.DrainPeriod)
+
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Web.ShutdownTimeout)
+		defer cancel()
+
+		// Collaborative editing runs on hijacked connections that Shutdown
+		// doesn't wait for, the sessions are saved and closed before.
+		if err := collabHub.Close(ctx); err != nil {
+			log.Error("shutdown: collab", "error", err)
+		}
+
+		if err := srv.Shutdown(ctx); err != nil {
+			srv.Close()
+			return fmt.Errorf("could not stop server gracefully: %w", err)
+		}
+		if err := <-serverErrors; !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("server: %w", err)
+		}
+		log.Info("shutdown: complete")
+	}
+
+	return nil
+}
+
+func routes(app *web.App, cfg mux.Config) {
+	attachmentsgrp.Routes(app, cfg)
+	auditgrp.Routes(app, cfg)
+	checkgrp.Routes(app, cfg)
+	collabgrp.Routes(app, cfg)
+	docsgrp.Routes(app, cfg)
+	eventsgrp.Routes(app, cfg)
+	linksgrp.Routes(app, cfg)
+	notesgrp.Routes(app, cfg)
+	syncgrp.Routes(app, cfg)
+	transfergrp.Routes(app, cfg)
+	usersgrp.Routes(app, cfg)
+	webhooksgrp.Routes(app, cfg)
+}
+
+// attachmentStore returns the configured blob store for attachments.
+func attachmentStore(cfg config.Config) (blobstore.Store, error) {
+	if cfg.Attachments.Store == "s3" {
+		s3, err := blobstore.NewS3(blobstore.S3Config{
+			Endpoint:  cfg.Attachments.S3.Endpoint,
+			Region:    cfg.Attachments.S3.Region,
+			Bucket:    cfg.Attachments.S3.Bucket,
+			AccessKey: cfg.Attachments.S3.AccessKey,
+			SecretKey: cfg.Attachments.S3.SecretKey,
+		})
+		return s3, err
+	}
+	fs, err := blobstore.NewFS(cfg.Attachments.Dir)
+	return fs, err
+}
+
+func migrationsCheck(db *sql.DB) func(context.Context) error {
+	return func(ctx context.Context) error {
+		applied, latest, err := migrate.Status(ctx, db)
+		if err != nil {
+			return err
+		}
+		if applied != latest {
+			return fmt.Errorf("schema version %d, want %d", applied, latest)
+		}
+		return nil
+	}
+}
+
+func signingKeysCheck(jwtSvc auth.JWTService) func(context.Context) error {
+	return func(ctx context.Context) error {
+		tokenS, err := jwtSvc.CreateToken(uuid.UUID{}, time.Minute)
+		if err != nil {
+			return err
+		}
+		_, err = jwtSvc.Verify(tokenS)
+		return err
+	}
+}
+
+// loadConfig loads the configuration and prints the usage for --help.
+func loadConfig(args []string) (config.Config, error) {
+	cfg, err := config.Load(args)
+	if errors.Is(err, conf.ErrHelp) {
+		usage, uErr := conf.Usage(&config.Config{}, config.Prefix)
+		if uErr != nil {
+			return config.Config{}, uErr
+		}
+		fmt.Printf("Usage: notes-api [config print] [flags]\n\n%s", usage)
+	}
+	return cfg, err
+}
+
+// configCmd implements "notes-api config print", which prints the effective
+// configuration with secrets redacted.
+func configCmd(args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return errors.New("usage: notes-api config print [flags]")
+	}
+
+	cfg, err := loadConfig(args[1:])
+	if err != nil {
+		if errors.Is(err, conf.ErrHelp) {
+			return nil
+		}
+		return fmt.Errorf("config: %w", err)
+	}
+
+	fmt.Print(cfg.String())
+	return nil
+}
@@ -0,0 +1,26 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/domain/core/audit"
+)
+
+// AuditVerify checks the hash chain of the audit trail and prints its last
+// entry. Keep the printed hash elsewhere: entries removed from the end of
+// the trail only show when it is compared with such a copy.
+func AuditVerify(ctx context.Context, w io.Writer, auditSvc audit.Service) error {
+	head, err := auditSvc.Verify(ctx)
+	if err != nil {
+		return fmt.Errorf("audit verify: %w", err)
+	}
+	if head.Seq == 0 {
+		fmt.Fprintln(w, "audit trail is empty")
+		return nil
+	}
+	fmt.Fprintf(w, "audit trail intact: %d entries, last %s at %s\n", head.Seq, head.Hash, head.CreatedAt.Format(time.RFC3339))
+	return nil
+}
@@ -0,0 +1,46 @@
+// Package commands implements the notes-admin commands. They operate
+// directly on the domain services, without going through the API.
+package commands
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/Keisn1/note-taking-app/domain/core/audit"
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	"github.com/Keisn1/note-taking-app/foundation/common"
+	"github.com/google/uuid"
+)
+
+// generatedPasswordBytes yields passwords of 24 characters.
+const generatedPasswordBytes = 18
+
+// auditUserAgent marks the entries of notes-admin in the audit trail, their
+// actor is anonymous.
+const auditUserAgent = "notes-admin"
+
+// lookupUser finds a user by ID or by email address.
+func lookupUser(ctx context.Context, userSvc user.Service, idOrEmail string) (user.User, error) {
+	if id, err := uuid.Parse(idOrEmail); err == nil {
+		return userSvc.QueryByID(ctx, id)
+	}
+	return userSvc.QueryByEmail(ctx, idOrEmail)
+}
+
+// record adds an action on the user to the audit trail.
+func record(ctx context.Context, auditSvc audit.Service, action string, userID uuid.UUID) error {
+	ctx = audit.WithRequest(ctx, audit.Request{UserAgent: auditUserAgent})
+	if _, err := auditSvc.Record(ctx, audit.Entry{Action: action, Target: audit.UserTarget(userID)}); err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+	return nil
+}
+
+func generatePassword() string {
+	return base64.RawURLEncoding.EncodeToString(common.MustGenerateRandomKey(generatedPasswordBytes))
+}
+
+func userLabel(u user.User) string {
+	return fmt.Sprintf("%s <%s>", u.ID, u.Email.String().Address)
+}
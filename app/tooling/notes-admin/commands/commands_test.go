@@ -0,0 +1,161 @@
+package commands_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/app/tooling/notes-admin/commands"
+	"github.com/Keisn1/note-taking-app/domain/core/audit"
+	auditmemory "github.com/Keisn1/note-taking-app/domain/core/audit/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	notememory "github.com/Keisn1/note-taking-app/domain/core/note/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	usermemory "github.com/Keisn1/note-taking-app/domain/core/user/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/web/auth"
+	"github.com/Keisn1/note-taking-app/foundation/common"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var rob = user.User{ID: uuid.UUID{1}, Name: user.NewName("rob"), Email: user.NewEmail("rob@example.com")}
+
+func newUserSvc() user.Service {
+	return user.NewSvc(usermemory.NewRepo([]user.User{rob}))
+}
+
+func newAuditSvc() audit.Svc {
+	return audit.NewSvc(auditmemory.NewRepo())
+}
+
+func TestUserCreate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Create a user with a generated password", func(t *testing.T) {
+		userSvc := newUserSvc()
+		var out bytes.Buffer
+
+		err := commands.UserCreate(ctx, &out, userSvc, newAuditSvc(), "anna", "anna@example.com", "")
+		require.NoError(t, err)
+
+		u, err := userSvc.QueryByEmail(ctx, "anna@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, "anna", u.Name.String())
+
+		_, password, found := strings.Cut(out.String(), "password: ")
+		require.True(t, found)
+		password = strings.TrimSpace(password)
+		assert.NoError(t, bcrypt.CompareHashAndPassword(u.PasswordHash, []byte(password)))
+	})
+
+	t.Run("Email must be unique", func(t *testing.T) {
+		err := commands.UserCreate(ctx, &bytes.Buffer{}, newUserSvc(), newAuditSvc(), "robbie", "rob@example.com", "password")
+		assert.ErrorIs(t, err, user.ErrEmailTaken)
+	})
+
+	t.Run("Input is validated", func(t *testing.T) {
+		err := commands.UserCreate(ctx, &bytes.Buffer{}, newUserSvc(), newAuditSvc(), "", "no-email", "short")
+		assert.ErrorContains(t, err, "user create: name: is required")
+		assert.ErrorContains(t, err, "email: must be a valid email address")
+		assert.ErrorContains(t, err, "password: must be at least 8 bytes")
+	})
+}
+
+func TestUserSetDisabled(t *testing.T) {
+	ctx := context.Background()
+	userSvc, auditSvc := newUserSvc(), newAuditSvc()
+	var out bytes.Buffer
+
+	require.NoError(t, commands.UserSetDisabled(ctx, &out, userSvc, auditSvc, "rob@example.com", true))
+	assert.Equal(t, "disabled user 01000000-0000-0000-0000-000000000000 <rob@example.com>\n", out.String())
+
+	u, err := userSvc.QueryByID(ctx, rob.ID)
+	require.NoError(t, err)
+	assert.True(t, u.Disabled)
+
+	require.NoError(t, commands.UserSetDisabled(ctx, &out, userSvc, auditSvc, rob.ID.String(), false))
+	u, err = userSvc.QueryByID(ctx, rob.ID)
+	require.NoError(t, err)
+	assert.False(t, u.Disabled)
+
+	err = commands.UserSetDisabled(ctx, &out, userSvc, auditSvc, "nobody@example.com", true)
+	assert.ErrorIs(t, err, user.ErrUserNotFound)
+
+	p, err := auditSvc.Query(ctx, audit.Filter{Target: audit.UserTarget(rob.ID), Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, p.Entries, 2)
+	assert.Equal(t, audit.ActionUserEnabled, p.Entries[0].Action)
+	assert.Equal(t, audit.ActionUserDisabled, p.Entries[1].Action)
+	assert.Equal(t, uuid.Nil, p.Entries[1].Actor)
+	assert.Equal(t, "notes-admin", p.Entries[1].UserAgent)
+}
+
+func TestUserResetPassword(t *testing.T) {
+	ctx := context.Background()
+	userSvc := newUserSvc()
+
+	require.NoError(t, commands.UserResetPassword(ctx, &bytes.Buffer{}, userSvc, newAuditSvc(), "rob@example.com", "new password"))
+
+	u, err := userSvc.QueryByID(ctx, rob.ID)
+	require.NoError(t, err)
+	assert.NoError(t, bcrypt.CompareHashAndPassword(u.PasswordHash, []byte("new password")))
+
+	err = commands.UserResetPassword(ctx, &bytes.Buffer{}, userSvc, newAuditSvc(), "rob@example.com", "short")
+	assert.EqualError(t, err, "user reset password: password must be between 8 and 72 bytes")
+}
+
+func TestNotesList(t *testing.T) {
+	ctx := context.Background()
+	userSvc := newUserSvc()
+	notes := []note.Note{
+		{ID: uuid.UUID{2}, Title: note.NewTitle("groceries"), Content: note.NewContent("milk"), UserID: rob.ID},
+		{ID: uuid.UUID{3}, Title: note.NewTitle("other"), Content: note.NewContent(""), UserID: uuid.UUID{9}},
+	}
+	noteSvc := note.NewNotesService(notememory.MustNewRepo(notes), userSvc)
+
+	var out bytes.Buffer
+	require.NoError(t, commands.NotesList(ctx, &out, userSvc, noteSvc, "rob@example.com"))
+
+	assert.Contains(t, out.String(), "ID")
+	assert.Contains(t, out.String(), "02000000-0000-0000-0000-000000000000  groceries")
+	assert.NotContains(t, out.String(), "other")
+}
+
+func TestToken(t *testing.T) {
+	ctx := context.Background()
+	userSvc, auditSvc := newUserSvc(), newAuditSvc()
+	jwtSvc := auth.MustNewJWTService(common.MustGenerateRandomKey(32))
+
+	var out bytes.Buffer
+	require.NoError(t, commands.Token(ctx, &out, userSvc, auditSvc, jwtSvc, "rob@example.com", time.Minute))
+
+	claims, err := jwtSvc.Verify(strings.TrimSpace(out.String()))
+	require.NoError(t, err)
+	assert.Equal(t, rob.ID.String(), claims.Subject)
+
+	_, err = userSvc.SetDisabled(ctx, rob.ID, true)
+	require.NoError(t, err)
+	err = commands.Token(ctx, &out, userSvc, auditSvc, jwtSvc, rob.ID.String(), time.Minute)
+	assert.EqualError(t, err, "token: user is disabled")
+}
+
+func TestAuditVerify(t *testing.T) {
+	ctx := context.Background()
+	auditSvc := newAuditSvc()
+
+	var out bytes.Buffer
+	require.NoError(t, commands.AuditVerify(ctx, &out, auditSvc))
+	assert.Equal(t, "audit trail is empty\n", out.String())
+
+	require.NoError(t, commands.UserSetDisabled(ctx, &bytes.Buffer{}, newUserSvc(), auditSvc, "rob@example.com", true))
+	e, err := auditSvc.Verify(ctx)
+	require.NoError(t, err)
+
+	out.Reset()
+	require.NoError(t, commands.AuditVerify(ctx, &out, auditSvc))
+	assert.Contains(t, out.String(), "audit trail intact: 1 entries, last "+e.Hash)
+}
@@ -0,0 +1,29 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+
+	"github.com/Keisn1/note-taking-app/domain/data/migrate"
+)
+
+// Migrate applies all pending migrations.
+func Migrate(ctx context.Context, w io.Writer, db *sql.DB) error {
+	if err := migrate.Migrate(ctx, db); err != nil {
+		return err
+	}
+	return MigrateStatus(ctx, w, db)
+}
+
+// MigrateStatus prints the applied and the latest schema version.
+func MigrateStatus(ctx context.Context, w io.Writer, db *sql.DB) error {
+	applied, latest, err := migrate.Status(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "schema version %d, latest %d\n", applied, latest)
+	return nil
+}
@@ -0,0 +1,31 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+)
+
+// NotesList prints the notes of the user identified by ID or email.
+func NotesList(ctx context.Context, w io.Writer, userSvc user.Service, noteSvc note.Service, idOrEmail string) error {
+	u, err := lookupUser(ctx, userSvc, idOrEmail)
+	if err != nil {
+		return fmt.Errorf("notes list: %w", err)
+	}
+
+	notes, err := noteSvc.GetNotesByUserID(ctx, u.ID)
+	if err != nil {
+		return fmt.Errorf("notes list: %w", err)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTITLE")
+	for _, n := range notes {
+		fmt.Fprintf(tw, "%s\t%s\n", n.ID, n.Title.String())
+	}
+	return tw.Flush()
+}
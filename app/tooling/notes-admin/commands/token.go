@@ -0,0 +1,36 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/domain/core/audit"
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	"github.com/Keisn1/note-taking-app/domain/web/auth"
+)
+
+// Token mints a token for the user identified by ID or email, e.g. to debug
+// requests on behalf of the user.
+func Token(ctx context.Context, w io.Writer, userSvc user.Service, auditSvc audit.Service, jwtSvc auth.JWTService, idOrEmail string, ttl time.Duration) error {
+	u, err := lookupUser(ctx, userSvc, idOrEmail)
+	if err != nil {
+		return fmt.Errorf("token: %w", err)
+	}
+	if u.Disabled {
+		return errors.New("token: user is disabled")
+	}
+
+	tokenS, err := jwtSvc.CreateToken(u.ID, ttl)
+	if err != nil {
+		return fmt.Errorf("token: %w", err)
+	}
+
+	fmt.Fprintln(w, tokenS)
+	if err := record(ctx, auditSvc, audit.ActionTokenIssued, u.ID); err != nil {
+		return fmt.Errorf("token: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/Keisn1/note-taking-app/app/api"
+	"github.com/Keisn1/note-taking-app/domain/core/audit"
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+)
+
+// UserCreate creates a user. A random password is generated and printed if
+// password is empty.
+func UserCreate(ctx context.Context, w io.Writer, userSvc user.Service, auditSvc audit.Service, name, email, password string) error {
+	generated := password == ""
+	if generated {
+		password = generatePassword()
+	}
+
+	up := api.UserPost{Name: name, Email: email, Password: password}
+	if err := up.Validate(); err != nil {
+		return fmt.Errorf("user create: %w", err)
+	}
+
+	addr, err := user.ParseEmail(email)
+	if err != nil {
+		return fmt.Errorf("user create: %w", err)
+	}
+
+	u, err := userSvc.Create(ctx, user.UpdateUser{
+		Name:     user.NewName(name),
+		Email:    user.NewEmail(addr.String().Address),
+		Password: user.NewPassword(password),
+	})
+	if err != nil {
+		return fmt.Errorf("user create: %w", err)
+	}
+
+	fmt.Fprintf(w, "created user %s\n", userLabel(u))
+	if generated {
+		fmt.Fprintf(w, "password: %s\n", password)
+	}
+	if err := record(ctx, auditSvc, audit.ActionUserCreated, u.ID); err != nil {
+		return fmt.Errorf("user create: %w", err)
+	}
+	return nil
+}
+
+// UserSetDisabled disables or re-enables the user identified by ID or email.
+func UserSetDisabled(ctx context.Context, w io.Writer, userSvc user.Service, auditSvc audit.Service, idOrEmail string, disabled bool) error {
+	u, err := lookupUser(ctx, userSvc, idOrEmail)
+	if err != nil {
+		return fmt.Errorf("user set disabled: %w", err)
+	}
+
+	u, err = userSvc.SetDisabled(ctx, u.ID, disabled)
+	if err != nil {
+		return fmt.Errorf("user set disabled: %w", err)
+	}
+
+	state, action := "enabled", audit.ActionUserEnabled
+	if u.Disabled {
+		state, action = "disabled", audit.ActionUserDisabled
+	}
+	fmt.Fprintf(w, "%s user %s\n", state, userLabel(u))
+	if err := record(ctx, auditSvc, action, u.ID); err != nil {
+		return fmt.Errorf("user set disabled: %w", err)
+	}
+	return nil
+}
+
+// UserResetPassword sets a new password for the user identified by ID or
+// email. A random password is generated and printed if password is empty.
+func UserResetPassword(ctx context.Context, w io.Writer, userSvc user.Service, auditSvc audit.Service, idOrEmail, password string) error {
+	generated := password == ""
+	if generated {
+		password = generatePassword()
+	}
+
+	if len(password) < user.MinPasswordLength || len(password) > user.MaxPasswordLength {
+		return fmt.Errorf("user reset password: password must be between %d and %d bytes",
+			user.MinPasswordLength, user.MaxPasswordLength)
+	}
+
+	u, err := lookupUser(ctx, userSvc, idOrEmail)
+	if err != nil {
+		return fmt.Errorf("user reset password: %w", err)
+	}
+
+	u, err = userSvc.Update(ctx, u, user.UpdateUser{Password: user.NewPassword(password)})
+	if err != nil {
+		return fmt.Errorf("user reset password: %w", err)
+	}
+
+	fmt.Fprintf(w, "reset password of user %s\n", userLabel(u))
+	if generated {
+		fmt.Fprintf(w, "password: %s\n", password)
+	}
+	if err := record(ctx, auditSvc, audit.ActionPasswordReset, u.ID); err != nil {
+		return fmt.Errorf("user reset password: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+// notes-admin manages users and data directly on the configured database.
+// It reads the same configuration as notes-api from NOTES_* environment
+// variables and the NOTES_CONFIG file.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/app/config"
+	"github.com/Keisn1/note-taking-app/app/tooling/notes-admin/commands"
+	"github.com/Keisn1/note-taking-app/domain/core/audit"
+	"github.com/Keisn1/note-taking-app/domain/core/audit/repositories/auditdb"
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/Keisn1/note-taking-app/domain/core/note/repositories/notedb"
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	"github.com/Keisn1/note-taking-app/domain/core/user/repositories/userdb"
+	"github.com/Keisn1/note-taking-app/domain/web/auth"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+const usage = `Usage: notes-admin <command> [flags] [arguments]
+
+Commands:
+  users create --name NAME --email EMAIL [--password PASSWORD]
+  users disable USER
+  users enable USER
+  users reset-password [--password PASSWORD] USER
+  notes list USER
+  migrate [status]
+  token [--ttl DURATION] USER
+  audit verify
+  config print
+
+USER is a user ID or email address. Passwords are generated and printed
+if not given. The configuration is read from NOTES_* environment variables
+and the NOTES_CONFIG file, see notes-api --help. Changes of users and
+issued tokens are recorded in the audit trail.
+`
+
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	err := run(context.Background(), os.Args[1:])
+	switch {
+	case errors.Is(err, errUsage), errors.Is(err, flag.ErrHelp):
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	cfg, err := config.Load(nil)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+
+	cmd, args := args[0], args[1:]
+	if cmd == "config" {
+		if len(args) != 1 || args[0] != "print" {
+			return errUsage
+		}
+		fmt.Print(cfg.String())
+		return nil
+	}
+
+	db, err := sql.Open("pgx", cfg.DB.DSN)
+	if err != nil {
+		return fmt.Errorf("open db: %w", err)
+	}
+	defer db.Close()
+
+	userSvc := user.NewSvc(userdb.NewUserRepo(db))
+	noteSvc := note.NewNotesService(notedb.NewNotesRepo(db), userSvc)
+	auditSvc := audit.NewSvc(auditdb.NewAuditRepo(db))
+
+	switch cmd {
+	case "users":
+		return usersCmd(ctx, userSvc, auditSvc, args)
+
+	case "notes":
+		if len(args) != 2 || args[0] != "list" {
+			return errUsage
+		}
+		return commands.NotesList(ctx, os.Stdout, userSvc, noteSvc, args[1])
+
+	case "migrate":
+		switch {
+		case len(args) == 0:
+			return commands.Migrate(ctx, os.Stdout, db)
+		case len(args) == 1 && args[0] == "status":
+			return commands.MigrateStatus(ctx, os.Stdout, db)
+		}
+		return errUsage
+
+	case "token":
+		fs := newFlagSet("token")
+		ttl := fs.Duration("ttl", time.Hour, "lifetime of the token")
+		if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+			return errUsage
+		}
+
+		jwtSvc, err := jwtService(cfg)
+		if err != nil {
+			return err
+		}
+		return commands.Token(ctx, os.Stdout, userSvc, auditSvc, jwtSvc, fs.Arg(0), *ttl)
+
+	case "audit":
+		if len(args) != 1 || args[0] != "verify" {
+			return errUsage
+		}
+		return commands.AuditVerify(ctx, os.Stdout, auditSvc)
+	}
+
+	return errUsage
+}
+
+func usersCmd(ctx context.Context, userSvc user.Service, auditSvc audit.Service, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	sub, args := args[0], args[1:]
+	fs := newFlagSet("users " + sub)
+	password := fs.String("password", "", "password, generated if empty")
+
+	switch sub {
+	case "create":
+		name := fs.String("name", "", "name of the user")
+		email := fs.String("email", "", "email address of the user")
+		if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+			return errUsage
+		}
+		return commands.UserCreate(ctx, os.Stdout, userSvc, auditSvc, *name, *email, *password)
+
+	case "disable", "enable":
+		if len(args) != 1 {
+			return errUsage
+		}
+		return commands.UserSetDisabled(ctx, os.Stdout, userSvc, auditSvc, args[0], sub == "disable")
+
+	case "reset-password":
+		if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+			return errUsage
+		}
+		return commands.UserResetPassword(ctx, os.Stdout, userSvc, auditSvc, fs.Arg(0), *password)
+	}
+
+	return errUsage
+}
+
+// newFlagSet returns a flag set that reports parse errors without its own
+// usage, the command usage is printed instead.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {}
+	return fs
+}
+
+// jwtService signs with the configured key. A random key would yield tokens
+// the API doesn't accept, so a key is required.
+func jwtService(cfg config.Config) (auth.JWTService, error) {
+	key, _, err := cfg.SigningKeys()
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, errors.New("token: auth.signing_key is not configured")
+	}
+	return auth.NewJWTService(key)
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/Keisn1/note-taking-app/app/api"
+	"github.com/Keisn1/note-taking-app/app/client"
+	"github.com/google/uuid"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputText  = "text"
+)
+
+func (c *cli) login(ctx context.Context, args []string) error {
+	fs := newFlagSet("login")
+	server := fs.String("server", "", "URL of the notes API")
+	email := fs.String("email", "", "email address")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		return errUsage
+	}
+
+	// Keep server and email of the previous login as defaults.
+	prev, _ := loadCredentials(c.configPath)
+	if *server == "" {
+		*server = prev.Server
+	}
+	if *server == "" {
+		*server = defaultServer
+	}
+	if *email == "" && prev.Email != "" {
+		*email = prev.Email
+	}
+
+	var err error
+	if *email == "" {
+		if *email, err = c.prompt("Email: "); err != nil {
+			return fmt.Errorf("login: %w", err)
+		}
+	}
+
+	password, err := c.readPassword()
+	if err != nil {
+		return fmt.Errorf("login: %w", err)
+	}
+
+	tr, err := client.New(*server, client.WithHTTPClient(c.httpClient)).Login(ctx, *email, password)
+	if err != nil {
+		return err
+	}
+
+	creds := credentials{Server: *server, Email: *email, Token: tr.Token, ExpiresAt: tr.ExpiresAt}
+	if err := saveCredentials(c.configPath, creds); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stdout, "logged in as %s\n", *email)
+	return nil
+}
+
+func (c *cli) newNote(ctx context.Context, args []string) error {
+	fs := newFlagSet("new")
+	title := fs.String("title", "", "title of the note")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		return errUsage
+	}
+
+	cl, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	newTitle, content, path, err := c.editNote(*title, "")
+	if err != nil {
+		return err
+	}
+
+	n, err := cl.CreateNote(ctx, api.NotePost{Title: newTitle, Content: content})
+	if err != nil {
+		return fmt.Errorf("new: %w (your note is saved in %s)", err, path)
+	}
+	os.Remove(path)
+
+	fmt.Fprintln(c.stdout, n.ID)
+	return nil
+}
+
+func (c *cli) list(ctx context.Context, args []string) error {
+	fs := newFlagSet("ls")
+	title := fs.String("title", "", "only notes whose title contains the text")
+	output := fs.String("output", outputTable, "table or json")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		return errUsage
+	}
+
+	return c.printList(ctx, client.NoteFilter{Title: *title}, *output)
+}
+
+func (c *cli) search(ctx context.Context, args []string) error {
+	fs := newFlagSet("search")
+	output := fs.String("output", outputTable, "table or json")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return errUsage
+	}
+
+	return c.printList(ctx, client.NoteFilter{Query: fs.Arg(0)}, *output)
+}
+
+func (c *cli) printList(ctx context.Context, filter client.NoteFilter, output string) error {
+	if output != outputTable && output != outputJSON {
+		return fmt.Errorf("unknown output format %q", output)
+	}
+
+	cl, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	notes, err := cl.AllNotes(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	if output == outputJSON {
+		return c.printJSON(notes)
+	}
+
+	tw := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTITLE")
+	for _, n := range notes {
+		fmt.Fprintf(tw, "%s\t%s\n", n.ID, n.Title)
+	}
+	return tw.Flush()
+}
+
+func (c *cli) cat(ctx context.Context, args []string) error {
+	fs := newFlagSet("cat")
+	output := fs.String("output", outputText, "text or json")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return errUsage
+	}
+	if *output != outputText && *output != outputJSON {
+		return fmt.Errorf("unknown output format %q", *output)
+	}
+
+	id, err := parseID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	cl, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	n, err := cl.GetNote(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if *output == outputJSON {
+		return c.printJSON(n.NoteResponse)
+	}
+	fmt.Fprint(c.stdout, formatNote(n.Title, n.Content))
+	return nil
+}
+
+// edit round-trips the note through the editor. The update is rejected if
+// the note changed on the server in the meantime, the edited version is kept
+// in a file then.
+func (c *cli) edit(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	id, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+
+	cl, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	n, err := cl.GetNote(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	title, content, path, err := c.editNote(n.Title, n.Content)
+	if err != nil {
+		return err
+	}
+
+	if title == n.Title && content == n.Content {
+		os.Remove(path)
+		fmt.Fprintln(c.stdout, "no changes")
+		return nil
+	}
+
+	_, err = cl.UpdateNote(ctx, id, api.NotePost{Title: title, Content: content}, n.ETag)
+	if err != nil {
+		if errors.Is(err, client.ErrPreconditionFailed) {
+			return fmt.Errorf("edit: the note was changed on the server since you opened it, your version is saved in %s", path)
+		}
+		return fmt.Errorf("edit: %w (your version is saved in %s)", err, path)
+	}
+	os.Remove(path)
+
+	fmt.Fprintf(c.stdout, "updated %s\n", id)
+	return nil
+}
+
+func (c *cli) remove(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	cl, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	for _, arg := range args {
+		id, err := parseID(arg)
+		if err != nil {
+			return err
+		}
+		if err := cl.DeleteNote(ctx, id); err != nil {
+			return fmt.Errorf("rm %s: %w", id, err)
+		}
+		fmt.Fprintf(c.stdout, "deleted %s\n", id)
+	}
+	return nil
+}
+
+func parseID(s string) (uuid.UUID, error) {
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid note id %q", s)
+	}
+	return id, nil
+}
+
+func (c *cli) printJSON(v any) error {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var errNotLoggedIn = errors.New("not logged in, run: notes login")
+
+// credentials are stored in the config file after a successful login.
+type credentials struct {
+	Server    string    `json:"server"`
+	Email     string    `json:"email"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// defaultConfigPath is $NOTES_CLI_CONFIG or notes/credentials.json in the
+// user config directory.
+func defaultConfigPath() (string, error) {
+	if path := os.Getenv("NOTES_CLI_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "notes", "credentials.json"), nil
+}
+
+func loadCredentials(path string) (credentials, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return credentials{}, errNotLoggedIn
+		}
+		return credentials{}, fmt.Errorf("load credentials: %w", err)
+	}
+
+	var c credentials
+	if err := json.Unmarshal(data, &c); err != nil {
+		return credentials{}, fmt.Errorf("load credentials %s: %w", path, err)
+	}
+	if c.Token == "" {
+		return credentials{}, errNotLoggedIn
+	}
+	if !c.ExpiresAt.IsZero() && time.Now().After(c.ExpiresAt) {
+		return credentials{}, errors.New("session expired, run: notes login")
+	}
+	return c, nil
+}
+
+// saveCredentials writes the file readable only by the user, it contains
+// the token.
+func saveCredentials(path string, c credentials) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("save credentials: %w", err)
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("save credentials: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("save credentials: %w", err)
+	}
+	return nil
+}
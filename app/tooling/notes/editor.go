@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+var errEmptyTitle = errors.New("aborted: the note has no title")
+
+// runEditor opens path in $VISUAL, $EDITOR or vi.
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	args := append(strings.Fields(editor), path)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor: %w", err)
+	}
+	return nil
+}
+
+// formatNote renders a note for editing: the title on the first line, an
+// empty line and the content.
+func formatNote(title, content string) string {
+	return title + "\n\n" + strings.TrimRight(content, "\n") + "\n"
+}
+
+// parseNote is the inverse of formatNote. A leading "# " of the title is
+// dropped, trailing newlines of the content too.
+func parseNote(s string) (title, content string, err error) {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	title, content, _ = strings.Cut(s, "\n")
+
+	title = strings.TrimSpace(strings.TrimPrefix(title, "# "))
+	if title == "" {
+		return "", "", errEmptyTitle
+	}
+
+	content = strings.TrimPrefix(content, "\n")
+	content = strings.TrimRight(content, "\n")
+	return title, content, nil
+}
+
+// editNote lets the user edit the note in a temporary file. On success the
+// caller owns the file at path, so that it can be kept if saving fails.
+func (c *cli) editNote(title, content string) (newTitle, newContent, path string, err error) {
+	f, err := os.CreateTemp("", "note-*.md")
+	if err != nil {
+		return "", "", "", err
+	}
+	path = f.Name()
+
+	_, err = f.WriteString(formatNote(title, content))
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", "", "", err
+	}
+
+	if err := c.editor(path); err != nil {
+		os.Remove(path)
+		return "", "", "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		os.Remove(path)
+		return "", "", "", err
+	}
+
+	newTitle, newContent, err = parseNote(string(data))
+	if err != nil {
+		os.Remove(path)
+		return "", "", "", err
+	}
+	return newTitle, newContent, path, nil
+}
@@ -0,0 +1,154 @@
+// notes is a command line client of the notes API.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/app/client"
+	"golang.org/x/term"
+)
+
+const usage = `Usage: notes <command> [flags] [arguments]
+
+Commands:
+  login [--server URL] [--email EMAIL]   log in and store the token
+  new [--title TITLE]                    write a new note in $EDITOR
+  ls [--title TEXT] [--output FORMAT]    list notes, optionally filtered by title
+  cat [--output FORMAT] ID               print a note
+  edit ID                                edit a note in $EDITOR
+  rm ID...                               delete notes
+  search [--output FORMAT] QUERY         find notes by title or content
+
+FORMAT is table (the default) or json; cat prints text by default.
+The token is stored in $NOTES_CLI_CONFIG, by default notes/credentials.json
+in the user config directory.
+`
+
+const defaultServer = "http://localhost:3000"
+
+var errUsage = errors.New("invalid usage")
+
+type cli struct {
+	stdin      io.Reader
+	stdout     io.Writer
+	stderr     io.Writer
+	configPath string
+	httpClient *http.Client
+
+	// editor opens the file for editing and returns when the user is done.
+	editor func(path string) error
+	// readPassword prompts for the password without echoing it.
+	readPassword func() (string, error)
+}
+
+func main() {
+	configPath, err := defaultConfigPath()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	c := &cli{
+		stdin:        os.Stdin,
+		stdout:       os.Stdout,
+		stderr:       os.Stderr,
+		configPath:   configPath,
+		httpClient:   &http.Client{Timeout: 30 * time.Second},
+		editor:       runEditor,
+		readPassword: readTerminalPassword,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err = c.run(ctx, os.Args[1:])
+	switch {
+	case errors.Is(err, errUsage), errors.Is(err, flag.ErrHelp):
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "notes:", err)
+		os.Exit(1)
+	}
+}
+
+func (c *cli) run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "login":
+		return c.login(ctx, args)
+	case "new":
+		return c.newNote(ctx, args)
+	case "ls":
+		return c.list(ctx, args)
+	case "cat":
+		return c.cat(ctx, args)
+	case "edit":
+		return c.edit(ctx, args)
+	case "rm":
+		return c.remove(ctx, args)
+	case "search":
+		return c.search(ctx, args)
+	}
+	return errUsage
+}
+
+// client returns an API client authenticated with the stored token.
+func (c *cli) client() (*client.Client, error) {
+	creds, err := loadCredentials(c.configPath)
+	if err != nil {
+		return nil, err
+	}
+	return client.New(creds.Server, client.WithHTTPClient(c.httpClient), client.WithToken(creds.Token, creds.ExpiresAt)), nil
+}
+
+// prompt reads a line from stdin.
+func (c *cli) prompt(label string) (string, error) {
+	fmt.Fprint(c.stderr, label)
+	line, err := bufio.NewReader(c.stdin).ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func readTerminalPassword() (string, error) {
+	fmt.Fprint(os.Stderr, "Password: ")
+	defer fmt.Fprintln(os.Stderr)
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", err
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	password, err := term.ReadPassword(int(os.Stdin.Fd()))
+	if err != nil {
+		return "", err
+	}
+	return string(password), nil
+}
+
+// newFlagSet returns a flag set that reports parse errors without its own
+// usage, the command usage is printed instead.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {}
+	return fs
+}
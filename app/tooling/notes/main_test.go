@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/app/api"
+	"github.com/Keisn1/note-taking-app/app/client"
+	"github.com/Keisn1/note-taking-app/app/handlers/notesgrp"
+	"github.com/Keisn1/note-taking-app/app/handlers/usersgrp"
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	notememory "github.com/Keisn1/note-taking-app/domain/core/note/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	usermemory "github.com/Keisn1/note-taking-app/domain/core/user/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/web/auth"
+	"github.com/Keisn1/note-taking-app/domain/web/mux"
+	"github.com/Keisn1/note-taking-app/foundation/common"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	userSvc := user.NewSvc(usermemory.NewRepo(nil))
+	_, err := userSvc.Create(context.Background(), user.UpdateUser{
+		Name: user.NewName("rob"), Email: user.NewEmail("rob@example.com"), Password: user.NewPassword("password"),
+	})
+	require.NoError(t, err)
+
+	cfg := mux.Config{
+		Auth:    auth.NewAuth(auth.MustNewJWTService(common.MustGenerateRandomKey(32))),
+		NoteSvc: note.NewNotesService(notememory.MustNewRepo(nil), userSvc),
+		UserSvc: userSvc,
+	}
+	routes := func(app *web.App, cfg mux.Config) {
+		notesgrp.Routes(app, cfg)
+		usersgrp.Routes(app, cfg)
+	}
+
+	srv := httptest.NewServer(mux.NewAPI(routes, cfg))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+type testCLI struct {
+	*cli
+	out *bytes.Buffer
+	// edits are applied by the editor in order, each replaces the file.
+	edits []string
+}
+
+func newTestCLI(t *testing.T) *testCLI {
+	tc := &testCLI{out: &bytes.Buffer{}}
+	tc.cli = &cli{
+		stdin:        strings.NewReader(""),
+		stdout:       tc.out,
+		stderr:       &bytes.Buffer{},
+		configPath:   filepath.Join(t.TempDir(), "notes", "credentials.json"),
+		httpClient:   http.DefaultClient,
+		readPassword: func() (string, error) { return "password", nil },
+		editor: func(path string) error {
+			require.NotEmpty(t, tc.edits, "unexpected editor call")
+			edit := tc.edits[0]
+			tc.edits = tc.edits[1:]
+			return os.WriteFile(path, []byte(edit), 0o600)
+		},
+	}
+	return tc
+}
+
+func (tc *testCLI) mustRun(t *testing.T, args ...string) string {
+	t.Helper()
+	tc.out.Reset()
+	require.NoError(t, tc.run(context.Background(), args))
+	return tc.out.String()
+}
+
+func TestCLI(t *testing.T) {
+	srv := newTestServer(t)
+	c := newTestCLI(t)
+
+	t.Run("commands require login", func(t *testing.T) {
+		err := c.run(context.Background(), []string{"ls"})
+		assert.ErrorIs(t, err, errNotLoggedIn)
+	})
+
+	t.Run("login stores the token", func(t *testing.T) {
+		out := c.mustRun(t, "login", "--server", srv.URL, "--email", "rob@example.com")
+		assert.Equal(t, "logged in as rob@example.com\n", out)
+
+		info, err := os.Stat(c.configPath)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+		creds, err := loadCredentials(c.configPath)
+		require.NoError(t, err)
+		assert.Equal(t, srv.URL, creds.Server)
+		assert.NotEmpty(t, creds.Token)
+	})
+
+	var groceriesID, ideasID string
+
+	t.Run("new creates notes from the editor", func(t *testing.T) {
+		c.edits = []string{"# Groceries\n\nmilk\neggs\n"}
+		groceriesID = strings.TrimSpace(c.mustRun(t, "new"))
+
+		c.edits = []string{"Ideas\n\nbuy more milk\n"}
+		ideasID = strings.TrimSpace(c.mustRun(t, "new", "--title", "ignored by the edit"))
+	})
+
+	t.Run("new aborts without title", func(t *testing.T) {
+		c.edits = []string{"\n\ncontent\n"}
+		err := c.run(context.Background(), []string{"new"})
+		assert.ErrorIs(t, err, errEmptyTitle)
+	})
+
+	t.Run("ls lists and filters notes", func(t *testing.T) {
+		out := c.mustRun(t, "ls")
+		assert.Contains(t, out, "ID")
+		assert.Contains(t, out, groceriesID+"  Groceries")
+		assert.Contains(t, out, ideasID+"  Ideas")
+
+		out = c.mustRun(t, "ls", "--title", "groc", "--output", "json")
+		var notes []api.NoteResponse
+		require.NoError(t, json.Unmarshal([]byte(out), &notes))
+		require.Len(t, notes, 1)
+		assert.Equal(t, "Groceries", notes[0].Title)
+		assert.Equal(t, "milk\neggs", notes[0].Content)
+	})
+
+	t.Run("search finds notes by content", func(t *testing.T) {
+		out := c.mustRun(t, "search", "more milk")
+		assert.Contains(t, out, "Ideas")
+		assert.NotContains(t, out, "Groceries")
+	})
+
+	t.Run("cat prints a note", func(t *testing.T) {
+		out := c.mustRun(t, "cat", groceriesID)
+		assert.Equal(t, "Groceries\n\nmilk\neggs\n", out)
+	})
+
+	t.Run("edit updates a note", func(t *testing.T) {
+		c.edits = []string{"Groceries\n\nmilk\neggs\nbread\n"}
+		out := c.mustRun(t, "edit", groceriesID)
+		assert.Equal(t, "updated "+groceriesID+"\n", out)
+
+		out = c.mustRun(t, "cat", groceriesID)
+		assert.Equal(t, "Groceries\n\nmilk\neggs\nbread\n", out)
+	})
+
+	t.Run("edit detects conflicts", func(t *testing.T) {
+		// While the note is open in the editor, it is changed elsewhere.
+		other := newTestCLI(t)
+		other.configPath = c.configPath
+		editor := c.editor
+		t.Cleanup(func() { c.editor = editor })
+		c.editor = func(path string) error {
+			other.edits = []string{"Groceries\n\nchanged elsewhere\n"}
+			other.mustRun(t, "edit", groceriesID)
+			return os.WriteFile(path, []byte("Groceries\n\nmy change\n"), 0o600)
+		}
+
+		err := c.run(context.Background(), []string{"edit", groceriesID})
+		require.ErrorContains(t, err, "the note was changed on the server since you opened it")
+
+		path := err.Error()[strings.LastIndex(err.Error(), " ")+1:]
+		kept, readErr := os.ReadFile(path)
+		require.NoError(t, readErr)
+		assert.Equal(t, "Groceries\n\nmy change\n", string(kept))
+		os.Remove(path)
+
+		out := c.mustRun(t, "cat", groceriesID)
+		assert.Equal(t, "Groceries\n\nchanged elsewhere\n", out)
+	})
+
+	t.Run("rm deletes notes", func(t *testing.T) {
+		out := c.mustRun(t, "rm", groceriesID, ideasID)
+		assert.Equal(t, "deleted "+groceriesID+"\ndeleted "+ideasID+"\n", out)
+
+		out = c.mustRun(t, "ls", "--output", "json")
+		assert.Equal(t, "[]\n", out)
+	})
+
+	t.Run("unknown note", func(t *testing.T) {
+		err := c.run(context.Background(), []string{"cat", groceriesID})
+		var apiErr *client.Error
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+	})
+}
+
+func TestLogin_InvalidCredentials(t *testing.T) {
+	srv := newTestServer(t)
+	c := newTestCLI(t)
+	c.readPassword = func() (string, error) { return "wrong password", nil }
+
+	err := c.run(context.Background(), []string{"login", "--server", srv.URL, "--email", "rob@example.com"})
+	assert.EqualError(t, err, "login: server answered 401: invalid credentials")
+
+	_, err = os.Stat(c.configPath)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestLoadCredentials_Expired(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	require.NoError(t, saveCredentials(path, credentials{Token: "token", ExpiresAt: time.Now().Add(-time.Minute)}))
+
+	_, err := loadCredentials(path)
+	assert.EqualError(t, err, "session expired, run: notes login")
+}
+
+func TestParseNote(t *testing.T) {
+	testCases := []struct {
+		name        string
+		in          string
+		wantTitle   string
+		wantContent string
+		wantErr     error
+	}{
+		{name: "title and content", in: "Title\n\nline 1\nline 2\n", wantTitle: "Title", wantContent: "line 1\nline 2"},
+		{name: "markdown heading", in: "# Title\n\ncontent", wantTitle: "Title", wantContent: "content"},
+		{name: "no empty line", in: "Title\ncontent\n", wantTitle: "Title", wantContent: "content"},
+		{name: "only title", in: "Title", wantTitle: "Title"},
+		{name: "windows line endings", in: "Title\r\n\r\ncontent\r\n", wantTitle: "Title", wantContent: "content"},
+		{name: "empty title", in: "\n\ncontent", wantErr: errEmptyTitle},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			title, content, err := parseNote(tc.in)
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.Equal(t, tc.wantTitle, title)
+			assert.Equal(t, tc.wantContent, content)
+		})
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		title, content, err := parseNote(formatNote("Title", "content\n\nmore"))
+		require.NoError(t, err)
+		assert.Equal(t, "Title", title)
+		assert.Equal(t, "content\n\nmore", content)
+	})
+}
@@ -0,0 +1,70 @@
+// Package attachment stores files attached to notes. The files are kept in a
+// blobstore.Store, their metadata in a Repo.
+package attachment
+
+import (
+	"errors"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultMaxSize = 25 << 20  // bytes per file
+	DefaultQuota   = 500 << 20 // bytes per user
+
+	maxFilenameLength = 255 // bytes
+)
+
+var (
+	ErrAttachmentNotFound = errors.New("the attachment was not found")
+	ErrEmpty              = errors.New("the file is empty")
+	ErrTooLarge           = errors.New("the file is too large")
+	ErrQuotaExceeded      = errors.New("the storage quota is exceeded")
+	ErrUnsupportedType    = errors.New("the file type is not supported")
+)
+
+// ContentTypes are the types of files that can be attached. The type is
+// detected from the content, the type claimed by the client is ignored.
+var ContentTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp", "application/pdf"}
+
+type Attachment struct {
+	ID          uuid.UUID
+	NoteID      uuid.UUID
+	UserID      uuid.UUID
+	Filename    string
+	ContentType string
+	Size        int64
+	CreatedAt   time.Time
+}
+
+// blobKey is the key of the content in the blob store.
+func (a Attachment) blobKey() string {
+	return "users/" + a.UserID.String() + "/" + a.ID.String()
+}
+
+// cleanFilename returns the base name of the file as sent by the client
+// without control characters.
+func cleanFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == unicode.ReplacementChar {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+
+	for len(name) > maxFilenameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+	return name
+}
@@ -0,0 +1,237 @@
+package attachment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/foundation/blobstore"
+	"github.com/Keisn1/note-taking-app/foundation/tracing"
+	"github.com/Keisn1/note-taking-app/foundation/transaction"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/Keisn1/note-taking-app/domain/core/attachment")
+
+type Service interface {
+	Create(ctx context.Context, noteID, userID uuid.UUID, filename string, r io.Reader) (Attachment, error)
+	QueryByID(ctx context.Context, id uuid.UUID) (Attachment, error)
+	QueryByNoteID(ctx context.Context, noteID uuid.UUID) ([]Attachment, error)
+	Open(ctx context.Context, a Attachment) (blobstore.Object, error)
+	Delete(ctx context.Context, a Attachment) error
+	DeleteByNoteID(ctx context.Context, noteID uuid.UUID) error
+	Usage(ctx context.Context, userID uuid.UUID) (int64, error)
+	Limits() Limits
+}
+
+// Limits restrict the size of the attachments. Zero values are replaced by
+// the defaults.
+type Limits struct {
+	MaxSize int64 // bytes per file
+	Quota   int64 // bytes per user
+}
+
+type AttachmentService struct {
+	repo   Repo
+	store  blobstore.Store
+	limits Limits
+}
+
+func NewSvc(repo Repo, store blobstore.Store, limits Limits) AttachmentService {
+	if limits.MaxSize <= 0 {
+		limits.MaxSize = DefaultMaxSize
+	}
+	if limits.Quota <= 0 {
+		limits.Quota = DefaultQuota
+	}
+	return AttachmentService{repo: repo, store: store, limits: limits}
+}
+
+// Create stores the content of r as an attachment of the note. The content
+// is spooled to a temporary file first, because its size is only known at
+// the end and the blob store needs it upfront. The usage is checked early to
+// bound the upload; the repository checks it again when inserting, as
+// concurrent uploads of the user may have taken the space meanwhile.
+func (as AttachmentService) Create(ctx context.Context, noteID, userID uuid.UUID, filename string, r io.Reader) (_ Attachment, err error) {
+	ctx, span := tracer.Start(ctx, "attachment.Create", trace.WithAttributes(attribute.String("note.id", noteID.String())))
+	defer tracing.End(span, &err)
+
+	usage, err := as.repo.UsageByUserID(ctx, userID)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("create: %w", err)
+	}
+	limit := min(as.limits.MaxSize, as.limits.Quota-usage)
+	if limit <= 0 {
+		return Attachment{}, ErrQuotaExceeded
+	}
+
+	f, err := os.CreateTemp("", "attachment-*")
+	if err != nil {
+		return Attachment{}, fmt.Errorf("create: %w", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	size, err := io.Copy(f, io.LimitReader(r, limit+1))
+	if err != nil {
+		return Attachment{}, fmt.Errorf("create: %w", err)
+	}
+	switch {
+	case size == 0:
+		return Attachment{}, ErrEmpty
+	case size > as.limits.MaxSize:
+		return Attachment{}, ErrTooLarge
+	case size > limit:
+		return Attachment{}, ErrQuotaExceeded
+	}
+
+	contentType, err := sniff(f)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("create: %w", err)
+	}
+	if !slices.Contains(ContentTypes, contentType) {
+		return Attachment{}, fmt.Errorf("%w: %s", ErrUnsupportedType, contentType)
+	}
+
+	a := Attachment{
+		ID:          uuid.New(),
+		NoteID:      noteID,
+		UserID:      userID,
+		Filename:    cleanFilename(filename),
+		ContentType: contentType,
+		Size:        size,
+		CreatedAt:   time.Now().UTC().Truncate(time.Microsecond),
+	}
+
+	if err := as.store.Put(ctx, a.blobKey(), f, size, contentType); err != nil {
+		return Attachment{}, fmt.Errorf("create: %w", err)
+	}
+	if err := as.repo.Create(ctx, a, as.limits.Quota); err != nil {
+		if errors.Is(err, ErrQuotaExceeded) {
+			err = ErrQuotaExceeded
+		} else {
+			err = fmt.Errorf("create: %w", err)
+		}
+		return Attachment{}, errors.Join(err, as.store.Delete(ctx, a.blobKey()))
+	}
+	return a, nil
+}
+
+// sniff detects the content type of the file from its first bytes and
+// rewinds it.
+func sniff(f *os.File) (string, error) {
+	buf := make([]byte, 512)
+	n, err := f.ReadAt(buf, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(buf[:n]))
+	return mediaType, nil
+}
+
+func (as AttachmentService) QueryByID(ctx context.Context, id uuid.UUID) (_ Attachment, err error) {
+	ctx, span := tracer.Start(ctx, "attachment.QueryByID", trace.WithAttributes(attribute.String("attachment.id", id.String())))
+	defer tracing.End(span, &err)
+
+	a, err := as.repo.QueryByID(ctx, id)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("queryByID: [%s]: %w", id, err)
+	}
+	return a, nil
+}
+
+func (as AttachmentService) QueryByNoteID(ctx context.Context, noteID uuid.UUID) (_ []Attachment, err error) {
+	ctx, span := tracer.Start(ctx, "attachment.QueryByNoteID", trace.WithAttributes(attribute.String("note.id", noteID.String())))
+	defer tracing.End(span, &err)
+
+	attachments, err := as.repo.QueryByNoteID(ctx, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("queryByNoteID: [%s]: %w", noteID, err)
+	}
+	return attachments, nil
+}
+
+// Open returns the content of the attachment. A missing blob is reported as
+// ErrAttachmentNotFound.
+func (as AttachmentService) Open(ctx context.Context, a Attachment) (blobstore.Object, error) {
+	obj, err := as.store.Open(ctx, a.blobKey())
+	if errors.Is(err, blobstore.ErrNotFound) {
+		return nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open: [%s]: %w", a.ID, err)
+	}
+	return obj, nil
+}
+
+// Delete deletes the content and then the metadata, so that a failure leaves
+// an attachment that can be deleted again rather than an orphaned blob.
+func (as AttachmentService) Delete(ctx context.Context, a Attachment) (err error) {
+	ctx, span := tracer.Start(ctx, "attachment.Delete", trace.WithAttributes(attribute.String("attachment.id", a.ID.String())))
+	defer tracing.End(span, &err)
+
+	if err := as.store.Delete(ctx, a.blobKey()); err != nil {
+		return fmt.Errorf("delete: [%s]: %w", a.ID, err)
+	}
+	if err := as.repo.Delete(ctx, a.ID); err != nil {
+		return fmt.Errorf("delete: [%s]: %w", a.ID, err)
+	}
+	return nil
+}
+
+// DeleteByNoteID deletes all attachments of the note. It is meant to run
+// before the note is deleted, see note.WithDeleteHook. The content is only
+// deleted once the transaction of ctx is committed, so that the attachments
+// stay downloadable if it is rolled back.
+func (as AttachmentService) DeleteByNoteID(ctx context.Context, noteID uuid.UUID) (err error) {
+	ctx, span := tracer.Start(ctx, "attachment.DeleteByNoteID", trace.WithAttributes(attribute.String("note.id", noteID.String())))
+	defer tracing.End(span, &err)
+
+	attachments, err := as.repo.QueryByNoteID(ctx, noteID)
+	if err != nil {
+		return fmt.Errorf("deleteByNoteID: [%s]: %w", noteID, err)
+	}
+	if err := as.repo.DeleteByNoteID(ctx, noteID); err != nil {
+		return fmt.Errorf("deleteByNoteID: [%s]: %w", noteID, err)
+	}
+	transaction.AfterCommit(ctx, func(ctx context.Context) { as.deleteContent(ctx, attachments) })
+	return nil
+}
+
+// deleteContent deletes the content of the deleted attachments. The content
+// that fails to be deleted stays in the store, the error is recorded in the
+// trace.
+func (as AttachmentService) deleteContent(ctx context.Context, attachments []Attachment) {
+	var err error
+	ctx, span := tracer.Start(context.WithoutCancel(ctx), "attachment.deleteContent")
+	defer tracing.End(span, &err)
+
+	for _, a := range attachments {
+		err = errors.Join(err, as.store.Delete(ctx, a.blobKey()))
+	}
+}
+
+func (as AttachmentService) Usage(ctx context.Context, userID uuid.UUID) (int64, error) {
+	usage, err := as.repo.UsageByUserID(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("usage: [%s]: %w", userID, err)
+	}
+	return usage, nil
+}
+
+func (as AttachmentService) Limits() Limits {
+	return as.limits
+}
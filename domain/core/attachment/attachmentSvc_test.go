@@ -0,0 +1,168 @@
+package attachment_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Keisn1/note-taking-app/domain/core/attachment"
+	"github.com/Keisn1/note-taking-app/domain/core/attachment/repositories/memory"
+	"github.com/Keisn1/note-taking-app/foundation/blobstore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+func png(size int) []byte {
+	return append(bytes.Clone(pngHeader), make([]byte, size-len(pngHeader))...)
+}
+
+func setup(t *testing.T, limits attachment.Limits) attachment.AttachmentService {
+	t.Helper()
+	store, err := blobstore.NewFS(t.TempDir())
+	require.NoError(t, err)
+	return attachment.NewSvc(memory.NewRepo(), store, limits)
+}
+
+func TestAttachmentService_Create(t *testing.T) {
+	ctx := context.Background()
+	noteID, userID := uuid.UUID{1}, uuid.UUID{2}
+
+	t.Run("Stores the content and detects its type", func(t *testing.T) {
+		as := setup(t, attachment.Limits{})
+
+		a, err := as.Create(ctx, noteID, userID, `C:\photos\cat.png`, bytes.NewReader(png(100)))
+		require.NoError(t, err)
+		assert.Equal(t, "cat.png", a.Filename)
+		assert.Equal(t, "image/png", a.ContentType)
+		assert.Equal(t, int64(100), a.Size)
+
+		got, err := as.QueryByID(ctx, a.ID)
+		require.NoError(t, err)
+		assert.Equal(t, a, got)
+
+		obj, err := as.Open(ctx, a)
+		require.NoError(t, err)
+		defer obj.Close()
+		content, err := io.ReadAll(obj)
+		require.NoError(t, err)
+		assert.Equal(t, png(100), content)
+	})
+
+	t.Run("Rejects invalid files", func(t *testing.T) {
+		as := setup(t, attachment.Limits{MaxSize: 100, Quota: 150})
+
+		_, err := as.Create(ctx, noteID, userID, "empty.png", strings.NewReader(""))
+		assert.ErrorIs(t, err, attachment.ErrEmpty)
+
+		_, err = as.Create(ctx, noteID, userID, "big.png", bytes.NewReader(png(101)))
+		assert.ErrorIs(t, err, attachment.ErrTooLarge)
+
+		_, err = as.Create(ctx, noteID, userID, "script.png", strings.NewReader("<html><script>alert(1)</script>"))
+		assert.ErrorIs(t, err, attachment.ErrUnsupportedType)
+	})
+
+	t.Run("Enforces the quota of the user", func(t *testing.T) {
+		as := setup(t, attachment.Limits{MaxSize: 100, Quota: 150})
+
+		_, err := as.Create(ctx, noteID, userID, "1.png", bytes.NewReader(png(100)))
+		require.NoError(t, err)
+		_, err = as.Create(ctx, noteID, userID, "2.png", bytes.NewReader(png(51)))
+		assert.ErrorIs(t, err, attachment.ErrQuotaExceeded)
+		_, err = as.Create(ctx, noteID, uuid.UUID{3}, "2.png", bytes.NewReader(png(51)))
+		assert.NoError(t, err)
+
+		usage, err := as.Usage(ctx, userID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(100), usage)
+	})
+
+	t.Run("Concurrent uploads can't overrun the quota", func(t *testing.T) {
+		const uploads = 5
+		dir := t.TempDir()
+		store, err := blobstore.NewFS(dir)
+		require.NoError(t, err)
+		// All uploads pass the early check before any of them is inserted.
+		var arrived sync.WaitGroup
+		arrived.Add(uploads)
+		repo := barrierRepo{Repo: memory.NewRepo(), arrived: &arrived}
+		as := attachment.NewSvc(repo, store, attachment.Limits{MaxSize: 100, Quota: 150})
+
+		errs := make(chan error, uploads)
+		for range uploads {
+			go func() {
+				_, err := as.Create(ctx, noteID, userID, "1.png", bytes.NewReader(png(100)))
+				errs <- err
+			}()
+		}
+		var created int
+		for range uploads {
+			if err := <-errs; err == nil {
+				created++
+			} else {
+				assert.EqualError(t, err, attachment.ErrQuotaExceeded.Error())
+			}
+		}
+		assert.Equal(t, 1, created)
+
+		usage, err := as.Usage(ctx, userID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(100), usage)
+
+		var blobs int
+		require.NoError(t, filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				blobs++
+			}
+			return err
+		}))
+		assert.Equal(t, 1, blobs, "the blobs of rejected uploads are deleted")
+	})
+}
+
+type barrierRepo struct {
+	attachment.Repo
+	arrived *sync.WaitGroup
+}
+
+func (r barrierRepo) Create(ctx context.Context, a attachment.Attachment, quota int64) error {
+	r.arrived.Done()
+	r.arrived.Wait()
+	return r.Repo.Create(ctx, a, quota)
+}
+
+func TestAttachmentService_Delete(t *testing.T) {
+	ctx := context.Background()
+	as := setup(t, attachment.Limits{})
+
+	a1, err := as.Create(ctx, uuid.UUID{1}, uuid.UUID{2}, "1.png", bytes.NewReader(png(10)))
+	require.NoError(t, err)
+	a2, err := as.Create(ctx, uuid.UUID{1}, uuid.UUID{2}, "2.png", bytes.NewReader(png(10)))
+	require.NoError(t, err)
+	a3, err := as.Create(ctx, uuid.UUID{9}, uuid.UUID{2}, "3.png", bytes.NewReader(png(10)))
+	require.NoError(t, err)
+
+	require.NoError(t, as.Delete(ctx, a1))
+	_, err = as.QueryByID(ctx, a1.ID)
+	assert.ErrorIs(t, err, attachment.ErrAttachmentNotFound)
+	_, err = as.Open(ctx, a1)
+	assert.ErrorIs(t, err, attachment.ErrAttachmentNotFound)
+
+	require.NoError(t, as.DeleteByNoteID(ctx, uuid.UUID{1}))
+	got, err := as.QueryByNoteID(ctx, uuid.UUID{1})
+	require.NoError(t, err)
+	assert.Empty(t, got)
+	_, err = as.Open(ctx, a2)
+	assert.ErrorIs(t, err, attachment.ErrAttachmentNotFound)
+
+	got, err = as.QueryByNoteID(ctx, uuid.UUID{9})
+	require.NoError(t, err)
+	assert.Equal(t, []attachment.Attachment{a3}, got)
+}
@@ -0,0 +1,178 @@
+package attachmentdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/domain/core/attachment"
+	"github.com/Keisn1/note-taking-app/foundation/tracing"
+	"github.com/Keisn1/note-taking-app/foundation/transaction"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/Keisn1/note-taking-app/domain/core/attachment/repositories/attachmentdb")
+
+type dbAttachment struct {
+	id          uuid.UUID
+	noteID      uuid.UUID
+	userID      uuid.UUID
+	filename    string
+	contentType string
+	size        int64
+	createdAt   time.Time
+}
+
+type AttachmentRepo struct {
+	db transaction.DB
+}
+
+func NewAttachmentRepo(db transaction.DB) AttachmentRepo {
+	return AttachmentRepo{db: db}
+}
+
+// Create inserts the attachment only if the usage of the user stays within
+// quota. The advisory lock serializes the creations per user, so that
+// concurrent uploads can't both pass the check.
+func (aR AttachmentRepo) Create(ctx context.Context, a attachment.Attachment, quota int64) (err error) {
+	lockUser := `SELECT pg_advisory_xact_lock(hashtextextended($1::text, 0))`
+	insertRow := `
+	INSERT INTO attachments (id, note_id, user_id, filename, content_type, size, created_at)
+	SELECT $1::uuid, $2::uuid, $3::uuid, $4::text, $5::text, $6::bigint, $7::timestamptz
+	WHERE (SELECT COALESCE(SUM(size), 0) FROM attachments WHERE user_id=$3) + $6 <= $8::bigint`
+	ctx, span := startSpan(ctx, "attachmentdb.Create", insertRow)
+	defer tracing.End(span, &err)
+
+	err = transaction.Run(ctx, aR.db, func(tx transaction.Conn) error {
+		if _, err := tx.ExecContext(ctx, lockUser, "attachments:"+a.UserID.String()); err != nil {
+			return err
+		}
+		res, err := tx.ExecContext(ctx, insertRow, a.ID, a.NoteID, a.UserID, a.Filename, a.ContentType, a.Size, a.CreatedAt, quota)
+		if err != nil {
+			return err
+		}
+		if c, err := res.RowsAffected(); err != nil {
+			return err
+		} else if c == 0 {
+			return attachment.ErrQuotaExceeded
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("create: [%s]: %w", a.ID, err)
+	}
+	return nil
+}
+
+func (aR AttachmentRepo) QueryByID(ctx context.Context, id uuid.UUID) (_ attachment.Attachment, err error) {
+	queryByID := `
+	SELECT id, note_id, user_id, filename, content_type, size, created_at FROM attachments WHERE id=$1`
+	ctx, span := startSpan(ctx, "attachmentdb.QueryByID", queryByID)
+	defer tracing.End(span, &err)
+
+	var aDB dbAttachment
+	err = transaction.ConnFrom(ctx, aR.db).QueryRowContext(ctx, queryByID, id).Scan(
+		&aDB.id, &aDB.noteID, &aDB.userID, &aDB.filename, &aDB.contentType, &aDB.size, &aDB.createdAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return attachment.Attachment{}, attachment.ErrAttachmentNotFound
+		}
+		return attachment.Attachment{}, fmt.Errorf("queryByID: [%s]: %w", id, err)
+	}
+	return attachmentDBToAttachment(aDB), nil
+}
+
+func (aR AttachmentRepo) QueryByNoteID(ctx context.Context, noteID uuid.UUID) (_ []attachment.Attachment, err error) {
+	queryByNoteID := `
+	SELECT id, note_id, user_id, filename, content_type, size, created_at FROM attachments
+	WHERE note_id=$1 ORDER BY created_at, id`
+	ctx, span := startSpan(ctx, "attachmentdb.QueryByNoteID", queryByNoteID)
+	defer tracing.End(span, &err)
+
+	rows, err := transaction.ConnFrom(ctx, aR.db).QueryContext(ctx, queryByNoteID, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("queryByNoteID: [%s]: %w", noteID, err)
+	}
+	defer rows.Close()
+
+	var ret []attachment.Attachment
+	for rows.Next() {
+		var aDB dbAttachment
+		err := rows.Scan(&aDB.id, &aDB.noteID, &aDB.userID, &aDB.filename, &aDB.contentType, &aDB.size, &aDB.createdAt)
+		if err != nil {
+			return nil, fmt.Errorf("queryByNoteID: [%s]: scan rows: %w", noteID, err)
+		}
+		ret = append(ret, attachmentDBToAttachment(aDB))
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("queryByNoteID: [%s]: %w", noteID, err)
+	}
+	return ret, nil
+}
+
+func (aR AttachmentRepo) Delete(ctx context.Context, id uuid.UUID) (err error) {
+	deleteRow := `DELETE FROM attachments WHERE id=$1`
+	ctx, span := startSpan(ctx, "attachmentdb.Delete", deleteRow)
+	defer tracing.End(span, &err)
+
+	res, err := transaction.ConnFrom(ctx, aR.db).ExecContext(ctx, deleteRow, id)
+	if err != nil {
+		return fmt.Errorf("delete: [%s]: %w", id, err)
+	}
+	if c, _ := res.RowsAffected(); c == 0 {
+		return attachment.ErrAttachmentNotFound
+	}
+	return nil
+}
+
+func (aR AttachmentRepo) DeleteByNoteID(ctx context.Context, noteID uuid.UUID) (err error) {
+	deleteRows := `DELETE FROM attachments WHERE note_id=$1`
+	ctx, span := startSpan(ctx, "attachmentdb.DeleteByNoteID", deleteRows)
+	defer tracing.End(span, &err)
+
+	if _, err := transaction.ConnFrom(ctx, aR.db).ExecContext(ctx, deleteRows, noteID); err != nil {
+		return fmt.Errorf("deleteByNoteID: [%s]: %w", noteID, err)
+	}
+	return nil
+}
+
+func (aR AttachmentRepo) UsageByUserID(ctx context.Context, userID uuid.UUID) (_ int64, err error) {
+	usageByUserID := `SELECT COALESCE(SUM(size), 0) FROM attachments WHERE user_id=$1`
+	ctx, span := startSpan(ctx, "attachmentdb.UsageByUserID", usageByUserID)
+	defer tracing.End(span, &err)
+
+	var usage int64
+	if err := transaction.ConnFrom(ctx, aR.db).QueryRowContext(ctx, usageByUserID, userID).Scan(&usage); err != nil {
+		return 0, fmt.Errorf("usageByUserID: [%s]: %w", userID, err)
+	}
+	return usage, nil
+}
+
+func startSpan(ctx context.Context, name, query string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.statement", query),
+		),
+	)
+}
+
+func attachmentDBToAttachment(aDB dbAttachment) attachment.Attachment {
+	return attachment.Attachment{
+		ID:          aDB.id,
+		NoteID:      aDB.noteID,
+		UserID:      aDB.userID,
+		Filename:    aDB.filename,
+		ContentType: aDB.contentType,
+		Size:        aDB.size,
+		CreatedAt:   aDB.createdAt.UTC(),
+	}
+}
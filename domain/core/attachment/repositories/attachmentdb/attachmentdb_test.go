@@ -0,0 +1,116 @@
+package attachmentdb_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/domain/core/attachment"
+	"github.com/Keisn1/note-taking-app/domain/core/attachment/repositories/attachmentdb"
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testDBName   = "test_note_taking_app_attachments"
+	testUser     = "postgres"
+	testPassword = "password"
+)
+
+func TestMain(m *testing.M) {
+	exitCode := run(m)
+	os.Exit(exitCode)
+}
+
+func TestAttachmentRepo_CreateAndQueryByID(t *testing.T) {
+	testDB, deleteTable := SetupAttachmentsTable(t, fixtureAttachments())
+	defer testDB.Close()
+	defer deleteTable()
+
+	t.Run("Created attachments can be queried", func(t *testing.T) {
+		aR := attachmentdb.NewAttachmentRepo(testDB)
+		a := attachment.Attachment{
+			ID: uuid.New(), NoteID: uuid.UUID{1}, UserID: uuid.UUID{1}, Filename: "new.png",
+			ContentType: "image/png", Size: 5, CreatedAt: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		}
+
+		assert.NoError(t, aR.Create(context.Background(), a, 65))
+		got, err := aR.QueryByID(context.Background(), a.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, a, got)
+	})
+
+	t.Run("Attachments beyond the quota are not created", func(t *testing.T) {
+		aR := attachmentdb.NewAttachmentRepo(testDB)
+		a := attachment.Attachment{
+			ID: uuid.New(), NoteID: uuid.UUID{1}, UserID: uuid.UUID{1}, Filename: "big.png",
+			ContentType: "image/png", Size: 36, CreatedAt: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		}
+
+		assert.ErrorIs(t, aR.Create(context.Background(), a, 100), attachment.ErrQuotaExceeded)
+		_, err := aR.QueryByID(context.Background(), a.ID)
+		assert.ErrorIs(t, err, attachment.ErrAttachmentNotFound)
+	})
+
+	t.Run("Unknown attachments are not found", func(t *testing.T) {
+		aR := attachmentdb.NewAttachmentRepo(testDB)
+		_, err := aR.QueryByID(context.Background(), uuid.New())
+		assert.ErrorIs(t, err, attachment.ErrAttachmentNotFound)
+	})
+
+	t.Run("Forwards error on database error", func(t *testing.T) {
+		aR := attachmentdb.NewAttachmentRepo(&stubSQLDB{})
+		a := attachment.Attachment{ID: uuid.UUID{9}}
+		err := aR.Create(context.Background(), a, 100)
+		assert.EqualError(t, err, fmt.Errorf("create: [%s]: %w", a.ID, errors.New("DBError")).Error())
+	})
+}
+
+func TestAttachmentRepo_QueryByNoteID(t *testing.T) {
+	testDB, deleteTable := SetupAttachmentsTable(t, fixtureAttachments())
+	defer testDB.Close()
+	defer deleteTable()
+
+	aR := attachmentdb.NewAttachmentRepo(testDB)
+	got, err := aR.QueryByNoteID(context.Background(), uuid.UUID{1})
+	assert.NoError(t, err)
+	assert.Equal(t, fixtureAttachments()[:2], got)
+
+	got, err = aR.QueryByNoteID(context.Background(), uuid.New())
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestAttachmentRepo_Delete(t *testing.T) {
+	testDB, deleteTable := SetupAttachmentsTable(t, fixtureAttachments())
+	defer testDB.Close()
+	defer deleteTable()
+
+	aR := attachmentdb.NewAttachmentRepo(testDB)
+	assert.NoError(t, aR.Delete(context.Background(), uuid.UUID{1}))
+	assert.ErrorIs(t, aR.Delete(context.Background(), uuid.UUID{1}), attachment.ErrAttachmentNotFound)
+
+	assert.NoError(t, aR.DeleteByNoteID(context.Background(), uuid.UUID{2}))
+	got, err := aR.QueryByNoteID(context.Background(), uuid.UUID{2})
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestAttachmentRepo_UsageByUserID(t *testing.T) {
+	testDB, deleteTable := SetupAttachmentsTable(t, fixtureAttachments())
+	defer testDB.Close()
+	defer deleteTable()
+
+	aR := attachmentdb.NewAttachmentRepo(testDB)
+	usage, err := aR.UsageByUserID(context.Background(), uuid.UUID{1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(60), usage)
+
+	usage, err = aR.UsageByUserID(context.Background(), uuid.New())
+	assert.NoError(t, err)
+	assert.Zero(t, usage)
+}
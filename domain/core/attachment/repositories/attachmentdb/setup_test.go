@@ -0,0 +1,98 @@
+package attachmentdb_test
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/domain/core/attachment"
+	"github.com/google/uuid"
+)
+
+func run(m *testing.M) int {
+	var (
+		dropDB   = fmt.Sprintf(`DROP DATABASE IF EXISTS %s;`, testDBName)
+		createDB = fmt.Sprintf(`CREATE DATABASE %s;`, testDBName)
+	)
+
+	dsn := fmt.Sprintf("host=localhost port=5432 user=%s password=%s sslmode=disable", testUser, testPassword)
+	postgresDB, err := sql.Open("pgx", dsn)
+	if err != nil {
+		panic(err)
+	}
+	defer postgresDB.Close()
+
+	_, err = postgresDB.Exec(dropDB)
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = postgresDB.Exec(createDB)
+	if err != nil {
+		panic(err)
+	}
+
+	defer func() {
+		_, err = postgresDB.Exec(dropDB)
+		if err != nil {
+			panic(fmt.Errorf("postgresDB.Exec() err = %s", err))
+		}
+	}()
+
+	return m.Run()
+}
+
+func SetupAttachmentsTable(t *testing.T, attachments []attachment.Attachment) (*sql.DB, func()) {
+	var (
+		createAttachmentsTable = `CREATE TABLE attachments(
+								id UUID PRIMARY KEY,
+								note_id UUID NOT NULL,
+								user_id UUID NOT NULL,
+								filename TEXT NOT NULL,
+								content_type TEXT NOT NULL,
+								size BIGINT NOT NULL,
+								created_at TIMESTAMPTZ NOT NULL DEFAULT now())`
+		dropAttachmentsTable = `DROP TABLE attachments`
+	)
+
+	dsn := fmt.Sprintf("host=localhost port=5432 user=%s password=%s sslmode=disable dbname=%s ", testUser, testPassword, testDBName)
+	testDB, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = testDB.Exec(createAttachmentsTable)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	insertRow := `INSERT INTO attachments (id, note_id, user_id, filename, content_type, size, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	for _, a := range attachments {
+		_, err = testDB.Exec(insertRow, a.ID, a.NoteID, a.UserID, a.Filename, a.ContentType, a.Size, a.CreatedAt)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deleteTable := func() {
+		_, err := testDB.Exec(dropAttachmentsTable)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testDB.Close()
+	}
+
+	return testDB, deleteTable
+}
+
+func fixtureAttachments() []attachment.Attachment {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	return []attachment.Attachment{
+		{ID: uuid.UUID{1}, NoteID: uuid.UUID{1}, UserID: uuid.UUID{1}, Filename: "a.png", ContentType: "image/png", Size: 10, CreatedAt: created},
+		{ID: uuid.UUID{2}, NoteID: uuid.UUID{1}, UserID: uuid.UUID{1}, Filename: "b.pdf", ContentType: "application/pdf", Size: 20, CreatedAt: created.Add(time.Minute)},
+		{ID: uuid.UUID{3}, NoteID: uuid.UUID{2}, UserID: uuid.UUID{1}, Filename: "c.gif", ContentType: "image/gif", Size: 30, CreatedAt: created},
+		{ID: uuid.UUID{4}, NoteID: uuid.UUID{3}, UserID: uuid.UUID{2}, Filename: "d.jpg", ContentType: "image/jpeg", Size: 40, CreatedAt: created},
+	}
+}
@@ -0,0 +1,123 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/Keisn1/note-taking-app/domain/core/attachment"
+	"github.com/google/uuid"
+)
+
+type Repo struct {
+	mu          *sync.Mutex
+	attachments map[uuid.UUID]attachment.Attachment
+}
+
+func NewRepo() Repo {
+	return Repo{mu: &sync.Mutex{}, attachments: make(map[uuid.UUID]attachment.Attachment)}
+}
+
+func (aR Repo) Create(ctx context.Context, a attachment.Attachment, quota int64) error {
+	aR.mu.Lock()
+	defer aR.mu.Unlock()
+
+	if _, ok := aR.attachments[a.ID]; ok {
+		return fmt.Errorf("create: already present %s", a.ID)
+	}
+	usage := a.Size
+	for _, b := range aR.attachments {
+		if b.UserID == a.UserID {
+			usage += b.Size
+		}
+	}
+	if usage > quota {
+		return attachment.ErrQuotaExceeded
+	}
+	aR.attachments[a.ID] = a
+	return nil
+}
+
+func (aR Repo) QueryByID(ctx context.Context, id uuid.UUID) (attachment.Attachment, error) {
+	aR.mu.Lock()
+	defer aR.mu.Unlock()
+
+	a, ok := aR.attachments[id]
+	if !ok {
+		return attachment.Attachment{}, attachment.ErrAttachmentNotFound
+	}
+	return a, nil
+}
+
+func (aR Repo) QueryByNoteID(ctx context.Context, noteID uuid.UUID) ([]attachment.Attachment, error) {
+	aR.mu.Lock()
+	defer aR.mu.Unlock()
+
+	var ret []attachment.Attachment
+	for _, a := range aR.attachments {
+		if a.NoteID == noteID {
+			ret = append(ret, a)
+		}
+	}
+	slices.SortFunc(ret, func(a, b attachment.Attachment) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID.String(), b.ID.String())
+	})
+	return ret, nil
+}
+
+func (aR Repo) Delete(ctx context.Context, id uuid.UUID) error {
+	aR.mu.Lock()
+	defer aR.mu.Unlock()
+
+	if _, ok := aR.attachments[id]; !ok {
+		return attachment.ErrAttachmentNotFound
+	}
+	delete(aR.attachments, id)
+	return nil
+}
+
+func (aR Repo) DeleteByNoteID(ctx context.Context, noteID uuid.UUID) error {
+	aR.mu.Lock()
+	defer aR.mu.Unlock()
+
+	for id, a := range aR.attachments {
+		if a.NoteID == noteID {
+			delete(aR.attachments, id)
+		}
+	}
+	return nil
+}
+
+func (aR Repo) UsageByUserID(ctx context.Context, userID uuid.UUID) (int64, error) {
+	aR.mu.Lock()
+	defer aR.mu.Unlock()
+
+	var usage int64
+	for _, a := range aR.attachments {
+		if a.UserID == userID {
+			usage += a.Size
+		}
+	}
+	return usage, nil
+}
+
+// Snapshot copies the attachments for a transaction.Memory. The files are
+// not part of it.
+func (aR Repo) Snapshot() (restore func()) {
+	aR.mu.Lock()
+	defer aR.mu.Unlock()
+
+	attachments := maps.Clone(aR.attachments)
+	return func() {
+		aR.mu.Lock()
+		defer aR.mu.Unlock()
+		clear(aR.attachments)
+		maps.Copy(aR.attachments, attachments)
+	}
+}
@@ -0,0 +1,21 @@
+package attachment
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type Repo interface {
+	// Create fails with ErrQuotaExceeded if the attachments of the user
+	// would take more than quota bytes with a. The check and the insert are
+	// atomic.
+	Create(ctx context.Context, a Attachment, quota int64) error
+	QueryByID(ctx context.Context, id uuid.UUID) (Attachment, error)
+	// QueryByNoteID returns the attachments of the note, oldest first.
+	QueryByNoteID(ctx context.Context, noteID uuid.UUID) ([]Attachment, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	DeleteByNoteID(ctx context.Context, noteID uuid.UUID) error
+	// UsageByUserID returns the total size of the attachments of the user.
+	UsageByUserID(ctx context.Context, userID uuid.UUID) (int64, error)
+}
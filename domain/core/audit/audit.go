@@ -0,0 +1,138 @@
+// Package audit keeps the append-only audit trail of security relevant
+// actions. The entries form a hash chain: every entry contains the hash of
+// the previous one and its own hash covers both, so that changing or
+// removing an entry breaks the chain from there on.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ActionLogin        = "user.login"
+	ActionLoginFailed  = "user.login_failed"
+	ActionAuthFailed   = "auth.failed"
+	ActionAccessDenied = "auth.denied"
+	ActionNoteCreated  = "note.created"
+	ActionNoteUpdated  = "note.updated"
+	ActionNoteDeleted  = "note.deleted"
+	ActionAuditQueried = "audit.queried"
+
+	// The actions of notes-admin.
+	ActionUserCreated   = "admin.user_created"
+	ActionUserDisabled  = "admin.user_disabled"
+	ActionUserEnabled   = "admin.user_enabled"
+	ActionPasswordReset = "admin.password_reset"
+	ActionTokenIssued   = "admin.token_issued"
+)
+
+var ErrChainBroken = errors.New("audit trail is broken")
+
+// Entry is an action of Actor on Target, e.g. "note:<id>". Actor is Nil if
+// the action is anonymous, like a failed login, or taken with notes-admin.
+type Entry struct {
+	Seq       int64
+	ID        uuid.UUID
+	Actor     uuid.UUID
+	Action    string
+	Target    string
+	IP        string
+	UserAgent string
+	RequestID string
+	CreatedAt time.Time
+	PrevHash  string
+	Hash      string
+}
+
+func NoteTarget(id uuid.UUID) string  { return "note:" + id.String() }
+func UserTarget(id uuid.UUID) string  { return "user:" + id.String() }
+func EmailTarget(email string) string { return "email:" + email }
+func PathTarget(path string) string   { return "path:" + path }
+
+// ComputeHash returns the hex encoded SHA-256 of the fields of e, including
+// PrevHash.
+func (e Entry) ComputeHash() string {
+	// JSON keeps the fields apart, whatever they contain.
+	b, _ := json.Marshal([]any{
+		e.Seq, e.ID, e.Actor, e.Action, e.Target, e.IP, e.UserAgent, e.RequestID,
+		e.CreatedAt.UTC().Format(time.RFC3339Nano), e.PrevHash,
+	})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// Chain appends e to prev, the last entry of the chain or the zero Entry if
+// the chain is empty.
+func Chain(prev, e Entry) Entry {
+	e.Seq = prev.Seq + 1
+	e.PrevHash = prev.Hash
+	e.Hash = e.ComputeHash()
+	return e
+}
+
+// Verify checks that entries, ordered by Seq, continue the chain after prev.
+func Verify(prev Entry, entries []Entry) error {
+	for _, e := range entries {
+		switch {
+		case e.Seq != prev.Seq+1:
+			return fmt.Errorf("%w: entry %d follows entry %d", ErrChainBroken, e.Seq, prev.Seq)
+		case e.PrevHash != prev.Hash:
+			return fmt.Errorf("%w: entry %d doesn't link to entry %d", ErrChainBroken, e.Seq, prev.Seq)
+		case e.Hash != e.ComputeHash():
+			return fmt.Errorf("%w: entry %d was changed", ErrChainBroken, e.Seq)
+		}
+		prev = e
+	}
+	return nil
+}
+
+// Filter selects entries. Zero fields match all entries, From and To bound
+// CreatedAt inclusively and exclusively. Before is a Seq that pages back
+// through the trail.
+type Filter struct {
+	Actor  uuid.UUID
+	Action string
+	Target string
+	From   time.Time
+	To     time.Time
+	Before int64
+	Limit  int
+}
+
+// Page is a page of the trail, newest first. Next is passed as Before to
+// get the following entries.
+type Page struct {
+	Entries []Entry
+	Next    int64
+	HasMore bool
+}
+
+// Request is the client that the entries recorded for a request are
+// attributed to.
+type Request struct {
+	IP        string
+	UserAgent string
+	RequestID string
+}
+
+type requestKey struct{}
+
+// WithRequest returns a context whose entries are attributed to r.
+func WithRequest(ctx context.Context, r Request) context.Context {
+	return context.WithValue(ctx, requestKey{}, r)
+}
+
+// RequestFrom returns the request of the context, the zero Request if there
+// is none.
+func RequestFrom(ctx context.Context) Request {
+	r, _ := ctx.Value(requestKey{}).(Request)
+	return r
+}
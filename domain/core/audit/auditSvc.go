@@ -0,0 +1,119 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/Keisn1/note-taking-app/foundation/tracing"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/Keisn1/note-taking-app/domain/core/audit")
+
+// verifyBatchSize is the number of entries Verify reads at once.
+const verifyBatchSize = 1000
+
+type Service interface {
+	Record(ctx context.Context, e Entry) (Entry, error)
+	Query(ctx context.Context, f Filter) (Page, error)
+	Verify(ctx context.Context) (Entry, error)
+}
+
+type Svc struct {
+	repo Repo
+}
+
+func NewSvc(repo Repo) Svc {
+	return Svc{repo: repo}
+}
+
+// Record appends an entry for e.Actor, e.Action and e.Target. The client is
+// taken from the request of the context, see WithRequest. The entry shows
+// up in the trail once a Chainer chained it.
+func (s Svc) Record(ctx context.Context, e Entry) (_ Entry, err error) {
+	ctx, span := tracer.Start(ctx, "audit.Record", trace.WithAttributes(attribute.String("audit.action", e.Action)))
+	defer tracing.End(span, &err)
+
+	r := RequestFrom(ctx)
+	e.ID = uuid.New()
+	e.IP, e.UserAgent, e.RequestID = r.IP, r.UserAgent, r.RequestID
+	// Postgres keeps microseconds, the hash has to match after a round trip.
+	e.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+
+	if err := s.repo.Append(ctx, e); err != nil {
+		return Entry{}, fmt.Errorf("record: [%s]: %w", e.Action, err)
+	}
+	return e, nil
+}
+
+// Query returns up to f.Limit entries matching f, newest first.
+func (s Svc) Query(ctx context.Context, f Filter) (_ Page, err error) {
+	ctx, span := tracer.Start(ctx, "audit.Query")
+	defer tracing.End(span, &err)
+
+	limit := f.Limit
+	f.Limit++
+	entries, err := s.repo.Query(ctx, f)
+	if err != nil {
+		return Page{}, fmt.Errorf("query: %w", err)
+	}
+
+	p := Page{Entries: entries, Next: f.Before}
+	if len(entries) > limit {
+		p.Entries, p.HasMore = entries[:limit], true
+	}
+	if len(p.Entries) > 0 {
+		p.Next = p.Entries[len(p.Entries)-1].Seq
+	}
+	return p, nil
+}
+
+// Verify walks the whole chain and returns its last entry, the zero Entry if
+// the trail is empty. The error wraps ErrChainBroken if an entry was changed
+// or removed. Removing the newest entries keeps the chain intact, compare
+// the last entry with a copy kept elsewhere to notice that.
+func (s Svc) Verify(ctx context.Context) (_ Entry, err error) {
+	ctx, span := tracer.Start(ctx, "audit.Verify")
+	defer tracing.End(span, &err)
+
+	var head Entry
+	for {
+		entries, err := s.repo.QueryAfter(ctx, head.Seq, verifyBatchSize)
+		if err != nil {
+			return Entry{}, fmt.Errorf("verify: %w", err)
+		}
+		if err := Verify(head, entries); err != nil {
+			return Entry{}, fmt.Errorf("verify: %w", err)
+		}
+		if len(entries) == 0 {
+			return head, nil
+		}
+		head = entries[len(entries)-1]
+		if len(entries) < verifyBatchSize {
+			return head, nil
+		}
+	}
+}
+
+// NoteEventHook records the changes of notes, for note.WithEventHook. Only
+// the owner can change a note, so the owner is the actor.
+func NoteEventHook(svc Service) func(ctx context.Context, e note.Event) error {
+	actions := map[string]string{
+		note.EventCreated: ActionNoteCreated,
+		note.EventUpdated: ActionNoteUpdated,
+		note.EventDeleted: ActionNoteDeleted,
+	}
+	return func(ctx context.Context, e note.Event) error {
+		action, ok := actions[e.Type]
+		if !ok {
+			return nil
+		}
+		_, err := svc.Record(ctx, Entry{Actor: e.Note.UserID, Action: action, Target: NoteTarget(e.Note.ID)})
+		return err
+	}
+}
@@ -0,0 +1,118 @@
+package audit_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Keisn1/note-taking-app/domain/core/audit"
+	"github.com/Keisn1/note-taking-app/domain/core/audit/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditService(t *testing.T) {
+	rob, anna := uuid.UUID{1}, uuid.UUID{2}
+	ctx := audit.WithRequest(context.Background(), audit.Request{IP: "192.0.2.1", UserAgent: "curl/8.0", RequestID: "req-1"})
+
+	newSvc := func(t *testing.T) (audit.Svc, memory.Repo) {
+		repo := memory.NewRepo()
+		svc := audit.NewSvc(repo)
+		for _, e := range []audit.Entry{
+			{Actor: uuid.Nil, Action: audit.ActionLoginFailed, Target: audit.EmailTarget("rob@example.com")},
+			{Actor: rob, Action: audit.ActionLogin, Target: audit.UserTarget(rob)},
+			{Actor: anna, Action: audit.ActionLogin, Target: audit.UserTarget(anna)},
+			{Actor: rob, Action: audit.ActionAccessDenied, Target: audit.NoteTarget(uuid.UUID{3})},
+			{Actor: rob, Action: audit.ActionLogin, Target: audit.UserTarget(rob)},
+		} {
+			_, err := svc.Record(ctx, e)
+			require.NoError(t, err)
+		}
+		return svc, repo
+	}
+
+	t.Run("Entries are chained and attributed to the request", func(t *testing.T) {
+		_, repo := newSvc(t)
+		got, err := repo.QueryAfter(ctx, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, got, 5)
+
+		assert.Equal(t, int64(1), got[0].Seq)
+		assert.Empty(t, got[0].PrevHash)
+		assert.Equal(t, got[0].Hash, got[1].PrevHash)
+		assert.Equal(t, "192.0.2.1", got[1].IP)
+		assert.Equal(t, "curl/8.0", got[1].UserAgent)
+		assert.Equal(t, "req-1", got[1].RequestID)
+		assert.NotEqual(t, uuid.Nil, got[1].ID)
+		assert.False(t, got[1].CreatedAt.IsZero())
+	})
+
+	t.Run("Query pages through the matching entries, newest first", func(t *testing.T) {
+		svc, _ := newSvc(t)
+		p, err := svc.Query(ctx, audit.Filter{Actor: rob, Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, p.Entries, 2)
+		assert.Equal(t, []int64{5, 4}, []int64{p.Entries[0].Seq, p.Entries[1].Seq})
+		assert.True(t, p.HasMore)
+		assert.Equal(t, int64(4), p.Next)
+
+		p, err = svc.Query(ctx, audit.Filter{Actor: rob, Before: p.Next, Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, p.Entries, 1)
+		assert.Equal(t, int64(2), p.Entries[0].Seq)
+		assert.False(t, p.HasMore)
+
+		p, err = svc.Query(ctx, audit.Filter{Target: audit.UserTarget(anna), Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, p.Entries, 1)
+		assert.Equal(t, anna, p.Entries[0].Actor)
+	})
+
+	t.Run("Verify returns the last entry of an intact chain", func(t *testing.T) {
+		svc, _ := newSvc(t)
+		head, err := svc.Verify(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), head.Seq)
+
+		head, err = audit.NewSvc(memory.NewRepo()).Verify(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, audit.Entry{}, head)
+	})
+
+	t.Run("Changed and removed entries break the chain", func(t *testing.T) {
+		_, repo := newSvc(t)
+		entries, err := repo.QueryAfter(ctx, 0, 10)
+		require.NoError(t, err)
+
+		changed := append([]audit.Entry(nil), entries...)
+		changed[1].Actor = anna
+		assert.ErrorIs(t, audit.Verify(audit.Entry{}, changed), audit.ErrChainBroken)
+
+		rehashed := append([]audit.Entry(nil), entries...)
+		rehashed[1].Actor = anna
+		rehashed[1].Hash = rehashed[1].ComputeHash()
+		assert.ErrorIs(t, audit.Verify(audit.Entry{}, rehashed), audit.ErrChainBroken)
+
+		removed := append(append([]audit.Entry(nil), entries[:2]...), entries[3:]...)
+		assert.ErrorIs(t, audit.Verify(audit.Entry{}, removed), audit.ErrChainBroken)
+
+		assert.NoError(t, audit.Verify(audit.Entry{}, entries))
+	})
+
+	t.Run("Note events are recorded for the owner of the note", func(t *testing.T) {
+		repo := memory.NewRepo()
+		hook := audit.NoteEventHook(audit.NewSvc(repo))
+		n := note.Note{ID: uuid.UUID{3}, UserID: rob}
+		require.NoError(t, hook(ctx, note.Event{Type: note.EventCreated, Note: n}))
+		require.NoError(t, hook(ctx, note.Event{Type: note.EventDeleted, Note: n}))
+
+		got, err := repo.QueryAfter(ctx, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, got, 2)
+		assert.Equal(t, audit.ActionNoteCreated, got[0].Action)
+		assert.Equal(t, audit.ActionNoteDeleted, got[1].Action)
+		assert.Equal(t, rob, got[1].Actor)
+		assert.Equal(t, audit.NoteTarget(n.ID), got[1].Target)
+	})
+}
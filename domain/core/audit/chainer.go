@@ -0,0 +1,69 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ChainerConfig configures a Chainer. Zero values are replaced by the
+// defaults.
+type ChainerConfig struct {
+	Interval  time.Duration // default 1s
+	BatchSize int           // entries chained at once, default 1000
+
+	// OnError is called with the errors of the repository.
+	OnError func(error)
+}
+
+// Chainer chains the recorded entries in the background, so that recording
+// doesn't wait for the other writers of the trail. Several chainers, e.g.
+// one per instance, take turns.
+type Chainer struct {
+	repo Repo
+	cfg  ChainerConfig
+}
+
+func NewChainer(repo Repo, cfg ChainerConfig) Chainer {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 1000
+	}
+	if cfg.OnError == nil {
+		cfg.OnError = func(error) {}
+	}
+	return Chainer{repo: repo, cfg: cfg}
+}
+
+// Run chains the pending entries every interval until ctx is done.
+func (c Chainer) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.Chain(ctx); err != nil && ctx.Err() == nil {
+			c.cfg.OnError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Chain chains the pending entries until none is left.
+func (c Chainer) Chain(ctx context.Context) error {
+	for {
+		n, err := c.repo.ChainPending(ctx, c.cfg.BatchSize)
+		if err != nil {
+			return fmt.Errorf("chain: %w", err)
+		}
+		if n < c.cfg.BatchSize || ctx.Err() != nil {
+			return nil
+		}
+	}
+}
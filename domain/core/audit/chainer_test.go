@@ -0,0 +1,50 @@
+package audit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Keisn1/note-taking-app/domain/core/audit"
+	"github.com/stretchr/testify/assert"
+)
+
+// pendingRepo has pending entries to chain.
+type pendingRepo struct {
+	audit.Repo
+	pending *int
+	calls   *int
+	err     error
+}
+
+func (r pendingRepo) ChainPending(ctx context.Context, limit int) (int, error) {
+	*r.calls++
+	if r.err != nil {
+		return 0, r.err
+	}
+	n := min(*r.pending, limit)
+	*r.pending -= n
+	return n, nil
+}
+
+func TestChainer_Chain(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Batches are chained until none is left", func(t *testing.T) {
+		pending, calls := 25, 0
+		c := audit.NewChainer(pendingRepo{pending: &pending, calls: &calls}, audit.ChainerConfig{BatchSize: 10})
+		assert.NoError(t, c.Chain(ctx))
+		assert.Zero(t, pending)
+		assert.Equal(t, 3, calls)
+
+		pending, calls = 20, 0
+		assert.NoError(t, c.Chain(ctx))
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("Errors of the repository are returned", func(t *testing.T) {
+		pending, calls := 5, 0
+		c := audit.NewChainer(pendingRepo{pending: &pending, calls: &calls, err: errors.New("DBError")}, audit.ChainerConfig{})
+		assert.EqualError(t, c.Chain(ctx), "chain: DBError")
+	})
+}
@@ -0,0 +1,210 @@
+package auditdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Keisn1/note-taking-app/domain/core/audit"
+	"github.com/Keisn1/note-taking-app/foundation/tracing"
+	"github.com/Keisn1/note-taking-app/foundation/transaction"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/Keisn1/note-taking-app/domain/core/audit/repositories/auditdb")
+
+const columns = `seq, id, actor, action, target, ip, user_agent, request_id, created_at, prev_hash, hash`
+
+type AuditRepo struct {
+	db transaction.DB
+}
+
+func NewAuditRepo(db transaction.DB) AuditRepo {
+	return AuditRepo{db: db}
+}
+
+// Append inserts e without seq and hashes, in the transaction of ctx if
+// there is one. ChainPending sees it once that one is committed.
+func (aR AuditRepo) Append(ctx context.Context, e audit.Entry) (err error) {
+	insertRow := `
+	INSERT INTO audit_log (id, actor, action, target, ip, user_agent, request_id, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	ctx, span := startSpan(ctx, "auditdb.Append", insertRow)
+	defer tracing.End(span, &err)
+
+	_, err = transaction.ConnFrom(ctx, aR.db).ExecContext(ctx, insertRow,
+		e.ID, nullUUID(e.Actor), e.Action, e.Target, e.IP, e.UserAgent, e.RequestID, e.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("append: [%s]: %w", e.Action, err)
+	}
+	return nil
+}
+
+// ChainPending holds an advisory lock that only the other chainers wait
+// for. An entry whose transaction commits after newer entries were chained
+// is chained after them.
+func (aR AuditRepo) ChainPending(ctx context.Context, limit int) (_ int, err error) {
+	queryPending := `
+	SELECT id, actor, action, target, ip, user_agent, request_id, created_at FROM audit_log
+	WHERE seq IS NULL ORDER BY created_at, id LIMIT $1`
+	chainRow := `UPDATE audit_log SET seq = $1, prev_hash = $2, hash = $3 WHERE id = $4`
+	ctx, span := startSpan(ctx, "auditdb.ChainPending", queryPending)
+	defer tracing.End(span, &err)
+
+	var chained int
+	err = transaction.Run(ctx, aR.db, func(tx transaction.Conn) error {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtextextended('audit_log', 0))`); err != nil {
+			return err
+		}
+
+		var prev audit.Entry
+		err := tx.QueryRowContext(ctx, `SELECT seq, hash FROM audit_log WHERE seq IS NOT NULL ORDER BY seq DESC LIMIT 1`).Scan(&prev.Seq, &prev.Hash)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		pending, err := queryPendingEntries(ctx, tx, queryPending, limit)
+		if err != nil {
+			return err
+		}
+		for _, e := range pending {
+			e = audit.Chain(prev, e)
+			if _, err := tx.ExecContext(ctx, chainRow, e.Seq, e.PrevHash, e.Hash, e.ID); err != nil {
+				return err
+			}
+			prev = e
+		}
+		chained = len(pending)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("chainPending: %w", err)
+	}
+	return chained, nil
+}
+
+func queryPendingEntries(ctx context.Context, tx transaction.Conn, query string, limit int) ([]audit.Entry, error) {
+	rows, err := tx.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []audit.Entry
+	for rows.Next() {
+		var (
+			e     audit.Entry
+			actor uuid.NullUUID
+		)
+		if err := rows.Scan(&e.ID, &actor, &e.Action, &e.Target, &e.IP, &e.UserAgent, &e.RequestID, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan rows: %w", err)
+		}
+		e.Actor = actor.UUID
+		e.CreatedAt = e.CreatedAt.UTC()
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (aR AuditRepo) Query(ctx context.Context, f audit.Filter) (_ []audit.Entry, err error) {
+	var (
+		where = []string{"seq IS NOT NULL"}
+		args  []any
+	)
+	cond := func(c string, arg any) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(c, len(args)))
+	}
+	if f.Actor != uuid.Nil {
+		cond("actor = $%d", f.Actor)
+	}
+	if f.Action != "" {
+		cond("action = $%d", f.Action)
+	}
+	if f.Target != "" {
+		cond("target = $%d", f.Target)
+	}
+	if !f.From.IsZero() {
+		cond("created_at >= $%d", f.From)
+	}
+	if !f.To.IsZero() {
+		cond("created_at < $%d", f.To)
+	}
+	if f.Before > 0 {
+		cond("seq < $%d", f.Before)
+	}
+
+	query := `SELECT ` + columns + ` FROM audit_log WHERE ` + strings.Join(where, " AND ")
+	args = append(args, f.Limit)
+	query += fmt.Sprintf(` ORDER BY seq DESC LIMIT $%d`, len(args))
+	ctx, span := startSpan(ctx, "auditdb.Query", query)
+	defer tracing.End(span, &err)
+
+	entries, err := aR.query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	return entries, nil
+}
+
+func (aR AuditRepo) QueryAfter(ctx context.Context, seq int64, limit int) (_ []audit.Entry, err error) {
+	queryAfter := `SELECT ` + columns + ` FROM audit_log WHERE seq > $1 ORDER BY seq LIMIT $2`
+	ctx, span := startSpan(ctx, "auditdb.QueryAfter", queryAfter)
+	defer tracing.End(span, &err)
+
+	entries, err := aR.query(ctx, queryAfter, seq, limit)
+	if err != nil {
+		return nil, fmt.Errorf("queryAfter: [%d]: %w", seq, err)
+	}
+	return entries, nil
+}
+
+func (aR AuditRepo) query(ctx context.Context, query string, args ...any) ([]audit.Entry, error) {
+	rows, err := transaction.ConnFrom(ctx, aR.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []audit.Entry
+	for rows.Next() {
+		var (
+			e     audit.Entry
+			actor uuid.NullUUID
+		)
+		err := rows.Scan(&e.Seq, &e.ID, &actor, &e.Action, &e.Target, &e.IP, &e.UserAgent, &e.RequestID, &e.CreatedAt, &e.PrevHash, &e.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("scan rows: %w", err)
+		}
+		e.Actor = actor.UUID
+		e.CreatedAt = e.CreatedAt.UTC()
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// nullUUID stores anonymous actors as NULL.
+func nullUUID(id uuid.UUID) uuid.NullUUID {
+	return uuid.NullUUID{UUID: id, Valid: id != uuid.Nil}
+}
+
+func startSpan(ctx context.Context, name, query string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.statement", query),
+		),
+	)
+}
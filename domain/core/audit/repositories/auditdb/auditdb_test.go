@@ -0,0 +1,137 @@
+package auditdb_test
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/domain/core/audit"
+	"github.com/Keisn1/note-taking-app/domain/core/audit/repositories/auditdb"
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testDBName   = "test_note_taking_app_audit"
+	testUser     = "postgres"
+	testPassword = "password"
+)
+
+func TestMain(m *testing.M) {
+	exitCode := run(m)
+	os.Exit(exitCode)
+}
+
+func TestAuditRepo(t *testing.T) {
+	testDB, deleteTable := SetupAuditTable(t)
+	defer testDB.Close()
+	defer deleteTable()
+	aR := auditdb.NewAuditRepo(testDB)
+	ctx := context.Background()
+
+	rob := uuid.UUID{1}
+	start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	entry := func(i int, actor uuid.UUID, action string) audit.Entry {
+		return audit.Entry{
+			ID: uuid.New(), Actor: actor, Action: action, Target: audit.UserTarget(rob),
+			IP: "192.0.2.1", UserAgent: "curl/8.0", RequestID: "req", CreatedAt: start.Add(time.Duration(i) * time.Hour),
+		}
+	}
+
+	require.NoError(t, aR.Append(ctx, entry(1, rob, audit.ActionLogin)))
+	require.NoError(t, aR.Append(ctx, entry(0, uuid.Nil, audit.ActionLoginFailed)))
+
+	t.Run("Entries show up once chained, oldest first", func(t *testing.T) {
+		got, err := aR.QueryAfter(ctx, 0, 100)
+		require.NoError(t, err)
+		assert.Empty(t, got)
+
+		n, err := aR.ChainPending(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		n, err = aR.ChainPending(ctx, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+
+		got, err = aR.QueryAfter(ctx, 0, 100)
+		require.NoError(t, err)
+		require.Len(t, got, 2)
+		assert.Equal(t, audit.ActionLoginFailed, got[0].Action)
+		assert.Empty(t, got[0].PrevHash)
+		assert.Equal(t, got[0].Hash, got[1].PrevHash)
+		assert.NoError(t, audit.Verify(audit.Entry{}, got))
+	})
+
+	t.Run("Appends don't wait for other transactions", func(t *testing.T) {
+		tx, err := testDB.BeginTx(ctx, nil)
+		require.NoError(t, err)
+		defer tx.Rollback()
+		_, err = tx.ExecContext(ctx, `INSERT INTO audit_log (id, action, target, ip, user_agent, request_id, created_at)
+			VALUES ($1, 'note.created', '', '', '', '', now())`, uuid.New())
+		require.NoError(t, err)
+
+		appendCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		assert.NoError(t, aR.Append(appendCtx, entry(2, rob, audit.ActionNoteCreated)))
+		_, err = aR.ChainPending(appendCtx, 10)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Concurrent chainers don't fork the chain", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, aR.Append(ctx, entry(3+i, rob, audit.ActionNoteCreated)))
+				_, err := aR.ChainPending(ctx, 2)
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+		_, err := aR.ChainPending(ctx, 100)
+		require.NoError(t, err)
+
+		got, err := aR.QueryAfter(ctx, 0, 100)
+		require.NoError(t, err)
+		require.Len(t, got, 11)
+		assert.NoError(t, audit.Verify(audit.Entry{}, got))
+	})
+
+	t.Run("Entries are filtered, newest first", func(t *testing.T) {
+		got, err := aR.Query(ctx, audit.Filter{Actor: rob, Action: audit.ActionNoteCreated, From: start.Add(4 * time.Hour), To: start.Add(7 * time.Hour), Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, got, 3)
+		for i := 1; i < len(got); i++ {
+			assert.Less(t, got[i].Seq, got[i-1].Seq)
+		}
+
+		got, err = aR.Query(ctx, audit.Filter{Target: audit.UserTarget(rob), Before: 3, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, got, 2)
+		assert.Equal(t, uuid.Nil, got[1].Actor)
+	})
+
+	t.Run("Entries can't be changed or deleted", func(t *testing.T) {
+		_, err := testDB.Exec(`UPDATE audit_log SET actor = NULL WHERE seq = 2`)
+		assert.ErrorContains(t, err, "audit_log is append-only")
+		_, err = testDB.Exec(`UPDATE audit_log SET hash = 'x' WHERE seq = 2`)
+		assert.ErrorContains(t, err, "audit_log is append-only")
+		_, err = testDB.Exec(`DELETE FROM audit_log WHERE seq = 1`)
+		assert.ErrorContains(t, err, "audit_log is append-only")
+	})
+
+	t.Run("Given an error received by the DB, the error is forwarded", func(t *testing.T) {
+		aR := auditdb.NewAuditRepo(&stubSQLDB{})
+		err := aR.Append(ctx, entry(0, rob, audit.ActionLogin))
+		assert.ErrorContains(t, err, "append: [user.login]: DBError")
+		_, err = aR.ChainPending(ctx, 10)
+		assert.ErrorContains(t, err, "chainPending: DBError")
+		_, err = aR.Query(ctx, audit.Filter{Limit: 10})
+		assert.ErrorContains(t, err, "query: DBError")
+	})
+}
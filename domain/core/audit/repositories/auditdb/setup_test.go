@@ -0,0 +1,95 @@
+package auditdb_test
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+)
+
+func run(m *testing.M) int {
+	var (
+		dropDB   = fmt.Sprintf(`DROP DATABASE IF EXISTS %s;`, testDBName)
+		createDB = fmt.Sprintf(`CREATE DATABASE %s;`, testDBName)
+	)
+
+	dsn := fmt.Sprintf("host=localhost port=5432 user=%s password=%s sslmode=disable", testUser, testPassword)
+	postgresDB, err := sql.Open("pgx", dsn)
+	if err != nil {
+		panic(err)
+	}
+	defer postgresDB.Close()
+
+	_, err = postgresDB.Exec(dropDB)
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = postgresDB.Exec(createDB)
+	if err != nil {
+		panic(err)
+	}
+
+	defer func() {
+		_, err = postgresDB.Exec(dropDB)
+		if err != nil {
+			panic(fmt.Errorf("postgresDB.Exec() err = %s", err))
+		}
+	}()
+
+	return m.Run()
+}
+
+func SetupAuditTable(t *testing.T) (*sql.DB, func()) {
+	var (
+		createTable = `
+		CREATE TABLE audit_log(
+			seq BIGINT UNIQUE,
+			id UUID PRIMARY KEY,
+			actor UUID,
+			action TEXT NOT NULL,
+			target TEXT NOT NULL,
+			ip TEXT NOT NULL,
+			user_agent TEXT NOT NULL,
+			request_id TEXT NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL,
+			prev_hash TEXT,
+			hash TEXT);
+		CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+		BEGIN
+			IF TG_OP = 'UPDATE' THEN
+				IF OLD.seq IS NULL AND NEW.seq IS NOT NULL
+					AND (NEW.id, NEW.actor, NEW.action, NEW.target, NEW.ip, NEW.user_agent, NEW.request_id, NEW.created_at)
+					IS NOT DISTINCT FROM
+					(OLD.id, OLD.actor, OLD.action, OLD.target, OLD.ip, OLD.user_agent, OLD.request_id, OLD.created_at) THEN
+					RETURN NEW;
+				END IF;
+			END IF;
+			RAISE EXCEPTION 'audit_log is append-only';
+		END;
+		$$ LANGUAGE plpgsql;
+		CREATE TRIGGER audit_log_no_change BEFORE UPDATE OR DELETE ON audit_log
+			FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();`
+		dropTable = `DROP TABLE audit_log`
+	)
+
+	dsn := fmt.Sprintf("host=localhost port=5432 user=%s password=%s sslmode=disable dbname=%s ", testUser, testPassword, testDBName)
+	testDB, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = testDB.Exec(createTable)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deleteTable := func() {
+		_, err := testDB.Exec(dropTable)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testDB.Close()
+	}
+
+	return testDB, deleteTable
+}
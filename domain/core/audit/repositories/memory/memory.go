@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/Keisn1/note-taking-app/domain/core/audit"
+	"github.com/google/uuid"
+)
+
+type Repo struct {
+	mu      *sync.Mutex
+	entries *[]audit.Entry // by seq
+}
+
+func NewRepo() Repo {
+	return Repo{mu: &sync.Mutex{}, entries: &[]audit.Entry{}}
+}
+
+// Append chains e right away, so that nothing is pending for ChainPending.
+func (aR Repo) Append(ctx context.Context, e audit.Entry) error {
+	aR.mu.Lock()
+	defer aR.mu.Unlock()
+
+	var prev audit.Entry
+	if n := len(*aR.entries); n > 0 {
+		prev = (*aR.entries)[n-1]
+	}
+	*aR.entries = append(*aR.entries, audit.Chain(prev, e))
+	return nil
+}
+
+func (aR Repo) ChainPending(ctx context.Context, limit int) (int, error) {
+	return 0, nil
+}
+
+func (aR Repo) Query(ctx context.Context, f audit.Filter) ([]audit.Entry, error) {
+	aR.mu.Lock()
+	defer aR.mu.Unlock()
+
+	var ret []audit.Entry
+	for i := len(*aR.entries) - 1; i >= 0 && len(ret) < f.Limit; i-- {
+		if e := (*aR.entries)[i]; matches(f, e) {
+			ret = append(ret, e)
+		}
+	}
+	return ret, nil
+}
+
+func (aR Repo) QueryAfter(ctx context.Context, seq int64, limit int) ([]audit.Entry, error) {
+	aR.mu.Lock()
+	defer aR.mu.Unlock()
+
+	start := min(int(max(seq, 0)), len(*aR.entries))
+	return slices.Clone((*aR.entries)[start:min(start+limit, len(*aR.entries))]), nil
+}
+
+// Snapshot copies the entries for a transaction.Memory.
+func (aR Repo) Snapshot() (restore func()) {
+	aR.mu.Lock()
+	defer aR.mu.Unlock()
+
+	entries := slices.Clone(*aR.entries)
+	return func() {
+		aR.mu.Lock()
+		defer aR.mu.Unlock()
+		*aR.entries = entries
+	}
+}
+
+func matches(f audit.Filter, e audit.Entry) bool {
+	switch {
+	case f.Actor != uuid.Nil && e.Actor != f.Actor:
+		return false
+	case f.Action != "" && e.Action != f.Action:
+		return false
+	case f.Target != "" && e.Target != f.Target:
+		return false
+	case !f.From.IsZero() && e.CreatedAt.Before(f.From):
+		return false
+	case !f.To.IsZero() && !e.CreatedAt.Before(f.To):
+		return false
+	case f.Before > 0 && e.Seq >= f.Before:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,18 @@
+package audit
+
+import "context"
+
+type Repo interface {
+	// Append stores e unchained. It takes no lock, so that it can run in the
+	// transaction of a change without holding up the others.
+	Append(ctx context.Context, e Entry) error
+	// ChainPending chains up to limit unchained entries to the last chained
+	// one, see Chain, oldest first, and returns how many it chained. Calls
+	// are serialized, so that the chain doesn't fork.
+	ChainPending(ctx context.Context, limit int) (int, error)
+	// Query returns up to f.Limit chained entries matching f, newest first.
+	Query(ctx context.Context, f Filter) ([]Entry, error)
+	// QueryAfter returns up to limit chained entries after seq, oldest
+	// first.
+	QueryAfter(ctx context.Context, seq int64, limit int) ([]Entry, error)
+}
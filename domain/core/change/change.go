@@ -0,0 +1,88 @@
+// Package change keeps the change feed of the notes of each user, for the
+// sync of clients that work offline. Every change of a note gets the next
+// number of the sequence of its user, which is also the version of the note.
+// Only the latest change of a note is kept, deleted notes stay in the feed
+// as tombstones.
+package change
+
+import (
+	"errors"
+
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/google/uuid"
+)
+
+// ConflictSuffix is appended to the title of conflict copies.
+const ConflictSuffix = " (conflict copy)"
+
+var (
+	ErrNotFound  = errors.New("the note has no changes")
+	ErrForbidden = errors.New("the note belongs to another user")
+)
+
+// Change is the latest change of a note.
+type Change struct {
+	NoteID  uuid.UUID
+	UserID  uuid.UUID
+	Seq     int64
+	Deleted bool
+}
+
+// Entry is a change together with the note, which is empty for tombstones.
+type Entry struct {
+	Change
+	Note note.Note
+}
+
+// Feed is a page of the changes of a user. Clients pass Next as since to get
+// the following changes.
+type Feed struct {
+	Entries []Entry
+	Next    int64
+	HasMore bool
+}
+
+type Op string
+
+const (
+	OpUpsert Op = "upsert"
+	OpDelete Op = "delete"
+)
+
+// Mutation is a change a client made offline. BaseVersion is the version of
+// the note the client changed, 0 for notes it created. An empty Format keeps
+// the format of the note.
+type Mutation struct {
+	Op          Op
+	NoteID      uuid.UUID
+	BaseVersion int64
+	Title       string
+	Content     string
+	Format      note.Format
+}
+
+type Status string
+
+const (
+	// StatusApplied means the mutation was applied, or the note already was
+	// as the mutation wanted it.
+	StatusApplied Status = "applied"
+	// StatusConflict means the note changed since the base version. The
+	// version on the server is kept, the version of the client is saved as
+	// a conflict copy unless it was a deletion.
+	StatusConflict Status = "conflict"
+	// StatusRejected means the mutation can't be applied, see Err.
+	StatusRejected Status = "rejected"
+)
+
+// Result is the outcome of a mutation. Version and Note are the state of the
+// note on the server afterwards, Note is empty if it is deleted. Copy is the
+// conflict copy, empty if there is none.
+type Result struct {
+	NoteID  uuid.UUID
+	Status  Status
+	Version int64
+	Note    note.Note
+	Copy    note.Note
+	Err     error
+}
@@ -0,0 +1,280 @@
+package change
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/Keisn1/note-taking-app/foundation/tracing"
+	"github.com/Keisn1/note-taking-app/foundation/transaction"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/Keisn1/note-taking-app/domain/core/change")
+
+// NoteRepo decorates a note.Repo and records the changes made through it.
+// Everything that writes notes has to use it, also the services that bypass
+// the note service.
+//
+// Recording a change locks the sequence of the user until the transaction
+// ends. To always take the locks in the same order, every write first takes
+// the lock of the notes of the user, see Repo.Lock, which also makes writers
+// wait for the SyncService checking a version.
+type NoteRepo struct {
+	note.Repo
+	changes Repo
+}
+
+func NewNoteRepo(noteRepo note.Repo, changes Repo) NoteRepo {
+	return NoteRepo{Repo: noteRepo, changes: changes}
+}
+
+// QueryByIDForUpdate takes the lock of the notes of the owner before reading
+// the note again for update.
+func (nR NoteRepo) QueryByIDForUpdate(ctx context.Context, noteID uuid.UUID) (note.Note, error) {
+	n, err := nR.Repo.QueryByID(ctx, noteID)
+	if err != nil {
+		return note.Note{}, err
+	}
+	if err := nR.changes.Lock(ctx, n.UserID); err != nil {
+		return note.Note{}, err
+	}
+	return nR.Repo.QueryByIDForUpdate(ctx, noteID)
+}
+
+func (nR NoteRepo) Create(ctx context.Context, n note.Note) error {
+	if err := nR.changes.Lock(ctx, n.UserID); err != nil {
+		return err
+	}
+	if err := nR.Repo.Create(ctx, n); err != nil {
+		return err
+	}
+	return nR.record(ctx, Change{NoteID: n.ID, UserID: n.UserID})
+}
+
+func (nR NoteRepo) Update(ctx context.Context, n note.Note) error {
+	if err := nR.changes.Lock(ctx, n.UserID); err != nil {
+		return err
+	}
+	if err := nR.Repo.Update(ctx, n); err != nil {
+		return err
+	}
+	return nR.record(ctx, Change{NoteID: n.ID, UserID: n.UserID})
+}
+
+// Delete records the tombstone of the note after deleting it.
+func (nR NoteRepo) Delete(ctx context.Context, noteID uuid.UUID) error {
+	n, err := nR.QueryByIDForUpdate(ctx, noteID)
+	if err != nil {
+		return err
+	}
+	if err := nR.Repo.Delete(ctx, noteID); err != nil {
+		return err
+	}
+	return nR.record(ctx, Change{NoteID: noteID, UserID: n.UserID, Deleted: true})
+}
+
+func (nR NoteRepo) record(ctx context.Context, c Change) error {
+	if _, err := nR.changes.Record(ctx, c); err != nil {
+		return fmt.Errorf("record: [%s]: %w", c.NoteID, err)
+	}
+	return nil
+}
+
+type Service interface {
+	Changes(ctx context.Context, userID uuid.UUID, since int64, limit int) (Feed, error)
+	Apply(ctx context.Context, userID uuid.UUID, mutations []Mutation) ([]Result, error)
+}
+
+// SyncService changes the notes through the note.Service, so that the
+// changes are recorded like any other.
+type SyncService struct {
+	repo  Repo
+	notes note.Service
+	tx    transaction.Manager
+}
+
+func NewSvc(repo Repo, ns note.Service, tx transaction.Manager) SyncService {
+	return SyncService{repo: repo, notes: ns, tx: tx}
+}
+
+// Changes returns up to limit changes of the notes of the user after since,
+// ordered by their number, with the notes as they are now.
+func (ss SyncService) Changes(ctx context.Context, userID uuid.UUID, since int64, limit int) (_ Feed, err error) {
+	ctx, span := tracer.Start(ctx, "change.Changes", trace.WithAttributes(attribute.String("user.id", userID.String())))
+	defer tracing.End(span, &err)
+
+	changes, err := ss.repo.QuerySince(ctx, userID, since, limit+1)
+	if err != nil {
+		return Feed{}, fmt.Errorf("changes: [%s]: %w", userID, err)
+	}
+
+	f := Feed{Entries: make([]Entry, 0, min(len(changes), limit)), Next: since}
+	if len(changes) > limit {
+		changes, f.HasMore = changes[:limit], true
+	}
+	for _, c := range changes {
+		f.Next = c.Seq
+		e := Entry{Change: c}
+		if !c.Deleted {
+			e.Note, err = ss.notes.QueryByID(ctx, c.NoteID)
+			// Deleted since, its tombstone comes after Next.
+			if errors.Is(err, note.ErrNoteNotFound) {
+				continue
+			}
+			if err != nil {
+				return Feed{}, fmt.Errorf("changes: [%s]: %w", userID, err)
+			}
+		}
+		f.Entries = append(f.Entries, e)
+	}
+	return f, nil
+}
+
+// Apply applies the mutations of the user in their order. A mutation whose
+// base version is the version of the note is applied. Otherwise the version
+// on the server wins: a mutation that leaves the note as it already is counts
+// as applied, a conflicting upsert is saved as a new note, the conflict copy,
+// and a conflicting delete is dropped. The ID of a conflict copy is derived
+// from the mutation, so that retrying a batch doesn't copy twice.
+//
+// Each mutation is checked and applied in a transaction that holds the lock
+// of the notes of the user, a change made by another request in between is
+// a conflict.
+// When a mutation fails the ones before it stay applied, retrying the batch
+// is safe.
+func (ss SyncService) Apply(ctx context.Context, userID uuid.UUID, mutations []Mutation) (_ []Result, err error) {
+	ctx, span := tracer.Start(ctx, "change.Apply", trace.WithAttributes(attribute.String("user.id", userID.String())))
+	defer tracing.End(span, &err)
+
+	results := make([]Result, 0, len(mutations))
+	for _, m := range mutations {
+		var r Result
+		err := ss.tx.WithinTx(ctx, func(ctx context.Context) (err error) {
+			if err := ss.repo.Lock(ctx, userID); err != nil {
+				return err
+			}
+			r, err = ss.apply(ctx, userID, m)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("apply: [%s]: %w", m.NoteID, err)
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+func (ss SyncService) apply(ctx context.Context, userID uuid.UUID, m Mutation) (Result, error) {
+	c, n, err := ss.current(ctx, m.NoteID)
+	if err != nil {
+		return Result{}, err
+	}
+	if c.UserID != uuid.Nil && c.UserID != userID {
+		return Result{NoteID: m.NoteID, Status: StatusRejected, Err: ErrForbidden}, nil
+	}
+
+	status := StatusApplied
+	var cp note.Note
+	switch {
+	case m.BaseVersion == c.Seq:
+		if err := ss.write(ctx, userID, m, n); err != nil {
+			return Result{}, err
+		}
+	case m.satisfiedBy(n):
+	default:
+		status = StatusConflict
+		if m.Op == OpUpsert {
+			if cp, err = ss.conflictCopy(ctx, userID, m); err != nil {
+				return Result{}, err
+			}
+		}
+	}
+
+	c, n, err = ss.current(ctx, m.NoteID)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{NoteID: m.NoteID, Status: status, Version: c.Seq, Note: n, Copy: cp}, nil
+}
+
+// current returns the latest change of the note, with Seq 0 if there is
+// none, and the note, empty if it doesn't exist.
+func (ss SyncService) current(ctx context.Context, noteID uuid.UUID) (Change, note.Note, error) {
+	c, err := ss.repo.QueryByNoteID(ctx, noteID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return Change{}, note.Note{}, err
+	}
+	if c.Deleted {
+		return c, note.Note{}, nil
+	}
+
+	n, err := ss.notes.QueryByID(ctx, noteID)
+	if errors.Is(err, note.ErrNoteNotFound) {
+		return c, note.Note{}, nil
+	}
+	if err != nil {
+		return Change{}, note.Note{}, err
+	}
+	c.UserID = n.UserID
+	return c, n, nil
+}
+
+func (ss SyncService) write(ctx context.Context, userID uuid.UUID, m Mutation, n note.Note) error {
+	exists := n.ID != uuid.Nil
+	var err error
+	switch {
+	case m.Op == OpDelete && exists:
+		err = ss.notes.Delete(ctx, m.NoteID)
+	case m.Op == OpDelete:
+	case exists:
+		_, err = ss.notes.Update(ctx, n, note.UpdateNote{Title: note.NewTitle(m.Title), Content: note.NewContent(m.Content), Format: m.Format})
+	default:
+		_, err = ss.notes.Create(ctx, note.UpdateNote{ID: m.NoteID, Title: note.NewTitle(m.Title), Content: note.NewContent(m.Content), Format: m.Format, UserID: userID})
+	}
+	return err
+}
+
+// conflictCopy creates the conflict copy of the upsert, or returns it if it
+// was created by an earlier attempt.
+func (ss SyncService) conflictCopy(ctx context.Context, userID uuid.UUID, m Mutation) (note.Note, error) {
+	id := uuid.NewSHA1(m.NoteID, []byte(fmt.Sprintf("%d\x00%s\x00%s\x00%s", m.BaseVersion, m.Title, m.Content, m.Format)))
+
+	n, err := ss.notes.QueryByID(ctx, id)
+	switch {
+	case err == nil && n.UserID == userID:
+		return n, nil
+	case err == nil:
+		// Another user took the ID.
+		id = uuid.New()
+	case !errors.Is(err, note.ErrNoteNotFound):
+		return note.Note{}, err
+	}
+
+	return ss.notes.Create(ctx, note.UpdateNote{ID: id, Title: note.NewTitle(copyTitle(m.Title)), Content: note.NewContent(m.Content), Format: m.Format, UserID: userID})
+}
+
+func copyTitle(title string) string {
+	max := note.MaxTitleLength - utf8.RuneCountInString(ConflictSuffix)
+	if r := []rune(title); len(r) > max {
+		title = string(r[:max])
+	}
+	return title + ConflictSuffix
+}
+
+// satisfiedBy reports whether the note, empty if it doesn't exist, already is
+// as the mutation wants it.
+func (m Mutation) satisfiedBy(n note.Note) bool {
+	if m.Op == OpDelete {
+		return n.ID == uuid.Nil
+	}
+	return n.ID != uuid.Nil &&
+		n.Title.String() == m.Title &&
+		n.Content.String() == m.Content &&
+		(m.Format.IsEmpty() || n.Format == m.Format)
+}
@@ -0,0 +1,267 @@
+package change_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/domain/core/change"
+	changememory "github.com/Keisn1/note-taking-app/domain/core/change/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	notememory "github.com/Keisn1/note-taking-app/domain/core/note/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	"github.com/Keisn1/note-taking-app/foundation/transaction"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubUserService struct {
+	user.Service
+}
+
+func (stubUserService) QueryByID(ctx context.Context, userID uuid.UUID) (user.User, error) {
+	return user.User{ID: userID}, nil
+}
+
+// slowNoteService widens the window between checking the version of a note
+// and writing it.
+type slowNoteService struct {
+	note.Service
+}
+
+func (s slowNoteService) QueryByID(ctx context.Context, noteID uuid.UUID) (note.Note, error) {
+	time.Sleep(10 * time.Millisecond)
+	return s.Service.QueryByID(ctx, noteID)
+}
+
+// callsRepo logs the locks and records.
+type callsRepo struct {
+	change.Repo
+	calls *[]string
+}
+
+func (r callsRepo) Lock(ctx context.Context, userID uuid.UUID) error {
+	*r.calls = append(*r.calls, "lock "+userID.String())
+	return r.Repo.Lock(ctx, userID)
+}
+
+func (r callsRepo) Record(ctx context.Context, c change.Change) (int64, error) {
+	*r.calls = append(*r.calls, "record "+c.NoteID.String())
+	return r.Repo.Record(ctx, c)
+}
+
+var rob, anna = uuid.UUID{1}, uuid.UUID{2}
+
+func setup(t *testing.T) (note.NotesService, change.SyncService) {
+	t.Helper()
+	changeRepo, noteRepo := changememory.NewRepo(), notememory.MustNewRepo(nil)
+	noteSvc := note.NewNotesService(change.NewNoteRepo(noteRepo, changeRepo), stubUserService{})
+	return noteSvc, change.NewSvc(changeRepo, noteSvc, transaction.NewMemory(changeRepo, noteRepo))
+}
+
+func create(t *testing.T, ns note.NotesService, userID uuid.UUID, title string) note.Note {
+	t.Helper()
+	n, err := ns.Create(context.Background(), note.UpdateNote{Title: note.NewTitle(title), Content: note.NewContent(""), UserID: userID})
+	require.NoError(t, err)
+	return n
+}
+
+func apply(t *testing.T, ss change.SyncService, m change.Mutation) change.Result {
+	t.Helper()
+	results, err := ss.Apply(context.Background(), rob, []change.Mutation{m})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	return results[0]
+}
+
+func TestNoteRepo(t *testing.T) {
+	ctx := context.Background()
+	var calls []string
+	nR := change.NewNoteRepo(notememory.MustNewRepo(nil), callsRepo{Repo: changememory.NewRepo(), calls: &calls})
+	n := note.Note{ID: uuid.New(), Title: note.NewTitle("title"), Format: note.FormatPlain, UserID: rob}
+
+	t.Run("Writes take the lock of the owner before recording", func(t *testing.T) {
+		require.NoError(t, nR.Create(ctx, n))
+		require.NoError(t, nR.Update(ctx, n))
+		_, err := nR.QueryByIDForUpdate(ctx, n.ID)
+		require.NoError(t, err)
+		require.NoError(t, nR.Delete(ctx, n.ID))
+
+		lock, record := "lock "+rob.String(), "record "+n.ID.String()
+		assert.Equal(t, []string{lock, record, lock, record, lock, lock, record}, calls)
+	})
+}
+
+func TestSyncService_Changes(t *testing.T) {
+	ctx := context.Background()
+	ns, ss := setup(t)
+
+	groceries := create(t, ns, rob, "Groceries")
+	create(t, ns, anna, "Recipes")
+	plan := create(t, ns, rob, "Plan")
+	groceries, err := ns.Update(ctx, groceries, note.UpdateNote{Content: note.NewContent("milk")})
+	require.NoError(t, err)
+	require.NoError(t, ns.Delete(ctx, plan.ID))
+
+	t.Run("The latest changes of the notes of the user are returned in order", func(t *testing.T) {
+		f, err := ss.Changes(ctx, rob, 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, change.Feed{
+			Entries: []change.Entry{
+				{Change: change.Change{NoteID: groceries.ID, UserID: rob, Seq: 3}, Note: groceries},
+				{Change: change.Change{NoteID: plan.ID, UserID: rob, Seq: 4, Deleted: true}},
+			},
+			Next: 4,
+		}, f)
+	})
+
+	t.Run("Changes are paginated", func(t *testing.T) {
+		f, err := ss.Changes(ctx, rob, 0, 1)
+		require.NoError(t, err)
+		assert.Len(t, f.Entries, 1)
+		assert.Equal(t, int64(3), f.Next)
+		assert.True(t, f.HasMore)
+
+		f, err = ss.Changes(ctx, rob, f.Next, 1)
+		require.NoError(t, err)
+		assert.Equal(t, plan.ID, f.Entries[0].NoteID)
+		assert.False(t, f.HasMore)
+
+		f, err = ss.Changes(ctx, rob, f.Next, 1)
+		require.NoError(t, err)
+		assert.Empty(t, f.Entries)
+		assert.Equal(t, int64(4), f.Next)
+	})
+}
+
+func TestSyncService_Apply(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Notes are created, updated and deleted from their base version", func(t *testing.T) {
+		ns, ss := setup(t)
+		id := uuid.New()
+
+		r := apply(t, ss, change.Mutation{Op: change.OpUpsert, NoteID: id, Title: "Offline", Content: "draft"})
+		assert.Equal(t, change.StatusApplied, r.Status)
+		assert.Equal(t, int64(1), r.Version)
+		assert.Equal(t, id, r.Note.ID)
+		assert.Equal(t, note.FormatPlain, r.Note.Format)
+
+		r = apply(t, ss, change.Mutation{Op: change.OpUpsert, NoteID: id, BaseVersion: 1, Title: "Offline", Content: "done", Format: note.FormatMarkdown})
+		assert.Equal(t, change.StatusApplied, r.Status)
+		assert.Equal(t, int64(2), r.Version)
+		assert.Equal(t, "done", r.Note.Content.String())
+		assert.Equal(t, note.FormatMarkdown, r.Note.Format)
+
+		r = apply(t, ss, change.Mutation{Op: change.OpDelete, NoteID: id, BaseVersion: 2})
+		assert.Equal(t, change.Result{NoteID: id, Status: change.StatusApplied, Version: 3}, r)
+		_, err := ns.QueryByID(ctx, id)
+		assert.ErrorIs(t, err, note.ErrNoteNotFound)
+	})
+
+	t.Run("A conflicting upsert is kept as conflict copy, also when retried", func(t *testing.T) {
+		ns, ss := setup(t)
+		n := create(t, ns, rob, "Groceries")
+		_, err := ns.Update(ctx, n, note.UpdateNote{Content: note.NewContent("milk")})
+		require.NoError(t, err)
+
+		m := change.Mutation{Op: change.OpUpsert, NoteID: n.ID, BaseVersion: 1, Title: "Groceries", Content: "eggs"}
+		r := apply(t, ss, m)
+		assert.Equal(t, change.StatusConflict, r.Status)
+		assert.Equal(t, int64(2), r.Version)
+		assert.Equal(t, "milk", r.Note.Content.String())
+		assert.Equal(t, "Groceries (conflict copy)", r.Copy.Title.String())
+		assert.Equal(t, "eggs", r.Copy.Content.String())
+		assert.Equal(t, rob, r.Copy.UserID)
+
+		again := apply(t, ss, m)
+		assert.Equal(t, r.Copy, again.Copy)
+		notes, err := ns.GetNotesByUserID(ctx, rob)
+		require.NoError(t, err)
+		assert.Len(t, notes, 2)
+	})
+
+	t.Run("The version on the server wins over a deletion", func(t *testing.T) {
+		ns, ss := setup(t)
+		n := create(t, ns, rob, "Groceries")
+		_, err := ns.Update(ctx, n, note.UpdateNote{Content: note.NewContent("milk")})
+		require.NoError(t, err)
+
+		r := apply(t, ss, change.Mutation{Op: change.OpDelete, NoteID: n.ID, BaseVersion: 1})
+		assert.Equal(t, change.StatusConflict, r.Status)
+		assert.Equal(t, "milk", r.Note.Content.String())
+		assert.Equal(t, note.Note{}, r.Copy)
+	})
+
+	t.Run("An edit of a deleted note is kept as conflict copy", func(t *testing.T) {
+		ns, ss := setup(t)
+		n := create(t, ns, rob, strings.Repeat("a", note.MaxTitleLength))
+		require.NoError(t, ns.Delete(ctx, n.ID))
+
+		r := apply(t, ss, change.Mutation{Op: change.OpUpsert, NoteID: n.ID, BaseVersion: 1, Title: n.Title.String(), Content: "b"})
+		assert.Equal(t, change.StatusConflict, r.Status)
+		assert.Equal(t, int64(2), r.Version)
+		assert.Equal(t, note.Note{}, r.Note)
+		assert.Len(t, []rune(r.Copy.Title.String()), note.MaxTitleLength)
+		assert.True(t, strings.HasSuffix(r.Copy.Title.String(), change.ConflictSuffix))
+	})
+
+	t.Run("Mutations that match the server are applied", func(t *testing.T) {
+		ns, ss := setup(t)
+		n := create(t, ns, rob, "Groceries")
+		n, err := ns.Update(ctx, n, note.UpdateNote{Content: note.NewContent("milk")})
+		require.NoError(t, err)
+
+		r := apply(t, ss, change.Mutation{Op: change.OpUpsert, NoteID: n.ID, BaseVersion: 1, Title: "Groceries", Content: "milk"})
+		assert.Equal(t, change.Result{NoteID: n.ID, Status: change.StatusApplied, Version: 2, Note: n}, r)
+
+		require.NoError(t, ns.Delete(ctx, n.ID))
+		r = apply(t, ss, change.Mutation{Op: change.OpDelete, NoteID: n.ID, BaseVersion: 2})
+		assert.Equal(t, change.Result{NoteID: n.ID, Status: change.StatusApplied, Version: 3}, r)
+	})
+
+	t.Run("Notes of other users are rejected", func(t *testing.T) {
+		ns, ss := setup(t)
+		n := create(t, ns, anna, "Recipes")
+
+		r := apply(t, ss, change.Mutation{Op: change.OpUpsert, NoteID: n.ID, BaseVersion: 1, Title: "Mine"})
+		assert.Equal(t, change.Result{NoteID: n.ID, Status: change.StatusRejected, Err: change.ErrForbidden}, r)
+
+		require.NoError(t, ns.Delete(ctx, n.ID))
+		r = apply(t, ss, change.Mutation{Op: change.OpUpsert, NoteID: n.ID, BaseVersion: 2, Title: "Mine"})
+		assert.Equal(t, change.StatusRejected, r.Status)
+	})
+
+	t.Run("Of concurrent mutations from the same base version one is applied", func(t *testing.T) {
+		changeRepo, noteRepo := changememory.NewRepo(), notememory.MustNewRepo(nil)
+		ns := note.NewNotesService(change.NewNoteRepo(noteRepo, changeRepo), stubUserService{})
+		ss := change.NewSvc(changeRepo, slowNoteService{ns}, transaction.NewMemory(changeRepo, noteRepo))
+		n := create(t, ns, rob, "Groceries")
+
+		const devices = 5
+		done := make(chan []change.Result, devices)
+		for i := range devices {
+			go func() {
+				results, err := ss.Apply(ctx, rob, []change.Mutation{{Op: change.OpUpsert, NoteID: n.ID, BaseVersion: 1, Title: "Groceries", Content: fmt.Sprint(i)}})
+				assert.NoError(t, err)
+				done <- results
+			}()
+		}
+		var applied int
+		for range devices {
+			for _, r := range <-done {
+				if r.Status == change.StatusApplied {
+					applied++
+				}
+			}
+		}
+		assert.Equal(t, 1, applied)
+
+		notes, err := ns.GetNotesByUserID(ctx, rob)
+		require.NoError(t, err)
+		assert.Len(t, notes, devices, "the others are kept as conflict copies")
+	})
+}
@@ -0,0 +1,114 @@
+package changedb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Keisn1/note-taking-app/domain/core/change"
+	"github.com/Keisn1/note-taking-app/foundation/tracing"
+	"github.com/Keisn1/note-taking-app/foundation/transaction"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/Keisn1/note-taking-app/domain/core/change/repositories/changedb")
+
+type ChangeRepo struct {
+	db transaction.Conn
+}
+
+func NewChangeRepo(db transaction.Conn) ChangeRepo {
+	return ChangeRepo{db: db}
+}
+
+// Record takes the next number from the counter of the user in the same
+// statement. The counter row stays locked until the statement commits, so
+// the changes of a user become visible in the order of their numbers.
+func (cR ChangeRepo) Record(ctx context.Context, c change.Change) (_ int64, err error) {
+	record := `
+	WITH next AS (
+		INSERT INTO change_seqs (user_id, seq) VALUES ($1, 1)
+		ON CONFLICT (user_id) DO UPDATE SET seq = change_seqs.seq + 1
+		RETURNING seq
+	)
+	INSERT INTO changes (note_id, user_id, seq, deleted) SELECT $2, $1, seq, $3 FROM next
+	ON CONFLICT (note_id) DO UPDATE SET seq = EXCLUDED.seq, deleted = EXCLUDED.deleted
+	RETURNING seq`
+	ctx, span := startSpan(ctx, "changedb.Record", record)
+	defer tracing.End(span, &err)
+
+	var seq int64
+	if err := transaction.ConnFrom(ctx, cR.db).QueryRowContext(ctx, record, c.UserID, c.NoteID, c.Deleted).Scan(&seq); err != nil {
+		return 0, fmt.Errorf("record: [%s]: %w", c.NoteID, err)
+	}
+	return seq, nil
+}
+
+func (cR ChangeRepo) QueryByNoteID(ctx context.Context, noteID uuid.UUID) (_ change.Change, err error) {
+	queryByNoteID := `SELECT note_id, user_id, seq, deleted FROM changes WHERE note_id=$1`
+	ctx, span := startSpan(ctx, "changedb.QueryByNoteID", queryByNoteID)
+	defer tracing.End(span, &err)
+
+	var c change.Change
+	err = transaction.ConnFrom(ctx, cR.db).QueryRowContext(ctx, queryByNoteID, noteID).Scan(&c.NoteID, &c.UserID, &c.Seq, &c.Deleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return change.Change{}, change.ErrNotFound
+	}
+	if err != nil {
+		return change.Change{}, fmt.Errorf("queryByNoteID: [%s]: %w", noteID, err)
+	}
+	return c, nil
+}
+
+func (cR ChangeRepo) QuerySince(ctx context.Context, userID uuid.UUID, seq int64, limit int) (_ []change.Change, err error) {
+	querySince := `
+	SELECT note_id, user_id, seq, deleted FROM changes WHERE user_id=$1 AND seq > $2 ORDER BY seq LIMIT $3`
+	ctx, span := startSpan(ctx, "changedb.QuerySince", querySince)
+	defer tracing.End(span, &err)
+
+	rows, err := transaction.ConnFrom(ctx, cR.db).QueryContext(ctx, querySince, userID, seq, limit)
+	if err != nil {
+		return nil, fmt.Errorf("querySince: [%s]: %w", userID, err)
+	}
+	defer rows.Close()
+
+	var changes []change.Change
+	for rows.Next() {
+		var c change.Change
+		if err := rows.Scan(&c.NoteID, &c.UserID, &c.Seq, &c.Deleted); err != nil {
+			return nil, fmt.Errorf("querySince: [%s]: scan rows: %w", userID, err)
+		}
+		changes = append(changes, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("querySince: [%s]: %w", userID, err)
+	}
+	return changes, nil
+}
+
+// Lock takes an advisory lock, which also covers notes that don't exist yet.
+// Outside of a transaction it is released right away.
+func (cR ChangeRepo) Lock(ctx context.Context, userID uuid.UUID) (err error) {
+	lock := `SELECT pg_advisory_xact_lock(hashtextextended($1::text, 0))`
+	ctx, span := startSpan(ctx, "changedb.Lock", lock)
+	defer tracing.End(span, &err)
+
+	if _, err := transaction.ConnFrom(ctx, cR.db).ExecContext(ctx, lock, "changes:"+userID.String()); err != nil {
+		return fmt.Errorf("lock: [%s]: %w", userID, err)
+	}
+	return nil
+}
+
+func startSpan(ctx context.Context, name, query string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.statement", query),
+		),
+	)
+}
@@ -0,0 +1,108 @@
+package changedb_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/domain/core/change"
+	"github.com/Keisn1/note-taking-app/domain/core/change/repositories/changedb"
+	"github.com/Keisn1/note-taking-app/foundation/transaction"
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testDBName   = "test_note_taking_app_changes"
+	testUser     = "postgres"
+	testPassword = "password"
+)
+
+func TestMain(m *testing.M) {
+	exitCode := run(m)
+	os.Exit(exitCode)
+}
+
+func TestChangeRepo(t *testing.T) {
+	rob, anna := uuid.UUID{1}, uuid.UUID{2}
+	testDB, deleteTables := SetupChangesTables(t, []change.Change{
+		{NoteID: uuid.UUID{1}, UserID: rob},
+		{NoteID: uuid.UUID{2}, UserID: rob},
+		{NoteID: uuid.UUID{3}, UserID: anna},
+	})
+	defer testDB.Close()
+	defer deleteTables()
+	cR := changedb.NewChangeRepo(testDB)
+	ctx := context.Background()
+
+	t.Run("Numbers increase per user, the latest change of a note replaces the previous one", func(t *testing.T) {
+		seq, err := cR.Record(ctx, change.Change{NoteID: uuid.UUID{1}, UserID: rob, Deleted: true})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), seq)
+
+		got, err := cR.QueryByNoteID(ctx, uuid.UUID{1})
+		assert.NoError(t, err)
+		assert.Equal(t, change.Change{NoteID: uuid.UUID{1}, UserID: rob, Seq: 3, Deleted: true}, got)
+
+		got, err = cR.QueryByNoteID(ctx, uuid.UUID{3})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), got.Seq)
+
+		_, err = cR.QueryByNoteID(ctx, uuid.UUID{9})
+		assert.ErrorIs(t, err, change.ErrNotFound)
+	})
+
+	t.Run("Changes are returned ordered by number", func(t *testing.T) {
+		got, err := cR.QuerySince(ctx, rob, 0, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []change.Change{
+			{NoteID: uuid.UUID{2}, UserID: rob, Seq: 2},
+			{NoteID: uuid.UUID{1}, UserID: rob, Seq: 3, Deleted: true},
+		}, got)
+
+		got, err = cR.QuerySince(ctx, rob, 2, 10)
+		assert.NoError(t, err)
+		assert.Len(t, got, 1)
+
+		got, err = cR.QuerySince(ctx, rob, 0, 1)
+		assert.NoError(t, err)
+		assert.Len(t, got, 1)
+	})
+
+	t.Run("The lock of the notes of a user is held until the transaction ends", func(t *testing.T) {
+		tm := transaction.NewPostgres(testDB)
+		locked, release := make(chan struct{}), make(chan struct{})
+		done := make(chan error)
+		go func() {
+			done <- tm.WithinTx(ctx, func(ctx context.Context) error {
+				if err := cR.Lock(ctx, uuid.UUID{1}); err != nil {
+					return err
+				}
+				close(locked)
+				<-release
+				return nil
+			})
+		}()
+		<-locked
+
+		waitCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+		err := tm.WithinTx(waitCtx, func(ctx context.Context) error { return cR.Lock(ctx, uuid.UUID{1}) })
+		assert.Error(t, err)
+		assert.NoError(t, tm.WithinTx(ctx, func(ctx context.Context) error { return cR.Lock(ctx, uuid.UUID{2}) }))
+
+		close(release)
+		assert.NoError(t, <-done)
+		assert.NoError(t, tm.WithinTx(ctx, func(ctx context.Context) error { return cR.Lock(ctx, uuid.UUID{1}) }))
+	})
+
+	t.Run("Forwards error on database error", func(t *testing.T) {
+		cR := changedb.NewChangeRepo(&stubSQLDB{})
+		_, err := cR.QuerySince(ctx, rob, 0, 10)
+		assert.EqualError(t, err, fmt.Errorf("querySince: [%s]: %w", rob, errors.New("DBError")).Error())
+	})
+}
@@ -0,0 +1,87 @@
+package changedb_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/Keisn1/note-taking-app/domain/core/change"
+	"github.com/Keisn1/note-taking-app/domain/core/change/repositories/changedb"
+)
+
+func run(m *testing.M) int {
+	var (
+		dropDB   = fmt.Sprintf(`DROP DATABASE IF EXISTS %s;`, testDBName)
+		createDB = fmt.Sprintf(`CREATE DATABASE %s;`, testDBName)
+	)
+
+	dsn := fmt.Sprintf("host=localhost port=5432 user=%s password=%s sslmode=disable", testUser, testPassword)
+	postgresDB, err := sql.Open("pgx", dsn)
+	if err != nil {
+		panic(err)
+	}
+	defer postgresDB.Close()
+
+	_, err = postgresDB.Exec(dropDB)
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = postgresDB.Exec(createDB)
+	if err != nil {
+		panic(err)
+	}
+
+	defer func() {
+		_, err = postgresDB.Exec(dropDB)
+		if err != nil {
+			panic(fmt.Errorf("postgresDB.Exec() err = %s", err))
+		}
+	}()
+
+	return m.Run()
+}
+
+func SetupChangesTables(t *testing.T, changes []change.Change) (*sql.DB, func()) {
+	var (
+		createTables = `
+		CREATE TABLE change_seqs(
+			user_id UUID PRIMARY KEY,
+			seq BIGINT NOT NULL);
+		CREATE TABLE changes(
+			note_id UUID PRIMARY KEY,
+			user_id UUID NOT NULL,
+			seq BIGINT NOT NULL,
+			deleted BOOLEAN NOT NULL DEFAULT false)`
+		dropTables = `DROP TABLE changes, change_seqs`
+	)
+
+	dsn := fmt.Sprintf("host=localhost port=5432 user=%s password=%s sslmode=disable dbname=%s ", testUser, testPassword, testDBName)
+	testDB, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = testDB.Exec(createTables)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cR := changedb.NewChangeRepo(testDB)
+	for _, c := range changes {
+		if _, err := cR.Record(context.Background(), c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deleteTables := func() {
+		_, err := testDB.Exec(dropTables)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testDB.Close()
+	}
+
+	return testDB, deleteTables
+}
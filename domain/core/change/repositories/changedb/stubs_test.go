@@ -0,0 +1,21 @@
+package changedb_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type stubSQLDB struct{}
+
+func (s *stubSQLDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return nil, errors.New("DBError")
+}
+
+func (s *stubSQLDB) QueryRowContext(ctx context.Context, query string, args ...any) (row *sql.Row) {
+	return
+}
+
+func (s *stubSQLDB) ExecContext(ctx context.Context, query string, args ...any) (res sql.Result, err error) {
+	return nil, errors.New("DBError")
+}
@@ -0,0 +1,79 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/Keisn1/note-taking-app/domain/core/change"
+	"github.com/google/uuid"
+)
+
+type Repo struct {
+	mu      *sync.Mutex
+	seqs    map[uuid.UUID]int64 // by user
+	changes map[uuid.UUID]change.Change
+}
+
+func NewRepo() Repo {
+	return Repo{mu: &sync.Mutex{}, seqs: make(map[uuid.UUID]int64), changes: make(map[uuid.UUID]change.Change)}
+}
+
+func (cR Repo) Record(ctx context.Context, c change.Change) (int64, error) {
+	cR.mu.Lock()
+	defer cR.mu.Unlock()
+
+	cR.seqs[c.UserID]++
+	c.Seq = cR.seqs[c.UserID]
+	cR.changes[c.NoteID] = c
+	return c.Seq, nil
+}
+
+func (cR Repo) QueryByNoteID(ctx context.Context, noteID uuid.UUID) (change.Change, error) {
+	cR.mu.Lock()
+	defer cR.mu.Unlock()
+
+	c, ok := cR.changes[noteID]
+	if !ok {
+		return change.Change{}, fmt.Errorf("queryByNoteID: [%s]: %w", noteID, change.ErrNotFound)
+	}
+	return c, nil
+}
+
+func (cR Repo) QuerySince(ctx context.Context, userID uuid.UUID, seq int64, limit int) ([]change.Change, error) {
+	cR.mu.Lock()
+	defer cR.mu.Unlock()
+
+	var ret []change.Change
+	for _, c := range cR.changes {
+		if c.UserID == userID && c.Seq > seq {
+			ret = append(ret, c)
+		}
+	}
+	slices.SortFunc(ret, func(a, b change.Change) int { return cmp.Compare(a.Seq, b.Seq) })
+	return ret[:min(len(ret), limit)], nil
+}
+
+// Lock does nothing, a transaction.Memory runs one transaction at a time.
+func (cR Repo) Lock(ctx context.Context, userID uuid.UUID) error {
+	return nil
+}
+
+// Snapshot copies the changes for a transaction.Memory.
+func (cR Repo) Snapshot() (restore func()) {
+	cR.mu.Lock()
+	defer cR.mu.Unlock()
+
+	seqs, changes := maps.Clone(cR.seqs), maps.Clone(cR.changes)
+	return func() {
+		cR.mu.Lock()
+		defer cR.mu.Unlock()
+		clear(cR.seqs)
+		maps.Copy(cR.seqs, seqs)
+		clear(cR.changes)
+		maps.Copy(cR.changes, changes)
+	}
+}
@@ -0,0 +1,22 @@
+package change
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type Repo interface {
+	// Record gives c the next number of the sequence of its user, replaces
+	// the previous change of the note with it and returns the number.
+	Record(ctx context.Context, c Change) (int64, error)
+	// QueryByNoteID returns the latest change of the note, ErrNotFound if
+	// there is none.
+	QueryByNoteID(ctx context.Context, noteID uuid.UUID) (Change, error)
+	// QuerySince returns up to limit changes of the user after seq, ordered
+	// by seq.
+	QuerySince(ctx context.Context, userID uuid.UUID, seq int64, limit int) ([]Change, error)
+	// Lock takes the lock of the notes of the user for the transaction of
+	// ctx. Other transactions that change one of them wait until it ends.
+	Lock(ctx context.Context, userID uuid.UUID) error
+}
@@ -0,0 +1,67 @@
+// Package collab lets several clients edit the content of a note at the same
+// time. The clients of a note share a session that holds the content as a
+// text CRDT, relays the operations and cursors of each client to the others
+// and periodically saves the merged content back to the note.
+package collab
+
+import (
+	"errors"
+
+	"github.com/Keisn1/note-taking-app/foundation/crdt"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrClosed      = errors.New("the editing session was closed")
+	ErrNoteDeleted = errors.New("the note was deleted")
+	ErrLagging     = errors.New("the client didn't keep up with the updates")
+	ErrTooLarge    = errors.New("the content is too large")
+)
+
+// ServerSite is the site of the characters the server inserts: the content
+// the session started with and changes made to the note outside of it.
+const ServerSite = "server"
+
+const (
+	UpdateInit   = "init"
+	UpdateOps    = "ops"
+	UpdateJoin   = "join"
+	UpdateLeave  = "leave"
+	UpdateCursor = "cursor"
+	UpdateError  = "error"
+)
+
+// Update is sent to the clients of a session.
+//
+//   - UpdateInit is the first update of a client, with the site assigned to
+//     it, the document, its clock and the other clients.
+//   - UpdateOps has the operations of Site.
+//   - UpdateJoin and UpdateLeave tell that the client Site joined or left.
+//   - UpdateCursor has the new Cursor of Site.
+//   - UpdateError has an Err that doesn't end the session, such as a failed
+//     save that is retried.
+type Update struct {
+	Type   string
+	Site   string
+	UserID uuid.UUID
+	Clock  uint64
+	Doc    []crdt.Element
+	Peers  []Peer
+	Ops    []crdt.Op
+	Cursor *Cursor
+	Err    error
+}
+
+// Cursor is a selection, Anchor and Head are the elements the ends of the
+// selection follow. The zero ID is the start of the text.
+type Cursor struct {
+	Anchor crdt.ID
+	Head   crdt.ID
+}
+
+// Peer is another client of the session.
+type Peer struct {
+	Site   string
+	UserID uuid.UUID
+	Cursor *Cursor
+}
@@ -0,0 +1,100 @@
+package collab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/google/uuid"
+)
+
+// DefaultSnapshotInterval is how often sessions save their content.
+const DefaultSnapshotInterval = 10 * time.Second
+
+// saveTimeout bounds the periodic saves, which don't belong to a request.
+const saveTimeout = 10 * time.Second
+
+// Hub holds the editing sessions of the notes. A session starts with the
+// first client of a note and ends, after a final save, when the last one
+// leaves.
+type Hub struct {
+	noteSvc  note.Service
+	interval time.Duration
+
+	mu       sync.Mutex
+	sessions map[uuid.UUID]*session
+	closed   bool
+}
+
+func NewHub(ns note.Service, snapshotInterval time.Duration) *Hub {
+	if snapshotInterval <= 0 {
+		snapshotInterval = DefaultSnapshotInterval
+	}
+	return &Hub{noteSvc: ns, interval: snapshotInterval, sessions: map[uuid.UUID]*session{}}
+}
+
+// Join adds a client of the user to the session of the note, starting it if
+// there is none. The first update of the client is an UpdateInit.
+func (h *Hub) Join(ctx context.Context, noteID, userID uuid.UUID) (*Client, error) {
+	for {
+		// Queried every time, a session that just ended may have saved it.
+		n, err := h.noteSvc.QueryByID(ctx, noteID)
+		if err != nil {
+			return nil, fmt.Errorf("join: [%s]: %w", noteID, err)
+		}
+
+		h.mu.Lock()
+		if h.closed {
+			h.mu.Unlock()
+			return nil, fmt.Errorf("join: [%s]: %w", noteID, ErrClosed)
+		}
+		s, ok := h.sessions[noteID]
+		if !ok {
+			s = newSession(h, n)
+			h.sessions[noteID] = s
+			go s.run(h.interval)
+		}
+		h.mu.Unlock()
+
+		if c, ok := s.join(userID); ok {
+			return c, nil
+		}
+		// The session is ending, join the next one once it is saved.
+		select {
+		case <-s.done:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("join: [%s]: %w", noteID, ctx.Err())
+		}
+	}
+}
+
+// Close saves and ends all sessions, their clients are closed with
+// ErrClosed. Clients can't join afterwards.
+func (h *Hub) Close(ctx context.Context) error {
+	h.mu.Lock()
+	h.closed = true
+	sessions := make([]*session, 0, len(h.sessions))
+	for _, s := range h.sessions {
+		sessions = append(sessions, s)
+	}
+	h.mu.Unlock()
+
+	var errs []error
+	for _, s := range sessions {
+		if err := s.close(ctx, ErrClosed, true); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h *Hub) remove(s *session) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.sessions[s.noteID] == s {
+		delete(h.sessions, s.noteID)
+	}
+}
@@ -0,0 +1,301 @@
+package collab_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/domain/core/collab"
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	notememory "github.com/Keisn1/note-taking-app/domain/core/note/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	"github.com/Keisn1/note-taking-app/foundation/crdt"
+	"github.com/Keisn1/note-taking-app/foundation/transaction"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubUserService struct {
+	user.Service
+}
+
+func (stubUserService) QueryByID(ctx context.Context, userID uuid.UUID) (user.User, error) {
+	return user.User{ID: userID}, nil
+}
+
+var rob = uuid.UUID{1}
+
+func setup(t *testing.T, content string, interval time.Duration) (note.NotesService, *collab.Hub, note.Note) {
+	t.Helper()
+	ns := note.NewNotesService(notememory.MustNewRepo(nil), stubUserService{})
+	n, err := ns.Create(context.Background(), note.UpdateNote{Title: note.NewTitle("Groceries"), Content: note.NewContent(content), UserID: rob})
+	require.NoError(t, err)
+	return ns, collab.NewHub(ns, interval), n
+}
+
+// racingNoteService runs race next to the first update of a note, once the
+// note was read.
+type racingNoteService struct {
+	note.Service
+	race func()
+	done chan struct{}
+	once *sync.Once
+}
+
+func (s racingNoteService) UpdateFunc(ctx context.Context, noteID uuid.UUID, fn func(note.Note) (note.UpdateNote, error)) (note.Note, error) {
+	return s.Service.UpdateFunc(ctx, noteID, func(n note.Note) (note.UpdateNote, error) {
+		s.once.Do(func() {
+			go func() {
+				defer close(s.done)
+				s.race()
+			}()
+			time.Sleep(20 * time.Millisecond)
+		})
+		return fn(n)
+	})
+}
+
+// client is a client of a session with its replica of the document.
+type client struct {
+	*collab.Client
+	doc *crdt.RGA
+}
+
+func join(t *testing.T, h *collab.Hub, noteID uuid.UUID) client {
+	t.Helper()
+	c, err := h.Join(context.Background(), noteID, rob)
+	require.NoError(t, err)
+
+	init := next(t, c)
+	require.Equal(t, collab.UpdateInit, init.Type)
+	assert.Equal(t, c.Site, init.Site)
+
+	doc := crdt.New()
+	var after *crdt.ID
+	for _, e := range init.Doc {
+		require.NoError(t, doc.Apply(crdt.Op{Type: crdt.OpInsert, ID: e.ID, After: after, Char: e.Char}))
+		if e.Deleted {
+			require.NoError(t, doc.Apply(crdt.Op{Type: crdt.OpDelete, ID: e.ID}))
+		}
+		id := e.ID
+		after = &id
+	}
+	assert.Equal(t, init.Clock, doc.Clock())
+	return client{Client: c, doc: doc}
+}
+
+// insert inserts text after the n-th character of the replica and sends it.
+func (c client) insert(t *testing.T, n int, text string) {
+	t.Helper()
+	after := crdt.ID{}
+	if n > 0 {
+		after = c.doc.Visible()[n-1]
+	}
+	require.NoError(t, c.Apply(c.doc.Insert(c.Site, after, text)))
+}
+
+// receive applies the next update, which must have operations.
+func (c client) receive(t *testing.T) collab.Update {
+	t.Helper()
+	u := next(t, c.Client)
+	require.Equal(t, collab.UpdateOps, u.Type)
+	for _, op := range u.Ops {
+		require.NoError(t, c.doc.Apply(op))
+	}
+	return u
+}
+
+func next(t *testing.T, c *collab.Client) collab.Update {
+	t.Helper()
+	select {
+	case u, ok := <-c.Updates():
+		require.True(t, ok, "client closed: %v", c.Err())
+		return u
+	case <-time.After(time.Second):
+		require.FailNow(t, "no update")
+		return collab.Update{}
+	}
+}
+
+func content(t *testing.T, ns note.NotesService, noteID uuid.UUID) string {
+	t.Helper()
+	n, err := ns.QueryByID(context.Background(), noteID)
+	require.NoError(t, err)
+	return n.Content.String()
+}
+
+func TestHub_Session(t *testing.T) {
+	ctx := context.Background()
+	ns, h, n := setup(t, "milk", time.Hour)
+
+	alice := join(t, h, n.ID)
+	assert.Equal(t, "milk", alice.doc.Text())
+	bob := join(t, h, n.ID)
+	assert.NotEqual(t, alice.Site, bob.Site)
+
+	joined := next(t, alice.Client)
+	assert.Equal(t, collab.Update{Type: collab.UpdateJoin, Site: bob.Site, UserID: rob}, joined)
+
+	t.Run("Operations are sent to the other clients", func(t *testing.T) {
+		alice.insert(t, 4, ", eggs")
+		bob.insert(t, 0, "- ")
+
+		u := bob.receive(t)
+		assert.Equal(t, alice.Site, u.Site)
+		alice.receive(t)
+
+		assert.Equal(t, "- milk, eggs", alice.doc.Text())
+		assert.Equal(t, alice.doc.Text(), bob.doc.Text())
+	})
+
+	t.Run("Cursors are sent to the other clients", func(t *testing.T) {
+		cur := collab.Cursor{Anchor: alice.doc.Visible()[1], Head: alice.doc.Visible()[5]}
+		alice.MoveCursor(cur)
+
+		u := next(t, bob.Client)
+		assert.Equal(t, collab.Update{Type: collab.UpdateCursor, Site: alice.Site, UserID: rob, Cursor: &cur}, u)
+
+		carol := join(t, h, n.ID)
+		next(t, alice.Client)
+		next(t, bob.Client)
+		require.NoError(t, carol.Leave(ctx))
+		assert.Equal(t, collab.UpdateLeave, next(t, alice.Client).Type)
+		assert.Equal(t, collab.UpdateLeave, next(t, bob.Client).Type)
+	})
+
+	t.Run("Invalid operations are rejected, the ones before are kept", func(t *testing.T) {
+		ops := alice.doc.Insert(alice.Site, crdt.ID{}, "!")
+		foreign := crdt.Op{Type: crdt.OpInsert, ID: crdt.ID{Clock: 100, Site: bob.Site}, Char: "?"}
+		err := alice.Apply(append(ops, foreign))
+		assert.ErrorIs(t, err, crdt.ErrInvalidOp)
+
+		unknown := crdt.Op{Type: crdt.OpDelete, ID: crdt.ID{Clock: 100, Site: "nobody"}}
+		assert.ErrorIs(t, alice.Apply([]crdt.Op{unknown}), crdt.ErrUnknownElement)
+
+		u := bob.receive(t)
+		assert.Equal(t, ops, u.Ops)
+		assert.Equal(t, "!- milk, eggs", bob.doc.Text())
+	})
+
+	t.Run("The last client to leave saves the content", func(t *testing.T) {
+		require.NoError(t, alice.Leave(ctx))
+		assert.Equal(t, collab.Update{Type: collab.UpdateLeave, Site: alice.Site, UserID: rob}, next(t, bob.Client))
+		assert.Equal(t, "milk", content(t, ns, n.ID))
+
+		require.NoError(t, bob.Leave(ctx))
+		assert.Equal(t, "!- milk, eggs", content(t, ns, n.ID))
+		_, ok := <-bob.Updates()
+		assert.False(t, ok)
+		assert.NoError(t, bob.Err())
+
+		assert.ErrorIs(t, alice.Apply(nil), collab.ErrClosed)
+	})
+
+	t.Run("A new session starts with the saved content", func(t *testing.T) {
+		c := join(t, h, n.ID)
+		assert.Equal(t, "!- milk, eggs", c.doc.Text())
+		require.NoError(t, c.Leave(ctx))
+	})
+}
+
+func TestHub_Snapshots(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("The content is saved periodically", func(t *testing.T) {
+		ns, h, n := setup(t, "milk", 10*time.Millisecond)
+		c := join(t, h, n.ID)
+		defer c.Leave(ctx)
+
+		c.insert(t, 4, " and eggs")
+		assert.Eventually(t, func() bool { return content(t, ns, n.ID) == "milk and eggs" }, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("Changes made outside the session are merged", func(t *testing.T) {
+		ns, h, n := setup(t, "milk\neggs", time.Hour)
+		alice := join(t, h, n.ID)
+		alice.insert(t, 0, "- ")
+
+		_, err := ns.Update(ctx, n, note.UpdateNote{Content: note.NewContent("milk\nbread\neggs")})
+		require.NoError(t, err)
+
+		require.NoError(t, alice.Leave(ctx))
+		assert.Equal(t, "- milk\nbread\neggs", content(t, ns, n.ID))
+	})
+
+	t.Run("Changes made while saving wait for the save", func(t *testing.T) {
+		repo := notememory.MustNewRepo(nil)
+		ns := note.NewNotesService(repo, stubUserService{}, note.WithTransactions(transaction.NewMemory(repo)))
+		n, err := ns.Create(ctx, note.UpdateNote{Title: note.NewTitle("Groceries"), Content: note.NewContent("milk\neggs"), UserID: rob})
+		require.NoError(t, err)
+
+		racing := racingNoteService{Service: ns, done: make(chan struct{}), once: &sync.Once{}, race: func() {
+			_, err := ns.Update(ctx, n, note.UpdateNote{Content: note.NewContent("milk\nbread\neggs")})
+			assert.NoError(t, err)
+		}}
+		h := collab.NewHub(racing, time.Hour)
+		alice := join(t, h, n.ID)
+		alice.insert(t, 0, "- ")
+
+		require.NoError(t, h.Close(ctx))
+		select {
+		case <-racing.done:
+		case <-time.After(time.Second):
+			require.FailNow(t, "the session didn't update the note")
+		}
+		assert.Equal(t, "milk\nbread\neggs", content(t, ns, n.ID), "the change is saved after the session, not overwritten")
+	})
+
+	t.Run("Clients are closed when the note is deleted", func(t *testing.T) {
+		ns, h, n := setup(t, "milk", 10*time.Millisecond)
+		c := join(t, h, n.ID)
+		require.NoError(t, ns.Delete(ctx, n.ID))
+
+		select {
+		case _, ok := <-c.Updates():
+			assert.False(t, ok)
+		case <-time.After(time.Second):
+			require.FailNow(t, "client not closed")
+		}
+		assert.ErrorIs(t, c.Err(), collab.ErrNoteDeleted)
+		assert.NoError(t, c.Leave(ctx))
+
+		_, err := h.Join(ctx, n.ID, rob)
+		assert.ErrorIs(t, err, note.ErrNoteNotFound)
+	})
+}
+
+func TestHub_Close(t *testing.T) {
+	ctx := context.Background()
+	ns, h, n := setup(t, "milk", time.Hour)
+	c := join(t, h, n.ID)
+	c.insert(t, 0, "oat ")
+
+	require.NoError(t, h.Close(ctx))
+	assert.Equal(t, "oat milk", content(t, ns, n.ID))
+	_, ok := <-c.Updates()
+	assert.False(t, ok)
+	assert.ErrorIs(t, c.Err(), collab.ErrClosed)
+	assert.NoError(t, c.Leave(ctx))
+
+	_, err := h.Join(ctx, n.ID, rob)
+	assert.ErrorIs(t, err, collab.ErrClosed)
+}
+
+func TestHub_Lagging(t *testing.T) {
+	ctx := context.Background()
+	_, h, n := setup(t, "", time.Hour)
+	slow := join(t, h, n.ID)
+	fast := join(t, h, n.ID)
+	defer fast.Leave(ctx)
+
+	for i := 0; i < 300; i++ {
+		fast.insert(t, 0, "a")
+	}
+
+	for range slow.Updates() {
+	}
+	assert.ErrorIs(t, slow.Err(), collab.ErrLagging)
+	assert.NoError(t, slow.Leave(ctx))
+}
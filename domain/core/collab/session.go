@@ -0,0 +1,354 @@
+package collab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/Keisn1/note-taking-app/foundation/crdt"
+	"github.com/google/uuid"
+)
+
+// updateBuffer is the number of updates a client can fall behind before it
+// is closed with ErrLagging.
+const updateBuffer = 256
+
+type session struct {
+	hub    *Hub
+	noteID uuid.UUID
+
+	// saveMu serializes saves, mu guards the rest.
+	saveMu sync.Mutex
+	mu     sync.Mutex
+
+	doc *crdt.RGA
+	// saved is the content of the note as of the last save and savedIDs the
+	// elements of its characters, to merge changes made outside the session.
+	saved    string
+	savedIDs []crdt.ID
+
+	clients map[string]*Client
+	closing bool
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+func newSession(h *Hub, n note.Note) *session {
+	content := n.Content.String()
+	doc := crdt.FromText(ServerSite, content)
+	return &session{
+		hub:      h,
+		noteID:   n.ID,
+		doc:      doc,
+		saved:    content,
+		savedIDs: doc.Visible(),
+		clients:  map[string]*Client{},
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// join adds a client, ok is false if the session is ending.
+func (s *session) join(userID uuid.UUID) (_ *Client, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closing {
+		return nil, false
+	}
+
+	site := newSite()
+	for s.clients[site] != nil {
+		site = newSite()
+	}
+	c := &Client{Site: site, UserID: userID, s: s, updates: make(chan Update, updateBuffer)}
+
+	peers := make([]Peer, 0, len(s.clients))
+	for _, o := range s.clients {
+		peers = append(peers, Peer{Site: o.Site, UserID: o.UserID, Cursor: o.cursor})
+	}
+	slices.SortFunc(peers, func(a, b Peer) int { return strings.Compare(a.Site, b.Site) })
+
+	c.updates <- Update{Type: UpdateInit, Site: site, UserID: userID, Clock: s.doc.Clock(), Doc: s.doc.Elements(), Peers: peers}
+	s.broadcast(nil, Update{Type: UpdateJoin, Site: site, UserID: userID})
+	s.clients[site] = c
+	return c, true
+}
+
+func newSite() string {
+	return strings.ReplaceAll(uuid.NewString(), "-", "")[:12]
+}
+
+// broadcast sends u to all clients but from. Clients whose buffer is full
+// are removed with ErrLagging. Must be called with s.mu held.
+func (s *session) broadcast(from *Client, u Update) {
+	var lagging []*Client
+	for _, c := range s.clients {
+		if c == from {
+			continue
+		}
+		select {
+		case c.updates <- u:
+		default:
+			lagging = append(lagging, c)
+		}
+	}
+	for _, c := range lagging {
+		s.remove(c, ErrLagging)
+	}
+}
+
+// remove closes the client with err and tells the others that it left. Must
+// be called with s.mu held.
+func (s *session) remove(c *Client, err error) {
+	if s.clients[c.Site] != c {
+		return
+	}
+	delete(s.clients, c.Site)
+	c.err = err
+	close(c.updates)
+	s.broadcast(nil, Update{Type: UpdateLeave, Site: c.Site, UserID: c.UserID})
+}
+
+// run saves the session every interval until it ends. It ends the session
+// when the note was deleted.
+func (s *session) run(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-t.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), saveTimeout)
+		err := s.save(ctx)
+		cancel()
+		switch {
+		case errors.Is(err, note.ErrNoteNotFound):
+			s.close(context.Background(), ErrNoteDeleted, false)
+			return
+		case err != nil:
+			s.mu.Lock()
+			s.broadcast(nil, Update{Type: UpdateError, Err: err})
+			s.mu.Unlock()
+		}
+	}
+}
+
+// close ends the session, its clients are closed with cause. The content is
+// saved if save is set.
+func (s *session) close(ctx context.Context, cause error, save bool) error {
+	s.mu.Lock()
+	if s.closing {
+		s.mu.Unlock()
+		select {
+		case <-s.done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	s.closing = true
+	close(s.stop)
+	for _, c := range s.clients {
+		s.remove(c, cause)
+	}
+	s.mu.Unlock()
+
+	return s.finish(ctx, save)
+}
+
+// finish saves the content if save is set and removes the session from the
+// hub. The session must be closing.
+func (s *session) finish(ctx context.Context, save bool) error {
+	var err error
+	if save {
+		err = s.save(ctx)
+	}
+	s.hub.remove(s)
+	close(s.done)
+	return err
+}
+
+// errUnchanged ends a save without writing the note.
+var errUnchanged = errors.New("unchanged")
+
+// save merges changes made to the note since the last save into the
+// document and writes the document to the note if it differs, which also
+// notices when the note was deleted. Reading, merging and writing are one
+// update of the note, changes made meanwhile wait for it and are merged at
+// the next save.
+func (s *session) save(ctx context.Context) error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	var (
+		text string
+		ids  []crdt.ID
+	)
+	_, err := s.hub.noteSvc.UpdateFunc(ctx, s.noteID, func(n note.Note) (note.UpdateNote, error) {
+		stored := n.Content.String()
+
+		s.mu.Lock()
+		if stored != s.saved {
+			ops := s.merge(stored)
+			s.broadcast(nil, Update{Type: UpdateOps, Site: ServerSite, Ops: ops})
+		}
+		text, ids = s.doc.Text(), s.doc.Visible()
+		s.mu.Unlock()
+
+		if text == stored {
+			return note.UpdateNote{}, errUnchanged
+		}
+		return note.UpdateNote{Content: note.NewContent(text)}, nil
+	})
+	if err != nil && !errors.Is(err, errUnchanged) {
+		return fmt.Errorf("save: [%s]: %w", s.noteID, err)
+	}
+
+	s.mu.Lock()
+	s.saved, s.savedIDs = text, ids
+	s.mu.Unlock()
+	return nil
+}
+
+// merge applies the difference between the saved content and stored, a
+// single replaced range, to the document and returns the operations. Must
+// be called with s.mu held.
+func (s *session) merge(stored string) []crdt.Op {
+	old, cur := []rune(s.saved), []rune(stored)
+
+	prefix := 0
+	for prefix < len(old) && prefix < len(cur) && old[prefix] == cur[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(cur)-prefix && old[len(old)-1-suffix] == cur[len(cur)-1-suffix] {
+		suffix++
+	}
+
+	var ops []crdt.Op
+	for _, id := range s.savedIDs[prefix : len(old)-suffix] {
+		// Can't fail, the saved elements are in the document.
+		op, _ := s.doc.Delete(id)
+		ops = append(ops, op)
+	}
+	after := crdt.ID{}
+	if prefix > 0 {
+		after = s.savedIDs[prefix-1]
+	}
+	inserted := s.doc.Insert(ServerSite, after, string(cur[prefix:len(cur)-suffix]))
+	ops = append(ops, inserted...)
+
+	ids := slices.Clone(s.savedIDs[:prefix])
+	for _, op := range inserted {
+		ids = append(ids, op.ID)
+	}
+	s.saved, s.savedIDs = stored, append(ids, s.savedIDs[len(old)-suffix:]...)
+	return ops
+}
+
+// Client is a client of a session.
+type Client struct {
+	Site   string
+	UserID uuid.UUID
+
+	s       *session
+	cursor  *Cursor
+	updates chan Update
+	err     error
+}
+
+// Updates returns the updates for the client. The channel is closed when the
+// client leaves or is closed, see Err.
+func (c *Client) Updates() <-chan Update {
+	return c.updates
+}
+
+// Err returns why the client was closed, nil if it left or is still open.
+func (c *Client) Err() error {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+	return c.err
+}
+
+// Apply applies operations of the client to the document and sends them to
+// the other clients. Inserts must use the site of the client. It stops at
+// the first operation that fails, the ones before it are kept.
+func (c *Client) Apply(ops []crdt.Op) error {
+	s := c.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.clients[c.Site] != c {
+		return fmt.Errorf("apply: %w", ErrClosed)
+	}
+
+	var err error
+	applied := 0
+	for _, op := range ops {
+		if op.Type == crdt.OpInsert {
+			if op.ID.Site != c.Site {
+				err = fmt.Errorf("insert: [%s]: %w: site must be %s", op.ID, crdt.ErrInvalidOp, c.Site)
+				break
+			}
+			if s.doc.Size()+len(op.Char) > note.MaxContentLength {
+				err = fmt.Errorf("insert: [%s]: %w", op.ID, ErrTooLarge)
+				break
+			}
+		}
+		if err = s.doc.Apply(op); err != nil {
+			break
+		}
+		applied++
+	}
+
+	if applied > 0 {
+		s.broadcast(c, Update{Type: UpdateOps, Site: c.Site, UserID: c.UserID, Ops: ops[:applied]})
+	}
+	if err != nil {
+		return fmt.Errorf("apply: %w", err)
+	}
+	return nil
+}
+
+// MoveCursor sets the cursor of the client and sends it to the other
+// clients.
+func (c *Client) MoveCursor(cur Cursor) {
+	s := c.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.clients[c.Site] != c {
+		return
+	}
+	c.cursor = &cur
+	s.broadcast(c, Update{Type: UpdateCursor, Site: c.Site, UserID: c.UserID, Cursor: &cur})
+}
+
+// Leave removes the client from the session. When the last client leaves,
+// the content is saved and the session ends.
+func (c *Client) Leave(ctx context.Context) error {
+	s := c.s
+	s.mu.Lock()
+	s.remove(c, nil)
+	last := len(s.clients) == 0 && !s.closing
+	if last {
+		s.closing = true
+		close(s.stop)
+	}
+	s.mu.Unlock()
+
+	if !last {
+		return nil
+	}
+	return s.finish(ctx, true)
+}
@@ -0,0 +1,187 @@
+package link
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/Keisn1/note-taking-app/foundation/tracing"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MaxHubs is the number of hubs reported in GraphMetrics.
+const MaxHubs = 10
+
+var ErrRootNotFound = errors.New("the root note was not found")
+
+// GraphFilter restricts a graph to the notes within Depth links of Root, in
+// either direction. A zero Root selects all notes.
+type GraphFilter struct {
+	Root  uuid.UUID
+	Depth int
+}
+
+// Graph is the link graph of the notes of a user. Links of a note to itself
+// and broken links are left out, several links between the same notes are one
+// edge.
+type Graph struct {
+	Nodes   []Node
+	Edges   []Edge
+	Metrics GraphMetrics
+}
+
+// Node is a note. The degrees count all links of the note, also those to
+// notes outside of a filtered graph.
+type Node struct {
+	Note     note.Note
+	LinksIn  int
+	LinksOut int
+	Orphan   bool
+	Distance int // from the root, 0 without one
+}
+
+type Edge struct {
+	SourceID uuid.UUID
+	TargetID uuid.UUID
+}
+
+// GraphMetrics are computed over the nodes of the graph. Orphans are notes
+// without any link from or to another note, hubs the notes with the most
+// incoming links.
+type GraphMetrics struct {
+	Orphans []uuid.UUID
+	Hubs    []uuid.UUID
+}
+
+// Graph returns the link graph of the notes of the user, nodes ordered by
+// title.
+func (ls LinkService) Graph(ctx context.Context, userID uuid.UUID, f GraphFilter) (_ Graph, err error) {
+	ctx, span := tracer.Start(ctx, "link.Graph", trace.WithAttributes(attribute.String("user.id", userID.String())))
+	defer tracing.End(span, &err)
+
+	var notes []note.Note
+	if err := ls.noteRepo.IterateByUserID(ctx, userID, func(n note.Note) error {
+		notes = append(notes, n)
+		return nil
+	}); err != nil {
+		return Graph{}, fmt.Errorf("graph: [%s]: %w", userID, err)
+	}
+	links, err := ls.repo.QueryByUserID(ctx, userID)
+	if err != nil {
+		return Graph{}, fmt.Errorf("graph: [%s]: %w", userID, err)
+	}
+
+	g := buildGraph(notes, links)
+	if f.Root != uuid.Nil {
+		if g, err = g.around(f.Root, f.Depth); err != nil {
+			return Graph{}, fmt.Errorf("graph: [%s]: %w", userID, err)
+		}
+	}
+	g.Metrics = g.metrics()
+	return g, nil
+}
+
+func buildGraph(notes []note.Note, links []Link) Graph {
+	index := make(map[uuid.UUID]int, len(notes))
+	g := Graph{Nodes: make([]Node, len(notes)), Edges: []Edge{}}
+	for i, n := range notes {
+		g.Nodes[i] = Node{Note: n}
+		index[n.ID] = i
+	}
+
+	seen := map[Edge]bool{}
+	for _, l := range links {
+		e := Edge{SourceID: l.SourceID, TargetID: l.TargetID}
+		if l.Broken() || e.SourceID == e.TargetID || seen[e] {
+			continue
+		}
+		si, sok := index[e.SourceID]
+		ti, tok := index[e.TargetID]
+		if !sok || !tok {
+			continue
+		}
+		seen[e] = true
+		g.Edges = append(g.Edges, e)
+		g.Nodes[si].LinksOut++
+		g.Nodes[ti].LinksIn++
+	}
+	slices.SortFunc(g.Edges, func(a, b Edge) int {
+		return cmp.Or(cmp.Compare(index[a.SourceID], index[b.SourceID]), cmp.Compare(index[a.TargetID], index[b.TargetID]))
+	})
+
+	for i := range g.Nodes {
+		g.Nodes[i].Orphan = g.Nodes[i].LinksIn == 0 && g.Nodes[i].LinksOut == 0
+	}
+	return g
+}
+
+// around returns the subgraph of the nodes within depth edges of root.
+func (g Graph) around(root uuid.UUID, depth int) (Graph, error) {
+	neighbours := map[uuid.UUID][]uuid.UUID{}
+	for _, e := range g.Edges {
+		neighbours[e.SourceID] = append(neighbours[e.SourceID], e.TargetID)
+		neighbours[e.TargetID] = append(neighbours[e.TargetID], e.SourceID)
+	}
+
+	if !slices.ContainsFunc(g.Nodes, func(n Node) bool { return n.Note.ID == root }) {
+		return Graph{}, ErrRootNotFound
+	}
+
+	distance := map[uuid.UUID]int{root: 0}
+	queue := []uuid.UUID{root}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if distance[id] == depth {
+			continue
+		}
+		for _, nb := range neighbours[id] {
+			if _, ok := distance[nb]; !ok {
+				distance[nb] = distance[id] + 1
+				queue = append(queue, nb)
+			}
+		}
+	}
+
+	sub := Graph{Nodes: []Node{}, Edges: []Edge{}}
+	for _, n := range g.Nodes {
+		if d, ok := distance[n.Note.ID]; ok {
+			n.Distance = d
+			sub.Nodes = append(sub.Nodes, n)
+		}
+	}
+	for _, e := range g.Edges {
+		_, sok := distance[e.SourceID]
+		_, tok := distance[e.TargetID]
+		if sok && tok {
+			sub.Edges = append(sub.Edges, e)
+		}
+	}
+	return sub, nil
+}
+
+func (g Graph) metrics() GraphMetrics {
+	m := GraphMetrics{Orphans: []uuid.UUID{}, Hubs: []uuid.UUID{}}
+
+	var hubs []Node
+	for _, n := range g.Nodes {
+		if n.Orphan {
+			m.Orphans = append(m.Orphans, n.Note.ID)
+		}
+		if n.LinksIn > 0 {
+			hubs = append(hubs, n)
+		}
+	}
+
+	// the nodes are ordered by title, a stable sort keeps that for ties
+	slices.SortStableFunc(hubs, func(a, b Node) int { return cmp.Compare(b.LinksIn, a.LinksIn) })
+	for _, n := range hubs[:min(len(hubs), MaxHubs)] {
+		m.Hubs = append(m.Hubs, n.Note.ID)
+	}
+	return m
+}
@@ -0,0 +1,71 @@
+package link_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Keisn1/note-taking-app/domain/core/link"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinkService_Graph(t *testing.T) {
+	ctx := context.Background()
+	rob := uuid.UUID{1}
+	ns, ls := setup(t)
+
+	// a -> b -> c <- d, e alone, b links twice to c and once to itself
+	c := create(t, ns, rob, "c", "")
+	b := create(t, ns, rob, "b", "[[c]] [["+c.ID.String()+"]] [[b]] [[missing]]")
+	a := create(t, ns, rob, "a", "[[b]]")
+	d := create(t, ns, rob, "d", "[[c]]")
+	e := create(t, ns, rob, "e", "")
+	create(t, ns, uuid.UUID{2}, "other", "[[a]]")
+
+	titles := func(g link.Graph) []string {
+		var ret []string
+		for _, n := range g.Nodes {
+			ret = append(ret, n.Note.Title.String())
+		}
+		return ret
+	}
+
+	t.Run("All notes of the user", func(t *testing.T) {
+		g, err := ls.Graph(ctx, rob, link.GraphFilter{})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"a", "b", "c", "d", "e"}, titles(g))
+		assert.Equal(t, []link.Edge{
+			{SourceID: a.ID, TargetID: b.ID},
+			{SourceID: b.ID, TargetID: c.ID},
+			{SourceID: d.ID, TargetID: c.ID},
+		}, g.Edges)
+		assert.Equal(t, 2, g.Nodes[2].LinksIn)
+		assert.Equal(t, 1, g.Nodes[1].LinksOut)
+		assert.Equal(t, []uuid.UUID{e.ID}, g.Metrics.Orphans)
+		assert.Equal(t, []uuid.UUID{c.ID, b.ID}, g.Metrics.Hubs)
+	})
+
+	t.Run("Notes around a root", func(t *testing.T) {
+		g, err := ls.Graph(ctx, rob, link.GraphFilter{Root: b.ID, Depth: 1})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b", "c"}, titles(g))
+		assert.Len(t, g.Edges, 2)
+		assert.Equal(t, []int{1, 0, 1}, []int{g.Nodes[0].Distance, g.Nodes[1].Distance, g.Nodes[2].Distance})
+
+		g, err = ls.Graph(ctx, rob, link.GraphFilter{Root: a.ID, Depth: 3})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b", "c", "d"}, titles(g))
+
+		g, err = ls.Graph(ctx, rob, link.GraphFilter{Root: e.ID, Depth: 3})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"e"}, titles(g))
+		assert.Empty(t, g.Edges)
+	})
+
+	t.Run("Root of another user", func(t *testing.T) {
+		_, err := ls.Graph(ctx, uuid.UUID{2}, link.GraphFilter{Root: a.ID, Depth: 1})
+		assert.ErrorIs(t, err, link.ErrRootNotFound)
+	})
+}
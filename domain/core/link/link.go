@@ -0,0 +1,66 @@
+// Package link keeps track of the wiki links between notes. A link is
+// written as [[Title]] or [[note id]] in the content of a note, optionally
+// with a label: [[Title|label]].
+package link
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// MaxLinks is the number of distinct targets of a note that are tracked,
+// further links are ignored.
+const MaxLinks = 1000
+
+var linkRe = regexp.MustCompile(`\[\[([^\[\]|\n]+)(\|[^\[\]\n]*)?\]\]`)
+
+type Link struct {
+	SourceID uuid.UUID
+	UserID   uuid.UUID
+	// Target is the title or id as written between the brackets.
+	Target string
+	// TargetID is the note the link points to, uuid.Nil if no note of the
+	// user matches Target.
+	TargetID uuid.UUID
+}
+
+func (l Link) Broken() bool { return l.TargetID == uuid.Nil }
+
+// Parse returns the distinct targets of the links in content, in the order
+// of their first occurrence. Targets that differ only in case are the same.
+func Parse(content string) []string {
+	var targets []string
+	seen := map[string]bool{}
+	for _, m := range linkRe.FindAllStringSubmatch(content, -1) {
+		target := strings.TrimSpace(m[1])
+		key := strings.ToLower(target)
+		if target == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		targets = append(targets, target)
+		if len(targets) == MaxLinks {
+			break
+		}
+	}
+	return targets
+}
+
+// Rewrite replaces the target of the links to from in content by to. Labels
+// are kept.
+func Rewrite(content, from, to string) string {
+	return linkRe.ReplaceAllStringFunc(content, func(s string) string {
+		m := linkRe.FindStringSubmatch(s)
+		if !strings.EqualFold(strings.TrimSpace(m[1]), from) {
+			return s
+		}
+		return "[[" + to + m[2] + "]]"
+	})
+}
+
+// linkable reports whether title can be written as target of a link.
+func linkable(title string) bool {
+	return title != "" && title == strings.TrimSpace(title) && !strings.ContainsAny(title, "[]|\n")
+}
@@ -0,0 +1,260 @@
+package link
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/Keisn1/note-taking-app/foundation/tracing"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/Keisn1/note-taking-app/domain/core/link")
+
+// Target is a link together with the note it points to, which is empty if
+// the link is broken.
+type Target struct {
+	Link
+	Note note.Note
+}
+
+// Source is a note that links to another one.
+type Source struct {
+	Link
+	Note note.Note
+}
+
+type Service interface {
+	Links(ctx context.Context, n note.Note) ([]Target, error)
+	Backlinks(ctx context.Context, n note.Note) ([]Source, error)
+	Broken(ctx context.Context, userID uuid.UUID) ([]Source, error)
+	Graph(ctx context.Context, userID uuid.UUID, f GraphFilter) (Graph, error)
+	Sync(ctx context.Context, old, n note.Note) ([]note.Note, error)
+	DeleteByNoteID(ctx context.Context, noteID uuid.UUID) error
+}
+
+// LinkService reads and rewrites the notes through the note.Repo, not the
+// note.Service, so that its changes don't trigger Sync again. Sync returns the
+// notes it rewrote, the note service sends their events.
+type LinkService struct {
+	repo     Repo
+	noteRepo note.Repo
+}
+
+func NewSvc(repo Repo, noteRepo note.Repo) LinkService {
+	return LinkService{repo: repo, noteRepo: noteRepo}
+}
+
+// Links returns the links of the note in the order they appear.
+func (ls LinkService) Links(ctx context.Context, n note.Note) (_ []Target, err error) {
+	ctx, span := tracer.Start(ctx, "link.Links", trace.WithAttributes(attribute.String("note.id", n.ID.String())))
+	defer tracing.End(span, &err)
+
+	links, err := ls.repo.QueryBySourceID(ctx, n.ID)
+	if err != nil {
+		return nil, fmt.Errorf("links: [%s]: %w", n.ID, err)
+	}
+
+	targets := make([]Target, 0, len(links))
+	for _, l := range links {
+		t := Target{Link: l}
+		if !l.Broken() {
+			if t.Note, err = ls.noteRepo.QueryByID(ctx, l.TargetID); err != nil {
+				return nil, fmt.Errorf("links: [%s]: %w", n.ID, err)
+			}
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+// Backlinks returns the notes that link to the note, ordered by title.
+func (ls LinkService) Backlinks(ctx context.Context, n note.Note) (_ []Source, err error) {
+	ctx, span := tracer.Start(ctx, "link.Backlinks", trace.WithAttributes(attribute.String("note.id", n.ID.String())))
+	defer tracing.End(span, &err)
+
+	links, err := ls.repo.QueryByTargetID(ctx, n.ID)
+	if err != nil {
+		return nil, fmt.Errorf("backlinks: [%s]: %w", n.ID, err)
+	}
+	sources, err := ls.sources(ctx, links)
+	if err != nil {
+		return nil, fmt.Errorf("backlinks: [%s]: %w", n.ID, err)
+	}
+	return sources, nil
+}
+
+// Broken returns the broken links of the notes of the user, ordered by the
+// title of the note they are in.
+func (ls LinkService) Broken(ctx context.Context, userID uuid.UUID) (_ []Source, err error) {
+	ctx, span := tracer.Start(ctx, "link.Broken", trace.WithAttributes(attribute.String("user.id", userID.String())))
+	defer tracing.End(span, &err)
+
+	links, err := ls.repo.QueryBrokenByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("broken: [%s]: %w", userID, err)
+	}
+	sources, err := ls.sources(ctx, links)
+	if err != nil {
+		return nil, fmt.Errorf("broken: [%s]: %w", userID, err)
+	}
+	return sources, nil
+}
+
+func (ls LinkService) sources(ctx context.Context, links []Link) ([]Source, error) {
+	sources := make([]Source, 0, len(links))
+	for _, l := range links {
+		n, err := ls.noteRepo.QueryByID(ctx, l.SourceID)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, Source{Link: l, Note: n})
+	}
+	slices.SortStableFunc(sources, func(a, b Source) int {
+		return cmp.Or(
+			cmp.Compare(a.Note.Title.String(), b.Note.Title.String()),
+			cmp.Compare(a.Note.ID.String(), b.Note.ID.String()),
+		)
+	})
+	return sources, nil
+}
+
+// Sync updates the links after the note was created or updated, see
+// note.WithSaveHook. It stores the links of the content, resolves broken
+// links to the title of the note and, if the title changed, rewrites the
+// links to the note in the other notes, which it returns.
+func (ls LinkService) Sync(ctx context.Context, old, n note.Note) (_ []note.Note, err error) {
+	ctx, span := tracer.Start(ctx, "link.Sync", trace.WithAttributes(attribute.String("note.id", n.ID.String())))
+	defer tracing.End(span, &err)
+
+	created := old.ID == uuid.Nil
+	renamed := !created && old.Title.String() != n.Title.String()
+
+	if created || renamed || old.Content.String() != n.Content.String() {
+		if err := ls.replace(ctx, n); err != nil {
+			return nil, fmt.Errorf("sync: [%s]: %w", n.ID, err)
+		}
+	}
+	var rewritten []note.Note
+	if renamed {
+		if rewritten, err = ls.rewrite(ctx, old, n); err != nil {
+			return nil, fmt.Errorf("sync: [%s]: %w", n.ID, err)
+		}
+	}
+	if created || renamed {
+		if err := ls.repo.Resolve(ctx, n.UserID, strings.TrimSpace(n.Title.String()), n.ID); err != nil {
+			return nil, fmt.Errorf("sync: [%s]: %w", n.ID, err)
+		}
+	}
+	return rewritten, nil
+}
+
+// replace stores the links of the content of the note.
+func (ls LinkService) replace(ctx context.Context, n note.Note) error {
+	targets := Parse(n.Content.String())
+
+	var byTitle map[string]uuid.UUID
+	links := make([]Link, 0, len(targets))
+	for _, target := range targets {
+		l := Link{SourceID: n.ID, UserID: n.UserID, Target: target}
+
+		if id, err := uuid.Parse(target); err == nil {
+			t, err := ls.noteRepo.QueryByID(ctx, id)
+			if err != nil && !errors.Is(err, note.ErrNoteNotFound) {
+				return err
+			}
+			if err == nil && t.UserID == n.UserID {
+				l.TargetID = t.ID
+			}
+		} else {
+			if byTitle == nil {
+				var err error
+				if byTitle, err = ls.titles(ctx, n.UserID); err != nil {
+					return err
+				}
+			}
+			l.TargetID = byTitle[strings.ToLower(target)]
+		}
+		links = append(links, l)
+	}
+
+	return ls.repo.Replace(ctx, n.ID, links)
+}
+
+// titles maps the lower case titles of the notes of the user to their ids.
+// Of several notes with the same title the first in title order wins.
+func (ls LinkService) titles(ctx context.Context, userID uuid.UUID) (map[string]uuid.UUID, error) {
+	titles := map[string]uuid.UUID{}
+	err := ls.noteRepo.IterateByUserID(ctx, userID, func(n note.Note) error {
+		key := strings.ToLower(strings.TrimSpace(n.Title.String()))
+		if _, ok := titles[key]; !ok {
+			titles[key] = n.ID
+		}
+		return nil
+	})
+	return titles, err
+}
+
+// rewrite changes the links by title to the renamed note in the other notes.
+// Titles that can't be written in a link are replaced by the id of the note.
+// Links of the note to itself are left alone, the caller has the note. The
+// other notes are read for update, so that a concurrent update of one isn't
+// overwritten, and skipped if they were deleted meanwhile.
+func (ls LinkService) rewrite(ctx context.Context, old, n note.Note) ([]note.Note, error) {
+	backlinks, err := ls.repo.QueryByTargetID(ctx, n.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	to := n.Title.String()
+	if !linkable(to) {
+		to = n.ID.String()
+	}
+
+	var rewritten []note.Note
+	for _, l := range backlinks {
+		if l.SourceID == n.ID || !strings.EqualFold(l.Target, strings.TrimSpace(old.Title.String())) {
+			continue
+		}
+
+		source, err := ls.noteRepo.QueryByIDForUpdate(ctx, l.SourceID)
+		if errors.Is(err, note.ErrNoteNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		content := Rewrite(source.Content.String(), l.Target, to)
+		if content == source.Content.String() {
+			continue
+		}
+		source.Content = note.NewContent(content)
+		if err := ls.noteRepo.Update(ctx, source); err != nil {
+			return nil, err
+		}
+		if err := ls.replace(ctx, source); err != nil {
+			return nil, err
+		}
+		rewritten = append(rewritten, source)
+	}
+	return rewritten, nil
+}
+
+// DeleteByNoteID deletes the links of the note and breaks the links to it,
+// see note.WithDeleteHook.
+func (ls LinkService) DeleteByNoteID(ctx context.Context, noteID uuid.UUID) (err error) {
+	ctx, span := tracer.Start(ctx, "link.DeleteByNoteID", trace.WithAttributes(attribute.String("note.id", noteID.String())))
+	defer tracing.End(span, &err)
+
+	if err := ls.repo.DeleteByNoteID(ctx, noteID); err != nil {
+		return fmt.Errorf("deleteByNoteID: [%s]: %w", noteID, err)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package link_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Keisn1/note-taking-app/domain/core/link"
+	linkmemory "github.com/Keisn1/note-taking-app/domain/core/link/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	notememory "github.com/Keisn1/note-taking-app/domain/core/note/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubUserService struct {
+	user.Service
+}
+
+func (stubUserService) QueryByID(ctx context.Context, userID uuid.UUID) (user.User, error) {
+	return user.User{ID: userID}, nil
+}
+
+func setup(t *testing.T) (note.NotesService, link.LinkService) {
+	t.Helper()
+	noteRepo := notememory.MustNewRepo(nil)
+	linkSvc := link.NewSvc(linkmemory.NewRepo(), noteRepo)
+	noteSvc := note.NewNotesService(noteRepo, stubUserService{}, note.WithSaveHook(linkSvc.Sync), note.WithDeleteHook(linkSvc.DeleteByNoteID))
+	return noteSvc, linkSvc
+}
+
+func create(t *testing.T, ns note.NotesService, userID uuid.UUID, title, content string) note.Note {
+	t.Helper()
+	n, err := ns.Create(context.Background(), note.UpdateNote{Title: note.NewTitle(title), Content: note.NewContent(content), UserID: userID})
+	require.NoError(t, err)
+	return n
+}
+
+func targets(t *testing.T, ls link.LinkService, n note.Note) []string {
+	t.Helper()
+	got, err := ls.Links(context.Background(), n)
+	require.NoError(t, err)
+	var ret []string
+	for _, l := range got {
+		if l.Broken() {
+			ret = append(ret, l.Target+" -> broken")
+			continue
+		}
+		ret = append(ret, l.Target+" -> "+l.Note.Title.String())
+	}
+	return ret
+}
+
+func TestLinkService(t *testing.T) {
+	ctx := context.Background()
+	rob, anna := uuid.UUID{1}, uuid.UUID{2}
+
+	t.Run("Links are resolved by title and id within the notes of the user", func(t *testing.T) {
+		ns, ls := setup(t)
+		groceries := create(t, ns, rob, "Groceries", "")
+		annas := create(t, ns, anna, "Recipes", "")
+		n := create(t, ns, rob, "Plan", "[[groceries]], [["+groceries.ID.String()+"|list]], [[Recipes]], [["+annas.ID.String()+"]]")
+
+		assert.Equal(t, []string{
+			"groceries -> Groceries",
+			groceries.ID.String() + " -> Groceries",
+			"Recipes -> broken",
+			annas.ID.String() + " -> broken",
+		}, targets(t, ls, n))
+	})
+
+	t.Run("Broken links are resolved when the note is created or renamed", func(t *testing.T) {
+		ns, ls := setup(t)
+		n := create(t, ns, rob, "Plan", "[[Groceries]] [[Recipes]]")
+		broken, err := ls.Broken(ctx, rob)
+		require.NoError(t, err)
+		assert.Len(t, broken, 2)
+
+		create(t, ns, rob, "groceries", "")
+		recipes := create(t, ns, rob, "Cooking", "")
+		_, err = ns.Update(ctx, recipes, note.UpdateNote{Title: note.NewTitle("Recipes")})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"Groceries -> groceries", "Recipes -> Recipes"}, targets(t, ls, n))
+		broken, err = ls.Broken(ctx, rob)
+		require.NoError(t, err)
+		assert.Empty(t, broken)
+	})
+
+	t.Run("Backlinks", func(t *testing.T) {
+		ns, ls := setup(t)
+		groceries := create(t, ns, rob, "Groceries", "")
+		create(t, ns, rob, "B", "[[Groceries]]")
+		create(t, ns, rob, "A", "[["+groceries.ID.String()+"]]")
+		create(t, ns, rob, "C", "[[Other]]")
+
+		got, err := ls.Backlinks(ctx, groceries)
+		require.NoError(t, err)
+		require.Len(t, got, 2)
+		assert.Equal(t, "A", got[0].Note.Title.String())
+		assert.Equal(t, "B", got[1].Note.Title.String())
+	})
+
+	t.Run("Renaming a note rewrites the links to it", func(t *testing.T) {
+		ns, ls := setup(t)
+		groceries := create(t, ns, rob, "Groceries", "")
+		byTitle := create(t, ns, rob, "Plan", "buy [[groceries|stuff]] and [[Groceries]], not [[Groceries 2]]")
+		byID := create(t, ns, rob, "Other", "[["+groceries.ID.String()+"]]")
+
+		renamed, err := ns.Update(ctx, groceries, note.UpdateNote{Title: note.NewTitle("Shopping")})
+		require.NoError(t, err)
+
+		got, err := ns.QueryByID(ctx, byTitle.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "buy [[Shopping|stuff]] and [[Shopping]], not [[Groceries 2]]", got.Content.String())
+		assert.Equal(t, []string{"Shopping -> Shopping", "Groceries 2 -> broken"}, targets(t, ls, got))
+
+		got, err = ns.QueryByID(ctx, byID.ID)
+		require.NoError(t, err)
+		assert.Equal(t, byID.Content.String(), got.Content.String())
+
+		_, err = ns.Update(ctx, renamed, note.UpdateNote{Title: note.NewTitle("Shopping [old]")})
+		require.NoError(t, err)
+		got, err = ns.QueryByID(ctx, byTitle.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "buy [["+groceries.ID.String()+"|stuff]] and [["+groceries.ID.String()+"]], not [[Groceries 2]]", got.Content.String())
+	})
+
+	t.Run("The rewritten notes are published", func(t *testing.T) {
+		noteRepo := notememory.MustNewRepo(nil)
+		ls := link.NewSvc(linkmemory.NewRepo(), noteRepo)
+		var events []note.Event
+		ns := note.NewNotesService(noteRepo, stubUserService{}, note.WithSaveHook(ls.Sync), note.WithPublisher(note.PublisherFunc(func(ctx context.Context, e note.Event) {
+			events = append(events, e)
+		})))
+		groceries := create(t, ns, rob, "Groceries", "")
+		plan := create(t, ns, rob, "Plan", "buy [[Groceries]]")
+		events = nil
+
+		renamed, err := ns.Update(ctx, groceries, note.UpdateNote{Title: note.NewTitle("Shopping")})
+		require.NoError(t, err)
+
+		plan.Content = note.NewContent("buy [[Shopping]]")
+		assert.Equal(t, []note.Event{
+			{Type: note.EventUpdated, Note: renamed},
+			{Type: note.EventUpdated, Note: plan},
+		}, events)
+	})
+
+	t.Run("Deleting a note breaks the links to it", func(t *testing.T) {
+		ns, ls := setup(t)
+		groceries := create(t, ns, rob, "Groceries", "[[Plan]]")
+		n := create(t, ns, rob, "Plan", "[[Groceries]]")
+
+		require.NoError(t, ns.Delete(ctx, groceries.ID))
+		assert.Equal(t, []string{"Groceries -> broken"}, targets(t, ls, n))
+
+		got, err := ls.Backlinks(ctx, n)
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+}
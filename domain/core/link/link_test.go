@@ -0,0 +1,36 @@
+package link_test
+
+import (
+	"testing"
+
+	"github.com/Keisn1/note-taking-app/domain/core/link"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{name: "no links", content: "just [text] and [[ ]]", want: nil},
+		{name: "title and id", content: "see [[Groceries]] and [[0b000000-0000-0000-0000-000000000000]]", want: []string{"Groceries", "0b000000-0000-0000-0000-000000000000"}},
+		{name: "label", content: "[[Groceries|the list]]", want: []string{"Groceries"}},
+		{name: "spaces are trimmed", content: "[[ Groceries ]]", want: []string{"Groceries"}},
+		{name: "duplicates ignoring case", content: "[[Groceries]] [[b]] [[groceries]]", want: []string{"Groceries", "b"}},
+		{name: "no line breaks", content: "[[Gro\nceries]]", want: nil},
+		{name: "nested brackets", content: "[[[Groceries]]]", want: []string{"Groceries"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, link.Parse(tc.content))
+		})
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	content := "[[Groceries]], [[groceries|the list]], [[Groceries 2]] and [[ GROCERIES ]]"
+	got := link.Rewrite(content, "groceries", "Shopping")
+	assert.Equal(t, "[[Shopping]], [[Shopping|the list]], [[Groceries 2]] and [[Shopping]]", got)
+}
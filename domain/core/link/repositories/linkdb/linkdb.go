@@ -0,0 +1,165 @@
+package linkdb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Keisn1/note-taking-app/domain/core/link"
+	"github.com/Keisn1/note-taking-app/foundation/tracing"
+	"github.com/Keisn1/note-taking-app/foundation/transaction"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/Keisn1/note-taking-app/domain/core/link/repositories/linkdb")
+
+type LinkRepo struct {
+	db transaction.DB
+}
+
+func NewLinkRepo(db transaction.DB) LinkRepo {
+	return LinkRepo{db: db}
+}
+
+// Replace deletes the links of the note and inserts the new ones in one
+// transaction.
+func (lR LinkRepo) Replace(ctx context.Context, sourceID uuid.UUID, links []link.Link) (err error) {
+	insertRow := `INSERT INTO links (source_id, position, target, target_id, user_id) VALUES ($1, $2, $3, $4, $5)`
+	ctx, span := startSpan(ctx, "linkdb.Replace", insertRow)
+	defer tracing.End(span, &err)
+
+	err = transaction.Run(ctx, lR.db, func(tx transaction.Conn) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM links WHERE source_id=$1`, sourceID); err != nil {
+			return err
+		}
+		for i, l := range links {
+			if _, err := tx.ExecContext(ctx, insertRow, sourceID, i, l.Target, nullUUID(l.TargetID), l.UserID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("replace: [%s]: %w", sourceID, err)
+	}
+	return nil
+}
+
+func (lR LinkRepo) QueryBySourceID(ctx context.Context, sourceID uuid.UUID) (_ []link.Link, err error) {
+	queryBySourceID := `
+	SELECT source_id, user_id, target, target_id FROM links WHERE source_id=$1 ORDER BY position`
+	ctx, span := startSpan(ctx, "linkdb.QueryBySourceID", queryBySourceID)
+	defer tracing.End(span, &err)
+
+	links, err := lR.query(ctx, queryBySourceID, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("queryBySourceID: [%s]: %w", sourceID, err)
+	}
+	return links, nil
+}
+
+func (lR LinkRepo) QueryByTargetID(ctx context.Context, targetID uuid.UUID) (_ []link.Link, err error) {
+	queryByTargetID := `
+	SELECT source_id, user_id, target, target_id FROM links WHERE target_id=$1 ORDER BY source_id, position`
+	ctx, span := startSpan(ctx, "linkdb.QueryByTargetID", queryByTargetID)
+	defer tracing.End(span, &err)
+
+	links, err := lR.query(ctx, queryByTargetID, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("queryByTargetID: [%s]: %w", targetID, err)
+	}
+	return links, nil
+}
+
+func (lR LinkRepo) QueryByUserID(ctx context.Context, userID uuid.UUID) (_ []link.Link, err error) {
+	queryByUserID := `
+	SELECT source_id, user_id, target, target_id FROM links WHERE user_id=$1 ORDER BY source_id, position`
+	ctx, span := startSpan(ctx, "linkdb.QueryByUserID", queryByUserID)
+	defer tracing.End(span, &err)
+
+	links, err := lR.query(ctx, queryByUserID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("queryByUserID: [%s]: %w", userID, err)
+	}
+	return links, nil
+}
+
+func (lR LinkRepo) QueryBrokenByUserID(ctx context.Context, userID uuid.UUID) (_ []link.Link, err error) {
+	queryBroken := `
+	SELECT source_id, user_id, target, target_id FROM links
+	WHERE user_id=$1 AND target_id IS NULL ORDER BY source_id, position`
+	ctx, span := startSpan(ctx, "linkdb.QueryBrokenByUserID", queryBroken)
+	defer tracing.End(span, &err)
+
+	links, err := lR.query(ctx, queryBroken, userID)
+	if err != nil {
+		return nil, fmt.Errorf("queryBrokenByUserID: [%s]: %w", userID, err)
+	}
+	return links, nil
+}
+
+func (lR LinkRepo) Resolve(ctx context.Context, userID uuid.UUID, title string, targetID uuid.UUID) (err error) {
+	resolve := `
+	UPDATE links SET target_id=$1 WHERE user_id=$2 AND target_id IS NULL AND lower(target)=lower($3)`
+	ctx, span := startSpan(ctx, "linkdb.Resolve", resolve)
+	defer tracing.End(span, &err)
+
+	if _, err := transaction.ConnFrom(ctx, lR.db).ExecContext(ctx, resolve, targetID, userID, title); err != nil {
+		return fmt.Errorf("resolve: [%s]: %w", targetID, err)
+	}
+	return nil
+}
+
+// DeleteByNoteID does what the foreign keys do when the note is deleted, so
+// that the links are consistent before.
+func (lR LinkRepo) DeleteByNoteID(ctx context.Context, noteID uuid.UUID) (err error) {
+	deleteRows := `DELETE FROM links WHERE source_id=$1`
+	ctx, span := startSpan(ctx, "linkdb.DeleteByNoteID", deleteRows)
+	defer tracing.End(span, &err)
+
+	if _, err := transaction.ConnFrom(ctx, lR.db).ExecContext(ctx, deleteRows, noteID); err != nil {
+		return fmt.Errorf("deleteByNoteID: [%s]: %w", noteID, err)
+	}
+	if _, err := transaction.ConnFrom(ctx, lR.db).ExecContext(ctx, `UPDATE links SET target_id=NULL WHERE target_id=$1`, noteID); err != nil {
+		return fmt.Errorf("deleteByNoteID: [%s]: %w", noteID, err)
+	}
+	return nil
+}
+
+func (lR LinkRepo) query(ctx context.Context, query string, args ...any) ([]link.Link, error) {
+	rows, err := transaction.ConnFrom(ctx, lR.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []link.Link
+	for rows.Next() {
+		var (
+			l        link.Link
+			targetID uuid.NullUUID
+		)
+		if err := rows.Scan(&l.SourceID, &l.UserID, &l.Target, &targetID); err != nil {
+			return nil, fmt.Errorf("scan rows: %w", err)
+		}
+		l.TargetID = targetID.UUID
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
+
+func nullUUID(id uuid.UUID) uuid.NullUUID {
+	return uuid.NullUUID{UUID: id, Valid: id != uuid.Nil}
+}
+
+func startSpan(ctx context.Context, name, query string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.statement", query),
+		),
+	)
+}
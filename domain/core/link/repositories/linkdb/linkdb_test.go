@@ -0,0 +1,96 @@
+package linkdb_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/Keisn1/note-taking-app/domain/core/link"
+	"github.com/Keisn1/note-taking-app/domain/core/link/repositories/linkdb"
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testDBName   = "test_note_taking_app_links"
+	testUser     = "postgres"
+	testPassword = "password"
+)
+
+func TestMain(m *testing.M) {
+	exitCode := run(m)
+	os.Exit(exitCode)
+}
+
+func TestLinkRepo_Query(t *testing.T) {
+	testDB, deleteTable := SetupLinksTable(t, fixtureLinks())
+	defer testDB.Close()
+	defer deleteTable()
+	lR := linkdb.NewLinkRepo(testDB)
+	ctx := context.Background()
+
+	got, err := lR.QueryBySourceID(ctx, uuid.UUID{1})
+	assert.NoError(t, err)
+	assert.Equal(t, fixtureLinks()[:2], got)
+
+	got, err = lR.QueryByTargetID(ctx, uuid.UUID{2})
+	assert.NoError(t, err)
+	assert.Equal(t, []link.Link{fixtureLinks()[0], fixtureLinks()[2]}, got)
+
+	got, err = lR.QueryBrokenByUserID(ctx, uuid.UUID{1})
+	assert.NoError(t, err)
+	assert.Equal(t, []link.Link{fixtureLinks()[1]}, got)
+
+	got, err = lR.QueryByUserID(ctx, uuid.UUID{1})
+	assert.NoError(t, err)
+	assert.Equal(t, fixtureLinks()[:3], got)
+
+	t.Run("Forwards error on database error", func(t *testing.T) {
+		lR := linkdb.NewLinkRepo(&stubSQLDB{})
+		_, err := lR.QueryBySourceID(ctx, uuid.UUID{1})
+		assert.EqualError(t, err, fmt.Errorf("queryBySourceID: [%s]: %w", uuid.UUID{1}, errors.New("DBError")).Error())
+	})
+}
+
+func TestLinkRepo_Replace(t *testing.T) {
+	testDB, deleteTable := SetupLinksTable(t, fixtureLinks())
+	defer testDB.Close()
+	defer deleteTable()
+	lR := linkdb.NewLinkRepo(testDB)
+	ctx := context.Background()
+
+	links := []link.Link{{SourceID: uuid.UUID{1}, UserID: uuid.UUID{1}, Target: "new"}}
+	assert.NoError(t, lR.Replace(ctx, uuid.UUID{1}, links))
+	got, err := lR.QueryBySourceID(ctx, uuid.UUID{1})
+	assert.NoError(t, err)
+	assert.Equal(t, links, got)
+
+	assert.NoError(t, lR.Replace(ctx, uuid.UUID{1}, nil))
+	got, err = lR.QueryBySourceID(ctx, uuid.UUID{1})
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestLinkRepo_ResolveAndDelete(t *testing.T) {
+	testDB, deleteTable := SetupLinksTable(t, fixtureLinks())
+	defer testDB.Close()
+	defer deleteTable()
+	lR := linkdb.NewLinkRepo(testDB)
+	ctx := context.Background()
+
+	assert.NoError(t, lR.Resolve(ctx, uuid.UUID{1}, "MISSING", uuid.UUID{9}))
+	got, err := lR.QueryByTargetID(ctx, uuid.UUID{9})
+	assert.NoError(t, err)
+	assert.Equal(t, []link.Link{{SourceID: uuid.UUID{1}, UserID: uuid.UUID{1}, Target: "Missing", TargetID: uuid.UUID{9}}}, got)
+
+	assert.NoError(t, lR.DeleteByNoteID(ctx, uuid.UUID{2}))
+	got, err = lR.QueryByTargetID(ctx, uuid.UUID{2})
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+	got, err = lR.QueryBrokenByUserID(ctx, uuid.UUID{1})
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+}
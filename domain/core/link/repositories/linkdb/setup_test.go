@@ -0,0 +1,99 @@
+package linkdb_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/Keisn1/note-taking-app/domain/core/link"
+	"github.com/Keisn1/note-taking-app/domain/core/link/repositories/linkdb"
+	"github.com/google/uuid"
+)
+
+func run(m *testing.M) int {
+	var (
+		dropDB   = fmt.Sprintf(`DROP DATABASE IF EXISTS %s;`, testDBName)
+		createDB = fmt.Sprintf(`CREATE DATABASE %s;`, testDBName)
+	)
+
+	dsn := fmt.Sprintf("host=localhost port=5432 user=%s password=%s sslmode=disable", testUser, testPassword)
+	postgresDB, err := sql.Open("pgx", dsn)
+	if err != nil {
+		panic(err)
+	}
+	defer postgresDB.Close()
+
+	_, err = postgresDB.Exec(dropDB)
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = postgresDB.Exec(createDB)
+	if err != nil {
+		panic(err)
+	}
+
+	defer func() {
+		_, err = postgresDB.Exec(dropDB)
+		if err != nil {
+			panic(fmt.Errorf("postgresDB.Exec() err = %s", err))
+		}
+	}()
+
+	return m.Run()
+}
+
+func SetupLinksTable(t *testing.T, links []link.Link) (*sql.DB, func()) {
+	var (
+		createLinksTable = `CREATE TABLE links(
+								source_id UUID NOT NULL,
+								position INT NOT NULL,
+								target TEXT NOT NULL,
+								target_id UUID,
+								user_id UUID NOT NULL,
+								PRIMARY KEY (source_id, position))`
+		dropLinksTable = `DROP TABLE links`
+	)
+
+	dsn := fmt.Sprintf("host=localhost port=5432 user=%s password=%s sslmode=disable dbname=%s ", testUser, testPassword, testDBName)
+	testDB, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = testDB.Exec(createLinksTable)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bySource := map[uuid.UUID][]link.Link{}
+	for _, l := range links {
+		bySource[l.SourceID] = append(bySource[l.SourceID], l)
+	}
+	lR := linkdb.NewLinkRepo(testDB)
+	for sourceID, links := range bySource {
+		if err := lR.Replace(context.Background(), sourceID, links); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deleteTable := func() {
+		_, err := testDB.Exec(dropLinksTable)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testDB.Close()
+	}
+
+	return testDB, deleteTable
+}
+
+func fixtureLinks() []link.Link {
+	return []link.Link{
+		{SourceID: uuid.UUID{1}, UserID: uuid.UUID{1}, Target: "robs 2nd note", TargetID: uuid.UUID{2}},
+		{SourceID: uuid.UUID{1}, UserID: uuid.UUID{1}, Target: "Missing"},
+		{SourceID: uuid.UUID{3}, UserID: uuid.UUID{1}, Target: "02000000-0000-0000-0000-000000000000", TargetID: uuid.UUID{2}},
+		{SourceID: uuid.UUID{4}, UserID: uuid.UUID{2}, Target: "missing"},
+	}
+}
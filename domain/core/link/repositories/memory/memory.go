@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/Keisn1/note-taking-app/domain/core/link"
+	"github.com/google/uuid"
+)
+
+type Repo struct {
+	mu    *sync.Mutex
+	links map[uuid.UUID][]link.Link // by source
+}
+
+func NewRepo() Repo {
+	return Repo{mu: &sync.Mutex{}, links: make(map[uuid.UUID][]link.Link)}
+}
+
+func (lR Repo) Replace(ctx context.Context, sourceID uuid.UUID, links []link.Link) error {
+	lR.mu.Lock()
+	defer lR.mu.Unlock()
+
+	if len(links) == 0 {
+		delete(lR.links, sourceID)
+		return nil
+	}
+	lR.links[sourceID] = slices.Clone(links)
+	return nil
+}
+
+func (lR Repo) QueryBySourceID(ctx context.Context, sourceID uuid.UUID) ([]link.Link, error) {
+	lR.mu.Lock()
+	defer lR.mu.Unlock()
+
+	return slices.Clone(lR.links[sourceID]), nil
+}
+
+func (lR Repo) QueryByTargetID(ctx context.Context, targetID uuid.UUID) ([]link.Link, error) {
+	return lR.filter(func(l link.Link) bool { return l.TargetID == targetID }), nil
+}
+
+func (lR Repo) QueryByUserID(ctx context.Context, userID uuid.UUID) ([]link.Link, error) {
+	return lR.filter(func(l link.Link) bool { return l.UserID == userID }), nil
+}
+
+func (lR Repo) QueryBrokenByUserID(ctx context.Context, userID uuid.UUID) ([]link.Link, error) {
+	return lR.filter(func(l link.Link) bool { return l.UserID == userID && l.Broken() }), nil
+}
+
+func (lR Repo) Resolve(ctx context.Context, userID uuid.UUID, title string, targetID uuid.UUID) error {
+	lR.mu.Lock()
+	defer lR.mu.Unlock()
+
+	for _, links := range lR.links {
+		for i, l := range links {
+			if l.UserID == userID && l.Broken() && strings.EqualFold(l.Target, title) {
+				links[i].TargetID = targetID
+			}
+		}
+	}
+	return nil
+}
+
+func (lR Repo) DeleteByNoteID(ctx context.Context, noteID uuid.UUID) error {
+	lR.mu.Lock()
+	defer lR.mu.Unlock()
+
+	delete(lR.links, noteID)
+	for _, links := range lR.links {
+		for i, l := range links {
+			if l.TargetID == noteID {
+				links[i].TargetID = uuid.Nil
+			}
+		}
+	}
+	return nil
+}
+
+func (lR Repo) filter(keep func(link.Link) bool) []link.Link {
+	lR.mu.Lock()
+	defer lR.mu.Unlock()
+
+	var ret []link.Link
+	for _, links := range lR.links {
+		for _, l := range links {
+			if keep(l) {
+				ret = append(ret, l)
+			}
+		}
+	}
+	return ret
+}
+
+// Snapshot copies the links for a transaction.Memory.
+func (lR Repo) Snapshot() (restore func()) {
+	lR.mu.Lock()
+	defer lR.mu.Unlock()
+
+	links := make(map[uuid.UUID][]link.Link, len(lR.links))
+	for id, ls := range lR.links {
+		links[id] = slices.Clone(ls)
+	}
+	return func() {
+		lR.mu.Lock()
+		defer lR.mu.Unlock()
+		clear(lR.links)
+		maps.Copy(lR.links, links)
+	}
+}
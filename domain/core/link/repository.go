@@ -0,0 +1,26 @@
+package link
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type Repo interface {
+	// Replace sets the links of the note to links, in their order.
+	Replace(ctx context.Context, sourceID uuid.UUID, links []Link) error
+	// QueryBySourceID returns the links of the note in their order.
+	QueryBySourceID(ctx context.Context, sourceID uuid.UUID) ([]Link, error)
+	// QueryByTargetID returns the links to the note.
+	QueryByTargetID(ctx context.Context, targetID uuid.UUID) ([]Link, error)
+	// QueryByUserID returns the links of the notes of the user.
+	QueryByUserID(ctx context.Context, userID uuid.UUID) ([]Link, error)
+	// QueryBrokenByUserID returns the broken links of the notes of the user.
+	QueryBrokenByUserID(ctx context.Context, userID uuid.UUID) ([]Link, error)
+	// Resolve points the broken links of the user whose target equals title,
+	// ignoring case, to targetID.
+	Resolve(ctx context.Context, userID uuid.UUID, title string, targetID uuid.UUID) error
+	// DeleteByNoteID deletes the links of the note and breaks the links to
+	// it.
+	DeleteByNoteID(ctx context.Context, noteID uuid.UUID) error
+}
@@ -0,0 +1,100 @@
+package note
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EventCreated = "note.created"
+	EventUpdated = "note.updated"
+	EventDeleted = "note.deleted"
+)
+
+var ErrUnknownEvent = errors.New("unknown event")
+
+// Event tells that a note was created, updated or deleted. Note is the note
+// as it is after the change, or as it was before it was deleted. ID is the
+// ID of the outbox event if the event was relayed from there, receivers
+// use it to drop duplicates.
+type Event struct {
+	ID   uuid.UUID
+	Type string
+	Note Note
+}
+
+// NoteCreated, NoteUpdated and NoteDeleted are the domain events of notes.
+// The repositories that support it write them to the outbox in the
+// transaction of the change; their JSON is the payload.
+type (
+	NoteCreated struct{ eventNote }
+	NoteUpdated struct{ eventNote }
+	NoteDeleted struct {
+		NoteID uuid.UUID `json:"note_id"`
+		UserID uuid.UUID `json:"user_id"`
+	}
+)
+
+type eventNote struct {
+	NoteID  uuid.UUID `json:"note_id"`
+	UserID  uuid.UUID `json:"user_id"`
+	Title   string    `json:"title"`
+	Content string    `json:"content"`
+	Format  string    `json:"format"`
+}
+
+func NewNoteCreated(n Note) NoteCreated { return NoteCreated{toEventNote(n)} }
+func NewNoteUpdated(n Note) NoteUpdated { return NoteUpdated{toEventNote(n)} }
+
+func (NoteCreated) EventType() string        { return EventCreated }
+func (e NoteCreated) AggregateID() uuid.UUID { return e.NoteID }
+func (NoteUpdated) EventType() string        { return EventUpdated }
+func (e NoteUpdated) AggregateID() uuid.UUID { return e.NoteID }
+func (NoteDeleted) EventType() string        { return EventDeleted }
+func (e NoteDeleted) AggregateID() uuid.UUID { return e.NoteID }
+
+func toEventNote(n Note) eventNote {
+	return eventNote{NoteID: n.ID, UserID: n.UserID, Title: n.Title.String(), Content: n.Content.String(), Format: n.Format.String()}
+}
+
+func (en eventNote) note() Note {
+	return Note{ID: en.NoteID, Title: NewTitle(en.Title), Content: NewContent(en.Content), Format: Format(en.Format), UserID: en.UserID}
+}
+
+// ParseEvent decodes the payload of a domain event of type typ. Deleted
+// notes only carry their ID and owner. It returns ErrUnknownEvent for the
+// events of other domains.
+func ParseEvent(typ string, payload []byte) (Event, error) {
+	switch typ {
+	case EventCreated, EventUpdated:
+		var en eventNote
+		if err := json.Unmarshal(payload, &en); err != nil {
+			return Event{}, fmt.Errorf("parseEvent: [%s]: %w", typ, err)
+		}
+		return Event{Type: typ, Note: en.note()}, nil
+	case EventDeleted:
+		var nd NoteDeleted
+		if err := json.Unmarshal(payload, &nd); err != nil {
+			return Event{}, fmt.Errorf("parseEvent: [%s]: %w", typ, err)
+		}
+		return Event{Type: typ, Note: Note{ID: nd.NoteID, UserID: nd.UserID}}, nil
+	}
+	return Event{}, fmt.Errorf("parseEvent: [%s]: %w", typ, ErrUnknownEvent)
+}
+
+// Publisher is notified of events after the change was saved. Publish must
+// not block.
+type Publisher interface {
+	Publish(ctx context.Context, e Event)
+}
+
+// PublisherFunc adapts a function to a Publisher.
+type PublisherFunc func(ctx context.Context, e Event)
+
+func (f PublisherFunc) Publish(ctx context.Context, e Event) {
+	f(ctx, e)
+}
@@ -0,0 +1,35 @@
+package note_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEvent(t *testing.T) {
+	n := note.Note{ID: uuid.New(), Title: note.NewTitle("Groceries"), Content: note.NewContent("milk"), Format: note.FormatMarkdown, UserID: uuid.New()}
+
+	for _, tc := range []struct {
+		de   interface{ EventType() string }
+		want note.Event
+	}{
+		{de: note.NewNoteCreated(n), want: note.Event{Type: note.EventCreated, Note: n}},
+		{de: note.NewNoteUpdated(n), want: note.Event{Type: note.EventUpdated, Note: n}},
+		{de: note.NoteDeleted{NoteID: n.ID, UserID: n.UserID}, want: note.Event{Type: note.EventDeleted, Note: note.Note{ID: n.ID, UserID: n.UserID}}},
+	} {
+		payload, err := json.Marshal(tc.de)
+		require.NoError(t, err)
+		got, err := note.ParseEvent(tc.de.EventType(), payload)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.want, got)
+	}
+
+	_, err := note.ParseEvent("user.deleted", []byte(`{}`))
+	assert.ErrorIs(t, err, note.ErrUnknownEvent)
+	_, err = note.ParseEvent(note.EventCreated, []byte(`[]`))
+	assert.Error(t, err)
+}
@@ -1,22 +1,84 @@
 package note
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
 	"github.com/google/uuid"
 )
 
+const (
+	MaxTitleLength   = 200     // characters
+	MaxContentLength = 1 << 20 // bytes
+)
+
 type Note struct {
 	ID      uuid.UUID
 	Title   Title
 	Content Content
+	Format  Format
 	UserID  uuid.UUID
 }
 
+// UpdateNote holds the changes of a note. Empty fields are kept, new notes
+// default to FormatPlain. ID is only used by Create, for notes whose ID the
+// client chose; a new one is generated if it is uuid.Nil.
 type UpdateNote struct {
+	ID      uuid.UUID
 	Title   Title
 	Content Content
+	Format  Format
 	UserID  uuid.UUID
 }
 
+// Format is the markup of the content.
+type Format string
+
+const (
+	FormatPlain    Format = "plain"
+	FormatMarkdown Format = "markdown"
+)
+
+var ErrInvalidFormat = errors.New("invalid format")
+
+// ParseFormat returns the format named s.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case FormatPlain, FormatMarkdown:
+		return f, nil
+	}
+	return "", fmt.Errorf("parseFormat: %w: %q", ErrInvalidFormat, s)
+}
+
+func (f Format) IsEmpty() bool { return f == "" }
+
+func (f Format) String() string { return string(f) }
+
+func (f Format) MarshalJSON() ([]byte, error) {
+	if f.IsEmpty() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(string(f))
+}
+
+func (f *Format) UnmarshalJSON(data []byte) error {
+	var s *string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s == nil {
+		*f = ""
+		return nil
+	}
+	parsed, err := ParseFormat(*s)
+	if err != nil {
+		return err
+	}
+	*f = parsed
+	return nil
+}
+
 type Content struct {
 	content *string
 }
@@ -42,6 +104,12 @@ func (tt Title) String() string {
 
 func (tt Title) IsEmpty() bool { return tt.title == nil }
 
+// MarshalJSON encodes an empty Title as null.
+func (tt Title) MarshalJSON() ([]byte, error) { return marshalString(tt.title) }
+
+// UnmarshalJSON leaves the Title empty for null.
+func (tt *Title) UnmarshalJSON(data []byte) error { return unmarshalString(data, &tt.title) }
+
 func NewContent(content string) Content {
 	return Content{content: &content}
 }
@@ -58,3 +126,28 @@ func (c Content) String() string {
 }
 
 func (c Content) IsEmpty() bool { return c.content == nil }
+
+// MarshalJSON encodes an empty Content as null.
+func (c Content) MarshalJSON() ([]byte, error) { return marshalString(c.content) }
+
+// UnmarshalJSON leaves the Content empty for null.
+func (c *Content) UnmarshalJSON(data []byte) error { return unmarshalString(data, &c.content) }
+
+func marshalString(s *string) ([]byte, error) {
+	if s == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(*s)
+}
+
+func unmarshalString(data []byte, s **string) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var v string
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*s = &v
+	return nil
+}
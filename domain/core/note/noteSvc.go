@@ -5,35 +5,159 @@ import (
 	"fmt"
 
 	"github.com/Keisn1/note-taking-app/domain/core/user"
+	"github.com/Keisn1/note-taking-app/foundation/tracing"
+	"github.com/Keisn1/note-taking-app/foundation/transaction"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/Keisn1/note-taking-app/domain/core/note")
+
 type Service interface {
-	Delete(noteID uuid.UUID) error
-	Create(nN UpdateNote) (Note, error)
-	Update(n Note, newN UpdateNote) (Note, error)
+	Delete(ctx context.Context, noteID uuid.UUID) error
+	DeleteByUserID(ctx context.Context, userID uuid.UUID) error
+	Create(ctx context.Context, nN UpdateNote) (Note, error)
+	Update(ctx context.Context, n Note, newN UpdateNote) (Note, error)
+	UpdateFunc(ctx context.Context, noteID uuid.UUID, fn func(n Note) (UpdateNote, error)) (Note, error)
 	QueryByID(ctx context.Context, noteID uuid.UUID) (Note, error)
-	GetNotesByUserID(userID uuid.UUID) ([]Note, error)
+	GetNotesByUserID(ctx context.Context, userID uuid.UUID) ([]Note, error)
+	IterateByUserID(ctx context.Context, userID uuid.UUID, fn func(Note) error) error
+	QueryByFilter(ctx context.Context, f Filter) ([]Note, int, error)
 }
 
 type NotesService struct {
-	repo    Repo
-	userSvc user.Service
+	repo        Repo
+	userSvc     user.Service
+	saveHooks   []func(ctx context.Context, old, n Note) ([]Note, error)
+	deleteHooks []func(ctx context.Context, noteID uuid.UUID) error
+	eventHooks  []func(ctx context.Context, e Event) error
+	publishers  []Publisher
+	txm         transaction.Manager
+}
+
+type Option func(*NotesService)
+
+// WithDeleteHook registers fn to be called before a note is deleted, for
+// data that belongs to the note but is stored elsewhere. The note is not
+// deleted if fn fails.
+func WithDeleteHook(fn func(ctx context.Context, noteID uuid.UUID) error) Option {
+	return func(ns *NotesService) {
+		ns.deleteHooks = append(ns.deleteHooks, fn)
+	}
+}
+
+// WithSaveHook registers fn to be called after a note is created or updated,
+// with the note as it was before (zero when created) and as it is now. fn
+// returns the other notes it updated along with it, their events are sent
+// like the one of the note.
+func WithSaveHook(fn func(ctx context.Context, old, n Note) ([]Note, error)) Option {
+	return func(ns *NotesService) {
+		ns.saveHooks = append(ns.saveHooks, fn)
+	}
+}
+
+// WithEventHook registers fn to be called with the event of every change
+// after it was saved, before the publishers are notified. Unlike a
+// publisher fn may block and its error is returned, the change is saved
+// already when it fails, unless the service runs in transactions.
+func WithEventHook(fn func(ctx context.Context, e Event) error) Option {
+	return func(ns *NotesService) {
+		ns.eventHooks = append(ns.eventHooks, fn)
+	}
+}
+
+// WithTransactions runs the changes of notes in transactions of m, together
+// with the delete, save and event hooks; a change is rolled back if one of
+// them fails. The publishers are notified after the commit, of the outer
+// transaction if the change runs in one of the caller. Hooks that
+// write elsewhere than to the repositories of m, like the attachment
+// store, defer that with transaction.AfterCommit.
+func WithTransactions(m transaction.Manager) Option {
+	return func(ns *NotesService) {
+		ns.txm = m
+	}
+}
+
+// WithPublisher registers p to be notified of the notes that were created,
+// updated or deleted.
+func WithPublisher(p Publisher) Option {
+	return func(ns *NotesService) {
+		ns.publishers = append(ns.publishers, p)
+	}
+}
+
+func NewNotesService(nR Repo, us user.Service, opts ...Option) NotesService {
+	ns := NotesService{repo: nR, userSvc: us}
+	for _, opt := range opts {
+		opt(&ns)
+	}
+	return ns
 }
 
-func NewNotesService(nR Repo, us user.Service) NotesService {
-	return NotesService{repo: nR, userSvc: us}
+func (ns NotesService) Delete(ctx context.Context, noteID uuid.UUID) (err error) {
+	ctx, span := tracer.Start(ctx, "note.Delete", trace.WithAttributes(attribute.String("note.id", noteID.String())))
+	defer tracing.End(span, &err)
+
+	var n Note
+	err = ns.withinTx(ctx, func(ctx context.Context) error {
+		// Locked before the hooks change anything. The event carries the
+		// deleted note, so that it can be delivered to its owner.
+		var err error
+		if n, err = ns.repo.QueryByIDForUpdate(ctx, noteID); err != nil {
+			return err
+		}
+
+		for _, hook := range ns.deleteHooks {
+			if err := hook(ctx, noteID); err != nil {
+				return err
+			}
+		}
+
+		if err := ns.repo.Delete(ctx, noteID); err != nil {
+			return err
+		}
+		if err := ns.runEventHooks(ctx, Event{Type: EventDeleted, Note: n}); err != nil {
+			return err
+		}
+		ns.notify(ctx, Event{Type: EventDeleted, Note: n})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("delete: [%s]: %w", noteID, err)
+	}
+	return nil
 }
 
-func (ns NotesService) Delete(noteID uuid.UUID) error {
-	err := ns.repo.Delete(noteID)
+// DeleteByUserID deletes the notes of the user one by one, with the delete
+// hooks and events of each, see user.WithDeleteHook.
+func (ns NotesService) DeleteByUserID(ctx context.Context, userID uuid.UUID) (err error) {
+	ctx, span := tracer.Start(ctx, "note.DeleteByUserID", trace.WithAttributes(attribute.String("user.id", userID.String())))
+	defer tracing.End(span, &err)
+
+	err = ns.withinTx(ctx, func(ctx context.Context) error {
+		notes, err := ns.repo.QueryByUserID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		for _, n := range notes {
+			if err := ns.Delete(ctx, n.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("delete: [%s]", noteID)
+		return fmt.Errorf("deleteByUserID: [%s]: %w", userID, err)
 	}
 	return nil
 }
 
-func (ns NotesService) Create(ctx context.Context, nN UpdateNote) (Note, error) {
+func (ns NotesService) Create(ctx context.Context, nN UpdateNote) (_ Note, err error) {
+	ctx, span := tracer.Start(ctx, "note.Create", trace.WithAttributes(attribute.String("user.id", nN.UserID.String())))
+	defer tracing.End(span, &err)
+
 	// MidAuthenticate authenticates user but could still submit
 	// a note with a UserID different from its id
 	if _, err := ns.userSvc.QueryByID(ctx, nN.UserID); err != nil {
@@ -41,36 +165,155 @@ func (ns NotesService) Create(ctx context.Context, nN UpdateNote) (Note, error)
 	}
 
 	n := Note{
-		ID:      uuid.New(),
+		ID:      nN.ID,
 		Title:   nN.Title,
 		Content: nN.Content,
+		Format:  nN.Format,
 		UserID:  nN.UserID,
 	}
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	if n.Format.IsEmpty() {
+		n.Format = FormatPlain
+	}
 
-	err := ns.repo.Create(n)
+	err = ns.withinTx(ctx, func(ctx context.Context) error {
+		if err := ns.repo.Create(ctx, n); err != nil {
+			return err
+		}
+		events, err := ns.runSaveHooks(ctx, Note{}, n)
+		if err != nil {
+			return fmt.Errorf("create: [%s]: %w", n.ID, err)
+		}
+		events = append([]Event{{Type: EventCreated, Note: n}}, events...)
+		if err := ns.runEventHooks(ctx, events...); err != nil {
+			return fmt.Errorf("create: [%s]: %w", n.ID, err)
+		}
+		ns.notify(ctx, events...)
+		return nil
+	})
 	if err != nil {
 		return Note{}, err
 	}
 	return n, nil
 }
 
-func (ns NotesService) Update(n Note, newN UpdateNote) (Note, error) {
-	if !newN.Title.IsEmpty() {
-		n.Title = newN.Title
-	}
+// Update applies the changes to the note as it is stored, n only names it,
+// so that changes made since n was read are kept. See UpdateFunc.
+func (ns NotesService) Update(ctx context.Context, n Note, newN UpdateNote) (_ Note, err error) {
+	ctx, span := tracer.Start(ctx, "note.Update", trace.WithAttributes(attribute.String("note.id", n.ID.String())))
+	defer tracing.End(span, &err)
 
-	if !newN.Content.IsEmpty() {
-		n.Content = newN.Content
-	}
+	return ns.update(ctx, n.ID, func(Note) (UpdateNote, error) { return newN, nil })
+}
+
+// UpdateFunc reads the note for update, which keeps others from changing it
+// until the change is saved, and applies the changes fn returns for it. An
+// error of fn is returned and nothing is changed. fn runs in the transaction
+// if the service has one.
+func (ns NotesService) UpdateFunc(ctx context.Context, noteID uuid.UUID, fn func(n Note) (UpdateNote, error)) (_ Note, err error) {
+	ctx, span := tracer.Start(ctx, "note.UpdateFunc", trace.WithAttributes(attribute.String("note.id", noteID.String())))
+	defer tracing.End(span, &err)
+
+	return ns.update(ctx, noteID, fn)
+}
 
-	err := ns.repo.Update(n)
+func (ns NotesService) update(ctx context.Context, noteID uuid.UUID, fn func(n Note) (UpdateNote, error)) (Note, error) {
+	var n Note
+	err := ns.withinTx(ctx, func(ctx context.Context) error {
+		old, err := ns.repo.QueryByIDForUpdate(ctx, noteID)
+		if err != nil {
+			return fmt.Errorf("update: %w", err)
+		}
+		newN, err := fn(old)
+		if err != nil {
+			return fmt.Errorf("update: [%s]: %w", noteID, err)
+		}
+
+		n = old
+		if !newN.Title.IsEmpty() {
+			n.Title = newN.Title
+		}
+		if !newN.Content.IsEmpty() {
+			n.Content = newN.Content
+		}
+		if !newN.Format.IsEmpty() {
+			n.Format = newN.Format
+		}
+
+		if err := ns.repo.Update(ctx, n); err != nil {
+			return fmt.Errorf("update: %w", err)
+		}
+		events, err := ns.runSaveHooks(ctx, old, n)
+		if err != nil {
+			return fmt.Errorf("update: [%s]: %w", n.ID, err)
+		}
+		events = append([]Event{{Type: EventUpdated, Note: n}}, events...)
+		if err := ns.runEventHooks(ctx, events...); err != nil {
+			return fmt.Errorf("update: [%s]: %w", n.ID, err)
+		}
+		ns.notify(ctx, events...)
+		return nil
+	})
 	if err != nil {
-		return Note{}, fmt.Errorf("update: %w", err)
+		return Note{}, err
 	}
 	return n, nil
 }
 
-func (nS NotesService) QueryByID(ctx context.Context, noteID uuid.UUID) (Note, error) {
+// runSaveHooks runs the save hooks and returns the events of the other notes
+// they updated. The note is already saved when one fails, unless the service
+// runs in transactions.
+func (ns NotesService) runSaveHooks(ctx context.Context, old, n Note) ([]Event, error) {
+	var events []Event
+	for _, hook := range ns.saveHooks {
+		updated, err := hook(ctx, old, n)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range updated {
+			events = append(events, Event{Type: EventUpdated, Note: u})
+		}
+	}
+	return events, nil
+}
+
+func (ns NotesService) runEventHooks(ctx context.Context, events ...Event) error {
+	for _, e := range events {
+		for _, hook := range ns.eventHooks {
+			if err := hook(ctx, e); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// notify notifies the publishers once the changes were saved, see
+// transaction.AfterCommit.
+func (ns NotesService) notify(ctx context.Context, events ...Event) {
+	transaction.AfterCommit(ctx, func(ctx context.Context) {
+		for _, e := range events {
+			for _, p := range ns.publishers {
+				p.Publish(ctx, e)
+			}
+		}
+	})
+}
+
+// withinTx runs fn in a transaction if the service has a manager.
+func (ns NotesService) withinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ns.txm == nil {
+		return fn(ctx)
+	}
+	return ns.txm.WithinTx(ctx, fn)
+}
+
+func (nS NotesService) QueryByID(ctx context.Context, noteID uuid.UUID) (_ Note, err error) {
+	ctx, span := tracer.Start(ctx, "note.QueryByID", trace.WithAttributes(attribute.String("note.id", noteID.String())))
+	defer tracing.End(span, &err)
+
 	n, err := nS.repo.QueryByID(ctx, noteID)
 	if err != nil {
 		return Note{}, fmt.Errorf("getNoteByID: [%s]: %w", noteID, err)
@@ -78,10 +321,54 @@ func (nS NotesService) QueryByID(ctx context.Context, noteID uuid.UUID) (Note, e
 	return n, nil
 }
 
-func (nS NotesService) GetNotesByUserID(userID uuid.UUID) ([]Note, error) {
-	notes, err := nS.repo.QueryByUserID(userID)
+func (nS NotesService) GetNotesByUserID(ctx context.Context, userID uuid.UUID) (_ []Note, err error) {
+	ctx, span := tracer.Start(ctx, "note.GetNotesByUserID", trace.WithAttributes(attribute.String("user.id", userID.String())))
+	defer tracing.End(span, &err)
+
+	if _, err := nS.userSvc.QueryByID(ctx, userID); err != nil {
+		return nil, fmt.Errorf("getNoteByUserID: [%s]: %w", userID, err)
+	}
+
+	notes, err := nS.repo.QueryByUserID(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("getNoteByUserID: [%s]: %w", userID, err)
 	}
 	return notes, nil
 }
+
+// IterateByUserID calls fn with each note of the user, see
+// Repo.IterateByUserID.
+func (nS NotesService) IterateByUserID(ctx context.Context, userID uuid.UUID, fn func(Note) error) (err error) {
+	ctx, span := tracer.Start(ctx, "note.IterateByUserID", trace.WithAttributes(attribute.String("user.id", userID.String())))
+	defer tracing.End(span, &err)
+
+	if _, err := nS.userSvc.QueryByID(ctx, userID); err != nil {
+		return fmt.Errorf("iterateByUserID: [%s]: %w", userID, err)
+	}
+
+	if err := nS.repo.IterateByUserID(ctx, userID, fn); err != nil {
+		return fmt.Errorf("iterateByUserID: [%s]: %w", userID, err)
+	}
+	return nil
+}
+
+// QueryByFilter returns the page of notes selected by f and the number of all
+// notes that match it.
+func (nS NotesService) QueryByFilter(ctx context.Context, f Filter) (_ []Note, total int, err error) {
+	ctx, span := tracer.Start(ctx, "note.QueryByFilter", trace.WithAttributes(attribute.String("user.id", f.UserID.String())))
+	defer tracing.End(span, &err)
+
+	if _, err := nS.userSvc.QueryByID(ctx, f.UserID); err != nil {
+		return nil, 0, fmt.Errorf("queryByFilter: [%s]: %w", f.UserID, err)
+	}
+
+	total, err = nS.repo.CountByFilter(ctx, f)
+	if err != nil {
+		return nil, 0, fmt.Errorf("queryByFilter: [%s]: %w", f.UserID, err)
+	}
+	notes, err := nS.repo.QueryByFilter(ctx, f)
+	if err != nil {
+		return nil, 0, fmt.Errorf("queryByFilter: [%s]: %w", f.UserID, err)
+	}
+	return notes, total, nil
+}
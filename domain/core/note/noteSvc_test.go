@@ -2,13 +2,19 @@ package note_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 
 	"github.com/Keisn1/note-taking-app/domain/core/note"
-	"github.com/Keisn1/note-taking-app/domain/core/user"
+	"github.com/Keisn1/note-taking-app/domain/core/note/repositories/memory"
+	"github.com/Keisn1/note-taking-app/foundation/transaction"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestNoteService_Delete(t *testing.T) {
@@ -16,7 +22,7 @@ func TestNoteService_Delete(t *testing.T) {
 		notesS := Setup(t, fixtureNotes())
 		noteID := uuid.UUID{}
 
-		err := notesS.Delete(noteID)
+		err := notesS.Delete(context.Background(), noteID)
 		assert.ErrorContains(t, err, fmt.Errorf("delete: [%s]", noteID).Error())
 	})
 
@@ -25,12 +31,103 @@ func TestNoteService_Delete(t *testing.T) {
 		robsNote := fixtureNotes()[0]
 		noteID := robsNote.ID
 
-		err := notesS.Delete(noteID)
+		err := notesS.Delete(context.Background(), noteID)
 		assert.NoError(t, err)
 
 		_, err = notesS.QueryByID(context.Background(), noteID)
 		assert.ErrorContains(t, err, fmt.Errorf("getNoteByID: [%s]", noteID).Error())
 	})
+
+	t.Run("Delete hooks run before the note is deleted and can prevent it", func(t *testing.T) {
+		repo, err := memory.NewRepo(fixtureNotes())
+		assert.NoError(t, err)
+		noteID := fixtureNotes()[0].ID
+
+		var called []uuid.UUID
+		hookErr := errors.New("hook failed")
+		notesS := note.NewNotesService(repo, StubUserService{}, note.WithDeleteHook(func(ctx context.Context, id uuid.UUID) error {
+			called = append(called, id)
+			return hookErr
+		}))
+
+		err = notesS.Delete(context.Background(), noteID)
+		assert.ErrorIs(t, err, hookErr)
+		assert.Equal(t, []uuid.UUID{noteID}, called)
+
+		_, err = notesS.QueryByID(context.Background(), noteID)
+		assert.NoError(t, err)
+	})
+}
+
+func TestNoteService_SaveHooks(t *testing.T) {
+	repo, err := memory.NewRepo(fixtureNotes())
+	assert.NoError(t, err)
+	userID := fixtureNotes()[0].UserID
+
+	other := fixtureNotes()[1]
+
+	type call struct{ old, n note.Note }
+	var (
+		calls          []call
+		hooked, events []note.Event
+	)
+	notesS := note.NewNotesService(repo, StubUserService{ids: map[uuid.UUID]struct{}{userID: {}}},
+		note.WithSaveHook(func(ctx context.Context, old, n note.Note) ([]note.Note, error) {
+			calls = append(calls, call{old, n})
+			if old.ID == uuid.Nil {
+				return nil, nil
+			}
+			return []note.Note{other}, nil
+		}),
+		note.WithEventHook(func(ctx context.Context, e note.Event) error {
+			hooked = append(hooked, e)
+			return nil
+		}),
+		note.WithPublisher(note.PublisherFunc(func(ctx context.Context, e note.Event) {
+			events = append(events, e)
+		})),
+	)
+
+	created, err := notesS.Create(context.Background(), note.UpdateNote{Title: note.NewTitle("new"), Content: note.NewContent(""), UserID: userID})
+	assert.NoError(t, err)
+	updated, err := notesS.Update(context.Background(), created, note.UpdateNote{Title: note.NewTitle("renamed")})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []call{{note.Note{}, created}, {created, updated}}, calls)
+	assert.Equal(t, "new", calls[1].old.Title.String())
+
+	// The notes the hook updated get events like the note itself.
+	want := []note.Event{
+		{Type: note.EventCreated, Note: created},
+		{Type: note.EventUpdated, Note: updated},
+		{Type: note.EventUpdated, Note: other},
+	}
+	assert.Equal(t, want, hooked)
+	assert.Equal(t, want, events)
+}
+
+func TestNoteService_Publish(t *testing.T) {
+	repo, err := memory.NewRepo(fixtureNotes())
+	assert.NoError(t, err)
+	userID := fixtureNotes()[0].UserID
+
+	var events []note.Event
+	notesS := note.NewNotesService(repo, StubUserService{ids: map[uuid.UUID]struct{}{userID: {}}}, note.WithPublisher(note.PublisherFunc(func(ctx context.Context, e note.Event) {
+		events = append(events, e)
+	})))
+
+	created, err := notesS.Create(context.Background(), note.UpdateNote{Title: note.NewTitle("new"), Content: note.NewContent(""), UserID: userID})
+	assert.NoError(t, err)
+	updated, err := notesS.Update(context.Background(), created, note.UpdateNote{Title: note.NewTitle("renamed")})
+	assert.NoError(t, err)
+	assert.NoError(t, notesS.Delete(context.Background(), created.ID))
+	assert.Error(t, notesS.Delete(context.Background(), created.ID))
+
+	assert.Equal(t, []note.Event{
+		{Type: note.EventCreated, Note: created},
+		{Type: note.EventUpdated, Note: updated},
+		{Type: note.EventDeleted, Note: updated},
+	}, events)
 }
 
 func TestNoteService_Create(t *testing.T) {
@@ -44,10 +141,10 @@ func TestNoteService_Create(t *testing.T) {
 	})
 
 	t.Run("Throws error if repo throws error (given repo.Create is called)", func(t *testing.T) {
+		userID := uuid.New()
 		errorRepo := ErrorNoteRepo{}
-		notesS := note.NewNotesService(errorRepo, user.Svc{})
+		notesS := note.NewNotesService(errorRepo, StubUserService{ids: map[uuid.UUID]struct{}{userID: {}}})
 
-		userID := uuid.New()
 		newNote := note.UpdateNote{Title: note.NewTitle(""), Content: note.NewContent(""), UserID: userID}
 		_, err := notesS.Create(context.Background(), newNote)
 		assert.Error(t, err)
@@ -74,12 +171,19 @@ func TestNoteService_Create(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, want, got)
 	})
+
+	t.Run("The note gets the ID chosen by the client", func(t *testing.T) {
+		notesS := Setup(t, fixtureNotes())
+		noteID := uuid.New()
+
+		got, err := notesS.Create(context.Background(), note.UpdateNote{ID: noteID, Title: note.NewTitle("offline"), UserID: uuid.UUID{1}})
+		assert.NoError(t, err)
+		assert.Equal(t, noteID, got.ID)
+	})
 }
 
 func TestNoteService_Update(t *testing.T) {
 	t.Run("Given a note present in the system and a note containing updates for this note, I can update the present note inside the system", func(t *testing.T) {
-		notesS := Setup(t, fixtureNotes())
-
 		type testCase struct {
 			name       string
 			currNote   note.Note
@@ -132,7 +236,8 @@ func TestNoteService_Update(t *testing.T) {
 
 		for _, tc := range testCases {
 			t.Run(tc.name, func(t *testing.T) {
-				got, err := notesS.Update(tc.currNote, tc.updateNote)
+				notesS := Setup(t, fixtureNotes())
+				got, err := notesS.Update(context.Background(), tc.currNote, tc.updateNote)
 				assert.NoError(t, err)
 				assert.Equal(t, tc.want, got) // assert that the right note was sent back
 
@@ -142,6 +247,42 @@ func TestNoteService_Update(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("The changes are applied to the note as it is stored", func(t *testing.T) {
+		notesS := Setup(t, fixtureNotes())
+		stale, err := notesS.QueryByID(context.Background(), uuid.UUID{1})
+		assert.NoError(t, err)
+		_, err = notesS.Update(context.Background(), stale, note.UpdateNote{Content: note.NewContent("changed meanwhile")})
+		assert.NoError(t, err)
+
+		got, err := notesS.Update(context.Background(), stale, note.UpdateNote{Title: note.NewTitle("new title")})
+		assert.NoError(t, err)
+		assert.Equal(t, "new title", got.Title.String())
+		assert.Equal(t, "changed meanwhile", got.Content.String())
+	})
+
+	t.Run("UpdateFunc passes the stored note to fn and keeps it if fn fails", func(t *testing.T) {
+		notesS := Setup(t, fixtureNotes())
+		failed := errors.New("failed")
+
+		_, err := notesS.UpdateFunc(context.Background(), uuid.UUID{1}, func(n note.Note) (note.UpdateNote, error) {
+			assert.Equal(t, "robs 1st note content", n.Content.String())
+			return note.UpdateNote{Content: note.NewContent("new content")}, failed
+		})
+		assert.ErrorIs(t, err, failed)
+
+		got, err := notesS.UpdateFunc(context.Background(), uuid.UUID{1}, func(n note.Note) (note.UpdateNote, error) {
+			return note.UpdateNote{Content: note.NewContent(n.Content.String() + "!")}, nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "robs 1st note content!", got.Content.String())
+
+		_, err = notesS.UpdateFunc(context.Background(), uuid.New(), func(n note.Note) (note.UpdateNote, error) {
+			t.Error("fn called for a missing note")
+			return note.UpdateNote{}, nil
+		})
+		assert.ErrorIs(t, err, note.ErrNoteNotFound)
+	})
 }
 
 func TestNoteService_QueryByID(t *testing.T) {
@@ -155,7 +296,7 @@ func TestNoteService_QueryByID(t *testing.T) {
 	t.Run("GetNoteByUserID return errors on missing user", func(t *testing.T) {
 		notesS := Setup(t, fixtureNotes())
 		userID := uuid.New()
-		_, err := notesS.GetNotesByUserID(userID)
+		_, err := notesS.GetNotesByUserID(context.Background(), userID)
 		assert.ErrorContains(t, err, fmt.Errorf("getNoteByUserID: [%s]", userID).Error())
 	})
 
@@ -215,9 +356,190 @@ func TestNoteService_GetNotesByUserID(t *testing.T) {
 		}
 
 		for _, tc := range testCases {
-			got, err := notesS.GetNotesByUserID(tc.userID)
+			got, err := notesS.GetNotesByUserID(context.Background(), tc.userID)
 			assert.NoError(t, err)
 			assert.ElementsMatch(t, tc.want, got)
 		}
 	})
 }
+
+func TestNoteService_QueryByFilter(t *testing.T) {
+	notesS := Setup(t, fixtureNotes())
+	ctx := context.Background()
+
+	t.Run("Returns the page and the number of all matches", func(t *testing.T) {
+		got, total, err := notesS.QueryByFilter(ctx, note.Filter{UserID: uuid.UUID{1}, Query: "NOTE CONTENT", Offset: 1, Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, total)
+		assert.Equal(t, []note.Note{
+			{ID: uuid.UUID{2}, Title: note.NewTitle("robs 2nd note"), Content: note.NewContent("robs 2nd note content"), UserID: uuid.UUID{1}},
+		}, got)
+
+		got, total, err = notesS.QueryByFilter(ctx, note.Filter{UserID: uuid.UUID{1}, Title: "1st", Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, total)
+		assert.Len(t, got, 1)
+	})
+
+	t.Run("Fails for unknown users", func(t *testing.T) {
+		_, _, err := notesS.QueryByFilter(ctx, note.Filter{UserID: uuid.New(), Limit: 10})
+		assert.ErrorContains(t, err, "queryByFilter")
+	})
+}
+
+func TestNoteService_Tracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	notesS := Setup(t, fixtureNotes())
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	_, err := notesS.QueryByID(ctx, uuid.UUID{1})
+	assert.NoError(t, err)
+	_, err = notesS.Create(ctx, note.UpdateNote{Title: note.NewTitle(""), Content: note.NewContent(""), UserID: uuid.New()})
+	assert.Error(t, err)
+	parent.End()
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 3)
+
+	assert.Equal(t, "note.QueryByID", spans[0].Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+
+	assert.Equal(t, "note.Create", spans[1].Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[1].Parent().SpanID())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+}
+
+func TestNoteService_EventHooks(t *testing.T) {
+	repo, err := memory.NewRepo(fixtureNotes())
+	assert.NoError(t, err)
+	userID := fixtureNotes()[0].UserID
+
+	hookErr := errors.New("hook failed")
+	var hooked, published []string
+	notesS := note.NewNotesService(repo, StubUserService{ids: map[uuid.UUID]struct{}{userID: {}}},
+		note.WithEventHook(func(ctx context.Context, e note.Event) error {
+			hooked = append(hooked, e.Type)
+			if e.Type == note.EventUpdated {
+				return hookErr
+			}
+			return nil
+		}),
+		note.WithPublisher(note.PublisherFunc(func(ctx context.Context, e note.Event) {
+			published = append(published, e.Type)
+		})),
+	)
+
+	created, err := notesS.Create(context.Background(), note.UpdateNote{Title: note.NewTitle("new"), Content: note.NewContent(""), UserID: userID})
+	assert.NoError(t, err)
+	_, err = notesS.Update(context.Background(), created, note.UpdateNote{Title: note.NewTitle("renamed")})
+	assert.ErrorIs(t, err, hookErr)
+	assert.NoError(t, notesS.Delete(context.Background(), created.ID))
+
+	assert.Equal(t, []string{note.EventCreated, note.EventUpdated, note.EventDeleted}, hooked)
+	assert.Equal(t, []string{note.EventCreated, note.EventDeleted}, published)
+}
+
+func TestNoteService_Transactions(t *testing.T) {
+	repo, err := memory.NewRepo(fixtureNotes())
+	assert.NoError(t, err)
+	userID := fixtureNotes()[0].UserID
+
+	hookErr := errors.New("hook failed")
+	var published []string
+	notesS := note.NewNotesService(repo, StubUserService{ids: map[uuid.UUID]struct{}{userID: {}}},
+		note.WithTransactions(transaction.NewMemory(repo)),
+		note.WithSaveHook(func(ctx context.Context, old, n note.Note) ([]note.Note, error) {
+			if n.Title.String() == "fails" {
+				return nil, hookErr
+			}
+			return nil, nil
+		}),
+		note.WithDeleteHook(func(ctx context.Context, noteID uuid.UUID) error {
+			return repo.Delete(ctx, noteID)
+		}),
+		note.WithPublisher(note.PublisherFunc(func(ctx context.Context, e note.Event) {
+			published = append(published, e.Type)
+		})),
+	)
+
+	t.Run("A change is rolled back if a hook fails", func(t *testing.T) {
+		_, err := notesS.Create(context.Background(), note.UpdateNote{Title: note.NewTitle("fails"), Content: note.NewContent(""), UserID: userID})
+		assert.ErrorIs(t, err, hookErr)
+		notes, err := repo.QueryByUserID(context.Background(), userID)
+		assert.NoError(t, err)
+		assert.Len(t, notes, 2)
+
+		n := fixtureNotes()[0]
+		_, err = notesS.Update(context.Background(), n, note.UpdateNote{Title: note.NewTitle("fails")})
+		assert.ErrorIs(t, err, hookErr)
+		got, err := repo.QueryByID(context.Background(), n.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, n.Title, got.Title)
+	})
+
+	t.Run("A change is rolled back if the repository fails after a hook", func(t *testing.T) {
+		// The delete hook deletes the note, so that the repository doesn't
+		// find it anymore.
+		n := fixtureNotes()[0]
+		assert.Error(t, notesS.Delete(context.Background(), n.ID))
+		_, err := repo.QueryByID(context.Background(), n.ID)
+		assert.NoError(t, err)
+	})
+
+	assert.Empty(t, published)
+}
+
+func TestNoteService_DeleteByUserID(t *testing.T) {
+	repo, err := memory.NewRepo(fixtureNotes())
+	assert.NoError(t, err)
+	rob, anna := fixtureNotes()[0].UserID, fixtureNotes()[2].UserID
+	txm := transaction.NewMemory(repo)
+
+	var deleted []uuid.UUID
+	var published []note.Event
+	notesS := note.NewNotesService(repo, StubUserService{ids: map[uuid.UUID]struct{}{rob: {}, anna: {}}},
+		note.WithTransactions(txm),
+		note.WithDeleteHook(func(ctx context.Context, noteID uuid.UUID) error {
+			deleted = append(deleted, noteID)
+			return nil
+		}),
+		note.WithPublisher(note.PublisherFunc(func(ctx context.Context, e note.Event) {
+			published = append(published, e)
+		})),
+	)
+
+	t.Run("The events are published once the outer transaction commits", func(t *testing.T) {
+		userErr := errors.New("user not deleted")
+		err := txm.WithinTx(context.Background(), func(ctx context.Context) error {
+			if err := notesS.DeleteByUserID(ctx, rob); err != nil {
+				return err
+			}
+			return userErr
+		})
+		assert.ErrorIs(t, err, userErr)
+		assert.Empty(t, published)
+		notes, err := repo.QueryByUserID(context.Background(), rob)
+		assert.NoError(t, err)
+		assert.Len(t, notes, 2)
+	})
+
+	t.Run("The notes of the user are deleted with their hooks and events", func(t *testing.T) {
+		deleted = nil
+		assert.NoError(t, notesS.DeleteByUserID(context.Background(), rob))
+
+		notes, err := repo.QueryByUserID(context.Background(), rob)
+		assert.NoError(t, err)
+		assert.Empty(t, notes)
+		notes, err = repo.QueryByUserID(context.Background(), anna)
+		assert.NoError(t, err)
+		assert.Len(t, notes, 2)
+
+		assert.ElementsMatch(t, []uuid.UUID{fixtureNotes()[0].ID, fixtureNotes()[1].ID}, deleted)
+		assert.ElementsMatch(t, []note.Event{
+			{Type: note.EventDeleted, Note: fixtureNotes()[0]},
+			{Type: note.EventDeleted, Note: fixtureNotes()[1]},
+		}, published)
+	})
+}
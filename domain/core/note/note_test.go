@@ -1,9 +1,11 @@
 package note_test
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -46,3 +48,40 @@ func TestNote(t *testing.T) {
 		assert.True(t, content.IsEmpty())
 	})
 }
+
+func TestNote_JSON(t *testing.T) {
+	t.Run("Values are encoded as strings, empty values as null", func(t *testing.T) {
+		n := note.Note{ID: uuid.UUID{1}, Title: note.NewTitle("title"), UserID: uuid.UUID{2}}
+		data, err := json.Marshal(n)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{
+			"ID": "01000000-0000-0000-0000-000000000000",
+			"Title": "title",
+			"Content": null,
+			"Format": null,
+			"UserID": "02000000-0000-0000-0000-000000000000"
+		}`, string(data))
+	})
+
+	t.Run("Null leaves the value empty", func(t *testing.T) {
+		var un note.UpdateNote
+		assert.NoError(t, json.Unmarshal([]byte(`{"Title": "title", "Content": null}`), &un))
+		assert.Equal(t, "title", un.Title.String())
+		assert.True(t, un.Content.IsEmpty())
+
+		assert.NoError(t, json.Unmarshal([]byte(`{"Content": ""}`), &un))
+		assert.False(t, un.Content.IsEmpty())
+	})
+
+	t.Run("Non-strings are rejected", func(t *testing.T) {
+		var tt note.Title
+		assert.Error(t, json.Unmarshal([]byte(`1`), &tt))
+	})
+
+	t.Run("Formats are parsed", func(t *testing.T) {
+		var f note.Format
+		assert.NoError(t, json.Unmarshal([]byte(`"markdown"`), &f))
+		assert.Equal(t, note.FormatMarkdown, f)
+		assert.ErrorIs(t, json.Unmarshal([]byte(`"html"`), &f), note.ErrInvalidFormat)
+	})
+}
@@ -1,15 +1,21 @@
 package memory
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/Keisn1/note-taking-app/domain/core/note"
 	"github.com/google/uuid"
 )
 
 type Repo struct {
+	mu    *sync.Mutex
 	notes map[uuid.UUID]note.Note
 }
 
@@ -19,6 +25,7 @@ func NewRepo(notes []note.Note) (Repo, error) {
 		return Repo{}, fmt.Errorf("newNotesRepo: %w", err)
 	}
 
+	nR.mu = &sync.Mutex{}
 	nR.notes = make(map[uuid.UUID]note.Note)
 	for _, n := range notes {
 		nR.notes[n.ID] = n
@@ -34,7 +41,10 @@ func MustNewRepo(notes []note.Note) Repo {
 	return nr
 }
 
-func (nR Repo) Delete(noteID uuid.UUID) error {
+func (nR Repo) Delete(ctx context.Context, noteID uuid.UUID) error {
+	nR.mu.Lock()
+	defer nR.mu.Unlock()
+
 	if _, ok := nR.notes[noteID]; ok {
 		delete(nR.notes, noteID)
 		return nil
@@ -43,7 +53,10 @@ func (nR Repo) Delete(noteID uuid.UUID) error {
 
 }
 
-func (nR Repo) Create(n note.Note) error {
+func (nR Repo) Create(ctx context.Context, n note.Note) error {
+	nR.mu.Lock()
+	defer nR.mu.Unlock()
+
 	if _, ok := nR.notes[n.ID]; ok {
 		return fmt.Errorf("create: already present %s", n.ID)
 	}
@@ -51,7 +64,10 @@ func (nR Repo) Create(n note.Note) error {
 	return nil
 }
 
-func (nR Repo) Update(note note.Note) error {
+func (nR Repo) Update(ctx context.Context, note note.Note) error {
+	nR.mu.Lock()
+	defer nR.mu.Unlock()
+
 	if _, ok := nR.notes[note.ID]; ok {
 		nR.notes[note.ID] = note
 		return nil
@@ -60,29 +76,91 @@ func (nR Repo) Update(note note.Note) error {
 }
 
 func (nR Repo) QueryByID(ctx context.Context, noteID uuid.UUID) (note.Note, error) {
+	nR.mu.Lock()
+	defer nR.mu.Unlock()
+
 	for _, n := range nR.notes {
 		if n.ID == noteID {
 			return n, nil
 		}
 	}
-	return note.Note{}, fmt.Errorf("GetNoteByID: Not found [%s]", noteID)
+	return note.Note{}, fmt.Errorf("GetNoteByID: Not found [%s]: %w", noteID, note.ErrNoteNotFound)
 }
 
-func (nR Repo) QueryByUserID(userID uuid.UUID) ([]note.Note, error) {
+// QueryByIDForUpdate is QueryByID, a transaction.Memory runs one transaction
+// at a time.
+func (nR Repo) QueryByIDForUpdate(ctx context.Context, noteID uuid.UUID) (note.Note, error) {
+	return nR.QueryByID(ctx, noteID)
+}
+
+func (nR Repo) QueryByUserID(ctx context.Context, userID uuid.UUID) ([]note.Note, error) {
+	nR.mu.Lock()
+	defer nR.mu.Unlock()
+
 	var ret []note.Note
-	var found bool
 	for _, n := range nR.notes {
 		if n.UserID == userID {
-			found = true
 			ret = append(ret, n)
 		}
 	}
-	if !found {
-		return nil, fmt.Errorf("getNotesByUserID: not found [%s]", userID)
-	}
 	return ret, nil
 }
 
+// IterateByUserID calls fn with the notes of the user ordered by title and ID.
+func (nR Repo) IterateByUserID(ctx context.Context, userID uuid.UUID, fn func(note.Note) error) error {
+	notes, _ := nR.QueryByUserID(ctx, userID)
+	slices.SortFunc(notes, func(a, b note.Note) int {
+		return cmp.Or(cmp.Compare(a.Title.String(), b.Title.String()), cmp.Compare(a.ID.String(), b.ID.String()))
+	})
+	for _, n := range notes {
+		if err := fn(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// QueryByFilter returns the notes selected by f ordered by the lower-cased
+// title and the ID.
+func (nR Repo) QueryByFilter(ctx context.Context, f note.Filter) ([]note.Note, error) {
+	notes := nR.filter(f)
+	slices.SortFunc(notes, func(a, b note.Note) int {
+		return cmp.Or(
+			cmp.Compare(strings.ToLower(a.Title.String()), strings.ToLower(b.Title.String())),
+			cmp.Compare(a.ID.String(), b.ID.String()),
+		)
+	})
+	if f.Offset >= len(notes) {
+		return nil, nil
+	}
+	return notes[f.Offset:min(f.Offset+f.Limit, len(notes))], nil
+}
+
+func (nR Repo) CountByFilter(ctx context.Context, f note.Filter) (int, error) {
+	return len(nR.filter(f)), nil
+}
+
+func (nR Repo) filter(f note.Filter) []note.Note {
+	contains := func(s, substr string) bool {
+		return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+	}
+
+	nR.mu.Lock()
+	defer nR.mu.Unlock()
+
+	var ret []note.Note
+	for _, n := range nR.notes {
+		if n.UserID != f.UserID || !contains(n.Title.String(), f.Title) {
+			continue
+		}
+		if !contains(n.Title.String(), f.Query) && !contains(n.Content.String(), f.Query) {
+			continue
+		}
+		ret = append(ret, n)
+	}
+	return ret
+}
+
 func noDuplicate(notes []note.Note) error {
 	noteIDSet := make(map[uuid.UUID]struct{})
 	for _, n := range notes {
@@ -93,3 +171,17 @@ func noDuplicate(notes []note.Note) error {
 	}
 	return nil
 }
+
+// Snapshot copies the notes for a transaction.Memory.
+func (nR Repo) Snapshot() (restore func()) {
+	nR.mu.Lock()
+	defer nR.mu.Unlock()
+
+	notes := maps.Clone(nR.notes)
+	return func() {
+		nR.mu.Lock()
+		defer nR.mu.Unlock()
+		clear(nR.notes)
+		maps.Copy(nR.notes, notes)
+	}
+}
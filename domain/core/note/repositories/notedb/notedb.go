@@ -3,83 +3,136 @@ package notedb
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/Keisn1/note-taking-app/domain/core/outbox"
+	"github.com/Keisn1/note-taking-app/domain/core/outbox/repositories/outboxdb"
+	"github.com/Keisn1/note-taking-app/foundation/tracing"
+	"github.com/Keisn1/note-taking-app/foundation/transaction"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/Keisn1/note-taking-app/domain/core/note/repositories/notedb")
+
 type dbNote struct {
 	id      uuid.UUID
 	title   string
 	content string
+	format  string
 	userID  uuid.UUID
 }
 
-type database interface {
-	Query(query string, args ...interface{}) (*sql.Rows, error)
-	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
-	Exec(query string, args ...any) (sql.Result, error)
-}
-
 type NoteRepo struct {
-	db database
+	db transaction.DB
 }
 
-func NewNotesRepo(db database) NoteRepo {
+func NewNotesRepo(db transaction.DB) NoteRepo {
 	return NoteRepo{db: db}
 }
 
-func (nR NoteRepo) Update(n note.Note) error {
+func (nR NoteRepo) Update(ctx context.Context, n note.Note) (err error) {
 	updateRow := `
 	UPDATE notes
-	SET title = $1, content = $2 WHERE id=$3 `
+	SET title = $1, content = $2, format = $3 WHERE id=$4 RETURNING user_id`
+	ctx, span := startSpan(ctx, "notedb.Update", updateRow)
+	defer tracing.End(span, &err)
 
-	res, err := nR.db.Exec(updateRow, n.Title.String(), n.Content.String(), n.ID)
+	err = nR.withEvent(ctx, func(tx transaction.Conn) (outbox.DomainEvent, error) {
+		// The owner is taken from the row, the event must not name another
+		// one than the note has.
+		err := tx.QueryRowContext(ctx, updateRow, n.Title.String(), n.Content.String(), n.Format.String(), n.ID).Scan(&n.UserID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, note.ErrNoteNotFound
+		}
+		return note.NewNoteUpdated(n), err
+	})
+	if errors.Is(err, note.ErrNoteNotFound) {
+		return err
+	}
 	if err != nil {
 		return fmt.Errorf("update: [%v]: %w", n, err)
 	}
-	if c, _ := res.RowsAffected(); c == 0 {
-		return note.ErrNoteNotFound
-	}
-
 	return nil
 }
 
-func (nR NoteRepo) Delete(noteID uuid.UUID) error {
-	deleteRow := `DELETE FROM notes WHERE id=$1`
-	res, _ := nR.db.Exec(deleteRow, noteID)
+func (nR NoteRepo) Delete(ctx context.Context, noteID uuid.UUID) (err error) {
+	deleteRow := `DELETE FROM notes WHERE id=$1 RETURNING user_id`
+	ctx, span := startSpan(ctx, "notedb.Delete", deleteRow)
+	defer tracing.End(span, &err)
 
-	if c, _ := res.RowsAffected(); c == 0 {
-		return note.ErrNoteNotFound
+	err = nR.withEvent(ctx, func(tx transaction.Conn) (outbox.DomainEvent, error) {
+		var userID uuid.UUID
+		err := tx.QueryRowContext(ctx, deleteRow, noteID).Scan(&userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, note.ErrNoteNotFound
+		}
+		return note.NoteDeleted{NoteID: noteID, UserID: userID}, err
+	})
+	if errors.Is(err, note.ErrNoteNotFound) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("delete: [%s]: %w", noteID, err)
 	}
-
 	return nil
 }
 
-func (nR NoteRepo) Create(n note.Note) error {
-	insertRow := `INSERT INTO notes (id, title, content, user_id) VALUES ($1, $2, $3, $4)`
-	_, err := nR.db.Exec(
-		insertRow,
-		n.ID,
-		n.Title.String(),
-		n.Content.String(),
-		n.UserID,
-	)
+func (nR NoteRepo) Create(ctx context.Context, n note.Note) (err error) {
+	insertRow := `INSERT INTO notes (id, title, content, format, user_id) VALUES ($1, $2, $3, $4, $5)`
+	ctx, span := startSpan(ctx, "notedb.Create", insertRow)
+	defer tracing.End(span, &err)
+
+	err = nR.withEvent(ctx, func(tx transaction.Conn) (outbox.DomainEvent, error) {
+		_, err := tx.ExecContext(
+			ctx,
+			insertRow,
+			n.ID,
+			n.Title.String(),
+			n.Content.String(),
+			n.Format.String(),
+			n.UserID,
+		)
+		return note.NewNoteCreated(n), err
+	})
 	if err != nil {
-		return fmt.Errorf("create: [%s]", n.ID)
+		return fmt.Errorf("create: [%s]: %w", n.ID, err)
 	}
 
 	return nil
 }
 
-func (nR NoteRepo) QueryByID(ctx context.Context, noteID uuid.UUID) (note.Note, error) {
+// withEvent runs fn and writes the event it returns to the outbox in one
+// transaction, or in the transaction of ctx.
+func (nR NoteRepo) withEvent(ctx context.Context, fn func(tx transaction.Conn) (outbox.DomainEvent, error)) error {
+	return transaction.Run(ctx, nR.db, func(tx transaction.Conn) error {
+		de, err := fn(tx)
+		if err != nil {
+			return err
+		}
+		e, err := outbox.NewEvent(de)
+		if err != nil {
+			return err
+		}
+		return outboxdb.NewOutboxRepo(tx).Append(ctx, e)
+	})
+}
+
+func (nR NoteRepo) QueryByID(ctx context.Context, noteID uuid.UUID) (_ note.Note, err error) {
 	queryByIDSqlStmt := `
-	SELECT id, title, content, user_id FROM notes WHERE id=$1;
+	SELECT id, title, content, format, user_id FROM notes WHERE id=$1;
 	`
-	row := nR.db.QueryRowContext(ctx, queryByIDSqlStmt, noteID)
+	ctx, span := startSpan(ctx, "notedb.QueryByID", queryByIDSqlStmt)
+	defer tracing.End(span, &err)
+
+	row := transaction.ConnFrom(ctx, nR.db).QueryRowContext(ctx, queryByIDSqlStmt, noteID)
 	var nDB dbNote
-	err := row.Scan(&nDB.id, &nDB.title, &nDB.content, &nDB.userID)
+	err = row.Scan(&nDB.id, &nDB.title, &nDB.content, &nDB.format, &nDB.userID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return note.Note{}, note.ErrNoteNotFound
@@ -90,11 +143,35 @@ func (nR NoteRepo) QueryByID(ctx context.Context, noteID uuid.UUID) (note.Note,
 	return noteDBToNote(nDB), nil
 }
 
-func (nR NoteRepo) GetNotesByUserID(userID uuid.UUID) ([]note.Note, error) {
+// QueryByIDForUpdate locks the row of the note.
+func (nR NoteRepo) QueryByIDForUpdate(ctx context.Context, noteID uuid.UUID) (_ note.Note, err error) {
+	queryByIDForUpdate := `
+	SELECT id, title, content, format, user_id FROM notes WHERE id=$1 FOR UPDATE;
+	`
+	ctx, span := startSpan(ctx, "notedb.QueryByIDForUpdate", queryByIDForUpdate)
+	defer tracing.End(span, &err)
+
+	row := transaction.ConnFrom(ctx, nR.db).QueryRowContext(ctx, queryByIDForUpdate, noteID)
+	var nDB dbNote
+	err = row.Scan(&nDB.id, &nDB.title, &nDB.content, &nDB.format, &nDB.userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return note.Note{}, note.ErrNoteNotFound
+		}
+		return note.Note{}, fmt.Errorf("queryByIDForUpdate: [%s]: %w", noteID, err)
+	}
+
+	return noteDBToNote(nDB), nil
+}
+
+func (nR NoteRepo) QueryByUserID(ctx context.Context, userID uuid.UUID) (_ []note.Note, err error) {
 	getNotesByUserID := `
-	SELECT id, title, content, user_id FROM notes WHERE user_id=$1;
+	SELECT id, title, content, format, user_id FROM notes WHERE user_id=$1;
 	`
-	rows, err := nR.db.Query(getNotesByUserID, userID)
+	ctx, span := startSpan(ctx, "notedb.QueryByUserID", getNotesByUserID)
+	defer tracing.End(span, &err)
+
+	rows, err := transaction.ConnFrom(ctx, nR.db).QueryContext(ctx, getNotesByUserID, userID)
 	if err != nil {
 		return nil, fmt.Errorf("getNotesByUserID: [%s]: %w", userID, err)
 	}
@@ -103,15 +180,15 @@ func (nR NoteRepo) GetNotesByUserID(userID uuid.UUID) ([]note.Note, error) {
 	var notes []dbNote
 	for rows.Next() {
 		var nDB dbNote
-		err := rows.Scan(&nDB.id, &nDB.title, &nDB.content, &nDB.userID)
+		err := rows.Scan(&nDB.id, &nDB.title, &nDB.content, &nDB.format, &nDB.userID)
 		if err != nil {
 			return nil, fmt.Errorf("getNotesByUserId: [%s]: scan rows: %w", userID, err)
 		}
 		notes = append(notes, nDB)
 	}
 
-	if len(notes) == 0 {
-		return nil, fmt.Errorf("getNotesByUserID: not found [%s]", userID)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("getNotesByUserID: [%s]: %w", userID, err)
 	}
 
 	var ret []note.Note
@@ -122,11 +199,116 @@ func (nR NoteRepo) GetNotesByUserID(userID uuid.UUID) ([]note.Note, error) {
 	return ret, nil
 }
 
+// IterateByUserID scans the notes of the user one row at a time and calls fn
+// with each of them.
+func (nR NoteRepo) IterateByUserID(ctx context.Context, userID uuid.UUID, fn func(note.Note) error) (err error) {
+	iterateByUserID := `
+	SELECT id, title, content, format, user_id FROM notes WHERE user_id=$1 ORDER BY title, id;
+	`
+	ctx, span := startSpan(ctx, "notedb.IterateByUserID", iterateByUserID)
+	defer tracing.End(span, &err)
+
+	rows, err := transaction.ConnFrom(ctx, nR.db).QueryContext(ctx, iterateByUserID, userID)
+	if err != nil {
+		return fmt.Errorf("iterateByUserID: [%s]: %w", userID, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var nDB dbNote
+		if err := rows.Scan(&nDB.id, &nDB.title, &nDB.content, &nDB.format, &nDB.userID); err != nil {
+			return fmt.Errorf("iterateByUserID: [%s]: scan rows: %w", userID, err)
+		}
+		if err := fn(noteDBToNote(nDB)); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterateByUserID: [%s]: %w", userID, err)
+	}
+	return nil
+}
+
+func (nR NoteRepo) QueryByFilter(ctx context.Context, f note.Filter) (_ []note.Note, err error) {
+	where, args := filterWhere(f)
+	args = append(args, f.Limit, f.Offset)
+	queryByFilter := `SELECT id, title, content, format, user_id FROM notes WHERE ` + where +
+		fmt.Sprintf(` ORDER BY lower(title), id LIMIT $%d OFFSET $%d`, len(args)-1, len(args))
+	ctx, span := startSpan(ctx, "notedb.QueryByFilter", queryByFilter)
+	defer tracing.End(span, &err)
+
+	rows, err := transaction.ConnFrom(ctx, nR.db).QueryContext(ctx, queryByFilter, args...)
+	if err != nil {
+		return nil, fmt.Errorf("queryByFilter: [%s]: %w", f.UserID, err)
+	}
+	defer rows.Close()
+
+	var notes []note.Note
+	for rows.Next() {
+		var nDB dbNote
+		if err := rows.Scan(&nDB.id, &nDB.title, &nDB.content, &nDB.format, &nDB.userID); err != nil {
+			return nil, fmt.Errorf("queryByFilter: [%s]: scan rows: %w", f.UserID, err)
+		}
+		notes = append(notes, noteDBToNote(nDB))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("queryByFilter: [%s]: %w", f.UserID, err)
+	}
+	return notes, nil
+}
+
+func (nR NoteRepo) CountByFilter(ctx context.Context, f note.Filter) (_ int, err error) {
+	where, args := filterWhere(f)
+	countByFilter := `SELECT count(*) FROM notes WHERE ` + where
+	ctx, span := startSpan(ctx, "notedb.CountByFilter", countByFilter)
+	defer tracing.End(span, &err)
+
+	var count int
+	if err := transaction.ConnFrom(ctx, nR.db).QueryRowContext(ctx, countByFilter, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("countByFilter: [%s]: %w", f.UserID, err)
+	}
+	return count, nil
+}
+
+// filterWhere returns the condition selecting the notes that match f, without
+// Offset and Limit.
+func filterWhere(f note.Filter) (string, []any) {
+	where := []string{"user_id = $1"}
+	args := []any{f.UserID}
+	if f.Title != "" {
+		args = append(args, containsPattern(f.Title))
+		where = append(where, fmt.Sprintf(`title ILIKE $%d`, len(args)))
+	}
+	if f.Query != "" {
+		args = append(args, containsPattern(f.Query))
+		where = append(where, fmt.Sprintf(`(title ILIKE $%[1]d OR content ILIKE $%[1]d)`, len(args)))
+	}
+	return strings.Join(where, " AND "), args
+}
+
+// containsPattern returns a LIKE pattern matching s anywhere. The wildcards
+// in s are escaped with the default escape character \.
+func containsPattern(s string) string {
+	return "%" + strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s) + "%"
+}
+
+func startSpan(ctx context.Context, name, query string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.statement", query),
+		),
+	)
+}
+
 func noteDBToNote(nDB dbNote) note.Note {
 	return note.Note{
 		ID:      nDB.id,
 		Title:   note.NewTitle(nDB.title),
 		Content: note.NewContent(nDB.content),
+		Format:  note.Format(nDB.format),
 		UserID:  nDB.userID,
 	}
 }
@@ -6,9 +6,12 @@ import (
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/Keisn1/note-taking-app/domain/core/note"
 	"github.com/Keisn1/note-taking-app/domain/core/note/repositories/notedb"
+	"github.com/Keisn1/note-taking-app/domain/core/outbox/repositories/outboxdb"
+	"github.com/Keisn1/note-taking-app/foundation/transaction"
 	"github.com/google/uuid"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/stretchr/testify/assert"
@@ -33,14 +36,14 @@ func TestNotesRepo_Update(t *testing.T) {
 	t.Run("Given an error received by the DB, the error is forwarded", func(t *testing.T) {
 		nR := notedb.NewNotesRepo(&stubSQLDB{})
 		n := note.Note{ID: uuid.New(), Title: note.NewTitle(""), Content: note.NewContent(""), UserID: uuid.New()}
-		err := nR.Update(n)
+		err := nR.Update(context.Background(), n)
 		assert.ErrorContains(t, err, fmt.Sprintf("update: [%v]: DBError", n))
 	})
 
 	t.Run("Given a note NOT present in the system, return ErrNoteNotFound", func(t *testing.T) {
 		nR := notedb.NewNotesRepo(testDB)
 		n := note.Note{ID: uuid.New(), Title: note.NewTitle(""), Content: note.NewContent(""), UserID: uuid.New()}
-		err := nR.Update(n)
+		err := nR.Update(context.Background(), n)
 		assert.ErrorContains(t, err, note.ErrNoteNotFound.Error())
 	})
 
@@ -48,7 +51,7 @@ func TestNotesRepo_Update(t *testing.T) {
 		nR := notedb.NewNotesRepo(testDB)
 		n := note.Note{ID: uuid.UUID{1}, Title: note.NewTitle("new title"), Content: note.NewContent("new content"), UserID: uuid.UUID{1}}
 
-		err := nR.Update(n)
+		err := nR.Update(context.Background(), n)
 		assert.NoError(t, err)
 
 		got, err := nR.QueryByID(context.Background(), n.ID)
@@ -68,12 +71,12 @@ func TestNotesRepo_Delete(t *testing.T) {
 		noteID := uuid.New()
 		n := note.Note{ID: noteID, Title: note.NewTitle("new title"), Content: note.NewContent("new content"), UserID: uuid.UUID{1}}
 
-		nR.Create(n)
+		nR.Create(context.Background(), n)
 		got, err := nR.QueryByID(ctx, noteID)
 		assert.NoError(t, err)
 		assert.Equal(t, n, got)
 
-		err = nR.Delete(noteID)
+		err = nR.Delete(context.Background(), noteID)
 		assert.NoError(t, err)
 
 		_, err = nR.QueryByID(ctx, noteID)
@@ -84,7 +87,7 @@ func TestNotesRepo_Delete(t *testing.T) {
 		nR := notedb.NewNotesRepo(testDB)
 
 		noteID := uuid.New()
-		err := nR.Delete(noteID)
+		err := nR.Delete(context.Background(), noteID)
 		assert.ErrorContains(t, err, note.ErrNoteNotFound.Error())
 		assert.ErrorContains(t, err, "not found")
 	})
@@ -100,7 +103,7 @@ func TestNotesRepo_Create(t *testing.T) {
 		ctx := context.Background()
 		n := note.Note{ID: uuid.UUID{1}, Title: note.NewTitle("new title"), Content: note.NewContent("new content"), UserID: uuid.UUID{1}}
 
-		err := nR.Create(n)
+		err := nR.Create(context.Background(), n)
 		assert.NoError(t, err)
 
 		got, err := nR.QueryByID(ctx, n.ID)
@@ -115,7 +118,7 @@ func TestNotesRepo_Create(t *testing.T) {
 		nR := notedb.NewNotesRepo(testDB)
 
 		n := note.Note{ID: uuid.UUID{1}, Title: note.NewTitle("new title"), Content: note.NewContent("new content"), UserID: uuid.UUID{1}}
-		err := nR.Create(n)
+		err := nR.Create(context.Background(), n)
 		assert.Error(t, err)
 		assert.ErrorContains(t, err, fmt.Sprintf("create: [%s]", n.ID))
 	})
@@ -138,13 +141,13 @@ func TestNotesRepo_QueryByID(t *testing.T) {
 			{
 				noteID: uuid.UUID{1},
 				want: note.Note{
-					ID: uuid.UUID{1}, Title: note.NewTitle("robs 1st note"), Content: note.NewContent("robs 1st note content"), UserID: uuid.UUID{1},
+					ID: uuid.UUID{1}, Title: note.NewTitle("robs 1st note"), Content: note.NewContent("robs 1st note content"), Format: note.FormatPlain, UserID: uuid.UUID{1},
 				},
 			},
 			{
 				noteID: uuid.UUID{3},
 				want: note.Note{
-					ID: uuid.UUID{3}, Title: note.NewTitle("annas 1st note"), Content: note.NewContent("annas 1st note content"), UserID: uuid.UUID{2},
+					ID: uuid.UUID{3}, Title: note.NewTitle("annas 1st note"), Content: note.NewContent("annas 1st note content"), Format: note.FormatPlain, UserID: uuid.UUID{2},
 				},
 			},
 		}
@@ -182,32 +185,31 @@ func TestNotesRepo_GetNotesByUserID(t *testing.T) {
 			{
 				userID: uuid.UUID{1},
 				want: []note.Note{
-					{ID: uuid.UUID{1}, Title: note.NewTitle("robs 1st note"), Content: note.NewContent("robs 1st note content"), UserID: uuid.UUID{1}},
-					{ID: uuid.UUID{2}, Title: note.NewTitle("robs 2nd note"), Content: note.NewContent("robs 2nd note content"), UserID: uuid.UUID{1}},
+					{ID: uuid.UUID{1}, Title: note.NewTitle("robs 1st note"), Content: note.NewContent("robs 1st note content"), Format: note.FormatPlain, UserID: uuid.UUID{1}},
+					{ID: uuid.UUID{2}, Title: note.NewTitle("robs 2nd note"), Content: note.NewContent("robs 2nd note content"), Format: note.FormatPlain, UserID: uuid.UUID{1}},
 				},
 			},
 			{
 				userID: uuid.UUID{2},
 				want: []note.Note{
-					{ID: uuid.UUID{3}, Title: note.NewTitle("annas 1st note"), Content: note.NewContent("annas 1st note content"), UserID: uuid.UUID{2}},
-					{ID: uuid.UUID{4}, Title: note.NewTitle("annas 2nd note"), Content: note.NewContent("annas 2nd note content"), UserID: uuid.UUID{2}},
+					{ID: uuid.UUID{3}, Title: note.NewTitle("annas 1st note"), Content: note.NewContent("annas 1st note content"), Format: note.FormatPlain, UserID: uuid.UUID{2}},
+					{ID: uuid.UUID{4}, Title: note.NewTitle("annas 2nd note"), Content: note.NewContent("annas 2nd note content"), Format: note.FormatPlain, UserID: uuid.UUID{2}},
 				},
 			},
 		}
 
 		for _, tc := range testCases {
-			got, err := nR.GetNotesByUserID(tc.userID)
+			got, err := nR.QueryByUserID(context.Background(), tc.userID)
 			assert.NoError(t, err)
 			assert.ElementsMatch(t, tc.want, got)
 		}
 	})
-	t.Run("Returns error on missing user", func(t *testing.T) {
+	t.Run("Returns no notes for user without notes", func(t *testing.T) {
 		nR := notedb.NewNotesRepo(testDB)
 
-		userID := uuid.UUID{}
-		wantErrMsg := fmt.Sprintf("getNotesByUserID: not found [%s]", userID)
-		_, err := nR.GetNotesByUserID(userID)
-		assert.ErrorContains(t, err, wantErrMsg)
+		got, err := nR.QueryByUserID(context.Background(), uuid.UUID{})
+		assert.NoError(t, err)
+		assert.Empty(t, got)
 	})
 
 	t.Run("Fowards error on database error", func(t *testing.T) {
@@ -216,8 +218,204 @@ func TestNotesRepo_GetNotesByUserID(t *testing.T) {
 
 		userID := uuid.UUID{}
 		wantErr := fmt.Errorf("getNotesByUserID: [%s]: %w", userID, errors.New("DBError"))
-		_, err := nR.GetNotesByUserID(userID)
+		_, err := nR.QueryByUserID(context.Background(), userID)
+		assert.EqualError(t, err, wantErr.Error())
+	})
+
+}
+
+func TestNotesRepo_IterateByUserID(t *testing.T) {
+	testDB, deleteTable := SetupNotesTable(t, fixtureNotes())
+	defer testDB.Close()
+	defer deleteTable()
+
+	t.Run("Calls fn with the notes of the user ordered by title", func(t *testing.T) {
+		nR := notedb.NewNotesRepo(testDB)
+
+		var got []string
+		err := nR.IterateByUserID(context.Background(), uuid.UUID{2}, func(n note.Note) error {
+			got = append(got, n.Title.String())
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"annas 1st note", "annas 2nd note"}, got)
+	})
+
+	t.Run("Stops at the first error of fn", func(t *testing.T) {
+		nR := notedb.NewNotesRepo(testDB)
+		stop := errors.New("stop")
+
+		calls := 0
+		err := nR.IterateByUserID(context.Background(), uuid.UUID{1}, func(n note.Note) error {
+			calls++
+			return stop
+		})
+		assert.ErrorIs(t, err, stop)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Fowards error on database error", func(t *testing.T) {
+		nR := notedb.NewNotesRepo(&stubSQLDB{})
+
+		userID := uuid.UUID{}
+		wantErr := fmt.Errorf("iterateByUserID: [%s]: %w", userID, errors.New("DBError"))
+		err := nR.IterateByUserID(context.Background(), userID, func(note.Note) error { return nil })
 		assert.EqualError(t, err, wantErr.Error())
 	})
+}
+
+func TestNotesRepo_QueryByFilter(t *testing.T) {
+	notes := append(fixtureNotes(),
+		note.Note{ID: uuid.UUID{5}, Title: note.NewTitle("Rob's 100% plan"), Content: note.NewContent("a_b"), Format: note.FormatPlain, UserID: uuid.UUID{1}},
+	)
+	testDB, deleteTable := SetupNotesTable(t, notes)
+	defer testDB.Close()
+	defer deleteTable()
+	nR := notedb.NewNotesRepo(testDB)
+	ctx := context.Background()
+
+	titles := func(notes []note.Note) []string {
+		var titles []string
+		for _, n := range notes {
+			titles = append(titles, n.Title.String())
+		}
+		return titles
+	}
+
+	testCases := []struct {
+		name   string
+		filter note.Filter
+		want   []string
+		total  int
+	}{
+		{name: "all notes ordered by lower-cased title", filter: note.Filter{UserID: uuid.UUID{1}, Limit: 10}, want: []string{"Rob's 100% plan", "robs 1st note", "robs 2nd note"}, total: 3},
+		{name: "page", filter: note.Filter{UserID: uuid.UUID{1}, Offset: 1, Limit: 1}, want: []string{"robs 1st note"}, total: 3},
+		{name: "title", filter: note.Filter{UserID: uuid.UUID{1}, Title: "2ND", Limit: 10}, want: []string{"robs 2nd note"}, total: 1},
+		{name: "title or content", filter: note.Filter{UserID: uuid.UUID{1}, Query: "1st note content", Limit: 10}, want: []string{"robs 1st note"}, total: 1},
+		{name: "wildcards are matched literally", filter: note.Filter{UserID: uuid.UUID{1}, Query: "0%", Limit: 10}, want: []string{"Rob's 100% plan"}, total: 1},
+		{name: "underscore", filter: note.Filter{UserID: uuid.UUID{1}, Query: "_", Limit: 10}, want: []string{"Rob's 100% plan"}, total: 1},
+		{name: "no match", filter: note.Filter{UserID: uuid.UUID{2}, Query: "robs", Limit: 10}, total: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := nR.QueryByFilter(ctx, tc.filter)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, titles(got))
+
+			total, err := nR.CountByFilter(ctx, tc.filter)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.total, total)
+		})
+	}
+
+	t.Run("Fowards error on database error", func(t *testing.T) {
+		nR := notedb.NewNotesRepo(&stubSQLDB{})
+		_, err := nR.QueryByFilter(ctx, note.Filter{Limit: 10})
+		assert.ErrorContains(t, err, "queryByFilter: [00000000-0000-0000-0000-000000000000]: DBError")
+		_, err = nR.CountByFilter(ctx, note.Filter{})
+		assert.ErrorContains(t, err, "countByFilter: [00000000-0000-0000-0000-000000000000]: DBError")
+	})
+}
+
+func TestNotesRepo_Outbox(t *testing.T) {
+	testDB, deleteTable := SetupNotesTable(t, fixtureNotes())
+	defer testDB.Close()
+	defer deleteTable()
+
+	ctx := context.Background()
+	nR := notedb.NewNotesRepo(testDB)
+	n := note.Note{ID: uuid.New(), Title: note.NewTitle("new title"), Content: note.NewContent("new content"), Format: note.FormatPlain, UserID: uuid.UUID{1}}
+
+	assert.NoError(t, nR.Create(ctx, n))
+	assert.Error(t, nR.Create(ctx, n))
+	n.Title = note.NewTitle("newer title")
+	assert.NoError(t, nR.Update(ctx, n))
+	assert.NoError(t, nR.Delete(ctx, n.ID))
+	assert.ErrorIs(t, nR.Delete(ctx, n.ID), note.ErrNoteNotFound)
+
+	records, err := outboxdb.NewOutboxRepo(testDB).Claim(ctx, time.Now(), time.Now(), 10)
+	assert.NoError(t, err)
+	var events []note.Event
+	for _, r := range records {
+		assert.Equal(t, n.ID, r.AggregateID)
+		e, err := note.ParseEvent(r.Type, r.Payload)
+		assert.NoError(t, err)
+		events = append(events, e)
+	}
+	assert.Equal(t, []note.Event{
+		{Type: note.EventCreated, Note: note.Note{ID: n.ID, Title: note.NewTitle("new title"), Content: note.NewContent("new content"), Format: note.FormatPlain, UserID: n.UserID}},
+		{Type: note.EventUpdated, Note: n},
+		{Type: note.EventDeleted, Note: note.Note{ID: n.ID, UserID: n.UserID}},
+	}, events)
+}
+
+func TestNotesRepo_Transactions(t *testing.T) {
+	testDB, deleteTable := SetupNotesTable(t, fixtureNotes())
+	defer testDB.Close()
+	defer deleteTable()
+
+	ctx := context.Background()
+	nR := notedb.NewNotesRepo(testDB)
+	txm := transaction.NewPostgres(testDB)
+	n := note.Note{ID: uuid.New(), Title: note.NewTitle("new title"), Content: note.NewContent("new content"), Format: note.FormatPlain, UserID: uuid.UUID{1}}
+
+	t.Run("The changes are rolled back with their events if fn fails", func(t *testing.T) {
+		failed := errors.New("failed")
+		err := txm.WithinTx(ctx, func(ctx context.Context) error {
+			assert.NoError(t, nR.Create(ctx, n))
+			assert.NoError(t, nR.Delete(ctx, uuid.UUID{2}))
+			got, err := nR.QueryByID(ctx, n.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, n, got)
+			return failed
+		})
+		assert.ErrorIs(t, err, failed)
+
+		_, err = nR.QueryByID(ctx, n.ID)
+		assert.ErrorIs(t, err, note.ErrNoteNotFound)
+		_, err = nR.QueryByID(ctx, uuid.UUID{2})
+		assert.NoError(t, err)
+		records, err := outboxdb.NewOutboxRepo(testDB).Claim(ctx, time.Now(), time.Now(), 10)
+		assert.NoError(t, err)
+		assert.Empty(t, records)
+	})
 
+	t.Run("The changes are committed if fn succeeds", func(t *testing.T) {
+		err := txm.WithinTx(ctx, func(ctx context.Context) error {
+			return nR.Create(ctx, n)
+		})
+		assert.NoError(t, err)
+		_, err = nR.QueryByID(ctx, n.ID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("A note read for update stays locked until the transaction ends", func(t *testing.T) {
+		locked, release := make(chan struct{}), make(chan struct{})
+		done := make(chan error)
+		go func() {
+			done <- txm.WithinTx(ctx, func(ctx context.Context) error {
+				if _, err := nR.QueryByIDForUpdate(ctx, uuid.UUID{1}); err != nil {
+					return err
+				}
+				close(locked)
+				<-release
+				return nil
+			})
+		}()
+		<-locked
+
+		waitCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+		err := txm.WithinTx(waitCtx, func(ctx context.Context) error {
+			return nR.Update(ctx, note.Note{ID: uuid.UUID{1}, Title: note.NewTitle("changed"), Format: note.FormatPlain, UserID: uuid.UUID{1}})
+		})
+		assert.Error(t, err)
+		_, err = nR.QueryByID(ctx, uuid.UUID{1})
+		assert.NoError(t, err, "reading doesn't wait")
+
+		close(release)
+		assert.NoError(t, <-done)
+		_, err = nR.QueryByIDForUpdate(ctx, uuid.UUID{99})
+		assert.ErrorIs(t, err, note.ErrNoteNotFound)
+	})
 }
@@ -48,8 +48,21 @@ func SetupNotesTable(t *testing.T, notes []note.Note) (*sql.DB, func()) {
 							id UUID PRIMARY KEY,
 							title TEXT,
 							content TEXT,
+							format TEXT NOT NULL DEFAULT 'plain',
 							user_id UUID NOT NULL)`
-		dropNotesTable = `DROP TABLE notes`
+		createOutboxTable = `CREATE TABLE outbox(
+							seq BIGSERIAL PRIMARY KEY,
+							id UUID NOT NULL UNIQUE,
+							type TEXT NOT NULL,
+							aggregate_id UUID NOT NULL,
+							payload JSONB NOT NULL,
+							created_at TIMESTAMPTZ NOT NULL,
+							attempts INT NOT NULL DEFAULT 0,
+							next_attempt_at TIMESTAMPTZ NOT NULL,
+							handled_by TEXT NOT NULL DEFAULT '',
+							last_error TEXT NOT NULL DEFAULT '',
+							published_at TIMESTAMPTZ)`
+		dropNotesTable = `DROP TABLE notes, outbox`
 	)
 
 	dsn := fmt.Sprintf("host=localhost port=5432 user=%s password=%s sslmode=disable dbname=%s ", testUser, testPassword, testDBName)
@@ -63,13 +76,19 @@ func SetupNotesTable(t *testing.T, notes []note.Note) (*sql.DB, func()) {
 		t.Fatal(err)
 	}
 
-	insertRow := `INSERT INTO notes (id, title, content, user_id) VALUES ($1, $2, $3, $4)`
+	_, err = testDB.Exec(createOutboxTable)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	insertRow := `INSERT INTO notes (id, title, content, format, user_id) VALUES ($1, $2, $3, $4, $5)`
 	for _, n := range notes {
 		_, err = testDB.Exec(
 			insertRow,
 			n.ID,
 			n.Title.String(),
 			n.Content.String(),
+			n.Format.String(),
 			n.UserID,
 		)
 		if err != nil {
@@ -90,9 +109,9 @@ func SetupNotesTable(t *testing.T, notes []note.Note) (*sql.DB, func()) {
 
 func fixtureNotes() []note.Note {
 	return []note.Note{
-		{ID: uuid.UUID{1}, Title: note.NewTitle("robs 1st note"), Content: note.NewContent("robs 1st note content"), UserID: uuid.UUID{1}},
-		{ID: uuid.UUID{2}, Title: note.NewTitle("robs 2nd note"), Content: note.NewContent("robs 2nd note content"), UserID: uuid.UUID{1}},
-		{ID: uuid.UUID{3}, Title: note.NewTitle("annas 1st note"), Content: note.NewContent("annas 1st note content"), UserID: uuid.UUID{2}},
-		{ID: uuid.UUID{4}, Title: note.NewTitle("annas 2nd note"), Content: note.NewContent("annas 2nd note content"), UserID: uuid.UUID{2}},
+		{ID: uuid.UUID{1}, Title: note.NewTitle("robs 1st note"), Content: note.NewContent("robs 1st note content"), Format: note.FormatPlain, UserID: uuid.UUID{1}},
+		{ID: uuid.UUID{2}, Title: note.NewTitle("robs 2nd note"), Content: note.NewContent("robs 2nd note content"), Format: note.FormatPlain, UserID: uuid.UUID{1}},
+		{ID: uuid.UUID{3}, Title: note.NewTitle("annas 1st note"), Content: note.NewContent("annas 1st note content"), Format: note.FormatPlain, UserID: uuid.UUID{2}},
+		{ID: uuid.UUID{4}, Title: note.NewTitle("annas 2nd note"), Content: note.NewContent("annas 2nd note content"), Format: note.FormatPlain, UserID: uuid.UUID{2}},
 	}
 }
@@ -8,7 +8,7 @@ import (
 
 type stubSQLDB struct{}
 
-func (s *stubSQLDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+func (s *stubSQLDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
 	return nil, errors.New("DBError")
 }
 
@@ -16,6 +16,10 @@ func (s *stubSQLDB) QueryRowContext(ctx context.Context, query string, args ...a
 	return
 }
 
-func (s *stubSQLDB) Exec(query string, args ...any) (res sql.Result, err error) {
+func (s *stubSQLDB) ExecContext(ctx context.Context, query string, args ...any) (res sql.Result, err error) {
+	return nil, errors.New("DBError")
+}
+
+func (s *stubSQLDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
 	return nil, errors.New("DBError")
 }
@@ -0,0 +1,84 @@
+package notemetrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/Keisn1/note-taking-app/foundation/metrics"
+	"github.com/google/uuid"
+)
+
+const repoName = "notes"
+
+// Repo decorates a note.Repo with latency metrics and counters of the
+// created, updated and deleted notes.
+type Repo struct {
+	repo note.Repo
+}
+
+func NewRepo(repo note.Repo) Repo {
+	return Repo{repo: repo}
+}
+
+func (r Repo) Delete(ctx context.Context, noteID uuid.UUID) (err error) {
+	defer observe("delete", time.Now(), &err)
+	defer countChange("deleted", &err)
+	return r.repo.Delete(ctx, noteID)
+}
+
+func (r Repo) Create(ctx context.Context, n note.Note) (err error) {
+	defer observe("create", time.Now(), &err)
+	defer countChange("created", &err)
+	return r.repo.Create(ctx, n)
+}
+
+func (r Repo) Update(ctx context.Context, n note.Note) (err error) {
+	defer observe("update", time.Now(), &err)
+	defer countChange("updated", &err)
+	return r.repo.Update(ctx, n)
+}
+
+func (r Repo) QueryByID(ctx context.Context, noteID uuid.UUID) (_ note.Note, err error) {
+	defer observe("query_by_id", time.Now(), &err)
+	return r.repo.QueryByID(ctx, noteID)
+}
+
+func (r Repo) QueryByIDForUpdate(ctx context.Context, noteID uuid.UUID) (_ note.Note, err error) {
+	defer observe("query_by_id_for_update", time.Now(), &err)
+	return r.repo.QueryByIDForUpdate(ctx, noteID)
+}
+
+func (r Repo) QueryByUserID(ctx context.Context, userID uuid.UUID) (_ []note.Note, err error) {
+	defer observe("query_by_user_id", time.Now(), &err)
+	return r.repo.QueryByUserID(ctx, userID)
+}
+
+func (r Repo) IterateByUserID(ctx context.Context, userID uuid.UUID, fn func(note.Note) error) (err error) {
+	defer observe("iterate_by_user_id", time.Now(), &err)
+	return r.repo.IterateByUserID(ctx, userID, fn)
+}
+
+func (r Repo) QueryByFilter(ctx context.Context, f note.Filter) (_ []note.Note, err error) {
+	defer observe("query_by_filter", time.Now(), &err)
+	return r.repo.QueryByFilter(ctx, f)
+}
+
+func (r Repo) CountByFilter(ctx context.Context, f note.Filter) (_ int, err error) {
+	defer observe("count_by_filter", time.Now(), &err)
+	return r.repo.CountByFilter(ctx, f)
+}
+
+func observe(operation string, start time.Time, err *error) {
+	result := "success"
+	if *err != nil {
+		result = "error"
+	}
+	metrics.RepoDuration.WithLabelValues(repoName, operation, result).Observe(time.Since(start).Seconds())
+}
+
+func countChange(change string, err *error) {
+	if *err == nil {
+		metrics.NoteChanges.WithLabelValues(change).Inc()
+	}
+}
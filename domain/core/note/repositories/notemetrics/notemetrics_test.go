@@ -0,0 +1,44 @@
+package notemetrics_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/Keisn1/note-taking-app/domain/core/note/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/core/note/repositories/notemetrics"
+	"github.com/Keisn1/note-taking-app/foundation/metrics"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := notemetrics.NewRepo(memory.MustNewRepo(nil))
+	n := note.Note{ID: uuid.New(), Title: note.NewTitle("title"), Content: note.NewContent("content"), UserID: uuid.New()}
+
+	changes := func(change string) float64 {
+		return testutil.ToFloat64(metrics.NoteChanges.WithLabelValues(change))
+	}
+	created, updated, deleted := changes("created"), changes("updated"), changes("deleted")
+
+	assert.NoError(t, repo.Create(ctx, n))
+	assert.Error(t, repo.Create(ctx, n))
+	assert.Equal(t, float64(1), changes("created")-created)
+
+	got, err := repo.QueryByID(ctx, n.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, n, got)
+
+	n.Title = note.NewTitle("new title")
+	assert.NoError(t, repo.Update(ctx, n))
+	assert.Equal(t, float64(1), changes("updated")-updated)
+
+	assert.NoError(t, repo.Delete(ctx, n.ID))
+	assert.Error(t, repo.Delete(ctx, n.ID))
+	assert.Equal(t, float64(1), changes("deleted")-deleted)
+
+	// create, query_by_id, update and delete succeeded, create and delete failed
+	assert.Equal(t, 6, testutil.CollectAndCount(metrics.RepoDuration))
+}
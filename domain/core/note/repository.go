@@ -11,10 +11,33 @@ var (
 	ErrNoteNotFound = errors.New("the note was not found")
 )
 
+// Filter selects notes of the user. Title matches case-insensitive substrings
+// of the title, Query of the title or the content; empty ones match all
+// notes. Offset and Limit select a page of the matches.
+type Filter struct {
+	UserID uuid.UUID
+	Title  string
+	Query  string
+	Offset int
+	Limit  int
+}
+
 type Repo interface {
-	Delete(noteID uuid.UUID) error
-	Create(n Note) error
-	Update(note Note) error
+	Delete(ctx context.Context, noteID uuid.UUID) error
+	Create(ctx context.Context, n Note) error
+	Update(ctx context.Context, note Note) error
 	QueryByID(ctx context.Context, noteID uuid.UUID) (Note, error)
-	QueryByUserID(userID uuid.UUID) ([]Note, error)
+	// QueryByIDForUpdate returns the note like QueryByID and keeps other
+	// transactions from changing it until the transaction of ctx ends.
+	QueryByIDForUpdate(ctx context.Context, noteID uuid.UUID) (Note, error)
+	QueryByUserID(ctx context.Context, userID uuid.UUID) ([]Note, error)
+	// IterateByUserID calls fn with each note of the user, ordered by title,
+	// without loading all of them at once. It stops at the first error of fn
+	// and returns it.
+	IterateByUserID(ctx context.Context, userID uuid.UUID, fn func(Note) error) error
+	// QueryByFilter returns the page of notes selected by f, ordered by the
+	// lower-cased title and the ID.
+	QueryByFilter(ctx context.Context, f Filter) ([]Note, error)
+	// CountByFilter counts the notes that match f, ignoring Offset and Limit.
+	CountByFilter(ctx context.Context, f Filter) (int, error)
 }
@@ -13,13 +13,29 @@ type ErrorNoteRepo struct {
 	notes map[uuid.UUID]note.Note
 }
 
-func (nR ErrorNoteRepo) Create(n note.Note) error      { return errors.New("error in noteRepo") }
-func (nR ErrorNoteRepo) Delete(noteID uuid.UUID) error { return nil }
-func (nR ErrorNoteRepo) Update(note note.Note) error   { return nil }
+func (nR ErrorNoteRepo) Create(ctx context.Context, n note.Note) error {
+	return errors.New("error in noteRepo")
+}
+func (nR ErrorNoteRepo) Delete(ctx context.Context, noteID uuid.UUID) error { return nil }
+func (nR ErrorNoteRepo) Update(ctx context.Context, note note.Note) error   { return nil }
 func (nR ErrorNoteRepo) QueryByID(ctx context.Context, noteID uuid.UUID) (note.Note, error) {
 	return note.Note{}, nil
 }
-func (nR ErrorNoteRepo) QueryByUserID(userID uuid.UUID) ([]note.Note, error) { return nil, nil }
+func (nR ErrorNoteRepo) QueryByIDForUpdate(ctx context.Context, noteID uuid.UUID) (note.Note, error) {
+	return note.Note{}, nil
+}
+func (nR ErrorNoteRepo) QueryByUserID(ctx context.Context, userID uuid.UUID) ([]note.Note, error) {
+	return nil, nil
+}
+func (nR ErrorNoteRepo) IterateByUserID(ctx context.Context, userID uuid.UUID, fn func(note.Note) error) error {
+	return nil
+}
+func (nR ErrorNoteRepo) QueryByFilter(ctx context.Context, f note.Filter) ([]note.Note, error) {
+	return nil, nil
+}
+func (nR ErrorNoteRepo) CountByFilter(ctx context.Context, f note.Filter) (int, error) {
+	return 0, nil
+}
 
 type StubUserService struct {
 	ids map[uuid.UUID]struct{}
@@ -32,9 +48,21 @@ func (sus StubUserService) QueryByID(ctx context.Context, userID uuid.UUID) (use
 	return user.User{ID: userID}, nil
 }
 
+func (sus StubUserService) QueryByEmail(ctx context.Context, email string) (user.User, error) {
+	return user.User{}, errors.New("User not found")
+}
+
+func (sus StubUserService) Authenticate(ctx context.Context, email, password string) (user.User, error) {
+	return user.User{}, user.ErrInvalidCredentials
+}
+
 func (sus StubUserService) Create(ctx context.Context, uu user.UpdateUser) (user.User, error) {
 	return user.User{}, nil
 }
 func (sus StubUserService) Update(ctx context.Context, u user.User, uu user.UpdateUser) (user.User, error) {
 	return user.User{}, nil
 }
+func (sus StubUserService) SetDisabled(ctx context.Context, userID uuid.UUID, disabled bool) (user.User, error) {
+	return user.User{}, nil
+}
+func (sus StubUserService) Delete(ctx context.Context, userID uuid.UUID) error { return nil }
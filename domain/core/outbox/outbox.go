@@ -0,0 +1,78 @@
+package outbox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrNotFound = errors.New("outbox event not found")
+
+// DomainEvent is implemented by the events of the domain packages, such as
+// note.NoteCreated. Their JSON is the payload of the outbox event.
+type DomainEvent interface {
+	EventType() string
+	// AggregateID is the ID of the changed entity.
+	AggregateID() uuid.UUID
+}
+
+// Event is a domain event as it is written to the outbox.
+type Event struct {
+	ID          uuid.UUID
+	Type        string
+	AggregateID uuid.UUID
+	Payload     []byte // JSON
+	CreatedAt   time.Time
+}
+
+// NewEvent returns the outbox event of de with a new ID.
+func NewEvent(de DomainEvent) (Event, error) {
+	payload, err := json.Marshal(de)
+	if err != nil {
+		return Event{}, fmt.Errorf("newEvent: [%s]: %w", de.EventType(), err)
+	}
+	return Event{
+		ID:          uuid.New(),
+		Type:        de.EventType(),
+		AggregateID: de.AggregateID(),
+		Payload:     payload,
+		CreatedAt:   time.Now().UTC().Truncate(time.Microsecond),
+	}, nil
+}
+
+// Record is an event in the outbox with the state of its relay. Seq orders
+// the events as they were written. HandledBy holds the names of the
+// subscribers that handled the event, the event is published once all of
+// them did.
+type Record struct {
+	Event
+	Seq           int64
+	Attempts      int
+	NextAttemptAt time.Time
+	HandledBy     []string
+	LastError     string
+	PublishedAt   time.Time // zero while pending
+}
+
+func (r Record) Published() bool {
+	return !r.PublishedAt.IsZero()
+}
+
+func (r Record) HandledByName(name string) bool {
+	return slices.Contains(r.HandledBy, name)
+}
+
+// backoff is the delay before the next attempt after attempts failed ones,
+// from 30s doubling up to 1h.
+func backoff(attempts int) time.Duration {
+	const first, last = 30 * time.Second, time.Hour
+	d := first
+	for i := 1; i < attempts && d < last; i++ {
+		d *= 2
+	}
+	return min(d, last)
+}
@@ -0,0 +1,161 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// claimLease is how long a claimed event is skipped by other relays. An
+	// event whose relay stopped while handling it is handled again after.
+	claimLease = 5 * time.Minute
+
+	// pruneInterval is how often events published longer ago than the
+	// retention are deleted.
+	pruneInterval = time.Hour
+
+	maxErrorLength = 1000 // bytes of LastError
+)
+
+// Subscriber handles the events of the outbox. Name identifies it in the
+// records of the events it handled and must not change between releases.
+// Handle gets every event, it ignores those of types it doesn't know.
+type Subscriber struct {
+	Name   string
+	Handle func(ctx context.Context, e Event) error
+}
+
+// RelayConfig configures a Relay. Zero values are replaced by the defaults.
+type RelayConfig struct {
+	PollInterval time.Duration // default 1s
+	BatchSize    int           // events claimed at once, default 100
+	Retention    time.Duration // of published events, default 7 days
+
+	// OnError is called with the errors of the outbox and of the
+	// subscribers; the latter are also recorded in the events.
+	OnError func(error)
+}
+
+// Relay dispatches the events of the outbox to the subscribers. An event
+// is handed to each subscriber until it succeeds, after a failure with an
+// exponential backoff from 30s up to 1h, and is published once all of
+// them succeeded. Delivery is at least once: a subscriber that failed
+// isn't called again for an event once it handled it, but an event whose
+// relay stopped before recording the result is handled again, so
+// subscribers drop duplicates by the ID of the event.
+//
+// The events of a batch are handled in the order they were written, but
+// a failed event doesn't hold back the later ones.
+type Relay struct {
+	repo Repo
+	subs []Subscriber
+	cfg  RelayConfig
+}
+
+func NewRelay(repo Repo, cfg RelayConfig, subs ...Subscriber) Relay {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = 7 * 24 * time.Hour
+	}
+	if cfg.OnError == nil {
+		cfg.OnError = func(error) {}
+	}
+	return Relay{repo: repo, subs: subs, cfg: cfg}
+}
+
+// Run dispatches the due events every poll interval until ctx is done.
+func (r Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	var pruned time.Time
+	for {
+		now := time.Now()
+		if err := r.Dispatch(ctx, now); err != nil && ctx.Err() == nil {
+			r.cfg.OnError(err)
+		}
+		if now.Sub(pruned) >= pruneInterval {
+			if err := r.repo.DeletePublishedBefore(ctx, now.Add(-r.cfg.Retention)); err != nil && ctx.Err() == nil {
+				r.cfg.OnError(fmt.Errorf("prune: %w", err))
+			}
+			pruned = now
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch hands the events that are due at now to the subscribers, until
+// none is left.
+func (r Relay) Dispatch(ctx context.Context, now time.Time) error {
+	for {
+		records, err := r.repo.Claim(ctx, now, now.Add(claimLease), r.cfg.BatchSize)
+		if err != nil {
+			return fmt.Errorf("dispatch: %w", err)
+		}
+
+		for _, rec := range records {
+			if ctx.Err() != nil {
+				return nil
+			}
+			r.relay(ctx, rec, now)
+		}
+
+		if len(records) < r.cfg.BatchSize || ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+func (r Relay) relay(ctx context.Context, rec Record, now time.Time) {
+	var errs []error
+	for _, s := range r.subs {
+		if rec.HandledByName(s.Name) {
+			continue
+		}
+		if err := s.Handle(ctx, rec.Event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.Name, err))
+			continue
+		}
+		rec.HandledBy = append(rec.HandledBy, s.Name)
+	}
+	if ctx.Err() != nil {
+		// Stopped, the event is handled again after its lease; the
+		// subscribers that succeeded see it twice.
+		return
+	}
+
+	now = now.UTC().Truncate(time.Microsecond)
+	rec.LastError = ""
+	if err := errors.Join(errs...); err != nil {
+		rec.Attempts++
+		rec.LastError = truncate(err.Error())
+		rec.NextAttemptAt = now.Add(backoff(rec.Attempts))
+		r.cfg.OnError(fmt.Errorf("relay: [%s]: %w", rec.ID, err))
+	} else {
+		rec.PublishedAt = now
+	}
+
+	if err := r.repo.Update(ctx, rec); err != nil && ctx.Err() == nil {
+		r.cfg.OnError(fmt.Errorf("relay: [%s]: %w", rec.ID, err))
+	}
+}
+
+func truncate(s string) string {
+	if len(s) <= maxErrorLength {
+		return s
+	}
+	return strings.ToValidUTF8(s[:maxErrorLength], "")
+}
@@ -0,0 +1,143 @@
+package outbox_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/domain/core/outbox"
+	"github.com/Keisn1/note-taking-app/domain/core/outbox/repositories/memory"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testEvent struct {
+	NoteID uuid.UUID `json:"note_id"`
+}
+
+func (testEvent) EventType() string        { return "note.tested" }
+func (e testEvent) AggregateID() uuid.UUID { return e.NoteID }
+
+// recorder is a subscriber that records the events it got and fails while
+// err is set.
+type recorder struct {
+	name string
+	err  error
+	got  []uuid.UUID
+}
+
+func (r *recorder) subscriber() outbox.Subscriber {
+	return outbox.Subscriber{Name: r.name, Handle: func(ctx context.Context, e outbox.Event) error {
+		if r.err != nil {
+			return r.err
+		}
+		r.got = append(r.got, e.ID)
+		return nil
+	}}
+}
+
+func appendEvents(t *testing.T, repo memory.Repo, n int) []uuid.UUID {
+	t.Helper()
+	var ids []uuid.UUID
+	for range n {
+		e, err := outbox.NewEvent(testEvent{NoteID: uuid.New()})
+		require.NoError(t, err)
+		require.NoError(t, repo.Append(context.Background(), e))
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func TestNewEvent(t *testing.T) {
+	de := testEvent{NoteID: uuid.New()}
+	e, err := outbox.NewEvent(de)
+	require.NoError(t, err)
+	assert.Equal(t, "note.tested", e.Type)
+	assert.Equal(t, de.NoteID, e.AggregateID)
+
+	var got testEvent
+	require.NoError(t, json.Unmarshal(e.Payload, &got))
+	assert.Equal(t, de, got)
+}
+
+func TestRelay(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Events are handed to all subscribers in order and published", func(t *testing.T) {
+		repo := memory.NewRepo()
+		ids := appendEvents(t, repo, 5)
+		search, audit := &recorder{name: "search"}, &recorder{name: "audit"}
+		relay := outbox.NewRelay(repo, outbox.RelayConfig{BatchSize: 2}, search.subscriber(), audit.subscriber())
+
+		now := time.Now()
+		require.NoError(t, relay.Dispatch(ctx, now))
+		require.NoError(t, relay.Dispatch(ctx, now.Add(time.Hour)))
+		assert.Equal(t, ids, search.got)
+		assert.Equal(t, ids, audit.got)
+
+		for _, r := range repo.Records() {
+			assert.True(t, r.Published())
+		}
+
+		require.NoError(t, repo.DeletePublishedBefore(ctx, now.Add(time.Second)))
+		assert.Empty(t, repo.Records())
+	})
+
+	t.Run("Failed subscribers are retried with backoff, the others don't see the event again", func(t *testing.T) {
+		repo := memory.NewRepo()
+		ids := appendEvents(t, repo, 1)
+		search, audit := &recorder{name: "search"}, &recorder{name: "audit", err: errors.New("down")}
+		var errs []error
+		relay := outbox.NewRelay(repo, outbox.RelayConfig{OnError: func(err error) { errs = append(errs, err) }}, search.subscriber(), audit.subscriber())
+
+		now := time.Now()
+		require.NoError(t, relay.Dispatch(ctx, now))
+		require.Len(t, errs, 1)
+		assert.ErrorContains(t, errs[0], "audit: down")
+
+		r := repo.Records()[0]
+		assert.False(t, r.Published())
+		assert.Equal(t, 1, r.Attempts)
+		assert.Equal(t, []string{"search"}, r.HandledBy)
+		assert.Equal(t, "audit: down", r.LastError)
+		assert.WithinDuration(t, now.Add(30*time.Second), r.NextAttemptAt, time.Millisecond)
+
+		require.NoError(t, relay.Dispatch(ctx, now.Add(29*time.Second)))
+		assert.Len(t, errs, 1)
+
+		require.NoError(t, relay.Dispatch(ctx, now.Add(30*time.Second)))
+		r = repo.Records()[0]
+		assert.Equal(t, 2, r.Attempts)
+		assert.WithinDuration(t, now.Add(90*time.Second), r.NextAttemptAt, time.Millisecond)
+
+		audit.err = nil
+		require.NoError(t, relay.Dispatch(ctx, now.Add(90*time.Second)))
+		r = repo.Records()[0]
+		assert.True(t, r.Published())
+		assert.Empty(t, r.LastError)
+		assert.Equal(t, ids, search.got)
+		assert.Equal(t, ids, audit.got)
+	})
+
+	t.Run("Events of a stopped relay are handled again after their lease", func(t *testing.T) {
+		repo := memory.NewRepo()
+		ids := appendEvents(t, repo, 1)
+		ctx, cancel := context.WithCancel(ctx)
+		search := &recorder{name: "search"}
+		stopping := outbox.Subscriber{Name: "stopping", Handle: func(context.Context, outbox.Event) error {
+			cancel()
+			return context.Canceled
+		}}
+
+		now := time.Now()
+		require.NoError(t, outbox.NewRelay(repo, outbox.RelayConfig{}, search.subscriber(), stopping).Dispatch(ctx, now))
+		assert.False(t, repo.Records()[0].Published())
+
+		require.NoError(t, outbox.NewRelay(repo, outbox.RelayConfig{}, search.subscriber()).Dispatch(context.Background(), now.Add(5*time.Minute)))
+		assert.True(t, repo.Records()[0].Published())
+		assert.Equal(t, []uuid.UUID{ids[0], ids[0]}, search.got)
+	})
+}
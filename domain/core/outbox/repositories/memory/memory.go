@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/domain/core/outbox"
+	"github.com/google/uuid"
+)
+
+type Repo struct {
+	mu      *sync.Mutex
+	seq     *int64
+	records map[uuid.UUID]outbox.Record
+}
+
+func NewRepo() Repo {
+	return Repo{mu: &sync.Mutex{}, seq: new(int64), records: make(map[uuid.UUID]outbox.Record)}
+}
+
+func (oR Repo) Append(ctx context.Context, events ...outbox.Event) error {
+	oR.mu.Lock()
+	defer oR.mu.Unlock()
+
+	for _, e := range events {
+		if _, ok := oR.records[e.ID]; ok {
+			return fmt.Errorf("append: already present %s", e.ID)
+		}
+	}
+	for _, e := range events {
+		*oR.seq++
+		oR.records[e.ID] = outbox.Record{Event: e, Seq: *oR.seq, NextAttemptAt: e.CreatedAt}
+	}
+	return nil
+}
+
+func (oR Repo) Claim(ctx context.Context, now, until time.Time, limit int) ([]outbox.Record, error) {
+	oR.mu.Lock()
+	defer oR.mu.Unlock()
+
+	var due []outbox.Record
+	for _, r := range oR.records {
+		if !r.Published() && !r.NextAttemptAt.After(now) {
+			due = append(due, r)
+		}
+	}
+	slices.SortFunc(due, func(a, b outbox.Record) int { return cmp.Compare(a.Seq, b.Seq) })
+
+	due = due[:min(len(due), limit)]
+	for i := range due {
+		due[i].NextAttemptAt = until
+		oR.records[due[i].ID] = due[i]
+		due[i].HandledBy = slices.Clone(due[i].HandledBy)
+	}
+	return due, nil
+}
+
+func (oR Repo) Update(ctx context.Context, r outbox.Record) error {
+	oR.mu.Lock()
+	defer oR.mu.Unlock()
+
+	if _, ok := oR.records[r.ID]; !ok {
+		return outbox.ErrNotFound
+	}
+	r.HandledBy = slices.Clone(r.HandledBy)
+	oR.records[r.ID] = r
+	return nil
+}
+
+func (oR Repo) DeletePublishedBefore(ctx context.Context, t time.Time) error {
+	oR.mu.Lock()
+	defer oR.mu.Unlock()
+
+	for id, r := range oR.records {
+		if r.Published() && r.PublishedAt.Before(t) {
+			delete(oR.records, id)
+		}
+	}
+	return nil
+}
+
+// Records returns all events in the order they were appended, for tests.
+func (oR Repo) Records() []outbox.Record {
+	oR.mu.Lock()
+	defer oR.mu.Unlock()
+
+	ret := make([]outbox.Record, 0, len(oR.records))
+	for _, r := range oR.records {
+		ret = append(ret, r)
+	}
+	slices.SortFunc(ret, func(a, b outbox.Record) int { return cmp.Compare(a.Seq, b.Seq) })
+	return ret
+}
+
+// Snapshot copies the events for a transaction.Memory.
+func (oR Repo) Snapshot() (restore func()) {
+	oR.mu.Lock()
+	defer oR.mu.Unlock()
+
+	seq, records := *oR.seq, maps.Clone(oR.records)
+	return func() {
+		oR.mu.Lock()
+		defer oR.mu.Unlock()
+		*oR.seq = seq
+		clear(oR.records)
+		maps.Copy(oR.records, records)
+	}
+}
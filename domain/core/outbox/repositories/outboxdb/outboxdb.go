@@ -0,0 +1,141 @@
+package outboxdb
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/domain/core/outbox"
+	"github.com/Keisn1/note-taking-app/foundation/tracing"
+	"github.com/Keisn1/note-taking-app/foundation/transaction"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/Keisn1/note-taking-app/domain/core/outbox/repositories/outboxdb")
+
+type OutboxRepo struct {
+	db transaction.Conn
+}
+
+func NewOutboxRepo(db transaction.Conn) OutboxRepo {
+	return OutboxRepo{db: db}
+}
+
+func (oR OutboxRepo) Append(ctx context.Context, events ...outbox.Event) (err error) {
+	if len(events) == 0 {
+		return nil
+	}
+
+	var (
+		b    strings.Builder
+		args []any
+	)
+	b.WriteString(`INSERT INTO outbox (id, type, aggregate_id, payload, created_at, next_attempt_at) VALUES `)
+	for i, e := range events {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		n := len(args)
+		fmt.Fprintf(&b, "($%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+5)
+		args = append(args, e.ID, e.Type, e.AggregateID, string(e.Payload), e.CreatedAt)
+	}
+	insertRows := b.String()
+	ctx, span := startSpan(ctx, "outboxdb.Append", insertRows)
+	defer tracing.End(span, &err)
+
+	if _, err := transaction.ConnFrom(ctx, oR.db).ExecContext(ctx, insertRows, args...); err != nil {
+		return fmt.Errorf("append: [%s]: %w", events[0].Type, err)
+	}
+	return nil
+}
+
+// Claim locks the due rows with SKIP LOCKED, so that concurrent claims
+// don't wait for each other and never return the same event.
+func (oR OutboxRepo) Claim(ctx context.Context, now, until time.Time, limit int) (_ []outbox.Record, err error) {
+	claim := `
+	UPDATE outbox SET next_attempt_at = $2
+	WHERE seq IN (
+		SELECT seq FROM outbox
+		WHERE published_at IS NULL AND next_attempt_at <= $1
+		ORDER BY seq LIMIT $3
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING id, type, aggregate_id, payload, created_at, seq, attempts, next_attempt_at, handled_by, last_error`
+	ctx, span := startSpan(ctx, "outboxdb.Claim", claim)
+	defer tracing.End(span, &err)
+
+	rows, err := transaction.ConnFrom(ctx, oR.db).QueryContext(ctx, claim, now, until, limit)
+	if err != nil {
+		return nil, fmt.Errorf("claim: %w", err)
+	}
+	defer rows.Close()
+
+	var records []outbox.Record
+	for rows.Next() {
+		var (
+			r         outbox.Record
+			handledBy string
+		)
+		err := rows.Scan(&r.ID, &r.Type, &r.AggregateID, &r.Payload, &r.CreatedAt, &r.Seq, &r.Attempts, &r.NextAttemptAt, &handledBy, &r.LastError)
+		if err != nil {
+			return nil, fmt.Errorf("claim: scan rows: %w", err)
+		}
+		if handledBy != "" {
+			r.HandledBy = strings.Split(handledBy, ",")
+		}
+		records = append(records, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("claim: %w", err)
+	}
+
+	// RETURNING doesn't keep the order of the subquery.
+	slices.SortFunc(records, func(a, b outbox.Record) int { return cmp.Compare(a.Seq, b.Seq) })
+	return records, nil
+}
+
+func (oR OutboxRepo) Update(ctx context.Context, r outbox.Record) (err error) {
+	updateRow := `
+	UPDATE outbox
+	SET attempts=$2, next_attempt_at=$3, handled_by=$4, last_error=$5, published_at=$6
+	WHERE id=$1`
+	ctx, span := startSpan(ctx, "outboxdb.Update", updateRow)
+	defer tracing.End(span, &err)
+
+	publishedAt := sql.NullTime{Time: r.PublishedAt, Valid: r.Published()}
+	res, err := transaction.ConnFrom(ctx, oR.db).ExecContext(ctx, updateRow, r.ID, r.Attempts, r.NextAttemptAt, strings.Join(r.HandledBy, ","), r.LastError, publishedAt)
+	if err != nil {
+		return fmt.Errorf("update: [%s]: %w", r.ID, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return outbox.ErrNotFound
+	}
+	return nil
+}
+
+func (oR OutboxRepo) DeletePublishedBefore(ctx context.Context, t time.Time) (err error) {
+	deleteRows := `DELETE FROM outbox WHERE published_at < $1`
+	ctx, span := startSpan(ctx, "outboxdb.DeletePublishedBefore", deleteRows)
+	defer tracing.End(span, &err)
+
+	if _, err := transaction.ConnFrom(ctx, oR.db).ExecContext(ctx, deleteRows, t); err != nil {
+		return fmt.Errorf("deletePublishedBefore: %w", err)
+	}
+	return nil
+}
+
+func startSpan(ctx context.Context, name, query string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.statement", query),
+		),
+	)
+}
@@ -0,0 +1,96 @@
+package outboxdb_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/domain/core/outbox"
+	"github.com/Keisn1/note-taking-app/domain/core/outbox/repositories/outboxdb"
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testDBName   = "test_note_taking_app_outbox"
+	testUser     = "postgres"
+	testPassword = "password"
+)
+
+func TestMain(m *testing.M) {
+	exitCode := run(m)
+	os.Exit(exitCode)
+}
+
+func TestOutboxRepo(t *testing.T) {
+	testDB, deleteTable := SetupOutboxTable(t)
+	defer testDB.Close()
+	defer deleteTable()
+	oR := outboxdb.NewOutboxRepo(testDB)
+	ctx := context.Background()
+
+	created := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	event := func(id byte) outbox.Event {
+		return outbox.Event{ID: uuid.UUID{id}, Type: "note.created", AggregateID: uuid.UUID{9}, Payload: []byte(`{"note_id": 1}`), CreatedAt: created}
+	}
+	require.NoError(t, oR.Append(ctx, event(1), event(2)))
+	require.NoError(t, oR.Append(ctx, event(3)))
+	assert.Error(t, oR.Append(ctx, event(3)))
+
+	t.Run("Due events are claimed once, in the order they were appended", func(t *testing.T) {
+		until := created.Add(time.Hour)
+		got, err := oR.Claim(ctx, created, until, 2)
+		assert.NoError(t, err)
+		require.Len(t, got, 2)
+		assert.Equal(t, uuid.UUID{1}, got[0].ID)
+		assert.Equal(t, uuid.UUID{2}, got[1].ID)
+		assert.Equal(t, until, got[0].NextAttemptAt.UTC())
+		assert.JSONEq(t, `{"note_id": 1}`, string(got[0].Payload))
+
+		again, err := oR.Claim(ctx, created, until, 10)
+		assert.NoError(t, err)
+		require.Len(t, again, 1)
+		assert.Equal(t, uuid.UUID{3}, again[0].ID)
+	})
+
+	t.Run("Failed events keep the subscribers that handled them, published ones are not claimed", func(t *testing.T) {
+		now := created.Add(2 * time.Hour)
+		got, err := oR.Claim(ctx, now, now, 10)
+		require.NoError(t, err)
+		require.Len(t, got, 3)
+
+		failed := got[0]
+		failed.Attempts, failed.NextAttemptAt, failed.HandledBy, failed.LastError = 1, now, []string{"search", "webhooks"}, "audit: down"
+		assert.NoError(t, oR.Update(ctx, failed))
+		for _, r := range got[1:] {
+			r.PublishedAt = now
+			assert.NoError(t, oR.Update(ctx, r))
+		}
+
+		got, err = oR.Claim(ctx, now, now, 10)
+		assert.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.Equal(t, []string{"search", "webhooks"}, got[0].HandledBy)
+		assert.Equal(t, "audit: down", got[0].LastError)
+		assert.Equal(t, 1, got[0].Attempts)
+
+		assert.ErrorIs(t, oR.Update(ctx, outbox.Record{Event: outbox.Event{ID: uuid.New()}}), outbox.ErrNotFound)
+	})
+
+	t.Run("Published events are pruned", func(t *testing.T) {
+		assert.NoError(t, oR.DeletePublishedBefore(ctx, created.Add(3*time.Hour)))
+		var count int
+		require.NoError(t, testDB.QueryRow(`SELECT count(*) FROM outbox`).Scan(&count))
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("Given an error received by the DB, the error is forwarded", func(t *testing.T) {
+		oR := outboxdb.NewOutboxRepo(&stubSQLDB{})
+		assert.ErrorContains(t, oR.Append(ctx, event(4)), "append: [note.created]: DBError")
+		_, err := oR.Claim(ctx, created, created, 10)
+		assert.ErrorContains(t, err, "claim: DBError")
+	})
+}
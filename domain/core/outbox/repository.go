@@ -0,0 +1,19 @@
+package outbox
+
+import (
+	"context"
+	"time"
+)
+
+type Repo interface {
+	// Append writes the events. The repositories of the domain call it
+	// within the transaction of the change.
+	Append(ctx context.Context, events ...Event) error
+	// Claim returns up to limit pending events that are due at now, in the
+	// order of their Seq, and postpones them to until, so that other relays
+	// skip them while they are handled.
+	Claim(ctx context.Context, now, until time.Time, limit int) ([]Record, error)
+	Update(ctx context.Context, r Record) error
+	// DeletePublishedBefore deletes the events published before t.
+	DeletePublishedBefore(ctx context.Context, t time.Time) error
+}
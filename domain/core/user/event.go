@@ -0,0 +1,42 @@
+package user
+
+import "github.com/google/uuid"
+
+const (
+	EventCreated = "user.created"
+	EventUpdated = "user.updated"
+	EventDeleted = "user.deleted"
+)
+
+// UserCreated, UserUpdated and UserDeleted are the domain events of users.
+// The repositories that support it write them to the outbox in the
+// transaction of the change; their JSON is the payload. They never carry
+// the password hash.
+type (
+	UserCreated struct{ eventUser }
+	UserUpdated struct{ eventUser }
+	UserDeleted struct {
+		UserID uuid.UUID `json:"user_id"`
+	}
+)
+
+type eventUser struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Name     string    `json:"name"`
+	Email    string    `json:"email"`
+	Disabled bool      `json:"disabled"`
+}
+
+func NewUserCreated(u User) UserCreated { return UserCreated{toEventUser(u)} }
+func NewUserUpdated(u User) UserUpdated { return UserUpdated{toEventUser(u)} }
+
+func (UserCreated) EventType() string        { return EventCreated }
+func (e UserCreated) AggregateID() uuid.UUID { return e.UserID }
+func (UserUpdated) EventType() string        { return EventUpdated }
+func (e UserUpdated) AggregateID() uuid.UUID { return e.UserID }
+func (UserDeleted) EventType() string        { return EventDeleted }
+func (e UserDeleted) AggregateID() uuid.UUID { return e.UserID }
+
+func toEventUser(u User) eventUser {
+	return eventUser{UserID: u.ID, Name: u.Name.String(), Email: u.Email.String().Address, Disabled: u.Disabled}
+}
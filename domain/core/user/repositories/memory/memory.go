@@ -2,7 +2,7 @@ package memory
 
 import (
 	"context"
-	"errors"
+	"maps"
 
 	"github.com/Keisn1/note-taking-app/domain/core/user"
 	"github.com/google/uuid"
@@ -32,15 +32,33 @@ func (r InMemoryRepo) Create(ctx context.Context, u user.User) error {
 
 func (r InMemoryRepo) Delete(ctx context.Context, userID uuid.UUID) error {
 	if _, ok := r.users[userID]; !ok {
-		return errors.New("user not found")
+		return user.ErrUserNotFound
 	}
 	delete(r.users, userID)
 	return nil
 }
 
+func (r InMemoryRepo) QueryByEmail(ctx context.Context, email string) (user.User, error) {
+	for _, u := range r.users {
+		if u.Email.String().Address == email {
+			return u, nil
+		}
+	}
+	return user.User{}, user.ErrUserNotFound
+}
+
 func (r InMemoryRepo) QueryByID(ctx context.Context, userID uuid.UUID) (user.User, error) {
 	if user, ok := r.users[userID]; ok {
 		return user, nil
 	}
-	return user.User{}, errors.New("user not found")
+	return user.User{}, user.ErrUserNotFound
+}
+
+// Snapshot copies the users for a transaction.Memory.
+func (r InMemoryRepo) Snapshot() (restore func()) {
+	users := maps.Clone(r.users)
+	return func() {
+		clear(r.users)
+		maps.Copy(r.users, users)
+	}
 }
@@ -0,0 +1,82 @@
+package userdb_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	"github.com/Keisn1/note-taking-app/domain/data/migrate"
+	"github.com/google/uuid"
+)
+
+func run(m *testing.M) int {
+	var (
+		dropDB   = fmt.Sprintf(`DROP DATABASE IF EXISTS %s;`, testDBName)
+		createDB = fmt.Sprintf(`CREATE DATABASE %s;`, testDBName)
+	)
+
+	dsn := fmt.Sprintf("host=localhost port=5432 user=%s password=%s sslmode=disable", testUser, testPassword)
+	postgresDB, err := sql.Open("pgx", dsn)
+	if err != nil {
+		panic(err)
+	}
+	defer postgresDB.Close()
+
+	_, err = postgresDB.Exec(dropDB)
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = postgresDB.Exec(createDB)
+	if err != nil {
+		panic(err)
+	}
+
+	defer func() {
+		_, err = postgresDB.Exec(dropDB)
+		if err != nil {
+			panic(fmt.Errorf("postgresDB.Exec() err = %s", err))
+		}
+	}()
+
+	return m.Run()
+}
+
+func SetupUsersTable(t *testing.T, users []user.User) (*sql.DB, func()) {
+	dsn := fmt.Sprintf("host=localhost port=5432 user=%s password=%s sslmode=disable dbname=%s ", testUser, testPassword, testDBName)
+	testDB, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := migrate.Migrate(context.Background(), testDB); err != nil {
+		t.Fatal(err)
+	}
+
+	insertRow := `INSERT INTO users (id, name, email, password_hash) VALUES ($1, $2, $3, $4)`
+	for _, u := range users {
+		_, err = testDB.Exec(insertRow, u.ID, u.Name.String(), u.Email.String().Address, u.PasswordHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deleteTables := func() {
+		_, err := testDB.Exec(`DROP TABLE audit_log, outbox, webhook_deliveries, webhooks, changes, change_seqs, links, attachments, notes, users, schema_migrations`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testDB.Close()
+	}
+
+	return testDB, deleteTables
+}
+
+func fixtureUsers() []user.User {
+	return []user.User{
+		{ID: uuid.UUID{1}, Name: user.NewName("rob"), Email: user.NewEmail("rob@example.com"), PasswordHash: []byte("robs hash")},
+		{ID: uuid.UUID{2}, Name: user.NewName("anna"), Email: user.NewEmail("anna@example.com"), PasswordHash: []byte("annas hash")},
+	}
+}
@@ -0,0 +1,169 @@
+package userdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Keisn1/note-taking-app/domain/core/outbox"
+	"github.com/Keisn1/note-taking-app/domain/core/outbox/repositories/outboxdb"
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	"github.com/Keisn1/note-taking-app/foundation/tracing"
+	"github.com/Keisn1/note-taking-app/foundation/transaction"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/Keisn1/note-taking-app/domain/core/user/repositories/userdb")
+
+type dbUser struct {
+	id           uuid.UUID
+	name         string
+	email        string
+	passwordHash []byte
+	disabled     bool
+}
+
+type UserRepo struct {
+	db transaction.DB
+}
+
+func NewUserRepo(db transaction.DB) UserRepo {
+	return UserRepo{db: db}
+}
+
+func (uR UserRepo) QueryByID(ctx context.Context, userID uuid.UUID) (_ user.User, err error) {
+	queryByID := `SELECT id, name, email, password_hash, disabled FROM users WHERE id=$1`
+	ctx, span := startSpan(ctx, "userdb.QueryByID", queryByID)
+	defer tracing.End(span, &err)
+
+	var uDB dbUser
+	row := transaction.ConnFrom(ctx, uR.db).QueryRowContext(ctx, queryByID, userID)
+	if err := row.Scan(&uDB.id, &uDB.name, &uDB.email, &uDB.passwordHash, &uDB.disabled); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return user.User{}, user.ErrUserNotFound
+		}
+		return user.User{}, fmt.Errorf("queryByID: [%s]: %w", userID, err)
+	}
+
+	return userDBToUser(uDB), nil
+}
+
+func (uR UserRepo) QueryByEmail(ctx context.Context, email string) (_ user.User, err error) {
+	queryByEmail := `SELECT id, name, email, password_hash, disabled FROM users WHERE email=$1`
+	ctx, span := startSpan(ctx, "userdb.QueryByEmail", queryByEmail)
+	defer tracing.End(span, &err)
+
+	var uDB dbUser
+	row := transaction.ConnFrom(ctx, uR.db).QueryRowContext(ctx, queryByEmail, email)
+	if err := row.Scan(&uDB.id, &uDB.name, &uDB.email, &uDB.passwordHash, &uDB.disabled); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return user.User{}, user.ErrUserNotFound
+		}
+		return user.User{}, fmt.Errorf("queryByEmail: %w", err)
+	}
+
+	return userDBToUser(uDB), nil
+}
+
+func (uR UserRepo) Create(ctx context.Context, u user.User) (err error) {
+	insertRow := `INSERT INTO users (id, name, email, password_hash, disabled) VALUES ($1, $2, $3, $4, $5)`
+	ctx, span := startSpan(ctx, "userdb.Create", insertRow)
+	defer tracing.End(span, &err)
+
+	err = uR.withEvent(ctx, user.NewUserCreated(u), func(tx transaction.Conn) error {
+		_, err := tx.ExecContext(ctx, insertRow, u.ID, u.Name.String(), u.Email.String().Address, u.PasswordHash, u.Disabled)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("create: [%s]: %w", u.ID, err)
+	}
+	return nil
+}
+
+func (uR UserRepo) Update(ctx context.Context, u user.User) (err error) {
+	updateRow := `UPDATE users SET name = $1, email = $2, password_hash = $3, disabled = $4 WHERE id=$5`
+	ctx, span := startSpan(ctx, "userdb.Update", updateRow)
+	defer tracing.End(span, &err)
+
+	err = uR.withEvent(ctx, user.NewUserUpdated(u), func(tx transaction.Conn) error {
+		res, err := tx.ExecContext(ctx, updateRow, u.Name.String(), u.Email.String().Address, u.PasswordHash, u.Disabled, u.ID)
+		if err != nil {
+			return err
+		}
+		if c, _ := res.RowsAffected(); c == 0 {
+			return user.ErrUserNotFound
+		}
+		return nil
+	})
+	if errors.Is(err, user.ErrUserNotFound) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("update: [%s]: %w", u.ID, err)
+	}
+	return nil
+}
+
+func (uR UserRepo) Delete(ctx context.Context, userID uuid.UUID) (err error) {
+	deleteRow := `DELETE FROM users WHERE id=$1`
+	ctx, span := startSpan(ctx, "userdb.Delete", deleteRow)
+	defer tracing.End(span, &err)
+
+	err = uR.withEvent(ctx, user.UserDeleted{UserID: userID}, func(tx transaction.Conn) error {
+		res, err := tx.ExecContext(ctx, deleteRow, userID)
+		if err != nil {
+			return err
+		}
+		if c, _ := res.RowsAffected(); c == 0 {
+			return user.ErrUserNotFound
+		}
+		return nil
+	})
+	if errors.Is(err, user.ErrUserNotFound) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("delete: [%s]: %w", userID, err)
+	}
+	return nil
+}
+
+// withEvent runs fn and writes the event to the outbox in one transaction,
+// or in the transaction of ctx.
+func (uR UserRepo) withEvent(ctx context.Context, de outbox.DomainEvent, fn func(tx transaction.Conn) error) error {
+	e, err := outbox.NewEvent(de)
+	if err != nil {
+		return err
+	}
+
+	return transaction.Run(ctx, uR.db, func(tx transaction.Conn) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		return outboxdb.NewOutboxRepo(tx).Append(ctx, e)
+	})
+}
+
+func startSpan(ctx context.Context, name, query string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.statement", query),
+		),
+	)
+}
+
+func userDBToUser(uDB dbUser) user.User {
+	return user.User{
+		ID:           uDB.id,
+		Name:         user.NewName(uDB.name),
+		Email:        user.NewEmail(uDB.email),
+		PasswordHash: uDB.passwordHash,
+		Disabled:     uDB.disabled,
+	}
+}
@@ -0,0 +1,193 @@
+package userdb_test
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/domain/core/outbox/repositories/outboxdb"
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	"github.com/Keisn1/note-taking-app/domain/core/user/repositories/userdb"
+	"github.com/Keisn1/note-taking-app/domain/data/migrate"
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testDBName   = "test_note_taking_app_userdb"
+	testUser     = "postgres"
+	testPassword = "password"
+)
+
+func TestMain(m *testing.M) {
+	exitCode := run(m)
+	os.Exit(exitCode)
+}
+
+func TestMigrate(t *testing.T) {
+	testDB, deleteTables := SetupUsersTable(t, nil)
+	defer deleteTables()
+
+	applied, latest, err := migrate.Status(context.Background(), testDB)
+	assert.NoError(t, err)
+	assert.Equal(t, latest, applied)
+
+	// migrating twice is a no-op
+	assert.NoError(t, migrate.Migrate(context.Background(), testDB))
+
+	// instances started at the same time wait for each other
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = migrate.Migrate(context.Background(), testDB)
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+
+	var locks int
+	row := testDB.QueryRow(`
+	SELECT count(*) FROM pg_locks
+	WHERE locktype = 'advisory' AND database = (SELECT oid FROM pg_database WHERE datname = current_database())`)
+	assert.NoError(t, row.Scan(&locks))
+	assert.Zero(t, locks, "the lock is released")
+}
+
+func TestUserRepo_QueryByID(t *testing.T) {
+	testDB, deleteTables := SetupUsersTable(t, fixtureUsers())
+	defer deleteTables()
+	uR := userdb.NewUserRepo(testDB)
+
+	t.Run("Get user by id", func(t *testing.T) {
+		for _, want := range fixtureUsers() {
+			got, err := uR.QueryByID(context.Background(), want.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, want, got)
+		}
+	})
+
+	t.Run("User not found", func(t *testing.T) {
+		_, err := uR.QueryByID(context.Background(), uuid.New())
+		assert.ErrorIs(t, err, user.ErrUserNotFound)
+	})
+}
+
+func TestUserRepo_QueryByEmail(t *testing.T) {
+	testDB, deleteTables := SetupUsersTable(t, fixtureUsers())
+	defer deleteTables()
+	uR := userdb.NewUserRepo(testDB)
+
+	t.Run("Get user by email", func(t *testing.T) {
+		for _, want := range fixtureUsers() {
+			got, err := uR.QueryByEmail(context.Background(), want.Email.String().Address)
+			assert.NoError(t, err)
+			assert.Equal(t, want, got)
+		}
+	})
+
+	t.Run("User not found", func(t *testing.T) {
+		_, err := uR.QueryByEmail(context.Background(), "nobody@example.com")
+		assert.ErrorIs(t, err, user.ErrUserNotFound)
+	})
+}
+
+func TestUserRepo_Create(t *testing.T) {
+	testDB, deleteTables := SetupUsersTable(t, fixtureUsers())
+	defer deleteTables()
+	uR := userdb.NewUserRepo(testDB)
+
+	t.Run("Create a user", func(t *testing.T) {
+		u := user.User{ID: uuid.New(), Name: user.NewName("bob"), Email: user.NewEmail("bob@example.com"), PasswordHash: []byte("hash")}
+		assert.NoError(t, uR.Create(context.Background(), u))
+
+		got, err := uR.QueryByID(context.Background(), u.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, u, got)
+	})
+
+	t.Run("Email must be unique", func(t *testing.T) {
+		u := user.User{ID: uuid.New(), Name: user.NewName("rob"), Email: user.NewEmail("rob@example.com"), PasswordHash: []byte("hash")}
+		err := uR.Create(context.Background(), u)
+		assert.ErrorContains(t, err, "create: ")
+	})
+}
+
+func TestUserRepo_Update(t *testing.T) {
+	testDB, deleteTables := SetupUsersTable(t, fixtureUsers())
+	defer deleteTables()
+	uR := userdb.NewUserRepo(testDB)
+
+	t.Run("Update a user", func(t *testing.T) {
+		u := fixtureUsers()[0]
+		u.Name = user.NewName("robbie")
+		u.Email = user.NewEmail("robbie@example.com")
+		u.Disabled = true
+		assert.NoError(t, uR.Update(context.Background(), u))
+
+		got, err := uR.QueryByID(context.Background(), u.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, u, got)
+	})
+
+	t.Run("User not found", func(t *testing.T) {
+		u := user.User{ID: uuid.New(), Name: user.NewName("bob"), Email: user.NewEmail("bob@example.com")}
+		assert.ErrorIs(t, uR.Update(context.Background(), u), user.ErrUserNotFound)
+	})
+}
+
+func TestUserRepo_Delete(t *testing.T) {
+	testDB, deleteTables := SetupUsersTable(t, fixtureUsers())
+	defer deleteTables()
+	uR := userdb.NewUserRepo(testDB)
+
+	t.Run("Delete a user and their notes", func(t *testing.T) {
+		rob := fixtureUsers()[0]
+		_, err := testDB.Exec(`INSERT INTO notes (id, title, content, user_id) VALUES ($1, 'title', 'content', $2)`, uuid.New(), rob.ID)
+		assert.NoError(t, err)
+
+		assert.NoError(t, uR.Delete(context.Background(), rob.ID))
+
+		_, err = uR.QueryByID(context.Background(), rob.ID)
+		assert.ErrorIs(t, err, user.ErrUserNotFound)
+
+		var count int
+		assert.NoError(t, testDB.QueryRow(`SELECT count(*) FROM notes WHERE user_id=$1`, rob.ID).Scan(&count))
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("User not found", func(t *testing.T) {
+		assert.ErrorIs(t, uR.Delete(context.Background(), uuid.New()), user.ErrUserNotFound)
+	})
+}
+
+func TestUserRepo_Outbox(t *testing.T) {
+	testDB, deleteTables := SetupUsersTable(t, nil)
+	defer deleteTables()
+	uR := userdb.NewUserRepo(testDB)
+	ctx := context.Background()
+
+	u := user.User{ID: uuid.New(), Name: user.NewName("bob"), Email: user.NewEmail("bob@example.com"), PasswordHash: []byte("bobs hash")}
+	assert.NoError(t, uR.Create(ctx, u))
+	u.Disabled = true
+	assert.NoError(t, uR.Update(ctx, u))
+	assert.NoError(t, uR.Delete(ctx, u.ID))
+	assert.ErrorIs(t, uR.Delete(ctx, u.ID), user.ErrUserNotFound)
+
+	records, err := outboxdb.NewOutboxRepo(testDB).Claim(ctx, time.Now(), time.Now(), 10)
+	assert.NoError(t, err)
+	var types []string
+	for _, r := range records {
+		assert.Equal(t, u.ID, r.AggregateID)
+		assert.NotContains(t, string(r.Payload), "password")
+		types = append(types, r.Type)
+	}
+	assert.Equal(t, []string{user.EventCreated, user.EventUpdated, user.EventDeleted}, types)
+}
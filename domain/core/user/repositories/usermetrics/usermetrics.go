@@ -0,0 +1,54 @@
+package usermetrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	"github.com/Keisn1/note-taking-app/foundation/metrics"
+	"github.com/google/uuid"
+)
+
+const repoName = "users"
+
+// Repo decorates a user.Repo with latency metrics.
+type Repo struct {
+	repo user.Repo
+}
+
+func NewRepo(repo user.Repo) Repo {
+	return Repo{repo: repo}
+}
+
+func (r Repo) QueryByID(ctx context.Context, userID uuid.UUID) (_ user.User, err error) {
+	defer observe("query_by_id", time.Now(), &err)
+	return r.repo.QueryByID(ctx, userID)
+}
+
+func (r Repo) QueryByEmail(ctx context.Context, email string) (_ user.User, err error) {
+	defer observe("query_by_email", time.Now(), &err)
+	return r.repo.QueryByEmail(ctx, email)
+}
+
+func (r Repo) Create(ctx context.Context, u user.User) (err error) {
+	defer observe("create", time.Now(), &err)
+	return r.repo.Create(ctx, u)
+}
+
+func (r Repo) Update(ctx context.Context, u user.User) (err error) {
+	defer observe("update", time.Now(), &err)
+	return r.repo.Update(ctx, u)
+}
+
+func (r Repo) Delete(ctx context.Context, userID uuid.UUID) (err error) {
+	defer observe("delete", time.Now(), &err)
+	return r.repo.Delete(ctx, userID)
+}
+
+func observe(operation string, start time.Time, err *error) {
+	result := "success"
+	if *err != nil {
+		result = "error"
+	}
+	metrics.RepoDuration.WithLabelValues(repoName, operation, result).Observe(time.Since(start).Seconds())
+}
@@ -2,12 +2,18 @@ package user
 
 import (
 	"context"
+	"errors"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrUserNotFound = errors.New("user not found")
+)
+
 type Repo interface {
 	QueryByID(ctx context.Context, userID uuid.UUID) (User, error)
+	QueryByEmail(ctx context.Context, email string) (User, error)
 	Create(ctx context.Context, u User) error
 	Update(ctx context.Context, u User) error
 	Delete(ctx context.Context, userID uuid.UUID) error
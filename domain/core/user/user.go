@@ -1,16 +1,27 @@
 package user
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/mail"
 
 	"github.com/google/uuid"
 )
 
+const (
+	MaxNameLength     = 100 // characters
+	MinPasswordLength = 8   // bytes
+	MaxPasswordLength = 72  // bytes, bcrypt ignores everything beyond
+)
+
 type User struct {
 	ID           uuid.UUID
 	Name         Name
 	Email        Email
 	PasswordHash []byte
+	// Disabled users keep their data, but can't log in and their tokens are
+	// rejected.
+	Disabled bool
 }
 
 type UpdateUser struct {
@@ -36,6 +47,27 @@ func (n Name) String() string {
 	return *n.name
 }
 
+// MarshalJSON encodes an empty Name as null.
+func (n Name) MarshalJSON() ([]byte, error) {
+	if n.IsEmpty() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(*n.name)
+}
+
+// UnmarshalJSON leaves the Name empty for null.
+func (n *Name) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	*n = NewName(name)
+	return nil
+}
+
 type Email struct {
 	email *mail.Address
 }
@@ -43,6 +75,17 @@ type Email struct {
 func NewEmail(email string) Email {
 	return Email{email: &mail.Address{Address: email}}
 }
+
+// ParseEmail parses an RFC 5322 address, e.g. "rob@example.com" or
+// "Rob <rob@example.com>".
+func ParseEmail(email string) (Email, error) {
+	addr, err := mail.ParseAddress(email)
+	if err != nil {
+		return Email{}, fmt.Errorf("parseEmail: %w", err)
+	}
+	return Email{email: addr}, nil
+}
+
 func (e Email) IsEmpty() bool          { return e.email == nil }
 func (e Email) Set(email mail.Address) { *e.email = email }
 func (e Email) String() mail.Address {
@@ -52,6 +95,33 @@ func (e Email) String() mail.Address {
 	return *e.email
 }
 
+// MarshalJSON encodes the address without display name, or null if the Email
+// is empty.
+func (e Email) MarshalJSON() ([]byte, error) {
+	if e.IsEmpty() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(e.email.Address)
+}
+
+// UnmarshalJSON parses the address with ParseEmail and leaves the Email empty
+// for null.
+func (e *Email) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	email, err := ParseEmail(s)
+	if err != nil {
+		return err
+	}
+	*e = email
+	return nil
+}
+
 type Password struct {
 	password *string
 }
@@ -68,3 +138,17 @@ func (p Password) String() string {
 	}
 	return *p.password
 }
+
+// UnmarshalJSON leaves the Password empty for null. Passwords have no
+// MarshalJSON, they are never encoded.
+func (p *Password) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var password string
+	if err := json.Unmarshal(data, &password); err != nil {
+		return err
+	}
+	*p = NewPassword(password)
+	return nil
+}
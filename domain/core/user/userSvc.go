@@ -5,31 +5,76 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/Keisn1/note-taking-app/foundation/tracing"
+	"github.com/Keisn1/note-taking-app/foundation/transaction"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/crypto/bcrypt"
 )
 
+var tracer = otel.Tracer("github.com/Keisn1/note-taking-app/domain/core/user")
+
 var (
-	ErrInvalidPassword = errors.New("invalid password")
+	ErrInvalidPassword    = errors.New("invalid password")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrUserDisabled       = errors.New("user disabled")
+	ErrEmailTaken         = errors.New("email already taken")
 )
 
+// dummyHash is compared against if the email is unknown, so that the response
+// time doesn't reveal which emails are registered.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+
 type Service interface {
 	QueryByID(ctx context.Context, userID uuid.UUID) (User, error)
+	QueryByEmail(ctx context.Context, email string) (User, error)
+	Authenticate(ctx context.Context, email, password string) (User, error)
 	Create(ctx context.Context, nu UpdateUser) (User, error)
 	Update(ctx context.Context, u User, uu UpdateUser) (User, error)
+	SetDisabled(ctx context.Context, userID uuid.UUID, disabled bool) (User, error)
 	Delete(ctx context.Context, userID uuid.UUID) error
 }
 
 type Svc struct {
-	repo Repo
+	repo        Repo
+	deleteHooks []func(ctx context.Context, userID uuid.UUID) error
+	txm         transaction.Manager
+}
+
+type Option func(*Svc)
+
+// WithDeleteHook registers fn to be called before a user is deleted, for
+// data that belongs to the user but is stored elsewhere, like the notes. The
+// user is not deleted if fn fails.
+func WithDeleteHook(fn func(ctx context.Context, userID uuid.UUID) error) Option {
+	return func(s *Svc) {
+		s.deleteHooks = append(s.deleteHooks, fn)
+	}
+}
+
+// WithTransactions deletes a user in a transaction of m together with the
+// delete hooks, it is rolled back if one of them fails.
+func WithTransactions(m transaction.Manager) Option {
+	return func(s *Svc) {
+		s.txm = m
+	}
 }
 
-func NewSvc(repo Repo) Service {
-	return Svc{repo: repo}
+func NewSvc(repo Repo, opts ...Option) Service {
+	s := Svc{repo: repo}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s
 }
 
-func (s Svc) Update(ctx context.Context, u User, newU UpdateUser) (User, error) {
-	_, err := s.repo.QueryByID(ctx, u.ID)
+func (s Svc) Update(ctx context.Context, u User, newU UpdateUser) (_ User, err error) {
+	ctx, span := tracer.Start(ctx, "user.Update", trace.WithAttributes(attribute.String("user.id", u.ID.String())))
+	defer tracing.End(span, &err)
+
+	_, err = s.repo.QueryByID(ctx, u.ID)
 	if err != nil {
 		return User{}, err
 	}
@@ -39,6 +84,9 @@ func (s Svc) Update(ctx context.Context, u User, newU UpdateUser) (User, error)
 	}
 
 	if !newU.Email.IsEmpty() {
+		if err := s.checkEmailFree(ctx, newU.Email, u.ID); err != nil {
+			return User{}, fmt.Errorf("update: %w", err)
+		}
 		u.Email = newU.Email
 	}
 
@@ -50,19 +98,85 @@ func (s Svc) Update(ctx context.Context, u User, newU UpdateUser) (User, error)
 		u.PasswordHash = pwHash
 	}
 
-	s.repo.Update(ctx, u)
+	if err := s.repo.Update(ctx, u); err != nil {
+		return User{}, fmt.Errorf("update: %w", err)
+	}
+
+	return u, nil
+}
+
+// SetDisabled disables or re-enables the user.
+func (s Svc) SetDisabled(ctx context.Context, userID uuid.UUID, disabled bool) (_ User, err error) {
+	ctx, span := tracer.Start(ctx, "user.SetDisabled", trace.WithAttributes(attribute.String("user.id", userID.String())))
+	defer tracing.End(span, &err)
+
+	u, err := s.repo.QueryByID(ctx, userID)
+	if err != nil {
+		return User{}, fmt.Errorf("setDisabled: %w", err)
+	}
+
+	u.Disabled = disabled
+	if err := s.repo.Update(ctx, u); err != nil {
+		return User{}, fmt.Errorf("setDisabled: %w", err)
+	}
+	return u, nil
+}
+
+// Authenticate checks the password of the user with the email.
+func (s Svc) Authenticate(ctx context.Context, email, password string) (_ User, err error) {
+	ctx, span := tracer.Start(ctx, "user.Authenticate")
+	defer tracing.End(span, &err)
+
+	u, err := s.repo.QueryByEmail(ctx, email)
+	if err != nil {
+		if !errors.Is(err, ErrUserNotFound) {
+			return User{}, fmt.Errorf("authenticate: %w", err)
+		}
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return User{}, fmt.Errorf("authenticate: %w", ErrInvalidCredentials)
+	}
+
+	if err := bcrypt.CompareHashAndPassword(u.PasswordHash, []byte(password)); err != nil {
+		return User{}, fmt.Errorf("authenticate: %w", ErrInvalidCredentials)
+	}
 
+	if u.Disabled {
+		return User{}, fmt.Errorf("authenticate: %w", ErrUserDisabled)
+	}
 	return u, nil
 }
 
-func (s Svc) Delete(ctx context.Context, userID uuid.UUID) error {
-	if err := s.repo.Delete(ctx, userID); err != nil {
+// Delete runs the delete hooks and deletes the user.
+func (s Svc) Delete(ctx context.Context, userID uuid.UUID) (err error) {
+	ctx, span := tracer.Start(ctx, "user.Delete", trace.WithAttributes(attribute.String("user.id", userID.String())))
+	defer tracing.End(span, &err)
+
+	err = s.withinTx(ctx, func(ctx context.Context) error {
+		for _, hook := range s.deleteHooks {
+			if err := hook(ctx, userID); err != nil {
+				return err
+			}
+		}
+		return s.repo.Delete(ctx, userID)
+	})
+	if err != nil {
 		return fmt.Errorf("delete: %w", err)
 	}
 	return nil
 }
 
-func (s Svc) Create(ctx context.Context, newU UpdateUser) (User, error) {
+// withinTx runs fn in a transaction if the service has a manager.
+func (s Svc) withinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.txm == nil {
+		return fn(ctx)
+	}
+	return s.txm.WithinTx(ctx, fn)
+}
+
+func (s Svc) Create(ctx context.Context, newU UpdateUser) (_ User, err error) {
+	ctx, span := tracer.Start(ctx, "user.Create")
+	defer tracing.End(span, &err)
+
 	if len(newU.Password.String()) == 0 {
 		return User{}, fmt.Errorf("create: %w", ErrInvalidPassword)
 	}
@@ -73,6 +187,10 @@ func (s Svc) Create(ctx context.Context, newU UpdateUser) (User, error) {
 		return User{}, fmt.Errorf("create: %w: %w", ErrInvalidPassword, err)
 	}
 
+	if err := s.checkEmailFree(ctx, newU.Email, uuid.Nil); err != nil {
+		return User{}, fmt.Errorf("create: %w", err)
+	}
+
 	u := User{
 		ID:           uuid.New(),
 		Name:         newU.Name,
@@ -80,14 +198,45 @@ func (s Svc) Create(ctx context.Context, newU UpdateUser) (User, error) {
 		PasswordHash: pwHash,
 	}
 
-	s.repo.Create(ctx, u)
+	if err := s.repo.Create(ctx, u); err != nil {
+		return User{}, fmt.Errorf("create: %w", err)
+	}
 	return u, nil
 }
 
-func (s Svc) QueryByID(ctx context.Context, userID uuid.UUID) (User, error) {
+func (s Svc) QueryByID(ctx context.Context, userID uuid.UUID) (_ User, err error) {
+	ctx, span := tracer.Start(ctx, "user.QueryByID", trace.WithAttributes(attribute.String("user.id", userID.String())))
+	defer tracing.End(span, &err)
+
 	u, err := s.repo.QueryByID(ctx, userID)
 	if err != nil {
 		return User{}, fmt.Errorf("queryByID: %w", err)
 	}
 	return u, nil
 }
+
+func (s Svc) QueryByEmail(ctx context.Context, email string) (_ User, err error) {
+	ctx, span := tracer.Start(ctx, "user.QueryByEmail")
+	defer tracing.End(span, &err)
+
+	u, err := s.repo.QueryByEmail(ctx, email)
+	if err != nil {
+		return User{}, fmt.Errorf("queryByEmail: %w", err)
+	}
+	return u, nil
+}
+
+// checkEmailFree fails with ErrEmailTaken if a user other than userID has
+// the email.
+func (s Svc) checkEmailFree(ctx context.Context, email Email, userID uuid.UUID) error {
+	u, err := s.repo.QueryByEmail(ctx, email.String().Address)
+	switch {
+	case errors.Is(err, ErrUserNotFound):
+		return nil
+	case err != nil:
+		return err
+	case u.ID != userID:
+		return ErrEmailTaken
+	}
+	return nil
+}
@@ -2,10 +2,12 @@ package user_test
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/Keisn1/note-taking-app/domain/core/user"
 	"github.com/Keisn1/note-taking-app/domain/core/user/repositories/memory"
+	"github.com/Keisn1/note-taking-app/foundation/transaction"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/crypto/bcrypt"
@@ -35,6 +37,45 @@ func Test_Delete(t *testing.T) {
 		assert.Error(t, err)
 		assert.ErrorContains(t, err, "delete")
 	})
+
+	t.Run("The delete hooks run before the user is deleted", func(t *testing.T) {
+		rob := user.User{ID: uuid.UUID{1}, Name: user.NewName("rob"), Email: user.NewEmail("rob@example.com")}
+		repo := memory.NewRepo([]user.User{rob})
+		var found []error
+		svc := user.NewSvc(repo, user.WithDeleteHook(func(ctx context.Context, userID uuid.UUID) error {
+			_, err := repo.QueryByID(ctx, userID)
+			found = append(found, err)
+			return nil
+		}))
+
+		assert.NoError(t, svc.Delete(context.Background(), rob.ID))
+		assert.Equal(t, []error{nil}, found)
+		_, err := repo.QueryByID(context.Background(), rob.ID)
+		assert.ErrorIs(t, err, user.ErrUserNotFound)
+	})
+
+	t.Run("The deletion is rolled back if a hook fails", func(t *testing.T) {
+		rob := user.User{ID: uuid.UUID{1}, Name: user.NewName("rob"), Email: user.NewEmail("rob@example.com")}
+		anna := user.User{ID: uuid.UUID{2}, Name: user.NewName("anna"), Email: user.NewEmail("anna@example.com")}
+		repo := memory.NewRepo([]user.User{rob, anna})
+		hookErr := errors.New("hook failed")
+		svc := user.NewSvc(repo,
+			user.WithTransactions(transaction.NewMemory(repo)),
+			user.WithDeleteHook(func(ctx context.Context, userID uuid.UUID) error {
+				return repo.Delete(ctx, anna.ID)
+			}),
+			user.WithDeleteHook(func(ctx context.Context, userID uuid.UUID) error {
+				return hookErr
+			}),
+		)
+
+		assert.ErrorIs(t, svc.Delete(context.Background(), rob.ID), hookErr)
+		for _, u := range []user.User{rob, anna} {
+			got, err := repo.QueryByID(context.Background(), u.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, u, got)
+		}
+	})
 }
 
 func Test_Update(t *testing.T) {
@@ -228,3 +269,102 @@ func Test_QueryByID(t *testing.T) {
 		}
 	})
 }
+
+func Test_QueryByEmail(t *testing.T) {
+	rob := user.User{ID: uuid.UUID{1}, Name: user.NewName("rob"), Email: user.NewEmail("rob@example.com")}
+	svc := user.NewSvc(memory.NewRepo([]user.User{rob}))
+
+	t.Run("I can get a user by the email", func(t *testing.T) {
+		got, err := svc.QueryByEmail(context.Background(), "rob@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, rob, got)
+	})
+
+	t.Run("return error on missing user", func(t *testing.T) {
+		_, err := svc.QueryByEmail(context.Background(), "anna@example.com")
+		assert.ErrorIs(t, err, user.ErrUserNotFound)
+		assert.ErrorContains(t, err, "queryByEmail")
+	})
+}
+
+func Test_SetDisabled(t *testing.T) {
+	rob := user.User{ID: uuid.UUID{1}, Name: user.NewName("rob"), Email: user.NewEmail("rob@example.com")}
+	svc := user.NewSvc(memory.NewRepo([]user.User{rob}))
+
+	t.Run("Disable and enable a user", func(t *testing.T) {
+		got, err := svc.SetDisabled(context.Background(), rob.ID, true)
+		assert.NoError(t, err)
+		assert.True(t, got.Disabled)
+
+		got, err = svc.QueryByID(context.Background(), rob.ID)
+		assert.NoError(t, err)
+		assert.True(t, got.Disabled)
+
+		got, err = svc.SetDisabled(context.Background(), rob.ID, false)
+		assert.NoError(t, err)
+		assert.False(t, got.Disabled)
+	})
+
+	t.Run("Error if user not present", func(t *testing.T) {
+		_, err := svc.SetDisabled(context.Background(), uuid.New(), true)
+		assert.ErrorIs(t, err, user.ErrUserNotFound)
+		assert.ErrorContains(t, err, "setDisabled")
+	})
+}
+
+func Test_Authenticate(t *testing.T) {
+	ctx := context.Background()
+	svc := user.NewSvc(memory.NewRepo(nil))
+	rob, err := svc.Create(ctx, user.UpdateUser{
+		Name: user.NewName("rob"), Email: user.NewEmail("rob@example.com"), Password: user.NewPassword("password"),
+	})
+	assert.NoError(t, err)
+
+	t.Run("Correct credentials", func(t *testing.T) {
+		got, err := svc.Authenticate(ctx, "rob@example.com", "password")
+		assert.NoError(t, err)
+		assert.Equal(t, rob.ID, got.ID)
+	})
+
+	t.Run("Wrong password", func(t *testing.T) {
+		_, err := svc.Authenticate(ctx, "rob@example.com", "wrong password")
+		assert.ErrorIs(t, err, user.ErrInvalidCredentials)
+	})
+
+	t.Run("Unknown email", func(t *testing.T) {
+		_, err := svc.Authenticate(ctx, "anna@example.com", "password")
+		assert.ErrorIs(t, err, user.ErrInvalidCredentials)
+	})
+
+	t.Run("Disabled user", func(t *testing.T) {
+		_, err := svc.SetDisabled(ctx, rob.ID, true)
+		assert.NoError(t, err)
+
+		_, err = svc.Authenticate(ctx, "rob@example.com", "password")
+		assert.ErrorIs(t, err, user.ErrUserDisabled)
+	})
+}
+
+func Test_EmailTaken(t *testing.T) {
+	ctx := context.Background()
+	rob := user.User{ID: uuid.UUID{1}, Name: user.NewName("rob"), Email: user.NewEmail("rob@example.com")}
+	anna := user.User{ID: uuid.UUID{2}, Name: user.NewName("anna"), Email: user.NewEmail("anna@example.com")}
+	svc := user.NewSvc(memory.NewRepo([]user.User{rob, anna}))
+
+	t.Run("Create with a taken email", func(t *testing.T) {
+		_, err := svc.Create(ctx, user.UpdateUser{
+			Name: user.NewName("robbie"), Email: user.NewEmail("rob@example.com"), Password: user.NewPassword("password"),
+		})
+		assert.ErrorIs(t, err, user.ErrEmailTaken)
+	})
+
+	t.Run("Update to a taken email", func(t *testing.T) {
+		_, err := svc.Update(ctx, anna, user.UpdateUser{Email: user.NewEmail("rob@example.com")})
+		assert.ErrorIs(t, err, user.ErrEmailTaken)
+	})
+
+	t.Run("Update keeping the own email", func(t *testing.T) {
+		_, err := svc.Update(ctx, rob, user.UpdateUser{Name: user.NewName("robbie"), Email: user.NewEmail("rob@example.com")})
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,54 @@
+package user_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEmail(t *testing.T) {
+	t.Run("Valid addresses are parsed", func(t *testing.T) {
+		email, err := user.ParseEmail("rob@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, "rob@example.com", email.String().Address)
+
+		email, err = user.ParseEmail("Rob <rob@example.com>")
+		assert.NoError(t, err)
+		assert.Equal(t, "rob@example.com", email.String().Address)
+		assert.Equal(t, "Rob", email.String().Name)
+	})
+
+	t.Run("Invalid addresses return an error", func(t *testing.T) {
+		for _, s := range []string{"", "rob", "rob@", "@example.com", "rob@example.com, anna@example.com"} {
+			_, err := user.ParseEmail(s)
+			assert.ErrorContains(t, err, "parseEmail", s)
+		}
+	})
+}
+
+func TestUser_JSON(t *testing.T) {
+	t.Run("Name and email are encoded as strings, empty values as null", func(t *testing.T) {
+		data, err := json.Marshal(struct {
+			Name  user.Name
+			Email user.Email
+		}{Name: user.NewName("rob")})
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"Name": "rob", "Email": null}`, string(data))
+	})
+
+	t.Run("Emails are parsed", func(t *testing.T) {
+		var uu user.UpdateUser
+		assert.NoError(t, json.Unmarshal([]byte(`{"Name": null, "Email": "Rob <rob@example.com>", "Password": "password"}`), &uu))
+		assert.True(t, uu.Name.IsEmpty())
+		assert.Equal(t, "rob@example.com", uu.Email.String().Address)
+		assert.Equal(t, "password", uu.Password.String())
+
+		data, err := json.Marshal(uu.Email)
+		assert.NoError(t, err)
+		assert.Equal(t, `"rob@example.com"`, string(data))
+
+		assert.ErrorContains(t, json.Unmarshal([]byte(`{"Email": "rob"}`), &uu), "parseEmail")
+	})
+}
@@ -0,0 +1,217 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// claimLease is how long a claimed delivery is skipped by other
+	// dispatchers. It must exceed the timeout of the client; a delivery
+	// whose dispatcher stopped while sending it is sent again afterwards.
+	claimLease = 5 * time.Minute
+
+	// pruneInterval is how often finished deliveries older than the
+	// retention are deleted.
+	pruneInterval = time.Hour
+
+	maxErrorLength    = 1000 // bytes of LastError
+	maxResponseLength = 64 << 10
+)
+
+// DispatcherConfig configures a Dispatcher. Zero values are replaced by the
+// defaults.
+type DispatcherConfig struct {
+	PollInterval time.Duration // default 5s
+	BatchSize    int           // deliveries claimed at once, default 20
+	MaxAttempts  int           // default DefaultMaxAttempts
+	Retention    time.Duration // of finished deliveries, default 30 days
+
+	// OnError is called with the errors of the outbox, not those of the
+	// endpoints, which are recorded in the deliveries.
+	OnError func(error)
+}
+
+// Dispatcher sends the pending deliveries of the outbox. Several
+// dispatchers, also in other processes, can share an outbox; a delivery is
+// sent at least once, receivers drop duplicates by the ID in the payload.
+// Deliveries are not ordered, not even those of a note.
+//
+// A failed delivery is attempted again after an exponential backoff, from
+// 30s up to 6h, and is dead after MaxAttempts attempts.
+type Dispatcher struct {
+	repo   Repo
+	client *http.Client
+	cfg    DispatcherConfig
+}
+
+func NewDispatcher(repo Repo, client *http.Client, cfg DispatcherConfig) Dispatcher {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 5 * time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 20
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = DefaultMaxAttempts
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = 30 * 24 * time.Hour
+	}
+	if cfg.OnError == nil {
+		cfg.OnError = func(error) {}
+	}
+	return Dispatcher{repo: repo, client: client, cfg: cfg}
+}
+
+// Run dispatches the due deliveries every poll interval until ctx is done.
+// Deliveries that are being sent then are sent again after their lease.
+func (d Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	var pruned time.Time
+	for {
+		now := time.Now()
+		if err := d.Dispatch(ctx, now); err != nil && ctx.Err() == nil {
+			d.cfg.OnError(err)
+		}
+		if now.Sub(pruned) >= pruneInterval {
+			if err := d.repo.DeleteDeliveriesBefore(ctx, now.Add(-d.cfg.Retention)); err != nil && ctx.Err() == nil {
+				d.cfg.OnError(fmt.Errorf("prune: %w", err))
+			}
+			pruned = now
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch sends the deliveries that are due at now, until none is left.
+func (d Dispatcher) Dispatch(ctx context.Context, now time.Time) error {
+	for {
+		jobs, err := d.repo.Claim(ctx, now, now.Add(claimLease), d.cfg.BatchSize)
+		if err != nil {
+			return fmt.Errorf("dispatch: %w", err)
+		}
+
+		var wg sync.WaitGroup
+		for _, j := range jobs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				d.deliver(ctx, j, now)
+			}()
+		}
+		wg.Wait()
+
+		if len(jobs) < d.cfg.BatchSize || ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+func (d Dispatcher) deliver(ctx context.Context, j Job, now time.Time) {
+	del := j.Delivery
+	status, err := d.send(ctx, j, now)
+	if ctx.Err() != nil {
+		// Stopped, not the fault of the endpoint.
+		return
+	}
+
+	del.Attempts++
+	del.ResponseStatus = status
+	del.LastError = ""
+	del.UpdatedAt = now.UTC().Truncate(time.Microsecond)
+	switch {
+	case err == nil:
+		del.Status = StatusSucceeded
+	case del.Attempts >= d.cfg.MaxAttempts:
+		del.Status, del.LastError = StatusDead, truncate(err.Error())
+	default:
+		del.LastError = truncate(err.Error())
+		del.NextAttemptAt = del.UpdatedAt.Add(backoff(del.Attempts))
+	}
+
+	if err := d.repo.UpdateDelivery(ctx, del); err != nil && ctx.Err() == nil {
+		d.cfg.OnError(fmt.Errorf("deliver: [%s]: %w", del.ID, err))
+	}
+}
+
+// send posts the payload to the endpoint. Any status other than 2xx is a
+// failure, redirects are not followed.
+func (d Dispatcher) send(ctx context.Context, j Job, now time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, j.Webhook.URL, bytes.NewReader(j.Delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "notes-webhooks/1.0")
+	req.Header.Set(EventHeader, j.Delivery.Event)
+	req.Header.Set(DeliveryHeader, j.Delivery.ID.String())
+	req.Header.Set(SignatureHeader, Sign(j.Webhook.Secret, now, j.Delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Read a bit of the body, so that the connection can be reused.
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseLength))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("%w: %s", ErrUnexpectedStatus, resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+func truncate(s string) string {
+	if len(s) <= maxErrorLength {
+		return s
+	}
+	return strings.ToValidUTF8(s[:maxErrorLength], "")
+}
+
+// NewHTTPClient returns the client for the deliveries. Unless allowPrivate
+// is set it refuses to connect to loopback, private and link-local
+// addresses, so that webhooks can't reach the internal network. The check
+// is made on the resolved address, redirects and proxies are not used.
+func NewHTTPClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !ip.IsGlobalUnicast() || ip.IsPrivate() {
+				return fmt.Errorf("%w: %s", ErrForbiddenEndpoint, host)
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,136 @@
+package webhook_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/Keisn1/note-taking-app/domain/core/webhook"
+	"github.com/Keisn1/note-taking-app/domain/core/webhook/repositories/memory"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// endpoint records the deliveries it gets and answers with status.
+type endpoint struct {
+	mu       sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (e *endpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.requests = append(e.requests, r)
+	e.bodies = append(e.bodies, body)
+	w.WriteHeader(e.status)
+}
+
+func setupDispatcher(t *testing.T, status int) (*endpoint, webhook.WebhookService, webhook.Dispatcher, webhook.Webhook) {
+	t.Helper()
+	e := &endpoint{status: status}
+	srv := httptest.NewServer(e)
+	t.Cleanup(srv.Close)
+
+	repo := memory.NewRepo()
+	ws := webhook.NewSvc(repo)
+	w, err := ws.Create(context.Background(), webhook.NewWebhook{UserID: rob, URL: srv.URL})
+	require.NoError(t, err)
+	require.NoError(t, ws.Enqueue(context.Background(), note.Event{Type: note.EventCreated, Note: note.Note{ID: uuid.New(), UserID: rob}}))
+
+	d := webhook.NewDispatcher(repo, webhook.NewHTTPClient(time.Second, true), webhook.DispatcherConfig{MaxAttempts: 3})
+	return e, ws, d, w
+}
+
+func onlyDelivery(t *testing.T, ws webhook.WebhookService, w webhook.Webhook) webhook.Delivery {
+	t.Helper()
+	ds, err := ws.Deliveries(context.Background(), w.ID, "", 10)
+	require.NoError(t, err)
+	require.Len(t, ds, 1)
+	return ds[0]
+}
+
+func TestDispatcher(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Deliveries are signed and sent once", func(t *testing.T) {
+		e, ws, d, w := setupDispatcher(t, http.StatusNoContent)
+		now := time.Now()
+
+		require.NoError(t, d.Dispatch(ctx, now))
+		require.NoError(t, d.Dispatch(ctx, now.Add(time.Hour)))
+		require.Len(t, e.requests, 1)
+
+		del := onlyDelivery(t, ws, w)
+		assert.Equal(t, webhook.StatusSucceeded, del.Status)
+		assert.Equal(t, 1, del.Attempts)
+		assert.Equal(t, http.StatusNoContent, del.ResponseStatus)
+
+		r := e.requests[0]
+		assert.Equal(t, note.EventCreated, r.Header.Get(webhook.EventHeader))
+		assert.Equal(t, del.ID.String(), r.Header.Get(webhook.DeliveryHeader))
+		assert.Equal(t, del.Payload, e.bodies[0])
+		assert.NoError(t, webhook.Verify(w.Secret, r.Header.Get(webhook.SignatureHeader), e.bodies[0], now, time.Minute))
+		assert.ErrorIs(t, webhook.Verify("whsec_other", r.Header.Get(webhook.SignatureHeader), e.bodies[0], now, time.Minute), webhook.ErrInvalidSignature)
+		assert.ErrorIs(t, webhook.Verify(w.Secret, r.Header.Get(webhook.SignatureHeader), e.bodies[0], now.Add(time.Hour), time.Minute), webhook.ErrSignatureTooOld)
+	})
+
+	t.Run("Failed deliveries back off and are dead after the last attempt", func(t *testing.T) {
+		e, ws, d, w := setupDispatcher(t, http.StatusInternalServerError)
+		now := time.Now()
+
+		require.NoError(t, d.Dispatch(ctx, now))
+		del := onlyDelivery(t, ws, w)
+		assert.Equal(t, webhook.StatusPending, del.Status)
+		assert.Equal(t, http.StatusInternalServerError, del.ResponseStatus)
+		assert.Equal(t, "unexpected response status: 500 Internal Server Error", del.LastError)
+		assert.WithinDuration(t, now.Add(30*time.Second), del.NextAttemptAt, time.Millisecond)
+
+		require.NoError(t, d.Dispatch(ctx, now.Add(29*time.Second)))
+		assert.Len(t, e.requests, 1)
+
+		require.NoError(t, d.Dispatch(ctx, now.Add(30*time.Second)))
+		del = onlyDelivery(t, ws, w)
+		assert.Equal(t, 2, del.Attempts)
+		assert.WithinDuration(t, now.Add(90*time.Second), del.NextAttemptAt, time.Millisecond)
+
+		require.NoError(t, d.Dispatch(ctx, now.Add(90*time.Second)))
+		del = onlyDelivery(t, ws, w)
+		assert.Equal(t, webhook.StatusDead, del.Status)
+		assert.Equal(t, 3, del.Attempts)
+
+		require.NoError(t, d.Dispatch(ctx, now.Add(time.Hour)))
+		assert.Len(t, e.requests, 3)
+
+		del, err := ws.Retry(ctx, del)
+		require.NoError(t, err)
+		e.mu.Lock()
+		e.status = http.StatusOK
+		e.mu.Unlock()
+		require.NoError(t, d.Dispatch(ctx, time.Now()))
+		assert.Equal(t, webhook.StatusSucceeded, onlyDelivery(t, ws, w).Status)
+	})
+
+	t.Run("Private addresses are refused unless allowed", func(t *testing.T) {
+		_, ws, _, w := setupDispatcher(t, http.StatusOK)
+		repo := memory.NewRepo()
+		require.NoError(t, repo.Create(ctx, w))
+		require.NoError(t, repo.CreateDeliveries(ctx, []webhook.Delivery{onlyDelivery(t, ws, w)}))
+
+		d := webhook.NewDispatcher(repo, webhook.NewHTTPClient(time.Second, false), webhook.DispatcherConfig{})
+		require.NoError(t, d.Dispatch(ctx, time.Now()))
+
+		del, err := repo.QueryDeliveryByID(ctx, onlyDelivery(t, ws, w).ID)
+		require.NoError(t, err)
+		assert.Equal(t, webhook.StatusPending, del.Status)
+		assert.Contains(t, del.LastError, webhook.ErrForbiddenEndpoint.Error())
+	})
+}
@@ -0,0 +1,187 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/domain/core/webhook"
+	"github.com/google/uuid"
+)
+
+type Repo struct {
+	mu         *sync.Mutex
+	webhooks   map[uuid.UUID]webhook.Webhook
+	deliveries map[uuid.UUID]webhook.Delivery
+}
+
+func NewRepo() Repo {
+	return Repo{mu: &sync.Mutex{}, webhooks: make(map[uuid.UUID]webhook.Webhook), deliveries: make(map[uuid.UUID]webhook.Delivery)}
+}
+
+func (wR Repo) Create(ctx context.Context, w webhook.Webhook) error {
+	wR.mu.Lock()
+	defer wR.mu.Unlock()
+
+	if _, ok := wR.webhooks[w.ID]; ok {
+		return fmt.Errorf("create: already present %s", w.ID)
+	}
+	wR.webhooks[w.ID] = w
+	return nil
+}
+
+func (wR Repo) QueryByID(ctx context.Context, id uuid.UUID) (webhook.Webhook, error) {
+	wR.mu.Lock()
+	defer wR.mu.Unlock()
+
+	w, ok := wR.webhooks[id]
+	if !ok {
+		return webhook.Webhook{}, webhook.ErrNotFound
+	}
+	return w, nil
+}
+
+func (wR Repo) QueryByUserID(ctx context.Context, userID uuid.UUID) ([]webhook.Webhook, error) {
+	wR.mu.Lock()
+	defer wR.mu.Unlock()
+
+	var ret []webhook.Webhook
+	for _, w := range wR.webhooks {
+		if w.UserID == userID {
+			ret = append(ret, w)
+		}
+	}
+	slices.SortFunc(ret, func(a, b webhook.Webhook) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID.String(), b.ID.String())
+	})
+	return ret, nil
+}
+
+func (wR Repo) Delete(ctx context.Context, id uuid.UUID) error {
+	wR.mu.Lock()
+	defer wR.mu.Unlock()
+
+	if _, ok := wR.webhooks[id]; !ok {
+		return webhook.ErrNotFound
+	}
+	delete(wR.webhooks, id)
+	for dID, d := range wR.deliveries {
+		if d.WebhookID == id {
+			delete(wR.deliveries, dID)
+		}
+	}
+	return nil
+}
+
+func (wR Repo) CreateDeliveries(ctx context.Context, ds []webhook.Delivery) error {
+	wR.mu.Lock()
+	defer wR.mu.Unlock()
+
+	for _, d := range ds {
+		if _, ok := wR.webhooks[d.WebhookID]; !ok {
+			return fmt.Errorf("createDeliveries: %w", webhook.ErrNotFound)
+		}
+		if _, ok := wR.deliveries[d.ID]; ok {
+			continue
+		}
+		wR.deliveries[d.ID] = d
+	}
+	return nil
+}
+
+func (wR Repo) QueryDeliveryByID(ctx context.Context, id uuid.UUID) (webhook.Delivery, error) {
+	wR.mu.Lock()
+	defer wR.mu.Unlock()
+
+	d, ok := wR.deliveries[id]
+	if !ok {
+		return webhook.Delivery{}, webhook.ErrDeliveryNotFound
+	}
+	return d, nil
+}
+
+func (wR Repo) QueryDeliveries(ctx context.Context, webhookID uuid.UUID, status webhook.Status, limit int) ([]webhook.Delivery, error) {
+	wR.mu.Lock()
+	defer wR.mu.Unlock()
+
+	var ret []webhook.Delivery
+	for _, d := range wR.deliveries {
+		if d.WebhookID == webhookID && (status == "" || d.Status == status) {
+			ret = append(ret, d)
+		}
+	}
+	slices.SortFunc(ret, func(a, b webhook.Delivery) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.ID.String(), a.ID.String())
+	})
+	return ret[:min(len(ret), limit)], nil
+}
+
+func (wR Repo) UpdateDelivery(ctx context.Context, d webhook.Delivery) error {
+	wR.mu.Lock()
+	defer wR.mu.Unlock()
+
+	if _, ok := wR.deliveries[d.ID]; !ok {
+		return webhook.ErrDeliveryNotFound
+	}
+	wR.deliveries[d.ID] = d
+	return nil
+}
+
+func (wR Repo) Claim(ctx context.Context, now, until time.Time, limit int) ([]webhook.Job, error) {
+	wR.mu.Lock()
+	defer wR.mu.Unlock()
+
+	var due []webhook.Delivery
+	for _, d := range wR.deliveries {
+		if d.Status == webhook.StatusPending && !d.NextAttemptAt.After(now) {
+			due = append(due, d)
+		}
+	}
+	slices.SortFunc(due, func(a, b webhook.Delivery) int { return a.NextAttemptAt.Compare(b.NextAttemptAt) })
+
+	jobs := make([]webhook.Job, 0, min(len(due), limit))
+	for _, d := range due[:min(len(due), limit)] {
+		d.NextAttemptAt = until
+		wR.deliveries[d.ID] = d
+		jobs = append(jobs, webhook.Job{Webhook: wR.webhooks[d.WebhookID], Delivery: d})
+	}
+	return jobs, nil
+}
+
+func (wR Repo) DeleteDeliveriesBefore(ctx context.Context, t time.Time) error {
+	wR.mu.Lock()
+	defer wR.mu.Unlock()
+
+	for id, d := range wR.deliveries {
+		if d.Status != webhook.StatusPending && d.UpdatedAt.Before(t) {
+			delete(wR.deliveries, id)
+		}
+	}
+	return nil
+}
+
+// Snapshot copies the webhooks and deliveries for a transaction.Memory.
+func (wR Repo) Snapshot() (restore func()) {
+	wR.mu.Lock()
+	defer wR.mu.Unlock()
+
+	webhooks, deliveries := maps.Clone(wR.webhooks), maps.Clone(wR.deliveries)
+	return func() {
+		wR.mu.Lock()
+		defer wR.mu.Unlock()
+		clear(wR.webhooks)
+		maps.Copy(wR.webhooks, webhooks)
+		clear(wR.deliveries)
+		maps.Copy(wR.deliveries, deliveries)
+	}
+}
@@ -0,0 +1,87 @@
+package webhookdb_test
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+)
+
+func run(m *testing.M) int {
+	var (
+		dropDB   = fmt.Sprintf(`DROP DATABASE IF EXISTS %s;`, testDBName)
+		createDB = fmt.Sprintf(`CREATE DATABASE %s;`, testDBName)
+	)
+
+	dsn := fmt.Sprintf("host=localhost port=5432 user=%s password=%s sslmode=disable", testUser, testPassword)
+	postgresDB, err := sql.Open("pgx", dsn)
+	if err != nil {
+		panic(err)
+	}
+	defer postgresDB.Close()
+
+	_, err = postgresDB.Exec(dropDB)
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = postgresDB.Exec(createDB)
+	if err != nil {
+		panic(err)
+	}
+
+	defer func() {
+		_, err = postgresDB.Exec(dropDB)
+		if err != nil {
+			panic(fmt.Errorf("postgresDB.Exec() err = %s", err))
+		}
+	}()
+
+	return m.Run()
+}
+
+func SetupWebhooksTables(t *testing.T) (*sql.DB, func()) {
+	var (
+		createTables = `
+		CREATE TABLE webhooks(
+			id UUID PRIMARY KEY,
+			user_id UUID NOT NULL,
+			url TEXT NOT NULL,
+			events TEXT NOT NULL,
+			secret TEXT NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL);
+		CREATE TABLE webhook_deliveries(
+			id UUID PRIMARY KEY,
+			webhook_id UUID NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+			event TEXT NOT NULL,
+			payload BYTEA NOT NULL,
+			status TEXT NOT NULL,
+			attempts INT NOT NULL,
+			next_attempt_at TIMESTAMPTZ NOT NULL,
+			response_status INT NOT NULL,
+			last_error TEXT NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL)`
+		dropTables = `DROP TABLE webhook_deliveries, webhooks`
+	)
+
+	dsn := fmt.Sprintf("host=localhost port=5432 user=%s password=%s sslmode=disable dbname=%s ", testUser, testPassword, testDBName)
+	testDB, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = testDB.Exec(createTables)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deleteTables := func() {
+		_, err := testDB.Exec(dropTables)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testDB.Close()
+	}
+
+	return testDB, deleteTables
+}
@@ -0,0 +1,290 @@
+package webhookdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/domain/core/webhook"
+	"github.com/Keisn1/note-taking-app/foundation/tracing"
+	"github.com/Keisn1/note-taking-app/foundation/transaction"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/Keisn1/note-taking-app/domain/core/webhook/repositories/webhookdb")
+
+const (
+	webhookColumns  = `w.id, w.user_id, w.url, w.events, w.secret, w.created_at`
+	deliveryColumns = `d.id, d.webhook_id, d.event, d.payload, d.status, d.attempts, d.next_attempt_at, d.response_status, d.last_error, d.created_at, d.updated_at`
+)
+
+type WebhookRepo struct {
+	db transaction.Conn
+}
+
+func NewWebhookRepo(db transaction.Conn) WebhookRepo {
+	return WebhookRepo{db: db}
+}
+
+func (wR WebhookRepo) Create(ctx context.Context, w webhook.Webhook) (err error) {
+	insertRow := `INSERT INTO webhooks (id, user_id, url, events, secret, created_at) VALUES ($1, $2, $3, $4, $5, $6)`
+	ctx, span := startSpan(ctx, "webhookdb.Create", insertRow)
+	defer tracing.End(span, &err)
+
+	_, err = transaction.ConnFrom(ctx, wR.db).ExecContext(ctx, insertRow, w.ID, w.UserID, w.URL, strings.Join(w.Events, ","), w.Secret, w.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("create: [%s]: %w", w.ID, err)
+	}
+	return nil
+}
+
+func (wR WebhookRepo) QueryByID(ctx context.Context, id uuid.UUID) (_ webhook.Webhook, err error) {
+	queryByID := `SELECT ` + webhookColumns + ` FROM webhooks w WHERE w.id=$1`
+	ctx, span := startSpan(ctx, "webhookdb.QueryByID", queryByID)
+	defer tracing.End(span, &err)
+
+	w, err := scanWebhook(transaction.ConnFrom(ctx, wR.db).QueryRowContext(ctx, queryByID, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return webhook.Webhook{}, webhook.ErrNotFound
+	}
+	if err != nil {
+		return webhook.Webhook{}, fmt.Errorf("queryByID: [%s]: %w", id, err)
+	}
+	return w, nil
+}
+
+func (wR WebhookRepo) QueryByUserID(ctx context.Context, userID uuid.UUID) (_ []webhook.Webhook, err error) {
+	queryByUserID := `SELECT ` + webhookColumns + ` FROM webhooks w WHERE w.user_id=$1 ORDER BY w.created_at, w.id`
+	ctx, span := startSpan(ctx, "webhookdb.QueryByUserID", queryByUserID)
+	defer tracing.End(span, &err)
+
+	rows, err := transaction.ConnFrom(ctx, wR.db).QueryContext(ctx, queryByUserID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("queryByUserID: [%s]: %w", userID, err)
+	}
+	defer rows.Close()
+
+	var webhooks []webhook.Webhook
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("queryByUserID: [%s]: scan rows: %w", userID, err)
+		}
+		webhooks = append(webhooks, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("queryByUserID: [%s]: %w", userID, err)
+	}
+	return webhooks, nil
+}
+
+func (wR WebhookRepo) Delete(ctx context.Context, id uuid.UUID) (err error) {
+	deleteRow := `DELETE FROM webhooks WHERE id=$1`
+	ctx, span := startSpan(ctx, "webhookdb.Delete", deleteRow)
+	defer tracing.End(span, &err)
+
+	res, err := transaction.ConnFrom(ctx, wR.db).ExecContext(ctx, deleteRow, id)
+	if err != nil {
+		return fmt.Errorf("delete: [%s]: %w", id, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return webhook.ErrNotFound
+	}
+	return nil
+}
+
+// CreateDeliveries inserts the deliveries in one statement.
+func (wR WebhookRepo) CreateDeliveries(ctx context.Context, ds []webhook.Delivery) (err error) {
+	var (
+		b    strings.Builder
+		args []any
+	)
+	b.WriteString(`INSERT INTO webhook_deliveries (id, webhook_id, event, payload, status, attempts, next_attempt_at, response_status, last_error, created_at, updated_at) VALUES `)
+	for i, d := range ds {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		n := len(args)
+		fmt.Fprintf(&b, "($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9, n+10, n+11)
+		args = append(args, d.ID, d.WebhookID, d.Event, d.Payload, string(d.Status), d.Attempts, d.NextAttemptAt, d.ResponseStatus, d.LastError, d.CreatedAt, d.UpdatedAt)
+	}
+	b.WriteString(` ON CONFLICT (id) DO NOTHING`)
+	insertRows := b.String()
+	ctx, span := startSpan(ctx, "webhookdb.CreateDeliveries", insertRows)
+	defer tracing.End(span, &err)
+
+	if _, err := transaction.ConnFrom(ctx, wR.db).ExecContext(ctx, insertRows, args...); err != nil {
+		return fmt.Errorf("createDeliveries: %w", err)
+	}
+	return nil
+}
+
+func (wR WebhookRepo) QueryDeliveryByID(ctx context.Context, id uuid.UUID) (_ webhook.Delivery, err error) {
+	queryByID := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries d WHERE d.id=$1`
+	ctx, span := startSpan(ctx, "webhookdb.QueryDeliveryByID", queryByID)
+	defer tracing.End(span, &err)
+
+	d, err := scanDelivery(transaction.ConnFrom(ctx, wR.db).QueryRowContext(ctx, queryByID, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return webhook.Delivery{}, webhook.ErrDeliveryNotFound
+	}
+	if err != nil {
+		return webhook.Delivery{}, fmt.Errorf("queryDeliveryByID: [%s]: %w", id, err)
+	}
+	return d, nil
+}
+
+func (wR WebhookRepo) QueryDeliveries(ctx context.Context, webhookID uuid.UUID, status webhook.Status, limit int) (_ []webhook.Delivery, err error) {
+	queryDeliveries := `
+	SELECT ` + deliveryColumns + ` FROM webhook_deliveries d
+	WHERE d.webhook_id=$1 AND ($2 = '' OR d.status = $2)
+	ORDER BY d.created_at DESC, d.id DESC LIMIT $3`
+	ctx, span := startSpan(ctx, "webhookdb.QueryDeliveries", queryDeliveries)
+	defer tracing.End(span, &err)
+
+	rows, err := transaction.ConnFrom(ctx, wR.db).QueryContext(ctx, queryDeliveries, webhookID, string(status), limit)
+	if err != nil {
+		return nil, fmt.Errorf("queryDeliveries: [%s]: %w", webhookID, err)
+	}
+	defer rows.Close()
+
+	var ds []webhook.Delivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("queryDeliveries: [%s]: scan rows: %w", webhookID, err)
+		}
+		ds = append(ds, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("queryDeliveries: [%s]: %w", webhookID, err)
+	}
+	return ds, nil
+}
+
+func (wR WebhookRepo) UpdateDelivery(ctx context.Context, d webhook.Delivery) (err error) {
+	updateRow := `
+	UPDATE webhook_deliveries
+	SET status=$2, attempts=$3, next_attempt_at=$4, response_status=$5, last_error=$6, updated_at=$7
+	WHERE id=$1`
+	ctx, span := startSpan(ctx, "webhookdb.UpdateDelivery", updateRow)
+	defer tracing.End(span, &err)
+
+	res, err := transaction.ConnFrom(ctx, wR.db).ExecContext(ctx, updateRow, d.ID, string(d.Status), d.Attempts, d.NextAttemptAt, d.ResponseStatus, d.LastError, d.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("updateDelivery: [%s]: %w", d.ID, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return webhook.ErrDeliveryNotFound
+	}
+	return nil
+}
+
+// Claim locks the due rows with SKIP LOCKED, so that concurrent claims
+// don't wait for each other and never return the same delivery.
+func (wR WebhookRepo) Claim(ctx context.Context, now, until time.Time, limit int) (_ []webhook.Job, err error) {
+	claim := `
+	UPDATE webhook_deliveries d SET next_attempt_at = $2
+	FROM webhooks w
+	WHERE w.id = d.webhook_id AND d.id IN (
+		SELECT id FROM webhook_deliveries
+		WHERE status = 'pending' AND next_attempt_at <= $1
+		ORDER BY next_attempt_at LIMIT $3
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING ` + webhookColumns + `, ` + deliveryColumns
+	ctx, span := startSpan(ctx, "webhookdb.Claim", claim)
+	defer tracing.End(span, &err)
+
+	rows, err := transaction.ConnFrom(ctx, wR.db).QueryContext(ctx, claim, now, until, limit)
+	if err != nil {
+		return nil, fmt.Errorf("claim: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []webhook.Job
+	for rows.Next() {
+		var (
+			j      webhook.Job
+			events string
+			status string
+		)
+		err := rows.Scan(
+			&j.Webhook.ID, &j.Webhook.UserID, &j.Webhook.URL, &events, &j.Webhook.Secret, &j.Webhook.CreatedAt,
+			&j.Delivery.ID, &j.Delivery.WebhookID, &j.Delivery.Event, &j.Delivery.Payload, &status, &j.Delivery.Attempts,
+			&j.Delivery.NextAttemptAt, &j.Delivery.ResponseStatus, &j.Delivery.LastError, &j.Delivery.CreatedAt, &j.Delivery.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("claim: scan rows: %w", err)
+		}
+		j.Webhook.Events, j.Delivery.Status = splitEvents(events), webhook.Status(status)
+		jobs = append(jobs, j)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("claim: %w", err)
+	}
+	return jobs, nil
+}
+
+func (wR WebhookRepo) DeleteDeliveriesBefore(ctx context.Context, t time.Time) (err error) {
+	deleteRows := `DELETE FROM webhook_deliveries WHERE status <> 'pending' AND updated_at < $1`
+	ctx, span := startSpan(ctx, "webhookdb.DeleteDeliveriesBefore", deleteRows)
+	defer tracing.End(span, &err)
+
+	if _, err := transaction.ConnFrom(ctx, wR.db).ExecContext(ctx, deleteRows, t); err != nil {
+		return fmt.Errorf("deleteDeliveriesBefore: %w", err)
+	}
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanWebhook(s scanner) (webhook.Webhook, error) {
+	var (
+		w      webhook.Webhook
+		events string
+	)
+	if err := s.Scan(&w.ID, &w.UserID, &w.URL, &events, &w.Secret, &w.CreatedAt); err != nil {
+		return webhook.Webhook{}, err
+	}
+	w.Events = splitEvents(events)
+	return w, nil
+}
+
+func scanDelivery(s scanner) (webhook.Delivery, error) {
+	var (
+		d      webhook.Delivery
+		status string
+	)
+	err := s.Scan(&d.ID, &d.WebhookID, &d.Event, &d.Payload, &status, &d.Attempts, &d.NextAttemptAt, &d.ResponseStatus, &d.LastError, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		return webhook.Delivery{}, err
+	}
+	d.Status = webhook.Status(status)
+	return d, nil
+}
+
+func splitEvents(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func startSpan(ctx context.Context, name, query string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.statement", query),
+		),
+	)
+}
@@ -0,0 +1,116 @@
+package webhookdb_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/domain/core/webhook"
+	"github.com/Keisn1/note-taking-app/domain/core/webhook/repositories/webhookdb"
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testDBName   = "test_note_taking_app_webhooks"
+	testUser     = "postgres"
+	testPassword = "password"
+)
+
+func TestMain(m *testing.M) {
+	exitCode := run(m)
+	os.Exit(exitCode)
+}
+
+func TestWebhookRepo(t *testing.T) {
+	testDB, deleteTables := SetupWebhooksTables(t)
+	defer testDB.Close()
+	defer deleteTables()
+	wR := webhookdb.NewWebhookRepo(testDB)
+	ctx := context.Background()
+
+	created := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	all := webhook.Webhook{ID: uuid.UUID{1}, UserID: uuid.UUID{1}, URL: "https://example.com/all", Secret: "whsec_1", CreatedAt: created}
+	some := webhook.Webhook{ID: uuid.UUID{2}, UserID: uuid.UUID{1}, URL: "https://example.com/some", Events: []string{"note.created", "note.deleted"}, Secret: "whsec_2", CreatedAt: created.Add(time.Hour)}
+	require.NoError(t, wR.Create(ctx, some))
+	require.NoError(t, wR.Create(ctx, all))
+
+	t.Run("Webhooks are returned with their events, oldest first", func(t *testing.T) {
+		got, err := wR.QueryByUserID(ctx, uuid.UUID{1})
+		assert.NoError(t, err)
+		assert.Equal(t, []webhook.Webhook{all, some}, got)
+
+		_, err = wR.QueryByID(ctx, uuid.New())
+		assert.ErrorIs(t, err, webhook.ErrNotFound)
+	})
+
+	delivery := func(id byte, webhookID uuid.UUID, status webhook.Status, at time.Time) webhook.Delivery {
+		return webhook.Delivery{
+			ID: uuid.UUID{id}, WebhookID: webhookID, Event: "note.created", Payload: []byte(`{"id": 1}`),
+			Status: status, NextAttemptAt: at, CreatedAt: at, UpdatedAt: at,
+		}
+	}
+	require.NoError(t, wR.CreateDeliveries(ctx, []webhook.Delivery{
+		delivery(1, all.ID, webhook.StatusPending, created),
+		delivery(2, all.ID, webhook.StatusPending, created.Add(time.Minute)),
+		delivery(3, all.ID, webhook.StatusSucceeded, created.Add(2*time.Minute)),
+		delivery(4, some.ID, webhook.StatusPending, created.Add(time.Hour)),
+	}))
+
+	t.Run("Deliveries are listed newest first", func(t *testing.T) {
+		got, err := wR.QueryDeliveries(ctx, all.ID, "", 2)
+		assert.NoError(t, err)
+		require.Len(t, got, 2)
+		assert.Equal(t, delivery(3, all.ID, webhook.StatusSucceeded, created.Add(2*time.Minute)), got[0])
+
+		got, err = wR.QueryDeliveries(ctx, all.ID, webhook.StatusPending, 10)
+		assert.NoError(t, err)
+		assert.Len(t, got, 2)
+	})
+
+	t.Run("Due deliveries are claimed once", func(t *testing.T) {
+		until := created.Add(24 * time.Hour)
+		jobs, err := wR.Claim(ctx, created.Add(time.Minute), until, 10)
+		assert.NoError(t, err)
+		require.Len(t, jobs, 2)
+		assert.Equal(t, all, jobs[0].Webhook)
+		assert.Equal(t, until, jobs[0].Delivery.NextAttemptAt.UTC())
+
+		jobs, err = wR.Claim(ctx, created.Add(time.Minute), until, 10)
+		assert.NoError(t, err)
+		assert.Empty(t, jobs)
+	})
+
+	t.Run("Deliveries are updated and pruned", func(t *testing.T) {
+		d := delivery(1, all.ID, webhook.StatusDead, created)
+		d.Attempts, d.ResponseStatus, d.LastError, d.UpdatedAt = 8, 500, "unexpected response status", created.Add(time.Hour)
+		require.NoError(t, wR.UpdateDelivery(ctx, d))
+		got, err := wR.QueryDeliveryByID(ctx, d.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, d, got)
+
+		require.NoError(t, wR.DeleteDeliveriesBefore(ctx, created.Add(30*time.Minute)))
+		_, err = wR.QueryDeliveryByID(ctx, uuid.UUID{3})
+		assert.ErrorIs(t, err, webhook.ErrDeliveryNotFound)
+		_, err = wR.QueryDeliveryByID(ctx, uuid.UUID{1})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Deleting a webhook deletes its deliveries", func(t *testing.T) {
+		require.NoError(t, wR.Delete(ctx, all.ID))
+		assert.ErrorIs(t, wR.Delete(ctx, all.ID), webhook.ErrNotFound)
+		_, err := wR.QueryDeliveryByID(ctx, uuid.UUID{1})
+		assert.ErrorIs(t, err, webhook.ErrDeliveryNotFound)
+	})
+
+	t.Run("Forwards error on database error", func(t *testing.T) {
+		wR := webhookdb.NewWebhookRepo(&stubSQLDB{})
+		_, err := wR.QueryByUserID(ctx, uuid.UUID{1})
+		assert.EqualError(t, err, fmt.Errorf("queryByUserID: [%s]: %w", uuid.UUID{1}, errors.New("DBError")).Error())
+	})
+}
@@ -0,0 +1,32 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Repo interface {
+	Create(ctx context.Context, w Webhook) error
+	QueryByID(ctx context.Context, id uuid.UUID) (Webhook, error)
+	// QueryByUserID returns the webhooks of the user, oldest first.
+	QueryByUserID(ctx context.Context, userID uuid.UUID) ([]Webhook, error)
+	// Delete deletes the webhook and its deliveries.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// CreateDeliveries skips the deliveries whose ID exists already.
+	CreateDeliveries(ctx context.Context, ds []Delivery) error
+	QueryDeliveryByID(ctx context.Context, id uuid.UUID) (Delivery, error)
+	// QueryDeliveries returns up to limit deliveries of the webhook, newest
+	// first. An empty status selects all of them.
+	QueryDeliveries(ctx context.Context, webhookID uuid.UUID, status Status, limit int) ([]Delivery, error)
+	UpdateDelivery(ctx context.Context, d Delivery) error
+	// Claim returns up to limit pending deliveries that are due at now,
+	// with their webhooks, and postpones them to until, so that other
+	// dispatchers skip them while they are sent.
+	Claim(ctx context.Context, now, until time.Time, limit int) ([]Job, error)
+	// DeleteDeliveriesBefore deletes the deliveries that are not pending
+	// and were last updated before t.
+	DeleteDeliveriesBefore(ctx context.Context, t time.Time) error
+}
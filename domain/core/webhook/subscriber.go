@@ -0,0 +1,28 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/Keisn1/note-taking-app/domain/core/outbox"
+)
+
+// Subscriber returns the outbox subscriber that enqueues the deliveries of
+// the note events. Events relayed again are enqueued once, see Enqueue.
+func Subscriber(svc Service) outbox.Subscriber {
+	return outbox.Subscriber{
+		Name: "webhooks",
+		Handle: func(ctx context.Context, e outbox.Event) error {
+			ne, err := note.ParseEvent(e.Type, e.Payload)
+			if errors.Is(err, note.ErrUnknownEvent) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			ne.ID = e.ID
+			return svc.Enqueue(ctx, ne)
+		},
+	}
+}
@@ -0,0 +1,188 @@
+// Package webhook delivers the events of the notes of a user to the URLs the
+// user registered. The outbox Relay hands the saved events to the Subscriber,
+// which enqueues a delivery in the Repo for every subscribed webhook, and a
+// Dispatcher sends them, so that events aren't lost when an endpoint or the
+// process is down.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/google/uuid"
+)
+
+const (
+	MaxWebhooks  = 10   // per user
+	MaxURLLength = 2048 // bytes
+
+	DefaultMaxAttempts = 8
+)
+
+// Headers of the deliveries.
+const (
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+// Events are the events that can be subscribed to.
+var Events = []string{note.EventCreated, note.EventUpdated, note.EventDeleted}
+
+var (
+	ErrNotFound          = errors.New("the webhook was not found")
+	ErrDeliveryNotFound  = errors.New("the delivery was not found")
+	ErrLimitReached      = errors.New("the maximum number of webhooks is reached")
+	ErrNotDead           = errors.New("only dead deliveries can be retried")
+	ErrInvalidSignature  = errors.New("invalid signature")
+	ErrSignatureTooOld   = errors.New("the signature is too old")
+	ErrUnexpectedStatus  = errors.New("unexpected response status")
+	ErrForbiddenEndpoint = errors.New("the endpoint resolves to a private address")
+)
+
+// Webhook is an endpoint of a user. Secret is the key the deliveries are
+// signed with.
+type Webhook struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	URL       string
+	Events    []string
+	Secret    string
+	CreatedAt time.Time
+}
+
+// Subscribed reports whether the webhook receives the event. A webhook
+// without events receives all.
+func (w Webhook) Subscribed(event string) bool {
+	return len(w.Events) == 0 || slices.Contains(w.Events, event)
+}
+
+type NewWebhook struct {
+	UserID uuid.UUID
+	URL    string
+	Events []string
+}
+
+// Status is the state of a delivery. Pending deliveries are sent when they
+// are due, dead ones failed too often and are only sent again when retried.
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusSucceeded Status = "succeeded"
+	StatusDead      Status = "dead"
+)
+
+// ParseStatus returns the status named s.
+func ParseStatus(s string) (Status, error) {
+	switch st := Status(s); st {
+	case StatusPending, StatusSucceeded, StatusDead:
+		return st, nil
+	}
+	return "", fmt.Errorf("parseStatus: invalid status %q", s)
+}
+
+// Delivery is an event for a webhook. ResponseStatus and LastError are the
+// outcome of the last attempt, ResponseStatus is 0 if there was no response.
+type Delivery struct {
+	ID             uuid.UUID
+	WebhookID      uuid.UUID
+	Event          string
+	Payload        []byte
+	Status         Status
+	Attempts       int
+	NextAttemptAt  time.Time
+	ResponseStatus int
+	LastError      string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// Job is a delivery with its webhook, ready to be sent.
+type Job struct {
+	Webhook  Webhook
+	Delivery Delivery
+}
+
+// Payload is the JSON body of a delivery. ID is the ID of the delivery,
+// receivers use it to drop deliveries they got before. Note is null when
+// the note was deleted.
+type Payload struct {
+	ID        uuid.UUID    `json:"id"`
+	Event     string       `json:"event"`
+	CreatedAt time.Time    `json:"created_at"`
+	NoteID    uuid.UUID    `json:"note_id"`
+	Note      *PayloadNote `json:"note"`
+}
+
+type PayloadNote struct {
+	ID      uuid.UUID `json:"id"`
+	Title   string    `json:"title"`
+	Content string    `json:"content"`
+	Format  string    `json:"format"`
+	UserID  uuid.UUID `json:"user_id"`
+}
+
+// Sign returns the signature header of the body sent at t:
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<unix seconds>.<body>">". The
+// timestamp is signed too, so that receivers can reject replayed
+// deliveries.
+func Sign(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac(secret, ts, body))
+}
+
+// Verify checks the signature header of the body as receivers do. It fails
+// if the signature is older than tolerance at now.
+func Verify(secret, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	var ts, sig string
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(part, "=")
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			sig = v
+		}
+	}
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, mac(secret, ts, body)) {
+		return ErrInvalidSignature
+	}
+	if now.Sub(time.Unix(unix, 0)) > tolerance {
+		return ErrSignatureTooOld
+	}
+	return nil
+}
+
+func mac(secret, ts string, body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(ts))
+	h.Write([]byte("."))
+	h.Write(body)
+	return h.Sum(nil)
+}
+
+// backoff returns how long to wait after the attempts failed: 30s, doubled
+// with every attempt up to 6h.
+func backoff(attempts int) time.Duration {
+	const first, last = 30 * time.Second, 6 * time.Hour
+	d := first
+	for i := 1; i < attempts && d < last; i++ {
+		d *= 2
+	}
+	return min(d, last)
+}
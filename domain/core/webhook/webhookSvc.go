@@ -0,0 +1,201 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/Keisn1/note-taking-app/foundation/tracing"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/Keisn1/note-taking-app/domain/core/webhook")
+
+type Service interface {
+	Create(ctx context.Context, nw NewWebhook) (Webhook, error)
+	QueryByID(ctx context.Context, id uuid.UUID) (Webhook, error)
+	QueryByUserID(ctx context.Context, userID uuid.UUID) ([]Webhook, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	Deliveries(ctx context.Context, webhookID uuid.UUID, status Status, limit int) ([]Delivery, error)
+	QueryDeliveryByID(ctx context.Context, id uuid.UUID) (Delivery, error)
+	Retry(ctx context.Context, d Delivery) (Delivery, error)
+	Enqueue(ctx context.Context, e note.Event) error
+}
+
+type WebhookService struct {
+	repo Repo
+}
+
+func NewSvc(repo Repo) WebhookService {
+	return WebhookService{repo: repo}
+}
+
+// Create registers the webhook with a new secret. Only the returned webhook
+// shows the secret to the user.
+func (ws WebhookService) Create(ctx context.Context, nw NewWebhook) (_ Webhook, err error) {
+	ctx, span := tracer.Start(ctx, "webhook.Create", trace.WithAttributes(attribute.String("user.id", nw.UserID.String())))
+	defer tracing.End(span, &err)
+
+	webhooks, err := ws.repo.QueryByUserID(ctx, nw.UserID)
+	if err != nil {
+		return Webhook{}, fmt.Errorf("create: %w", err)
+	}
+	if len(webhooks) >= MaxWebhooks {
+		return Webhook{}, ErrLimitReached
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return Webhook{}, fmt.Errorf("create: %w", err)
+	}
+
+	w := Webhook{
+		ID:        uuid.New(),
+		UserID:    nw.UserID,
+		URL:       nw.URL,
+		Events:    nw.Events,
+		Secret:    "whsec_" + hex.EncodeToString(secret),
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if err := ws.repo.Create(ctx, w); err != nil {
+		return Webhook{}, fmt.Errorf("create: %w", err)
+	}
+	return w, nil
+}
+
+func (ws WebhookService) QueryByID(ctx context.Context, id uuid.UUID) (_ Webhook, err error) {
+	ctx, span := tracer.Start(ctx, "webhook.QueryByID", trace.WithAttributes(attribute.String("webhook.id", id.String())))
+	defer tracing.End(span, &err)
+
+	w, err := ws.repo.QueryByID(ctx, id)
+	if err != nil {
+		return Webhook{}, fmt.Errorf("queryByID: [%s]: %w", id, err)
+	}
+	return w, nil
+}
+
+func (ws WebhookService) QueryByUserID(ctx context.Context, userID uuid.UUID) (_ []Webhook, err error) {
+	ctx, span := tracer.Start(ctx, "webhook.QueryByUserID", trace.WithAttributes(attribute.String("user.id", userID.String())))
+	defer tracing.End(span, &err)
+
+	webhooks, err := ws.repo.QueryByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("queryByUserID: [%s]: %w", userID, err)
+	}
+	return webhooks, nil
+}
+
+// Delete deletes the webhook, its pending deliveries are dropped.
+func (ws WebhookService) Delete(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := tracer.Start(ctx, "webhook.Delete", trace.WithAttributes(attribute.String("webhook.id", id.String())))
+	defer tracing.End(span, &err)
+
+	if err := ws.repo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("delete: [%s]: %w", id, err)
+	}
+	return nil
+}
+
+// Deliveries returns up to limit deliveries of the webhook, newest first,
+// only those with the status unless it is empty.
+func (ws WebhookService) Deliveries(ctx context.Context, webhookID uuid.UUID, status Status, limit int) (_ []Delivery, err error) {
+	ctx, span := tracer.Start(ctx, "webhook.Deliveries", trace.WithAttributes(attribute.String("webhook.id", webhookID.String())))
+	defer tracing.End(span, &err)
+
+	ds, err := ws.repo.QueryDeliveries(ctx, webhookID, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("deliveries: [%s]: %w", webhookID, err)
+	}
+	return ds, nil
+}
+
+func (ws WebhookService) QueryDeliveryByID(ctx context.Context, id uuid.UUID) (_ Delivery, err error) {
+	ctx, span := tracer.Start(ctx, "webhook.QueryDeliveryByID", trace.WithAttributes(attribute.String("delivery.id", id.String())))
+	defer tracing.End(span, &err)
+
+	d, err := ws.repo.QueryDeliveryByID(ctx, id)
+	if err != nil {
+		return Delivery{}, fmt.Errorf("queryDeliveryByID: [%s]: %w", id, err)
+	}
+	return d, nil
+}
+
+// Retry makes a dead delivery pending again, it gets all attempts anew and
+// is due right away.
+func (ws WebhookService) Retry(ctx context.Context, d Delivery) (_ Delivery, err error) {
+	ctx, span := tracer.Start(ctx, "webhook.Retry", trace.WithAttributes(attribute.String("delivery.id", d.ID.String())))
+	defer tracing.End(span, &err)
+
+	if d.Status != StatusDead {
+		return Delivery{}, ErrNotDead
+	}
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	d.Status, d.Attempts, d.NextAttemptAt, d.UpdatedAt = StatusPending, 0, now, now
+	if err := ws.repo.UpdateDelivery(ctx, d); err != nil {
+		return Delivery{}, fmt.Errorf("retry: [%s]: %w", d.ID, err)
+	}
+	return d, nil
+}
+
+// Enqueue writes a delivery of the event for each webhook of the owner of
+// the note that subscribed to it; the deliveries are sent by a Dispatcher.
+// If the event has an ID the IDs of the deliveries are derived from it, so
+// that enqueueing it again doesn't duplicate them.
+func (ws WebhookService) Enqueue(ctx context.Context, e note.Event) (err error) {
+	ctx, span := tracer.Start(ctx, "webhook.Enqueue", trace.WithAttributes(attribute.String("note.id", e.Note.ID.String())))
+	defer tracing.End(span, &err)
+
+	webhooks, err := ws.repo.QueryByUserID(ctx, e.Note.UserID)
+	if err != nil {
+		return fmt.Errorf("enqueue: [%s]: %w", e.Note.ID, err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	var ds []Delivery
+	for _, w := range webhooks {
+		if !w.Subscribed(e.Type) {
+			continue
+		}
+
+		id := uuid.New()
+		if e.ID != uuid.Nil {
+			id = uuid.NewSHA1(e.ID, w.ID[:])
+		}
+		d := Delivery{ID: id, WebhookID: w.ID, Event: e.Type, Status: StatusPending, NextAttemptAt: now, CreatedAt: now, UpdatedAt: now}
+		d.Payload, err = json.Marshal(newPayload(d, e))
+		if err != nil {
+			return fmt.Errorf("enqueue: [%s]: %w", e.Note.ID, err)
+		}
+		ds = append(ds, d)
+	}
+	if len(ds) == 0 {
+		return nil
+	}
+
+	if err := ws.repo.CreateDeliveries(ctx, ds); err != nil {
+		return fmt.Errorf("enqueue: [%s]: %w", e.Note.ID, err)
+	}
+	return nil
+}
+
+func newPayload(d Delivery, e note.Event) Payload {
+	p := Payload{ID: d.ID, Event: e.Type, CreatedAt: d.CreatedAt, NoteID: e.Note.ID}
+	if e.Type != note.EventDeleted {
+		p.Note = &PayloadNote{
+			ID:      e.Note.ID,
+			Title:   e.Note.Title.String(),
+			Content: e.Note.Content.String(),
+			Format:  e.Note.Format.String(),
+			UserID:  e.Note.UserID,
+		}
+	}
+	return p
+}
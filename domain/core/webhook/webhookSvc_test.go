@@ -0,0 +1,144 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/Keisn1/note-taking-app/domain/core/outbox"
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	"github.com/Keisn1/note-taking-app/domain/core/webhook"
+	"github.com/Keisn1/note-taking-app/domain/core/webhook/repositories/memory"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var rob, anna = uuid.UUID{1}, uuid.UUID{2}
+
+func TestWebhookService_Create(t *testing.T) {
+	ctx := context.Background()
+	ws := webhook.NewSvc(memory.NewRepo())
+
+	w, err := ws.Create(ctx, webhook.NewWebhook{UserID: rob, URL: "https://example.com/hook", Events: []string{note.EventCreated}})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(w.Secret, "whsec_"))
+	assert.Len(t, w.Secret, len("whsec_")+64)
+
+	got, err := ws.QueryByID(ctx, w.ID)
+	require.NoError(t, err)
+	assert.Equal(t, w, got)
+
+	t.Run("The number of webhooks per user is limited", func(t *testing.T) {
+		for range webhook.MaxWebhooks - 1 {
+			_, err := ws.Create(ctx, webhook.NewWebhook{UserID: rob, URL: "https://example.com/hook"})
+			require.NoError(t, err)
+		}
+		_, err := ws.Create(ctx, webhook.NewWebhook{UserID: rob, URL: "https://example.com/hook"})
+		assert.ErrorIs(t, err, webhook.ErrLimitReached)
+
+		_, err = ws.Create(ctx, webhook.NewWebhook{UserID: anna, URL: "https://example.com/hook"})
+		assert.NoError(t, err)
+	})
+}
+
+func TestWebhookService_Enqueue(t *testing.T) {
+	ctx := context.Background()
+	ws := webhook.NewSvc(memory.NewRepo())
+
+	all, err := ws.Create(ctx, webhook.NewWebhook{UserID: rob, URL: "https://example.com/all"})
+	require.NoError(t, err)
+	deletes, err := ws.Create(ctx, webhook.NewWebhook{UserID: rob, URL: "https://example.com/deletes", Events: []string{note.EventDeleted}})
+	require.NoError(t, err)
+	annas, err := ws.Create(ctx, webhook.NewWebhook{UserID: anna, URL: "https://example.com/anna"})
+	require.NoError(t, err)
+
+	n := note.Note{ID: uuid.New(), Title: note.NewTitle("Groceries"), Content: note.NewContent("milk"), Format: note.FormatPlain, UserID: rob}
+	require.NoError(t, ws.Enqueue(ctx, note.Event{Type: note.EventUpdated, Note: n}))
+	require.NoError(t, ws.Enqueue(ctx, note.Event{Type: note.EventDeleted, Note: n}))
+
+	t.Run("Deliveries are written for the subscribed webhooks of the owner", func(t *testing.T) {
+		ds, err := ws.Deliveries(ctx, all.ID, "", 10)
+		require.NoError(t, err)
+		require.Len(t, ds, 2)
+
+		ds, err = ws.Deliveries(ctx, deletes.ID, "", 10)
+		require.NoError(t, err)
+		require.Len(t, ds, 1)
+		assert.Equal(t, note.EventDeleted, ds[0].Event)
+		assert.Equal(t, webhook.StatusPending, ds[0].Status)
+
+		ds, err = ws.Deliveries(ctx, annas.ID, "", 10)
+		require.NoError(t, err)
+		assert.Empty(t, ds)
+	})
+
+	t.Run("The payload carries the note, except for deletions", func(t *testing.T) {
+		ds, err := ws.Deliveries(ctx, all.ID, webhook.StatusPending, 10)
+		require.NoError(t, err)
+		require.Len(t, ds, 2)
+
+		for _, d := range ds {
+			var p webhook.Payload
+			require.NoError(t, json.Unmarshal(d.Payload, &p))
+			assert.Equal(t, d.ID, p.ID)
+			assert.Equal(t, d.Event, p.Event)
+			assert.Equal(t, n.ID, p.NoteID)
+			if d.Event == note.EventDeleted {
+				assert.Nil(t, p.Note)
+				continue
+			}
+			assert.Equal(t, &webhook.PayloadNote{ID: n.ID, Title: "Groceries", Content: "milk", Format: "plain", UserID: rob}, p.Note)
+		}
+	})
+}
+
+func TestWebhookService_Retry(t *testing.T) {
+	ctx := context.Background()
+	ws := webhook.NewSvc(memory.NewRepo())
+
+	w, err := ws.Create(ctx, webhook.NewWebhook{UserID: rob, URL: "https://example.com/hook"})
+	require.NoError(t, err)
+	require.NoError(t, ws.Enqueue(ctx, note.Event{Type: note.EventCreated, Note: note.Note{ID: uuid.New(), UserID: rob}}))
+	ds, err := ws.Deliveries(ctx, w.ID, "", 10)
+	require.NoError(t, err)
+
+	_, err = ws.Retry(ctx, ds[0])
+	assert.ErrorIs(t, err, webhook.ErrNotDead)
+
+	dead := ds[0]
+	dead.Status, dead.Attempts = webhook.StatusDead, webhook.DefaultMaxAttempts
+	d, err := ws.Retry(ctx, dead)
+	require.NoError(t, err)
+	assert.Equal(t, webhook.StatusPending, d.Status)
+	assert.Zero(t, d.Attempts)
+
+	got, err := ws.QueryDeliveryByID(ctx, d.ID)
+	require.NoError(t, err)
+	assert.Equal(t, d, got)
+}
+
+func TestSubscriber(t *testing.T) {
+	ctx := context.Background()
+	ws := webhook.NewSvc(memory.NewRepo())
+	w, err := ws.Create(ctx, webhook.NewWebhook{UserID: rob, URL: "https://example.com/hook"})
+	require.NoError(t, err)
+
+	n := note.Note{ID: uuid.New(), Title: note.NewTitle("Groceries"), Format: note.FormatPlain, UserID: rob}
+	created, err := outbox.NewEvent(note.NewNoteCreated(n))
+	require.NoError(t, err)
+	other, err := outbox.NewEvent(user.UserDeleted{UserID: rob})
+	require.NoError(t, err)
+
+	sub := webhook.Subscriber(ws)
+	require.NoError(t, sub.Handle(ctx, created))
+	require.NoError(t, sub.Handle(ctx, created))
+	require.NoError(t, sub.Handle(ctx, other))
+
+	ds, err := ws.Deliveries(ctx, w.ID, "", 10)
+	require.NoError(t, err)
+	require.Len(t, ds, 1, "an event relayed again is enqueued once, those of users are ignored")
+	assert.Equal(t, note.EventCreated, ds[0].Event)
+}
@@ -0,0 +1,170 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+type migration struct {
+	version int
+	name    string
+	stmt    string
+}
+
+// Migrate applies all pending migrations, each in its own transaction. It
+// holds an advisory lock while doing so, so that instances started at the
+// same time don't apply the migrations twice.
+func Migrate(ctx context.Context, db *sql.DB) (err error) {
+	migrations, err := load()
+	if err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+
+	// The lock belongs to the session, so everything runs on one connection.
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock(hashtextextended('schema_migrations', 0))`); err != nil {
+		return fmt.Errorf("migrate: lock: %w", err)
+	}
+	defer func() {
+		unlock := `SELECT pg_advisory_unlock(hashtextextended('schema_migrations', 0))`
+		if _, uErr := conn.ExecContext(context.WithoutCancel(ctx), unlock); uErr != nil && err == nil {
+			err = fmt.Errorf("migrate: unlock: %w", uErr)
+		}
+	}()
+
+	createVersionTable := `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`
+	if _, err := conn.ExecContext(ctx, createVersionTable); err != nil {
+		return fmt.Errorf("migrate: create version table: %w", err)
+	}
+
+	current, err := current(ctx, conn)
+	if err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := apply(ctx, conn, m); err != nil {
+			return fmt.Errorf("migrate: %s: %w", m.name, err)
+		}
+	}
+
+	return nil
+}
+
+// Status returns the version of the last applied migration and the version
+// of the latest known migration.
+func Status(ctx context.Context, db *sql.DB) (applied int, latest int, err error) {
+	latest, err = Latest()
+	if err != nil {
+		return 0, 0, fmt.Errorf("status: %w", err)
+	}
+
+	var exists bool
+	row := db.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`)
+	if err := row.Scan(&exists); err != nil {
+		return 0, 0, fmt.Errorf("status: %w", err)
+	}
+	if !exists {
+		return 0, latest, nil
+	}
+
+	applied, err = current(ctx, db)
+	if err != nil {
+		return 0, 0, fmt.Errorf("status: %w", err)
+	}
+	return applied, latest, nil
+}
+
+// Latest returns the version of the latest known migration.
+func Latest() (int, error) {
+	migrations, err := load()
+	if err != nil {
+		return 0, err
+	}
+	if len(migrations) == 0 {
+		return 0, nil
+	}
+	return migrations[len(migrations)-1].version, nil
+}
+
+func apply(ctx context.Context, conn *sql.Conn, m migration) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, m.stmt); err != nil {
+		return err
+	}
+
+	insertVersion := `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`
+	if _, err := tx.ExecContext(ctx, insertVersion, m.version, m.name); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// queryer is a *sql.DB or a *sql.Conn.
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func current(ctx context.Context, db queryer) (int, error) {
+	var version int
+	row := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`)
+	if err := row.Scan(&version); err != nil {
+		return 0, fmt.Errorf("current version: %w", err)
+	}
+	return version, nil
+}
+
+// load reads the embedded migrations. File names start with their version,
+// e.g. 0002_create_notes.sql.
+func load() ([]migration, error) {
+	entries, err := fs.ReadDir(files, "sql")
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []migration
+	for _, e := range entries {
+		prefix, _, _ := strings.Cut(e.Name(), "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration name %q", e.Name())
+		}
+
+		stmt, err := fs.ReadFile(files, "sql/"+e.Name())
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, migration{version: version, name: e.Name(), stmt: string(stmt)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
@@ -0,0 +1,7 @@
+CREATE TABLE users (
+	id            UUID PRIMARY KEY,
+	name          TEXT NOT NULL,
+	email         TEXT NOT NULL UNIQUE,
+	password_hash BYTEA NOT NULL,
+	created_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
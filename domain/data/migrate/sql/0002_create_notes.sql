@@ -0,0 +1,8 @@
+CREATE TABLE notes (
+	id      UUID PRIMARY KEY,
+	title   TEXT,
+	content TEXT,
+	user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX notes_user_id_idx ON notes (user_id);
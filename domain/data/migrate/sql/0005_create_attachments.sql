@@ -0,0 +1,12 @@
+CREATE TABLE attachments (
+	id           UUID PRIMARY KEY,
+	note_id      UUID NOT NULL REFERENCES notes (id) ON DELETE CASCADE,
+	user_id      UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	filename     TEXT NOT NULL,
+	content_type TEXT NOT NULL,
+	size         BIGINT NOT NULL,
+	created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX attachments_note_id_idx ON attachments (note_id);
+CREATE INDEX attachments_user_id_idx ON attachments (user_id);
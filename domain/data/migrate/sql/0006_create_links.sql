@@ -0,0 +1,11 @@
+CREATE TABLE links (
+	source_id UUID NOT NULL REFERENCES notes (id) ON DELETE CASCADE,
+	position  INT NOT NULL,
+	target    TEXT NOT NULL,
+	target_id UUID REFERENCES notes (id) ON DELETE SET NULL,
+	user_id   UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	PRIMARY KEY (source_id, position)
+);
+
+CREATE INDEX links_target_id_idx ON links (target_id);
+CREATE INDEX links_broken_idx ON links (user_id, lower(target)) WHERE target_id IS NULL;
@@ -0,0 +1,22 @@
+-- change_seqs holds the last number of the change sequence of each user.
+CREATE TABLE change_seqs (
+	user_id UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+	seq     BIGINT NOT NULL
+);
+
+-- changes holds the latest change of each note. The rows of deleted notes
+-- stay as tombstones, so note_id doesn't reference notes.
+CREATE TABLE changes (
+	note_id UUID PRIMARY KEY,
+	user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	seq     BIGINT NOT NULL,
+	deleted BOOLEAN NOT NULL DEFAULT false
+);
+
+CREATE UNIQUE INDEX changes_user_id_seq_idx ON changes (user_id, seq);
+
+INSERT INTO changes (note_id, user_id, seq)
+SELECT id, user_id, row_number() OVER (PARTITION BY user_id ORDER BY id) FROM notes;
+
+INSERT INTO change_seqs (user_id, seq)
+SELECT user_id, max(seq) FROM changes GROUP BY user_id;
@@ -0,0 +1,32 @@
+-- events is the comma separated list of the subscribed events, empty for
+-- all of them.
+CREATE TABLE webhooks (
+	id         UUID PRIMARY KEY,
+	user_id    UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	url        TEXT NOT NULL,
+	events     TEXT NOT NULL,
+	secret     TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX webhooks_user_id_idx ON webhooks (user_id);
+
+-- webhook_deliveries is the outbox of the webhooks and their delivery log.
+-- The payload is kept as sent, it is signed byte for byte.
+CREATE TABLE webhook_deliveries (
+	id              UUID PRIMARY KEY,
+	webhook_id      UUID NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+	event           TEXT NOT NULL,
+	payload         BYTEA NOT NULL,
+	status          TEXT NOT NULL,
+	attempts        INT NOT NULL DEFAULT 0,
+	next_attempt_at TIMESTAMPTZ NOT NULL,
+	response_status INT NOT NULL DEFAULT 0,
+	last_error      TEXT NOT NULL DEFAULT '',
+	created_at      TIMESTAMPTZ NOT NULL,
+	updated_at      TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX webhook_deliveries_webhook_id_idx ON webhook_deliveries (webhook_id, created_at);
+CREATE INDEX webhook_deliveries_updated_at_idx ON webhook_deliveries (updated_at) WHERE status <> 'pending';
@@ -0,0 +1,20 @@
+-- outbox holds the domain events, written in the transaction of the change
+-- and handed to the subscribers by the relay. handled_by is the comma
+-- separated list of the subscribers that handled the event, published_at
+-- is set once all of them did.
+CREATE TABLE outbox (
+	seq             BIGSERIAL PRIMARY KEY,
+	id              UUID NOT NULL UNIQUE,
+	type            TEXT NOT NULL,
+	aggregate_id    UUID NOT NULL,
+	payload         JSONB NOT NULL,
+	created_at      TIMESTAMPTZ NOT NULL,
+	attempts        INT NOT NULL DEFAULT 0,
+	next_attempt_at TIMESTAMPTZ NOT NULL,
+	handled_by      TEXT NOT NULL DEFAULT '',
+	last_error      TEXT NOT NULL DEFAULT '',
+	published_at    TIMESTAMPTZ
+);
+
+CREATE INDEX outbox_due_idx ON outbox (seq) WHERE published_at IS NULL;
+CREATE INDEX outbox_published_at_idx ON outbox (published_at) WHERE published_at IS NOT NULL;
@@ -0,0 +1,32 @@
+-- audit_log is the append-only audit trail. Every entry contains the hash of
+-- the previous entry, hash covers all other columns. actor is NULL for
+-- anonymous actions. The trigger rejects changes and deletions, the chain
+-- shows those made with the trigger disabled.
+CREATE TABLE audit_log (
+	seq        BIGINT PRIMARY KEY,
+	id         UUID NOT NULL UNIQUE,
+	actor      UUID,
+	action     TEXT NOT NULL,
+	target     TEXT NOT NULL,
+	ip         TEXT NOT NULL,
+	user_agent TEXT NOT NULL,
+	request_id TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL,
+	prev_hash  TEXT NOT NULL,
+	hash       TEXT NOT NULL
+);
+
+CREATE INDEX audit_log_actor_idx ON audit_log (actor, seq);
+CREATE INDEX audit_log_target_idx ON audit_log (target, seq);
+CREATE INDEX audit_log_created_at_idx ON audit_log (created_at);
+
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_no_change BEFORE UPDATE OR DELETE ON audit_log
+	FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+CREATE TRIGGER audit_log_no_truncate BEFORE TRUNCATE ON audit_log
+	FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
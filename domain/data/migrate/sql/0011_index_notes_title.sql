@@ -0,0 +1,2 @@
+-- Lists of notes are ordered by the lower-cased title.
+CREATE INDEX notes_user_id_lower_title_idx ON notes (user_id, lower(title), id);
@@ -0,0 +1,27 @@
+-- Entries are inserted without seq and hashes, so that recording takes no
+-- lock. The chainer fills them in afterwards, the trigger lets it do that
+-- once and rejects all other changes.
+ALTER TABLE audit_log DROP CONSTRAINT audit_log_pkey;
+ALTER TABLE audit_log DROP CONSTRAINT audit_log_id_key;
+ALTER TABLE audit_log ADD PRIMARY KEY (id);
+ALTER TABLE audit_log
+	ALTER COLUMN seq DROP NOT NULL,
+	ALTER COLUMN prev_hash DROP NOT NULL,
+	ALTER COLUMN hash DROP NOT NULL,
+	ADD CONSTRAINT audit_log_seq_key UNIQUE (seq);
+
+CREATE INDEX audit_log_pending_idx ON audit_log (created_at, id) WHERE seq IS NULL;
+
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP = 'UPDATE' THEN
+		IF OLD.seq IS NULL AND NEW.seq IS NOT NULL
+			AND (NEW.id, NEW.actor, NEW.action, NEW.target, NEW.ip, NEW.user_agent, NEW.request_id, NEW.created_at)
+			IS NOT DISTINCT FROM
+			(OLD.id, OLD.actor, OLD.action, OLD.target, OLD.ip, OLD.user_agent, OLD.request_id, OLD.created_at) THEN
+			RETURN NEW;
+		END IF;
+	END IF;
+	RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
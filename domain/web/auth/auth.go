@@ -4,6 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type AuthInterface interface {
@@ -32,6 +35,15 @@ func (a Auth) Authenticate(bearerToken string) (Claims, error) {
 	return claims, nil
 }
 
+// CreateToken issues a token for the user that is valid for d.
+func (a Auth) CreateToken(userID uuid.UUID, d time.Duration) (string, error) {
+	tokenS, err := a.jwtSvc.CreateToken(userID, d)
+	if err != nil {
+		return "", fmt.Errorf("createToken: %w", err)
+	}
+	return tokenS, nil
+}
+
 func getTokenString(bearerToken string) (string, error) {
 	parts := strings.Split(bearerToken, " ")
 	if len(parts) != 2 || parts[0] != "Bearer" {
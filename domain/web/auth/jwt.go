@@ -19,18 +19,27 @@ type JWTService interface {
 }
 
 type jwtSvc struct {
-	key []byte
+	key      []byte
+	previous [][]byte
 }
 
-func NewJWTService(key []byte) (*jwtSvc, error) {
+// NewJWTService signs tokens with key. Tokens signed with one of the previous
+// keys are still accepted, so that the signing key can be rotated without
+// logging out all users.
+func NewJWTService(key []byte, previous ...[]byte) (*jwtSvc, error) {
 	if len(key) < 32 {
 		return nil, errors.New("key minLength 32")
 	}
-	return &jwtSvc{key: key}, nil
+	for _, k := range previous {
+		if len(k) < 32 {
+			return nil, errors.New("previous key minLength 32")
+		}
+	}
+	return &jwtSvc{key: key, previous: previous}, nil
 }
 
-func MustNewJWTService(key []byte) *jwtSvc {
-	jwtSvc, err := NewJWTService(key)
+func MustNewJWTService(key []byte, previous ...[]byte) *jwtSvc {
+	jwtSvc, err := NewJWTService(key, previous...)
 	if err != nil {
 		panic(err)
 	}
@@ -71,5 +80,13 @@ func (j *jwtSvc) keyFunc(token *jwt.Token) (interface{}, error) {
 		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 	}
 
-	return []byte(j.key), nil
+	if len(j.previous) == 0 {
+		return j.key, nil
+	}
+
+	keys := jwt.VerificationKeySet{Keys: []jwt.VerificationKey{j.key}}
+	for _, k := range j.previous {
+		keys.Keys = append(keys.Keys, k)
+	}
+	return keys, nil
 }
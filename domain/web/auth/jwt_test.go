@@ -44,3 +44,24 @@ func TestJWT(t *testing.T) {
 	_, err = jwtS.Verify(tokenS)
 	assert.Error(t, err, "Verify should return an error for expired token")
 }
+
+func TestJWT_KeyRotation(t *testing.T) {
+	oldKey := common.MustGenerateRandomKey(32)
+	newKey := common.MustGenerateRandomKey(32)
+
+	t.Run("Assert min length of previous keys", func(t *testing.T) {
+		_, err := auth.NewJWTService(newKey, common.MustGenerateRandomKey(16))
+		assert.EqualError(t, err, "previous key minLength 32")
+	})
+
+	oldTokenS, err := auth.MustNewJWTService(oldKey).CreateToken(uuid.New(), time.Minute)
+	assert.NoError(t, err)
+
+	// tokens of the previous key are accepted after the rotation
+	_, err = auth.MustNewJWTService(newKey, oldKey).Verify(oldTokenS)
+	assert.NoError(t, err)
+
+	// and rejected once the previous key is dropped
+	_, err = auth.MustNewJWTService(newKey).Verify(oldTokenS)
+	assert.ErrorContains(t, err, "verify: ")
+}
@@ -0,0 +1,102 @@
+package mid
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/domain/core/audit"
+	"github.com/Keisn1/note-taking-app/foundation"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+	"github.com/google/uuid"
+)
+
+// maxAuditFieldLength caps the user agents and targets, which are chosen by
+// the client, in the audit trail.
+const maxAuditFieldLength = 512
+
+// Failed authentications and logins are recorded up to failureLimit times
+// per client IP and failureWindow, so that clients can't flood the audit
+// trail with them. The others are only logged. At most failureClients IPs
+// are tracked in a window, the failures of further ones are not recorded.
+const (
+	failureLimit   = 10
+	failureWindow  = time.Minute
+	failureClients = 10000
+)
+
+type auditor struct {
+	svc      audit.Service
+	failures *failureLimiter
+}
+
+// failureLimiter counts the failures per IP in fixed windows.
+type failureLimiter struct {
+	mu     sync.Mutex
+	start  time.Time
+	counts map[string]int
+}
+
+func (l *failureLimiter) allow(ip string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.start) >= failureWindow {
+		l.start = now
+		clear(l.counts)
+	}
+	n, ok := l.counts[ip]
+	if n >= failureLimit || !ok && len(l.counts) >= failureClients {
+		return false
+	}
+	l.counts[ip] = n + 1
+	return true
+}
+
+// Audit attributes the entries recorded for the request to its client and
+// makes svc available to RecordAudit. Nothing is recorded if svc is nil.
+func Audit(svc audit.Service) web.MidHandler {
+	a := auditor{svc: svc, failures: &failureLimiter{counts: make(map[string]int)}}
+	m := func(next http.Handler) http.Handler {
+		h := func(w http.ResponseWriter, r *http.Request) {
+			ar := audit.Request{IP: r.RemoteAddr, UserAgent: auditField(r.UserAgent())}
+			if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+				ar.IP = host
+			}
+			if v := web.GetValues(r.Context()); v != nil {
+				ar.RequestID = v.RequestID
+			}
+
+			ctx := audit.WithRequest(r.Context(), ar)
+			if svc != nil {
+				ctx = context.WithValue(ctx, foundation.AuditKey, a)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+		return http.HandlerFunc(h)
+	}
+	return m
+}
+
+// RecordAudit records the action in the audit trail. The request goes on if
+// that fails, the error is logged.
+func RecordAudit(ctx context.Context, actor uuid.UUID, action, target string) {
+	a, ok := ctx.Value(foundation.AuditKey).(auditor)
+	if !ok {
+		return
+	}
+	failure := action == audit.ActionAuthFailed || action == audit.ActionLoginFailed
+	if failure && !a.failures.allow(audit.RequestFrom(ctx).IP, time.Now()) {
+		return
+	}
+	if _, err := a.svc.Record(ctx, audit.Entry{Actor: actor, Action: action, Target: auditField(target)}); err != nil {
+		web.Logger(ctx).Error("audit", "action", action, "error", err)
+	}
+}
+
+func auditField(s string) string {
+	return strings.ToValidUTF8(s[:min(len(s), maxAuditFieldLength)], "")
+}
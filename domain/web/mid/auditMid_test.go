@@ -0,0 +1,104 @@
+package mid_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/domain/core/audit"
+	auditmemory "github.com/Keisn1/note-taking-app/domain/core/audit/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	usermemory "github.com/Keisn1/note-taking-app/domain/core/user/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/web/auth"
+	"github.com/Keisn1/note-taking-app/domain/web/mid"
+	"github.com/Keisn1/note-taking-app/foundation/common"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Audit(t *testing.T) {
+	rob, anna := uuid.New(), uuid.New()
+	robsNote := note.Note{ID: uuid.New(), Title: note.NewTitle(""), Content: note.NewContent(""), UserID: rob}
+	jwtSvc := auth.MustNewJWTService(common.MustGenerateRandomKey(32))
+	annaToken, err := jwtSvc.CreateToken(anna, time.Minute)
+	require.NoError(t, err)
+
+	repo := auditmemory.NewRepo()
+	app := web.NewApp(mid.RequestID(), mid.Audit(audit.NewSvc(repo)))
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	authenticate := mid.Authenticate(auth.NewAuth(jwtSvc), user.NewSvc(usermemory.NewRepo([]user.User{{ID: rob}, {ID: anna}})))
+	app.HandleFunc("GET /notes/{note_id}", ok, authenticate, mid.AuthorizeNote(&StubNoteService{notes: map[uuid.UUID]note.Note{robsNote.ID: robsNote}}))
+	app.HandleFunc("GET /audit", ok, authenticate, mid.AuthorizeAdmin([]uuid.UUID{rob}))
+
+	call := func(target, token string) audit.Entry {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.RemoteAddr = "192.0.2.1:4242"
+		req.Header.Set("User-Agent", "curl/8.0")
+		req.Header.Set(mid.RequestIDHeader, "req-1")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusForbidden, rr.Code)
+
+		p, err := audit.NewSvc(repo).Query(context.Background(), audit.Filter{Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, p.Entries, 1)
+		return p.Entries[0]
+	}
+
+	t.Run("Failed authentications are recorded with the client", func(t *testing.T) {
+		e := call("/notes/"+robsNote.ID.String(), "")
+		assert.Equal(t, uuid.Nil, e.Actor)
+		assert.Equal(t, audit.ActionAuthFailed, e.Action)
+		assert.Equal(t, audit.PathTarget("/notes/"+robsNote.ID.String()), e.Target)
+		assert.Equal(t, "192.0.2.1", e.IP)
+		assert.Equal(t, "curl/8.0", e.UserAgent)
+		assert.Equal(t, "req-1", e.RequestID)
+	})
+
+	t.Run("Denied access to notes of other users is recorded", func(t *testing.T) {
+		e := call("/notes/"+robsNote.ID.String(), annaToken)
+		assert.Equal(t, anna, e.Actor)
+		assert.Equal(t, audit.ActionAccessDenied, e.Action)
+		assert.Equal(t, audit.NoteTarget(robsNote.ID), e.Target)
+
+		e = call("/notes/invalid", annaToken)
+		assert.Equal(t, "note:invalid", e.Target)
+	})
+
+	t.Run("Denied admin access is recorded", func(t *testing.T) {
+		e := call("/audit", annaToken)
+		assert.Equal(t, anna, e.Actor)
+		assert.Equal(t, audit.ActionAccessDenied, e.Action)
+		assert.Equal(t, audit.PathTarget("/audit"), e.Target)
+	})
+	t.Run("Failed authentications are recorded up to a limit per client", func(t *testing.T) {
+		fail := func(ip string) {
+			req := httptest.NewRequest(http.MethodGet, "/audit", nil)
+			req.RemoteAddr = ip + ":4242"
+			rr := httptest.NewRecorder()
+			app.ServeHTTP(rr, req)
+			require.Equal(t, http.StatusForbidden, rr.Code)
+		}
+		for range 15 {
+			fail("198.51.100.1")
+		}
+		fail("198.51.100.2")
+
+		p, err := audit.NewSvc(repo).Query(context.Background(), audit.Filter{Action: audit.ActionAuthFailed, Limit: 100})
+		require.NoError(t, err)
+		recorded := make(map[string]int)
+		for _, e := range p.Entries {
+			recorded[e.IP]++
+		}
+		assert.Equal(t, map[string]int{"192.0.2.1": 1, "198.51.100.1": 10, "198.51.100.2": 1}, recorded)
+	})
+}
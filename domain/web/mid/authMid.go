@@ -2,13 +2,16 @@ package mid
 
 import (
 	"context"
-	"fmt"
-	"log/slog"
+	"errors"
 	"net/http"
+	"slices"
 
+	"github.com/Keisn1/note-taking-app/domain/core/audit"
 	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/Keisn1/note-taking-app/domain/core/user"
 	"github.com/Keisn1/note-taking-app/domain/web/auth"
 	"github.com/Keisn1/note-taking-app/foundation"
+	"github.com/Keisn1/note-taking-app/foundation/metrics"
 	"github.com/Keisn1/note-taking-app/foundation/web"
 	"github.com/google/uuid"
 )
@@ -16,21 +19,30 @@ import (
 func AuthorizeNote(ns note.Service) web.MidHandler {
 	m := func(next http.Handler) http.Handler {
 		h := func(w http.ResponseWriter, r *http.Request) {
+			log := web.Logger(r.Context())
+			userID := GetUserID(r.Context())
+			deny := func() {
+				http.Error(w, "", http.StatusForbidden)
+				RecordAudit(r.Context(), userID, audit.ActionAccessDenied, "note:"+r.PathValue("note_id"))
+			}
+
 			noteID, err := uuid.Parse(r.PathValue("note_id"))
 			if err != nil {
-				http.Error(w, "", http.StatusForbidden)
+				deny()
+				log.Info("failed authorization: invalid note id", "note_id", r.PathValue("note_id"))
 				return
 			}
 
-			userID := r.Context().Value(foundation.UserIDKey).(uuid.UUID)
 			n, err := ns.QueryByID(r.Context(), noteID)
 			if err != nil {
-				http.Error(w, "", http.StatusForbidden)
+				deny()
+				log.Info("failed authorization", "note_id", noteID, "error", err)
 				return
 			}
 
 			if n.UserID != userID {
-				http.Error(w, "", http.StatusForbidden)
+				deny()
+				log.Info("failed authorization: note of other user", "note_id", noteID)
 				return
 			}
 
@@ -44,18 +56,43 @@ func AuthorizeNote(ns note.Service) web.MidHandler {
 	return m
 }
 
-func Authenticate(a auth.AuthInterface) web.MidHandler {
+// Authenticate lets requests through that carry a valid token of an existing
+// user who isn't disabled. The user is looked up for every request, so that
+// disabling or deleting them takes effect before their tokens expire.
+func Authenticate(a auth.AuthInterface, us user.Service) web.MidHandler {
 	m := func(next http.Handler) http.Handler {
 		h := func(w http.ResponseWriter, r *http.Request) {
+			log := web.Logger(r.Context())
+			fail := func(userID uuid.UUID, err error) {
+				http.Error(w, "failed authentication", http.StatusForbidden)
+				log.Info("failed authentication", "error", err)
+				metrics.AuthFailures.Inc()
+				RecordAudit(r.Context(), userID, audit.ActionAuthFailed, audit.PathTarget(r.URL.Path))
+			}
+
 			bearerToken := r.Header.Get("Authorization")
 			claims, err := a.Authenticate(bearerToken)
 			if err != nil {
-				http.Error(w, "failed authentication", http.StatusForbidden)
-				slog.Info("failed authentication")
+				fail(uuid.Nil, err)
 				return
 			}
 
 			userID, _ := uuid.Parse(claims.Subject)
+			u, err := us.QueryByID(r.Context(), userID)
+			switch {
+			case errors.Is(err, user.ErrUserNotFound):
+				fail(uuid.Nil, err)
+				return
+			case err != nil:
+				http.Error(w, "", http.StatusInternalServerError)
+				log.Error("authenticate: query user", "user_id", userID, "error", err)
+				return
+			case u.Disabled:
+				fail(userID, user.ErrUserDisabled)
+				return
+			}
+
+			web.AddLogAttrs(r.Context(), "user_id", userID)
 			ctx := setUserID(r.Context(), userID)
 			ctx = setClaims(ctx, claims)
 			r = r.WithContext(ctx)
@@ -67,6 +104,26 @@ func Authenticate(a auth.AuthInterface) web.MidHandler {
 	return m
 }
 
+// AuthorizeAdmin lets only the admins through. It has to run after
+// Authenticate.
+func AuthorizeAdmin(admins []uuid.UUID) web.MidHandler {
+	m := func(next http.Handler) http.Handler {
+		h := func(w http.ResponseWriter, r *http.Request) {
+			userID := GetUserID(r.Context())
+			if userID == uuid.Nil || !slices.Contains(admins, userID) {
+				http.Error(w, "", http.StatusForbidden)
+				web.Logger(r.Context()).Info("failed authorization: not an admin")
+				RecordAudit(r.Context(), userID, audit.ActionAccessDenied, audit.PathTarget(r.URL.Path))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(h)
+	}
+	return m
+}
+
 func setUserID(ctx context.Context, userID uuid.UUID) context.Context {
 	return context.WithValue(ctx, foundation.UserIDKey, userID)
 }
@@ -86,7 +143,6 @@ func setNote(ctx context.Context, n note.Note) context.Context {
 func GetNote(ctx context.Context) note.Note {
 	n, ok := ctx.Value(foundation.NoteKey).(note.Note)
 	if !ok {
-		fmt.Println("Not ok")
 		return note.Note{}
 	}
 	return n
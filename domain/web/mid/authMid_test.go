@@ -3,6 +3,7 @@ package mid_test
 import (
 	"bytes"
 	"context"
+	"errors"
 	"log"
 	"net/http"
 	"net/http/httptest"
@@ -10,12 +11,15 @@ import (
 	"time"
 
 	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	usermemory "github.com/Keisn1/note-taking-app/domain/core/user/repositories/memory"
 	"github.com/Keisn1/note-taking-app/domain/web/auth"
 	"github.com/Keisn1/note-taking-app/domain/web/mid"
 	"github.com/Keisn1/note-taking-app/foundation"
 	"github.com/Keisn1/note-taking-app/foundation/common"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_Authorize(t *testing.T) {
@@ -61,6 +65,19 @@ func Test_Authorize(t *testing.T) {
 		assert.Equal(t, http.StatusForbidden, rr.Code)
 	})
 
+	t.Run("Authorize failure, no user id in context", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/notImplemented", nil)
+		req.SetPathValue("note_id", noteID.String())
+
+		handler := midAuthorize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("Test Handler"))
+		}))
+
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
 	t.Run("Authorize failure, note not present", func(t *testing.T) {
 		rr := httptest.NewRecorder()
 		req := httptest.NewRequest(http.MethodGet, "/notImplemented", nil)
@@ -86,12 +103,13 @@ func Test_Authenticate(t *testing.T) {
 	assert.NoError(t, err)
 	a := auth.NewAuth(jwtSvc)
 
-	midAuthenticate := mid.Authenticate(a)
+	userID := uuid.New()
+	userSvc := user.NewSvc(usermemory.NewRepo([]user.User{{ID: userID}}))
+	midAuthenticate := mid.Authenticate(a, userSvc)
 	handler := midAuthenticate(http.HandlerFunc(
 		func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("Test Handler")) }),
 	)
 
-	userID := uuid.New()
 	testCases := []struct {
 		name        string
 		setupHeader func(*http.Request)
@@ -147,6 +165,18 @@ func Test_Authenticate(t *testing.T) {
 				assert.Contains(t, logBuf.String(), "failed authentication")
 			},
 		},
+		{
+			name: "unknown user",
+			setupHeader: func(req *http.Request) {
+				tokenS, _ := jwtSvc.CreateToken(uuid.New(), time.Minute)
+				req.Header.Set("Authorization", "Bearer "+tokenS)
+			},
+			assertions: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusForbidden, recorder.Code)
+				assert.Contains(t, recorder.Body.String(), "failed authentication")
+				assert.Contains(t, logBuf.String(), "user not found")
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -160,15 +190,54 @@ func Test_Authenticate(t *testing.T) {
 		tc.assertions(t, recorder)
 	}
 
+	t.Run("Tokens issued before the user was disabled or deleted are rejected", func(t *testing.T) {
+		tokenS, err := jwtSvc.CreateToken(userID, time.Minute)
+		require.NoError(t, err)
+		call := func() *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, "/auth", nil)
+			req.Header.Set("Authorization", "Bearer "+tokenS)
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+			return recorder
+		}
+		require.Equal(t, http.StatusOK, call().Code)
+
+		_, err = userSvc.SetDisabled(context.Background(), userID, true)
+		require.NoError(t, err)
+		recorder := call()
+		assert.Equal(t, http.StatusForbidden, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "failed authentication")
+
+		_, err = userSvc.SetDisabled(context.Background(), userID, false)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, call().Code)
+
+		require.NoError(t, userSvc.Delete(context.Background(), userID))
+		assert.Equal(t, http.StatusForbidden, call().Code)
+	})
+
+	t.Run("Given the user can't be looked up, the request fails", func(t *testing.T) {
+		tokenS, err := jwtSvc.CreateToken(uuid.New(), time.Minute)
+		require.NoError(t, err)
+		handler := mid.Authenticate(a, StubUserService{err: errors.New("DBError")})(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) { t.Error("handler called") }),
+		)
+
+		req := httptest.NewRequest(http.MethodGet, "/auth", nil)
+		req.Header.Set("Authorization", "Bearer "+tokenS)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	})
+
 	t.Run("Test claims set on context after success", func(t *testing.T) {
 		key := common.MustGenerateRandomKey(32)
 		jwtSvc, err := auth.NewJWTService(key)
 		assert.NoError(t, err)
 		a := auth.NewAuth(jwtSvc)
 
-		midAuthenticate := mid.Authenticate(a)
-
 		wantUserID := uuid.New()
+		midAuthenticate := mid.Authenticate(a, user.NewSvc(usermemory.NewRepo([]user.User{{ID: wantUserID}})))
 		tokenS, err := jwtSvc.CreateToken(wantUserID, time.Minute)
 		assert.NoError(t, err)
 
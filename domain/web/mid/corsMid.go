@@ -0,0 +1,65 @@
+package mid
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Keisn1/note-taking-app/foundation/web"
+)
+
+const corsMaxAge = 600
+
+var (
+	corsMethods = strings.Join([]string{
+		http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+	}, ", ")
+	corsHeaders = strings.Join([]string{
+		"Authorization", "Content-Type", "If-Match", RequestIDHeader,
+	}, ", ")
+)
+
+// CORS allows browsers on the given origins to call the API. "*" allows any
+// origin. Preflight requests are answered without reaching the routes. It
+// returns nil if no origin is allowed.
+func CORS(origins []string) web.MidHandler {
+	if len(origins) == 0 {
+		return nil
+	}
+
+	allowed := make(map[string]bool, len(origins))
+	for _, o := range origins {
+		allowed[o] = true
+	}
+
+	m := func(next http.Handler) http.Handler {
+		h := func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Origin")
+			if !allowed[origin] && !allowed["*"] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Expose-Headers", RequestIDHeader+", ETag")
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				w.Header().Set("Access-Control-Allow-Methods", corsMethods)
+				w.Header().Set("Access-Control-Allow-Headers", corsHeaders)
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(corsMaxAge))
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(h)
+	}
+	return m
+}
@@ -0,0 +1,67 @@
+package mid_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Keisn1/note-taking-app/domain/web/mid"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CORS(t *testing.T) {
+	app := web.NewApp(mid.CORS([]string{"https://notes.example"}))
+	app.Get("/notes", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("notes"))
+	})
+
+	t.Run("allowed origin", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/notes", nil)
+		req.Header.Set("Origin", "https://notes.example")
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "https://notes.example", rr.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "Origin", rr.Header().Get("Vary"))
+		assert.Equal(t, "notes", rr.Body.String())
+	})
+
+	t.Run("disallowed origin", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/notes", nil)
+		req.Header.Set("Origin", "https://evil.example")
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("preflight", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/notes", nil)
+		req.Header.Set("Origin", "https://notes.example")
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.Contains(t, rr.Header().Get("Access-Control-Allow-Methods"), http.MethodPost)
+		assert.Contains(t, rr.Header().Get("Access-Control-Allow-Headers"), "Authorization")
+	})
+
+	t.Run("preflight of disallowed origin reaches the routes", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/notes", nil)
+		req.Header.Set("Origin", "https://evil.example")
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+		assert.Empty(t, rr.Header().Get("Access-Control-Allow-Methods"))
+	})
+
+	t.Run("no origins configured", func(t *testing.T) {
+		assert.Nil(t, mid.CORS(nil))
+	})
+}
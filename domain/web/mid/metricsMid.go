@@ -0,0 +1,50 @@
+package mid
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/foundation/metrics"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+)
+
+// Metrics counts requests and observes their latency by method, route pattern
+// and status. Requests that match no route are labelled "unmatched", methods
+// outside the standard ones "other", so that clients can't add labels.
+func Metrics() web.MidHandler {
+	m := func(next http.Handler) http.Handler {
+		h := func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+
+			v := web.GetValues(r.Context())
+			if v == nil {
+				return
+			}
+
+			route := v.Route
+			if route == "" {
+				route = "unmatched"
+			}
+			status := v.StatusCode
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			labels := []string{method(r.Method), route, strconv.Itoa(status)}
+			metrics.HTTPRequests.WithLabelValues(labels...).Inc()
+			metrics.HTTPDuration.WithLabelValues(labels...).Observe(time.Since(v.Now).Seconds())
+		}
+		return http.HandlerFunc(h)
+	}
+	return m
+}
+
+func method(m string) string {
+	switch m {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return m
+	}
+	return "other"
+}
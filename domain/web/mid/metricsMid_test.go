@@ -0,0 +1,72 @@
+package mid_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	usermemory "github.com/Keisn1/note-taking-app/domain/core/user/repositories/memory"
+	"github.com/Keisn1/note-taking-app/domain/web/auth"
+	"github.com/Keisn1/note-taking-app/domain/web/mid"
+	"github.com/Keisn1/note-taking-app/foundation/common"
+	"github.com/Keisn1/note-taking-app/foundation/metrics"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Metrics(t *testing.T) {
+	a := auth.NewAuth(auth.MustNewJWTService(common.MustGenerateRandomKey(32)))
+
+	app := web.NewApp(mid.Metrics())
+	app.Get("/metrics-test/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	app.Get("/metrics-test-auth", func(w http.ResponseWriter, r *http.Request) {}, mid.Authenticate(a, user.NewSvc(usermemory.NewRepo(nil))))
+
+	t.Run("Requests are counted by route pattern and status", func(t *testing.T) {
+		route := "GET /metrics-test/{id}"
+		before := testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues(http.MethodGet, route, "418"))
+
+		for _, id := range []string{"1", "2"} {
+			rr := httptest.NewRecorder()
+			app.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics-test/"+id, nil))
+		}
+
+		after := testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues(http.MethodGet, route, "418"))
+		assert.Equal(t, float64(2), after-before)
+	})
+
+	t.Run("Requests without route are labelled unmatched", func(t *testing.T) {
+		before := testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues(http.MethodGet, "unmatched", "404"))
+
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/does-not-exist", nil))
+
+		after := testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues(http.MethodGet, "unmatched", "404"))
+		assert.Equal(t, float64(1), after-before)
+	})
+
+	t.Run("Methods outside the standard ones are labelled other", func(t *testing.T) {
+		before := testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("other", "unmatched", "404"))
+
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, httptest.NewRequest("X-RANDOM-1", "/does-not-exist", nil))
+
+		after := testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("other", "unmatched", "404"))
+		assert.Equal(t, float64(1), after-before)
+		assert.Zero(t, testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("X-RANDOM-1", "unmatched", "404")))
+	})
+
+	t.Run("Authentication failures are counted", func(t *testing.T) {
+		before := testutil.ToFloat64(metrics.AuthFailures)
+
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics-test-auth", nil))
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+
+		after := testutil.ToFloat64(metrics.AuthFailures)
+		assert.Equal(t, float64(1), after-before)
+	})
+}
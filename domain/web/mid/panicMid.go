@@ -0,0 +1,40 @@
+package mid
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/Keisn1/note-taking-app/foundation/web"
+)
+
+// Panics recovers from panics in the following handlers. The panic is logged
+// with its stack trace and the client receives a 500.
+func Panics() web.MidHandler {
+	m := func(next http.Handler) http.Handler {
+		h := func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				web.Logger(r.Context()).Error("panic",
+					"error", fmt.Sprint(rec),
+					"stack", string(debug.Stack()),
+				)
+
+				if v := web.GetValues(r.Context()); v == nil || v.StatusCode == 0 {
+					http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+			}()
+
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(h)
+	}
+	return m
+}
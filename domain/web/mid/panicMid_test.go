@@ -0,0 +1,46 @@
+package mid_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Keisn1/note-taking-app/domain/web/mid"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Panics(t *testing.T) {
+	var logBuf bytes.Buffer
+	log := slog.New(slog.NewTextHandler(&logBuf, nil))
+
+	app := web.NewApp(mid.RequestID(), mid.Logger(log), mid.Panics())
+	app.Get("/panic", func(w http.ResponseWriter, r *http.Request) { panic("boom") })
+	app.Get("/panic-after-write", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		panic("boom")
+	})
+
+	t.Run("Panic is recovered into a 500", func(t *testing.T) {
+		logBuf.Reset()
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.Contains(t, logBuf.String(), "msg=panic")
+		assert.Contains(t, logBuf.String(), "error=boom")
+		assert.Contains(t, logBuf.String(), "panicMid_test.go")
+		assert.Contains(t, logBuf.String(), "status=500")
+	})
+
+	t.Run("Status is kept if header was already written", func(t *testing.T) {
+		logBuf.Reset()
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/panic-after-write", nil))
+
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+		assert.Contains(t, logBuf.String(), "msg=panic")
+	})
+}
@@ -0,0 +1,89 @@
+package mid
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/foundation/web"
+	"github.com/google/uuid"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+const maxRequestIDLength = 128
+
+// RequestID takes the request ID from the X-Request-ID header or generates a
+// new one if it is missing or invalid. The ID is echoed in the response.
+func RequestID() web.MidHandler {
+	m := func(next http.Handler) http.Handler {
+		h := func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(RequestIDHeader)
+			if !validRequestID(requestID) {
+				requestID = uuid.NewString()
+			}
+
+			if v := web.GetValues(r.Context()); v != nil {
+				v.RequestID = requestID
+			}
+			w.Header().Set(RequestIDHeader, requestID)
+
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(h)
+	}
+	return m
+}
+
+func validRequestID(id string) bool {
+	if len(id) == 0 || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Logger stores a request-scoped logger in the context and logs every
+// request once it is completed.
+func Logger(log *slog.Logger) web.MidHandler {
+	m := func(next http.Handler) http.Handler {
+		h := func(w http.ResponseWriter, r *http.Request) {
+			v := web.GetValues(r.Context())
+			if v == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			web.SetLogger(r.Context(), log.With("request_id", v.RequestID))
+
+			next.ServeHTTP(w, r)
+
+			status := v.StatusCode
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			level := slog.LevelInfo
+			if status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+
+			web.Logger(r.Context()).Log(r.Context(), level, "request completed",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", status,
+				"latency", time.Since(v.Now),
+				"bytes", v.Bytes,
+				"remote_addr", r.RemoteAddr,
+			)
+		}
+		return http.HandlerFunc(h)
+	}
+	return m
+}
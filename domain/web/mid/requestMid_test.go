@@ -0,0 +1,103 @@
+package mid_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Keisn1/note-taking-app/domain/web/mid"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RequestID(t *testing.T) {
+	app := web.NewApp(mid.RequestID())
+	app.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(web.GetValues(r.Context()).RequestID))
+	})
+
+	testCases := []struct {
+		name      string
+		requestID string
+		assertion func(t *testing.T, got string)
+	}{
+		{
+			name:      "propagates valid request id",
+			requestID: "abc-123_x.y",
+			assertion: func(t *testing.T, got string) { assert.Equal(t, "abc-123_x.y", got) },
+		},
+		{
+			name:      "generates request id if missing",
+			requestID: "",
+			assertion: func(t *testing.T, got string) { assert.Len(t, got, 36) },
+		},
+		{
+			name:      "replaces invalid request id",
+			requestID: "invalid id\n",
+			assertion: func(t *testing.T, got string) { assert.Len(t, got, 36) },
+		},
+		{
+			name:      "replaces too long request id",
+			requestID: strings.Repeat("a", 129),
+			assertion: func(t *testing.T, got string) { assert.Len(t, got, 36) },
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tc.requestID != "" {
+				req.Header.Set(mid.RequestIDHeader, tc.requestID)
+			}
+			app.ServeHTTP(rr, req)
+
+			tc.assertion(t, rr.Body.String())
+			assert.Equal(t, rr.Body.String(), rr.Header().Get(mid.RequestIDHeader))
+		})
+	}
+}
+
+func Test_Logger(t *testing.T) {
+	var logBuf bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&logBuf, nil))
+
+	app := web.NewApp(mid.RequestID(), mid.Logger(log))
+	app.Post("/notes/{note_id}", func(w http.ResponseWriter, r *http.Request) {
+		web.AddLogAttrs(r.Context(), "user_id", "rob")
+		web.Logger(r.Context()).Info("in handler")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello"))
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/notes/1", nil)
+	req.Header.Set(mid.RequestIDHeader, "req-1")
+	rr := httptest.NewRecorder()
+	app.ServeHTTP(rr, req)
+
+	lines := strings.Split(strings.TrimSpace(logBuf.String()), "\n")
+	assert.Len(t, lines, 2)
+
+	var handlerLog, requestLog map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &handlerLog))
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &requestLog))
+
+	assert.Equal(t, "in handler", handlerLog["msg"])
+	assert.Equal(t, "req-1", handlerLog["request_id"])
+	assert.Equal(t, "POST /notes/{note_id}", handlerLog["route"])
+	assert.Equal(t, "rob", handlerLog["user_id"])
+
+	assert.Equal(t, "request completed", requestLog["msg"])
+	assert.Equal(t, "req-1", requestLog["request_id"])
+	assert.Equal(t, "POST /notes/{note_id}", requestLog["route"])
+	assert.Equal(t, "rob", requestLog["user_id"])
+	assert.Equal(t, "POST", requestLog["method"])
+	assert.Equal(t, "/notes/1", requestLog["path"])
+	assert.Equal(t, float64(http.StatusCreated), requestLog["status"])
+	assert.Equal(t, float64(5), requestLog["bytes"])
+	assert.Contains(t, requestLog, "latency")
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/Keisn1/note-taking-app/domain/core/user"
 	"github.com/google/uuid"
 )
 
@@ -11,12 +12,38 @@ type StubNoteService struct {
 	notes map[uuid.UUID]note.Note
 }
 
-func (ns StubNoteService) Delete(noteID uuid.UUID) error                { return nil }
-func (ns StubNoteService) Create(nN note.UpdateNote) (note.Note, error) { return note.Note{}, nil }
-func (ns StubNoteService) Update(n note.Note, newN note.UpdateNote) (note.Note, error) {
+func (ns StubNoteService) Delete(ctx context.Context, noteID uuid.UUID) error { return nil }
+func (ns StubNoteService) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	return nil
+}
+func (ns StubNoteService) Create(ctx context.Context, nN note.UpdateNote) (note.Note, error) {
+	return note.Note{}, nil
+}
+func (ns StubNoteService) Update(ctx context.Context, n note.Note, newN note.UpdateNote) (note.Note, error) {
+	return note.Note{}, nil
+}
+func (ns StubNoteService) UpdateFunc(ctx context.Context, noteID uuid.UUID, fn func(note.Note) (note.UpdateNote, error)) (note.Note, error) {
 	return note.Note{}, nil
 }
 func (ns StubNoteService) QueryByID(ctx context.Context, noteID uuid.UUID) (note.Note, error) {
 	return ns.notes[noteID], nil
 }
-func (ns StubNoteService) GetNotesByUserID(userID uuid.UUID) ([]note.Note, error) { return nil, nil }
+func (ns StubNoteService) GetNotesByUserID(ctx context.Context, userID uuid.UUID) ([]note.Note, error) {
+	return nil, nil
+}
+func (ns StubNoteService) IterateByUserID(ctx context.Context, userID uuid.UUID, fn func(note.Note) error) error {
+	return nil
+}
+func (ns StubNoteService) QueryByFilter(ctx context.Context, f note.Filter) ([]note.Note, int, error) {
+	return nil, 0, nil
+}
+
+// StubUserService fails every lookup of a user with err.
+type StubUserService struct {
+	user.Service
+	err error
+}
+
+func (us StubUserService) QueryByID(ctx context.Context, userID uuid.UUID) (user.User, error) {
+	return user.User{}, us.err
+}
@@ -0,0 +1,59 @@
+package mid
+
+import (
+	"net/http"
+
+	"github.com/Keisn1/note-taking-app/foundation/web"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/Keisn1/note-taking-app/domain/web/mid"
+
+// Trace starts a server span for every request, continuing the trace of the
+// caller if the request carries a W3C traceparent header. The span is named
+// after the matched route pattern.
+func Trace() web.MidHandler {
+	m := func(next http.Handler) http.Handler {
+		h := func(w http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := otel.Tracer(tracerName).Start(ctx, r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", r.Method),
+					attribute.String("url.path", r.URL.Path),
+				),
+			)
+			defer span.End()
+
+			if sc := span.SpanContext(); sc.IsValid() {
+				web.AddLogAttrs(ctx, "trace_id", sc.TraceID().String())
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+
+			v := web.GetValues(ctx)
+			if v == nil {
+				return
+			}
+
+			status := v.StatusCode
+			if status == 0 {
+				status = http.StatusOK
+			}
+			span.SetAttributes(attribute.Int("http.response.status_code", status))
+			if v.Route != "" {
+				span.SetName(v.Route)
+				span.SetAttributes(attribute.String("http.route", v.Route))
+			}
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+		}
+		return http.HandlerFunc(h)
+	}
+	return m
+}
@@ -0,0 +1,60 @@
+package mid_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Keisn1/note-taking-app/domain/web/mid"
+	"github.com/Keisn1/note-taking-app/foundation/web"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func Test_Trace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	app := web.NewApp(mid.Trace(), mid.Panics())
+	app.Get("/notes/{note_id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(trace.SpanContextFromContext(r.Context()).TraceID().String()))
+	})
+	app.Get("/panic", func(w http.ResponseWriter, r *http.Request) { panic("boom") })
+
+	t.Run("Continues the trace of the caller", func(t *testing.T) {
+		traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+		req := httptest.NewRequest(http.MethodGet, "/notes/1", nil)
+		req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, req)
+
+		assert.Equal(t, traceID, rr.Body.String())
+
+		spans := recorder.Ended()
+		span := spans[len(spans)-1]
+		assert.Equal(t, "GET /notes/{note_id}", span.Name())
+		assert.Equal(t, traceID, span.SpanContext().TraceID().String())
+		assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+		assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+		assert.Contains(t, span.Attributes(), attribute.Int("http.response.status_code", http.StatusOK))
+		assert.Contains(t, span.Attributes(), attribute.String("http.route", "GET /notes/{note_id}"))
+	})
+
+	t.Run("Server errors set the span status", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+		spans := recorder.Ended()
+		span := spans[len(spans)-1]
+		assert.Equal(t, "GET /panic", span.Name())
+		assert.False(t, span.Parent().IsValid())
+		assert.Equal(t, codes.Error, span.Status().Code)
+	})
+}
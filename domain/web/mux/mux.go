@@ -1,20 +1,61 @@
 package mux
 
 import (
+	"log/slog"
 	"net/http"
+	"time"
 
+	"github.com/Keisn1/note-taking-app/domain/core/attachment"
+	"github.com/Keisn1/note-taking-app/domain/core/audit"
+	"github.com/Keisn1/note-taking-app/domain/core/change"
+	"github.com/Keisn1/note-taking-app/domain/core/collab"
+	"github.com/Keisn1/note-taking-app/domain/core/link"
+	"github.com/Keisn1/note-taking-app/domain/core/note"
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	"github.com/Keisn1/note-taking-app/domain/core/webhook"
 	"github.com/Keisn1/note-taking-app/domain/web/auth"
+	"github.com/Keisn1/note-taking-app/domain/web/mid"
+	"github.com/Keisn1/note-taking-app/foundation/eventbus"
+	"github.com/Keisn1/note-taking-app/foundation/health"
+	"github.com/Keisn1/note-taking-app/foundation/metrics"
 	"github.com/Keisn1/note-taking-app/foundation/web"
+	"github.com/google/uuid"
 )
 
 type Config struct {
-	Auth auth.Auth
+	Log           *slog.Logger
+	Auth          auth.Auth
+	NoteSvc       note.Service
+	UserSvc       user.Service
+	AttachmentSvc attachment.Service
+	LinkSvc       link.Service
+	SyncSvc       change.Service
+	WebhookSvc    webhook.Service
+	AuditSvc      audit.Service
+	Events        *eventbus.Bus
+	Collab        *collab.Hub
+	Readiness     *health.Readiness
+
+	// TokenTTL is the lifetime of the tokens issued at login.
+	TokenTTL time.Duration
+
+	// CORSOrigins are the origins browsers may call the API from.
+	CORSOrigins []string
+
+	// AuditAdmins are the users that may query the audit trail.
+	AuditAdmins []uuid.UUID
 }
 
 type RouteAdder func(api *web.App, cfg Config)
 
 func NewAPI(add RouteAdder, cfg Config) http.Handler {
-	app := web.NewApp()
+	log := cfg.Log
+	if log == nil {
+		log = slog.Default()
+	}
+
+	app := web.NewApp(mid.RequestID(), mid.Logger(log), mid.Audit(cfg.AuditSvc), mid.CORS(cfg.CORSOrigins), mid.Metrics(), mid.Trace(), mid.Panics())
+	app.Handle("GET /metrics", metrics.Handler())
 	add(app, cfg)
 	return app
 }
@@ -5,13 +5,17 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/Keisn1/note-taking-app/domain/core/user"
+	usermemory "github.com/Keisn1/note-taking-app/domain/core/user/repositories/memory"
 	"github.com/Keisn1/note-taking-app/domain/web/auth"
 	"github.com/Keisn1/note-taking-app/domain/web/mid"
 	"github.com/Keisn1/note-taking-app/domain/web/mux"
 	"github.com/Keisn1/note-taking-app/foundation/common"
 	"github.com/Keisn1/note-taking-app/foundation/web"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -47,12 +51,12 @@ func TestServer(t *testing.T) {
 
 	t.Run("Example with authentication", func(t *testing.T) {
 		key := common.MustGenerateRandomKey(32)
-		cfg := mux.Config{Auth: auth.NewAuth(auth.MustNewJWTService(key))}
+		userID := uuid.New()
+		cfg := mux.Config{Auth: auth.NewAuth(auth.MustNewJWTService(key)), UserSvc: user.NewSvc(usermemory.NewRepo([]user.User{{ID: userID}}))}
 
 		testRoutes := func(api *web.App, cfg mux.Config) {
-			authen := mid.Authenticate(cfg.Auth)
 			fetch := func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, "Hello from fetch") }
-			api.Handle("/fetch", authen(http.HandlerFunc(fetch)))
+			api.Handle("/fetch", http.HandlerFunc(fetch), mid.Authenticate(cfg.Auth, cfg.UserSvc))
 		}
 
 		api := mux.NewAPI(testRoutes, cfg)
@@ -69,7 +73,7 @@ func TestServer(t *testing.T) {
 			},
 			{
 				setupHeader: func(r *http.Request) {
-					token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{})
+					token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": userID.String()})
 					tokenS, err := token.SignedString(key)
 					assert.NoError(t, err)
 					r.Header.Set("Authorization", "Bearer "+tokenS)
@@ -88,4 +92,140 @@ func TestServer(t *testing.T) {
 			assert.Contains(t, resp.Body.String(), tc.want)
 		}
 	})
+
+	t.Run("Method aware routes", func(t *testing.T) {
+		testRoutes := func(api *web.App, cfg mux.Config) {
+			get := func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, "get ", r.PathValue("id")) }
+			post := func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, "post") }
+			api.Get("/items/{id}", get)
+			api.Post("/items", post)
+		}
+
+		api := mux.NewAPI(testRoutes, mux.Config{})
+
+		testCases := []struct {
+			method     string
+			endpoint   string
+			statusCode int
+			want       string
+		}{
+			{method: http.MethodGet, endpoint: "/items/1", statusCode: http.StatusOK, want: "get 1"},
+			{method: http.MethodPost, endpoint: "/items", statusCode: http.StatusOK, want: "post"},
+			{method: http.MethodDelete, endpoint: "/items/1", statusCode: http.StatusMethodNotAllowed},
+			{method: http.MethodGet, endpoint: "/unknown", statusCode: http.StatusNotFound},
+		}
+		for _, tc := range testCases {
+			resp := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.method, tc.endpoint, nil)
+			api.ServeHTTP(resp, req)
+			assert.Equal(t, tc.statusCode, resp.Code)
+			if tc.want != "" {
+				assert.Equal(t, tc.want, resp.Body.String())
+			}
+		}
+	})
+
+	t.Run("Middleware order of app, groups and routes", func(t *testing.T) {
+		var calls []string
+		track := func(name string) web.MidHandler {
+			return func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					calls = append(calls, name)
+					next.ServeHTTP(w, r)
+				})
+			}
+		}
+
+		testRoutes := func(api *web.App, cfg mux.Config) {
+			api.Use(track("app"))
+			handler := func(w http.ResponseWriter, r *http.Request) { calls = append(calls, "handler") }
+
+			group := api.Group("/v1", track("group"))
+			group.Get("/plain", handler)
+			group.Get("/route", handler, track("route"))
+
+			nested := group.Group("/nested", track("nested"))
+			nested.Get("", handler)
+		}
+
+		api := mux.NewAPI(testRoutes, mux.Config{})
+
+		testCases := []struct {
+			endpoint string
+			want     []string
+		}{
+			{endpoint: "/v1/plain", want: []string{"app", "group", "handler"}},
+			{endpoint: "/v1/route", want: []string{"app", "group", "route", "handler"}},
+			{endpoint: "/v1/nested", want: []string{"app", "group", "nested", "handler"}},
+			{endpoint: "/unknown", want: []string{"app"}},
+		}
+		for _, tc := range testCases {
+			calls = nil
+			resp := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tc.endpoint, nil)
+			api.ServeHTTP(resp, req)
+			assert.Equal(t, tc.want, calls, tc.endpoint)
+		}
+	})
+
+	t.Run("App middleware is built once", func(t *testing.T) {
+		var built int
+		count := func(next http.Handler) http.Handler {
+			built++
+			return next
+		}
+
+		api := mux.NewAPI(func(api *web.App, cfg mux.Config) { api.Use(count) }, mux.Config{})
+		built = 0
+		for range 3 {
+			api.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/unknown", nil))
+		}
+		assert.Equal(t, 0, built)
+	})
+
+	t.Run("Authenticated group", func(t *testing.T) {
+		key := common.MustGenerateRandomKey(32)
+		jwtSvc := auth.MustNewJWTService(key)
+		userID := uuid.New()
+		cfg := mux.Config{Auth: auth.NewAuth(jwtSvc), UserSvc: user.NewSvc(usermemory.NewRepo([]user.User{{ID: userID}}))}
+
+		testRoutes := func(api *web.App, cfg mux.Config) {
+			fetch := func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, mid.GetUserID(r.Context())) }
+			api.Group("/private", mid.Authenticate(cfg.Auth, cfg.UserSvc)).Get("/fetch", fetch)
+			api.Get("/public", func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, "public") })
+		}
+
+		api := mux.NewAPI(testRoutes, cfg)
+
+		resp := httptest.NewRecorder()
+		api.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/private/fetch", nil))
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+
+		resp = httptest.NewRecorder()
+		api.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/public", nil))
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		tokenS, err := jwtSvc.CreateToken(userID, time.Minute)
+		assert.NoError(t, err)
+		req := httptest.NewRequest(http.MethodGet, "/private/fetch", nil)
+		req.Header.Set("Authorization", "Bearer "+tokenS)
+		resp = httptest.NewRecorder()
+		api.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, userID.String(), resp.Body.String())
+	})
+
+	t.Run("Metrics endpoint", func(t *testing.T) {
+		testRoutes := func(api *web.App, cfg mux.Config) {
+			api.Get("/fetch", func(w http.ResponseWriter, r *http.Request) {})
+		}
+		api := mux.NewAPI(testRoutes, mux.Config{})
+
+		api.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fetch", nil))
+
+		resp := httptest.NewRecorder()
+		api.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `notes_http_requests_total{method="GET",route="GET /fetch",status="200"} 1`)
+	})
 }
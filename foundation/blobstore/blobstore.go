@@ -0,0 +1,44 @@
+// Package blobstore stores binary objects under string keys, on the local
+// file system or in an S3-compatible object store.
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// Store stores blobs. Keys are slash separated paths without . or ..
+// elements, such as "users/<id>/<id>".
+type Store interface {
+	// Put stores size bytes of r under key, replacing an existing blob.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open returns the blob stored under key or ErrNotFound.
+	Open(ctx context.Context, key string) (Object, error)
+	// Delete deletes the blob. Deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Object is a blob opened for reading. It can seek, so that ranges of it can
+// be served with http.ServeContent.
+type Object interface {
+	io.ReadSeekCloser
+	Size() int64
+	ModTime() time.Time
+}
+
+func validKey(key string) error {
+	if !fs.ValidPath(key) || key == "." || strings.Contains(key, `\`) {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return nil
+}
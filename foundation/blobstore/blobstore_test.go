@@ -0,0 +1,198 @@
+package blobstore_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/foundation/blobstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFS(t *testing.T) {
+	store, err := blobstore.NewFS(t.TempDir())
+	require.NoError(t, err)
+	testStore(t, store)
+}
+
+func TestS3(t *testing.T) {
+	fake := newFakeS3("notes", "access")
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	store, err := blobstore.NewS3(blobstore.S3Config{
+		Endpoint:  srv.URL,
+		Region:    "us-east-1",
+		Bucket:    "notes",
+		AccessKey: "access",
+		SecretKey: "secret",
+	})
+	require.NoError(t, err)
+	testStore(t, store)
+
+	assert.Equal(t, "text/plain", fake.contentTypes["notes/users/1/a"])
+	assert.True(t, fake.ranges > 0, "objects are read with ranges")
+
+	_, err = blobstore.NewS3(blobstore.S3Config{Endpoint: "localhost:9000", Region: "us-east-1", Bucket: "notes"})
+	assert.Error(t, err)
+}
+
+func testStore(t *testing.T, store blobstore.Store) {
+	ctx := context.Background()
+	content := "0123456789abcdefghij"
+
+	t.Run("Put and read", func(t *testing.T) {
+		require.NoError(t, store.Put(ctx, "users/1/a", strings.NewReader(content), int64(len(content)), "text/plain"))
+
+		obj, err := store.Open(ctx, "users/1/a")
+		require.NoError(t, err)
+		defer obj.Close()
+		assert.Equal(t, int64(len(content)), obj.Size())
+		assert.WithinDuration(t, time.Now(), obj.ModTime(), time.Minute)
+
+		data, err := io.ReadAll(obj)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(data))
+	})
+
+	t.Run("Seek", func(t *testing.T) {
+		obj, err := store.Open(ctx, "users/1/a")
+		require.NoError(t, err)
+		defer obj.Close()
+
+		buf := make([]byte, 3)
+		_, err = obj.Seek(10, io.SeekStart)
+		require.NoError(t, err)
+		_, err = io.ReadFull(obj, buf)
+		require.NoError(t, err)
+		assert.Equal(t, "abc", string(buf))
+
+		_, err = obj.Seek(-3, io.SeekEnd)
+		require.NoError(t, err)
+		_, err = io.ReadFull(obj, buf)
+		require.NoError(t, err)
+		assert.Equal(t, "hij", string(buf))
+
+		_, err = obj.Seek(2, io.SeekStart)
+		require.NoError(t, err)
+		_, err = io.ReadFull(obj, buf)
+		require.NoError(t, err)
+		assert.Equal(t, "234", string(buf))
+	})
+
+	t.Run("Serve ranges", func(t *testing.T) {
+		obj, err := store.Open(ctx, "users/1/a")
+		require.NoError(t, err)
+		defer obj.Close()
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Range", "bytes=5-7")
+		rr := httptest.NewRecorder()
+		http.ServeContent(rr, req, "", obj.ModTime(), obj)
+		assert.Equal(t, http.StatusPartialContent, rr.Code)
+		assert.Equal(t, "567", rr.Body.String())
+	})
+
+	t.Run("Size must match", func(t *testing.T) {
+		err := store.Put(ctx, "users/1/b", strings.NewReader(content), 5, "text/plain")
+		assert.Error(t, err)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		require.NoError(t, store.Delete(ctx, "users/1/a"))
+		_, err := store.Open(ctx, "users/1/a")
+		assert.ErrorIs(t, err, blobstore.ErrNotFound)
+
+		assert.NoError(t, store.Delete(ctx, "users/1/a"), "deleting twice is fine")
+	})
+
+	t.Run("Invalid keys", func(t *testing.T) {
+		for _, key := range []string{"", "../a", "/a", "a/../../b", `a\b`, "a//b"} {
+			err := store.Put(ctx, key, strings.NewReader(""), 0, "text/plain")
+			assert.ErrorIs(t, err, blobstore.ErrInvalidKey, key)
+			_, err = store.Open(ctx, key)
+			assert.ErrorIs(t, err, blobstore.ErrInvalidKey, key)
+		}
+	})
+}
+
+// fakeS3 is an in-memory stand-in for an S3-compatible server with the
+// operations used by blobstore.S3. Its path is /bucket/key.
+type fakeS3 struct {
+	bucket    string
+	accessKey string
+
+	mu           sync.Mutex
+	objects      map[string][]byte
+	contentTypes map[string]string
+	modTimes     map[string]time.Time
+	ranges       int
+}
+
+func newFakeS3(bucket, accessKey string) *fakeS3 {
+	return &fakeS3{
+		bucket:       bucket,
+		accessKey:    accessKey,
+		objects:      map[string][]byte{},
+		contentTypes: map[string]string{},
+		modTimes:     map[string]time.Time{},
+	}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential="+f.accessKey+"/") || r.Header.Get("X-Amz-Date") == "" {
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, "/"+f.bucket+"/") {
+		http.Error(w, "NoSuchBucket", http.StatusNotFound)
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/")
+
+	switch r.Method {
+	case http.MethodPut:
+		if r.ContentLength < 0 {
+			http.Error(w, "MissingContentLength", http.StatusLengthRequired)
+			return
+		}
+		data, err := io.ReadAll(r.Body)
+		if err != nil || int64(len(data)) != r.ContentLength {
+			http.Error(w, "IncompleteBody", http.StatusBadRequest)
+			return
+		}
+		f.objects[name] = data
+		f.contentTypes[name] = r.Header.Get("Content-Type")
+		f.modTimes[name] = time.Now()
+
+	case http.MethodGet, http.MethodHead:
+		data, ok := f.objects[name]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("Range") != "" {
+			f.ranges++
+		}
+		w.Header().Set("Content-Type", f.contentTypes[name])
+		http.ServeContent(w, r, "", f.modTimes[name], bytes.NewReader(data))
+
+	case http.MethodDelete:
+		delete(f.objects, name)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "MethodNotAllowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,104 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FS stores blobs as files below a directory.
+type FS struct {
+	dir string
+}
+
+func NewFS(dir string) (FS, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return FS{}, fmt.Errorf("newFS: %w", err)
+	}
+	return FS{dir: dir}, nil
+}
+
+// Put writes the blob to a temporary file first, so that readers never see
+// a partial blob.
+func (s FS) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if err := validKey(key); err != nil {
+		return fmt.Errorf("put: %w", err)
+	}
+
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("put: [%s]: %w", key, err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("put: [%s]: %w", key, err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	n, err := io.Copy(f, r)
+	if err != nil {
+		return fmt.Errorf("put: [%s]: %w", key, err)
+	}
+	if n != size {
+		return fmt.Errorf("put: [%s]: got %d bytes, want %d", key, n, size)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("put: [%s]: %w", key, err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("put: [%s]: %w", key, err)
+	}
+	return nil
+}
+
+func (s FS) Open(ctx context.Context, key string) (Object, error) {
+	if err := validKey(key); err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+
+	f, err := os.Open(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open: [%s]: %w", key, err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("open: [%s]: %w", key, err)
+	}
+	return fsObject{File: f, info: info}, nil
+}
+
+func (s FS) Delete(ctx context.Context, key string) error {
+	if err := validKey(key); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	err := os.Remove(s.path(key))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("delete: [%s]: %w", key, err)
+	}
+	return nil
+}
+
+func (s FS) path(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(key))
+}
+
+type fsObject struct {
+	*os.File
+	info fs.FileInfo
+}
+
+func (o fsObject) Size() int64        { return o.info.Size() }
+func (o fsObject) ModTime() time.Time { return o.info.ModTime() }
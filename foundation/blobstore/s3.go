@@ -0,0 +1,213 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3Config configures an S3-compatible store, such as AWS S3 or MinIO.
+type S3Config struct {
+	// Endpoint is the base URL, e.g. https://s3.eu-central-1.amazonaws.com
+	// or http://localhost:9000. Buckets are addressed path-style.
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+
+	// Client is used for the requests, http.DefaultClient if nil.
+	Client *http.Client
+}
+
+// S3 stores blobs as objects of a bucket, using the REST API directly.
+type S3 struct {
+	cfg      S3Config
+	endpoint *url.URL
+}
+
+func NewS3(cfg S3Config) (S3, error) {
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return S3{}, fmt.Errorf("newS3: invalid endpoint %q", cfg.Endpoint)
+	}
+	if cfg.Bucket == "" || cfg.Region == "" {
+		return S3{}, errors.New("newS3: bucket and region are required")
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	return S3{cfg: cfg, endpoint: u}, nil
+}
+
+func (s S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if err := validKey(key); err != nil {
+		return fmt.Errorf("put: %w", err)
+	}
+
+	req, err := s.newRequest(ctx, http.MethodPut, key, r)
+	if err != nil {
+		return fmt.Errorf("put: [%s]: %w", key, err)
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+
+	resp, err := s.do(req)
+	if err != nil {
+		return fmt.Errorf("put: [%s]: %w", key, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s S3) Open(ctx context.Context, key string) (Object, error) {
+	if err := validKey(key); err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+
+	req, err := s.newRequest(ctx, http.MethodHead, key, nil)
+	if err != nil {
+		return nil, fmt.Errorf("open: [%s]: %w", key, err)
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("open: [%s]: %w", key, err)
+	}
+	resp.Body.Close()
+
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return &s3Object{ctx: ctx, store: s, key: key, size: resp.ContentLength, modTime: modTime}, nil
+}
+
+func (s S3) Delete(ctx context.Context, key string) error {
+	if err := validKey(key); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return fmt.Errorf("delete: [%s]: %w", key, err)
+	}
+	resp, err := s.do(req)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("delete: [%s]: %w", key, err)
+	}
+	if resp != nil {
+		resp.Body.Close()
+	}
+	return nil
+}
+
+func (s S3) newRequest(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	u := *s.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.cfg.Bucket + "/" + key
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+	return req, nil
+}
+
+// do signs and sends the request. Responses other than 2xx are returned as
+// errors, 404 as ErrNotFound.
+func (s S3) do(req *http.Request) (*http.Response, error) {
+	signV4(req, s.cfg.AccessKey, s.cfg.SecretKey, s.cfg.Region, time.Now())
+
+	resp, err := s.cfg.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+	return nil, fmt.Errorf("%s answered %d: %s", req.Method, resp.StatusCode, strings.TrimSpace(string(msg)))
+}
+
+// s3Object reads an object with ranged GET requests. A request is only made
+// on Read, from the current offset to the end, and is dropped when seeking
+// elsewhere.
+type s3Object struct {
+	ctx     context.Context
+	store   S3
+	key     string
+	size    int64
+	modTime time.Time
+
+	offset int64
+	body   io.ReadCloser
+}
+
+func (o *s3Object) Size() int64        { return o.size }
+func (o *s3Object) ModTime() time.Time { return o.modTime }
+
+func (o *s3Object) Read(p []byte) (int, error) {
+	if o.offset >= o.size {
+		return 0, io.EOF
+	}
+
+	if o.body == nil {
+		req, err := o.store.newRequest(o.ctx, http.MethodGet, o.key, nil)
+		if err != nil {
+			return 0, fmt.Errorf("read: [%s]: %w", o.key, err)
+		}
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(o.offset, 10)+"-")
+		resp, err := o.store.do(req)
+		if err != nil {
+			return 0, fmt.Errorf("read: [%s]: %w", o.key, err)
+		}
+		if resp.StatusCode != http.StatusPartialContent && o.offset > 0 {
+			resp.Body.Close()
+			return 0, fmt.Errorf("read: [%s]: range not supported", o.key)
+		}
+		o.body = resp.Body
+	}
+
+	n, err := o.body.Read(p)
+	o.offset += int64(n)
+	return n, err
+}
+
+func (o *s3Object) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += o.offset
+	case io.SeekEnd:
+		offset += o.size
+	}
+	if offset < 0 {
+		return 0, errors.New("seek: negative position")
+	}
+
+	if offset != o.offset && o.body != nil {
+		o.body.Close()
+		o.body = nil
+	}
+	o.offset = offset
+	return offset, nil
+}
+
+func (o *s3Object) Close() error {
+	if o.body == nil {
+		return nil
+	}
+	err := o.body.Close()
+	o.body = nil
+	return err
+}
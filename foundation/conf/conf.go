@@ -0,0 +1,389 @@
+// Package conf fills a configuration struct from struct tag defaults, an
+// optional YAML or TOML file, environment variables and command line flags.
+// Later sources take precedence over earlier ones.
+//
+// The names of a field are derived from its path in the struct. The field
+// Web.ReadTimeout is read from the file key web.read_timeout, the variable
+// PREFIX_WEB_READ_TIMEOUT and the flag --web-read-timeout.
+//
+// Supported tags are default, help, required:"true" and secret:"true".
+// Secret values are redacted by String.
+package conf
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrHelp is returned by Parse if the arguments contain -h or --help.
+var ErrHelp = flag.ErrHelp
+
+// FileFlag names the flag, and with the prefix the environment variable, that
+// point to the configuration file.
+const FileFlag = "config"
+
+const redacted = "[redacted]"
+
+type field struct {
+	key      string
+	env      string
+	flag     string
+	help     string
+	def      string
+	secret   bool
+	required bool
+	value    reflect.Value
+}
+
+type setting struct {
+	f     field
+	value string
+}
+
+// Parse fills cfg, which must be a pointer to a struct. If cfg implements
+// Validate() error, it is called after all sources have been applied.
+func Parse(cfg any, prefix string, args []string) error {
+	fields, err := parseFields(cfg, prefix)
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet(FileFlag, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	file := os.Getenv(envName(prefix, FileFlag))
+	fs.StringVar(&file, FileFlag, file, "")
+
+	var flags []setting
+	for _, f := range fields {
+		set := func(s string) error {
+			// Fail on the offending flag instead of after all flags are parsed.
+			if err := setValue(reflect.New(f.value.Type()).Elem(), s); err != nil {
+				return err
+			}
+			flags = append(flags, setting{f: f, value: s})
+			return nil
+		}
+		if f.value.Kind() == reflect.Bool {
+			fs.BoolFunc(f.flag, f.help, set)
+			continue
+		}
+		fs.Func(f.flag, f.help, set)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ErrHelp
+		}
+		return fmt.Errorf("parse: %w", err)
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("parse: unexpected argument %q", fs.Arg(0))
+	}
+
+	for _, f := range fields {
+		if f.def == "" {
+			continue
+		}
+		if err := setValue(f.value, f.def); err != nil {
+			return fmt.Errorf("parse: default of %s: %w", f.key, err)
+		}
+	}
+
+	if file != "" {
+		if err := loadFile(file, fields); err != nil {
+			return fmt.Errorf("parse: %w", err)
+		}
+	}
+
+	for _, f := range fields {
+		v, ok := os.LookupEnv(f.env)
+		if !ok {
+			continue
+		}
+		if err := setValue(f.value, v); err != nil {
+			return fmt.Errorf("parse: %s: %w", f.env, err)
+		}
+	}
+
+	for _, s := range flags {
+		if err := setValue(s.f.value, s.value); err != nil {
+			return fmt.Errorf("parse: --%s: %w", s.f.flag, err)
+		}
+	}
+
+	for _, f := range fields {
+		if f.required && f.value.IsZero() {
+			return fmt.Errorf("parse: %s is required", f.key)
+		}
+	}
+
+	if v, ok := cfg.(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return fmt.Errorf("validate: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// String returns the configuration as key=value lines with secrets redacted.
+func String(cfg any) (string, error) {
+	fields, err := parseFields(cfg, "")
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, f := range fields {
+		v := formatValue(f.value)
+		if f.secret && v != "" {
+			v = redacted
+		}
+		fmt.Fprintf(&b, "%s=%s\n", f.key, v)
+	}
+	return b.String(), nil
+}
+
+// Usage describes the flags and environment variables of the configuration.
+func Usage(cfg any, prefix string) (string, error) {
+	fields, err := parseFields(cfg, prefix)
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	tw := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "  --%s\t%s\t%s\n", FileFlag, envName(prefix, FileFlag), "YAML or TOML configuration file")
+	for _, f := range fields {
+		help := f.help
+		if f.def != "" && !f.secret {
+			help = strings.TrimSpace(fmt.Sprintf("%s (default %q)", help, f.def))
+		}
+		if f.required {
+			help = strings.TrimSpace(help + " (required)")
+		}
+		fmt.Fprintf(tw, "  --%s\t%s\t%s\n", f.flag, f.env, help)
+	}
+	tw.Flush()
+
+	return b.String(), nil
+}
+
+func parseFields(cfg any, prefix string) ([]field, error) {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("parse: config must be a pointer to a struct")
+	}
+
+	var fields []field
+	var walk func(v reflect.Value, path []string) error
+	walk = func(v reflect.Value, path []string) error {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+
+			fieldPath := append(append([]string{}, path...), snakeCase(sf.Name))
+			fv := v.Field(i)
+
+			if sf.Type.Kind() == reflect.Struct && sf.Type != reflect.TypeOf(time.Duration(0)) {
+				if err := walk(fv, fieldPath); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if !supported(sf.Type) {
+				return fmt.Errorf("parse: %s: unsupported type %s", strings.Join(fieldPath, "."), sf.Type)
+			}
+
+			fields = append(fields, field{
+				key:      strings.Join(fieldPath, "."),
+				env:      envName(prefix, strings.Join(fieldPath, "_")),
+				flag:     strings.ReplaceAll(strings.Join(fieldPath, "-"), "_", "-"),
+				help:     sf.Tag.Get("help"),
+				def:      sf.Tag.Get("default"),
+				secret:   sf.Tag.Get("secret") == "true",
+				required: sf.Tag.Get("required") == "true",
+				value:    fv,
+			})
+		}
+		return nil
+	}
+
+	if err := walk(v.Elem(), nil); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func loadFile(path string, fields []field) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("load file: %w", err)
+	}
+
+	m := map[string]any{}
+	switch ext := filepath.Ext(path); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &m)
+	case ".toml":
+		err = toml.Unmarshal(data, &m)
+	default:
+		return fmt.Errorf("load file: unsupported format %q", ext)
+	}
+	if err != nil {
+		return fmt.Errorf("load file %s: %w", path, err)
+	}
+
+	values := map[string]any{}
+	flatten("", m, values)
+
+	byKey := make(map[string]field, len(fields))
+	for _, f := range fields {
+		byKey[f.key] = f
+	}
+
+	for key, v := range values {
+		f, ok := byKey[key]
+		if !ok {
+			return fmt.Errorf("load file %s: unknown key %q", path, key)
+		}
+		if err := setValue(f.value, fileValue(v)); err != nil {
+			return fmt.Errorf("load file %s: %s: %w", path, key, err)
+		}
+	}
+	return nil
+}
+
+func flatten(prefix string, m map[string]any, out map[string]any) {
+	for k, v := range m {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if nested, ok := v.(map[string]any); ok {
+			flatten(key, nested, out)
+			continue
+		}
+		out[key] = v
+	}
+}
+
+func fileValue(v any) string {
+	list, ok := v.([]any)
+	if !ok {
+		return fmt.Sprint(v)
+	}
+	items := make([]string, len(list))
+	for i, item := range list {
+		items[i] = fmt.Sprint(item)
+	}
+	return strings.Join(items, ",")
+}
+
+func supported(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int64, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.String
+	}
+	return false
+}
+
+func setValue(v reflect.Value, s string) error {
+	switch {
+	case v.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(s)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Int || v.Kind() == reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case v.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case v.Kind() == reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func formatValue(v reflect.Value) string {
+	switch {
+	case v.Type() == reflect.TypeOf(time.Duration(0)):
+		return time.Duration(v.Int()).String()
+	case v.Kind() == reflect.Slice:
+		return strings.Join(v.Interface().([]string), ",")
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func envName(prefix, name string) string {
+	name = strings.ToUpper(name)
+	if prefix == "" {
+		return name
+	}
+	return strings.ToUpper(prefix) + "_" + name
+}
+
+// snakeCase converts a Go field name to snake case, keeping acronyms
+// together: CORSOrigins becomes cors_origins and TokenTTL token_ttl.
+func snakeCase(name string) string {
+	runes := []rune(name)
+
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
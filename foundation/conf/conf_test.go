@@ -0,0 +1,208 @@
+package conf_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Keisn1/note-taking-app/foundation/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testConfig struct {
+	Web struct {
+		Addr        string        `default:":3000" help:"listen address"`
+		ReadTimeout time.Duration `default:"5s"`
+		CORSOrigins []string
+	}
+	DB struct {
+		DSN          string `secret:"true" required:"true"`
+		MaxOpenConns int    `default:"10"`
+		Migrate      bool   `default:"true"`
+	}
+	SampleRatio float64 `default:"1"`
+}
+
+type validatedConfig struct {
+	Level string `default:"info"`
+}
+
+func (c *validatedConfig) Validate() error {
+	if c.Level != "info" {
+		return errors.New("level must be info")
+	}
+	return nil
+}
+
+func TestParse_Defaults(t *testing.T) {
+	var cfg testConfig
+	err := conf.Parse(&cfg, "TEST", []string{"--db-dsn", "dsn"})
+	require.NoError(t, err)
+
+	assert.Equal(t, ":3000", cfg.Web.Addr)
+	assert.Equal(t, 5*time.Second, cfg.Web.ReadTimeout)
+	assert.Nil(t, cfg.Web.CORSOrigins)
+	assert.Equal(t, 10, cfg.DB.MaxOpenConns)
+	assert.True(t, cfg.DB.Migrate)
+	assert.Equal(t, 1.0, cfg.SampleRatio)
+}
+
+func TestParse_Precedence(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "notes.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+web:
+  addr: ":4000"
+  read_timeout: 7s
+  cors_origins:
+    - https://a.example
+    - https://b.example
+db:
+  dsn: from-file
+  max_open_conns: 20
+`), 0o600))
+
+	t.Setenv("TEST_CONFIG", file)
+	t.Setenv("TEST_WEB_ADDR", ":5000")
+	t.Setenv("TEST_DB_MAX_OPEN_CONNS", "30")
+
+	var cfg testConfig
+	err := conf.Parse(&cfg, "TEST", []string{"--db-max-open-conns=40", "--db-migrate=false"})
+	require.NoError(t, err)
+
+	assert.Equal(t, ":5000", cfg.Web.Addr)
+	assert.Equal(t, 7*time.Second, cfg.Web.ReadTimeout)
+	assert.Equal(t, []string{"https://a.example", "https://b.example"}, cfg.Web.CORSOrigins)
+	assert.Equal(t, "from-file", cfg.DB.DSN)
+	assert.Equal(t, 40, cfg.DB.MaxOpenConns)
+	assert.False(t, cfg.DB.Migrate)
+}
+
+func TestParse_TOML(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "notes.toml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+sample_ratio = 0.5
+
+[web]
+cors_origins = ["https://a.example"]
+
+[db]
+dsn = "from-toml"
+`), 0o600))
+
+	var cfg testConfig
+	err := conf.Parse(&cfg, "TEST", []string{"--config", file})
+	require.NoError(t, err)
+
+	assert.Equal(t, "from-toml", cfg.DB.DSN)
+	assert.Equal(t, []string{"https://a.example"}, cfg.Web.CORSOrigins)
+	assert.Equal(t, 0.5, cfg.SampleRatio)
+}
+
+func TestParse_Errors(t *testing.T) {
+	writeFile := func(t *testing.T, name, content string) string {
+		file := filepath.Join(t.TempDir(), name)
+		require.NoError(t, os.WriteFile(file, []byte(content), 0o600))
+		return file
+	}
+
+	testCases := []struct {
+		name string
+		args func(t *testing.T) []string
+		want string
+	}{
+		{
+			name: "missing required value",
+			args: func(t *testing.T) []string { return nil },
+			want: "parse: db.dsn is required",
+		},
+		{
+			name: "invalid flag value",
+			args: func(t *testing.T) []string { return []string{"--web-read-timeout", "soon"} },
+			want: `parse: invalid value "soon" for flag -web-read-timeout: time: invalid duration "soon"`,
+		},
+		{
+			name: "unknown flag",
+			args: func(t *testing.T) []string { return []string{"--web-port", "80"} },
+			want: "parse: flag provided but not defined: -web-port",
+		},
+		{
+			name: "positional argument",
+			args: func(t *testing.T) []string { return []string{"serve"} },
+			want: `parse: unexpected argument "serve"`,
+		},
+		{
+			name: "unknown file key",
+			args: func(t *testing.T) []string {
+				return []string{"--config", writeFile(t, "c.yaml", "web:\n  port: 80\n")}
+			},
+			want: `unknown key "web.port"`,
+		},
+		{
+			name: "unsupported file format",
+			args: func(t *testing.T) []string {
+				return []string{"--config", writeFile(t, "c.ini", "")}
+			},
+			want: `parse: load file: unsupported format ".ini"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var cfg testConfig
+			err := conf.Parse(&cfg, "TEST", tc.args(t))
+			assert.ErrorContains(t, err, tc.want)
+		})
+	}
+}
+
+func TestParse_InvalidEnv(t *testing.T) {
+	t.Setenv("TEST_DB_MAX_OPEN_CONNS", "many")
+
+	var cfg testConfig
+	err := conf.Parse(&cfg, "TEST", []string{"--db-dsn", "dsn"})
+	assert.ErrorContains(t, err, "parse: TEST_DB_MAX_OPEN_CONNS: ")
+}
+
+func TestParse_Validate(t *testing.T) {
+	var cfg validatedConfig
+	err := conf.Parse(&cfg, "TEST", []string{"--level", "debug"})
+	assert.EqualError(t, err, "validate: level must be info")
+}
+
+func TestParse_Help(t *testing.T) {
+	var cfg testConfig
+	err := conf.Parse(&cfg, "TEST", []string{"--help"})
+	assert.ErrorIs(t, err, conf.ErrHelp)
+}
+
+func TestString(t *testing.T) {
+	var cfg testConfig
+	require.NoError(t, conf.Parse(&cfg, "TEST", []string{"--db-dsn", "password=secret", "--web-cors-origins", "a, b"}))
+
+	s, err := conf.String(&cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, `web.addr=:3000
+web.read_timeout=5s
+web.cors_origins=a,b
+db.dsn=[redacted]
+db.max_open_conns=10
+db.migrate=true
+sample_ratio=1
+`, s)
+	assert.NotContains(t, s, "secret")
+}
+
+func TestUsage(t *testing.T) {
+	s, err := conf.Usage(&testConfig{}, "TEST")
+	require.NoError(t, err)
+
+	assert.Contains(t, s, "--config")
+	assert.Contains(t, s, "TEST_CONFIG")
+	assert.Regexp(t, `--web-addr\s+TEST_WEB_ADDR\s+listen address \(default ":3000"\)`, s)
+	assert.Regexp(t, `--db-dsn\s+TEST_DB_DSN\s+\(required\)`, s)
+}
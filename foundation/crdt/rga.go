@@ -0,0 +1,204 @@
+// Package crdt implements a replicated growable array (RGA), a text CRDT.
+// Every character is an element with a unique ID made of a Lamport clock and
+// the site that inserted it. Inserts reference the element they follow and
+// deletes leave a tombstone, so replicas that apply the same operations in
+// causal order end up with the same text whatever the order of concurrent
+// operations.
+package crdt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidOp      = errors.New("invalid operation")
+	ErrUnknownElement = errors.New("unknown element")
+	ErrDuplicateID    = errors.New("duplicate element id")
+)
+
+const (
+	OpInsert = "insert"
+	OpDelete = "delete"
+)
+
+// ID identifies an element. The zero ID stands for the start of the text.
+type ID struct {
+	Clock uint64 `json:"clock"`
+	Site  string `json:"site"`
+}
+
+func (id ID) IsZero() bool { return id == ID{} }
+
+func (id ID) String() string { return fmt.Sprintf("%d@%s", id.Clock, id.Site) }
+
+// precedes reports whether an element with id is placed before a concurrent
+// element with o that was inserted at the same position.
+func (id ID) precedes(o ID) bool {
+	if id.Clock != o.Clock {
+		return id.Clock > o.Clock
+	}
+	return id.Site > o.Site
+}
+
+// Op is an operation on the text. An insert adds the element ID with Char
+// after the element After, nil for the start of the text. A delete removes
+// the element ID.
+type Op struct {
+	Type  string `json:"op"`
+	ID    ID     `json:"id"`
+	After *ID    `json:"after,omitempty"`
+	Char  string `json:"char,omitempty"`
+}
+
+// Element is a character of the text. Deleted elements are kept, so that
+// operations that reference them can still be applied.
+type Element struct {
+	ID      ID     `json:"id"`
+	Char    string `json:"char"`
+	Deleted bool   `json:"deleted,omitempty"`
+}
+
+type node struct {
+	Element
+	next *node
+}
+
+// RGA is a replicated text. It is not safe for concurrent use.
+type RGA struct {
+	head  node
+	nodes map[ID]*node
+	clock uint64
+	size  int
+}
+
+func New() *RGA {
+	return &RGA{nodes: map[ID]*node{}}
+}
+
+// FromText returns a text with the characters of text inserted by site.
+func FromText(site, text string) *RGA {
+	r := New()
+	r.Insert(site, ID{}, text)
+	return r
+}
+
+// Clock returns the highest clock of the elements, new elements of a site
+// need a higher one.
+func (r *RGA) Clock() uint64 {
+	return r.clock
+}
+
+// Size returns the length of the text in bytes.
+func (r *RGA) Size() int {
+	return r.size
+}
+
+func (r *RGA) Text() string {
+	var b strings.Builder
+	b.Grow(r.size)
+	for n := r.head.next; n != nil; n = n.next {
+		if !n.Deleted {
+			b.WriteString(n.Char)
+		}
+	}
+	return b.String()
+}
+
+// Elements returns all elements in text order, including the deleted ones.
+func (r *RGA) Elements() []Element {
+	ret := make([]Element, 0, len(r.nodes))
+	for n := r.head.next; n != nil; n = n.next {
+		ret = append(ret, n.Element)
+	}
+	return ret
+}
+
+// Visible returns the IDs of the characters of the text, in order.
+func (r *RGA) Visible() []ID {
+	var ret []ID
+	for n := r.head.next; n != nil; n = n.next {
+		if !n.Deleted {
+			ret = append(ret, n.ID)
+		}
+	}
+	return ret
+}
+
+// Insert inserts text after the element after as site and returns the
+// operations that do so.
+func (r *RGA) Insert(site string, after ID, text string) []Op {
+	ops := make([]Op, 0, utf8.RuneCountInString(text))
+	for _, c := range text {
+		op := Op{Type: OpInsert, ID: ID{Clock: r.clock + 1, Site: site}, Char: string(c)}
+		if !after.IsZero() {
+			a := after
+			op.After = &a
+		}
+		// Can't fail, the ID is new and after exists.
+		_ = r.Apply(op)
+		ops = append(ops, op)
+		after = op.ID
+	}
+	return ops
+}
+
+// Delete deletes the element and returns the operation that does so.
+func (r *RGA) Delete(id ID) (Op, error) {
+	op := Op{Type: OpDelete, ID: id}
+	return op, r.Apply(op)
+}
+
+// Apply applies an operation. The elements it references must have been
+// inserted before. Deleting a deleted element does nothing.
+func (r *RGA) Apply(op Op) error {
+	switch op.Type {
+	case OpInsert:
+		return r.insert(op)
+	case OpDelete:
+		n, ok := r.nodes[op.ID]
+		if !ok {
+			return fmt.Errorf("delete: [%s]: %w", op.ID, ErrUnknownElement)
+		}
+		if !n.Deleted {
+			n.Deleted = true
+			r.size -= len(n.Char)
+		}
+		return nil
+	}
+	return fmt.Errorf("apply: %w: unknown type %q", ErrInvalidOp, op.Type)
+}
+
+func (r *RGA) insert(op Op) error {
+	if op.ID.Clock == 0 || op.ID.Site == "" {
+		return fmt.Errorf("insert: [%s]: %w: clock and site are required", op.ID, ErrInvalidOp)
+	}
+	if !utf8.ValidString(op.Char) || utf8.RuneCountInString(op.Char) != 1 {
+		return fmt.Errorf("insert: [%s]: %w: char must be a single character", op.ID, ErrInvalidOp)
+	}
+	if _, ok := r.nodes[op.ID]; ok {
+		return fmt.Errorf("insert: [%s]: %w", op.ID, ErrDuplicateID)
+	}
+
+	prev := &r.head
+	if op.After != nil && !op.After.IsZero() {
+		var ok bool
+		if prev, ok = r.nodes[*op.After]; !ok {
+			return fmt.Errorf("insert: [%s]: after [%s]: %w", op.ID, *op.After, ErrUnknownElement)
+		}
+	}
+	// Concurrent inserts at the same position, and the elements inserted
+	// after them, come first if their ID is greater.
+	for prev.next != nil && prev.next.ID.precedes(op.ID) {
+		prev = prev.next
+	}
+
+	n := &node{Element: Element{ID: op.ID, Char: op.Char}, next: prev.next}
+	prev.next = n
+	r.nodes[op.ID] = n
+	r.size += len(op.Char)
+	r.clock = max(r.clock, op.ID.Clock)
+	return nil
+}
@@ -0,0 +1,138 @@
+package crdt_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/Keisn1/note-taking-app/foundation/crdt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// replica copies the elements of r, as a client does on connect.
+func replica(t *testing.T, r *crdt.RGA) *crdt.RGA {
+	t.Helper()
+	c := crdt.New()
+	var after *crdt.ID
+	for _, e := range r.Elements() {
+		require.NoError(t, c.Apply(crdt.Op{Type: crdt.OpInsert, ID: e.ID, After: after, Char: e.Char}))
+		if e.Deleted {
+			require.NoError(t, c.Apply(crdt.Op{Type: crdt.OpDelete, ID: e.ID}))
+		}
+		id := e.ID
+		after = &id
+	}
+	return c
+}
+
+func applyAll(t *testing.T, r *crdt.RGA, ops ...[]crdt.Op) {
+	t.Helper()
+	for _, o := range ops {
+		for _, op := range o {
+			require.NoError(t, r.Apply(op))
+		}
+	}
+}
+
+func TestRGA_FromText(t *testing.T) {
+	r := crdt.FromText("server", "héllo")
+	assert.Equal(t, "héllo", r.Text())
+	assert.Equal(t, 6, r.Size())
+	assert.Equal(t, uint64(5), r.Clock())
+	assert.Len(t, r.Visible(), 5)
+
+	op, err := r.Delete(r.Visible()[1])
+	require.NoError(t, err)
+	assert.Equal(t, crdt.OpDelete, op.Type)
+	assert.Equal(t, "hllo", r.Text())
+	assert.Equal(t, 4, r.Size())
+	assert.Len(t, r.Elements(), 5)
+
+	// Deleting again does nothing.
+	_, err = r.Delete(op.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "hllo", replica(t, r).Text())
+}
+
+func TestRGA_Concurrent(t *testing.T) {
+	base := crdt.FromText("server", "ac")
+	a, c := base.Visible()[0], base.Visible()[1]
+
+	alice, bob := replica(t, base), replica(t, base)
+	fromAlice := alice.Insert("alice", a, "b")
+	del, err := alice.Delete(c)
+	require.NoError(t, err)
+	fromAlice = append(fromAlice, del)
+	fromBob := append(bob.Insert("bob", a, "B"), bob.Insert("bob", c, "d")...)
+
+	applyAll(t, alice, fromBob)
+	applyAll(t, bob, fromAlice)
+	applyAll(t, base, fromBob, fromAlice)
+
+	// Same clock, the greater site comes first.
+	assert.Equal(t, "aBbd", alice.Text())
+	assert.Equal(t, alice.Text(), bob.Text())
+	assert.Equal(t, alice.Text(), base.Text())
+	assert.Equal(t, alice.Elements(), bob.Elements())
+}
+
+func TestRGA_Convergence(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	base := crdt.FromText("server", "the quick brown fox")
+
+	sites := []string{"a", "b", "c"}
+	replicas := make([]*crdt.RGA, len(sites))
+	ops := make([][]crdt.Op, len(sites))
+	for i, site := range sites {
+		r := replica(t, base)
+		for range 50 {
+			visible := r.Visible()
+			if len(visible) > 0 && rnd.Intn(3) == 0 {
+				op, err := r.Delete(visible[rnd.Intn(len(visible))])
+				require.NoError(t, err)
+				ops[i] = append(ops[i], op)
+				continue
+			}
+			after := crdt.ID{}
+			if n := rnd.Intn(len(visible) + 1); n > 0 {
+				after = visible[n-1]
+			}
+			ops[i] = append(ops[i], r.Insert(site, after, string(rune('a'+rnd.Intn(26))))...)
+		}
+		replicas[i] = r
+	}
+
+	applyAll(t, replicas[0], ops[1], ops[2])
+	applyAll(t, replicas[1], ops[2], ops[0])
+	applyAll(t, replicas[2], ops[0], ops[1])
+	for _, r := range replicas[1:] {
+		assert.Equal(t, replicas[0].Text(), r.Text())
+		assert.Equal(t, replicas[0].Elements(), r.Elements())
+	}
+}
+
+func TestRGA_Apply_Errors(t *testing.T) {
+	r := crdt.FromText("server", "a")
+	unknown := crdt.ID{Clock: 7, Site: "alice"}
+
+	testCases := []struct {
+		name string
+		op   crdt.Op
+		want error
+	}{
+		{name: "Unknown type", op: crdt.Op{Type: "move"}, want: crdt.ErrInvalidOp},
+		{name: "Zero ID", op: crdt.Op{Type: crdt.OpInsert, Char: "x"}, want: crdt.ErrInvalidOp},
+		{name: "No character", op: crdt.Op{Type: crdt.OpInsert, ID: crdt.ID{Clock: 2, Site: "alice"}}, want: crdt.ErrInvalidOp},
+		{name: "Several characters", op: crdt.Op{Type: crdt.OpInsert, ID: crdt.ID{Clock: 2, Site: "alice"}, Char: "xy"}, want: crdt.ErrInvalidOp},
+		{name: "Invalid UTF-8", op: crdt.Op{Type: crdt.OpInsert, ID: crdt.ID{Clock: 2, Site: "alice"}, Char: "\xff"}, want: crdt.ErrInvalidOp},
+		{name: "Duplicate ID", op: crdt.Op{Type: crdt.OpInsert, ID: r.Visible()[0], Char: "x"}, want: crdt.ErrDuplicateID},
+		{name: "Insert after unknown element", op: crdt.Op{Type: crdt.OpInsert, ID: crdt.ID{Clock: 2, Site: "alice"}, After: &unknown, Char: "x"}, want: crdt.ErrUnknownElement},
+		{name: "Delete unknown element", op: crdt.Op{Type: crdt.OpDelete, ID: unknown}, want: crdt.ErrUnknownElement},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.ErrorIs(t, r.Apply(tc.op), tc.want)
+			assert.Equal(t, "a", r.Text())
+		})
+	}
+}
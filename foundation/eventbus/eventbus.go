@@ -0,0 +1,178 @@
+// Package eventbus delivers events within the process to subscribers and
+// keeps the latest of them in a bounded log, so that subscribers that
+// reconnect can resume where they left off.
+package eventbus
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultBuffer is the number of events a subscription holds before it is
+// closed as lagging.
+const DefaultBuffer = 64
+
+// Event is a published event. Seq increases by one with every event
+// published on the bus. Topic decides which subscriptions receive it.
+type Event struct {
+	Seq   uint64
+	Type  string
+	Topic string
+	Data  any
+	Time  time.Time
+}
+
+// Bus is an in-process event bus. Sequence numbers start over when the
+// process restarts; Epoch tells the lifetimes of buses apart.
+type Bus struct {
+	mu     sync.Mutex
+	epoch  int64
+	seq    uint64
+	log    []Event
+	start  int
+	buffer int
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+// New returns a bus that keeps the last size events.
+func New(size int) *Bus {
+	return &Bus{
+		epoch:  time.Now().UnixNano(),
+		log:    make([]Event, 0, max(size, 1)),
+		buffer: DefaultBuffer,
+		subs:   map[*Subscription]struct{}{},
+	}
+}
+
+// Epoch identifies the bus. It differs for buses created at different
+// times, so it does between restarts of the process.
+func (b *Bus) Epoch() int64 {
+	return b.epoch
+}
+
+// Publish appends the event to the log and delivers it to the subscriptions
+// to its topic. It never blocks: a subscription that can't take the event is
+// closed as lagging.
+func (b *Bus) Publish(typ, topic string, data any) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	e := Event{Seq: b.seq, Type: typ, Topic: topic, Data: data, Time: time.Now()}
+	if len(b.log) < cap(b.log) {
+		b.log = append(b.log, e)
+	} else {
+		b.log[b.start] = e
+		b.start = (b.start + 1) % len(b.log)
+	}
+
+	for s := range b.subs {
+		if s.topic != topic {
+			continue
+		}
+		select {
+		case s.c <- e:
+		default:
+			s.lagged = true
+			b.remove(s)
+		}
+	}
+	return e
+}
+
+// Subscribe subscribes to the events of topic published from now on.
+func (b *Bus) Subscribe(topic string) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.subscribe(topic)
+}
+
+// Resume subscribes to the events of topic published after the event with
+// sequence number after, and returns those of them that are still in the log
+// for replay. ok is false if that doesn't cover all of them, because some were
+// dropped from the log or after is from another epoch; the subscription then
+// only receives the events published from now on.
+func (b *Bus) Resume(topic string, after uint64) (_ *Subscription, replay []Event, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	oldest := b.seq - uint64(len(b.log)) + 1
+	if after > b.seq || after+1 < oldest {
+		return b.subscribe(topic), nil, false
+	}
+	for i := range b.log {
+		e := b.log[(b.start+i)%len(b.log)]
+		if e.Seq > after && e.Topic == topic {
+			replay = append(replay, e)
+		}
+	}
+	return b.subscribe(topic), replay, true
+}
+
+// Close closes all subscriptions and those made later, so that subscribers
+// stop when the process shuts down.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for s := range b.subs {
+		b.remove(s)
+	}
+}
+
+// subscribe must be called with b.mu held.
+func (b *Bus) subscribe(topic string) *Subscription {
+	s := &Subscription{bus: b, topic: topic, start: b.seq, c: make(chan Event, b.buffer)}
+	if b.closed {
+		close(s.c)
+		return s
+	}
+	b.subs[s] = struct{}{}
+	return s
+}
+
+// remove must be called with b.mu held.
+func (b *Bus) remove(s *Subscription) {
+	if _, ok := b.subs[s]; ok {
+		delete(b.subs, s)
+		close(s.c)
+	}
+}
+
+// Subscription receives the events of a topic.
+type Subscription struct {
+	bus    *Bus
+	topic  string
+	start  uint64
+	c      chan Event
+	lagged bool
+}
+
+// Start returns the sequence number of the latest event published on the
+// bus when the subscription was made. The subscription receives the events
+// after it.
+func (s *Subscription) Start() uint64 {
+	return s.start
+}
+
+// Events returns the events. The channel is closed when the subscription is
+// closed, by Close or because it lagged behind.
+func (s *Subscription) Events() <-chan Event {
+	return s.c
+}
+
+// Lagged reports whether the subscription was closed because it didn't take
+// events fast enough. The events it missed are in the log of the bus.
+func (s *Subscription) Lagged() bool {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	return s.lagged
+}
+
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	s.bus.remove(s)
+}
@@ -0,0 +1,106 @@
+package eventbus_test
+
+import (
+	"testing"
+
+	"github.com/Keisn1/note-taking-app/foundation/eventbus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seqs(events []eventbus.Event) []uint64 {
+	ret := []uint64{}
+	for _, e := range events {
+		ret = append(ret, e.Seq)
+	}
+	return ret
+}
+
+func TestBus_Publish(t *testing.T) {
+	b := eventbus.New(10)
+
+	b.Publish("note.created", "rob", "before")
+	rob := b.Subscribe("rob")
+	defer rob.Close()
+	assert.Equal(t, uint64(1), rob.Start())
+	anna := b.Subscribe("anna")
+	defer anna.Close()
+
+	b.Publish("note.created", "rob", "groceries")
+	b.Publish("note.created", "anna", "plan")
+	b.Publish("note.deleted", "rob", "groceries")
+
+	e := <-rob.Events()
+	assert.Equal(t, eventbus.Event{Seq: 2, Type: "note.created", Topic: "rob", Data: "groceries", Time: e.Time}, e)
+	assert.Equal(t, uint64(4), (<-rob.Events()).Seq)
+	assert.Equal(t, uint64(3), (<-anna.Events()).Seq)
+	assert.Empty(t, anna.Events())
+}
+
+func TestBus_Resume(t *testing.T) {
+	b := eventbus.New(3)
+	for range 5 {
+		b.Publish("note.updated", "rob", nil)
+	}
+	b.Publish("note.updated", "anna", nil)
+
+	testCases := []struct {
+		name   string
+		after  uint64
+		replay []uint64
+		ok     bool
+	}{
+		{name: "Up to date", after: 6, replay: []uint64{}, ok: true},
+		{name: "Replay from the log", after: 4, replay: []uint64{5}, ok: true},
+		{name: "Oldest event in the log", after: 3, replay: []uint64{4, 5}, ok: true},
+		{name: "Events dropped from the log", after: 1, replay: []uint64{}, ok: false},
+		{name: "Sequence number from the future", after: 7, replay: []uint64{}, ok: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, replay, ok := b.Resume("rob", tc.after)
+			defer s.Close()
+			assert.Equal(t, tc.replay, seqs(replay))
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, uint64(6), s.Start())
+		})
+	}
+}
+
+func TestBus_Lagging(t *testing.T) {
+	b := eventbus.New(100)
+	s := b.Subscribe("rob")
+
+	for range eventbus.DefaultBuffer + 1 {
+		b.Publish("note.updated", "rob", nil)
+	}
+
+	n := 0
+	for range s.Events() {
+		n++
+	}
+	assert.Equal(t, eventbus.DefaultBuffer, n)
+	assert.True(t, s.Lagged())
+
+	// Closing again is a no-op.
+	s.Close()
+
+	s, replay, ok := b.Resume("rob", uint64(n))
+	defer s.Close()
+	require.True(t, ok)
+	assert.Equal(t, []uint64{eventbus.DefaultBuffer + 1}, seqs(replay))
+	assert.False(t, s.Lagged())
+}
+
+func TestBus_Close(t *testing.T) {
+	b := eventbus.New(10)
+	s := b.Subscribe("rob")
+
+	b.Close()
+	_, ok := <-s.Events()
+	assert.False(t, ok)
+	assert.False(t, s.Lagged())
+
+	_, ok = <-b.Subscribe("rob").Events()
+	assert.False(t, ok)
+}
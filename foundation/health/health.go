@@ -0,0 +1,92 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK       = "ok"
+	StatusFailing  = "failing"
+	StatusDraining = "draining"
+)
+
+// Check reports whether a dependency of the service is usable.
+type Check struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// CheckResult is served to anyone asking. Err is left out of the JSON as it
+// may reveal hosts and users of the dependency, it's meant for the logs.
+type CheckResult struct {
+	Status string `json:"status"`
+	Err    error  `json:"-"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Readiness runs the readiness checks of the service. Once Drain is called it
+// reports the service as not ready, so that no new traffic is routed to it
+// during a graceful shutdown.
+type Readiness struct {
+	checks   []Check
+	timeout  time.Duration
+	draining atomic.Bool
+}
+
+func NewReadiness(timeout time.Duration, checks ...Check) *Readiness {
+	return &Readiness{checks: checks, timeout: timeout}
+}
+
+func (rd *Readiness) Drain() {
+	rd.draining.Store(true)
+}
+
+func (rd *Readiness) Draining() bool {
+	return rd.draining.Load()
+}
+
+// Check runs all checks concurrently. The report has status StatusOK only if
+// all checks pass and the service is not draining.
+func (rd *Readiness) Check(ctx context.Context) Report {
+	if rd.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rd.timeout)
+		defer cancel()
+	}
+
+	results := make(map[string]CheckResult, len(rd.checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range rd.checks {
+		wg.Add(1)
+		go func(c Check) {
+			defer wg.Done()
+			res := CheckResult{Status: StatusOK}
+			if err := c.Check(ctx); err != nil {
+				res = CheckResult{Status: StatusFailing, Err: err}
+			}
+			mu.Lock()
+			results[c.Name] = res
+			mu.Unlock()
+		}(c)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: results}
+	for _, res := range results {
+		if res.Status != StatusOK {
+			report.Status = StatusFailing
+		}
+	}
+	if rd.Draining() {
+		report.Status = StatusDraining
+	}
+	return report
+}
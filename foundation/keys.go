@@ -6,4 +6,5 @@ const (
 	UserIDKey contextKey = iota
 	ClaimsKey
 	NoteKey
+	AuditKey
 )
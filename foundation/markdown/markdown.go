@@ -0,0 +1,99 @@
+// Package markdown renders CommonMark with the GitHub extensions (tables,
+// task lists, strikethrough, autolinks) to sanitised HTML.
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// Heading is an entry of the table of contents. ID is the id attribute of the
+// heading in the HTML, so that it can be linked as #ID.
+type Heading struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+	ID    string `json:"id"`
+}
+
+type Document struct {
+	HTML string
+	TOC  []Heading
+}
+
+// Raw HTML in the source is dropped by goldmark, which doesn't render it
+// without html.WithUnsafe. The output is sanitised anyway, e.g. against
+// javascript: links.
+var md = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+)
+
+var policy = newPolicy()
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("id").Matching(regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)).OnElements("h1", "h2", "h3", "h4", "h5", "h6")
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").Matching(regexp.MustCompile(`^(|checked|disabled)$`)).OnElements("input")
+	p.AllowAttrs("style").Matching(regexp.MustCompile(`^text-align:(left|center|right)$`)).OnElements("th", "td")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+-]+$`)).OnElements("code")
+	return p
+}
+
+// Render renders the Markdown source and extracts its headings.
+func Render(src string) (Document, error) {
+	source := []byte(src)
+	root := md.Parser().Parse(text.NewReader(source))
+
+	var toc []Heading
+	err := ast.Walk(root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		h, ok := n.(*ast.Heading)
+		if !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+		id, _ := h.AttributeString("id")
+		idS, _ := id.([]byte)
+		toc = append(toc, Heading{Level: h.Level, Text: plainText(h, source), ID: string(idS)})
+		return ast.WalkSkipChildren, nil
+	})
+	if err != nil {
+		return Document{}, fmt.Errorf("render: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := md.Renderer().Render(&buf, source, root); err != nil {
+		return Document{}, fmt.Errorf("render: %w", err)
+	}
+
+	return Document{HTML: policy.Sanitize(buf.String()), TOC: toc}, nil
+}
+
+// plainText returns the text of the node without markup.
+func plainText(n ast.Node, source []byte) string {
+	var sb strings.Builder
+	ast.Walk(n, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch t := n.(type) {
+		case *ast.Text:
+			sb.Write(t.Segment.Value(source))
+			if t.SoftLineBreak() || t.HardLineBreak() {
+				sb.WriteByte(' ')
+			}
+		case *ast.String:
+			sb.Write(t.Value)
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.TrimSpace(sb.String())
+}
@@ -0,0 +1,66 @@
+package markdown_test
+
+import (
+	"testing"
+
+	"github.com/Keisn1/note-taking-app/foundation/markdown"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Render(t *testing.T) {
+	src := "# Intro\n\n" +
+		"| a | b |\n|:--|--:|\n| 1 | 2 |\n\n" +
+		"- [x] done\n- [ ] open\n\n" +
+		"~~old~~ https://example.com\n\n" +
+		"```go\nfmt.Println()\n```\n\n" +
+		"## Details *in* `code`\n\n" +
+		"## Intro\n"
+
+	doc, err := markdown.Render(src)
+	require.NoError(t, err)
+
+	assert.Equal(t, []markdown.Heading{
+		{Level: 1, Text: "Intro", ID: "intro"},
+		{Level: 2, Text: "Details in code", ID: "details-in-code"},
+		{Level: 2, Text: "Intro", ID: "intro-1"},
+	}, doc.TOC)
+
+	for _, want := range []string{
+		`<h1 id="intro">Intro</h1>`,
+		`<th style="text-align:left">a</th>`,
+		`<td style="text-align:right">2</td>`,
+		`<input checked="" disabled="" type="checkbox"> done`,
+		`<input disabled="" type="checkbox"> open`,
+		`<del>old</del>`,
+		`<a href="https://example.com" rel="nofollow">https://example.com</a>`,
+		`<code class="language-go">`,
+		`<h2 id="intro-1">Intro</h2>`,
+	} {
+		assert.Contains(t, doc.HTML, want)
+	}
+}
+
+func Test_Render_Sanitizes(t *testing.T) {
+	testCases := []struct {
+		name string
+		src  string
+	}{
+		{name: "script", src: "<script>alert(1)</script>"},
+		{name: "inline html", src: `<img src="x" onerror="alert(1)">`},
+		{name: "javascript link", src: "[click](javascript:alert(1))"},
+		{name: "javascript image", src: "![x](javascript:alert(1))"},
+		{name: "html in heading", src: `# <span onclick="alert(1)">x</span>`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := markdown.Render(tc.src)
+			require.NoError(t, err)
+			assert.NotContains(t, doc.HTML, "<script")
+			assert.NotContains(t, doc.HTML, "onerror=")
+			assert.NotContains(t, doc.HTML, "onclick=")
+			assert.NotContains(t, doc.HTML, "javascript:")
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Keisn1/note-taking-app/foundation/tracing"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestInit(t *testing.T) {
+	t.Run("Stdout exporter writes spans", func(t *testing.T) {
+		var buf bytes.Buffer
+		shutdown, err := tracing.Init(context.Background(), tracing.Config{
+			ServiceName: "notes-test",
+			Exporter:    tracing.ExporterStdout,
+			SampleRatio: 1,
+			Writer:      &buf,
+		})
+		assert.NoError(t, err)
+
+		_, span := otel.Tracer("test").Start(context.Background(), "test-span")
+		span.End()
+
+		assert.NoError(t, shutdown(context.Background()))
+		assert.Contains(t, buf.String(), `"Name":"test-span"`)
+		assert.Contains(t, buf.String(), "notes-test")
+	})
+
+	t.Run("Unknown exporter", func(t *testing.T) {
+		_, err := tracing.Init(context.Background(), tracing.Config{Exporter: "zipkin"})
+		assert.EqualError(t, err, `init: unknown exporter "zipkin"`)
+	})
+}
+
+func TestEnd(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	f := func(fail error) (err error) {
+		_, span := tracer.Start(context.Background(), "f")
+		defer tracing.End(span, &err)
+		return fail
+	}
+
+	assert.NoError(t, f(nil))
+	assert.Error(t, f(errors.New("failed")))
+	assert.Error(t, f(context.Canceled))
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 3)
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, "failed", spans[1].Status().Description)
+	assert.Equal(t, codes.Unset, spans[2].Status().Code)
+}
@@ -0,0 +1,57 @@
+package transaction
+
+import (
+	"context"
+	"sync"
+)
+
+// Snapshotter is implemented by the memory repositories. Snapshot copies
+// their state and returns a function that restores it.
+type Snapshotter interface {
+	Snapshot() (restore func())
+}
+
+// Memory runs the functions one at a time and restores the repositories
+// if they fail, for tests. Changes made outside of the transaction while
+// it runs are undone too.
+type Memory struct {
+	mu    *sync.Mutex
+	repos []Snapshotter
+}
+
+func NewMemory(repos ...Snapshotter) Memory {
+	return Memory{mu: &sync.Mutex{}, repos: repos}
+}
+
+func (m Memory) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if InTx(ctx) {
+		return fn(ctx)
+	}
+
+	txCtx, committed := withAfterCommit(context.WithValue(ctx, txKey{}, memoryTx{}))
+	if err := m.run(txCtx, fn); err != nil {
+		return err
+	}
+	committed(ctx)
+	return nil
+}
+
+func (m Memory) run(ctx context.Context, fn func(ctx context.Context) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	restores := make([]func(), 0, len(m.repos))
+	for _, r := range m.repos {
+		restores = append(restores, r.Snapshot())
+	}
+
+	if err := fn(ctx); err != nil {
+		for _, restore := range restores {
+			restore()
+		}
+		return err
+	}
+	return nil
+}
+
+type memoryTx struct{}
@@ -0,0 +1,94 @@
+package transaction_test
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"testing"
+
+	"github.com/Keisn1/note-taking-app/foundation/transaction"
+	"github.com/stretchr/testify/assert"
+)
+
+// store is a repository that can be snapshotted.
+type store map[string]int
+
+func (s store) Snapshot() func() {
+	saved := maps.Clone(s)
+	return func() {
+		clear(s)
+		maps.Copy(s, saved)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	a, b := store{"x": 1}, store{}
+	m := transaction.NewMemory(a, b)
+
+	t.Run("Changes are kept if fn succeeds", func(t *testing.T) {
+		err := m.WithinTx(ctx, func(ctx context.Context) error {
+			assert.True(t, transaction.InTx(ctx))
+			a["x"], b["y"] = 2, 2
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, store{"x": 2}, a)
+		assert.Equal(t, store{"y": 2}, b)
+	})
+
+	t.Run("All repositories are restored if fn fails", func(t *testing.T) {
+		failed := errors.New("failed")
+		err := m.WithinTx(ctx, func(ctx context.Context) error {
+			a["x"], b["y"] = 3, 3
+			delete(a, "x")
+			return failed
+		})
+		assert.ErrorIs(t, err, failed)
+		assert.Equal(t, store{"x": 2}, a)
+		assert.Equal(t, store{"y": 2}, b)
+	})
+
+	t.Run("Nested calls join the outer transaction", func(t *testing.T) {
+		failed := errors.New("failed")
+		err := m.WithinTx(ctx, func(ctx context.Context) error {
+			err := m.WithinTx(ctx, func(ctx context.Context) error {
+				a["x"] = 4
+				return nil
+			})
+			assert.NoError(t, err)
+			return failed
+		})
+		assert.ErrorIs(t, err, failed)
+		assert.Equal(t, store{"x": 2}, a)
+	})
+
+	t.Run("Functions deferred to the commit run after it", func(t *testing.T) {
+		var ran []string
+		transaction.AfterCommit(ctx, func(ctx context.Context) { ran = append(ran, "outside") })
+		assert.Equal(t, []string{"outside"}, ran)
+
+		err := m.WithinTx(ctx, func(ctx context.Context) error {
+			transaction.AfterCommit(ctx, func(ctx context.Context) {
+				assert.False(t, transaction.InTx(ctx))
+				ran = append(ran, "committed")
+			})
+			return m.WithinTx(ctx, func(ctx context.Context) error {
+				transaction.AfterCommit(ctx, func(ctx context.Context) { ran = append(ran, "nested") })
+				assert.Len(t, ran, 1)
+				return nil
+			})
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"outside", "committed", "nested"}, ran)
+
+		err = m.WithinTx(ctx, func(ctx context.Context) error {
+			transaction.AfterCommit(ctx, func(ctx context.Context) { ran = append(ran, "rolled back") })
+			return errors.New("failed")
+		})
+		assert.Error(t, err)
+		assert.Len(t, ran, 3)
+	})
+
+	assert.False(t, transaction.InTx(ctx))
+}
@@ -0,0 +1,38 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Postgres runs the functions in transactions of db.
+type Postgres struct {
+	db *sql.DB
+}
+
+func NewPostgres(db *sql.DB) Postgres {
+	return Postgres{db: db}
+}
+
+func (p Postgres) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if InTx(ctx) {
+		return fn(ctx)
+	}
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("withinTx: begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	txCtx, committed := withAfterCommit(context.WithValue(ctx, txKey{}, tx))
+	if err := fn(txCtx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("withinTx: commit: %w", err)
+	}
+	committed(ctx)
+	return nil
+}
@@ -0,0 +1,96 @@
+// Package transaction runs several repository operations atomically. A
+// Manager starts the transaction and passes it on in the context, the
+// repositories take their connection from there.
+package transaction
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Manager runs functions in a transaction.
+type Manager interface {
+	// WithinTx runs fn in a transaction that is committed if fn returns nil
+	// and rolled back otherwise. The repositories called with the ctx
+	// given to fn join it; so do nested calls of WithinTx.
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Conn is implemented by *sql.DB and *sql.Tx.
+type Conn interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// DB is implemented by *sql.DB.
+type DB interface {
+	Conn
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+type (
+	txKey          struct{}
+	afterCommitKey struct{}
+)
+
+// InTx tells if ctx runs in a transaction of a Manager.
+func InTx(ctx context.Context) bool {
+	return ctx.Value(txKey{}) != nil
+}
+
+// AfterCommit runs fn once the transaction of the Manager that ctx runs in
+// is committed, and not at all if it is rolled back. Outside of one fn runs
+// right away. It is meant for changes that a rollback can't undo, like
+// deleting files.
+func AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	fns, ok := ctx.Value(afterCommitKey{}).(*[]func(context.Context))
+	if !ok {
+		fn(ctx)
+		return
+	}
+	*fns = append(*fns, fn)
+}
+
+// withAfterCommit prepares ctx of a new transaction for AfterCommit. The
+// returned function runs the collected functions.
+func withAfterCommit(ctx context.Context) (context.Context, func(ctx context.Context)) {
+	var fns []func(context.Context)
+	committed := func(ctx context.Context) {
+		for _, fn := range fns {
+			fn(ctx)
+		}
+	}
+	return context.WithValue(ctx, afterCommitKey{}, &fns), committed
+}
+
+// ConnFrom returns the Postgres transaction ctx runs in, or db outside of
+// one.
+func ConnFrom(ctx context.Context, db Conn) Conn {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// Run runs fn in the Postgres transaction ctx runs in, or else in a new
+// transaction on db that is committed if fn returns nil. Repositories use
+// it for changes of several statements. If fn fails within the
+// transaction of ctx its statements are only undone once that one is
+// rolled back.
+func Run(ctx context.Context, db DB, fn func(tx Conn) error) error {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(tx)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
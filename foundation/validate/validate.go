@@ -0,0 +1,75 @@
+package validate
+
+import (
+	"net/mail"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type FieldError struct {
+	Field string `json:"field"`
+	Error string `json:"error"`
+}
+
+type FieldErrors []FieldError
+
+func (fe FieldErrors) Error() string {
+	msgs := make([]string, len(fe))
+	for i, e := range fe {
+		msgs[i] = e.Field + ": " + e.Error
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Validator collects field errors. Only the first error of each field is kept.
+type Validator struct {
+	errs FieldErrors
+}
+
+func (v *Validator) Check(ok bool, field, msg string) {
+	if ok {
+		return
+	}
+	for _, e := range v.errs {
+		if e.Field == field {
+			return
+		}
+	}
+	v.errs = append(v.errs, FieldError{Field: field, Error: msg})
+}
+
+// Err returns the collected FieldErrors or nil if there are none.
+func (v *Validator) Err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+func (v *Validator) Required(field, value string) {
+	v.Check(strings.TrimSpace(value) != "", field, "is required")
+}
+
+// Text checks that the value is valid UTF-8 and contains no NUL characters.
+func (v *Validator) Text(field, value string) {
+	v.Check(utf8.ValidString(value) && !strings.ContainsRune(value, 0), field, "must be valid UTF-8 text")
+}
+
+func (v *Validator) MaxLength(field, value string, max int) {
+	v.Check(utf8.RuneCountInString(value) <= max, field, "must be at most "+strconv.Itoa(max)+" characters")
+}
+
+func (v *Validator) MinBytes(field, value string, min int) {
+	v.Check(len(value) >= min, field, "must be at least "+strconv.Itoa(min)+" bytes")
+}
+
+func (v *Validator) MaxBytes(field, value string, max int) {
+	v.Check(len(value) <= max, field, "must be at most "+strconv.Itoa(max)+" bytes")
+}
+
+// Email checks that the value is a single RFC 5322 address.
+func (v *Validator) Email(field, value string) {
+	_, err := mail.ParseAddress(value)
+	v.Check(err == nil, field, "must be a valid email address")
+}
@@ -0,0 +1,98 @@
+package validate_test
+
+import (
+	"testing"
+
+	"github.com/Keisn1/note-taking-app/foundation/validate"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidator(t *testing.T) {
+	testCases := []struct {
+		name  string
+		check func(v *validate.Validator)
+		want  string
+	}{
+		{
+			name:  "Required accepts a value",
+			check: func(v *validate.Validator) { v.Required("name", "Rob") },
+		},
+		{
+			name:  "Required rejects blank values",
+			check: func(v *validate.Validator) { v.Required("name", " \t\n") },
+			want:  "name: is required",
+		},
+		{
+			name:  "MaxBytes accepts the limit",
+			check: func(v *validate.Validator) { v.MaxBytes("password", "abcd", 4) },
+		},
+		{
+			name:  "MaxBytes counts bytes, not characters",
+			check: func(v *validate.Validator) { v.MaxBytes("password", "äöü", 4) },
+			want:  "password: must be at most 4 bytes",
+		},
+		{
+			name:  "MinBytes rejects short values",
+			check: func(v *validate.Validator) { v.MinBytes("password", "abc", 4) },
+			want:  "password: must be at least 4 bytes",
+		},
+		{
+			name:  "MaxLength counts characters, not bytes",
+			check: func(v *validate.Validator) { v.MaxLength("title", "äöü", 3) },
+		},
+		{
+			name:  "MaxLength rejects long values",
+			check: func(v *validate.Validator) { v.MaxLength("title", "abcd", 3) },
+			want:  "title: must be at most 3 characters",
+		},
+		{
+			name:  "Email accepts an address",
+			check: func(v *validate.Validator) { v.Email("email", "Rob <rob@example.com>") },
+		},
+		{
+			name:  "Email rejects invalid addresses",
+			check: func(v *validate.Validator) { v.Email("email", "rob@") },
+			want:  "email: must be a valid email address",
+		},
+		{
+			name:  "Email rejects lists of addresses",
+			check: func(v *validate.Validator) { v.Email("email", "rob@example.com, ann@example.com") },
+			want:  "email: must be a valid email address",
+		},
+		{
+			name:  "Text accepts UTF-8",
+			check: func(v *validate.Validator) { v.Text("content", "Grüße 👋") },
+		},
+		{
+			name:  "Text rejects invalid UTF-8",
+			check: func(v *validate.Validator) { v.Text("content", "a\xffb") },
+			want:  "content: must be valid UTF-8 text",
+		},
+		{
+			name:  "Text rejects NUL characters",
+			check: func(v *validate.Validator) { v.Text("content", "a\x00b") },
+			want:  "content: must be valid UTF-8 text",
+		},
+		{
+			name: "Only the first error of a field is kept",
+			check: func(v *validate.Validator) {
+				v.Required("title", "")
+				v.MinBytes("title", "", 1)
+				v.Email("email", "")
+			},
+			want: "title: is required; email: must be a valid email address",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var v validate.Validator
+			tc.check(&v)
+			if tc.want == "" {
+				assert.NoError(t, v.Err())
+				return
+			}
+			assert.EqualError(t, v.Err(), tc.want)
+		})
+	}
+}
@@ -1,19 +1,136 @@
 package web
 
-import "net/http"
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
 
 type App struct {
-	mux *http.ServeMux
+	mux     *http.ServeMux
+	mw      []MidHandler
+	handler http.Handler // mux wrapped in mw
 }
 
-func NewApp() *App {
-	return &App{mux: http.NewServeMux()}
+func NewApp(mw ...MidHandler) *App {
+	a := &App{mux: http.NewServeMux(), mw: mw}
+	a.handler = wrapMiddleware(a.mw, a.mux)
+	return a
 }
 
-func (a *App) Handle(path string, handler http.Handler) {
-	a.mux.Handle(path, handler)
+// Use adds middleware that runs for every request served by the app,
+// including requests that don't match any route. It must not be called
+// while the app serves requests.
+func (a *App) Use(mw ...MidHandler) {
+	a.mw = append(a.mw, mw...)
+	a.handler = wrapMiddleware(a.mw, a.mux)
+}
+
+// Group returns a route group whose routes share the prefix and middleware.
+func (a *App) Group(prefix string, mw ...MidHandler) *Group {
+	return &Group{app: a, prefix: prefix, mw: mw}
+}
+
+// Handle registers the handler for the given pattern. The pattern follows
+// the http.ServeMux syntax, e.g. "GET /notes/{note_id}".
+func (a *App) Handle(pattern string, handler http.Handler, mw ...MidHandler) {
+	handler = wrapMiddleware(mw, handler)
+
+	h := func(w http.ResponseWriter, r *http.Request) {
+		if v := GetValues(r.Context()); v != nil {
+			v.Route = pattern
+			v.Logger = v.Logger.With("route", pattern)
+		}
+		handler.ServeHTTP(w, r)
+	}
+	a.mux.HandleFunc(pattern, h)
+}
+
+func (a *App) HandleFunc(pattern string, handler http.HandlerFunc, mw ...MidHandler) {
+	a.Handle(pattern, handler, mw...)
+}
+
+func (a *App) Get(path string, handler http.HandlerFunc, mw ...MidHandler) {
+	a.HandleFunc(http.MethodGet+" "+path, handler, mw...)
+}
+
+func (a *App) Post(path string, handler http.HandlerFunc, mw ...MidHandler) {
+	a.HandleFunc(http.MethodPost+" "+path, handler, mw...)
+}
+
+func (a *App) Put(path string, handler http.HandlerFunc, mw ...MidHandler) {
+	a.HandleFunc(http.MethodPut+" "+path, handler, mw...)
+}
+
+func (a *App) Patch(path string, handler http.HandlerFunc, mw ...MidHandler) {
+	a.HandleFunc(http.MethodPatch+" "+path, handler, mw...)
+}
+
+func (a *App) Delete(path string, handler http.HandlerFunc, mw ...MidHandler) {
+	a.HandleFunc(http.MethodDelete+" "+path, handler, mw...)
 }
 
 func (a *App) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	a.mux.ServeHTTP(w, r)
+	v := &Values{Now: time.Now(), Logger: slog.Default()}
+	r = r.WithContext(setValues(r.Context(), v))
+	w = &responseWriter{ResponseWriter: w, v: v}
+
+	a.handler.ServeHTTP(w, r)
+}
+
+type Group struct {
+	app    *App
+	prefix string
+	mw     []MidHandler
+}
+
+// Use adds middleware to all routes registered on the group afterwards.
+func (g *Group) Use(mw ...MidHandler) {
+	g.mw = append(g.mw, mw...)
+}
+
+// Group returns a nested group. It inherits the prefix and middleware of g.
+func (g *Group) Group(prefix string, mw ...MidHandler) *Group {
+	groupMw := append(append([]MidHandler{}, g.mw...), mw...)
+	return &Group{app: g.app, prefix: g.prefix + prefix, mw: groupMw}
+}
+
+func (g *Group) Handle(pattern string, handler http.Handler, mw ...MidHandler) {
+	method, path, found := strings.Cut(pattern, " ")
+	if !found {
+		method, path = "", pattern
+	}
+
+	pattern = g.prefix + path
+	if method != "" {
+		pattern = method + " " + pattern
+	}
+
+	routeMw := append(append([]MidHandler{}, g.mw...), mw...)
+	g.app.Handle(pattern, handler, routeMw...)
+}
+
+func (g *Group) HandleFunc(pattern string, handler http.HandlerFunc, mw ...MidHandler) {
+	g.Handle(pattern, handler, mw...)
+}
+
+func (g *Group) Get(path string, handler http.HandlerFunc, mw ...MidHandler) {
+	g.HandleFunc(http.MethodGet+" "+path, handler, mw...)
+}
+
+func (g *Group) Post(path string, handler http.HandlerFunc, mw ...MidHandler) {
+	g.HandleFunc(http.MethodPost+" "+path, handler, mw...)
+}
+
+func (g *Group) Put(path string, handler http.HandlerFunc, mw ...MidHandler) {
+	g.HandleFunc(http.MethodPut+" "+path, handler, mw...)
+}
+
+func (g *Group) Patch(path string, handler http.HandlerFunc, mw ...MidHandler) {
+	g.HandleFunc(http.MethodPatch+" "+path, handler, mw...)
+}
+
+func (g *Group) Delete(path string, handler http.HandlerFunc, mw ...MidHandler) {
+	g.HandleFunc(http.MethodDelete+" "+path, handler, mw...)
 }
@@ -3,3 +3,13 @@ package web
 import "net/http"
 
 type MidHandler func(http.Handler) http.Handler
+
+// wrapMiddleware wraps the handler so that mw[0] is the outermost middleware.
+func wrapMiddleware(mw []MidHandler, handler http.Handler) http.Handler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		if mw[i] != nil {
+			handler = mw[i](handler)
+		}
+	}
+	return handler
+}
@@ -0,0 +1,117 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxBodyBytes is the maximum size of a request body read by Decode.
+const MaxBodyBytes = 2 << 20
+
+var (
+	ErrEmptyBody   = errors.New("request body is empty")
+	ErrInvalidUTF8 = errors.New("request body is not valid UTF-8")
+)
+
+type validator interface {
+	Validate() error
+}
+
+// Decode reads a single JSON value from the request body into val. Unknown
+// fields are rejected and the body is limited to MaxBodyBytes. If val has a
+// Validate method it is called after decoding.
+func Decode(w http.ResponseWriter, r *http.Request, val any) error {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodyBytes))
+	if err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		return fmt.Errorf("decode: %w", ErrEmptyBody)
+	}
+
+	if !utf8.Valid(body) {
+		return fmt.Errorf("decode: %w", ErrInvalidUTF8)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(val); err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+
+	if dec.More() {
+		return errors.New("decode: body must contain a single JSON value")
+	}
+
+	if v, ok := val.(validator); ok {
+		if err := v.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Negotiate returns the media type of offers the client prefers according to
+// the Accept header of the request. Ties and a missing header go to the
+// earlier offer; if the client accepts none of them, Negotiate returns "".
+func Negotiate(r *http.Request, offers ...string) string {
+	accept := r.Header.Get("Accept")
+	if accept == "" && len(offers) > 0 {
+		return offers[0]
+	}
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		if q := acceptQuality(accept, offer); q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// acceptQuality returns the q-value the Accept header gives mediaType, using
+// the most specific matching range.
+func acceptQuality(accept, mediaType string) float64 {
+	typ, _, _ := strings.Cut(mediaType, "/")
+
+	q, specificity := 0.0, -1
+	for _, part := range strings.Split(accept, ",") {
+		rng, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		var s int
+		switch rng {
+		case mediaType:
+			s = 2
+		case typ + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		default:
+			continue
+		}
+		if s <= specificity {
+			continue
+		}
+
+		specificity, q = s, 1
+		if v, ok := params["q"]; ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+	}
+	return q
+}
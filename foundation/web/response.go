@@ -0,0 +1,24 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Respond writes data as JSON with the given status code.
+func Respond(w http.ResponseWriter, status int, data any) error {
+	if status == http.StatusNoContent || data == nil {
+		w.WriteHeader(status)
+		return nil
+	}
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, err = w.Write(body)
+	return err
+}
@@ -0,0 +1,104 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+type ctxKey int
+
+const valuesKey ctxKey = iota
+
+// Values holds request-scoped state shared between the app, the middleware
+// and the handlers of a single request.
+type Values struct {
+	RequestID  string
+	Route      string
+	Now        time.Time
+	StatusCode int
+	Bytes      int
+	Logger     *slog.Logger
+}
+
+func setValues(ctx context.Context, v *Values) context.Context {
+	return context.WithValue(ctx, valuesKey, v)
+}
+
+// GetValues returns the values of the request or nil if the request is not
+// served by an App.
+func GetValues(ctx context.Context) *Values {
+	v, ok := ctx.Value(valuesKey).(*Values)
+	if !ok {
+		return nil
+	}
+	return v
+}
+
+// Logger returns the request-scoped logger, falling back to slog.Default.
+func Logger(ctx context.Context) *slog.Logger {
+	if v := GetValues(ctx); v != nil && v.Logger != nil {
+		return v.Logger
+	}
+	return slog.Default()
+}
+
+// SetLogger replaces the request-scoped logger.
+func SetLogger(ctx context.Context, log *slog.Logger) {
+	if v := GetValues(ctx); v != nil {
+		v.Logger = log
+	}
+}
+
+// AddLogAttrs adds attributes to all following log lines of the request.
+func AddLogAttrs(ctx context.Context, args ...any) {
+	SetLogger(ctx, Logger(ctx).With(args...))
+}
+
+// responseWriter records status code and written bytes in the request values.
+type responseWriter struct {
+	http.ResponseWriter
+	v *Values
+}
+
+func (rw *responseWriter) WriteHeader(statusCode int) {
+	if rw.v.StatusCode == 0 {
+		rw.v.StatusCode = statusCode
+	}
+	rw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	if rw.v.StatusCode == 0 {
+		rw.v.StatusCode = http.StatusOK
+	}
+	n, err := rw.ResponseWriter.Write(b)
+	rw.v.Bytes += n
+	return n, err
+}
+
+func (rw *responseWriter) Flush() {
+	if rw.v.StatusCode == 0 {
+		rw.v.StatusCode = http.StatusOK
+	}
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets handlers take over the connection, as WebSocket servers do.
+// The status is recorded as 101 Switching Protocols.
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, buf, err := http.NewResponseController(rw.ResponseWriter).Hijack()
+	if err == nil && rw.v.StatusCode == 0 {
+		rw.v.StatusCode = http.StatusSwitchingProtocols
+	}
+	return conn, buf, err
+}
+
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}